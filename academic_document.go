@@ -0,0 +1,1213 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	AcademicDocumentReportCard = "report_card"
+	AcademicDocumentTranscript = "transcript"
+)
+
+var academicDocumentKinds = []string{AcademicDocumentReportCard, AcademicDocumentTranscript}
+
+const (
+	AcademicDocumentDraft = "draft"
+	AcademicDocumentFinal = "final"
+)
+
+// The largest PDF a document may render to
+const maxAcademicDocumentSize = 10 << 20
+
+var errAcademicDocumentFinalized = errors.New("document is finalized and cannot be changed")
+
+var errAcademicDocumentSigningKeyMissing = errors.New("documents cannot be finalized until a document signing key is configured")
+
+// One course on a report card or transcript. Grades are nil until the section has graded work,
+// and transcripts leave them out for courses still in progress.
+type CourseRecord struct {
+	SectionID     string   `json:"sectionId"`
+	CourseID      string   `json:"courseId"`
+	CourseCode    string   `json:"courseCode"`
+	CourseTitle   string   `json:"courseTitle"`
+	Level         string   `json:"level"`
+	Credits       float64  `json:"credits"`
+	Status        string   `json:"status"`
+	Percent       *float64 `json:"percent"`
+	Letter        string   `json:"letter"`
+	GradePoints   *float64 `json:"gradePoints"`
+	CreditsEarned float64  `json:"creditsEarned"`
+	Comment       string   `json:"comment"`
+}
+
+type TermRecord struct {
+	TermID           string             `json:"termId"`
+	Name             string             `json:"name"`
+	StartDate        Date               `json:"startDate"`
+	EndDate          Date               `json:"endDate"`
+	Courses          []CourseRecord     `json:"courses"`
+	GPA              *float64           `json:"gpa"`
+	WeightedGPA      *float64           `json:"weightedGpa"`
+	CreditsAttempted float64            `json:"creditsAttempted"`
+	CreditsEarned    float64            `json:"creditsEarned"`
+	Attendance       *AttendanceSummary `json:"attendance"`
+}
+
+// The grades, credits and attendance a report card or transcript shows
+type AcademicRecord struct {
+	Kind             string       `json:"kind"`
+	SchoolID         string       `json:"schoolId"`
+	SchoolName       string       `json:"schoolName"`
+	StudentUserID    string       `json:"studentUserId"`
+	StudentName      string       `json:"studentName"`
+	Terms            []TermRecord `json:"terms"`
+	GPA              *float64     `json:"gpa"`
+	WeightedGPA      *float64     `json:"weightedGpa"`
+	CreditsAttempted float64      `json:"creditsAttempted"`
+	CreditsEarned    float64      `json:"creditsEarned"`
+	Comment          string       `json:"comment"`
+	CompiledAt       time.Time    `json:"compiledAt"`
+}
+
+// A report card or transcript. Drafts are compiled from the gradebook each time they are
+// read. Finalizing one compiles it for the last time, signs it and stores its PDF, after
+// which it can no longer be changed.
+type AcademicDocument struct {
+	ID            string `json:"id"`
+	SchoolID      string `json:"schoolId"`
+	StudentUserID string `json:"studentUserId"`
+	Kind          string `json:"kind"`
+	// The term a report card covers. Transcripts cover every term.
+	TermID string `json:"termId,omitempty"`
+	Status string `json:"status"`
+	// Numbers the finalized documents of the same kind, student and term from 1. Zero for drafts.
+	Version int    `json:"version"`
+	Comment string `json:"comment"`
+	// Teacher comments keyed by section id
+	CourseComments    map[string]string `json:"courseComments"`
+	Content           *AcademicRecord   `json:"content"`
+	PDFChecksum       string            `json:"pdfChecksum,omitempty"`
+	Signature         string            `json:"signature,omitempty"`
+	CreatedByUserID   string            `json:"createdByUserId"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	UpdatedAt         time.Time         `json:"updatedAt"`
+	FinalizedAt       *time.Time        `json:"finalizedAt,omitempty"`
+	FinalizedByUserID string            `json:"finalizedByUserId,omitempty"`
+	// Whether the signature still matches the content. Set when a finalized document is read.
+	Verified bool `json:"verified,omitempty"`
+}
+
+// Narrows an academic document listing. Empty fields match everything.
+type AcademicDocumentFilter struct {
+	SchoolID      string
+	StudentUserID string
+	Kind          string
+	TermID        string
+	Status        string
+}
+
+type AcademicDocumentPostgresStore struct {
+	db *PostgresDB
+}
+
+type AcademicDocumentStore interface {
+	Create(ctx context.Context, document *AcademicDocument) error
+	GetByID(ctx context.Context, id string) (*AcademicDocument, error)
+	List(ctx context.Context, filter *AcademicDocumentFilter) ([]*AcademicDocument, error)
+	// Saves a draft's comments. Fails with errAcademicDocumentFinalized once it is final.
+	Update(ctx context.Context, document *AcademicDocument) error
+	// Locks a draft with its content, version and signature. Fails with
+	// errAcademicDocumentFinalized if it was finalized already.
+	Finalize(ctx context.Context, document *AcademicDocument) error
+	// Deletes a draft. Finalized documents are never deleted.
+	Delete(ctx context.Context, id string) error
+}
+
+const academicDocumentColumns = `
+	id, school_id, student_user_id, kind, COALESCE(term_id::text, ''), status, version, comment, course_comments,
+	content, pdf_checksum, signature, COALESCE(created_by_user_id::text, ''), created_at, updated_at, finalized_at,
+	COALESCE(finalized_by_user_id::text, '')
+`
+
+func scanAcademicDocument(row rowScanner) (*AcademicDocument, error) {
+	var document AcademicDocument
+
+	err := row.Scan(
+		&document.ID,
+		&document.SchoolID,
+		&document.StudentUserID,
+		&document.Kind,
+		&document.TermID,
+		&document.Status,
+		&document.Version,
+		&document.Comment,
+		&document.CourseComments,
+		&document.Content,
+		&document.PDFChecksum,
+		&document.Signature,
+		&document.CreatedByUserID,
+		&document.CreatedAt,
+		&document.UpdatedAt,
+		&document.FinalizedAt,
+		&document.FinalizedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+func (s *AcademicDocumentPostgresStore) Create(ctx context.Context, document *AcademicDocument) error {
+	query := `
+		INSERT INTO academic_documents (school_id, student_user_id, kind, term_id, status, comment, course_comments, created_by_user_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, NULLIF($8, '')::uuid, $9, $10)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		document.SchoolID,
+		document.StudentUserID,
+		document.Kind,
+		document.TermID,
+		document.Status,
+		document.Comment,
+		document.CourseComments,
+		document.CreatedByUserID,
+		document.CreatedAt,
+		document.UpdatedAt,
+	)
+
+	return row.Scan(&document.ID)
+}
+
+func (s *AcademicDocumentPostgresStore) GetByID(ctx context.Context, id string) (*AcademicDocument, error) {
+	query := `SELECT ` + academicDocumentColumns + ` FROM academic_documents WHERE id = $1`
+
+	return noRowsAsNil(scanAcademicDocument(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *AcademicDocumentPostgresStore) List(ctx context.Context, filter *AcademicDocumentFilter) ([]*AcademicDocument, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("school_id = $%d", filter.SchoolID)
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("student_user_id = $%d", filter.StudentUserID)
+	}
+
+	if filter.Kind != "" {
+		addCondition("kind = $%d", filter.Kind)
+	}
+
+	if filter.TermID != "" {
+		addCondition("term_id = $%d", filter.TermID)
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := `SELECT ` + academicDocumentColumns + ` FROM academic_documents ` + where + ` ORDER BY created_at DESC, id`
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var documents []*AcademicDocument
+
+	for rows.Next() {
+		document, err := scanAcademicDocument(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents, rows.Err()
+}
+
+func (s *AcademicDocumentPostgresStore) Update(ctx context.Context, document *AcademicDocument) error {
+	query := `
+		UPDATE academic_documents
+		SET comment = $1, course_comments = $2, updated_at = $3
+		WHERE id = $4 AND status = 'draft'
+	`
+
+	tag, err := s.db.pool.Exec(ctx, query, document.Comment, document.CourseComments, document.UpdatedAt, document.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errAcademicDocumentFinalized
+	}
+
+	return nil
+}
+
+func (s *AcademicDocumentPostgresStore) Finalize(ctx context.Context, document *AcademicDocument) error {
+	query := `
+		UPDATE academic_documents
+		SET status = $1, version = $2, content = $3, pdf_checksum = $4, signature = $5,
+			finalized_at = $6, finalized_by_user_id = NULLIF($7, '')::uuid, updated_at = $6
+		WHERE id = $8 AND status = 'draft'
+	`
+
+	tag, err := s.db.pool.Exec(ctx, query,
+		document.Status,
+		document.Version,
+		document.Content,
+		document.PDFChecksum,
+		document.Signature,
+		document.FinalizedAt,
+		document.FinalizedByUserID,
+		document.ID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errAcademicDocumentFinalized
+	}
+
+	return nil
+}
+
+func (s *AcademicDocumentPostgresStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM academic_documents WHERE id = $1 AND status = 'draft'`, id)
+	return err
+}
+
+// Averages the graded courses under the school's GPA rules
+func courseRecordsGPA(rules *GPARules, courses []CourseRecord) GPA {
+	gpaCourses := make([]GPACourse, len(courses))
+
+	for i, course := range courses {
+		gpaCourses[i] = GPACourse{Level: course.Level, Credits: course.Credits, GradePoints: course.GradePoints}
+	}
+
+	return rules.Calculate(gpaCourses)
+}
+
+// Fills in the term's credit totals and GPA from its courses
+func (t *TermRecord) total(rules *GPARules) {
+	t.CreditsAttempted, t.CreditsEarned = 0, 0
+
+	for _, course := range t.Courses {
+		if course.Status == EnrollmentStatusCompleted || course.Status == EnrollmentStatusFailed {
+			t.CreditsAttempted += course.Credits
+		}
+
+		t.CreditsEarned += course.CreditsEarned
+	}
+
+	gpa := courseRecordsGPA(rules, t.Courses)
+	t.GPA, t.WeightedGPA = gpa.Unweighted, gpa.Weighted
+}
+
+// Returns the signature over the document's identity and content. The content is signed as
+// encoding/json writes it, which reading it back from the database does not change.
+func academicDocumentSignature(key []byte, document *AcademicDocument) (string, error) {
+	content, err := json.Marshal(document.Content)
+
+	if err != nil {
+		return "", err
+	}
+
+	var finalizedAt string
+
+	if document.FinalizedAt != nil {
+		finalizedAt = document.FinalizedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s\n%d\n%s\n", document.ID, document.Kind, document.SchoolID, document.StudentUserID, document.TermID, document.Version, finalizedAt)
+	mac.Write(content)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// PDFs are keyed by their checksum as well, so a request that loses a race to finalize the
+// same draft cannot overwrite the winner's PDF
+func academicDocumentPDFKey(id string, checksum string) string {
+	return "documents/" + id + "/" + checksum + ".pdf"
+}
+
+func formatCredits(credits float64) string {
+	return fmt.Sprintf("%.2f", credits)
+}
+
+func (d *AcademicDocument) title() string {
+	if d.Kind == AcademicDocumentTranscript {
+		return "Official Transcript"
+	}
+
+	if d.Content != nil && len(d.Content.Terms) > 0 {
+		return "Report Card - " + d.Content.Terms[0].Name
+	}
+
+	return "Report Card"
+}
+
+// Lays the document out as a PDF. Drafts say so on every page.
+func renderAcademicDocument(document *AcademicDocument) []byte {
+	record := document.Content
+	footer := record.SchoolName + " - " + document.title() + " - " + record.StudentName
+
+	if document.Status == AcademicDocumentDraft {
+		footer += " - DRAFT, NOT AN OFFICIAL DOCUMENT"
+	}
+
+	pdf := newPDFWriter(footer)
+
+	pdf.Line(16, true, record.SchoolName)
+	pdf.Line(13, true, document.title())
+	pdf.Space(6)
+	pdf.Line(10, false, "Student: "+record.StudentName)
+	pdf.Line(10, false, "Compiled: "+DateOf(record.CompiledAt).String())
+
+	for _, term := range record.Terms {
+		pdf.Space(12)
+		pdf.Line(12, true, fmt.Sprintf("%s (%s to %s)", term.Name, term.StartDate, term.EndDate))
+		pdf.Row(9, true, pdfCell{X: 0, Text: "Code"}, pdfCell{X: 70, Text: "Course"}, pdfCell{X: 290, Text: "Credits"}, pdfCell{X: 345, Text: "Grade"}, pdfCell{X: 430, Text: "Earned"})
+
+		for _, course := range term.Courses {
+			grade := "-"
+
+			switch {
+			case course.Percent != nil:
+				grade = fmt.Sprintf("%s (%.1f%%)", course.Letter, *course.Percent)
+			case course.Status == EnrollmentStatusEnrolled:
+				grade = "In progress"
+			}
+
+			pdf.Row(9, false, pdfCell{X: 0, Text: course.CourseCode}, pdfCell{X: 70, Text: course.CourseTitle}, pdfCell{X: 290, Text: formatCredits(course.Credits)}, pdfCell{X: 345, Text: grade}, pdfCell{X: 430, Text: formatCredits(course.CreditsEarned)})
+
+			if course.Comment != "" {
+				pdf.Line(8, false, "    "+course.CourseCode+": "+course.Comment)
+			}
+		}
+
+		summary := fmt.Sprintf("Credits attempted %s, earned %s", formatCredits(term.CreditsAttempted), formatCredits(term.CreditsEarned))
+
+		if term.GPA != nil {
+			summary = fmt.Sprintf("Term GPA %.2f, weighted %.2f. %s", *term.GPA, *term.WeightedGPA, summary)
+		}
+
+		pdf.Line(9, false, summary)
+
+		if attendance := term.Attendance; attendance != nil && attendance.Days > 0 {
+			pdf.Line(9, false, fmt.Sprintf("Attendance %.1f%% of %d days recorded: %d absent, %d excused, %d tardy", *attendance.Rate, attendance.Days, attendance.Absent, attendance.Excused, attendance.Tardy))
+		}
+	}
+
+	pdf.Space(12)
+
+	summary := fmt.Sprintf("Total credits attempted %s, earned %s", formatCredits(record.CreditsAttempted), formatCredits(record.CreditsEarned))
+
+	if record.GPA != nil {
+		summary = fmt.Sprintf("Cumulative GPA %.2f, weighted %.2f. %s", *record.GPA, *record.WeightedGPA, summary)
+	}
+
+	pdf.Line(10, true, summary)
+
+	if record.Comment != "" {
+		pdf.Space(6)
+		pdf.Line(10, false, record.Comment)
+	}
+
+	if document.Status == AcademicDocumentFinal {
+		pdf.Space(18)
+		pdf.Line(8, false, fmt.Sprintf("Document %s, version %d, finalized %s", document.ID, document.Version, document.FinalizedAt.UTC().Format(time.RFC3339)))
+		pdf.Line(8, false, "Signature "+document.Signature)
+	}
+
+	return pdf.Bytes()
+}
+
+// Collects the student's report cards and transcripts for their data export
+func academicDocumentDataExport(documentStore AcademicDocumentStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		documents, err := documentStore.List(ctx, &AcademicDocumentFilter{StudentUserID: userID})
+		return toAnySlice(documents), err
+	}
+}
+
+type AcademicDocumentService struct {
+	documentStore     AcademicDocumentStore
+	gradebookService  *GradebookService
+	gpaService        *GPAService
+	attendanceService *AttendanceService
+	enrollmentStore   EnrollmentStore
+	sectionStore      SectionStore
+	courseStore       CourseStore
+	calendarStore     CalendarStore
+	schoolStore       SchoolStore
+	userStore         UserStore
+	memberStore       OrganizationMemberStore
+	blobStore         BlobStore
+	signingKey        []byte
+	auditService      *AuditService
+}
+
+func NewAcademicDocumentService(documentStore AcademicDocumentStore, gradebookService *GradebookService, gpaService *GPAService, attendanceService *AttendanceService, enrollmentStore EnrollmentStore, sectionStore SectionStore, courseStore CourseStore, calendarStore CalendarStore, schoolStore SchoolStore, userStore UserStore, memberStore OrganizationMemberStore, blobStore BlobStore, signingKey []byte, auditService *AuditService) *AcademicDocumentService {
+	return &AcademicDocumentService{
+		documentStore:     documentStore,
+		gradebookService:  gradebookService,
+		gpaService:        gpaService,
+		attendanceService: attendanceService,
+		enrollmentStore:   enrollmentStore,
+		sectionStore:      sectionStore,
+		courseStore:       courseStore,
+		calendarStore:     calendarStore,
+		schoolStore:       schoolStore,
+		userStore:         userStore,
+		memberStore:       memberStore,
+		blobStore:         blobStore,
+		signingKey:        signingKey,
+		auditService:      auditService,
+	}
+}
+
+func (s *AcademicDocumentService) getTerm(ctx context.Context, id string) (*Term, error) {
+	term, err := s.calendarStore.GetTerm(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return nil, ErrInternal
+	}
+
+	return term, nil
+}
+
+// Compiles the student's record at the school: every term for a transcript, or for a report
+// card the courses of sections whose terms overlap the report card's term
+func (s *AcademicDocumentService) compile(ctx context.Context, document *AcademicDocument, school *School) (*AcademicRecord, error) {
+	student, err := s.userStore.GetByID(ctx, document.StudentUserID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if student == nil {
+		return nil, notFound("student")
+	}
+
+	var reportTerm *Term
+
+	if document.Kind == AcademicDocumentReportCard {
+		if reportTerm, err = s.getTerm(ctx, document.TermID); err != nil {
+			return nil, err
+		}
+
+		if reportTerm == nil {
+			return nil, notFound("term")
+		}
+	}
+
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{
+		StudentUserID: document.StudentUserID,
+		Statuses:      []string{EnrollmentStatusEnrolled, EnrollmentStatusCompleted, EnrollmentStatusFailed},
+	})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	terms := map[string]*TermRecord{}
+
+	for _, enrollment := range enrollments {
+		section, err := s.sectionStore.GetByID(ctx, enrollment.SectionID)
+
+		if err != nil {
+			slog.Error("failed to get section", "error", err)
+			return nil, ErrInternal
+		}
+
+		if section == nil || section.SchoolID != school.ID {
+			continue
+		}
+
+		term, err := s.getTerm(ctx, section.TermID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if term == nil {
+			continue
+		}
+
+		if reportTerm != nil {
+			if !datesOverlap(term.StartDate, term.EndDate, reportTerm.StartDate, reportTerm.EndDate) {
+				continue
+			}
+
+			term = reportTerm
+		}
+
+		course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+		if err != nil {
+			slog.Error("failed to get course", "error", err)
+			return nil, ErrInternal
+		}
+
+		if course == nil {
+			continue
+		}
+
+		record := CourseRecord{
+			SectionID:   section.ID,
+			CourseID:    course.ID,
+			CourseCode:  course.Code,
+			CourseTitle: course.Title,
+			Level:       course.Level,
+			Credits:     course.Credits,
+			Status:      enrollment.Status,
+			Comment:     document.CourseComments[section.ID],
+		}
+
+		if enrollment.Status == EnrollmentStatusCompleted {
+			record.CreditsEarned = course.Credits
+		}
+
+		// Transcripts show final grades only
+		if document.Kind == AcademicDocumentReportCard || enrollment.Status != EnrollmentStatusEnrolled {
+			gradebook, err := s.gradebookService.build(ctx, section, school, []string{document.StudentUserID}, document.StudentUserID)
+
+			if err != nil {
+				return nil, err
+			}
+
+			average := gradebook.Students[0].Average
+			record.Percent = average.Percent
+			record.Letter = average.Letter
+			record.GradePoints = average.GradePoints
+		}
+
+		if terms[term.ID] == nil {
+			terms[term.ID] = &TermRecord{TermID: term.ID, Name: term.Name, StartDate: term.StartDate, EndDate: term.EndDate, Courses: []CourseRecord{}}
+		}
+
+		terms[term.ID].Courses = append(terms[term.ID].Courses, record)
+	}
+
+	if reportTerm != nil && terms[reportTerm.ID] == nil {
+		terms[reportTerm.ID] = &TermRecord{TermID: reportTerm.ID, Name: reportTerm.Name, StartDate: reportTerm.StartDate, EndDate: reportTerm.EndDate, Courses: []CourseRecord{}}
+	}
+
+	record := &AcademicRecord{
+		Kind:          document.Kind,
+		SchoolID:      school.ID,
+		SchoolName:    school.Name,
+		StudentUserID: student.ID,
+		StudentName:   student.FirstName + " " + student.LastName,
+		Terms:         []TermRecord{},
+		Comment:       document.Comment,
+		CompiledAt:    time.Now().UTC(),
+	}
+
+	policy, err := s.gpaService.policy(ctx, school.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var allCourses []CourseRecord
+
+	for _, term := range terms {
+		slices.SortFunc(term.Courses, func(a, b CourseRecord) int {
+			return cmp.Or(cmp.Compare(a.CourseCode, b.CourseCode), cmp.Compare(a.SectionID, b.SectionID))
+		})
+
+		term.total(&policy.GPARules)
+
+		summaries, err := s.attendanceService.summaries(ctx, school.ID, document.StudentUserID, &AttendanceSummaryFilter{From: term.StartDate, To: term.EndDate})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(summaries) > 0 {
+			term.Attendance = summaries[0]
+		}
+
+		record.Terms = append(record.Terms, *term)
+		record.CreditsAttempted += term.CreditsAttempted
+		record.CreditsEarned += term.CreditsEarned
+		allCourses = append(allCourses, term.Courses...)
+	}
+
+	slices.SortFunc(record.Terms, func(a, b TermRecord) int {
+		return cmp.Or(a.StartDate.Compare(b.StartDate.Time), cmp.Compare(a.Name, b.Name))
+	})
+
+	gpa := courseRecordsGPA(&policy.GPARules, allCourses)
+	record.GPA, record.WeightedGPA = gpa.Unweighted, gpa.Weighted
+
+	return record, nil
+}
+
+// Returns the document, its school and the session user's membership if the session user
+// may read it. Staff of the school's organization may read any document, students their own
+// finalized ones.
+func (s *AcademicDocumentService) authorize(ctx context.Context, id string, roles ...string) (*AcademicDocument, *School, *OrganizationMember, error) {
+	document, err := s.documentStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get academic document", "error", err)
+		return nil, nil, nil, ErrInternal
+	}
+
+	if document == nil {
+		return nil, nil, nil, notFound("document")
+	}
+
+	school, err := s.schoolStore.GetByID(ctx, document.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to get school", "error", err)
+		return nil, nil, nil, ErrInternal
+	}
+
+	if school == nil {
+		return nil, nil, nil, notFound("document")
+	}
+
+	member, err := requireMembership(ctx, s.memberStore, school.OrganizationID)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if member.Role == RoleStudent && (member.UserID != document.StudentUserID || document.Status != AcademicDocumentFinal) {
+		return nil, nil, nil, notFound("document")
+	}
+
+	if len(roles) > 0 && !slices.Contains(roles, member.Role) {
+		return nil, nil, nil, ErrForbidden
+	}
+
+	return document, school, member, nil
+}
+
+// Compiles a draft's content, or checks a finalized document's signature
+func (s *AcademicDocumentService) prepare(ctx context.Context, document *AcademicDocument, school *School) error {
+	if document.Status == AcademicDocumentFinal {
+		// Without the key nothing can be verified, and an empty key must not verify anything
+		if len(s.signingKey) == 0 {
+			return nil
+		}
+
+		signature, err := academicDocumentSignature(s.signingKey, document)
+
+		if err != nil {
+			slog.Error("failed to sign academic document", "error", err)
+			return ErrInternal
+		}
+
+		document.Verified = hmac.Equal([]byte(signature), []byte(document.Signature))
+
+		return nil
+	}
+
+	content, err := s.compile(ctx, document, school)
+
+	if err != nil {
+		return err
+	}
+
+	document.Content = content
+
+	return nil
+}
+
+// Starts a draft report card or transcript for a student of the school
+func (s *AcademicDocumentService) Create(ctx context.Context, document *AcademicDocument) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, document.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(academicDocumentKinds, document.Kind) {
+		return fmt.Errorf("kind must be one of %v", academicDocumentKinds)
+	}
+
+	switch {
+	case document.Kind == AcademicDocumentReportCard && document.TermID == "":
+		return errors.New("a report card needs a term")
+	case document.Kind == AcademicDocumentTranscript && document.TermID != "":
+		return errors.New("a transcript covers every term and cannot have one")
+	}
+
+	if document.TermID != "" {
+		term, err := s.getTerm(ctx, document.TermID)
+
+		if err != nil {
+			return err
+		}
+
+		if term == nil || term.SchoolID != school.ID {
+			return notFound("term")
+		}
+	}
+
+	student, err := s.memberStore.Get(ctx, school.OrganizationID, document.StudentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if student == nil || student.Role != RoleStudent {
+		return errors.New("student must be a student of the organization")
+	}
+
+	drafts, err := s.documentStore.List(ctx, &AcademicDocumentFilter{
+		SchoolID:      school.ID,
+		StudentUserID: document.StudentUserID,
+		Kind:          document.Kind,
+		TermID:        document.TermID,
+		Status:        AcademicDocumentDraft,
+	})
+
+	if err != nil {
+		slog.Error("failed to list academic documents", "error", err)
+		return ErrInternal
+	}
+
+	if len(drafts) > 0 {
+		return fmt.Errorf("the student already has a draft %s, %s", strings.ReplaceAll(document.Kind, "_", " "), drafts[0].ID)
+	}
+
+	session, _ := SessionFromContext(ctx)
+	now := time.Now()
+
+	document.Status = AcademicDocumentDraft
+	document.Version = 0
+	document.Content = nil
+	document.CreatedByUserID = session.UserID
+	document.CreatedAt = now
+	document.UpdatedAt = now
+
+	if document.CourseComments == nil {
+		document.CourseComments = map[string]string{}
+	}
+
+	if err := s.documentStore.Create(ctx, document); err != nil {
+		slog.Error("failed to create academic document", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "academic_document", document.ID, nil, document)
+
+	return s.prepare(ctx, document, school)
+}
+
+func (s *AcademicDocumentService) GetByID(ctx context.Context, id string) (*AcademicDocument, error) {
+	document, school, _, err := s.authorize(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.prepare(ctx, document, school); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// Lists a school's documents, newest first. Students may list their own finalized documents.
+// Drafts are listed without content.
+func (s *AcademicDocumentService) List(ctx context.Context, schoolID string, studentUserID string) ([]*AcademicDocument, error) {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &AcademicDocumentFilter{SchoolID: school.ID, StudentUserID: studentUserID}
+	member, err := requireMembership(ctx, s.memberStore, school.OrganizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if member.Role == RoleStudent {
+		if studentUserID != member.UserID {
+			return nil, ErrForbidden
+		}
+
+		filter.Status = AcademicDocumentFinal
+	}
+
+	documents, err := s.documentStore.List(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list academic documents", "error", err)
+		return nil, ErrInternal
+	}
+
+	if documents == nil {
+		documents = []*AcademicDocument{}
+	}
+
+	return documents, nil
+}
+
+type UpdateAcademicDocumentRequest struct {
+	Comment *string `json:"comment"`
+	// Comments to set, keyed by section id. An empty comment removes one.
+	CourseComments map[string]string `json:"courseComments"`
+}
+
+// Edits a draft's comments. Admins may edit any comment, teachers the comments on courses
+// they teach.
+func (s *AcademicDocumentService) Update(ctx context.Context, id string, request *UpdateAcademicDocumentRequest) (*AcademicDocument, error) {
+	document, school, member, err := s.authorize(ctx, id, RoleAdmin, RoleTeacher)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Status != AcademicDocumentDraft {
+		return nil, errAcademicDocumentFinalized
+	}
+
+	before := *document
+	before.CourseComments = maps.Clone(document.CourseComments)
+
+	if request.Comment != nil {
+		if member.Role != RoleAdmin {
+			return nil, forbidden("only admins may edit the general comment")
+		}
+
+		document.Comment = *request.Comment
+	}
+
+	for sectionID, comment := range request.CourseComments {
+		section, _, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if section.SchoolID != school.ID {
+			return nil, notFound("section")
+		}
+
+		if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+			return nil, err
+		}
+
+		if comment == "" {
+			delete(document.CourseComments, sectionID)
+		} else {
+			document.CourseComments[sectionID] = comment
+		}
+	}
+
+	document.UpdatedAt = time.Now()
+
+	if err := s.documentStore.Update(ctx, document); err != nil {
+		if errors.Is(err, errAcademicDocumentFinalized) {
+			return nil, err
+		}
+
+		slog.Error("failed to update academic document", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "academic_document", id, &before, document)
+
+	if err := s.prepare(ctx, document, school); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+const AuditActionFinalize = "finalize"
+
+// Compiles a draft for the last time, numbers and signs it and stores its PDF. The document
+// cannot be changed afterwards. Corrections take a new draft, which becomes the next version.
+func (s *AcademicDocumentService) Finalize(ctx context.Context, id string) (*AcademicDocument, error) {
+	document, school, _, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Status != AcademicDocumentDraft {
+		return nil, errAcademicDocumentFinalized
+	}
+
+	if len(s.signingKey) == 0 {
+		return nil, errAcademicDocumentSigningKeyMissing
+	}
+
+	content, err := s.compile(ctx, document, school)
+
+	if err != nil {
+		return nil, err
+	}
+
+	finals, err := s.documentStore.List(ctx, &AcademicDocumentFilter{
+		SchoolID:      school.ID,
+		StudentUserID: document.StudentUserID,
+		Kind:          document.Kind,
+		TermID:        document.TermID,
+		Status:        AcademicDocumentFinal,
+	})
+
+	if err != nil {
+		slog.Error("failed to list academic documents", "error", err)
+		return nil, ErrInternal
+	}
+
+	version := 1
+
+	for _, final := range finals {
+		version = max(version, final.Version+1)
+	}
+
+	session, _ := SessionFromContext(ctx)
+	// Kept to the precision the database stores so the signature still matches when read back
+	finalizedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	document.Status = AcademicDocumentFinal
+	document.Version = version
+	document.Content = content
+	document.FinalizedAt = &finalizedAt
+	document.FinalizedByUserID = session.UserID
+
+	if document.Signature, err = academicDocumentSignature(s.signingKey, document); err != nil {
+		slog.Error("failed to sign academic document", "error", err)
+		return nil, ErrInternal
+	}
+
+	data := renderAcademicDocument(document)
+	document.PDFChecksum = sha256Hex(string(data))
+	key := academicDocumentPDFKey(document.ID, document.PDFChecksum)
+
+	if _, err := putBlob(ctx, s.blobStore, key, bytes.NewReader(data), "application/pdf", maxAcademicDocumentSize); err != nil {
+		slog.Error("failed to store academic document pdf", "error", err)
+		return nil, ErrInternal
+	}
+
+	if err := s.documentStore.Finalize(ctx, document); err != nil {
+		if !errors.Is(err, errAcademicDocumentFinalized) {
+			slog.Error("failed to finalize academic document", "error", err)
+			err = ErrInternal
+		}
+
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			slog.Error("failed to delete academic document pdf", "error", err)
+		}
+
+		return nil, err
+	}
+
+	document.Verified = true
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionFinalize, "academic_document", id, nil, document)
+
+	return document, nil
+}
+
+// Deletes a draft. Finalized documents are kept for good.
+func (s *AcademicDocumentService) Delete(ctx context.Context, id string) error {
+	document, school, _, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if document.Status != AcademicDocumentDraft {
+		return errAcademicDocumentFinalized
+	}
+
+	if err := s.documentStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete academic document", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "academic_document", id, document, nil)
+
+	return nil
+}
+
+// Returns the document as a PDF. Finalized documents are served as they were stored, drafts
+// are rendered afresh.
+func (s *AcademicDocumentService) PDF(ctx context.Context, id string) (io.ReadCloser, *BlobMetadata, error) {
+	document, school, _, err := s.authorize(ctx, id)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if document.Status == AcademicDocumentFinal {
+		body, meta, err := s.blobStore.Get(ctx, academicDocumentPDFKey(id, document.PDFChecksum))
+
+		if err != nil {
+			slog.Error("failed to get academic document pdf", "error", err)
+			return nil, nil, ErrInternal
+		}
+
+		if body == nil {
+			return nil, nil, notFound("document pdf")
+		}
+
+		return body, meta, nil
+	}
+
+	if err := s.prepare(ctx, document, school); err != nil {
+		return nil, nil, err
+	}
+
+	data := renderAcademicDocument(document)
+
+	return io.NopCloser(bytes.NewReader(data)), &BlobMetadata{Size: int64(len(data)), ContentType: "application/pdf"}, nil
+}
+
+type AcademicDocumentHandler struct {
+	documentService *AcademicDocumentService
+}
+
+func (h *AcademicDocumentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var document AcademicDocument
+
+	if err := decodeJSON(r, &document); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	document.SchoolID = r.PathValue("id")
+
+	if err := h.documentService.Create(r.Context(), &document); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, document)
+}
+
+func (h *AcademicDocumentHandler) List(w http.ResponseWriter, r *http.Request) {
+	documents, err := h.documentService.List(r.Context(), r.PathValue("id"), r.URL.Query().Get("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, documents)
+}
+
+func (h *AcademicDocumentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	document, err := h.documentService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, document)
+}
+
+func (h *AcademicDocumentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateAcademicDocumentRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	document, err := h.documentService.Update(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, document)
+}
+
+func (h *AcademicDocumentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.documentService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AcademicDocumentHandler) Finalize(w http.ResponseWriter, r *http.Request) {
+	document, err := h.documentService.Finalize(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, document)
+}
+
+func (h *AcademicDocumentHandler) PDF(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	body, meta, err := h.documentService.PDF(r.Context(), id)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	defer body.Close()
+
+	serveBlob(w, body, meta, id+".pdf")
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAcademicDocumentStore struct {
+	CreateFunc   func(ctx context.Context, document *AcademicDocument) error
+	GetByIDFunc  func(ctx context.Context, id string) (*AcademicDocument, error)
+	ListFunc     func(ctx context.Context, filter *AcademicDocumentFilter) ([]*AcademicDocument, error)
+	UpdateFunc   func(ctx context.Context, document *AcademicDocument) error
+	FinalizeFunc func(ctx context.Context, document *AcademicDocument) error
+	DeleteFunc   func(ctx context.Context, id string) error
+}
+
+func (m *MockAcademicDocumentStore) Create(ctx context.Context, document *AcademicDocument) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, document)
+	}
+
+	return nil
+}
+
+func (m *MockAcademicDocumentStore) GetByID(ctx context.Context, id string) (*AcademicDocument, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockAcademicDocumentStore) List(ctx context.Context, filter *AcademicDocumentFilter) ([]*AcademicDocument, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockAcademicDocumentStore) Update(ctx context.Context, document *AcademicDocument) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, document)
+	}
+
+	return nil
+}
+
+func (m *MockAcademicDocumentStore) Finalize(ctx context.Context, document *AcademicDocument) error {
+	if m.FinalizeFunc != nil {
+		return m.FinalizeFunc(ctx, document)
+	}
+
+	return nil
+}
+
+func (m *MockAcademicDocumentStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// A student who completed Geometry with a 95 last fall and is taking Algebra 2 Honors, at 85
+// so far, this fall. Both courses are worth one credit. The student also took a course at
+// another school, which never shows up.
+func academicHistory() (*MockEnrollmentStore, *MockSectionStore, *MockCourseStore, *MockCalendarStore, *MockGradebookStore) {
+	enrollmentStore := &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			enrollments := []*Enrollment{
+				{ID: "1", SectionID: "geo-01", StudentUserID: filter.StudentUserID, Status: EnrollmentStatusCompleted},
+				{ID: "2", SectionID: "alg2-01", StudentUserID: filter.StudentUserID, Status: EnrollmentStatusEnrolled},
+				{ID: "3", SectionID: "elsewhere", StudentUserID: filter.StudentUserID, Status: EnrollmentStatusCompleted},
+			}
+
+			if filter.SectionID != "" {
+				enrollments = slices.DeleteFunc(enrollments, func(enrollment *Enrollment) bool {
+					return enrollment.SectionID != filter.SectionID
+				})
+			}
+
+			return enrollments, nil
+		},
+	}
+
+	sections := map[string]*Section{
+		"geo-01":    {ID: "geo-01", CourseID: "geo", SchoolID: "school", TermID: "last-fall", Teachers: []SectionTeacher{{UserID: "other-teacher", Role: SectionTeacherPrimary}}},
+		"alg2-01":   {ID: "alg2-01", CourseID: "alg2", SchoolID: "school", TermID: "fall", Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}}},
+		"elsewhere": {ID: "elsewhere", CourseID: "alg1", SchoolID: "other-school", TermID: "fall"},
+	}
+
+	sectionStore := &MockSectionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Section, error) {
+			return sections[id], nil
+		},
+	}
+
+	courses := map[string]*Course{
+		"alg1": {ID: "alg1", OrganizationID: "org", Code: "MATH101", Title: "Algebra 1", Subject: "Mathematics", Credits: 1, Level: CourseLevelStandard},
+		"geo":  {ID: "geo", OrganizationID: "org", Code: "MATH201", Title: "Geometry", Subject: "Mathematics", Credits: 1, Level: CourseLevelStandard},
+		"alg2": {ID: "alg2", OrganizationID: "org", Code: "MATH301", Title: "Algebra 2 Honors", Subject: "Mathematics", Credits: 1, Level: CourseLevelHonors},
+	}
+
+	courseStore := &MockCourseStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Course, error) {
+			return courses[id], nil
+		},
+	}
+
+	terms := map[string]*Term{
+		"last-fall": {ID: "last-fall", SchoolID: "school", Name: "Fall 2024", Type: TermTypeSemester, StartDate: NewDate(2024, time.August, 26), EndDate: NewDate(2025, time.January, 17)},
+		"fall":      {ID: "fall", SchoolID: "school", Name: "Fall 2025", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)},
+		"q1":        {ID: "q1", SchoolID: "school", Name: "Quarter 1", Type: TermTypeQuarter, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2025, time.October, 24)},
+	}
+
+	calendarStore := &MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return terms[id], nil
+		},
+	}
+
+	gradebookStore := &MockGradebookStore{
+		ListCategoriesFunc: func(ctx context.Context, sectionID string) ([]*GradeCategory, error) {
+			return []*GradeCategory{{ID: "tests", SectionID: sectionID, Name: "Tests", Weight: 100}}, nil
+		},
+		ListAssignmentsFunc: func(ctx context.Context, sectionID string) ([]*Assignment, error) {
+			return []*Assignment{{ID: sectionID + "-final", SectionID: sectionID, CategoryID: "tests", PointsPossible: 100}}, nil
+		},
+		ListGradesFunc: func(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error) {
+			score := map[string]float64{"geo-01": 95, "alg2-01": 85}[sectionID]
+			return []*Grade{{AssignmentID: sectionID + "-final", StudentUserID: studentUserID, Points: points(score)}}, nil
+		},
+	}
+
+	return enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore
+}
+
+// orgMembers with other-teacher added as a teacher of org
+func membersWithOtherTeacher() *MockOrganizationMemberStore {
+	return &MockOrganizationMemberStore{
+		GetFunc: func(ctx context.Context, organizationID string, userID string) (*OrganizationMember, error) {
+			if userID == "other-teacher" {
+				return &OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: RoleTeacher}, nil
+			}
+
+			return orgMembers().Get(ctx, organizationID, userID)
+		},
+	}
+}
+
+// Nine days present and one absent for reports covering 2025, and nothing otherwise
+func priorYearAttendance() *MockAttendanceStore {
+	return &MockAttendanceStore{
+		ListRecordsFunc: func(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error) {
+			if filter.From.Year() == 2025 {
+				return attendanceDays(filter.StudentUserID, 9, 1), nil
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+func johnDoe() *MockUserStore {
+	return &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: id, FirstName: "John", LastName: "Doe"}, nil
+		},
+	}
+}
+
+// A store holding a single document, which Update and Finalize write back to
+func storedDocument(document *AcademicDocument) *MockAcademicDocumentStore {
+	return &MockAcademicDocumentStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*AcademicDocument, error) {
+			copied := *document
+			return &copied, nil
+		},
+		UpdateFunc: func(ctx context.Context, updated *AcademicDocument) error {
+			*document = *updated
+			return nil
+		},
+		FinalizeFunc: func(ctx context.Context, finalized *AcademicDocument) error {
+			*document = *finalized
+			return nil
+		},
+	}
+}
+
+func draftReportCard() *AcademicDocument {
+	return &AcademicDocument{ID: "1", SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentReportCard, TermID: "q1", Status: AcademicDocumentDraft, CourseComments: map[string]string{}}
+}
+
+func TestAcademicDocumentService_Create_CompilesReportCardForTerm(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(&MockAcademicDocumentStore{}, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	document := &AcademicDocument{SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentReportCard, TermID: "q1", CourseComments: map[string]string{"alg2-01": "Works hard"}}
+	err := documentService.Create(sessionContext("admin"), document)
+
+	assert.NoError(t, err)
+	assert.Equal(t, AcademicDocumentDraft, document.Status)
+
+	record := document.Content
+	assert.Equal(t, "John Doe", record.StudentName)
+	assert.Len(t, record.Terms, 1)
+
+	// The quarter shows the semester course it overlaps, with its grade so far
+	term := record.Terms[0]
+	assert.Equal(t, "Quarter 1", term.Name)
+	assert.Len(t, term.Courses, 1)
+	assert.Equal(t, "MATH301", term.Courses[0].CourseCode)
+	assert.Equal(t, "B", term.Courses[0].Letter)
+	assert.Equal(t, "Works hard", term.Courses[0].Comment)
+	assert.Equal(t, 3.0, *term.GPA)
+	assert.Equal(t, 3.5, *term.WeightedGPA)
+	assert.Equal(t, 0.0, term.CreditsEarned)
+	assert.Equal(t, 90.0, *term.Attendance.Rate)
+}
+
+func TestAcademicDocumentService_Create_CompilesTranscriptWithFinalGradesOnly(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(&MockAcademicDocumentStore{}, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	document := &AcademicDocument{SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentTranscript}
+	err := documentService.Create(sessionContext("admin"), document)
+
+	assert.NoError(t, err)
+
+	record := document.Content
+	assert.Len(t, record.Terms, 2)
+	assert.Equal(t, "Fall 2024", record.Terms[0].Name)
+	assert.Equal(t, "A", record.Terms[0].Courses[0].Letter)
+	assert.Equal(t, "Fall 2025", record.Terms[1].Name)
+	assert.Nil(t, record.Terms[1].Courses[0].Percent)
+	assert.Equal(t, 4.0, *record.GPA)
+	assert.Equal(t, 1.0, record.CreditsAttempted)
+	assert.Equal(t, 1.0, record.CreditsEarned)
+}
+
+func TestAcademicDocumentService_Create_ReturnsNotFoundForDeletedStudent(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(&MockAcademicDocumentStore{}, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+	documentService.userStore = &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return nil, sql.ErrNoRows
+		},
+	}
+
+	err := documentService.Create(sessionContext("admin"), &AcademicDocument{SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentTranscript})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAcademicDocumentService_Create_ReturnsErrorForReportCardWithoutTerm(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(&MockAcademicDocumentStore{}, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	err := documentService.Create(sessionContext("admin"), &AcademicDocument{SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentReportCard})
+
+	assert.Error(t, err)
+	assert.Equal(t, "a report card needs a term", err.Error())
+}
+
+func TestAcademicDocumentService_Create_ReturnsErrorWhenDraftExists(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(&MockAcademicDocumentStore{
+		ListFunc: func(ctx context.Context, filter *AcademicDocumentFilter) ([]*AcademicDocument, error) {
+			return []*AcademicDocument{{ID: "1", Status: AcademicDocumentDraft}}, nil
+		},
+	}, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	err := documentService.Create(sessionContext("admin"), &AcademicDocument{SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentTranscript})
+
+	assert.Error(t, err)
+	assert.Equal(t, "the student already has a draft transcript, 1", err.Error())
+}
+
+func TestAcademicDocumentService_Create_ReturnsErrorForTeacher(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(&MockAcademicDocumentStore{}, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	err := documentService.Create(sessionContext("teacher"), &AcademicDocument{SchoolID: "school", StudentUserID: "student", Kind: AcademicDocumentTranscript})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAcademicDocumentService_Update_AllowsTeacherToCommentOnOwnCourse(t *testing.T) {
+	document := draftReportCard()
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(document), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	updated, err := documentService.Update(sessionContext("teacher"), "1", &UpdateAcademicDocumentRequest{CourseComments: map[string]string{"alg2-01": "Great progress"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Great progress", updated.Content.Terms[0].Courses[0].Comment)
+	assert.Equal(t, "Great progress", document.CourseComments["alg2-01"])
+}
+
+func TestAcademicDocumentService_Update_ReturnsErrorForCourseTaughtByOthers(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(draftReportCard()), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	_, err := documentService.Update(sessionContext("teacher"), "1", &UpdateAcademicDocumentRequest{CourseComments: map[string]string{"geo-01": "Well done"}})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAcademicDocumentService_Update_ReturnsErrorForFinalizedDocument(t *testing.T) {
+	document := draftReportCard()
+	document.Status = AcademicDocumentFinal
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(document), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	comment := "Too late"
+	_, err := documentService.Update(sessionContext("admin"), "1", &UpdateAcademicDocumentRequest{Comment: &comment})
+
+	assert.ErrorIs(t, err, errAcademicDocumentFinalized)
+}
+
+func TestAcademicDocumentService_Finalize_ReturnsErrorWithoutSigningKey(t *testing.T) {
+	document := draftReportCard()
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(document), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+	documentService.signingKey = nil
+
+	finalized, err := documentService.Finalize(sessionContext("admin"), "1")
+
+	assert.ErrorIs(t, err, errAcademicDocumentSigningKeyMissing)
+	assert.Nil(t, finalized)
+	assert.Equal(t, AcademicDocumentDraft, document.Status)
+}
+
+func TestAcademicDocumentService_Finalize_SignsAndStoresNextVersion(t *testing.T) {
+	document := draftReportCard()
+	store := storedDocument(document)
+	store.ListFunc = func(ctx context.Context, filter *AcademicDocumentFilter) ([]*AcademicDocument, error) {
+		assert.Equal(t, AcademicDocumentFinal, filter.Status)
+		return []*AcademicDocument{{ID: "0", Status: AcademicDocumentFinal, Version: 1}}, nil
+	}
+
+	blobs := &MockBlobStore{}
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(store, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, blobs, testSigningKey, auditService)
+
+	finalized, err := documentService.Finalize(sessionContext("admin"), "1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, AcademicDocumentFinal, finalized.Status)
+	assert.Equal(t, 2, finalized.Version)
+	assert.True(t, finalized.Verified)
+	assert.NotEmpty(t, document.Signature)
+
+	body, meta, err := documentService.PDF(sessionContext("student"), "1")
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", meta.ContentType)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4")))
+	assert.Contains(t, string(data), document.Signature)
+
+	read, err := documentService.GetByID(sessionContext("student"), "1")
+	assert.NoError(t, err)
+	assert.True(t, read.Verified)
+}
+
+func TestAcademicDocumentService_GetByID_DetectsTamperedContent(t *testing.T) {
+	document := draftReportCard()
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(document), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	_, err := documentService.Finalize(sessionContext("admin"), "1")
+	assert.NoError(t, err)
+
+	// Round trip the content as the database would, then change a grade
+	data, _ := json.Marshal(document.Content)
+	document.Content = nil
+	assert.NoError(t, json.Unmarshal(data, &document.Content))
+
+	read, err := documentService.GetByID(sessionContext("admin"), "1")
+	assert.NoError(t, err)
+	assert.True(t, read.Verified)
+
+	document.Content.Terms[0].Courses[0].Letter = "A"
+
+	read, err = documentService.GetByID(sessionContext("admin"), "1")
+	assert.NoError(t, err)
+	assert.False(t, read.Verified)
+}
+
+func TestAcademicDocumentService_GetByID_HidesDraftFromStudent(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(draftReportCard()), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	_, err := documentService.GetByID(sessionContext("student"), "1")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAcademicDocumentService_Delete_ReturnsErrorForFinalizedDocument(t *testing.T) {
+	document := draftReportCard()
+	document.Status = AcademicDocumentFinal
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	members := membersWithOtherTeacher()
+	auditService := NewAuditService(&MockAuditStore{}, members)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, &MockGPAStore{}, existingSchool(), members, auditService)
+	attendanceService := NewAttendanceService(priorYearAttendance(), sectionStore, enrollmentStore, calendarStore, &MockBellStore{}, existingSchool(), members, auditService)
+	gpaService := NewGPAService(&MockGPAStore{}, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), members, auditService)
+	documentService := NewAcademicDocumentService(storedDocument(document), gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), johnDoe(), members, &MockBlobStore{}, testSigningKey, auditService)
+
+	err := documentService.Delete(sessionContext("admin"), "1")
+
+	assert.ErrorIs(t, err, errAcademicDocumentFinalized)
+}
@@ -0,0 +1,1061 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How a code counts toward attendance rates. Tardy students attended, excused ones did not.
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceTardy   = "tardy"
+	AttendanceExcused = "excused"
+)
+
+var attendanceTypes = []string{AttendancePresent, AttendanceAbsent, AttendanceTardy, AttendanceExcused}
+
+const (
+	// Students missing at least this percent of the days recorded are chronically absent
+	defaultChronicAbsenceThreshold = 10
+	// Students with fewer days recorded are left out of the chronic absenteeism list
+	chronicAbsenceMinDays = 10
+	// The most students one request may take attendance for
+	maxAttendanceRecords = 1000
+)
+
+// A code a school marks attendance with, such as "P" or "AU" for an unexcused absence
+type AttendanceCode struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type AttendanceCodes struct {
+	SchoolID  string           `json:"schoolId"`
+	Codes     []AttendanceCode `json:"codes"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// Used by schools that have not configured codes of their own
+var defaultAttendanceCodes = []AttendanceCode{
+	{Code: "P", Name: "Present", Type: AttendancePresent},
+	{Code: "A", Name: "Absent", Type: AttendanceAbsent},
+	{Code: "T", Name: "Tardy", Type: AttendanceTardy},
+	{Code: "E", Name: "Excused", Type: AttendanceExcused},
+}
+
+// Returns the code matching code, ignoring case, or nil if there is none
+func (c *AttendanceCodes) Find(code string) *AttendanceCode {
+	for i := range c.Codes {
+		if strings.EqualFold(c.Codes[i].Code, code) {
+			return &c.Codes[i]
+		}
+	}
+
+	return nil
+}
+
+// A student's attendance on a day, or at a section's meeting that day
+type AttendanceRecord struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"schoolId"`
+	// Empty for daily attendance
+	SectionID     string `json:"sectionId,omitempty"`
+	StudentUserID string `json:"studentUserId"`
+	Date          Date   `json:"date"`
+	Code          string `json:"code"`
+	// The type of the code when it was recorded
+	Type             string    `json:"type"`
+	Comment          string    `json:"comment"`
+	RecordedByUserID string    `json:"recordedByUserId"`
+	RecordedAt       time.Time `json:"recordedAt"`
+}
+
+// Narrows an attendance listing. Empty fields other than SectionID match everything.
+type AttendanceFilter struct {
+	SchoolID string
+	// Lists the section's attendance, or daily attendance when empty
+	SectionID string
+	// Lists daily attendance and attendance in every section, ignoring SectionID
+	AllSections   bool
+	StudentUserID string
+	From          Date
+	To            Date
+}
+
+type AttendancePostgresStore struct {
+	db *PostgresDB
+}
+
+type AttendanceStore interface {
+	GetCodes(ctx context.Context, schoolID string) (*AttendanceCodes, error)
+	SaveCodes(ctx context.Context, codes *AttendanceCodes) error
+	// Saves the records, replacing those already kept for the same students, dates and sections
+	SaveRecords(ctx context.Context, records []*AttendanceRecord) error
+	ListRecords(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error)
+}
+
+func (s *AttendancePostgresStore) GetCodes(ctx context.Context, schoolID string) (*AttendanceCodes, error) {
+	query := `
+		SELECT school_id, codes, updated_at
+		FROM attendance_codes
+		WHERE school_id = $1
+	`
+
+	var codes AttendanceCodes
+
+	err := s.db.pool.QueryRow(ctx, query, schoolID).Scan(&codes.SchoolID, &codes.Codes, &codes.UpdatedAt)
+
+	return noRowsAsNil(&codes, err)
+}
+
+func (s *AttendancePostgresStore) SaveCodes(ctx context.Context, codes *AttendanceCodes) error {
+	query := `
+		INSERT INTO attendance_codes (school_id, codes, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (school_id) DO UPDATE SET codes = excluded.codes, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, codes.SchoolID, codes.Codes, codes.UpdatedAt)
+
+	return err
+}
+
+func (s *AttendancePostgresStore) SaveRecords(ctx context.Context, records []*AttendanceRecord) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO attendance_records (school_id, section_id, student_user_id, date, code, type, comment, recorded_by_user_id, recorded_at)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, NULLIF($8, '')::uuid, $9)
+		ON CONFLICT ON CONSTRAINT attendance_records_student_date_section_key DO UPDATE
+		SET code = excluded.code, type = excluded.type, comment = excluded.comment,
+			recorded_by_user_id = excluded.recorded_by_user_id, recorded_at = excluded.recorded_at
+		RETURNING id
+	`
+
+	for _, record := range records {
+		err := tx.QueryRow(ctx, query,
+			record.SchoolID,
+			record.SectionID,
+			record.StudentUserID,
+			record.Date,
+			record.Code,
+			record.Type,
+			record.Comment,
+			record.RecordedByUserID,
+			record.RecordedAt,
+		).Scan(&record.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *AttendancePostgresStore) ListRecords(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("school_id = $%d", filter.SchoolID)
+	}
+
+	if filter.SectionID != "" && !filter.AllSections {
+		addCondition("section_id = $%d", filter.SectionID)
+	} else if !filter.AllSections {
+		conditions = append(conditions, "section_id IS NULL")
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("student_user_id = $%d", filter.StudentUserID)
+	}
+
+	if !filter.From.IsZero() {
+		addCondition("date >= $%d", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		addCondition("date <= $%d", filter.To)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := `
+		SELECT id, school_id, COALESCE(section_id::text, ''), student_user_id, date, code, type, comment,
+			COALESCE(recorded_by_user_id::text, ''), recorded_at
+		FROM attendance_records
+		` + where + `
+		ORDER BY date, student_user_id
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var records []*AttendanceRecord
+
+	for rows.Next() {
+		var record AttendanceRecord
+
+		err := rows.Scan(
+			&record.ID,
+			&record.SchoolID,
+			&record.SectionID,
+			&record.StudentUserID,
+			&record.Date,
+			&record.Code,
+			&record.Type,
+			&record.Comment,
+			&record.RecordedByUserID,
+			&record.RecordedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, &record)
+	}
+
+	return records, rows.Err()
+}
+
+func validateAttendanceCodes(codes *AttendanceCodes) error {
+	if len(codes.Codes) == 0 {
+		return errors.New("at least one attendance code is required")
+	}
+
+	seen := map[string]bool{}
+	types := map[string]bool{}
+
+	for _, code := range codes.Codes {
+		if code.Code == "" || code.Name == "" {
+			return errors.New("every attendance code needs a code and a name")
+		}
+
+		if seen[strings.ToUpper(code.Code)] {
+			return fmt.Errorf("code %s is used more than once", code.Code)
+		}
+
+		seen[strings.ToUpper(code.Code)] = true
+
+		if !slices.Contains(attendanceTypes, code.Type) {
+			return fmt.Errorf("type must be one of %v", attendanceTypes)
+		}
+
+		types[code.Type] = true
+	}
+
+	if !types[AttendancePresent] || !types[AttendanceAbsent] {
+		return errors.New("codes must include a present and an absent type")
+	}
+
+	return nil
+}
+
+// A student's attendance over a range of days. Rates are percents of the days recorded and
+// are nil when nothing was recorded.
+type AttendanceSummary struct {
+	StudentUserID string   `json:"studentUserId"`
+	Days          int      `json:"days"`
+	Present       int      `json:"present"`
+	Tardy         int      `json:"tardy"`
+	Absent        int      `json:"absent"`
+	Excused       int      `json:"excused"`
+	Rate          *float64 `json:"rate"`
+	AbsenceRate   *float64 `json:"absenceRate"`
+}
+
+// Tallies the records per student, ordered by student
+func summarizeAttendance(records []*AttendanceRecord) []*AttendanceSummary {
+	byStudent := map[string]*AttendanceSummary{}
+
+	for _, record := range records {
+		summary := byStudent[record.StudentUserID]
+
+		if summary == nil {
+			summary = &AttendanceSummary{StudentUserID: record.StudentUserID}
+			byStudent[record.StudentUserID] = summary
+		}
+
+		summary.Days++
+
+		switch record.Type {
+		case AttendancePresent:
+			summary.Present++
+		case AttendanceTardy:
+			summary.Tardy++
+		case AttendanceAbsent:
+			summary.Absent++
+		case AttendanceExcused:
+			summary.Excused++
+		}
+	}
+
+	summaries := []*AttendanceSummary{}
+
+	for _, summary := range byStudent {
+		rate := float64(summary.Present+summary.Tardy) / float64(summary.Days) * 100
+		absenceRate := float64(summary.Absent+summary.Excused) / float64(summary.Days) * 100
+
+		summary.Rate = &rate
+		summary.AbsenceRate = &absenceRate
+		summaries = append(summaries, summary)
+	}
+
+	slices.SortFunc(summaries, func(a, b *AttendanceSummary) int {
+		return cmp.Compare(a.StudentUserID, b.StudentUserID)
+	})
+
+	return summaries
+}
+
+// Returns the students missing at least threshold percent of their recorded days, most absent first
+func chronicAbsentees(summaries []*AttendanceSummary, threshold float64) []*AttendanceSummary {
+	absentees := []*AttendanceSummary{}
+
+	for _, summary := range summaries {
+		if summary.Days >= chronicAbsenceMinDays && *summary.AbsenceRate >= threshold {
+			absentees = append(absentees, summary)
+		}
+	}
+
+	slices.SortStableFunc(absentees, func(a, b *AttendanceSummary) int {
+		return cmp.Compare(*b.AbsenceRate, *a.AbsenceRate)
+	})
+
+	return absentees
+}
+
+// Collects the student's daily and section attendance for their data export
+func attendanceDataExport(attendanceStore AttendanceStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		records, err := attendanceStore.ListRecords(ctx, &AttendanceFilter{StudentUserID: userID, AllSections: true})
+		return toAnySlice(records), err
+	}
+}
+
+type AttendanceService struct {
+	attendanceStore AttendanceStore
+	sectionStore    SectionStore
+	enrollmentStore EnrollmentStore
+	calendarStore   CalendarStore
+	bellStore       BellStore
+	schoolStore     SchoolStore
+	memberStore     OrganizationMemberStore
+	auditService    *AuditService
+}
+
+func NewAttendanceService(attendanceStore AttendanceStore, sectionStore SectionStore, enrollmentStore EnrollmentStore, calendarStore CalendarStore, bellStore BellStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *AttendanceService {
+	return &AttendanceService{
+		attendanceStore: attendanceStore,
+		sectionStore:    sectionStore,
+		enrollmentStore: enrollmentStore,
+		calendarStore:   calendarStore,
+		bellStore:       bellStore,
+		schoolStore:     schoolStore,
+		memberStore:     memberStore,
+		auditService:    auditService,
+	}
+}
+
+// Returns the school's attendance codes, or the default codes if it has not set any
+func (s *AttendanceService) codes(ctx context.Context, schoolID string) (*AttendanceCodes, error) {
+	codes, err := s.attendanceStore.GetCodes(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to get attendance codes", "error", err)
+		return nil, ErrInternal
+	}
+
+	if codes == nil {
+		codes = &AttendanceCodes{SchoolID: schoolID, Codes: slices.Clone(defaultAttendanceCodes)}
+	}
+
+	return codes, nil
+}
+
+func (s *AttendanceService) GetCodes(ctx context.Context, schoolID string) (*AttendanceCodes, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	return s.codes(ctx, schoolID)
+}
+
+// Replaces the school's attendance codes. Records taken with removed codes keep their type.
+func (s *AttendanceService) SaveCodes(ctx context.Context, codes *AttendanceCodes) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, codes.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateAttendanceCodes(codes); err != nil {
+		return err
+	}
+
+	before, err := s.codes(ctx, codes.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	codes.UpdatedAt = time.Now()
+
+	if err := s.attendanceStore.SaveCodes(ctx, codes); err != nil {
+		slog.Error("failed to save attendance codes", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "attendance_codes", codes.SchoolID, before, codes)
+
+	return nil
+}
+
+// Checks that attendance can be taken at the school on date: it must not be in the future
+// and must be an instructional day
+func (s *AttendanceService) checkDate(ctx context.Context, schoolID string, date Date) error {
+	if date.IsZero() {
+		return errors.New("date is required")
+	}
+
+	if date.After(DateOf(time.Now()).Time) {
+		return errors.New("attendance cannot be taken for a future date")
+	}
+
+	years, err := s.calendarStore.ListAcademicYears(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list academic years", "error", err)
+		return ErrInternal
+	}
+
+	events, err := s.calendarStore.ListEvents(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list calendar events", "error", err)
+		return ErrInternal
+	}
+
+	if len(instructionalDays(years, events, date, date)) == 0 {
+		return fmt.Errorf("%s is not an instructional day", date)
+	}
+
+	return nil
+}
+
+// Checks that the section meets on date
+func (s *AttendanceService) checkMeeting(ctx context.Context, section *Section, date Date) error {
+	term, err := s.calendarStore.GetTerm(ctx, section.TermID)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return ErrInternal
+	}
+
+	if term == nil || !date.Within(term.StartDate, term.EndDate) {
+		return fmt.Errorf("section does not meet on %s, it is outside the term", date)
+	}
+
+	// Sections in bell schedule periods meet on the days their periods run
+	if len(section.Periods) > 0 {
+		calendar, err := loadBellCalendar(ctx, s.bellStore, s.calendarStore, section.SchoolID)
+
+		if err != nil {
+			return err
+		}
+
+		if len(sectionOccurrences(section, calendar.days(date, date))) == 0 {
+			return fmt.Errorf("section does not meet on %s", date)
+		}
+
+		return nil
+	}
+
+	meets := slices.ContainsFunc(section.Meetings, func(meeting SectionMeeting) bool {
+		return meeting.Weekday == date.Weekday()
+	})
+
+	// Sections without a weekly schedule may meet on any day
+	if len(section.Meetings) > 0 && !meets {
+		return fmt.Errorf("section does not meet on %ss", date.Weekday())
+	}
+
+	return nil
+}
+
+type AttendanceEntry struct {
+	StudentUserID string `json:"studentUserId"`
+	Code          string `json:"code"`
+	Comment       string `json:"comment"`
+}
+
+type TakeAttendanceRequest struct {
+	Records []AttendanceEntry `json:"records"`
+}
+
+// Turns the entries into records, checking their codes and that each student is allowed by onRoster
+func (s *AttendanceService) records(ctx context.Context, schoolID string, sectionID string, date Date, request *TakeAttendanceRequest, onRoster func(studentUserID string) (bool, error)) ([]*AttendanceRecord, error) {
+	if len(request.Records) == 0 {
+		return nil, errors.New("at least one record is required")
+	}
+
+	if len(request.Records) > maxAttendanceRecords {
+		return nil, fmt.Errorf("at most %d records can be taken at once", maxAttendanceRecords)
+	}
+
+	codes, err := s.codes(ctx, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, _ := SessionFromContext(ctx)
+	now := time.Now()
+	seen := map[string]bool{}
+	records := make([]*AttendanceRecord, 0, len(request.Records))
+
+	for _, entry := range request.Records {
+		if entry.StudentUserID == "" {
+			return nil, errors.New("every record needs a student")
+		}
+
+		if seen[entry.StudentUserID] {
+			return nil, fmt.Errorf("student %s is listed more than once", entry.StudentUserID)
+		}
+
+		seen[entry.StudentUserID] = true
+
+		code := codes.Find(entry.Code)
+
+		if code == nil {
+			return nil, fmt.Errorf("unknown attendance code %q", entry.Code)
+		}
+
+		ok, err := onRoster(entry.StudentUserID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("student %s is not on the roster", entry.StudentUserID)
+		}
+
+		records = append(records, &AttendanceRecord{
+			SchoolID:         schoolID,
+			SectionID:        sectionID,
+			StudentUserID:    entry.StudentUserID,
+			Date:             date,
+			Code:             code.Code,
+			Type:             code.Type,
+			Comment:          entry.Comment,
+			RecordedByUserID: session.UserID,
+			RecordedAt:       now,
+		})
+	}
+
+	return records, nil
+}
+
+// Saves the records and audits them against what was recorded for the day before
+func (s *AttendanceService) save(ctx context.Context, school *School, entityID string, filter *AttendanceFilter, records []*AttendanceRecord) error {
+	before, err := s.attendanceStore.ListRecords(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list attendance records", "error", err)
+		return ErrInternal
+	}
+
+	if err := s.attendanceStore.SaveRecords(ctx, records); err != nil {
+		slog.Error("failed to save attendance records", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "attendance", entityID+":"+filter.From.String(), before, records)
+
+	return nil
+}
+
+// Takes attendance for students of the section's roster at its meeting on date. Students left
+// out keep whatever was recorded for them before.
+func (s *AttendanceService) TakeSectionAttendance(ctx context.Context, sectionID string, date Date, request *TakeAttendanceRequest) ([]*AttendanceRecord, error) {
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkDate(ctx, school.ID, date); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMeeting(ctx, section, date); err != nil {
+		return nil, err
+	}
+
+	roster, err := sectionRoster(ctx, s.enrollmentStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.records(ctx, school.ID, sectionID, date, request, func(studentUserID string) (bool, error) {
+		return slices.Contains(roster, studentUserID), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &AttendanceFilter{SchoolID: school.ID, SectionID: sectionID, From: date, To: date}
+
+	if err := s.save(ctx, school, sectionID, filter, records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Takes daily attendance at the school for students of its organization
+func (s *AttendanceService) TakeDailyAttendance(ctx context.Context, schoolID string, date Date, request *TakeAttendanceRequest) ([]*AttendanceRecord, error) {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkDate(ctx, school.ID, date); err != nil {
+		return nil, err
+	}
+
+	records, err := s.records(ctx, school.ID, "", date, request, func(studentUserID string) (bool, error) {
+		member, err := s.memberStore.Get(ctx, school.OrganizationID, studentUserID)
+
+		if err != nil {
+			slog.Error("failed to get organization member", "error", err)
+			return false, ErrInternal
+		}
+
+		return member != nil && member.Role == RoleStudent, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &AttendanceFilter{SchoolID: school.ID, From: date, To: date}
+
+	if err := s.save(ctx, school, school.ID, filter, records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// The attendance taken on a day. Unmarked lists the roster's students with no record yet.
+type AttendanceSheet struct {
+	SchoolID  string              `json:"schoolId"`
+	SectionID string              `json:"sectionId,omitempty"`
+	Date      Date                `json:"date"`
+	Records   []*AttendanceRecord `json:"records"`
+	Unmarked  []string            `json:"unmarked"`
+}
+
+func (s *AttendanceService) sheet(ctx context.Context, filter *AttendanceFilter, roster []string) (*AttendanceSheet, error) {
+	records, err := s.attendanceStore.ListRecords(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list attendance records", "error", err)
+		return nil, ErrInternal
+	}
+
+	sheet := &AttendanceSheet{SchoolID: filter.SchoolID, SectionID: filter.SectionID, Date: filter.From, Records: records, Unmarked: []string{}}
+
+	if sheet.Records == nil {
+		sheet.Records = []*AttendanceRecord{}
+	}
+
+	for _, studentUserID := range roster {
+		marked := slices.ContainsFunc(records, func(record *AttendanceRecord) bool {
+			return record.StudentUserID == studentUserID
+		})
+
+		if !marked {
+			sheet.Unmarked = append(sheet.Unmarked, studentUserID)
+		}
+	}
+
+	return sheet, nil
+}
+
+// Returns the attendance taken at the section's meeting on date
+func (s *AttendanceService) SectionAttendance(ctx context.Context, sectionID string, date Date) (*AttendanceSheet, error) {
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	roster, err := sectionRoster(ctx, s.enrollmentStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sheet(ctx, &AttendanceFilter{SchoolID: school.ID, SectionID: sectionID, From: date, To: date}, roster)
+}
+
+// Returns the daily attendance taken at the school on date
+func (s *AttendanceService) DailyAttendance(ctx context.Context, schoolID string, date Date) (*AttendanceSheet, error) {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sheet(ctx, &AttendanceFilter{SchoolID: school.ID, From: date, To: date}, nil)
+}
+
+// Narrows an attendance summary to a range of days and, optionally, to one section's meetings
+type AttendanceSummaryFilter struct {
+	SectionID string
+	From      Date
+	To        Date
+}
+
+// Checks the session user may read summaries of the school's attendance, or of a section's
+// when the filter names one. Students may read their own.
+func (s *AttendanceService) authorizeSummary(ctx context.Context, schoolID string, studentUserID string, filter *AttendanceSummaryFilter) error {
+	if err := validateDateRange(filter.From, filter.To); err != nil {
+		return err
+	}
+
+	if filter.To.Sub(filter.From.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return fmt.Errorf("range must not exceed %d days", maxInstructionalDaysRange)
+	}
+
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	self := studentUserID != "" && session.UserID == studentUserID
+
+	if filter.SectionID == "" {
+		if self {
+			_, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID)
+			return err
+		}
+
+		_, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher)
+
+		return err
+	}
+
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, filter.SectionID)
+
+	if err != nil {
+		return err
+	}
+
+	if section.SchoolID != schoolID {
+		return notFound("section")
+	}
+
+	if !self {
+		return authorizeSectionStaff(ctx, s.memberStore, section, school)
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, school.OrganizationID); err != nil {
+		return err
+	}
+
+	roster, err := sectionRoster(ctx, s.enrollmentStore, section.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(roster, studentUserID) {
+		return notFound("section")
+	}
+
+	return nil
+}
+
+func (s *AttendanceService) summaries(ctx context.Context, schoolID string, studentUserID string, filter *AttendanceSummaryFilter) ([]*AttendanceSummary, error) {
+	records, err := s.attendanceStore.ListRecords(ctx, &AttendanceFilter{
+		SchoolID:      schoolID,
+		SectionID:     filter.SectionID,
+		StudentUserID: studentUserID,
+		From:          filter.From,
+		To:            filter.To,
+	})
+
+	if err != nil {
+		slog.Error("failed to list attendance records", "error", err)
+		return nil, ErrInternal
+	}
+
+	return summarizeAttendance(records), nil
+}
+
+// Returns a student's attendance rate at the school, from daily attendance or from a section's
+func (s *AttendanceService) StudentSummary(ctx context.Context, schoolID string, studentUserID string, filter *AttendanceSummaryFilter) (*AttendanceSummary, error) {
+	if err := s.authorizeSummary(ctx, schoolID, studentUserID, filter); err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.summaries(ctx, schoolID, studentUserID, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(summaries) == 0 {
+		return &AttendanceSummary{StudentUserID: studentUserID}, nil
+	}
+
+	return summaries[0], nil
+}
+
+// Lists the students missing at least threshold percent of the days recorded
+func (s *AttendanceService) ChronicAbsenteeism(ctx context.Context, schoolID string, filter *AttendanceSummaryFilter, threshold float64) ([]*AttendanceSummary, error) {
+	if err := s.authorizeSummary(ctx, schoolID, "", filter); err != nil {
+		return nil, err
+	}
+
+	if threshold <= 0 || threshold > 100 {
+		return nil, errors.New("threshold must be greater than 0 and at most 100")
+	}
+
+	summaries, err := s.summaries(ctx, schoolID, "", filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return chronicAbsentees(summaries, threshold), nil
+}
+
+type AttendanceHandler struct {
+	attendanceService *AttendanceService
+}
+
+func (h *AttendanceHandler) GetCodes(w http.ResponseWriter, r *http.Request) {
+	codes, err := h.attendanceService.GetCodes(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, codes)
+}
+
+func (h *AttendanceHandler) SaveCodes(w http.ResponseWriter, r *http.Request) {
+	var codes AttendanceCodes
+
+	if err := decodeJSON(r, &codes); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	codes.SchoolID = r.PathValue("id")
+
+	if err := h.attendanceService.SaveCodes(r.Context(), &codes); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, codes)
+}
+
+func (h *AttendanceHandler) TakeSectionAttendance(w http.ResponseWriter, r *http.Request) {
+	date, err := ParseDate(r.PathValue("date"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var request TakeAttendanceRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	records, err := h.attendanceService.TakeSectionAttendance(r.Context(), r.PathValue("id"), date, &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (h *AttendanceHandler) SectionAttendance(w http.ResponseWriter, r *http.Request) {
+	date, err := ParseDate(r.PathValue("date"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sheet, err := h.attendanceService.SectionAttendance(r.Context(), r.PathValue("id"), date)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sheet)
+}
+
+func (h *AttendanceHandler) TakeDailyAttendance(w http.ResponseWriter, r *http.Request) {
+	date, err := ParseDate(r.PathValue("date"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var request TakeAttendanceRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	records, err := h.attendanceService.TakeDailyAttendance(r.Context(), r.PathValue("id"), date, &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (h *AttendanceHandler) DailyAttendance(w http.ResponseWriter, r *http.Request) {
+	date, err := ParseDate(r.PathValue("date"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sheet, err := h.attendanceService.DailyAttendance(r.Context(), r.PathValue("id"), date)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sheet)
+}
+
+// Reads the from, to and sectionId query parameters
+func attendanceSummaryFilter(r *http.Request) (*AttendanceSummaryFilter, error) {
+	from, err := dateQueryParam(r, "from", Date{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := dateQueryParam(r, "to", DateOf(time.Now()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttendanceSummaryFilter{SectionID: r.URL.Query().Get("sectionId"), From: from, To: to}, nil
+}
+
+func (h *AttendanceHandler) StudentSummary(w http.ResponseWriter, r *http.Request) {
+	filter, err := attendanceSummaryFilter(r)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	summary, err := h.attendanceService.StudentSummary(r.Context(), r.PathValue("id"), r.PathValue("studentId"), filter)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (h *AttendanceHandler) ChronicAbsenteeism(w http.ResponseWriter, r *http.Request) {
+	filter, err := attendanceSummaryFilter(r)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	threshold := float64(defaultChronicAbsenceThreshold)
+
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		if threshold, err = strconv.ParseFloat(value, 64); err != nil {
+			writeError(w, errors.New("threshold must be a number"))
+			return
+		}
+	}
+
+	summaries, err := h.attendanceService.ChronicAbsenteeism(r.Context(), r.PathValue("id"), filter, threshold)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAttendanceStore struct {
+	GetCodesFunc    func(ctx context.Context, schoolID string) (*AttendanceCodes, error)
+	SaveCodesFunc   func(ctx context.Context, codes *AttendanceCodes) error
+	SaveRecordsFunc func(ctx context.Context, records []*AttendanceRecord) error
+	ListRecordsFunc func(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error)
+}
+
+func (m *MockAttendanceStore) GetCodes(ctx context.Context, schoolID string) (*AttendanceCodes, error) {
+	if m.GetCodesFunc != nil {
+		return m.GetCodesFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockAttendanceStore) SaveCodes(ctx context.Context, codes *AttendanceCodes) error {
+	if m.SaveCodesFunc != nil {
+		return m.SaveCodesFunc(ctx, codes)
+	}
+
+	return nil
+}
+
+func (m *MockAttendanceStore) SaveRecords(ctx context.Context, records []*AttendanceRecord) error {
+	if m.SaveRecordsFunc != nil {
+		return m.SaveRecordsFunc(ctx, records)
+	}
+
+	return nil
+}
+
+func (m *MockAttendanceStore) ListRecords(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error) {
+	if m.ListRecordsFunc != nil {
+		return m.ListRecordsFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+// Monday, October 6th 2025, a school day. The following Monday is a holiday.
+var (
+	schoolDay = NewDate(2025, time.October, 6)
+	holiday   = NewDate(2025, time.October, 13)
+)
+
+// The 2025-26 school year with its fall term and a holiday. Geometry meets on Mondays and
+// Wednesdays, taught by "teacher".
+func attendanceFixtures() (*MockSectionStore, *MockCalendarStore) {
+	sectionStore := &MockSectionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Section, error) {
+			return &Section{ID: id, CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30,
+				Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}},
+				Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "08:00", EndTime: "08:50"}, {Weekday: time.Wednesday, StartTime: "08:00", EndTime: "08:50"}},
+			}, nil
+		},
+	}
+
+	calendarStore := &MockCalendarStore{
+		ListAcademicYearsFunc: func(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+			return []*AcademicYear{{ID: "year", SchoolID: schoolID, Name: "2025-26", StartDate: NewDate(2025, time.August, 1), EndDate: NewDate(2026, time.June, 30)}}, nil
+		},
+		ListEventsFunc: func(ctx context.Context, schoolID string) ([]*CalendarEvent, error) {
+			return []*CalendarEvent{{ID: "holiday", SchoolID: schoolID, Name: "Indigenous Peoples' Day", Type: CalendarEventHoliday, StartDate: holiday, EndDate: holiday}}, nil
+		},
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}, nil
+		},
+	}
+
+	return sectionStore, calendarStore
+}
+
+// Records for a student present on the first days given and absent on the rest
+func attendanceDays(studentUserID string, present int, absent int) []*AttendanceRecord {
+	var records []*AttendanceRecord
+
+	for i := 0; i < present+absent; i++ {
+		record := &AttendanceRecord{ID: fmt.Sprint(studentUserID, i), StudentUserID: studentUserID, Date: schoolDay.AddDays(i), Type: AttendancePresent}
+
+		if i >= present {
+			record.Type = AttendanceAbsent
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestValidateAttendanceCodes_ReturnsErrorForDuplicateCode(t *testing.T) {
+	err := validateAttendanceCodes(&AttendanceCodes{Codes: []AttendanceCode{
+		{Code: "P", Name: "Present", Type: AttendancePresent},
+		{Code: "p", Name: "Present online", Type: AttendancePresent},
+		{Code: "A", Name: "Absent", Type: AttendanceAbsent},
+	}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "code p is used more than once", err.Error())
+}
+
+func TestValidateAttendanceCodes_ReturnsErrorWithoutAbsentType(t *testing.T) {
+	err := validateAttendanceCodes(&AttendanceCodes{Codes: []AttendanceCode{
+		{Code: "P", Name: "Present", Type: AttendancePresent},
+		{Code: "E", Name: "Excused", Type: AttendanceExcused},
+	}})
+
+	assert.Error(t, err)
+}
+
+func TestSummarizeAttendance_CountsTardyAsAttendedAndExcusedAsAbsent(t *testing.T) {
+	summaries := summarizeAttendance([]*AttendanceRecord{
+		{StudentUserID: "student", Type: AttendancePresent},
+		{StudentUserID: "student", Type: AttendanceTardy},
+		{StudentUserID: "student", Type: AttendanceExcused},
+		{StudentUserID: "student", Type: AttendanceAbsent},
+	})
+
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, 4, summaries[0].Days)
+	assert.Equal(t, 1, summaries[0].Tardy)
+	assert.Equal(t, 50.0, *summaries[0].Rate)
+	assert.Equal(t, 50.0, *summaries[0].AbsenceRate)
+}
+
+func TestChronicAbsentees_ListsStudentsAtThresholdMostAbsentFirst(t *testing.T) {
+	var records []*AttendanceRecord
+
+	records = append(records, attendanceDays("rarely-absent", 19, 1)...)
+	records = append(records, attendanceDays("at-threshold", 18, 2)...)
+	records = append(records, attendanceDays("often-absent", 15, 5)...)
+	records = append(records, attendanceDays("new-student", 1, 2)...)
+
+	absentees := chronicAbsentees(summarizeAttendance(records), 10)
+
+	assert.Len(t, absentees, 2)
+	assert.Equal(t, "often-absent", absentees[0].StudentUserID)
+	assert.Equal(t, "at-threshold", absentees[1].StudentUserID)
+}
+
+func TestAttendanceService_SaveCodes_ReturnsErrorForTeacher(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := attendanceService.SaveCodes(sessionContext("teacher"), &AttendanceCodes{SchoolID: "school", Codes: defaultAttendanceCodes})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAttendanceService_TakeSectionAttendance_SavesRosterWithSchoolCodes(t *testing.T) {
+	var saved []*AttendanceRecord
+
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{
+		GetCodesFunc: func(ctx context.Context, schoolID string) (*AttendanceCodes, error) {
+			return &AttendanceCodes{SchoolID: schoolID, Codes: []AttendanceCode{
+				{Code: "P", Name: "Present", Type: AttendancePresent},
+				{Code: "AU", Name: "Unexcused absence", Type: AttendanceAbsent},
+			}}, nil
+		},
+		SaveRecordsFunc: func(ctx context.Context, records []*AttendanceRecord) error {
+			saved = records
+			return nil
+		},
+	}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	records, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", schoolDay, &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "au", Comment: "No note"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, records, saved)
+	assert.Equal(t, "AU", records[0].Code)
+	assert.Equal(t, AttendanceAbsent, records[0].Type)
+	assert.Equal(t, "section", records[0].SectionID)
+	assert.Equal(t, "school", records[0].SchoolID)
+	assert.Equal(t, "teacher", records[0].RecordedByUserID)
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorForStudentNotOnRoster(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", schoolDay, &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "other-student", Code: "P"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "student other-student is not on the roster", err.Error())
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorForUnknownCode(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", schoolDay, &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "X"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, `unknown attendance code "X"`, err.Error())
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorOnHoliday(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", holiday, &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "2025-10-13 is not an instructional day", err.Error())
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorWhenSectionDoesNotMeet(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", schoolDay.AddDays(1), &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "section does not meet on Tuesdays", err.Error())
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorForFutureDate(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", DateOf(time.Now()).AddDays(7), &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "attendance cannot be taken for a future date", err.Error())
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorForStudent(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("student"), "section", schoolDay, &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}},
+	})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAttendanceService_TakeDailyAttendance_ReturnsErrorForNonStudent(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.TakeDailyAttendance(sessionContext("admin"), "school", schoolDay, &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}, {StudentUserID: "teacher", Code: "P"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "student teacher is not on the roster", err.Error())
+}
+
+func TestAttendanceService_SectionAttendance_ListsUnmarkedStudents(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	sheet, err := attendanceService.SectionAttendance(sessionContext("teacher"), "section", schoolDay)
+
+	assert.NoError(t, err)
+	assert.Empty(t, sheet.Records)
+	assert.Equal(t, []string{"student"}, sheet.Unmarked)
+}
+
+func TestAttendanceService_StudentSummary_AllowsStudentToReadOwn(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{
+		ListRecordsFunc: func(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error) {
+			assert.Equal(t, "student", filter.StudentUserID)
+			return attendanceDays("student", 3, 1), nil
+		},
+	}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	summary, err := attendanceService.StudentSummary(sessionContext("student"), "school", "student", &AttendanceSummaryFilter{SectionID: "section", From: schoolDay, To: schoolDay.AddDays(30)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 75.0, *summary.Rate)
+}
+
+func TestAttendanceService_StudentSummary_ReturnsErrorForOtherStudent(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := attendanceService.StudentSummary(sessionContext("other-student"), "school", "student", &AttendanceSummaryFilter{From: schoolDay, To: schoolDay.AddDays(30)})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAttendanceService_StudentSummary_ReturnsEmptySummaryWithoutRecords(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	summary, err := attendanceService.StudentSummary(sessionContext("teacher"), "school", "student", &AttendanceSummaryFilter{From: schoolDay, To: schoolDay.AddDays(30)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.Days)
+	assert.Nil(t, summary.Rate)
+}
+
+func TestAttendanceService_TakeSectionAttendance_ReturnsErrorWhenPeriodDoesNotRunOnRotationDay(t *testing.T) {
+	sectionStore, calendarStore := attendanceFixtures()
+	sectionStore.GetByIDFunc = func(ctx context.Context, id string) (*Section, error) {
+		return &Section{ID: id, CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30,
+			Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}},
+			Periods:  []SectionPeriod{{Day: "A", Period: "1"}},
+		}, nil
+	}
+
+	attendanceService := NewAttendanceService(&MockAttendanceStore{}, sectionStore, enrolledStudent(), calendarStore, abRotation(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	// Tuesday the 7th is a B day
+	_, err := attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", schoolDay.AddDays(1), &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "section does not meet on 2025-10-07", err.Error())
+
+	_, err = attendanceService.TakeSectionAttendance(sessionContext("teacher"), "section", schoolDay.AddDays(2), &TakeAttendanceRequest{
+		Records: []AttendanceEntry{{StudentUserID: "student", Code: "P"}},
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestAttendanceDataExport_CollectsDailyAndSectionAttendance(t *testing.T) {
+	collect := attendanceDataExport(&MockAttendanceStore{
+		ListRecordsFunc: func(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error) {
+			assert.Equal(t, &AttendanceFilter{StudentUserID: "student", AllSections: true}, filter)
+			return []*AttendanceRecord{{ID: "daily"}, {ID: "section", SectionID: "section"}}, nil
+		},
+	})
+
+	records, err := collect(context.Background(), "student")
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionExport  = "export"
+	AuditActionImport  = "import"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// Fields whose values are never written to the audit log
+var redactedAuditFields = map[string]bool{
+	"password": true,
+}
+
+// The entity types whose audit entries hold personal data about one user, and are redacted when
+// that user is erased. Entities keyed by the user's id map to ""; the rest map to the field
+// naming the user, which their create and delete entries record.
+var userAuditEntities = map[string]string{
+	"user":                "",
+	"student_profile":     "",
+	"school_enrollment":   "studentUserId",
+	"staff_profile":       "",
+	"staff_certification": "userId",
+	"staff_assignment":    "userId",
+}
+
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditEntry struct {
+	ID                 string                 `json:"id"`
+	OrganizationID     string                 `json:"organizationId,omitempty"`
+	ActorUserID        string                 `json:"actorUserId,omitempty"`
+	ImpersonatorUserID string                 `json:"impersonatorUserId,omitempty"`
+	Action             string                 `json:"action"`
+	EntityType         string                 `json:"entityType"`
+	EntityID           string                 `json:"entityId"`
+	Changes            map[string]AuditChange `json:"changes,omitempty"`
+	RequestID          string                 `json:"requestId,omitempty"`
+	IPAddress          string                 `json:"ipAddress,omitempty"`
+	UserAgent          string                 `json:"userAgent,omitempty"`
+	CreatedAt          time.Time              `json:"createdAt"`
+}
+
+// Narrows the audit entries returned by AuditStore.List. A zero Limit returns every match.
+type AuditFilter struct {
+	OrganizationID string
+	ActorUserID    string
+	EntityType     string
+	EntityID       string
+	From           time.Time
+	To             time.Time
+	Limit          int
+}
+
+type AuditPostgresStore struct {
+	db *PostgresDB
+}
+
+// Audit entries are append-only, so the store has no update or delete
+type AuditStore interface {
+	Create(ctx context.Context, entry *AuditEntry) error
+	List(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error)
+	RedactUser(ctx context.Context, userID string) error
+}
+
+func (s *AuditPostgresStore) Create(ctx context.Context, entry *AuditEntry) error {
+	query := `
+		INSERT INTO audit_entries (organization_id, actor_user_id, impersonator_user_id, action, entity_type, entity_id, changes, request_id, ip_address, user_agent, created_at)
+		VALUES (NULLIF($1, '')::uuid, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		entry.OrganizationID,
+		entry.ActorUserID,
+		entry.ImpersonatorUserID,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Changes,
+		entry.RequestID,
+		entry.IPAddress,
+		entry.UserAgent,
+		entry.CreatedAt,
+	)
+
+	if err := row.Scan(&entry.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *AuditPostgresStore) List(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.ActorUserID != "" {
+		addCondition("(actor_user_id = $%[1]d OR impersonator_user_id = $%[1]d)", filter.ActorUserID)
+	}
+
+	if filter.EntityType != "" {
+		addCondition("entity_type = $%d", filter.EntityType)
+	}
+
+	if filter.EntityID != "" {
+		addCondition("entity_id = $%d", filter.EntityID)
+	}
+
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		addCondition("created_at < $%d", filter.To)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limit := ""
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(organization_id::text, ''), COALESCE(actor_user_id::text, ''), COALESCE(impersonator_user_id::text, ''),
+			action, entity_type, entity_id, changes, request_id, ip_address, user_agent, created_at
+		FROM audit_entries
+		%s
+		ORDER BY created_at DESC, id
+		%s
+	`, where, limit)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []*AuditEntry
+
+	for rows.Next() {
+		var entry AuditEntry
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.OrganizationID,
+			&entry.ActorUserID,
+			&entry.ImpersonatorUserID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Changes,
+			&entry.RequestID,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&entry.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (e *AuditEntry) attachRequestMetadata(ctx context.Context) {
+	metadata := RequestMetadataFromContext(ctx)
+
+	e.RequestID = metadata.RequestID
+	e.IPAddress = metadata.IPAddress
+	e.UserAgent = metadata.UserAgent
+}
+
+// Removes a user's personal data from the log while keeping the entries themselves: the recorded
+// changes to their account and their other records, see userAuditEntities, and the network
+// details of requests they made. This is the only modification the append-only trigger allows.
+func (s *AuditPostgresStore) RedactUser(ctx context.Context, userID string) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SET LOCAL divinity.audit_redaction = 'on'`); err != nil {
+		return err
+	}
+
+	for entityType, field := range userAuditEntities {
+		query := `
+			UPDATE audit_entries
+			SET changes = NULL
+			WHERE entity_type = $2 AND entity_id = $1 AND changes IS NOT NULL
+		`
+		args := []any{userID, entityType}
+
+		if field != "" {
+			query = `
+				UPDATE audit_entries
+				SET changes = NULL
+				WHERE entity_type = $2 AND changes IS NOT NULL AND entity_id IN (
+					SELECT entity_id
+					FROM audit_entries
+					WHERE entity_type = $2 AND (changes -> $3::text ->> 'before' = $1 OR changes -> $3::text ->> 'after' = $1)
+				)
+			`
+			args = append(args, field)
+		}
+
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE audit_entries
+		SET ip_address = '', user_agent = ''
+		WHERE actor_user_id = $1 AND (ip_address <> '' OR user_agent <> '')
+	`
+
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Returns the fields that differ between before and after, keyed by their JSON name.
+// Either side may be nil, as on create and delete.
+func diffForAudit(before any, after any) (map[string]AuditChange, error) {
+	beforeFields, err := auditFields(before)
+
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := auditFields(after)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]AuditChange{}
+
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = AuditChange{Before: nil, After: value}
+		}
+	}
+
+	for name, change := range changes {
+		if redactedAuditFields[name] {
+			changes[name] = AuditChange{Before: redactAuditValue(change.Before), After: redactAuditValue(change.After)}
+		}
+	}
+
+	return changes, nil
+}
+
+func auditFields(v any) (map[string]any, error) {
+	fields := map[string]any{}
+
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return fields, nil
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func redactAuditValue(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	return "[redacted]"
+}
+
+type AuditService struct {
+	auditStore  AuditStore
+	memberStore OrganizationMemberStore
+}
+
+func NewAuditService(auditStore AuditStore, memberStore OrganizationMemberStore) *AuditService {
+	return &AuditService{auditStore: auditStore, memberStore: memberStore}
+}
+
+// Records a mutation of an entity, attributing it to the session and request in ctx.
+// Failures are logged rather than returned because the mutation has already happened.
+func (s *AuditService) Record(ctx context.Context, organizationID string, action string, entityType string, entityID string, before any, after any) {
+	changes, err := diffForAudit(before, after)
+
+	if err != nil {
+		slog.Error("failed to diff audit entry", "error", err, "entityType", entityType, "entityId", entityID)
+		changes = nil
+	}
+
+	entry := &AuditEntry{
+		OrganizationID: organizationID,
+		Action:         entityType + "." + action,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		Changes:        changes,
+		CreatedAt:      time.Now(),
+	}
+
+	entry.attachRequestMetadata(ctx)
+
+	if session, ok := SessionFromContext(ctx); ok {
+		entry.ActorUserID = session.UserID
+		entry.ImpersonatorUserID = session.ImpersonatorUserID
+	}
+
+	if err := s.auditStore.Create(ctx, entry); err != nil {
+		slog.Error("failed to write audit entry", "error", err, "action", entry.Action, "entityId", entityID)
+	}
+}
+
+// Records a mutation of a user in the log of every organization they belong to, since admins
+// read the log one organization at a time. A user outside every organization gets one entry
+// that belongs to none.
+func (s *AuditService) RecordUser(ctx context.Context, action string, userID string, before any, after any) {
+	members, err := s.memberStore.ListByUser(ctx, userID)
+
+	if err != nil {
+		slog.Error("failed to list organization members", "error", err, "userId", userID)
+	}
+
+	if len(members) == 0 {
+		s.Record(ctx, "", action, "user", userID, before, after)
+		return
+	}
+
+	for _, member := range members {
+		s.Record(ctx, member.OrganizationID, action, "user", userID, before, after)
+	}
+}
+
+// Lists audit entries for an organization. Only organization admins may read its audit log.
+func (s *AuditService) List(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+	if filter.OrganizationID == "" {
+		return nil, errors.New("organization id is required")
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errors.New("from must be before to")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	filter.Limit = min(filter.Limit, maxAuditLimit)
+
+	if _, err := requireMembership(ctx, s.memberStore, filter.OrganizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.auditStore.List(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list audit entries", "error", err)
+		return nil, ErrInternal
+	}
+
+	if entries == nil {
+		entries = []*AuditEntry{}
+	}
+
+	return entries, nil
+}
+
+type AuditHandler struct {
+	auditService *AuditService
+}
+
+type ListAuditResponse struct {
+	Entries []*AuditEntry `json:"entries"`
+}
+
+// Lists audit entries filtered by the organizationId, actorUserId, from, to and limit query parameters.
+// Times are RFC 3339.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := &AuditFilter{
+		OrganizationID: query.Get("organizationId"),
+		ActorUserID:    query.Get("actorUserId"),
+	}
+
+	var err error
+
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			writeError(w, errors.New("from must be an RFC 3339 time"))
+			return
+		}
+	}
+
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			writeError(w, errors.New("to must be an RFC 3339 time"))
+			return
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			writeError(w, errors.New("limit must be a number"))
+			return
+		}
+	}
+
+	entries, err := h.auditService.List(r.Context(), filter)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListAuditResponse{Entries: entries})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAuditStore struct {
+	CreateFunc     func(ctx context.Context, entry *AuditEntry) error
+	ListFunc       func(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error)
+	RedactUserFunc func(ctx context.Context, userID string) error
+}
+
+func (m *MockAuditStore) Create(ctx context.Context, entry *AuditEntry) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, entry)
+	}
+
+	return nil
+}
+
+func (m *MockAuditStore) List(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockAuditStore) RedactUser(ctx context.Context, userID string) error {
+	if m.RedactUserFunc != nil {
+		return m.RedactUserFunc(ctx, userID)
+	}
+
+	return nil
+}
+
+func TestDiffForAudit_ReturnsOnlyChangedFields(t *testing.T) {
+	before := &School{ID: "1", Name: "North", City: "Springfield"}
+	after := &School{ID: "1", Name: "North High", City: "Springfield"}
+
+	changes, err := diffForAudit(before, after)
+
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, AuditChange{Before: "North", After: "North High"}, changes["name"])
+}
+
+func TestDiffForAudit_ReturnsAllFieldsOnCreate(t *testing.T) {
+	changes, err := diffForAudit(nil, &Organization{ID: "1", Name: "District"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, AuditChange{Before: nil, After: "District"}, changes["name"])
+	assert.Equal(t, AuditChange{Before: nil, After: "1"}, changes["id"])
+}
+
+func TestDiffForAudit_TreatsNilPointerAsEmpty(t *testing.T) {
+	var deleted *Organization
+
+	changes, err := diffForAudit(&Organization{ID: "1", Name: "District"}, deleted)
+
+	assert.NoError(t, err)
+	assert.Equal(t, AuditChange{Before: "District", After: nil}, changes["name"])
+}
+
+func TestDiffForAudit_RedactsPasswords(t *testing.T) {
+	changes, err := diffForAudit(&User{ID: "1", Password: "old"}, &User{ID: "1", Password: "new"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, AuditChange{Before: "[redacted]", After: "[redacted]"}, changes["password"])
+}
+
+func TestAuditService_Record_AttributesEntryToSessionAndRequest(t *testing.T) {
+	var entry *AuditEntry
+
+	auditService := NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	}, &MockOrganizationMemberStore{})
+
+	ctx := contextWithSession(context.Background(), &Session{UserID: "teacher", ImpersonatorUserID: "admin"})
+	ctx = context.WithValue(ctx, requestMetadataContextKey{}, RequestMetadata{RequestID: "req-1", IPAddress: "10.0.0.1", UserAgent: "test"})
+
+	auditService.Record(ctx, "org", AuditActionUpdate, "school", "1", &School{Name: "North"}, &School{Name: "South"})
+
+	assert.Equal(t, "school.update", entry.Action)
+	assert.Equal(t, "org", entry.OrganizationID)
+	assert.Equal(t, "teacher", entry.ActorUserID)
+	assert.Equal(t, "admin", entry.ImpersonatorUserID)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, "10.0.0.1", entry.IPAddress)
+	assert.Equal(t, "South", entry.Changes["name"].After)
+}
+
+func TestAuditService_List_ReturnsErrorForMissingOrganization(t *testing.T) {
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+
+	entries, err := auditService.List(contextWithSession(context.Background(), &Session{UserID: "admin"}), &AuditFilter{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "organization id is required", err.Error())
+	assert.Nil(t, entries)
+}
+
+func TestAuditService_List_ReturnsErrorForInvertedTimeRange(t *testing.T) {
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+
+	entries, err := auditService.List(contextWithSession(context.Background(), &Session{UserID: "admin"}), &AuditFilter{
+		OrganizationID: "org",
+		From:           time.Now(),
+		To:             time.Now().Add(-time.Hour),
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "from must be before to", err.Error())
+	assert.Nil(t, entries)
+}
+
+func TestAuditService_List_ReturnsErrorForNonAdmin(t *testing.T) {
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+
+	entries, err := auditService.List(contextWithSession(context.Background(), &Session{UserID: "teacher"}), &AuditFilter{OrganizationID: "org"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, entries)
+}
+
+func TestAuditService_List_ReturnsErrorForFailingToListEntries(t *testing.T) {
+	auditService := NewAuditService(&MockAuditStore{
+		ListFunc: func(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+			return nil, errors.New("random error")
+		},
+	}, orgMembers())
+
+	entries, err := auditService.List(contextWithSession(context.Background(), &Session{UserID: "admin"}), &AuditFilter{OrganizationID: "org"})
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Nil(t, entries)
+}
+
+func TestAuditService_List_CapsLimit(t *testing.T) {
+	var limit int
+
+	auditService := NewAuditService(&MockAuditStore{
+		ListFunc: func(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+			limit = filter.Limit
+			return []*AuditEntry{{ID: "1"}}, nil
+		},
+	}, orgMembers())
+
+	entries, err := auditService.List(contextWithSession(context.Background(), &Session{UserID: "admin"}), &AuditFilter{OrganizationID: "org", Limit: 5000})
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, maxAuditLimit, limit)
+}
+
+func TestAuditService_RecordUser_RecordsEntryForEveryOrganization(t *testing.T) {
+	var organizationIDs []string
+
+	members := orgMembers()
+	members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		if userID == "teacher" {
+			return []*OrganizationMember{{OrganizationID: "org", UserID: userID}, {OrganizationID: "other-org", UserID: userID}}, nil
+		}
+
+		return nil, nil
+	}
+
+	auditService := NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			assert.Equal(t, "user.update", e.Action)
+			organizationIDs = append(organizationIDs, e.OrganizationID)
+			return nil
+		},
+	}, members)
+
+	auditService.RecordUser(sessionContext("admin"), AuditActionUpdate, "teacher", &User{FirstName: "Ada"}, &User{FirstName: "Grace"})
+	auditService.RecordUser(sessionContext("admin"), AuditActionUpdate, "loner", nil, nil)
+
+	assert.Equal(t, []string{"org", "other-org", ""}, organizationIDs)
+}
@@ -0,0 +1,1192 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxBellPeriods  = 20
+	maxRotationDays = 10
+)
+
+// A named period of a bell schedule. Times are HH:MM on the school's local clock.
+type BellPeriod struct {
+	Name      string `json:"name"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+// The periods a school runs on a day, such as its regular, early-release or block schedule
+type BellSchedule struct {
+	ID        string       `json:"id"`
+	SchoolID  string       `json:"schoolId"`
+	Name      string       `json:"name"`
+	Periods   []BellPeriod `json:"periods"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// Returns the period with the given name, ignoring case
+func (b *BellSchedule) Period(name string) (BellPeriod, bool) {
+	for _, period := range b.Periods {
+		if strings.EqualFold(period.Name, name) {
+			return period, true
+		}
+	}
+
+	return BellPeriod{}, false
+}
+
+// A day of a rotation, such as A or B, and the bell schedule it runs
+type RotationDay struct {
+	Name           string `json:"name"`
+	BellScheduleID string `json:"bellScheduleId"`
+}
+
+// The cycle of days a school runs through. Each instructional day from StartDate takes the
+// next day of the cycle; days without instruction do not advance it. A school on a single
+// schedule has a rotation of one day.
+type BellRotation struct {
+	SchoolID  string        `json:"schoolId"`
+	StartDate Date          `json:"startDate"`
+	Days      []RotationDay `json:"days"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// Runs a different bell schedule on a date, such as an early-release day. The date keeps its
+// rotation day so sections still meet in their usual periods.
+type BellOverride struct {
+	ID             string    `json:"id"`
+	SchoolID       string    `json:"schoolId"`
+	Date           Date      `json:"date"`
+	BellScheduleID string    `json:"bellScheduleId"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type BellPostgresStore struct {
+	db *PostgresDB
+}
+
+type BellStore interface {
+	CreateSchedule(ctx context.Context, schedule *BellSchedule) error
+	GetSchedule(ctx context.Context, id string) (*BellSchedule, error)
+	ListSchedules(ctx context.Context, schoolID string) ([]*BellSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule *BellSchedule) error
+	DeleteSchedule(ctx context.Context, id string) error
+	GetRotation(ctx context.Context, schoolID string) (*BellRotation, error)
+	SaveRotation(ctx context.Context, rotation *BellRotation) error
+	CreateOverride(ctx context.Context, override *BellOverride) error
+	GetOverride(ctx context.Context, id string) (*BellOverride, error)
+	ListOverrides(ctx context.Context, schoolID string) ([]*BellOverride, error)
+	DeleteOverride(ctx context.Context, id string) error
+}
+
+const bellScheduleColumns = `id, school_id, name, periods, created_at, updated_at`
+
+func scanBellSchedule(row rowScanner) (*BellSchedule, error) {
+	var schedule BellSchedule
+
+	if err := row.Scan(&schedule.ID, &schedule.SchoolID, &schedule.Name, &schedule.Periods, &schedule.CreatedAt, &schedule.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (s *BellPostgresStore) CreateSchedule(ctx context.Context, schedule *BellSchedule) error {
+	query := `
+		INSERT INTO bell_schedules (school_id, name, periods, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, schedule.SchoolID, schedule.Name, schedule.Periods, schedule.CreatedAt, schedule.UpdatedAt)
+
+	return row.Scan(&schedule.ID)
+}
+
+func (s *BellPostgresStore) GetSchedule(ctx context.Context, id string) (*BellSchedule, error) {
+	query := `SELECT ` + bellScheduleColumns + ` FROM bell_schedules WHERE id = $1`
+
+	return noRowsAsNil(scanBellSchedule(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *BellPostgresStore) ListSchedules(ctx context.Context, schoolID string) ([]*BellSchedule, error) {
+	query := `SELECT ` + bellScheduleColumns + ` FROM bell_schedules WHERE school_id = $1 ORDER BY name, id`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var schedules []*BellSchedule
+
+	for rows.Next() {
+		schedule, err := scanBellSchedule(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (s *BellPostgresStore) UpdateSchedule(ctx context.Context, schedule *BellSchedule) error {
+	query := `UPDATE bell_schedules SET name = $1, periods = $2, updated_at = $3 WHERE id = $4`
+
+	_, err := s.db.pool.Exec(ctx, query, schedule.Name, schedule.Periods, schedule.UpdatedAt, schedule.ID)
+
+	return err
+}
+
+func (s *BellPostgresStore) DeleteSchedule(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM bell_schedules WHERE id = $1`, id)
+	return err
+}
+
+func (s *BellPostgresStore) GetRotation(ctx context.Context, schoolID string) (*BellRotation, error) {
+	query := `SELECT school_id, start_date, days, updated_at FROM bell_rotations WHERE school_id = $1`
+
+	var rotation BellRotation
+
+	err := s.db.pool.QueryRow(ctx, query, schoolID).Scan(&rotation.SchoolID, &rotation.StartDate, &rotation.Days, &rotation.UpdatedAt)
+
+	return noRowsAsNil(&rotation, err)
+}
+
+func (s *BellPostgresStore) SaveRotation(ctx context.Context, rotation *BellRotation) error {
+	query := `
+		INSERT INTO bell_rotations (school_id, start_date, days, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (school_id) DO UPDATE SET start_date = excluded.start_date, days = excluded.days, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, rotation.SchoolID, rotation.StartDate, rotation.Days, rotation.UpdatedAt)
+
+	return err
+}
+
+const bellOverrideColumns = `id, school_id, date, bell_schedule_id, name, created_at`
+
+func scanBellOverride(row rowScanner) (*BellOverride, error) {
+	var override BellOverride
+
+	if err := row.Scan(&override.ID, &override.SchoolID, &override.Date, &override.BellScheduleID, &override.Name, &override.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &override, nil
+}
+
+func (s *BellPostgresStore) CreateOverride(ctx context.Context, override *BellOverride) error {
+	query := `
+		INSERT INTO bell_overrides (school_id, date, bell_schedule_id, name, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, override.SchoolID, override.Date, override.BellScheduleID, override.Name, override.CreatedAt)
+
+	return row.Scan(&override.ID)
+}
+
+func (s *BellPostgresStore) GetOverride(ctx context.Context, id string) (*BellOverride, error) {
+	query := `SELECT ` + bellOverrideColumns + ` FROM bell_overrides WHERE id = $1`
+
+	return noRowsAsNil(scanBellOverride(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *BellPostgresStore) ListOverrides(ctx context.Context, schoolID string) ([]*BellOverride, error) {
+	query := `SELECT ` + bellOverrideColumns + ` FROM bell_overrides WHERE school_id = $1 ORDER BY date`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var overrides []*BellOverride
+
+	for rows.Next() {
+		override, err := scanBellOverride(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, rows.Err()
+}
+
+func (s *BellPostgresStore) DeleteOverride(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM bell_overrides WHERE id = $1`, id)
+	return err
+}
+
+// Checks the schedule's periods and sorts them by start time
+func validateBellSchedule(schedule *BellSchedule, existing []*BellSchedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+
+	if schedule.Name == "" {
+		return errors.New("name is required")
+	}
+
+	for _, other := range existing {
+		if other.ID != schedule.ID && strings.EqualFold(other.Name, schedule.Name) {
+			return fmt.Errorf("the school already has a bell schedule named %s", other.Name)
+		}
+	}
+
+	if len(schedule.Periods) == 0 {
+		return errors.New("a bell schedule needs at least one period")
+	}
+
+	if len(schedule.Periods) > maxBellPeriods {
+		return fmt.Errorf("a bell schedule can have at most %d periods", maxBellPeriods)
+	}
+
+	seen := map[string]bool{}
+
+	for _, period := range schedule.Periods {
+		if period.Name == "" {
+			return errors.New("every period needs a name")
+		}
+
+		if seen[strings.ToLower(period.Name)] {
+			return fmt.Errorf("period %s is defined more than once", period.Name)
+		}
+
+		seen[strings.ToLower(period.Name)] = true
+
+		_, startErr := time.Parse(clockLayout, period.StartTime)
+		_, endErr := time.Parse(clockLayout, period.EndTime)
+
+		if startErr != nil || endErr != nil {
+			return errors.New("period times must be HH:MM")
+		}
+
+		if period.EndTime <= period.StartTime {
+			return fmt.Errorf("period %s must end after it starts", period.Name)
+		}
+	}
+
+	slices.SortStableFunc(schedule.Periods, func(a, b BellPeriod) int {
+		return strings.Compare(a.StartTime, b.StartTime)
+	})
+
+	for i := 1; i < len(schedule.Periods); i++ {
+		// HH:MM compares correctly as a string
+		if schedule.Periods[i].StartTime < schedule.Periods[i-1].EndTime {
+			return fmt.Errorf("periods %s and %s overlap", schedule.Periods[i-1].Name, schedule.Periods[i].Name)
+		}
+	}
+
+	return nil
+}
+
+func validateBellRotation(rotation *BellRotation, schedules []*BellSchedule) error {
+	if len(rotation.Days) == 0 {
+		return nil
+	}
+
+	if rotation.StartDate.IsZero() {
+		return errors.New("start date is required")
+	}
+
+	if len(rotation.Days) > maxRotationDays {
+		return fmt.Errorf("a rotation can have at most %d days", maxRotationDays)
+	}
+
+	seen := map[string]bool{}
+
+	for _, day := range rotation.Days {
+		if day.Name == "" {
+			return errors.New("every rotation day needs a name")
+		}
+
+		if seen[strings.ToLower(day.Name)] {
+			return fmt.Errorf("rotation day %s is defined more than once", day.Name)
+		}
+
+		seen[strings.ToLower(day.Name)] = true
+
+		owned := slices.ContainsFunc(schedules, func(schedule *BellSchedule) bool {
+			return schedule.ID == day.BellScheduleID
+		})
+
+		if !owned {
+			return fmt.Errorf("bell schedule %s is not a bell schedule of this school", day.BellScheduleID)
+		}
+	}
+
+	return nil
+}
+
+// A school day with the rotation day and bell schedule it runs. Days without instruction
+// have no schedule.
+type SchoolDay struct {
+	Date          Date   `json:"date"`
+	Instructional bool   `json:"instructional"`
+	RotationDay   string `json:"rotationDay"`
+	// The name of the override when the day runs a different schedule than its rotation day
+	Override       string       `json:"override"`
+	BellScheduleID string       `json:"bellScheduleId"`
+	BellSchedule   string       `json:"bellSchedule"`
+	Periods        []BellPeriod `json:"periods"`
+}
+
+// Everything needed to work out which schedule a school runs on a day
+type bellCalendar struct {
+	years     []*AcademicYear
+	events    []*CalendarEvent
+	rotation  *BellRotation
+	overrides []*BellOverride
+	schedules []*BellSchedule
+}
+
+func (c *bellCalendar) schedule(id string) *BellSchedule {
+	for _, schedule := range c.schedules {
+		if schedule.ID == id {
+			return schedule
+		}
+	}
+
+	return nil
+}
+
+// Resolves the school days between from and to, inclusive. The rotation is counted from its
+// start date, so the day of the cycle does not depend on from.
+func (c *bellCalendar) days(from Date, to Date) []SchoolDay {
+	rotating := c.rotation != nil && len(c.rotation.Days) > 0 && !c.rotation.StartDate.After(to.Time)
+	walkFrom := from
+
+	if rotating && c.rotation.StartDate.Before(from.Time) {
+		walkFrom = c.rotation.StartDate
+	}
+
+	cycle := 0
+	instructional := instructionalDays(c.years, c.events, walkFrom, to)
+	days := []SchoolDay{}
+
+	for date := from; !date.After(to.Time); date = date.AddDays(1) {
+		day := SchoolDay{Date: date, Periods: []BellPeriod{}}
+
+		for len(instructional) > 0 && instructional[0].Before(date.Time) {
+			if rotating && !instructional[0].Before(c.rotation.StartDate.Time) {
+				cycle++
+			}
+
+			instructional = instructional[1:]
+		}
+
+		if len(instructional) == 0 || !instructional[0].Equal(date.Time) {
+			days = append(days, day)
+			continue
+		}
+
+		day.Instructional = true
+		var schedule *BellSchedule
+
+		if rotating && !date.Before(c.rotation.StartDate.Time) {
+			rotationDay := c.rotation.Days[cycle%len(c.rotation.Days)]
+			day.RotationDay = rotationDay.Name
+			schedule = c.schedule(rotationDay.BellScheduleID)
+		}
+
+		for _, override := range c.overrides {
+			if override.Date.Equal(date.Time) {
+				day.Override = override.Name
+				schedule = c.schedule(override.BellScheduleID)
+			}
+		}
+
+		if schedule != nil {
+			day.BellScheduleID = schedule.ID
+			day.BellSchedule = schedule.Name
+			day.Periods = schedule.Periods
+		}
+
+		days = append(days, day)
+	}
+
+	return days
+}
+
+// Loads what is needed to resolve the school's days
+func loadBellCalendar(ctx context.Context, bellStore BellStore, calendarStore CalendarStore, schoolID string) (*bellCalendar, error) {
+	years, err := calendarStore.ListAcademicYears(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list academic years", "error", err)
+		return nil, ErrInternal
+	}
+
+	events, err := calendarStore.ListEvents(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list calendar events", "error", err)
+		return nil, ErrInternal
+	}
+
+	rotation, err := bellStore.GetRotation(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to get bell rotation", "error", err)
+		return nil, ErrInternal
+	}
+
+	overrides, err := bellStore.ListOverrides(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell overrides", "error", err)
+		return nil, ErrInternal
+	}
+
+	schedules, err := bellStore.ListSchedules(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell schedules", "error", err)
+		return nil, ErrInternal
+	}
+
+	return &bellCalendar{years: years, events: events, rotation: rotation, overrides: overrides, schedules: schedules}, nil
+}
+
+// A time a section meets. Period is empty for sections on a weekly meeting schedule.
+type SectionOccurrence struct {
+	Date         Date   `json:"date"`
+	RotationDay  string `json:"rotationDay"`
+	BellSchedule string `json:"bellSchedule"`
+	Period       string `json:"period"`
+	StartTime    string `json:"startTime"`
+	EndTime      string `json:"endTime"`
+}
+
+// Returns when the section meets on the given days. Sections with periods meet in them on
+// matching rotation days; other sections fall back to their weekly meetings.
+func sectionOccurrences(section *Section, days []SchoolDay) []SectionOccurrence {
+	occurrences := []SectionOccurrence{}
+
+	for _, day := range days {
+		if !day.Instructional {
+			continue
+		}
+
+		if len(section.Periods) == 0 {
+			for _, meeting := range section.Meetings {
+				if meeting.Weekday == day.Date.Weekday() {
+					occurrences = append(occurrences, SectionOccurrence{Date: day.Date, RotationDay: day.RotationDay, BellSchedule: day.BellSchedule, StartTime: meeting.StartTime, EndTime: meeting.EndTime})
+				}
+			}
+
+			continue
+		}
+
+		schedule := &BellSchedule{Periods: day.Periods}
+
+		for _, sectionPeriod := range section.Periods {
+			if sectionPeriod.Day != "" && !strings.EqualFold(sectionPeriod.Day, day.RotationDay) {
+				continue
+			}
+
+			if period, ok := schedule.Period(sectionPeriod.Period); ok {
+				occurrences = append(occurrences, SectionOccurrence{Date: day.Date, RotationDay: day.RotationDay, BellSchedule: day.BellSchedule, Period: period.Name, StartTime: period.StartTime, EndTime: period.EndTime})
+			}
+		}
+	}
+
+	slices.SortStableFunc(occurrences, func(a, b SectionOccurrence) int {
+		return cmp.Or(a.Date.Compare(b.Date.Time), strings.Compare(a.StartTime, b.StartTime))
+	})
+
+	return occurrences
+}
+
+// Checks that the section's periods name periods and rotation days the school has defined
+func checkSectionPeriods(ctx context.Context, bellStore BellStore, section *Section) error {
+	if len(section.Periods) == 0 {
+		return nil
+	}
+
+	schedules, err := bellStore.ListSchedules(ctx, section.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell schedules", "error", err)
+		return ErrInternal
+	}
+
+	rotation, err := bellStore.GetRotation(ctx, section.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to get bell rotation", "error", err)
+		return ErrInternal
+	}
+
+	for _, sectionPeriod := range section.Periods {
+		defined := slices.ContainsFunc(schedules, func(schedule *BellSchedule) bool {
+			_, ok := schedule.Period(sectionPeriod.Period)
+			return ok
+		})
+
+		if !defined {
+			return fmt.Errorf("period %s is not in any of the school's bell schedules", sectionPeriod.Period)
+		}
+
+		if sectionPeriod.Day == "" {
+			continue
+		}
+
+		onRotation := rotation != nil && slices.ContainsFunc(rotation.Days, func(day RotationDay) bool {
+			return strings.EqualFold(day.Name, sectionPeriod.Day)
+		})
+
+		if !onRotation {
+			return fmt.Errorf("%s is not a day of the school's rotation", sectionPeriod.Day)
+		}
+	}
+
+	return nil
+}
+
+type BellService struct {
+	bellStore     BellStore
+	calendarStore CalendarStore
+	sectionStore  SectionStore
+	schoolStore   SchoolStore
+	memberStore   OrganizationMemberStore
+	auditService  *AuditService
+}
+
+func NewBellService(bellStore BellStore, calendarStore CalendarStore, sectionStore SectionStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *BellService {
+	return &BellService{
+		bellStore:     bellStore,
+		calendarStore: calendarStore,
+		sectionStore:  sectionStore,
+		schoolStore:   schoolStore,
+		memberStore:   memberStore,
+		auditService:  auditService,
+	}
+}
+
+func (s *BellService) listSchedules(ctx context.Context, schoolID string) ([]*BellSchedule, error) {
+	schedules, err := s.bellStore.ListSchedules(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell schedules", "error", err)
+		return nil, ErrInternal
+	}
+
+	if schedules == nil {
+		schedules = []*BellSchedule{}
+	}
+
+	return schedules, nil
+}
+
+func (s *BellService) CreateSchedule(ctx context.Context, schedule *BellSchedule) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schedule.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.listSchedules(ctx, schedule.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateBellSchedule(schedule, existing); err != nil {
+		return err
+	}
+
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.bellStore.CreateSchedule(ctx, schedule); err != nil {
+		slog.Error("failed to create bell schedule", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "bell_schedule", schedule.ID, nil, schedule)
+
+	return nil
+}
+
+func (s *BellService) ListSchedules(ctx context.Context, schoolID string) ([]*BellSchedule, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	return s.listSchedules(ctx, schoolID)
+}
+
+// Returns the bell schedule and its school if the session user belongs to the school's
+// organization with one of the given roles
+func (s *BellService) authorizeSchedule(ctx context.Context, id string, roles ...string) (*BellSchedule, *School, error) {
+	schedule, err := s.bellStore.GetSchedule(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get bell schedule", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if schedule == nil {
+		return nil, nil, notFound("bell schedule")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schedule.SchoolID, roles...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schedule, school, nil
+}
+
+func (s *BellService) GetSchedule(ctx context.Context, id string) (*BellSchedule, error) {
+	schedule, _, err := s.authorizeSchedule(ctx, id)
+	return schedule, err
+}
+
+type UpdateBellScheduleRequest struct {
+	Name string `json:"name"`
+	// Replaces the periods when present
+	Periods []BellPeriod `json:"periods"`
+}
+
+// Updates the schedule's name or periods. Sections meeting in a removed period no longer
+// meet on days that run this schedule.
+func (s *BellService) UpdateSchedule(ctx context.Context, id string, request *UpdateBellScheduleRequest) (*BellSchedule, error) {
+	schedule, school, err := s.authorizeSchedule(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *schedule
+
+	if request.Name != "" {
+		schedule.Name = request.Name
+	}
+
+	if request.Periods != nil {
+		schedule.Periods = request.Periods
+	}
+
+	existing, err := s.listSchedules(ctx, schedule.SchoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBellSchedule(schedule, existing); err != nil {
+		return nil, err
+	}
+
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.bellStore.UpdateSchedule(ctx, schedule); err != nil {
+		slog.Error("failed to update bell schedule", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "bell_schedule", id, &before, schedule)
+
+	return schedule, nil
+}
+
+// Deletes a bell schedule that neither the rotation nor an override runs
+func (s *BellService) DeleteSchedule(ctx context.Context, id string) error {
+	schedule, school, err := s.authorizeSchedule(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	rotation, err := s.bellStore.GetRotation(ctx, schedule.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to get bell rotation", "error", err)
+		return ErrInternal
+	}
+
+	if rotation != nil {
+		for _, day := range rotation.Days {
+			if day.BellScheduleID == id {
+				return fmt.Errorf("bell schedule is used by rotation day %s", day.Name)
+			}
+		}
+	}
+
+	overrides, err := s.bellStore.ListOverrides(ctx, schedule.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell overrides", "error", err)
+		return ErrInternal
+	}
+
+	for _, override := range overrides {
+		if override.BellScheduleID == id {
+			return fmt.Errorf("bell schedule is used by the override on %s", override.Date)
+		}
+	}
+
+	if err := s.bellStore.DeleteSchedule(ctx, id); err != nil {
+		slog.Error("failed to delete bell schedule", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "bell_schedule", id, schedule, nil)
+
+	return nil
+}
+
+// Returns the school's rotation, or an empty one if it has not set one
+func (s *BellService) GetRotation(ctx context.Context, schoolID string) (*BellRotation, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	rotation, err := s.bellStore.GetRotation(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to get bell rotation", "error", err)
+		return nil, ErrInternal
+	}
+
+	if rotation == nil {
+		rotation = &BellRotation{SchoolID: schoolID, Days: []RotationDay{}}
+	}
+
+	return rotation, nil
+}
+
+// Replaces the school's rotation. Sections meeting on a removed rotation day no longer meet.
+func (s *BellService) SaveRotation(ctx context.Context, rotation *BellRotation) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, rotation.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if rotation.Days == nil {
+		rotation.Days = []RotationDay{}
+	}
+
+	schedules, err := s.listSchedules(ctx, rotation.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateBellRotation(rotation, schedules); err != nil {
+		return err
+	}
+
+	before, err := s.bellStore.GetRotation(ctx, rotation.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to get bell rotation", "error", err)
+		return ErrInternal
+	}
+
+	rotation.UpdatedAt = time.Now()
+
+	if err := s.bellStore.SaveRotation(ctx, rotation); err != nil {
+		slog.Error("failed to save bell rotation", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "bell_rotation", rotation.SchoolID, before, rotation)
+
+	return nil
+}
+
+func (s *BellService) CreateOverride(ctx context.Context, override *BellOverride) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, override.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	override.Name = strings.TrimSpace(override.Name)
+
+	if override.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if override.Date.IsZero() {
+		return errors.New("date is required")
+	}
+
+	schedule, err := s.bellStore.GetSchedule(ctx, override.BellScheduleID)
+
+	if err != nil {
+		slog.Error("failed to get bell schedule", "error", err)
+		return ErrInternal
+	}
+
+	if schedule == nil || schedule.SchoolID != override.SchoolID {
+		return notFound("bell schedule")
+	}
+
+	overrides, err := s.bellStore.ListOverrides(ctx, override.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell overrides", "error", err)
+		return ErrInternal
+	}
+
+	for _, other := range overrides {
+		if other.Date.Equal(override.Date.Time) {
+			return fmt.Errorf("%s already runs the %s override", override.Date, other.Name)
+		}
+	}
+
+	override.CreatedAt = time.Now()
+
+	if err := s.bellStore.CreateOverride(ctx, override); err != nil {
+		slog.Error("failed to create bell override", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "bell_override", override.ID, nil, override)
+
+	return nil
+}
+
+func (s *BellService) ListOverrides(ctx context.Context, schoolID string) ([]*BellOverride, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.bellStore.ListOverrides(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list bell overrides", "error", err)
+		return nil, ErrInternal
+	}
+
+	if overrides == nil {
+		overrides = []*BellOverride{}
+	}
+
+	return overrides, nil
+}
+
+func (s *BellService) DeleteOverride(ctx context.Context, id string) error {
+	override, err := s.bellStore.GetOverride(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get bell override", "error", err)
+		return ErrInternal
+	}
+
+	if override == nil {
+		return notFound("bell override")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, override.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.bellStore.DeleteOverride(ctx, id); err != nil {
+		slog.Error("failed to delete bell override", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "bell_override", id, override, nil)
+
+	return nil
+}
+
+// Resolves the school's days between from and to, inclusive
+func (s *BellService) Days(ctx context.Context, schoolID string, from Date, to Date) ([]SchoolDay, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	if to.Sub(from.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return nil, fmt.Errorf("range must not exceed %d days", maxInstructionalDaysRange)
+	}
+
+	calendar, err := loadBellCalendar(ctx, s.bellStore, s.calendarStore, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar.days(from, to), nil
+}
+
+// Returns when the section meets between from and to, inclusive, within its term
+func (s *BellService) SectionTimetable(ctx context.Context, sectionID string, from Date, to Date) ([]SectionOccurrence, error) {
+	section, err := s.sectionStore.GetByID(ctx, sectionID)
+
+	if err != nil {
+		slog.Error("failed to get section", "error", err)
+		return nil, ErrInternal
+	}
+
+	if section == nil {
+		return nil, notFound("section")
+	}
+
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, section.SchoolID); err != nil {
+		return nil, err
+	}
+
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	if to.Sub(from.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return nil, fmt.Errorf("range must not exceed %d days", maxInstructionalDaysRange)
+	}
+
+	term, err := s.calendarStore.GetTerm(ctx, section.TermID)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return nil, ErrInternal
+	}
+
+	if term == nil || !datesOverlap(from, to, term.StartDate, term.EndDate) {
+		return []SectionOccurrence{}, nil
+	}
+
+	if from.Before(term.StartDate.Time) {
+		from = term.StartDate
+	}
+
+	if to.After(term.EndDate.Time) {
+		to = term.EndDate
+	}
+
+	calendar, err := loadBellCalendar(ctx, s.bellStore, s.calendarStore, section.SchoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sectionOccurrences(section, calendar.days(from, to)), nil
+}
+
+type BellHandler struct {
+	bellService *BellService
+}
+
+func (h *BellHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule BellSchedule
+
+	if err := decodeJSON(r, &schedule); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	schedule.ID = ""
+	schedule.SchoolID = r.PathValue("id")
+
+	if err := h.bellService.CreateSchedule(r.Context(), &schedule); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, schedule)
+}
+
+func (h *BellHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.bellService.ListSchedules(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+func (h *BellHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.bellService.GetSchedule(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *BellHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	var request UpdateBellScheduleRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	schedule, err := h.bellService.UpdateSchedule(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *BellHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := h.bellService.DeleteSchedule(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BellHandler) GetRotation(w http.ResponseWriter, r *http.Request) {
+	rotation, err := h.bellService.GetRotation(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rotation)
+}
+
+func (h *BellHandler) SaveRotation(w http.ResponseWriter, r *http.Request) {
+	var rotation BellRotation
+
+	if err := decodeJSON(r, &rotation); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rotation.SchoolID = r.PathValue("id")
+
+	if err := h.bellService.SaveRotation(r.Context(), &rotation); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rotation)
+}
+
+func (h *BellHandler) CreateOverride(w http.ResponseWriter, r *http.Request) {
+	var override BellOverride
+
+	if err := decodeJSON(r, &override); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	override.ID = ""
+	override.SchoolID = r.PathValue("id")
+
+	if err := h.bellService.CreateOverride(r.Context(), &override); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, override)
+}
+
+func (h *BellHandler) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := h.bellService.ListOverrides(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, overrides)
+}
+
+func (h *BellHandler) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	if err := h.bellService.DeleteOverride(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Resolves the school's days between the from and to query parameters, defaulting to today
+func (h *BellHandler) Days(w http.ResponseWriter, r *http.Request) {
+	today := DateOf(time.Now())
+	from, err := dateQueryParam(r, "from", today)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := dateQueryParam(r, "to", from)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	days, err := h.bellService.Days(r.Context(), r.PathValue("id"), from, to)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, days)
+}
+
+// Lists when the section meets between the from and to query parameters
+func (h *BellHandler) SectionTimetable(w http.ResponseWriter, r *http.Request) {
+	from, err := dateQueryParam(r, "from", Date{})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := dateQueryParam(r, "to", Date{})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	occurrences, err := h.bellService.SectionTimetable(r.Context(), r.PathValue("id"), from, to)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, occurrences)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockBellStore struct {
+	CreateScheduleFunc func(ctx context.Context, schedule *BellSchedule) error
+	GetScheduleFunc    func(ctx context.Context, id string) (*BellSchedule, error)
+	ListSchedulesFunc  func(ctx context.Context, schoolID string) ([]*BellSchedule, error)
+	UpdateScheduleFunc func(ctx context.Context, schedule *BellSchedule) error
+	DeleteScheduleFunc func(ctx context.Context, id string) error
+	GetRotationFunc    func(ctx context.Context, schoolID string) (*BellRotation, error)
+	SaveRotationFunc   func(ctx context.Context, rotation *BellRotation) error
+	CreateOverrideFunc func(ctx context.Context, override *BellOverride) error
+	GetOverrideFunc    func(ctx context.Context, id string) (*BellOverride, error)
+	ListOverridesFunc  func(ctx context.Context, schoolID string) ([]*BellOverride, error)
+	DeleteOverrideFunc func(ctx context.Context, id string) error
+}
+
+func (m *MockBellStore) CreateSchedule(ctx context.Context, schedule *BellSchedule) error {
+	if m.CreateScheduleFunc != nil {
+		return m.CreateScheduleFunc(ctx, schedule)
+	}
+
+	return nil
+}
+
+func (m *MockBellStore) GetSchedule(ctx context.Context, id string) (*BellSchedule, error) {
+	if m.GetScheduleFunc != nil {
+		return m.GetScheduleFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockBellStore) ListSchedules(ctx context.Context, schoolID string) ([]*BellSchedule, error) {
+	if m.ListSchedulesFunc != nil {
+		return m.ListSchedulesFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockBellStore) UpdateSchedule(ctx context.Context, schedule *BellSchedule) error {
+	if m.UpdateScheduleFunc != nil {
+		return m.UpdateScheduleFunc(ctx, schedule)
+	}
+
+	return nil
+}
+
+func (m *MockBellStore) DeleteSchedule(ctx context.Context, id string) error {
+	if m.DeleteScheduleFunc != nil {
+		return m.DeleteScheduleFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockBellStore) GetRotation(ctx context.Context, schoolID string) (*BellRotation, error) {
+	if m.GetRotationFunc != nil {
+		return m.GetRotationFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockBellStore) SaveRotation(ctx context.Context, rotation *BellRotation) error {
+	if m.SaveRotationFunc != nil {
+		return m.SaveRotationFunc(ctx, rotation)
+	}
+
+	return nil
+}
+
+func (m *MockBellStore) CreateOverride(ctx context.Context, override *BellOverride) error {
+	if m.CreateOverrideFunc != nil {
+		return m.CreateOverrideFunc(ctx, override)
+	}
+
+	return nil
+}
+
+func (m *MockBellStore) GetOverride(ctx context.Context, id string) (*BellOverride, error) {
+	if m.GetOverrideFunc != nil {
+		return m.GetOverrideFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockBellStore) ListOverrides(ctx context.Context, schoolID string) ([]*BellOverride, error) {
+	if m.ListOverridesFunc != nil {
+		return m.ListOverridesFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockBellStore) DeleteOverride(ctx context.Context, id string) error {
+	if m.DeleteOverrideFunc != nil {
+		return m.DeleteOverrideFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// A school alternating A and B days on its regular schedule from Monday, October 6th 2025,
+// with an early release on Wednesday the 8th
+func abRotation() *MockBellStore {
+	schedules := []*BellSchedule{
+		{ID: "regular", SchoolID: "school", Name: "Regular", Periods: []BellPeriod{
+			{Name: "1", StartTime: "08:00", EndTime: "09:30"},
+			{Name: "2", StartTime: "09:40", EndTime: "11:10"},
+		}},
+		{ID: "early", SchoolID: "school", Name: "Early release", Periods: []BellPeriod{
+			{Name: "1", StartTime: "08:00", EndTime: "08:50"},
+			{Name: "2", StartTime: "09:00", EndTime: "09:50"},
+		}},
+	}
+
+	return &MockBellStore{
+		GetScheduleFunc: func(ctx context.Context, id string) (*BellSchedule, error) {
+			for _, schedule := range schedules {
+				if schedule.ID == id {
+					return schedule, nil
+				}
+			}
+
+			return nil, nil
+		},
+		ListSchedulesFunc: func(ctx context.Context, schoolID string) ([]*BellSchedule, error) {
+			return schedules, nil
+		},
+		GetRotationFunc: func(ctx context.Context, schoolID string) (*BellRotation, error) {
+			return &BellRotation{SchoolID: schoolID, StartDate: schoolDay, Days: []RotationDay{{Name: "A", BellScheduleID: "regular"}, {Name: "B", BellScheduleID: "regular"}}}, nil
+		},
+		ListOverridesFunc: func(ctx context.Context, schoolID string) ([]*BellOverride, error) {
+			return []*BellOverride{{ID: "override", SchoolID: schoolID, Date: schoolDay.AddDays(2), BellScheduleID: "early", Name: "Conferences"}}, nil
+		},
+	}
+}
+
+// A section meeting in period 1 on A days and period 2 on B days
+func rotatingSection() *MockSectionStore {
+	return &MockSectionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Section, error) {
+			return &Section{ID: id, CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30, Periods: []SectionPeriod{{Day: "A", Period: "1"}, {Day: "B", Period: "2"}}}, nil
+		},
+	}
+}
+
+func TestBellService_Days_RotatesAcrossInstructionalDays(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	days, err := bellService.Days(sessionContext("student"), "school", schoolDay, schoolDay.AddDays(8))
+
+	assert.NoError(t, err)
+	assert.Len(t, days, 9)
+
+	rotation := []string{}
+
+	for _, day := range days {
+		rotation = append(rotation, day.RotationDay)
+	}
+
+	// The weekend and the holiday on the 13th do not advance the rotation
+	assert.Equal(t, []string{"A", "B", "A", "B", "A", "", "", "", "B"}, rotation)
+	assert.False(t, days[7].Instructional)
+	assert.Equal(t, "Regular", days[0].BellSchedule)
+	assert.Empty(t, days[5].Periods)
+}
+
+func TestBellService_Days_AppliesOverrideWithoutChangingRotationDay(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	days, err := bellService.Days(sessionContext("student"), "school", schoolDay.AddDays(2), schoolDay.AddDays(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "A", days[0].RotationDay)
+	assert.Equal(t, "Conferences", days[0].Override)
+	assert.Equal(t, "early", days[0].BellScheduleID)
+	assert.Equal(t, "08:50", days[0].Periods[0].EndTime)
+}
+
+func TestBellService_Days_CountsRotationFromStartDate(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	days, err := bellService.Days(sessionContext("student"), "school", schoolDay.AddDays(8), schoolDay.AddDays(9))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "B", days[0].RotationDay)
+	assert.Equal(t, "A", days[1].RotationDay)
+}
+
+func TestBellService_Days_ReturnsNoRotationDayBeforeStartDate(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	days, err := bellService.Days(sessionContext("student"), "school", schoolDay.AddDays(-3), schoolDay)
+
+	assert.NoError(t, err)
+	assert.True(t, days[0].Instructional)
+	assert.Empty(t, days[0].RotationDay)
+	assert.Empty(t, days[0].BellScheduleID)
+	assert.Equal(t, "A", days[3].RotationDay)
+}
+
+func TestBellService_SectionTimetable_ResolvesPeriodsToTimes(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	occurrences, err := bellService.SectionTimetable(sessionContext("student"), "section", schoolDay, schoolDay.AddDays(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []SectionOccurrence{
+		{Date: schoolDay, RotationDay: "A", BellSchedule: "Regular", Period: "1", StartTime: "08:00", EndTime: "09:30"},
+		{Date: schoolDay.AddDays(1), RotationDay: "B", BellSchedule: "Regular", Period: "2", StartTime: "09:40", EndTime: "11:10"},
+		{Date: schoolDay.AddDays(2), RotationDay: "A", BellSchedule: "Early release", Period: "1", StartTime: "08:00", EndTime: "08:50"},
+	}, occurrences)
+}
+
+func TestBellService_SectionTimetable_ClipsToTerm(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	occurrences, err := bellService.SectionTimetable(sessionContext("student"), "section", NewDate(2026, time.January, 16), NewDate(2026, time.February, 16))
+
+	assert.NoError(t, err)
+	assert.Len(t, occurrences, 1)
+	assert.Equal(t, NewDate(2026, time.January, 16), occurrences[0].Date)
+}
+
+func TestSectionOccurrences_FallsBackToWeeklyMeetings(t *testing.T) {
+	section := &Section{Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "10:00", EndTime: "10:50"}}}
+	days := []SchoolDay{{Date: schoolDay, Instructional: true}, {Date: schoolDay.AddDays(1), Instructional: true}, {Date: holiday}}
+
+	occurrences := sectionOccurrences(section, days)
+
+	assert.Equal(t, []SectionOccurrence{{Date: schoolDay, StartTime: "10:00", EndTime: "10:50"}}, occurrences)
+}
+
+func TestValidateBellSchedule_SortsPeriodsByStartTime(t *testing.T) {
+	schedule := &BellSchedule{Name: " Block ", Periods: []BellPeriod{
+		{Name: "2", StartTime: "10:00", EndTime: "11:30"},
+		{Name: "1", StartTime: "08:00", EndTime: "09:30"},
+	}}
+
+	err := validateBellSchedule(schedule, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Block", schedule.Name)
+	assert.Equal(t, "1", schedule.Periods[0].Name)
+}
+
+func TestValidateBellSchedule_ReturnsErrorForOverlappingPeriods(t *testing.T) {
+	err := validateBellSchedule(&BellSchedule{Name: "Regular", Periods: []BellPeriod{
+		{Name: "1", StartTime: "08:00", EndTime: "09:00"},
+		{Name: "2", StartTime: "08:55", EndTime: "10:00"},
+	}}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "periods 1 and 2 overlap", err.Error())
+}
+
+func TestValidateBellSchedule_ReturnsErrorForDuplicateName(t *testing.T) {
+	err := validateBellSchedule(&BellSchedule{Name: "regular", Periods: []BellPeriod{{Name: "1", StartTime: "08:00", EndTime: "09:00"}}}, []*BellSchedule{{ID: "regular", Name: "Regular"}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "the school already has a bell schedule named Regular", err.Error())
+}
+
+func TestValidateBellRotation_ReturnsErrorForScheduleOfOtherSchool(t *testing.T) {
+	err := validateBellRotation(&BellRotation{StartDate: schoolDay, Days: []RotationDay{{Name: "A", BellScheduleID: "elsewhere"}}}, []*BellSchedule{{ID: "regular"}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "bell schedule elsewhere is not a bell schedule of this school", err.Error())
+}
+
+func TestBellService_CreateSchedule_ReturnsErrorForTeacher(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(&MockBellStore{
+		CreateScheduleFunc: func(ctx context.Context, schedule *BellSchedule) error {
+			t.Fatal("bell schedule should not be created")
+			return nil
+		},
+	}, calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := bellService.CreateSchedule(sessionContext("teacher"), &BellSchedule{SchoolID: "school", Name: "Regular", Periods: []BellPeriod{{Name: "1", StartTime: "08:00", EndTime: "09:00"}}})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestBellService_DeleteSchedule_ReturnsErrorWhenRotationUsesIt(t *testing.T) {
+	bellStore := abRotation()
+	bellStore.DeleteScheduleFunc = func(ctx context.Context, id string) error {
+		t.Fatal("bell schedule should not be deleted")
+		return nil
+	}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewBellService(bellStore, calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).DeleteSchedule(sessionContext("admin"), "regular")
+
+	assert.Error(t, err)
+	assert.Equal(t, "bell schedule is used by rotation day A", err.Error())
+}
+
+func TestBellService_CreateOverride_ReturnsErrorForDateWithOverride(t *testing.T) {
+	_, calendarStore := attendanceFixtures()
+	bellService := NewBellService(abRotation(), calendarStore, rotatingSection(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := bellService.CreateOverride(sessionContext("admin"), &BellOverride{SchoolID: "school", Date: schoolDay.AddDays(2), BellScheduleID: "early", Name: "Snow delay"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "2025-10-08 already runs the Conferences override", err.Error())
+}
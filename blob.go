@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultBlobRoot = "data/blobs"
+
+var (
+	errBlobChecksumMismatch = errors.New("blob does not match its checksum")
+	errBlobTooLarge         = errors.New("file is too large")
+)
+
+// Describes a stored blob. Checksum is the hex encoded SHA-256 of the contents.
+type BlobMetadata struct {
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	Checksum    string `json:"checksum"`
+}
+
+// Stores file contents under slash separated keys such as "submissions/<id>/<name>".
+// Use putBlob rather than calling Put directly, so contents are size checked, sniffed and
+// checksummed the same way whichever backend is in use.
+type BlobStore interface {
+	// Stores body, which must match meta's size and checksum or nothing is stored
+	Put(ctx context.Context, key string, body io.Reader, meta *BlobMetadata) error
+	// Returns nil if there is no blob with the key. Reading the body fails with
+	// errBlobChecksumMismatch if the contents no longer match their checksum.
+	Get(ctx context.Context, key string) (io.ReadCloser, *BlobMetadata, error)
+	// Deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// Returns a URL anyone can download the blob from until expiresAt
+	SignedURL(ctx context.Context, key string, expiresAt time.Time) (string, error)
+}
+
+// Returns the configured blob store. DIVINITY_BLOB_BACKEND picks "local" (the default), which
+// keeps blobs below DIVINITY_BLOB_ROOT, or "s3", configured by the DIVINITY_S3_* variables.
+func blobStoreFromEnv(signingKey []byte) (BlobStore, error) {
+	switch backend := os.Getenv("DIVINITY_BLOB_BACKEND"); backend {
+	case "", "local":
+		root := os.Getenv("DIVINITY_BLOB_ROOT")
+
+		if root == "" {
+			slog.Warn("DIVINITY_BLOB_ROOT is not set, storing blobs in " + defaultBlobRoot)
+			root = defaultBlobRoot
+		}
+
+		return NewLocalBlobStore(root, signingKey), nil
+	case "s3":
+		return NewS3BlobStore(&S3Config{
+			Endpoint:        os.Getenv("DIVINITY_S3_ENDPOINT"),
+			Region:          os.Getenv("DIVINITY_S3_REGION"),
+			Bucket:          os.Getenv("DIVINITY_S3_BUCKET"),
+			AccessKeyID:     os.Getenv("DIVINITY_S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("DIVINITY_S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", backend)
+	}
+}
+
+// Returns a random identifier for use in blob keys
+func newBlobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// Content types that say nothing about the contents, so a declared type is preferred over them
+func isGenericContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/octet-stream" || mediaType == "text/plain"
+}
+
+// Picks the content type to store. The sniffed type wins unless it is generic, since
+// clients routinely send wrong or missing types.
+func blobContentType(declared string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+
+	if declared != "" && isGenericContentType(sniffed) {
+		if _, _, err := mime.ParseMediaType(declared); err == nil {
+			return declared
+		}
+	}
+
+	return sniffed
+}
+
+// Stores at most limit bytes of body under key. The contents are spooled to a temporary file
+// first so their size, type and checksum are known before the backend sees them.
+func putBlob(ctx context.Context, store BlobStore, key string, body io.Reader, declaredType string, limit int64) (*BlobMetadata, error) {
+	file, err := os.CreateTemp("", "divinity-blob-*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(body, limit+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if size > limit {
+		return nil, fmt.Errorf("%w, the limit is %s", errBlobTooLarge, formatByteSize(limit))
+	}
+
+	head := make([]byte, 512)
+	n, err := file.ReadAt(head, 0)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	meta := &BlobMetadata{
+		Size:        size,
+		ContentType: blobContentType(declaredType, head[:n]),
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if err := store.Put(ctx, key, file, meta); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// Formats a byte count as "25 MB", "512 KB" or "100 bytes"
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
+
+// Checks the contents read through it against a checksum once the end is reached
+type checksumReader struct {
+	body     io.ReadCloser
+	expected string
+	digest   interface {
+		io.Writer
+		Sum([]byte) []byte
+	}
+}
+
+// Wraps body so reading it to the end fails if it does not match meta's checksum. Blobs
+// without a checksum are passed through unchecked.
+func verifyBlob(body io.ReadCloser, meta *BlobMetadata) io.ReadCloser {
+	if meta.Checksum == "" {
+		return body
+	}
+
+	return &checksumReader{body: body, expected: meta.Checksum, digest: sha256.New()}
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.digest.Write(p[:n])
+
+	if errors.Is(err, io.EOF) && hex.EncodeToString(r.digest.Sum(nil)) != r.expected {
+		return n, errBlobChecksumMismatch
+	}
+
+	return n, err
+}
+
+func (r *checksumReader) Close() error {
+	return r.body.Close()
+}
+
+// Stores blobs as files below a directory on the local disk, each with a ".meta" file
+// holding its metadata. Signed URLs point at BlobHandler.
+type LocalBlobStore struct {
+	root       string
+	signingKey []byte
+}
+
+func NewLocalBlobStore(root string, signingKey []byte) *LocalBlobStore {
+	return &LocalBlobStore{root: root, signingKey: signingKey}
+}
+
+// Returns the file path for key, refusing keys that would escape the root or clash with
+// the store's own files
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || !fs.ValidPath(key) || strings.HasSuffix(key, ".meta") {
+		return "", errors.New("invalid blob key")
+	}
+
+	for _, element := range strings.Split(key, "/") {
+		if strings.HasPrefix(element, ".") {
+			return "", errors.New("invalid blob key")
+		}
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Writes the blob to a temporary file first so readers never see a partial blob
+func (s *LocalBlobStore) Put(ctx context.Context, key string, body io.Reader, meta *BlobMetadata) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), body)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if size != meta.Size || hex.EncodeToString(hash.Sum(nil)) != meta.Checksum {
+		return errBlobChecksumMismatch
+	}
+
+	metadata, err := json.Marshal(meta)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".meta", metadata, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, *BlobMetadata, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var meta BlobMetadata
+
+	data, err := os.ReadFile(path + ".meta")
+
+	switch {
+	case err == nil:
+		err = json.Unmarshal(data, &meta)
+	case errors.Is(err, fs.ErrNotExist):
+		// Blobs written before metadata was kept have no checksum to verify
+		err = nil
+		meta.ContentType = "application/octet-stream"
+
+		if info, statErr := file.Stat(); statErr == nil {
+			meta.Size = info.Size()
+		}
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return verifyBlob(file, &meta), &meta, nil
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{path, path + ".meta"} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *LocalBlobStore) SignedURL(ctx context.Context, key string, expiresAt time.Time) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	return signURL(s.signingKey, "/blobs/"+key, expiresAt), nil
+}
+
+// Serves blobs of the local store through the links it signs
+type BlobHandler struct {
+	blobStore  BlobStore
+	signingKey []byte
+}
+
+func (h *BlobHandler) Download(w http.ResponseWriter, r *http.Request) {
+	if err := verifySignedURL(h.signingKey, r, time.Now()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	key := r.PathValue("key")
+	body, meta, err := h.blobStore.Get(r.Context(), key)
+
+	if err != nil {
+		slog.Error("failed to get blob", "error", err)
+		writeError(w, ErrInternal)
+		return
+	}
+
+	if body == nil {
+		writeError(w, notFound("file"))
+		return
+	}
+
+	defer body.Close()
+
+	serveBlob(w, body, meta, path.Base(key))
+}
+
+// Writes a blob as a file download
+func serveBlob(w http.ResponseWriter, body io.Reader, meta *BlobMetadata, filename string) {
+	w.Header().Set("Content-Type", meta.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if meta.Size > 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(meta.Size))
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so a checksum failure can only cut the download short
+	if _, err := io.Copy(w, body); errors.Is(err, errBlobChecksumMismatch) {
+		slog.Error("blob failed checksum verification", "filename", filename)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Keeps blobs in memory. Signed URLs are signed with testSigningKey like the local store's.
+type MockBlobStore struct {
+	blobs  map[string][]byte
+	meta   map[string]*BlobMetadata
+	PutErr error
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, body io.Reader, meta *BlobMetadata) error {
+	if m.PutErr != nil {
+		return m.PutErr
+	}
+
+	data, err := io.ReadAll(body)
+
+	if err != nil {
+		return err
+	}
+
+	if m.blobs == nil {
+		m.blobs = map[string][]byte{}
+		m.meta = map[string]*BlobMetadata{}
+	}
+
+	m.blobs[key] = data
+	m.meta[key] = meta
+
+	return nil
+}
+
+func (m *MockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, *BlobMetadata, error) {
+	data, ok := m.blobs[key]
+
+	if !ok {
+		return nil, nil, nil
+	}
+
+	return verifyBlob(io.NopCloser(bytes.NewReader(data)), m.meta[key]), m.meta[key], nil
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	delete(m.blobs, key)
+	delete(m.meta, key)
+
+	return nil
+}
+
+func (m *MockBlobStore) SignedURL(ctx context.Context, key string, expiresAt time.Time) (string, error) {
+	return signURL(testSigningKey, "/blobs/"+key, expiresAt), nil
+}
+
+func TestPutBlob_SniffsContentTypeAndChecksums(t *testing.T) {
+	blobs := &MockBlobStore{}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	meta, err := putBlob(context.Background(), blobs, "photo", bytes.NewReader(png), "text/html", 1<<10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", meta.ContentType)
+	assert.Equal(t, int64(len(png)), meta.Size)
+	assert.Equal(t, sha256Hex(string(png)), meta.Checksum)
+}
+
+func TestPutBlob_KeepsDeclaredTypeWhenSniffingIsInconclusive(t *testing.T) {
+	meta, err := putBlob(context.Background(), &MockBlobStore{}, "roster", strings.NewReader("a,b\n1,2\n"), "text/csv", 1<<10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv", meta.ContentType)
+}
+
+func TestPutBlob_ReturnsErrorOverLimit(t *testing.T) {
+	blobs := &MockBlobStore{}
+
+	_, err := putBlob(context.Background(), blobs, "big", strings.NewReader(strings.Repeat("x", 2049)), "", 2<<10)
+
+	assert.ErrorIs(t, err, errBlobTooLarge)
+	assert.Equal(t, "file is too large, the limit is 2 KB", err.Error())
+	assert.Empty(t, blobs.blobs)
+}
+
+func TestLocalBlobStore_PutGetDelete(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir(), testSigningKey)
+	ctx := context.Background()
+
+	_, err := putBlob(ctx, store, "submissions/a/b/essay", strings.NewReader("hello"), "", 1<<10)
+	assert.NoError(t, err)
+
+	body, meta, err := store.Get(ctx, "submissions/a/b/essay")
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(body)
+	body.Close()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, "text/plain; charset=utf-8", meta.ContentType)
+	assert.NoError(t, store.Delete(ctx, "submissions/a/b/essay"))
+	assert.NoError(t, store.Delete(ctx, "submissions/a/b/essay"))
+
+	body, _, err = store.Get(ctx, "submissions/a/b/essay")
+	assert.NoError(t, err)
+	assert.Nil(t, body)
+}
+
+func TestLocalBlobStore_Put_RejectsContentNotMatchingChecksum(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir(), testSigningKey)
+
+	err := store.Put(context.Background(), "essay", strings.NewReader("hello"), &BlobMetadata{Size: 5, Checksum: sha256Hex("jello")})
+
+	assert.ErrorIs(t, err, errBlobChecksumMismatch)
+
+	body, _, _ := store.Get(context.Background(), "essay")
+	assert.Nil(t, body)
+}
+
+func TestLocalBlobStore_Get_DetectsCorruptedBlob(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocalBlobStore(root, testSigningKey)
+
+	_, err := putBlob(context.Background(), store, "essay", strings.NewReader("hello"), "", 1<<10)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "essay"), []byte("jello"), 0o644))
+
+	body, _, err := store.Get(context.Background(), "essay")
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(body)
+	assert.ErrorIs(t, err, errBlobChecksumMismatch)
+}
+
+func TestLocalBlobStore_RejectsKeysOutsideRoot(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir(), testSigningKey)
+
+	for _, key := range []string{"../escape", "/etc/passwd", "a/../../b", "", `a\..\b`, "essay.meta", "a/.upload-1"} {
+		err := store.Put(context.Background(), key, strings.NewReader(""), &BlobMetadata{Checksum: sha256Hex("")})
+
+		assert.Error(t, err, key)
+	}
+}
+
+func TestBlobHandler_Download_ServesSignedBlob(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir(), testSigningKey)
+	handler := &BlobHandler{blobStore: store, signingKey: testSigningKey}
+
+	_, err := putBlob(context.Background(), store, "exports/1.zip", strings.NewReader("archive"), "application/zip", 1<<10)
+	assert.NoError(t, err)
+
+	url, err := store.SignedURL(context.Background(), "exports/1.zip", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /blobs/{key...}", handler.Download)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=1.zip`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "archive", w.Body.String())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/blobs/exports/1.zip", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,952 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	TermTypeSemester      = "semester"
+	TermTypeTrimester     = "trimester"
+	TermTypeQuarter       = "quarter"
+	TermTypeGradingPeriod = "grading_period"
+)
+
+var termTypes = []string{TermTypeSemester, TermTypeTrimester, TermTypeQuarter, TermTypeGradingPeriod}
+
+const (
+	CalendarEventHoliday          = "holiday"
+	CalendarEventNonInstructional = "non_instructional"
+)
+
+var calendarEventTypes = []string{CalendarEventHoliday, CalendarEventNonInstructional}
+
+// The longest range the instructional days endpoint will walk
+const maxInstructionalDaysRange = 366 * 2
+
+type AcademicYear struct {
+	ID        string    `json:"id"`
+	SchoolID  string    `json:"schoolId"`
+	Name      string    `json:"name"`
+	StartDate Date      `json:"startDate"`
+	EndDate   Date      `json:"endDate"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Term struct {
+	ID             string `json:"id"`
+	SchoolID       string `json:"schoolId"`
+	AcademicYearID string `json:"academicYearId"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	StartDate      Date   `json:"startDate"`
+	EndDate        Date   `json:"endDate"`
+	// When students may enroll in the term's sections. An unset start is always open and an
+	// unset end closes with the term.
+	EnrollmentStartDate Date      `json:"enrollmentStartDate"`
+	EnrollmentEndDate   Date      `json:"enrollmentEndDate"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// A holiday or other day without instruction. Spans StartDate to EndDate inclusive.
+type CalendarEvent struct {
+	ID        string    `json:"id"`
+	SchoolID  string    `json:"schoolId"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	StartDate Date      `json:"startDate"`
+	EndDate   Date      `json:"endDate"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CalendarPostgresStore struct {
+	db *PostgresDB
+}
+
+type CalendarStore interface {
+	CreateAcademicYear(ctx context.Context, year *AcademicYear) error
+	GetAcademicYear(ctx context.Context, id string) (*AcademicYear, error)
+	ListAcademicYears(ctx context.Context, schoolID string) ([]*AcademicYear, error)
+	DeleteAcademicYear(ctx context.Context, id string) error
+	CreateTerm(ctx context.Context, term *Term) error
+	GetTerm(ctx context.Context, id string) (*Term, error)
+	ListTerms(ctx context.Context, schoolID string) ([]*Term, error)
+	DeleteTerm(ctx context.Context, id string) error
+	// Reports whether sections or academic documents still belong to the term
+	TermInUse(ctx context.Context, id string) (bool, error)
+	CreateEvent(ctx context.Context, event *CalendarEvent) error
+	GetEvent(ctx context.Context, id string) (*CalendarEvent, error)
+	ListEvents(ctx context.Context, schoolID string) ([]*CalendarEvent, error)
+	DeleteEvent(ctx context.Context, id string) error
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAcademicYear(row rowScanner) (*AcademicYear, error) {
+	var year AcademicYear
+
+	if err := row.Scan(&year.ID, &year.SchoolID, &year.Name, &year.StartDate, &year.EndDate, &year.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &year, nil
+}
+
+func scanTerm(row rowScanner) (*Term, error) {
+	var term Term
+
+	if err := row.Scan(&term.ID, &term.SchoolID, &term.AcademicYearID, &term.Name, &term.Type, &term.StartDate, &term.EndDate, &term.EnrollmentStartDate, &term.EnrollmentEndDate, &term.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &term, nil
+}
+
+func scanCalendarEvent(row rowScanner) (*CalendarEvent, error) {
+	var event CalendarEvent
+
+	if err := row.Scan(&event.ID, &event.SchoolID, &event.Name, &event.Type, &event.StartDate, &event.EndDate, &event.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// Returns nil, nil when the error is sql.ErrNoRows, matching how stores report missing rows
+func noRowsAsNil[T any](value *T, err error) (*T, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return value, err
+}
+
+func (s *CalendarPostgresStore) CreateAcademicYear(ctx context.Context, year *AcademicYear) error {
+	query := `
+		INSERT INTO academic_years (school_id, name, start_date, end_date, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	return s.db.pool.QueryRow(ctx, query, year.SchoolID, year.Name, year.StartDate, year.EndDate, year.CreatedAt).Scan(&year.ID)
+}
+
+func (s *CalendarPostgresStore) GetAcademicYear(ctx context.Context, id string) (*AcademicYear, error) {
+	query := `
+		SELECT id, school_id, name, start_date, end_date, created_at
+		FROM academic_years
+		WHERE id = $1
+	`
+
+	return noRowsAsNil(scanAcademicYear(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *CalendarPostgresStore) ListAcademicYears(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+	query := `
+		SELECT id, school_id, name, start_date, end_date, created_at
+		FROM academic_years
+		WHERE school_id = $1
+		ORDER BY start_date
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var years []*AcademicYear
+
+	for rows.Next() {
+		year, err := scanAcademicYear(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		years = append(years, year)
+	}
+
+	return years, rows.Err()
+}
+
+func (s *CalendarPostgresStore) DeleteAcademicYear(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM academic_years WHERE id = $1`, id)
+	return err
+}
+
+func (s *CalendarPostgresStore) CreateTerm(ctx context.Context, term *Term) error {
+	query := `
+		INSERT INTO terms (school_id, academic_year_id, name, type, start_date, end_date, enrollment_start_date, enrollment_end_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		term.SchoolID,
+		term.AcademicYearID,
+		term.Name,
+		term.Type,
+		term.StartDate,
+		term.EndDate,
+		term.EnrollmentStartDate,
+		term.EnrollmentEndDate,
+		term.CreatedAt,
+	)
+
+	return row.Scan(&term.ID)
+}
+
+func (s *CalendarPostgresStore) GetTerm(ctx context.Context, id string) (*Term, error) {
+	query := `
+		SELECT id, school_id, academic_year_id, name, type, start_date, end_date, enrollment_start_date, enrollment_end_date, created_at
+		FROM terms
+		WHERE id = $1
+	`
+
+	return noRowsAsNil(scanTerm(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *CalendarPostgresStore) ListTerms(ctx context.Context, schoolID string) ([]*Term, error) {
+	query := `
+		SELECT id, school_id, academic_year_id, name, type, start_date, end_date, enrollment_start_date, enrollment_end_date, created_at
+		FROM terms
+		WHERE school_id = $1
+		ORDER BY start_date, end_date DESC
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var terms []*Term
+
+	for rows.Next() {
+		term, err := scanTerm(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, term)
+	}
+
+	return terms, rows.Err()
+}
+
+func (s *CalendarPostgresStore) DeleteTerm(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM terms WHERE id = $1`, id)
+	return err
+}
+
+func (s *CalendarPostgresStore) TermInUse(ctx context.Context, id string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM sections WHERE term_id = $1)
+			OR EXISTS (SELECT 1 FROM academic_documents WHERE term_id = $1)
+	`
+
+	var inUse bool
+	err := s.db.pool.QueryRow(ctx, query, id).Scan(&inUse)
+
+	return inUse, err
+}
+
+func (s *CalendarPostgresStore) CreateEvent(ctx context.Context, event *CalendarEvent) error {
+	query := `
+		INSERT INTO calendar_events (school_id, name, type, start_date, end_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	return s.db.pool.QueryRow(ctx, query, event.SchoolID, event.Name, event.Type, event.StartDate, event.EndDate, event.CreatedAt).Scan(&event.ID)
+}
+
+func (s *CalendarPostgresStore) GetEvent(ctx context.Context, id string) (*CalendarEvent, error) {
+	query := `
+		SELECT id, school_id, name, type, start_date, end_date, created_at
+		FROM calendar_events
+		WHERE id = $1
+	`
+
+	return noRowsAsNil(scanCalendarEvent(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *CalendarPostgresStore) ListEvents(ctx context.Context, schoolID string) ([]*CalendarEvent, error) {
+	query := `
+		SELECT id, school_id, name, type, start_date, end_date, created_at
+		FROM calendar_events
+		WHERE school_id = $1
+		ORDER BY start_date
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []*CalendarEvent
+
+	for rows.Next() {
+		event, err := scanCalendarEvent(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *CalendarPostgresStore) DeleteEvent(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM calendar_events WHERE id = $1`, id)
+	return err
+}
+
+func validateDateRange(start Date, end Date) error {
+	if start.IsZero() {
+		return errors.New("start date is required")
+	}
+
+	if end.IsZero() {
+		return errors.New("end date is required")
+	}
+
+	if end.Before(start.Time) {
+		return errors.New("end date must not be before start date")
+	}
+
+	return nil
+}
+
+func validateAcademicYear(year *AcademicYear, existing []*AcademicYear) error {
+	if year.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if err := validateDateRange(year.StartDate, year.EndDate); err != nil {
+		return err
+	}
+
+	for _, other := range existing {
+		if datesOverlap(year.StartDate, year.EndDate, other.StartDate, other.EndDate) {
+			return fmt.Errorf("academic year overlaps %s", other.Name)
+		}
+	}
+
+	return nil
+}
+
+// Checks a term against its academic year and the terms already in it.
+// Terms of different types may overlap, so a semester can contain its grading periods.
+func validateTerm(term *Term, year *AcademicYear, existing []*Term) error {
+	if term.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if !slices.Contains(termTypes, term.Type) {
+		return fmt.Errorf("type must be one of %v", termTypes)
+	}
+
+	if err := validateDateRange(term.StartDate, term.EndDate); err != nil {
+		return err
+	}
+
+	if !term.StartDate.Within(year.StartDate, year.EndDate) || !term.EndDate.Within(year.StartDate, year.EndDate) {
+		return fmt.Errorf("term must fall within academic year %s", year.Name)
+	}
+
+	if !term.EnrollmentStartDate.IsZero() && !term.EnrollmentEndDate.IsZero() && term.EnrollmentEndDate.Before(term.EnrollmentStartDate.Time) {
+		return errors.New("enrollment end date must not be before enrollment start date")
+	}
+
+	for _, other := range existing {
+		if other.AcademicYearID == term.AcademicYearID && other.Type == term.Type && datesOverlap(term.StartDate, term.EndDate, other.StartDate, other.EndDate) {
+			return fmt.Errorf("term overlaps %s", other.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateCalendarEvent(event *CalendarEvent) error {
+	if event.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if !slices.Contains(calendarEventTypes, event.Type) {
+		return fmt.Errorf("type must be one of %v", calendarEventTypes)
+	}
+
+	return validateDateRange(event.StartDate, event.EndDate)
+}
+
+// Reports whether students may enroll in the term's sections on date
+func (t *Term) EnrollmentOpen(date Date) bool {
+	if !t.EnrollmentStartDate.IsZero() && date.Before(t.EnrollmentStartDate.Time) {
+		return false
+	}
+
+	end := t.EnrollmentEndDate
+
+	if end.IsZero() {
+		end = t.EndDate
+	}
+
+	return !date.After(end.Time)
+}
+
+// Returns the terms in progress on date, shortest first, so a grading period comes before its semester
+func activeTerms(terms []*Term, date Date) []*Term {
+	active := []*Term{}
+
+	for _, term := range terms {
+		if date.Within(term.StartDate, term.EndDate) {
+			active = append(active, term)
+		}
+	}
+
+	slices.SortStableFunc(active, func(a, b *Term) int {
+		return cmp.Compare(a.EndDate.Sub(a.StartDate.Time), b.EndDate.Sub(b.StartDate.Time))
+	})
+
+	return active
+}
+
+// Returns the weekdays between from and to, inclusive, that fall within an academic year
+// and are not covered by a calendar event
+func instructionalDays(years []*AcademicYear, events []*CalendarEvent, from Date, to Date) []Date {
+	days := []Date{}
+
+	for day := from; !day.After(to.Time); day = day.AddDays(1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		inYear := slices.ContainsFunc(years, func(year *AcademicYear) bool {
+			return day.Within(year.StartDate, year.EndDate)
+		})
+
+		if !inYear {
+			continue
+		}
+
+		closed := slices.ContainsFunc(events, func(event *CalendarEvent) bool {
+			return day.Within(event.StartDate, event.EndDate)
+		})
+
+		if !closed {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+type CalendarService struct {
+	calendarStore CalendarStore
+	schoolStore   SchoolStore
+	memberStore   OrganizationMemberStore
+	auditService  *AuditService
+}
+
+func NewCalendarService(calendarStore CalendarStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *CalendarService {
+	return &CalendarService{calendarStore: calendarStore, schoolStore: schoolStore, memberStore: memberStore, auditService: auditService}
+}
+
+func (s *CalendarService) CreateAcademicYear(ctx context.Context, year *AcademicYear) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, year.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.calendarStore.ListAcademicYears(ctx, year.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to list academic years", "error", err)
+		return ErrInternal
+	}
+
+	if err := validateAcademicYear(year, existing); err != nil {
+		return err
+	}
+
+	year.CreatedAt = time.Now()
+
+	if err := s.calendarStore.CreateAcademicYear(ctx, year); err != nil {
+		slog.Error("failed to create academic year", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "academic_year", year.ID, nil, year)
+
+	return nil
+}
+
+func (s *CalendarService) ListAcademicYears(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	years, err := s.calendarStore.ListAcademicYears(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list academic years", "error", err)
+		return nil, ErrInternal
+	}
+
+	if years == nil {
+		years = []*AcademicYear{}
+	}
+
+	return years, nil
+}
+
+func (s *CalendarService) DeleteAcademicYear(ctx context.Context, id string) error {
+	year, err := s.calendarStore.GetAcademicYear(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get academic year", "error", err)
+		return ErrInternal
+	}
+
+	if year == nil {
+		return notFound("academic year")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, year.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	terms, err := s.calendarStore.ListTerms(ctx, year.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to list terms", "error", err)
+		return ErrInternal
+	}
+
+	if slices.ContainsFunc(terms, func(term *Term) bool { return term.AcademicYearID == id }) {
+		return errors.New("academic year still has terms")
+	}
+
+	if err := s.calendarStore.DeleteAcademicYear(ctx, id); err != nil {
+		slog.Error("failed to delete academic year", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "academic_year", id, year, nil)
+
+	return nil
+}
+
+func (s *CalendarService) CreateTerm(ctx context.Context, term *Term) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, term.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if term.AcademicYearID == "" {
+		return errors.New("academic year id is required")
+	}
+
+	year, err := s.calendarStore.GetAcademicYear(ctx, term.AcademicYearID)
+
+	if err != nil {
+		slog.Error("failed to get academic year", "error", err)
+		return ErrInternal
+	}
+
+	if year == nil || year.SchoolID != term.SchoolID {
+		return notFound("academic year")
+	}
+
+	existing, err := s.calendarStore.ListTerms(ctx, term.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to list terms", "error", err)
+		return ErrInternal
+	}
+
+	if err := validateTerm(term, year, existing); err != nil {
+		return err
+	}
+
+	term.CreatedAt = time.Now()
+
+	if err := s.calendarStore.CreateTerm(ctx, term); err != nil {
+		slog.Error("failed to create term", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "term", term.ID, nil, term)
+
+	return nil
+}
+
+func (s *CalendarService) ListTerms(ctx context.Context, schoolID string) ([]*Term, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	terms, err := s.calendarStore.ListTerms(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list terms", "error", err)
+		return nil, ErrInternal
+	}
+
+	if terms == nil {
+		terms = []*Term{}
+	}
+
+	return terms, nil
+}
+
+// Returns the terms of a school in progress on date, most specific first
+func (s *CalendarService) ActiveTerms(ctx context.Context, schoolID string, date Date) ([]*Term, error) {
+	terms, err := s.ListTerms(ctx, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return activeTerms(terms, date), nil
+}
+
+func (s *CalendarService) DeleteTerm(ctx context.Context, id string) error {
+	term, err := s.calendarStore.GetTerm(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return ErrInternal
+	}
+
+	if term == nil {
+		return notFound("term")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, term.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	inUse, err := s.calendarStore.TermInUse(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to check term references", "error", err)
+		return ErrInternal
+	}
+
+	// Sections and academic documents keep their term, so the database refuses the delete
+	if inUse {
+		return errors.New("term still has sections or academic documents")
+	}
+
+	if err := s.calendarStore.DeleteTerm(ctx, id); err != nil {
+		slog.Error("failed to delete term", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "term", id, term, nil)
+
+	return nil
+}
+
+func (s *CalendarService) CreateEvent(ctx context.Context, event *CalendarEvent) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, event.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateCalendarEvent(event); err != nil {
+		return err
+	}
+
+	event.CreatedAt = time.Now()
+
+	if err := s.calendarStore.CreateEvent(ctx, event); err != nil {
+		slog.Error("failed to create calendar event", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "calendar_event", event.ID, nil, event)
+
+	return nil
+}
+
+func (s *CalendarService) ListEvents(ctx context.Context, schoolID string) ([]*CalendarEvent, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.calendarStore.ListEvents(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list calendar events", "error", err)
+		return nil, ErrInternal
+	}
+
+	if events == nil {
+		events = []*CalendarEvent{}
+	}
+
+	return events, nil
+}
+
+func (s *CalendarService) DeleteEvent(ctx context.Context, id string) error {
+	event, err := s.calendarStore.GetEvent(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get calendar event", "error", err)
+		return ErrInternal
+	}
+
+	if event == nil {
+		return notFound("calendar event")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, event.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.calendarStore.DeleteEvent(ctx, id); err != nil {
+		slog.Error("failed to delete calendar event", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "calendar_event", id, event, nil)
+
+	return nil
+}
+
+// Returns the instructional days of a school between from and to, inclusive
+func (s *CalendarService) InstructionalDays(ctx context.Context, schoolID string, from Date, to Date) ([]Date, error) {
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	if to.Sub(from.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return nil, fmt.Errorf("range must not exceed %d days", maxInstructionalDaysRange)
+	}
+
+	years, err := s.ListAcademicYears(ctx, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.calendarStore.ListEvents(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list calendar events", "error", err)
+		return nil, ErrInternal
+	}
+
+	return instructionalDays(years, events, from, to), nil
+}
+
+type CalendarHandler struct {
+	calendarService *CalendarService
+}
+
+// Reads a YYYY-MM-DD query parameter, falling back to fallback when it is absent
+func dateQueryParam(r *http.Request, name string, fallback Date) (Date, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return fallback, nil
+	}
+
+	return ParseDate(value)
+}
+
+func (h *CalendarHandler) CreateAcademicYear(w http.ResponseWriter, r *http.Request) {
+	var year AcademicYear
+
+	if err := decodeJSON(r, &year); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	year.SchoolID = r.PathValue("id")
+
+	if err := h.calendarService.CreateAcademicYear(r.Context(), &year); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, year)
+}
+
+func (h *CalendarHandler) ListAcademicYears(w http.ResponseWriter, r *http.Request) {
+	years, err := h.calendarService.ListAcademicYears(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, years)
+}
+
+func (h *CalendarHandler) DeleteAcademicYear(w http.ResponseWriter, r *http.Request) {
+	if err := h.calendarService.DeleteAcademicYear(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CalendarHandler) CreateTerm(w http.ResponseWriter, r *http.Request) {
+	var term Term
+
+	if err := decodeJSON(r, &term); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	term.SchoolID = r.PathValue("id")
+
+	if err := h.calendarService.CreateTerm(r.Context(), &term); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, term)
+}
+
+func (h *CalendarHandler) ListTerms(w http.ResponseWriter, r *http.Request) {
+	terms, err := h.calendarService.ListTerms(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, terms)
+}
+
+// Lists the terms active on the date query parameter, defaulting to today
+func (h *CalendarHandler) ActiveTerms(w http.ResponseWriter, r *http.Request) {
+	date, err := dateQueryParam(r, "date", DateOf(time.Now()))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	terms, err := h.calendarService.ActiveTerms(r.Context(), r.PathValue("id"), date)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, terms)
+}
+
+func (h *CalendarHandler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	if err := h.calendarService.DeleteTerm(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CalendarHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	var event CalendarEvent
+
+	if err := decodeJSON(r, &event); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	event.SchoolID = r.PathValue("id")
+
+	if err := h.calendarService.CreateEvent(r.Context(), &event); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, event)
+}
+
+func (h *CalendarHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.calendarService.ListEvents(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (h *CalendarHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	if err := h.calendarService.DeleteEvent(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type InstructionalDaysResponse struct {
+	Count int    `json:"count"`
+	Days  []Date `json:"days"`
+}
+
+// Lists instructional days between the from and to query parameters
+func (h *CalendarHandler) InstructionalDays(w http.ResponseWriter, r *http.Request) {
+	from, err := dateQueryParam(r, "from", Date{})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := dateQueryParam(r, "to", Date{})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	days, err := h.calendarService.InstructionalDays(r.Context(), r.PathValue("id"), from, to)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, InstructionalDaysResponse{Count: len(days), Days: days})
+}
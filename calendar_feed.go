@@ -0,0 +1,719 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// How far back and ahead of today a feed reaches
+	calendarFeedPastDays   = 30
+	calendarFeedFutureDays = 366
+	// iCalendar lines longer than this many octets are folded
+	icsLineLength = 75
+)
+
+// A user's secret iCalendar feed. Only the token's hash is stored; the token itself is shown
+// once, when the feed is created.
+type CalendarFeed struct {
+	UserID    string    `json:"userId"`
+	TokenHash string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CalendarFeedPostgresStore struct {
+	db *PostgresDB
+}
+
+type CalendarFeedStore interface {
+	Get(ctx context.Context, userID string) (*CalendarFeed, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*CalendarFeed, error)
+	// Creates the user's feed or replaces its token
+	Save(ctx context.Context, feed *CalendarFeed) error
+	Delete(ctx context.Context, userID string) error
+}
+
+func scanCalendarFeed(row rowScanner) (*CalendarFeed, error) {
+	var feed CalendarFeed
+
+	if err := row.Scan(&feed.UserID, &feed.TokenHash, &feed.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+func (s *CalendarFeedPostgresStore) Get(ctx context.Context, userID string) (*CalendarFeed, error) {
+	query := `SELECT user_id, token_hash, created_at FROM calendar_feeds WHERE user_id = $1`
+
+	return noRowsAsNil(scanCalendarFeed(s.db.pool.QueryRow(ctx, query, userID)))
+}
+
+func (s *CalendarFeedPostgresStore) GetByTokenHash(ctx context.Context, tokenHash string) (*CalendarFeed, error) {
+	query := `SELECT user_id, token_hash, created_at FROM calendar_feeds WHERE token_hash = $1`
+
+	return noRowsAsNil(scanCalendarFeed(s.db.pool.QueryRow(ctx, query, tokenHash)))
+}
+
+func (s *CalendarFeedPostgresStore) Save(ctx context.Context, feed *CalendarFeed) error {
+	query := `
+		INSERT INTO calendar_feeds (user_id, token_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = excluded.token_hash, created_at = excluded.created_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, feed.UserID, feed.TokenHash, feed.CreatedAt)
+
+	return err
+}
+
+func (s *CalendarFeedPostgresStore) Delete(ctx context.Context, userID string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM calendar_feeds WHERE user_id = $1`, userID)
+	return err
+}
+
+// Writes iCalendar (RFC 5545) content lines
+type icsWriter struct {
+	b strings.Builder
+}
+
+// Writes a content line, folding it so no line exceeds icsLineLength octets
+func (w *icsWriter) line(name string, value string) {
+	line := name + ":" + value
+	width := 0
+
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+
+		if width+size > icsLineLength {
+			w.b.WriteString("\r\n ")
+			width = 1
+		}
+
+		w.b.WriteRune(r)
+		width += size
+	}
+
+	w.b.WriteString("\r\n")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Writes a TEXT property, escaping the value
+func (w *icsWriter) text(name string, value string) {
+	w.line(name, icsTextEscaper.Replace(value))
+}
+
+// Writes a DATE-TIME property for a clock time on date in zone. UTC times are written in UTC
+// form; others reference the zone's VTIMEZONE.
+func (w *icsWriter) localTime(name string, date Date, clock string, zone *time.Location) {
+	value := date.Format("20060102") + "T" + strings.ReplaceAll(clock, ":", "") + "00"
+
+	if zone == time.UTC {
+		w.line(name, value+"Z")
+		return
+	}
+
+	w.line(name+";TZID="+zone.String(), value)
+}
+
+func (w *icsWriter) utcTime(name string, t time.Time) {
+	w.line(name, t.UTC().Format("20060102T150405Z"))
+}
+
+// Formats a UTC offset as ±HHMM, or ±HHMMSS when it has seconds
+func icsOffset(seconds int) string {
+	sign := "+"
+
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+
+	return offset
+}
+
+// A change of a zone's UTC offset
+type zoneTransition struct {
+	at         time.Time
+	fromOffset int
+	toOffset   int
+	name       string
+	dst        bool
+}
+
+// Returns the offset changes of zone between from and to
+func zoneTransitions(zone *time.Location, from time.Time, to time.Time) []zoneTransition {
+	var transitions []zoneTransition
+	_, offset := from.In(zone).Zone()
+
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		_, nextOffset := next.In(zone).Zone()
+
+		if nextOffset == offset {
+			continue
+		}
+
+		// Narrow down to the second the offset changes
+		lo, hi := day, next
+
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+
+			if _, midOffset := mid.In(zone).Zone(); midOffset == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		name, _ := hi.In(zone).Zone()
+		transitions = append(transitions, zoneTransition{at: hi, fromOffset: offset, toOffset: nextOffset, name: name, dst: hi.In(zone).IsDST()})
+		offset = nextOffset
+	}
+
+	return transitions
+}
+
+// Writes a VTIMEZONE describing zone's offsets between from and to, with one observance for
+// the offset at from and one for each change after it
+func (w *icsWriter) timezone(zone *time.Location, from time.Time, to time.Time) {
+	observance := func(start time.Time, fromOffset int, toOffset int, name string, dst bool) {
+		kind := "STANDARD"
+
+		if dst {
+			kind = "DAYLIGHT"
+		}
+
+		w.line("BEGIN", kind)
+		// Observances start at the local time in effect before the change
+		w.line("DTSTART", start.In(time.FixedZone("", fromOffset)).Format("20060102T150405"))
+		w.line("TZOFFSETFROM", icsOffset(fromOffset))
+		w.line("TZOFFSETTO", icsOffset(toOffset))
+		w.text("TZNAME", name)
+		w.line("END", kind)
+	}
+
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", zone.String())
+
+	start := from.In(zone)
+	name, offset := start.Zone()
+	observance(start, offset, offset, name, start.IsDST())
+
+	for _, transition := range zoneTransitions(zone, from, to) {
+		observance(transition.at, transition.fromOffset, transition.toOffset, transition.name, transition.dst)
+	}
+
+	w.line("END", "VTIMEZONE")
+}
+
+// A section in a feed, with the school days of its term that fall in the feed's window
+type feedSection struct {
+	section *Section
+	course  *Course
+	school  *School
+	days    []SchoolDay
+}
+
+func (s *feedSection) summary() string {
+	if s.course == nil {
+		return "Section " + s.section.Code
+	}
+
+	return s.course.Code + " " + s.course.Title
+}
+
+// Everything a user's feed shows
+type feedContent struct {
+	name        string
+	from        Date
+	to          Date
+	sections    []*feedSection
+	assignments []*Assignment
+	// Summaries of the assignments' sections by section id
+	assignmentSections map[string]string
+	events             []*CalendarEvent
+}
+
+// Renders the feed as an iCalendar document. Sections on a weekly schedule repeat by
+// RRULE with their days off excluded; sections in bell schedule periods are listed day by
+// day since rotations do not map to recurrence rules.
+func renderCalendarFeed(content *feedContent, now time.Time) string {
+	w := &icsWriter{}
+	stamp := func() { w.utcTime("DTSTAMP", now) }
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//Divinity//Calendar Feed//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", content.name)
+
+	var zones []*time.Location
+
+	for _, section := range content.sections {
+		zone := section.school.Location()
+
+		if zone != time.UTC && !slices.ContainsFunc(zones, func(other *time.Location) bool { return other.String() == zone.String() }) {
+			zones = append(zones, zone)
+		}
+	}
+
+	for _, zone := range zones {
+		w.timezone(zone, content.from.AddDays(-1).Time, content.to.AddDays(1).Time)
+	}
+
+	for _, section := range content.sections {
+		zone := section.school.Location()
+
+		event := func(uid string) {
+			w.line("BEGIN", "VEVENT")
+			w.line("UID", uid)
+			stamp()
+			w.text("SUMMARY", section.summary())
+
+			if section.section.Room != "" {
+				w.text("LOCATION", section.section.Room)
+			}
+		}
+
+		if len(section.section.Periods) > 0 {
+			for _, occurrence := range sectionOccurrences(section.section, section.days) {
+				event(fmt.Sprintf("section-%s-%s-%s@divinity", section.section.ID, occurrence.Date.Format("20060102"), occurrence.Period))
+				w.localTime("DTSTART", occurrence.Date, occurrence.StartTime, zone)
+				w.localTime("DTEND", occurrence.Date, occurrence.EndTime, zone)
+				w.text("DESCRIPTION", fmt.Sprintf("%s day, period %s", occurrence.RotationDay, occurrence.Period))
+				w.line("END", "VEVENT")
+			}
+
+			continue
+		}
+
+		for _, meeting := range section.section.Meetings {
+			var dates, closed []Date
+
+			for _, day := range section.days {
+				if day.Date.Weekday() != meeting.Weekday {
+					continue
+				}
+
+				dates = append(dates, day.Date)
+
+				if !day.Instructional {
+					closed = append(closed, day.Date)
+				}
+			}
+
+			if len(dates) == len(closed) {
+				continue
+			}
+
+			first, last := dates[0], dates[len(dates)-1]
+			startClock, _ := time.Parse(clockLayout, meeting.StartTime)
+			until := time.Date(last.Year(), last.Month(), last.Day(), startClock.Hour(), startClock.Minute(), 0, 0, zone)
+
+			event(fmt.Sprintf("section-%s-%d@divinity", section.section.ID, meeting.Weekday))
+			w.localTime("DTSTART", first, meeting.StartTime, zone)
+			w.localTime("DTEND", first, meeting.EndTime, zone)
+			w.line("RRULE", "FREQ=WEEKLY;UNTIL="+until.UTC().Format("20060102T150405Z"))
+
+			for _, date := range closed {
+				w.localTime("EXDATE", date, meeting.StartTime, zone)
+			}
+
+			w.line("END", "VEVENT")
+		}
+	}
+
+	for _, assignment := range content.assignments {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", fmt.Sprintf("assignment-%s@divinity", assignment.ID))
+		stamp()
+		w.text("SUMMARY", "Due: "+assignment.Title)
+		w.utcTime("DTSTART", *assignment.DueAt)
+		w.utcTime("DTEND", *assignment.DueAt)
+		w.text("DESCRIPTION", content.assignmentSections[assignment.SectionID])
+		w.line("END", "VEVENT")
+	}
+
+	for _, event := range content.events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", fmt.Sprintf("calendar-event-%s@divinity", event.ID))
+		stamp()
+		w.text("SUMMARY", event.Name)
+		w.line("DTSTART;VALUE=DATE", event.StartDate.Format("20060102"))
+		// All-day events end on the day after their last day
+		w.line("DTEND;VALUE=DATE", event.EndDate.AddDays(1).Format("20060102"))
+		w.line("TRANSP", "TRANSPARENT")
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+
+	return w.b.String()
+}
+
+type CalendarFeedService struct {
+	feedStore       CalendarFeedStore
+	userStore       UserStore
+	memberStore     OrganizationMemberStore
+	schoolStore     SchoolStore
+	calendarStore   CalendarStore
+	bellStore       BellStore
+	sectionStore    SectionStore
+	enrollmentStore EnrollmentStore
+	courseStore     CourseStore
+	gradebookStore  GradebookStore
+}
+
+func NewCalendarFeedService(feedStore CalendarFeedStore, userStore UserStore, memberStore OrganizationMemberStore, schoolStore SchoolStore, calendarStore CalendarStore, bellStore BellStore, sectionStore SectionStore, enrollmentStore EnrollmentStore, courseStore CourseStore, gradebookStore GradebookStore) *CalendarFeedService {
+	return &CalendarFeedService{
+		feedStore:       feedStore,
+		userStore:       userStore,
+		memberStore:     memberStore,
+		schoolStore:     schoolStore,
+		calendarStore:   calendarStore,
+		bellStore:       bellStore,
+		sectionStore:    sectionStore,
+		enrollmentStore: enrollmentStore,
+		courseStore:     courseStore,
+		gradebookStore:  gradebookStore,
+	}
+}
+
+// Checks that the session user is userID acting as themselves. Feed links are credentials,
+// so neither administrators nor impersonators may manage another user's.
+func requireFeedOwner(ctx context.Context, userID string) error {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	if session.UserID != userID {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+type CalendarFeedResponse struct {
+	// The feed's path. Only returned when the feed is created.
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func calendarFeedPath(token string) string {
+	return "/calendar-feeds/" + token + "/calendar.ics"
+}
+
+// Returns when the user's feed was created, without its link
+func (s *CalendarFeedService) Get(ctx context.Context, userID string) (*CalendarFeedResponse, error) {
+	if err := requireFeedOwner(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	feed, err := s.feedStore.Get(ctx, userID)
+
+	if err != nil {
+		slog.Error("failed to get calendar feed", "error", err)
+		return nil, ErrInternal
+	}
+
+	if feed == nil {
+		return nil, notFound("calendar feed")
+	}
+
+	return &CalendarFeedResponse{CreatedAt: feed.CreatedAt}, nil
+}
+
+// Creates the user's feed, or replaces its link so the old one stops working
+func (s *CalendarFeedService) Create(ctx context.Context, userID string) (*CalendarFeedResponse, error) {
+	if err := requireFeedOwner(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := newSessionToken()
+
+	if err != nil {
+		slog.Error("failed to generate calendar feed token", "error", err)
+		return nil, ErrInternal
+	}
+
+	feed := &CalendarFeed{UserID: userID, TokenHash: tokenHash, CreatedAt: time.Now()}
+
+	if err := s.feedStore.Save(ctx, feed); err != nil {
+		slog.Error("failed to save calendar feed", "error", err)
+		return nil, ErrInternal
+	}
+
+	return &CalendarFeedResponse{URL: calendarFeedPath(token), CreatedAt: feed.CreatedAt}, nil
+}
+
+func (s *CalendarFeedService) Delete(ctx context.Context, userID string) error {
+	if err := requireFeedOwner(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.feedStore.Delete(ctx, userID); err != nil {
+		slog.Error("failed to delete calendar feed", "error", err)
+		return ErrInternal
+	}
+
+	return nil
+}
+
+// Renders the feed the token belongs to
+func (s *CalendarFeedService) Render(ctx context.Context, token string, now time.Time) (string, error) {
+	feed, err := s.feedStore.GetByTokenHash(ctx, hashSessionToken(token))
+
+	if err != nil {
+		slog.Error("failed to get calendar feed", "error", err)
+		return "", ErrInternal
+	}
+
+	if feed == nil {
+		return "", unauthorized("invalid calendar feed link")
+	}
+
+	user, err := s.userStore.GetByID(ctx, feed.UserID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get user", "error", err)
+		return "", ErrInternal
+	}
+
+	// Deleted users' feeds stop working
+	if user == nil {
+		return "", unauthorized("invalid calendar feed link")
+	}
+
+	content, err := s.content(ctx, user, DateOf(now))
+
+	if err != nil {
+		return "", err
+	}
+
+	return renderCalendarFeed(content, now), nil
+}
+
+// Gathers the sections the user is enrolled in or teaches at schools of organizations they
+// belong to, with their assignments and their schools' days off
+func (s *CalendarFeedService) content(ctx context.Context, user *User, today Date) (*feedContent, error) {
+	content := &feedContent{
+		name:               user.FirstName + " " + user.LastName,
+		from:               today.AddDays(-calendarFeedPastDays),
+		to:                 today.AddDays(calendarFeedFutureDays),
+		assignmentSections: map[string]string{},
+	}
+
+	members, err := s.memberStore.ListByUser(ctx, user.ID)
+
+	if err != nil {
+		slog.Error("failed to list organization members", "error", err)
+		return nil, ErrInternal
+	}
+
+	organizations := map[string]bool{}
+
+	for _, member := range members {
+		organizations[member.OrganizationID] = true
+	}
+
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{StudentUserID: user.ID, Statuses: []string{EnrollmentStatusEnrolled}})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	var sections []*Section
+
+	for _, enrollment := range enrollments {
+		section, err := s.sectionStore.GetByID(ctx, enrollment.SectionID)
+
+		if err != nil {
+			slog.Error("failed to get section", "error", err)
+			return nil, ErrInternal
+		}
+
+		if section != nil {
+			sections = append(sections, section)
+		}
+	}
+
+	taught, err := s.sectionStore.List(ctx, &SectionFilter{TeacherUserID: user.ID})
+
+	if err != nil {
+		slog.Error("failed to list sections", "error", err)
+		return nil, ErrInternal
+	}
+
+	sections = append(sections, taught...)
+
+	schools := map[string]*School{}
+	calendars := map[string]*bellCalendar{}
+	seen := map[string]bool{}
+
+	for _, section := range sections {
+		if seen[section.ID] {
+			continue
+		}
+
+		seen[section.ID] = true
+		school, ok := schools[section.SchoolID]
+
+		if !ok {
+			if school, err = s.schoolStore.GetByID(ctx, section.SchoolID); err != nil {
+				slog.Error("failed to get school", "error", err)
+				return nil, ErrInternal
+			}
+
+			schools[section.SchoolID] = school
+		}
+
+		if school == nil || !organizations[school.OrganizationID] {
+			continue
+		}
+
+		term, err := s.calendarStore.GetTerm(ctx, section.TermID)
+
+		if err != nil {
+			slog.Error("failed to get term", "error", err)
+			return nil, ErrInternal
+		}
+
+		if term == nil || !datesOverlap(term.StartDate, term.EndDate, content.from, content.to) {
+			continue
+		}
+
+		calendar, ok := calendars[school.ID]
+
+		if !ok {
+			if calendar, err = loadBellCalendar(ctx, s.bellStore, s.calendarStore, school.ID); err != nil {
+				return nil, err
+			}
+
+			calendars[school.ID] = calendar
+		}
+
+		from, to := term.StartDate, term.EndDate
+
+		if from.Before(content.from.Time) {
+			from = content.from
+		}
+
+		if to.After(content.to.Time) {
+			to = content.to
+		}
+
+		course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+		if err != nil {
+			slog.Error("failed to get course", "error", err)
+			return nil, ErrInternal
+		}
+
+		feedSection := &feedSection{section: section, course: course, school: school, days: calendar.days(from, to)}
+		content.sections = append(content.sections, feedSection)
+		content.assignmentSections[section.ID] = feedSection.summary()
+
+		assignments, err := s.gradebookStore.ListAssignments(ctx, section.ID)
+
+		if err != nil {
+			slog.Error("failed to list assignments", "error", err)
+			return nil, ErrInternal
+		}
+
+		for _, assignment := range assignments {
+			if assignment.DueAt != nil && DateOf(*assignment.DueAt).Within(content.from, content.to) {
+				content.assignments = append(content.assignments, assignment)
+			}
+		}
+	}
+
+	for schoolID, calendar := range calendars {
+		for _, event := range calendar.events {
+			if event.SchoolID == schoolID && datesOverlap(event.StartDate, event.EndDate, content.from, content.to) {
+				content.events = append(content.events, event)
+			}
+		}
+	}
+
+	slices.SortStableFunc(content.events, func(a, b *CalendarEvent) int {
+		return cmp.Or(a.StartDate.Compare(b.StartDate.Time), strings.Compare(a.ID, b.ID))
+	})
+
+	return content, nil
+}
+
+type CalendarFeedHandler struct {
+	feedService *CalendarFeedService
+}
+
+func (h *CalendarFeedHandler) Get(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.feedService.Get(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, feed)
+}
+
+func (h *CalendarFeedHandler) Create(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.feedService.Create(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, feed)
+}
+
+func (h *CalendarFeedHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.feedService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Serves the feed to calendar apps, which authenticate with the token in the path
+func (h *CalendarFeedHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	calendar, err := h.feedService.Render(r.Context(), r.PathValue("token"), time.Now())
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(calendar))
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockCalendarFeedStore struct {
+	GetFunc            func(ctx context.Context, userID string) (*CalendarFeed, error)
+	GetByTokenHashFunc func(ctx context.Context, tokenHash string) (*CalendarFeed, error)
+	SaveFunc           func(ctx context.Context, feed *CalendarFeed) error
+	DeleteFunc         func(ctx context.Context, userID string) error
+}
+
+func (m *MockCalendarFeedStore) Get(ctx context.Context, userID string) (*CalendarFeed, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarFeedStore) GetByTokenHash(ctx context.Context, tokenHash string) (*CalendarFeed, error) {
+	if m.GetByTokenHashFunc != nil {
+		return m.GetByTokenHashFunc(ctx, tokenHash)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarFeedStore) Save(ctx context.Context, feed *CalendarFeed) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(ctx, feed)
+	}
+
+	return nil
+}
+
+func (m *MockCalendarFeedStore) Delete(ctx context.Context, userID string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, userID)
+	}
+
+	return nil
+}
+
+const feedToken = "feed-token"
+
+// A feed for the student, who is enrolled in geometry
+func studentFeed() *MockCalendarFeedStore {
+	return &MockCalendarFeedStore{
+		GetByTokenHashFunc: func(ctx context.Context, tokenHash string) (*CalendarFeed, error) {
+			if tokenHash != hashSessionToken(feedToken) {
+				return nil, nil
+			}
+
+			return &CalendarFeed{UserID: "student"}, nil
+		},
+	}
+}
+
+type calendarFeedFixtures struct {
+	feedStore       *MockCalendarFeedStore
+	userStore       *MockUserStore
+	memberStore     *MockOrganizationMemberStore
+	schoolStore     *MockSchoolStore
+	bellStore       *MockBellStore
+	sectionStore    *MockSectionStore
+	enrollmentStore *MockEnrollmentStore
+	gradebookStore  *MockGradebookStore
+}
+
+func newCalendarFeedFixtures() *calendarFeedFixtures {
+	sectionStore, _ := attendanceFixtures()
+	memberStore := orgMembers()
+	memberStore.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleStudent}}, nil
+	}
+
+	return &calendarFeedFixtures{
+		feedStore: studentFeed(),
+		userStore: &MockUserStore{
+			GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+				return &User{ID: id, FirstName: "Ada", LastName: "Lovelace"}, nil
+			},
+		},
+		memberStore:  memberStore,
+		schoolStore:  existingSchool(),
+		bellStore:    &MockBellStore{},
+		sectionStore: sectionStore,
+		enrollmentStore: &MockEnrollmentStore{
+			ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+				return []*Enrollment{{ID: "enrollment", SectionID: "section", StudentUserID: filter.StudentUserID, Status: EnrollmentStatusEnrolled}}, nil
+			},
+		},
+		gradebookStore: &MockGradebookStore{
+			ListAssignmentsFunc: func(ctx context.Context, sectionID string) ([]*Assignment, error) {
+				due := time.Date(2025, time.October, 10, 23, 59, 0, 0, time.UTC)
+				return []*Assignment{{ID: "proofs", SectionID: sectionID, Title: "Proofs, part 1", DueAt: &due}, {ID: "undated", SectionID: sectionID, Title: "Reading"}}, nil
+			},
+		},
+	}
+}
+
+func (f *calendarFeedFixtures) service() *CalendarFeedService {
+	_, calendarStore := attendanceFixtures()
+
+	return NewCalendarFeedService(f.feedStore, f.userStore, f.memberStore, f.schoolStore, calendarStore, f.bellStore, f.sectionStore, f.enrollmentStore, mathCatalog(), f.gradebookStore)
+}
+
+// The feed's content lines, unfolded
+func feedLines(calendar string) []string {
+	return strings.Split(strings.ReplaceAll(strings.TrimSuffix(calendar, "\r\n"), "\r\n ", ""), "\r\n")
+}
+
+var feedNow = time.Date(2025, time.October, 6, 12, 0, 0, 0, time.UTC)
+
+func TestCalendarFeedService_Render_RepeatsWeeklyMeetingsWithoutDaysOff(t *testing.T) {
+	calendar, err := newCalendarFeedFixtures().service().Render(context.Background(), feedToken, feedNow)
+
+	assert.NoError(t, err)
+	lines := feedLines(calendar)
+	assert.Contains(t, lines, "X-WR-CALNAME:Ada Lovelace")
+	assert.Contains(t, lines, "UID:section-section-1@divinity")
+	assert.Contains(t, lines, "SUMMARY:MATH201 Geometry")
+	// The window starts 30 days back, on a Saturday, so Mondays start on September 8
+	assert.Contains(t, lines, "DTSTART:20250908T080000Z")
+	assert.Contains(t, lines, "DTEND:20250908T085000Z")
+	assert.Contains(t, lines, "RRULE:FREQ=WEEKLY;UNTIL=20260112T080000Z")
+	assert.Contains(t, lines, "EXDATE:20251013T080000Z")
+	assert.Contains(t, lines, "UID:section-section-3@divinity")
+	assert.NotContains(t, calendar, "VTIMEZONE")
+}
+
+func TestCalendarFeedService_Render_IncludesDueDatesAndHolidays(t *testing.T) {
+	calendar, err := newCalendarFeedFixtures().service().Render(context.Background(), feedToken, feedNow)
+
+	assert.NoError(t, err)
+	lines := feedLines(calendar)
+	assert.Contains(t, lines, "SUMMARY:Due: Proofs\\, part 1")
+	assert.Contains(t, lines, "DTSTART:20251010T235900Z")
+	assert.NotContains(t, calendar, "Due: Reading")
+	assert.Contains(t, lines, "SUMMARY:Indigenous Peoples' Day")
+	assert.Contains(t, lines, "DTSTART;VALUE=DATE:20251013")
+	assert.Contains(t, lines, "DTEND;VALUE=DATE:20251014")
+}
+
+func TestCalendarFeedService_Render_UsesSchoolTimezone(t *testing.T) {
+	fixtures := newCalendarFeedFixtures()
+	fixtures.schoolStore.GetByIDFunc = func(ctx context.Context, id string) (*School, error) {
+		return &School{ID: id, OrganizationID: "org", Name: "North High", Timezone: "America/New_York"}, nil
+	}
+
+	calendar, err := fixtures.service().Render(context.Background(), feedToken, feedNow)
+
+	assert.NoError(t, err)
+	lines := feedLines(calendar)
+	assert.Contains(t, lines, "TZID:America/New_York")
+	assert.Contains(t, lines, "BEGIN:DAYLIGHT")
+	assert.Contains(t, lines, "BEGIN:STANDARD")
+	// Daylight saving time ends on November 2, 2025 at 02:00 local time
+	assert.Contains(t, lines, "DTSTART:20251102T020000")
+	assert.Contains(t, lines, "TZOFFSETFROM:-0400")
+	assert.Contains(t, lines, "TZOFFSETTO:-0500")
+	assert.Contains(t, lines, "DTSTART;TZID=America/New_York:20250908T080000")
+	// The last Monday of the term is in standard time
+	assert.Contains(t, lines, "RRULE:FREQ=WEEKLY;UNTIL=20260112T130000Z")
+	assert.Contains(t, lines, "EXDATE;TZID=America/New_York:20251013T080000")
+}
+
+func TestCalendarFeedService_Render_ListsBellPeriodsDayByDay(t *testing.T) {
+	fixtures := newCalendarFeedFixtures()
+	fixtures.bellStore = abRotation()
+	fixtures.sectionStore.GetByIDFunc = func(ctx context.Context, id string) (*Section, error) {
+		return &Section{ID: id, CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Room: "B12", Periods: []SectionPeriod{{Day: "A", Period: "1"}}}, nil
+	}
+
+	calendar, err := fixtures.service().Render(context.Background(), feedToken, feedNow)
+
+	assert.NoError(t, err)
+	lines := feedLines(calendar)
+	assert.NotContains(t, calendar, "RRULE")
+	assert.Contains(t, lines, "UID:section-section-20251006-1@divinity")
+	assert.Contains(t, lines, "LOCATION:B12")
+	// The override on October 8 shortens the A day's first period
+	assert.Contains(t, lines, "DTSTART:20251008T080000Z")
+	assert.Contains(t, lines, "DTEND:20251008T085000Z")
+	assert.NotContains(t, calendar, "UID:section-section-20251007-1@divinity")
+}
+
+func TestCalendarFeedService_Render_OmitsSectionsOfFormerOrganizations(t *testing.T) {
+	fixtures := newCalendarFeedFixtures()
+	fixtures.memberStore.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return nil, nil
+	}
+
+	calendar, err := fixtures.service().Render(context.Background(), feedToken, feedNow)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, calendar, "Geometry")
+	assert.NotContains(t, calendar, "Indigenous Peoples' Day")
+}
+
+func TestCalendarFeedService_Render_ReturnsErrorForUnknownToken(t *testing.T) {
+	calendar, err := newCalendarFeedFixtures().service().Render(context.Background(), "other-token", feedNow)
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Empty(t, calendar)
+}
+
+func TestCalendarFeedService_Render_ReturnsErrorForDeletedUser(t *testing.T) {
+	fixtures := newCalendarFeedFixtures()
+	fixtures.userStore.GetByIDFunc = func(ctx context.Context, id string) (*User, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	calendar, err := fixtures.service().Render(context.Background(), feedToken, feedNow)
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Empty(t, calendar)
+}
+
+func TestCalendarFeedService_Create_ReturnsLinkToFeed(t *testing.T) {
+	fixtures := newCalendarFeedFixtures()
+	var saved *CalendarFeed
+	fixtures.feedStore.SaveFunc = func(ctx context.Context, feed *CalendarFeed) error {
+		saved = feed
+		return nil
+	}
+
+	response, err := fixtures.service().Create(sessionContext("student"), "student")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "student", saved.UserID)
+	token := strings.TrimSuffix(strings.TrimPrefix(response.URL, "/calendar-feeds/"), "/calendar.ics")
+	assert.Equal(t, hashSessionToken(token), saved.TokenHash)
+}
+
+func TestCalendarFeedService_Create_ReturnsErrorForOtherUser(t *testing.T) {
+	response, err := newCalendarFeedFixtures().service().Create(sessionContext("admin"), "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, response)
+}
+
+func TestCalendarFeedService_Create_ReturnsErrorWhileImpersonating(t *testing.T) {
+	ctx := contextWithSession(context.Background(), &Session{UserID: "student", ImpersonatorUserID: "admin"})
+
+	response, err := newCalendarFeedFixtures().service().Create(ctx, "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, response)
+}
+
+func TestCalendarFeedService_Get_ReturnsErrorWithoutFeed(t *testing.T) {
+	response, err := newCalendarFeedFixtures().service().Get(sessionContext("student"), "student")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, response)
+}
+
+func TestIcsWriter_FoldsLongLines(t *testing.T) {
+	w := &icsWriter{}
+	w.text("SUMMARY", strings.Repeat("é", 40))
+
+	for _, line := range strings.Split(strings.TrimSuffix(w.b.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), icsLineLength)
+	}
+
+	assert.Equal(t, []string{"SUMMARY:" + strings.Repeat("é", 40)}, feedLines(w.b.String()))
+}
+
+func TestIcsWriter_EscapesText(t *testing.T) {
+	w := &icsWriter{}
+	w.text("DESCRIPTION", "a;b,c\\d\ne")
+
+	assert.Equal(t, "DESCRIPTION:a\\;b\\,c\\\\d\\ne\r\n", w.b.String())
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockCalendarStore struct {
+	CreateAcademicYearFunc func(ctx context.Context, year *AcademicYear) error
+	GetAcademicYearFunc    func(ctx context.Context, id string) (*AcademicYear, error)
+	ListAcademicYearsFunc  func(ctx context.Context, schoolID string) ([]*AcademicYear, error)
+	DeleteAcademicYearFunc func(ctx context.Context, id string) error
+	CreateTermFunc         func(ctx context.Context, term *Term) error
+	GetTermFunc            func(ctx context.Context, id string) (*Term, error)
+	ListTermsFunc          func(ctx context.Context, schoolID string) ([]*Term, error)
+	DeleteTermFunc         func(ctx context.Context, id string) error
+	TermInUseFunc          func(ctx context.Context, id string) (bool, error)
+	CreateEventFunc        func(ctx context.Context, event *CalendarEvent) error
+	GetEventFunc           func(ctx context.Context, id string) (*CalendarEvent, error)
+	ListEventsFunc         func(ctx context.Context, schoolID string) ([]*CalendarEvent, error)
+	DeleteEventFunc        func(ctx context.Context, id string) error
+}
+
+func (m *MockCalendarStore) CreateAcademicYear(ctx context.Context, year *AcademicYear) error {
+	if m.CreateAcademicYearFunc != nil {
+		return m.CreateAcademicYearFunc(ctx, year)
+	}
+
+	return nil
+}
+
+func (m *MockCalendarStore) GetAcademicYear(ctx context.Context, id string) (*AcademicYear, error) {
+	if m.GetAcademicYearFunc != nil {
+		return m.GetAcademicYearFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarStore) ListAcademicYears(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+	if m.ListAcademicYearsFunc != nil {
+		return m.ListAcademicYearsFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarStore) DeleteAcademicYear(ctx context.Context, id string) error {
+	if m.DeleteAcademicYearFunc != nil {
+		return m.DeleteAcademicYearFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockCalendarStore) CreateTerm(ctx context.Context, term *Term) error {
+	if m.CreateTermFunc != nil {
+		return m.CreateTermFunc(ctx, term)
+	}
+
+	return nil
+}
+
+func (m *MockCalendarStore) GetTerm(ctx context.Context, id string) (*Term, error) {
+	if m.GetTermFunc != nil {
+		return m.GetTermFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarStore) ListTerms(ctx context.Context, schoolID string) ([]*Term, error) {
+	if m.ListTermsFunc != nil {
+		return m.ListTermsFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarStore) DeleteTerm(ctx context.Context, id string) error {
+	if m.DeleteTermFunc != nil {
+		return m.DeleteTermFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockCalendarStore) TermInUse(ctx context.Context, id string) (bool, error) {
+	if m.TermInUseFunc != nil {
+		return m.TermInUseFunc(ctx, id)
+	}
+
+	return false, nil
+}
+
+func (m *MockCalendarStore) CreateEvent(ctx context.Context, event *CalendarEvent) error {
+	if m.CreateEventFunc != nil {
+		return m.CreateEventFunc(ctx, event)
+	}
+
+	return nil
+}
+
+func (m *MockCalendarStore) GetEvent(ctx context.Context, id string) (*CalendarEvent, error) {
+	if m.GetEventFunc != nil {
+		return m.GetEventFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarStore) ListEvents(ctx context.Context, schoolID string) ([]*CalendarEvent, error) {
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCalendarStore) DeleteEvent(ctx context.Context, id string) error {
+	if m.DeleteEventFunc != nil {
+		return m.DeleteEventFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func schoolYear2025() *AcademicYear {
+	return &AcademicYear{ID: "year", SchoolID: "school", Name: "2025-26", StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.June, 12)}
+}
+
+func TestCalendarService_CreateAcademicYear_CreatesYear(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		CreateAcademicYearFunc: func(ctx context.Context, year *AcademicYear) error {
+			year.ID = "year"
+			return nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	year := &AcademicYear{SchoolID: "school", Name: "2025-26", StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.June, 12)}
+	err := calendarService.CreateAcademicYear(sessionContext("admin"), year)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "year", year.ID)
+	assert.False(t, year.CreatedAt.IsZero())
+}
+
+func TestCalendarService_CreateAcademicYear_ReturnsErrorForNonAdmin(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	year := &AcademicYear{SchoolID: "school", Name: "2025-26", StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.June, 12)}
+	err := calendarService.CreateAcademicYear(sessionContext("teacher"), year)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCalendarService_CreateAcademicYear_ReturnsErrorForEndBeforeStart(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	year := &AcademicYear{SchoolID: "school", Name: "2025-26", StartDate: NewDate(2026, time.June, 12), EndDate: NewDate(2025, time.August, 25)}
+	err := calendarService.CreateAcademicYear(sessionContext("admin"), year)
+
+	assert.Error(t, err)
+	assert.Equal(t, "end date must not be before start date", err.Error())
+}
+
+func TestCalendarService_CreateAcademicYear_ReturnsErrorForOverlap(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		ListAcademicYearsFunc: func(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+			return []*AcademicYear{schoolYear2025()}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	year := &AcademicYear{SchoolID: "school", Name: "2026-27", StartDate: NewDate(2026, time.June, 1), EndDate: NewDate(2027, time.June, 11)}
+	err := calendarService.CreateAcademicYear(sessionContext("admin"), year)
+
+	assert.Error(t, err)
+	assert.Equal(t, "academic year overlaps 2025-26", err.Error())
+}
+
+func TestCalendarService_CreateAcademicYear_ReturnsInternalErrorOnStoreFailure(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		CreateAcademicYearFunc: func(ctx context.Context, year *AcademicYear) error {
+			return errors.New("db error")
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	year := &AcademicYear{SchoolID: "school", Name: "2025-26", StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.June, 12)}
+	err := calendarService.CreateAcademicYear(sessionContext("admin"), year)
+
+	assert.ErrorIs(t, err, ErrInternal)
+}
+
+func TestCalendarService_DeleteAcademicYear_ReturnsErrorWhenTermsRemain(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			return schoolYear2025(), nil
+		},
+		ListTermsFunc: func(ctx context.Context, schoolID string) ([]*Term, error) {
+			return []*Term{{ID: "fall", AcademicYearID: "year"}}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := calendarService.DeleteAcademicYear(sessionContext("admin"), "year")
+
+	assert.Error(t, err)
+	assert.Equal(t, "academic year still has terms", err.Error())
+}
+
+func TestCalendarService_DeleteAcademicYear_ReturnsNotFound(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := calendarService.DeleteAcademicYear(sessionContext("admin"), "year")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCalendarService_DeleteTerm_ReturnsErrorWhenTermIsInUse(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", AcademicYearID: "year"}, nil
+		},
+		TermInUseFunc: func(ctx context.Context, id string) (bool, error) {
+			return true, nil
+		},
+		DeleteTermFunc: func(ctx context.Context, id string) error {
+			t.Fatal("a term in use must not be deleted")
+			return nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := calendarService.DeleteTerm(sessionContext("admin"), "fall")
+
+	assert.Error(t, err)
+	assert.Equal(t, "term still has sections or academic documents", err.Error())
+}
+
+func TestCalendarService_DeleteTerm_DeletesUnusedTerm(t *testing.T) {
+	var deletedID string
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", AcademicYearID: "year"}, nil
+		},
+		DeleteTermFunc: func(ctx context.Context, id string) error {
+			deletedID = id
+			return nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := calendarService.DeleteTerm(sessionContext("admin"), "fall")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fall", deletedID)
+}
+
+func TestCalendarService_CreateTerm_CreatesTermWithinYear(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			return schoolYear2025(), nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	term := &Term{SchoolID: "school", AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}
+	err := calendarService.CreateTerm(sessionContext("admin"), term)
+
+	assert.NoError(t, err)
+}
+
+func TestCalendarService_CreateTerm_ReturnsErrorOutsideYear(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			return schoolYear2025(), nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	term := &Term{SchoolID: "school", AcademicYearID: "year", Name: "Summer", Type: TermTypeQuarter, StartDate: NewDate(2026, time.June, 15), EndDate: NewDate(2026, time.August, 1)}
+	err := calendarService.CreateTerm(sessionContext("admin"), term)
+
+	assert.Error(t, err)
+	assert.Equal(t, "term must fall within academic year 2025-26", err.Error())
+}
+
+func TestCalendarService_CreateTerm_ReturnsErrorForOverlappingTermOfSameType(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			return schoolYear2025(), nil
+		},
+		ListTermsFunc: func(ctx context.Context, schoolID string) ([]*Term, error) {
+			return []*Term{{ID: "fall", AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	term := &Term{SchoolID: "school", AcademicYearID: "year", Name: "Spring", Type: TermTypeSemester, StartDate: NewDate(2026, time.January, 16), EndDate: NewDate(2026, time.June, 12)}
+	err := calendarService.CreateTerm(sessionContext("admin"), term)
+
+	assert.Error(t, err)
+	assert.Equal(t, "term overlaps Fall", err.Error())
+}
+
+func TestCalendarService_CreateTerm_AllowsNestedTermOfOtherType(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			return schoolYear2025(), nil
+		},
+		ListTermsFunc: func(ctx context.Context, schoolID string) ([]*Term, error) {
+			return []*Term{{ID: "fall", AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	term := &Term{SchoolID: "school", AcademicYearID: "year", Name: "Q1", Type: TermTypeQuarter, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2025, time.October, 31)}
+	err := calendarService.CreateTerm(sessionContext("admin"), term)
+
+	assert.NoError(t, err)
+}
+
+func TestCalendarService_CreateTerm_ReturnsNotFoundForYearOfOtherSchool(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			year := schoolYear2025()
+			year.SchoolID = "other-school"
+			return year, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	term := &Term{SchoolID: "school", AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}
+	err := calendarService.CreateTerm(sessionContext("admin"), term)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCalendarService_CreateTerm_ReturnsErrorForUnknownType(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetAcademicYearFunc: func(ctx context.Context, id string) (*AcademicYear, error) {
+			return schoolYear2025(), nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	term := &Term{SchoolID: "school", AcademicYearID: "year", Name: "Fall", Type: "season", StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}
+	err := calendarService.CreateTerm(sessionContext("admin"), term)
+
+	assert.Error(t, err)
+}
+
+func TestCalendarService_ActiveTerms_ReturnsMostSpecificFirst(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		ListTermsFunc: func(ctx context.Context, schoolID string) ([]*Term, error) {
+			return []*Term{
+				{ID: "fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)},
+				{ID: "q1", Type: TermTypeQuarter, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2025, time.October, 31)},
+				{ID: "q2", Type: TermTypeQuarter, StartDate: NewDate(2025, time.November, 3), EndDate: NewDate(2026, time.January, 16)},
+			}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	terms, err := calendarService.ActiveTerms(sessionContext("teacher"), "school", NewDate(2025, time.September, 10))
+
+	assert.NoError(t, err)
+	assert.Len(t, terms, 2)
+	assert.Equal(t, "q1", terms[0].ID)
+	assert.Equal(t, "fall", terms[1].ID)
+}
+
+func TestCalendarService_ListTerms_ReturnsErrorForNonMember(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	terms, err := calendarService.ListTerms(sessionContext("stranger"), "school")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, terms)
+}
+
+func TestCalendarService_CreateEvent_ReturnsErrorForUnknownType(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	event := &CalendarEvent{SchoolID: "school", Name: "Picnic", Type: "party", StartDate: NewDate(2025, time.October, 1), EndDate: NewDate(2025, time.October, 1)}
+	err := calendarService.CreateEvent(sessionContext("admin"), event)
+
+	assert.Error(t, err)
+}
+
+func TestCalendarService_DeleteEvent_ReturnsErrorForNonAdmin(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		GetEventFunc: func(ctx context.Context, id string) (*CalendarEvent, error) {
+			return &CalendarEvent{ID: id, SchoolID: "school"}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := calendarService.DeleteEvent(sessionContext("teacher"), "event")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCalendarService_InstructionalDays_SkipsWeekendsAndEvents(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		ListAcademicYearsFunc: func(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+			return []*AcademicYear{schoolYear2025()}, nil
+		},
+		ListEventsFunc: func(ctx context.Context, schoolID string) ([]*CalendarEvent, error) {
+			return []*CalendarEvent{
+				{Name: "Thanksgiving", Type: CalendarEventHoliday, StartDate: NewDate(2025, time.November, 27), EndDate: NewDate(2025, time.November, 28)},
+			}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	// Monday the 24th through Sunday the 30th, with Thursday and Friday off
+	days, err := calendarService.InstructionalDays(sessionContext("teacher"), "school", NewDate(2025, time.November, 24), NewDate(2025, time.November, 30))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Date{NewDate(2025, time.November, 24), NewDate(2025, time.November, 25), NewDate(2025, time.November, 26)}, days)
+}
+
+func TestCalendarService_InstructionalDays_SkipsDaysOutsideAcademicYear(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{
+		ListAcademicYearsFunc: func(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+			return []*AcademicYear{schoolYear2025()}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	days, err := calendarService.InstructionalDays(sessionContext("teacher"), "school", NewDate(2025, time.August, 18), NewDate(2025, time.August, 29))
+
+	assert.NoError(t, err)
+	assert.Len(t, days, 5)
+}
+
+func TestCalendarService_InstructionalDays_ReturnsErrorForLongRange(t *testing.T) {
+	calendarService := NewCalendarService(&MockCalendarStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	days, err := calendarService.InstructionalDays(sessionContext("teacher"), "school", NewDate(2020, time.January, 1), NewDate(2025, time.January, 1))
+
+	assert.Error(t, err)
+	assert.Nil(t, days)
+}
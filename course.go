@@ -0,0 +1,560 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Grade levels run from pre-kindergarten (-1) and kindergarten (0) to 12
+const (
+	minGradeLevel = -1
+	maxGradeLevel = 12
+)
+
+// How demanding a course is. Honors and AP courses earn extra grade points in weighted GPAs.
+const (
+	CourseLevelStandard = "standard"
+	CourseLevelHonors   = "honors"
+	CourseLevelAP       = "ap"
+)
+
+var courseLevels = []string{CourseLevelStandard, CourseLevelHonors, CourseLevelAP}
+
+type Course struct {
+	ID              string    `json:"id"`
+	OrganizationID  string    `json:"organizationId"`
+	Code            string    `json:"code"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	Subject         string    `json:"subject"`
+	Credits         float64   `json:"credits"`
+	Level           string    `json:"level"`
+	GradeLevels     []int     `json:"gradeLevels"`
+	PrerequisiteIDs []string  `json:"prerequisiteIds"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type CoursePostgresStore struct {
+	db *PostgresDB
+}
+
+type CourseStore interface {
+	Create(ctx context.Context, course *Course) error
+	GetByID(ctx context.Context, id string) (*Course, error)
+	GetByCode(ctx context.Context, organizationID string, code string) (*Course, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*Course, error)
+	Update(ctx context.Context, course *Course) error
+	Delete(ctx context.Context, id string) error
+}
+
+const courseColumns = `id, organization_id, code, title, description, subject, credits, level, grade_levels, prerequisite_ids::text[], created_at, updated_at`
+
+func scanCourse(row rowScanner) (*Course, error) {
+	var course Course
+
+	err := row.Scan(
+		&course.ID,
+		&course.OrganizationID,
+		&course.Code,
+		&course.Title,
+		&course.Description,
+		&course.Subject,
+		&course.Credits,
+		&course.Level,
+		&course.GradeLevels,
+		&course.PrerequisiteIDs,
+		&course.CreatedAt,
+		&course.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+func (s *CoursePostgresStore) Create(ctx context.Context, course *Course) error {
+	query := `
+		INSERT INTO courses (organization_id, code, title, description, subject, credits, level, grade_levels, prerequisite_ids, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::uuid[], $10, $11)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		course.OrganizationID,
+		course.Code,
+		course.Title,
+		course.Description,
+		course.Subject,
+		course.Credits,
+		course.Level,
+		course.GradeLevels,
+		course.PrerequisiteIDs,
+		course.CreatedAt,
+		course.UpdatedAt,
+	)
+
+	return row.Scan(&course.ID)
+}
+
+func (s *CoursePostgresStore) GetByID(ctx context.Context, id string) (*Course, error) {
+	query := `SELECT ` + courseColumns + ` FROM courses WHERE id = $1`
+
+	return noRowsAsNil(scanCourse(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *CoursePostgresStore) GetByCode(ctx context.Context, organizationID string, code string) (*Course, error) {
+	query := `SELECT ` + courseColumns + ` FROM courses WHERE organization_id = $1 AND lower(code) = lower($2)`
+
+	return noRowsAsNil(scanCourse(s.db.pool.QueryRow(ctx, query, organizationID, code)))
+}
+
+func (s *CoursePostgresStore) ListByOrganization(ctx context.Context, organizationID string) ([]*Course, error) {
+	query := `SELECT ` + courseColumns + ` FROM courses WHERE organization_id = $1 ORDER BY code`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var courses []*Course
+
+	for rows.Next() {
+		course, err := scanCourse(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		courses = append(courses, course)
+	}
+
+	return courses, rows.Err()
+}
+
+func (s *CoursePostgresStore) Update(ctx context.Context, course *Course) error {
+	query := `
+		UPDATE courses
+		SET code = $1, title = $2, description = $3, subject = $4, credits = $5, level = $6, grade_levels = $7, prerequisite_ids = $8::uuid[], updated_at = $9
+		WHERE id = $10
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		course.Code,
+		course.Title,
+		course.Description,
+		course.Subject,
+		course.Credits,
+		course.Level,
+		course.GradeLevels,
+		course.PrerequisiteIDs,
+		course.UpdatedAt,
+		course.ID,
+	)
+
+	return err
+}
+
+func (s *CoursePostgresStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM courses WHERE id = $1`, id)
+	return err
+}
+
+func validateCourse(course *Course) error {
+	if course.OrganizationID == "" {
+		return errors.New("organization id is required")
+	}
+
+	if course.Code == "" {
+		return errors.New("code is required")
+	}
+
+	if course.Title == "" {
+		return errors.New("title is required")
+	}
+
+	if course.Credits < 0 {
+		return errors.New("credits must not be negative")
+	}
+
+	for _, level := range course.GradeLevels {
+		if level < minGradeLevel || level > maxGradeLevel {
+			return fmt.Errorf("grade levels must be between %d and %d", minGradeLevel, maxGradeLevel)
+		}
+	}
+
+	if !slices.Contains(courseLevels, course.Level) {
+		return fmt.Errorf("level must be one of %v", courseLevels)
+	}
+
+	if course.ID != "" && slices.Contains(course.PrerequisiteIDs, course.ID) {
+		return errors.New("a course cannot be its own prerequisite")
+	}
+
+	return nil
+}
+
+// Checks that the prerequisites of course are courses of the same organization and that
+// following them never leads back to course
+func checkPrerequisites(course *Course, courses []*Course) error {
+	byID := make(map[string]*Course, len(courses))
+
+	for _, other := range courses {
+		byID[other.ID] = other
+	}
+
+	for _, id := range course.PrerequisiteIDs {
+		if _, ok := byID[id]; !ok {
+			return fmt.Errorf("prerequisite %s is not a course of this organization", id)
+		}
+	}
+
+	if course.ID == "" {
+		return nil
+	}
+
+	visited := map[string]bool{}
+	pending := slices.Clone(course.PrerequisiteIDs)
+
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if id == course.ID {
+			return errors.New("prerequisites must not form a cycle")
+		}
+
+		if visited[id] {
+			continue
+		}
+
+		visited[id] = true
+
+		if prerequisite, ok := byID[id]; ok {
+			pending = append(pending, prerequisite.PrerequisiteIDs...)
+		}
+	}
+
+	return nil
+}
+
+type CourseService struct {
+	courseStore  CourseStore
+	sectionStore SectionStore
+	gpaStore     GPAStore
+	memberStore  OrganizationMemberStore
+	auditService *AuditService
+}
+
+func NewCourseService(courseStore CourseStore, sectionStore SectionStore, gpaStore GPAStore, memberStore OrganizationMemberStore, auditService *AuditService) *CourseService {
+	return &CourseService{courseStore: courseStore, sectionStore: sectionStore, gpaStore: gpaStore, memberStore: memberStore, auditService: auditService}
+}
+
+// Validates a new or changed course against the rest of its organization's catalog
+func (s *CourseService) check(ctx context.Context, course *Course) error {
+	if err := validateCourse(course); err != nil {
+		return err
+	}
+
+	existing, err := s.courseStore.GetByCode(ctx, course.OrganizationID, course.Code)
+
+	if err != nil {
+		slog.Error("failed to get course by code", "error", err)
+		return ErrInternal
+	}
+
+	if existing != nil && existing.ID != course.ID {
+		return fmt.Errorf("course code %s is already in use", course.Code)
+	}
+
+	if len(course.PrerequisiteIDs) == 0 {
+		return nil
+	}
+
+	courses, err := s.courseStore.ListByOrganization(ctx, course.OrganizationID)
+
+	if err != nil {
+		slog.Error("failed to list courses", "error", err)
+		return ErrInternal
+	}
+
+	return checkPrerequisites(course, courses)
+}
+
+func (s *CourseService) Create(ctx context.Context, course *Course) error {
+	if _, err := requireMembership(ctx, s.memberStore, course.OrganizationID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if course.Level == "" {
+		course.Level = CourseLevelStandard
+	}
+
+	if course.GradeLevels == nil {
+		course.GradeLevels = []int{}
+	}
+
+	if course.PrerequisiteIDs == nil {
+		course.PrerequisiteIDs = []string{}
+	}
+
+	if err := s.check(ctx, course); err != nil {
+		return err
+	}
+
+	course.CreatedAt = time.Now()
+	course.UpdatedAt = time.Now()
+
+	if err := s.courseStore.Create(ctx, course); err != nil {
+		slog.Error("failed to create course", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, course.OrganizationID, AuditActionCreate, "course", course.ID, nil, course)
+
+	return nil
+}
+
+// Returns the course if the session user belongs to its organization with one of the given roles
+func (s *CourseService) authorize(ctx context.Context, id string, roles ...string) (*Course, error) {
+	course, err := s.courseStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get course", "error", err)
+		return nil, ErrInternal
+	}
+
+	if course == nil {
+		return nil, notFound("course")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, course.OrganizationID, roles...); err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func (s *CourseService) GetByID(ctx context.Context, id string) (*Course, error) {
+	return s.authorize(ctx, id)
+}
+
+func (s *CourseService) ListByOrganization(ctx context.Context, organizationID string) ([]*Course, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID); err != nil {
+		return nil, err
+	}
+
+	courses, err := s.courseStore.ListByOrganization(ctx, organizationID)
+
+	if err != nil {
+		slog.Error("failed to list courses", "error", err)
+		return nil, ErrInternal
+	}
+
+	if courses == nil {
+		courses = []*Course{}
+	}
+
+	return courses, nil
+}
+
+type UpdateCourseRequest struct {
+	Code        string   `json:"code"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Subject     string   `json:"subject"`
+	Credits     *float64 `json:"credits"`
+	Level       string   `json:"level"`
+	// Replaces the grade levels when present
+	GradeLevels []int `json:"gradeLevels"`
+	// Replaces the prerequisites when present. An empty list removes them all.
+	PrerequisiteIDs []string `json:"prerequisiteIds"`
+}
+
+func (s *CourseService) Update(ctx context.Context, id string, request *UpdateCourseRequest) (*Course, error) {
+	existingCourse, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *existingCourse
+
+	if request.Code != "" {
+		existingCourse.Code = request.Code
+	}
+
+	if request.Title != "" {
+		existingCourse.Title = request.Title
+	}
+
+	if request.Description != "" {
+		existingCourse.Description = request.Description
+	}
+
+	if request.Subject != "" {
+		existingCourse.Subject = request.Subject
+	}
+
+	if request.Credits != nil {
+		existingCourse.Credits = *request.Credits
+	}
+
+	if request.Level != "" {
+		existingCourse.Level = request.Level
+	}
+
+	if request.GradeLevels != nil {
+		existingCourse.GradeLevels = request.GradeLevels
+	}
+
+	if request.PrerequisiteIDs != nil {
+		existingCourse.PrerequisiteIDs = request.PrerequisiteIDs
+	}
+
+	if err := s.check(ctx, existingCourse); err != nil {
+		return nil, err
+	}
+
+	existingCourse.UpdatedAt = time.Now()
+
+	if err := s.courseStore.Update(ctx, existingCourse); err != nil {
+		slog.Error("failed to update course", "error", err)
+		return nil, ErrInternal
+	}
+
+	// Credits and level count towards every GPA the course is on
+	if existingCourse.Credits != before.Credits || existingCourse.Level != before.Level {
+		if err := s.gpaStore.EnqueueCourse(ctx, id); err != nil {
+			slog.Error("failed to queue GPA recompute", "error", err, "courseId", id)
+		}
+	}
+
+	s.auditService.Record(ctx, existingCourse.OrganizationID, AuditActionUpdate, "course", id, &before, existingCourse)
+
+	return existingCourse, nil
+}
+
+// Deletes a course that has no sections and is not a prerequisite of another course
+func (s *CourseService) Delete(ctx context.Context, id string) error {
+	course, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	sections, err := s.sectionStore.List(ctx, &SectionFilter{CourseID: id})
+
+	if err != nil {
+		slog.Error("failed to list sections", "error", err)
+		return ErrInternal
+	}
+
+	if len(sections) > 0 {
+		return errors.New("course still has sections")
+	}
+
+	courses, err := s.courseStore.ListByOrganization(ctx, course.OrganizationID)
+
+	if err != nil {
+		slog.Error("failed to list courses", "error", err)
+		return ErrInternal
+	}
+
+	for _, other := range courses {
+		if slices.Contains(other.PrerequisiteIDs, id) {
+			return fmt.Errorf("course is a prerequisite of %s", other.Code)
+		}
+	}
+
+	if err := s.courseStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete course", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, course.OrganizationID, AuditActionDelete, "course", id, course, nil)
+
+	return nil
+}
+
+type CourseHandler struct {
+	courseService *CourseService
+}
+
+func (h *CourseHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var course Course
+
+	if err := decodeJSON(r, &course); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	course.ID = ""
+	course.OrganizationID = r.PathValue("organizationId")
+
+	if err := h.courseService.Create(r.Context(), &course); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, course)
+}
+
+func (h *CourseHandler) List(w http.ResponseWriter, r *http.Request) {
+	courses, err := h.courseService.ListByOrganization(r.Context(), r.PathValue("organizationId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, courses)
+}
+
+func (h *CourseHandler) Get(w http.ResponseWriter, r *http.Request) {
+	course, err := h.courseService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, course)
+}
+
+func (h *CourseHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateCourseRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	course, err := h.courseService.Update(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, course)
+}
+
+func (h *CourseHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.courseService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockCourseStore struct {
+	CreateFunc             func(ctx context.Context, course *Course) error
+	GetByIDFunc            func(ctx context.Context, id string) (*Course, error)
+	GetByCodeFunc          func(ctx context.Context, organizationID string, code string) (*Course, error)
+	ListByOrganizationFunc func(ctx context.Context, organizationID string) ([]*Course, error)
+	UpdateFunc             func(ctx context.Context, course *Course) error
+	DeleteFunc             func(ctx context.Context, id string) error
+}
+
+func (m *MockCourseStore) Create(ctx context.Context, course *Course) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, course)
+	}
+
+	return nil
+}
+
+func (m *MockCourseStore) GetByID(ctx context.Context, id string) (*Course, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCourseStore) GetByCode(ctx context.Context, organizationID string, code string) (*Course, error) {
+	if m.GetByCodeFunc != nil {
+		return m.GetByCodeFunc(ctx, organizationID, code)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCourseStore) ListByOrganization(ctx context.Context, organizationID string) ([]*Course, error) {
+	if m.ListByOrganizationFunc != nil {
+		return m.ListByOrganizationFunc(ctx, organizationID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockCourseStore) Update(ctx context.Context, course *Course) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, course)
+	}
+
+	return nil
+}
+
+func (m *MockCourseStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// Algebra 1 is a prerequisite of Geometry, which is a prerequisite of Algebra 2
+func mathCatalog() *MockCourseStore {
+	courses := []*Course{
+		{ID: "alg1", OrganizationID: "org", Code: "MATH101", Title: "Algebra 1", Level: CourseLevelStandard, PrerequisiteIDs: []string{}},
+		{ID: "geo", OrganizationID: "org", Code: "MATH201", Title: "Geometry", Level: CourseLevelStandard, PrerequisiteIDs: []string{"alg1"}},
+		{ID: "alg2", OrganizationID: "org", Code: "MATH301", Title: "Algebra 2", Level: CourseLevelStandard, PrerequisiteIDs: []string{"geo"}},
+	}
+
+	return &MockCourseStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Course, error) {
+			for _, course := range courses {
+				if course.ID == id {
+					copied := *course
+					return &copied, nil
+				}
+			}
+
+			return nil, nil
+		},
+		ListByOrganizationFunc: func(ctx context.Context, organizationID string) ([]*Course, error) {
+			return courses, nil
+		},
+	}
+}
+
+func TestValidateCourse_ReturnsErrorForMissingCode(t *testing.T) {
+	err := validateCourse(&Course{OrganizationID: "org", Title: "Algebra 1"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "code is required", err.Error())
+}
+
+func TestValidateCourse_ReturnsErrorForGradeLevelOutOfRange(t *testing.T) {
+	err := validateCourse(&Course{OrganizationID: "org", Code: "MATH101", Title: "Algebra 1", GradeLevels: []int{9, 13}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "grade levels must be between -1 and 12", err.Error())
+}
+
+func TestCourseService_Create_CreatesCourse(t *testing.T) {
+	courseService := NewCourseService(&MockCourseStore{
+		CreateFunc: func(ctx context.Context, course *Course) error {
+			course.ID = "course"
+			return nil
+		},
+	}, &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	course := &Course{OrganizationID: "org", Code: "ENG101", Title: "English 9", Credits: 1, GradeLevels: []int{9}}
+	err := courseService.Create(sessionContext("admin"), course)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "course", course.ID)
+	assert.Equal(t, CourseLevelStandard, course.Level)
+	assert.Equal(t, []string{}, course.PrerequisiteIDs)
+}
+
+func TestCourseService_Create_ReturnsErrorForUnknownLevel(t *testing.T) {
+	courseService := NewCourseService(&MockCourseStore{}, &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Create(sessionContext("admin"), &Course{OrganizationID: "org", Code: "ENG101", Title: "English 9", Level: "ib"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "level must be one of [standard honors ap]", err.Error())
+}
+
+func TestCourseService_Create_ReturnsErrorForNonAdmin(t *testing.T) {
+	courseService := NewCourseService(&MockCourseStore{}, &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Create(sessionContext("teacher"), &Course{OrganizationID: "org", Code: "ENG101", Title: "English 9"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCourseService_Create_ReturnsErrorForDuplicateCode(t *testing.T) {
+	courseService := NewCourseService(&MockCourseStore{
+		GetByCodeFunc: func(ctx context.Context, organizationID string, code string) (*Course, error) {
+			return &Course{ID: "existing", OrganizationID: organizationID, Code: "ENG101"}, nil
+		},
+	}, &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Create(sessionContext("admin"), &Course{OrganizationID: "org", Code: "eng101", Title: "English 9"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "course code eng101 is already in use", err.Error())
+}
+
+func TestCourseService_Create_ReturnsErrorForUnknownPrerequisite(t *testing.T) {
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Create(sessionContext("admin"), &Course{OrganizationID: "org", Code: "MATH401", Title: "Calculus", PrerequisiteIDs: []string{"physics"}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "prerequisite physics is not a course of this organization", err.Error())
+}
+
+func TestCourseService_Update_ReturnsErrorForPrerequisiteCycle(t *testing.T) {
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	course, err := courseService.Update(sessionContext("admin"), "alg1", &UpdateCourseRequest{PrerequisiteIDs: []string{"alg2"}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "prerequisites must not form a cycle", err.Error())
+	assert.Nil(t, course)
+}
+
+func TestCourseService_Update_UpdatesOnlyProvidedFields(t *testing.T) {
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	credits := 0.5
+
+	course, err := courseService.Update(sessionContext("admin"), "geo", &UpdateCourseRequest{Credits: &credits})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Geometry", course.Title)
+	assert.Equal(t, 0.5, course.Credits)
+	assert.Equal(t, []string{"alg1"}, course.PrerequisiteIDs)
+}
+
+func TestCourseService_Update_QueuesGPARecomputeWhenLevelChanges(t *testing.T) {
+	var queued []string
+	gpaStore := &MockGPAStore{
+		EnqueueCourseFunc: func(ctx context.Context, courseID string) error {
+			queued = append(queued, courseID)
+			return nil
+		},
+	}
+
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{}, gpaStore, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := courseService.Update(sessionContext("admin"), "geo", &UpdateCourseRequest{Title: "Geometry Honors"})
+	assert.NoError(t, err)
+	assert.Empty(t, queued)
+
+	course, err := courseService.Update(sessionContext("admin"), "geo", &UpdateCourseRequest{Level: CourseLevelHonors})
+	assert.NoError(t, err)
+	assert.Equal(t, CourseLevelHonors, course.Level)
+	assert.Equal(t, []string{"geo"}, queued)
+}
+
+func TestCourseService_GetByID_ReturnsErrorForNonMember(t *testing.T) {
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	course, err := courseService.GetByID(sessionContext("stranger"), "geo")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, course)
+}
+
+func TestCourseService_Delete_ReturnsErrorWhenCourseIsPrerequisite(t *testing.T) {
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Delete(sessionContext("admin"), "geo")
+
+	assert.Error(t, err)
+	assert.Equal(t, "course is a prerequisite of MATH301", err.Error())
+}
+
+func TestCourseService_Delete_ReturnsErrorWhenSectionsExist(t *testing.T) {
+	courseService := NewCourseService(mathCatalog(), &MockSectionStore{
+		ListFunc: func(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+			return []*Section{{ID: "section", CourseID: filter.CourseID}}, nil
+		},
+	}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Delete(sessionContext("admin"), "alg2")
+
+	assert.Error(t, err)
+	assert.Equal(t, "course still has sections", err.Error())
+}
+
+func TestCourseService_Delete_ReturnsInternalErrorOnStoreFailure(t *testing.T) {
+	store := mathCatalog()
+	store.DeleteFunc = func(ctx context.Context, id string) error {
+		return errors.New("db error")
+	}
+
+	courseService := NewCourseService(store, &MockSectionStore{}, &MockGPAStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := courseService.Delete(sessionContext("admin"), "alg2")
+
+	assert.ErrorIs(t, err, ErrInternal)
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusCompleted  = "completed"
+	DataExportStatusFailed     = "failed"
+)
+
+const (
+	dataExportRetention    = 7 * 24 * time.Hour
+	dataExportPollInterval = 10 * time.Second
+	maxDataExportSize      = 1 << 30
+)
+
+type DataExport struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"userId"`
+	RequestedByUserID string     `json:"requestedByUserId"`
+	Status            string     `json:"status"`
+	Error             string     `json:"error,omitempty"`
+	DownloadURL       string     `json:"downloadUrl,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	CompletedAt       *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+}
+
+type DataExportPostgresStore struct {
+	db *PostgresDB
+}
+
+type DataExportStore interface {
+	Create(ctx context.Context, export *DataExport) error
+	GetByID(ctx context.Context, id string) (*DataExport, error)
+	ClaimPending(ctx context.Context) (*DataExport, error)
+	// Marks the export completed once its archive is in the blob store
+	Complete(ctx context.Context, id string, completedAt time.Time, expiresAt time.Time) error
+	Fail(ctx context.Context, id string, message string) error
+	// Marks the archives of expired exports as gone and returns the ids of those exports,
+	// so their blobs can be deleted
+	DeleteExpired(ctx context.Context, now time.Time) ([]string, error)
+	// Like DeleteExpired, for every export of a user
+	DeleteArchivesByUser(ctx context.Context, userID string) ([]string, error)
+	// Returns the ids of the user's exports whose archives are still stored
+	ListArchivesByUser(ctx context.Context, userID string) ([]string, error)
+}
+
+const dataExportColumns = `id, user_id, requested_by_user_id, status, error, created_at, completed_at, expires_at`
+
+func scanDataExport(row interface{ Scan(dest ...any) error }) (*DataExport, error) {
+	var export DataExport
+
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.RequestedByUserID,
+		&export.Status,
+		&export.Error,
+		&export.CreatedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+func (s *DataExportPostgresStore) Create(ctx context.Context, export *DataExport) error {
+	query := `
+		INSERT INTO data_exports (user_id, requested_by_user_id, status, error, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, export.UserID, export.RequestedByUserID, export.Status, export.Error, export.CreatedAt)
+
+	if err := row.Scan(&export.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *DataExportPostgresStore) GetByID(ctx context.Context, id string) (*DataExport, error) {
+	query := `
+		SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE id = $1
+	`
+
+	return scanDataExport(s.db.pool.QueryRow(ctx, query, id))
+}
+
+// Marks the oldest pending export as processing and returns it, or nil if there is none.
+// Concurrent workers never claim the same export.
+func (s *DataExportPostgresStore) ClaimPending(ctx context.Context) (*DataExport, error) {
+	query := `
+		UPDATE data_exports
+		SET status = 'processing'
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + dataExportColumns
+
+	return scanDataExport(s.db.pool.QueryRow(ctx, query))
+}
+
+func (s *DataExportPostgresStore) Complete(ctx context.Context, id string, completedAt time.Time, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'completed', archive_stored = TRUE, completed_at = $1, expires_at = $2
+		WHERE id = $3
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, completedAt, expiresAt, id)
+
+	return err
+}
+
+func (s *DataExportPostgresStore) Fail(ctx context.Context, id string, message string) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'failed', error = $1
+		WHERE id = $2
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, message, id)
+
+	return err
+}
+
+// Runs an UPDATE ... RETURNING id query and collects the ids
+// The export rows are kept as a record
+func (s *DataExportPostgresStore) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	query := `
+		UPDATE data_exports
+		SET archive_stored = FALSE
+		WHERE expires_at < $1 AND archive_stored
+		RETURNING id
+	`
+
+	return s.db.queryIDs(ctx, query, now)
+}
+
+func (s *DataExportPostgresStore) DeleteArchivesByUser(ctx context.Context, userID string) ([]string, error) {
+	query := `
+		UPDATE data_exports
+		SET archive_stored = FALSE
+		WHERE user_id = $1 AND archive_stored
+		RETURNING id
+	`
+
+	return s.db.queryIDs(ctx, query, userID)
+}
+
+func (s *DataExportPostgresStore) ListArchivesByUser(ctx context.Context, userID string) ([]string, error) {
+	query := `
+		SELECT id
+		FROM data_exports
+		WHERE user_id = $1 AND archive_stored
+	`
+
+	return s.db.queryIDs(ctx, query, userID)
+}
+
+func dataExportArchiveKey(id string) string {
+	return "exports/" + id + ".zip"
+}
+
+// Deletes the archives of the exports from the blob store, logging failures
+func deleteDataExportArchives(ctx context.Context, blobStore BlobStore, ids []string) {
+	for _, id := range ids {
+		if err := blobStore.Delete(ctx, dataExportArchiveKey(id)); err != nil {
+			slog.Error("failed to delete data export archive", "error", err, "exportId", id)
+		}
+	}
+}
+
+// Collects the records about a user that belong in one section of their data export
+type DataExportCollector func(ctx context.Context, userID string) ([]any, error)
+
+type dataExportSection struct {
+	name    string
+	collect DataExportCollector
+}
+
+type DataExportService struct {
+	exportStore  DataExportStore
+	memberStore  OrganizationMemberStore
+	auditService *AuditService
+	sections     []dataExportSection
+	blobStore    BlobStore
+}
+
+func NewDataExportService(exportStore DataExportStore, userStore UserStore, memberStore OrganizationMemberStore, auditService *AuditService, blobStore BlobStore) *DataExportService {
+	s := &DataExportService{exportStore: exportStore, memberStore: memberStore, auditService: auditService, blobStore: blobStore}
+
+	s.AddSection("profile", func(ctx context.Context, userID string) ([]any, error) {
+		user, err := userStore.GetByID(ctx, userID)
+
+		// The user may have been deleted since the export was queued
+		if errors.Is(err, sql.ErrNoRows) {
+			user, err = userStore.GetDeletedByID(ctx, userID)
+		}
+
+		if err != nil || user == nil {
+			return nil, err
+		}
+
+		user.Password = ""
+
+		return []any{user}, nil
+	})
+
+	s.AddSection("memberships", func(ctx context.Context, userID string) ([]any, error) {
+		members, err := memberStore.ListByUser(ctx, userID)
+		return toAnySlice(members), err
+	})
+
+	s.AddSection("audit", func(ctx context.Context, userID string) ([]any, error) {
+		return collectUserAuditEntries(ctx, auditService.auditStore, userID)
+	})
+
+	return s
+}
+
+// Adds a section to every data export. Sections appear in the archive in the order they were added.
+func (s *DataExportService) AddSection(name string, collect DataExportCollector) {
+	s.sections = append(s.sections, dataExportSection{name: name, collect: collect})
+}
+
+func toAnySlice[T any](items []T) []any {
+	result := make([]any, len(items))
+
+	for i, item := range items {
+		result[i] = item
+	}
+
+	return result
+}
+
+// Returns audit entries the user performed, was impersonated during, or that changed their account
+func collectUserAuditEntries(ctx context.Context, auditStore AuditStore, userID string) ([]any, error) {
+	byActor, err := auditStore.List(ctx, &AuditFilter{ActorUserID: userID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	bySubject, err := auditStore.List(ctx, &AuditFilter{EntityType: "user", EntityID: userID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var entries []*AuditEntry
+
+	for _, entry := range append(byActor, bySubject...) {
+		if !seen[entry.ID] {
+			seen[entry.ID] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b *AuditEntry) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return toAnySlice(entries), nil
+}
+
+// Queues an export of everything tied to a user. Only users may export their own data: the
+// account is shared by every organization they belong to, so an export requested by one of
+// their admins would hand over records kept by the others.
+func (s *DataExportService) Request(ctx context.Context, userID string) (*DataExport, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	if session.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	export := &DataExport{
+		UserID:            userID,
+		RequestedByUserID: session.UserID,
+		Status:            DataExportStatusPending,
+		CreatedAt:         time.Now(),
+	}
+
+	if err := s.exportStore.Create(ctx, export); err != nil {
+		slog.Error("failed to create data export", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.RecordUser(ctx, AuditActionExport, userID, nil, nil)
+
+	return export, nil
+}
+
+// Returns the export to the user it belongs to or the user who requested it, with a signed
+// download link once the archive is ready
+func (s *DataExportService) Get(ctx context.Context, id string, now time.Time) (*DataExport, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	export, err := s.exportStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get data export", "error", err)
+		return nil, ErrInternal
+	}
+
+	if export == nil || (session.UserID != export.UserID && session.UserID != export.RequestedByUserID) {
+		return nil, notFound("data export")
+	}
+
+	if export.Status == DataExportStatusCompleted && export.ExpiresAt != nil && now.Before(*export.ExpiresAt) {
+		url, err := s.blobStore.SignedURL(ctx, dataExportArchiveKey(export.ID), *export.ExpiresAt)
+
+		if err != nil {
+			slog.Error("failed to sign data export download url", "error", err)
+			return nil, ErrInternal
+		}
+
+		export.DownloadURL = url
+	}
+
+	return export, nil
+}
+
+// Builds the archive for the next pending export. Returns false when there was nothing to do.
+func (s *DataExportService) ProcessNext(ctx context.Context) (bool, error) {
+	export, err := s.exportStore.ClaimPending(ctx)
+
+	if err != nil {
+		slog.Error("failed to claim data export", "error", err)
+		return false, ErrInternal
+	}
+
+	if export == nil {
+		return false, nil
+	}
+
+	archive, err := s.buildArchive(ctx, export.UserID)
+
+	if err != nil {
+		slog.Error("failed to build data export", "error", err, "exportId", export.ID)
+
+		if err := s.exportStore.Fail(ctx, export.ID, "failed to build export"); err != nil {
+			slog.Error("failed to mark data export as failed", "error", err, "exportId", export.ID)
+		}
+
+		return true, nil
+	}
+
+	if _, err := putBlob(ctx, s.blobStore, dataExportArchiveKey(export.ID), bytes.NewReader(archive), "application/zip", maxDataExportSize); err != nil {
+		slog.Error("failed to store data export archive", "error", err, "exportId", export.ID)
+
+		if err := s.exportStore.Fail(ctx, export.ID, "failed to store export"); err != nil {
+			slog.Error("failed to mark data export as failed", "error", err, "exportId", export.ID)
+		}
+
+		return true, nil
+	}
+
+	completedAt := time.Now()
+
+	if err := s.exportStore.Complete(ctx, export.ID, completedAt, completedAt.Add(dataExportRetention)); err != nil {
+		slog.Error("failed to complete data export", "error", err, "exportId", export.ID)
+		return true, ErrInternal
+	}
+
+	return true, nil
+}
+
+// Processes exports and drops expired archives until ctx is cancelled
+func (s *DataExportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(dataExportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.ProcessNext(ctx)
+
+			if err != nil || !processed {
+				break
+			}
+		}
+
+		ids, err := s.exportStore.DeleteExpired(ctx, time.Now())
+
+		if err != nil {
+			slog.Error("failed to delete expired data exports", "error", err)
+		}
+
+		deleteDataExportArchives(ctx, s.blobStore, ids)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collects every section and writes it to a zip archive as export.json plus one CSV per section
+func (s *DataExportService) buildArchive(ctx context.Context, userID string) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	everything := map[string][]any{}
+
+	for _, section := range s.sections {
+		records, err := section.collect(ctx, userID)
+
+		if err != nil {
+			return nil, fmt.Errorf("collecting %s: %w", section.name, err)
+		}
+
+		if records == nil {
+			records = []any{}
+		}
+
+		everything[section.name] = records
+
+		data, err := recordsToCSV(records)
+
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", section.name, err)
+		}
+
+		w, err := archive.Create(section.name + ".csv")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := archive.Create("export.json")
+
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(everything); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Writes records as CSV with one column per JSON field. Nested values are written as JSON.
+func recordsToCSV(records []any) ([]byte, error) {
+	rows := make([]map[string]any, len(records))
+	columnSet := map[string]bool{}
+
+	for i, record := range records {
+		b, err := json.Marshal(record)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(b, &rows[i]); err != nil {
+			return nil, err
+		}
+
+		for column := range rows[i] {
+			columnSet[column] = true
+		}
+	}
+
+	columns := make([]string, 0, len(columnSet))
+
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+
+	sort.Strings(columns)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		values := make([]string, len(columns))
+
+		for i, column := range columns {
+			switch value := row[column].(type) {
+			case nil:
+			case string:
+				values[i] = value
+			default:
+				b, err := json.Marshal(value)
+
+				if err != nil {
+					return nil, err
+				}
+
+				values[i] = string(b)
+			}
+		}
+
+		if err := w.Write(values); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+type DataExportHandler struct {
+	dataExportService *DataExportService
+}
+
+func (h *DataExportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	export, err := h.dataExportService.Request(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, export)
+}
+
+func (h *DataExportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	export, err := h.dataExportService.Get(r.Context(), r.PathValue("id"), time.Now())
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, export)
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockDataExportStore struct {
+	CreateFunc               func(ctx context.Context, export *DataExport) error
+	GetByIDFunc              func(ctx context.Context, id string) (*DataExport, error)
+	ClaimPendingFunc         func(ctx context.Context) (*DataExport, error)
+	CompleteFunc             func(ctx context.Context, id string, completedAt time.Time, expiresAt time.Time) error
+	FailFunc                 func(ctx context.Context, id string, message string) error
+	DeleteExpiredFunc        func(ctx context.Context, now time.Time) ([]string, error)
+	DeleteArchivesByUserFunc func(ctx context.Context, userID string) ([]string, error)
+	ListArchivesByUserFunc   func(ctx context.Context, userID string) ([]string, error)
+}
+
+func (m *MockDataExportStore) Create(ctx context.Context, export *DataExport) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, export)
+	}
+
+	return nil
+}
+
+func (m *MockDataExportStore) GetByID(ctx context.Context, id string) (*DataExport, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockDataExportStore) ClaimPending(ctx context.Context) (*DataExport, error) {
+	if m.ClaimPendingFunc != nil {
+		return m.ClaimPendingFunc(ctx)
+	}
+
+	return nil, nil
+}
+
+func (m *MockDataExportStore) Complete(ctx context.Context, id string, completedAt time.Time, expiresAt time.Time) error {
+	if m.CompleteFunc != nil {
+		return m.CompleteFunc(ctx, id, completedAt, expiresAt)
+	}
+
+	return nil
+}
+
+func (m *MockDataExportStore) Fail(ctx context.Context, id string, message string) error {
+	if m.FailFunc != nil {
+		return m.FailFunc(ctx, id, message)
+	}
+
+	return nil
+}
+
+func (m *MockDataExportStore) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	if m.DeleteExpiredFunc != nil {
+		return m.DeleteExpiredFunc(ctx, now)
+	}
+
+	return nil, nil
+}
+
+func (m *MockDataExportStore) DeleteArchivesByUser(ctx context.Context, userID string) ([]string, error) {
+	if m.DeleteArchivesByUserFunc != nil {
+		return m.DeleteArchivesByUserFunc(ctx, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockDataExportStore) ListArchivesByUser(ctx context.Context, userID string) ([]string, error) {
+	if m.ListArchivesByUserFunc != nil {
+		return m.ListArchivesByUserFunc(ctx, userID)
+	}
+
+	return nil, nil
+}
+
+var testSigningKey = []byte("test-signing-key")
+
+// Members for whom every user is a teacher of org
+func orgTeachers() *MockOrganizationMemberStore {
+	members := orgMembers()
+	members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleTeacher}}, nil
+	}
+
+	return members
+}
+
+func TestDataExportService_Request_ReturnsErrorForUnrelatedUser(t *testing.T) {
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	export, err := dataExportService.Request(sessionContext("student"), "teacher")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, export)
+}
+
+func TestDataExportService_Request_ReturnsErrorWhileImpersonating(t *testing.T) {
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	ctx := contextWithSession(context.Background(), &Session{UserID: "teacher", ImpersonatorUserID: "admin", OrganizationID: "org"})
+	export, err := dataExportService.Request(ctx, "teacher")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, export)
+}
+
+func TestDataExportService_Request_QueuesExportForSelf(t *testing.T) {
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	export, err := dataExportService.Request(sessionContext("teacher"), "teacher")
+
+	assert.NoError(t, err)
+	assert.Equal(t, DataExportStatusPending, export.Status)
+	assert.Equal(t, "teacher", export.RequestedByUserID)
+}
+
+func TestDataExportService_Request_ReturnsErrorForAdmin(t *testing.T) {
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		CreateFunc: func(ctx context.Context, export *DataExport) error {
+			t.Fatal("admins must not export another user's data")
+			return nil
+		},
+	}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	export, err := dataExportService.Request(sessionContext("admin"), "teacher")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, export)
+}
+
+func TestDataExportService_Request_RecordsExportInUsersOrganizations(t *testing.T) {
+	var entry *AuditEntry
+	members := orgTeachers()
+	auditService := NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	}, members)
+	dataExportService := NewDataExportService(&MockDataExportStore{}, &MockUserStore{}, members, auditService, &MockBlobStore{})
+
+	_, err := dataExportService.Request(sessionContext("teacher"), "teacher")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user.export", entry.Action)
+	assert.Equal(t, "org", entry.OrganizationID)
+}
+
+func TestDataExportService_Get_HidesExportFromOtherUsers(t *testing.T) {
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*DataExport, error) {
+			return &DataExport{ID: id, UserID: "teacher", RequestedByUserID: "teacher"}, nil
+		},
+	}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	export, err := dataExportService.Get(sessionContext("student"), "1", time.Now())
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, export)
+}
+
+func TestDataExportService_Get_SignsDownloadURLForCompletedExport(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*DataExport, error) {
+			return &DataExport{ID: id, UserID: "teacher", Status: DataExportStatusCompleted, ExpiresAt: &expiresAt}, nil
+		},
+	}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	export, err := dataExportService.Get(sessionContext("teacher"), "1", time.Now())
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, export.DownloadURL)
+
+	r := httptest.NewRequest("GET", export.DownloadURL, nil)
+	assert.Equal(t, "/blobs/exports/1.zip", r.URL.Path)
+	assert.NoError(t, verifySignedURL(testSigningKey, r, time.Now()))
+}
+
+func TestDataExportService_Get_OmitsDownloadURLForExpiredExport(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Hour)
+
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*DataExport, error) {
+			return &DataExport{ID: id, UserID: "teacher", Status: DataExportStatusCompleted, ExpiresAt: &expiresAt}, nil
+		},
+	}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	export, err := dataExportService.Get(sessionContext("teacher"), "1", time.Now())
+
+	assert.NoError(t, err)
+	assert.Empty(t, export.DownloadURL)
+}
+
+func TestDataExportService_ProcessNext_ReturnsFalseWhenNothingPending(t *testing.T) {
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	processed, err := dataExportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestDataExportService_ProcessNext_MarksExportFailedWhenSectionFails(t *testing.T) {
+	var failedID string
+
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		ClaimPendingFunc: func(ctx context.Context) (*DataExport, error) {
+			return &DataExport{ID: "1", UserID: "teacher"}, nil
+		},
+		FailFunc: func(ctx context.Context, id string, message string) error {
+			failedID = id
+			return nil
+		},
+	}, &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return nil, errors.New("random error")
+		},
+	}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{})
+
+	processed, err := dataExportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, "1", failedID)
+}
+
+func TestDataExportService_ProcessNext_BuildsArchiveWithJSONAndCSV(t *testing.T) {
+	var completedID string
+
+	blobs := &MockBlobStore{}
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		ClaimPendingFunc: func(ctx context.Context) (*DataExport, error) {
+			return &DataExport{ID: "1", UserID: "teacher"}, nil
+		},
+		CompleteFunc: func(ctx context.Context, id string, completedAt time.Time, expiresAt time.Time) error {
+			completedID = id
+			assert.Equal(t, dataExportRetention, expiresAt.Sub(completedAt))
+			return nil
+		},
+	}, &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: id, FirstName: "John", LastName: "Doe", Email: "john.doe@example.com", Password: "hash"}, nil
+		},
+	}, members, NewAuditService(&MockAuditStore{}, members), blobs)
+
+	processed, err := dataExportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, "1", completedID)
+	assert.Equal(t, "application/zip", blobs.meta["exports/1.zip"].ContentType)
+
+	archive := blobs.blobs["exports/1.zip"]
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+
+	for _, file := range reader.File {
+		f, err := file.Open()
+		assert.NoError(t, err)
+
+		b, err := io.ReadAll(f)
+		assert.NoError(t, err)
+
+		files[file.Name] = string(b)
+	}
+
+	assert.Contains(t, files, "profile.csv")
+	assert.Contains(t, files, "memberships.csv")
+	assert.Contains(t, files, "audit.csv")
+	assert.Contains(t, files["profile.csv"], "john.doe@example.com")
+	assert.NotContains(t, files["profile.csv"], "hash")
+
+	var everything map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(files["export.json"]), &everything))
+	assert.Equal(t, "John", everything["profile"][0]["firstName"])
+	assert.Len(t, everything["memberships"], 1)
+}
+
+func TestDataExportService_ProcessNext_ExportsProfileOfDeletedUser(t *testing.T) {
+	blobs := &MockBlobStore{}
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		ClaimPendingFunc: func(ctx context.Context) (*DataExport, error) {
+			return &DataExport{ID: "1", UserID: "teacher"}, nil
+		},
+	}, &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return nil, sql.ErrNoRows
+		},
+		GetDeletedByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: id, Email: "john.doe@example.com"}, nil
+		},
+	}, members, NewAuditService(&MockAuditStore{}, members), blobs)
+
+	processed, err := dataExportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.NotEmpty(t, blobs.blobs["exports/1.zip"])
+}
+
+func TestDataExportService_ProcessNext_MarksExportFailedWhenArchiveCannotBeStored(t *testing.T) {
+	var failedID string
+
+	members := orgTeachers()
+	dataExportService := NewDataExportService(&MockDataExportStore{
+		ClaimPendingFunc: func(ctx context.Context) (*DataExport, error) {
+			return &DataExport{ID: "1", UserID: "teacher"}, nil
+		},
+		CompleteFunc: func(ctx context.Context, id string, completedAt time.Time, expiresAt time.Time) error {
+			t.Fatal("export should not be completed")
+			return nil
+		},
+		FailFunc: func(ctx context.Context, id string, message string) error {
+			failedID = id
+			return nil
+		},
+	}, &MockUserStore{}, members, NewAuditService(&MockAuditStore{}, members), &MockBlobStore{PutErr: errors.New("disk full")})
+
+	processed, err := dataExportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, "1", failedID)
+}
+
+func TestRecordsToCSV_WritesOneColumnPerField(t *testing.T) {
+	data, err := recordsToCSV([]any{
+		map[string]any{"b": "x", "a": 1},
+		map[string]any{"a": 2, "c": []string{"y"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b,c\n1,x,\n2,,\"[\"\"y\"\"]\"\n", string(data))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// A calendar date without a time of day, written as YYYY-MM-DD in JSON and stored as DATE
+type Date struct {
+	time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(dateLayout, value)
+
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+
+	return Date{t}, nil
+}
+
+// Returns the date t falls on in its own location
+func DateOf(t time.Time) Date {
+	return NewDate(t.Year(), t.Month(), t.Day())
+}
+
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+func (d Date) AddDays(days int) Date {
+	return Date{d.AddDate(0, 0, days)}
+}
+
+// Reports whether d falls within start and end, inclusive
+func (d Date) Within(start Date, end Date) bool {
+	return !d.Before(start.Time) && !d.After(end.Time)
+}
+
+// Reports whether the inclusive ranges [aStart, aEnd] and [bStart, bEnd] share a day
+func datesOverlap(aStart Date, aEnd Date, bStart Date, bEnd Date) bool {
+	return !aStart.After(bEnd.Time) && !bStart.After(aEnd.Time)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+
+	if err := json.Unmarshal(data, &value); err != nil || value == "" {
+		*d = Date{}
+		return err
+	}
+
+	parsed, err := ParseDate(value)
+
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+func (d *Date) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = DateOf(value)
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.Time, nil
+}
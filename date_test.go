@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDate_MarshalJSON_WritesYearMonthDay(t *testing.T) {
+	data, err := json.Marshal(NewDate(2025, time.September, 2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, `"2025-09-02"`, string(data))
+}
+
+func TestDate_UnmarshalJSON_ReturnsErrorForInvalidDate(t *testing.T) {
+	var date Date
+
+	err := json.Unmarshal([]byte(`"09/02/2025"`), &date)
+
+	assert.Error(t, err)
+	assert.Equal(t, `invalid date "09/02/2025", expected YYYY-MM-DD`, err.Error())
+}
+
+func TestDate_Within_IncludesBothEnds(t *testing.T) {
+	start := NewDate(2025, time.September, 1)
+	end := NewDate(2025, time.September, 5)
+
+	assert.True(t, start.Within(start, end))
+	assert.True(t, end.Within(start, end))
+	assert.False(t, end.AddDays(1).Within(start, end))
+}
+
+func TestDatesOverlap_DetectsSharedDay(t *testing.T) {
+	assert.True(t, datesOverlap(NewDate(2025, time.January, 1), NewDate(2025, time.January, 10), NewDate(2025, time.January, 10), NewDate(2025, time.January, 20)))
+	assert.False(t, datesOverlap(NewDate(2025, time.January, 1), NewDate(2025, time.January, 9), NewDate(2025, time.January, 10), NewDate(2025, time.January, 20)))
+}
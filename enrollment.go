@@ -0,0 +1,855 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	EnrollmentStatusEnrolled   = "enrolled"
+	EnrollmentStatusWaitlisted = "waitlisted"
+	EnrollmentStatusDropped    = "dropped"
+	EnrollmentStatusCompleted  = "completed"
+	EnrollmentStatusFailed     = "failed"
+)
+
+// Statuses that hold a seat or a place on the waitlist
+var activeEnrollmentStatuses = []string{EnrollmentStatusEnrolled, EnrollmentStatusWaitlisted}
+
+var (
+	errSectionFull     = errors.New("section is full")
+	errAlreadyEnrolled = errors.New("student is already enrolled in this section")
+)
+
+type Enrollment struct {
+	ID            string `json:"id"`
+	SectionID     string `json:"sectionId"`
+	StudentUserID string `json:"studentUserId"`
+	Status        string `json:"status"`
+	// Copied from the section when the enrollment is read
+	CourseID string `json:"courseId"`
+	TermID   string `json:"termId"`
+	// Place in line among the section's waitlisted students, starting at 1
+	WaitlistPosition int        `json:"waitlistPosition,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	DroppedAt        *time.Time `json:"droppedAt,omitempty"`
+}
+
+func (e *Enrollment) IsActive() bool {
+	return slices.Contains(activeEnrollmentStatuses, e.Status)
+}
+
+// Narrows an enrollment listing. Empty fields match everything.
+type EnrollmentFilter struct {
+	// Lists enrollments in the sections of every school of the organization
+	OrganizationID string
+	SectionID      string
+	StudentUserID  string
+	Statuses       []string
+}
+
+type EnrollmentPostgresStore struct {
+	db *PostgresDB
+}
+
+type EnrollmentStore interface {
+	// Takes a seat in the section, or a place on its waitlist when the section is full and
+	// allowWaitlist is set. Returns errSectionFull otherwise, and errAlreadyEnrolled when the
+	// student already holds a seat or place in the section. check, if set, runs once the student
+	// is locked, so concurrent enrollments of one student are checked one at a time.
+	Enroll(ctx context.Context, enrollment *Enrollment, allowWaitlist bool, check func(ctx context.Context) error) error
+	GetByID(ctx context.Context, id string) (*Enrollment, error)
+	List(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error)
+	// Drops the enrollment and promotes waitlisted students into any seats that frees
+	Drop(ctx context.Context, id string) ([]*Enrollment, error)
+	// Promotes waitlisted students into the section's open seats
+	FillSeats(ctx context.Context, sectionID string) ([]*Enrollment, error)
+	SetStatus(ctx context.Context, id string, status string) error
+}
+
+const enrollmentColumns = `
+	e.id, e.section_id, e.student_user_id, e.status, s.course_id, s.term_id,
+	CASE WHEN e.status = 'waitlisted' THEN (
+		SELECT count(*) FROM enrollments w
+		WHERE w.section_id = e.section_id AND w.status = 'waitlisted' AND (w.created_at, w.id) <= (e.created_at, e.id)
+	) ELSE 0 END,
+	e.created_at, e.updated_at, e.dropped_at
+`
+
+func scanEnrollment(row rowScanner) (*Enrollment, error) {
+	var enrollment Enrollment
+
+	err := row.Scan(
+		&enrollment.ID,
+		&enrollment.SectionID,
+		&enrollment.StudentUserID,
+		&enrollment.Status,
+		&enrollment.CourseID,
+		&enrollment.TermID,
+		&enrollment.WaitlistPosition,
+		&enrollment.CreatedAt,
+		&enrollment.UpdatedAt,
+		&enrollment.DroppedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// Locks the section row so seat counts stay stable until the transaction ends. Every
+// transaction that changes who holds a seat takes this lock first.
+func lockSection(ctx context.Context, tx pgx.Tx, sectionID string) (int, error) {
+	var capacity int
+
+	err := tx.QueryRow(ctx, `SELECT capacity FROM sections WHERE id = $1 FOR UPDATE`, sectionID).Scan(&capacity)
+
+	return capacity, err
+}
+
+func (s *EnrollmentPostgresStore) Enroll(ctx context.Context, enrollment *Enrollment, allowWaitlist bool, check func(ctx context.Context) error) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	// NO KEY UPDATE still lets other rows reference the user
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR NO KEY UPDATE`, enrollment.StudentUserID); err != nil {
+		return err
+	}
+
+	if check != nil {
+		if err := check(ctx); err != nil {
+			return err
+		}
+	}
+
+	capacity, err := lockSection(ctx, tx, enrollment.SectionID)
+
+	if err != nil {
+		return err
+	}
+
+	var enrolled int
+
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM enrollments WHERE section_id = $1 AND status = 'enrolled'`, enrollment.SectionID).Scan(&enrolled); err != nil {
+		return err
+	}
+
+	enrollment.Status = EnrollmentStatusEnrolled
+
+	if enrolled >= capacity {
+		if !allowWaitlist {
+			return errSectionFull
+		}
+
+		enrollment.Status = EnrollmentStatusWaitlisted
+	}
+
+	query := `
+		INSERT INTO enrollments (section_id, student_user_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	row := tx.QueryRow(ctx, query, enrollment.SectionID, enrollment.StudentUserID, enrollment.Status, enrollment.CreatedAt, enrollment.UpdatedAt)
+
+	if err := row.Scan(&enrollment.ID); err != nil {
+		var pgErr *pgconn.PgError
+
+		// enrollments_active_idx
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errAlreadyEnrolled
+		}
+
+		return err
+	}
+
+	if enrollment.Status == EnrollmentStatusWaitlisted {
+		if err := tx.QueryRow(ctx, `SELECT count(*) FROM enrollments WHERE section_id = $1 AND status = 'waitlisted'`, enrollment.SectionID).Scan(&enrollment.WaitlistPosition); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *EnrollmentPostgresStore) GetByID(ctx context.Context, id string) (*Enrollment, error) {
+	query := `SELECT ` + enrollmentColumns + ` FROM enrollments e JOIN sections s ON s.id = e.section_id WHERE e.id = $1`
+
+	return noRowsAsNil(scanEnrollment(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *EnrollmentPostgresStore) List(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("s.school_id IN (SELECT id FROM schools WHERE organization_id = $%d)", filter.OrganizationID)
+	}
+
+	if filter.SectionID != "" {
+		addCondition("e.section_id = $%d", filter.SectionID)
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("e.student_user_id = $%d", filter.StudentUserID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		addCondition("e.status = ANY($%d)", filter.Statuses)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM enrollments e
+		JOIN sections s ON s.id = e.section_id
+		%s
+		ORDER BY e.created_at, e.id
+	`, enrollmentColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var enrollments []*Enrollment
+
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		enrollments = append(enrollments, enrollment)
+	}
+
+	return enrollments, rows.Err()
+}
+
+func (s *EnrollmentPostgresStore) Drop(ctx context.Context, id string) ([]*Enrollment, error) {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var sectionID string
+
+	if err := tx.QueryRow(ctx, `SELECT section_id FROM enrollments WHERE id = $1`, id).Scan(&sectionID); err != nil {
+		return nil, err
+	}
+
+	if _, err := lockSection(ctx, tx, sectionID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE enrollments
+		SET status = 'dropped', dropped_at = now(), updated_at = now()
+		WHERE id = $1 AND status IN ('enrolled', 'waitlisted')
+	`
+
+	if _, err := tx.Exec(ctx, query, id); err != nil {
+		return nil, err
+	}
+
+	promoted, err := fillSeats(ctx, tx, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, tx.Commit(ctx)
+}
+
+func (s *EnrollmentPostgresStore) FillSeats(ctx context.Context, sectionID string) ([]*Enrollment, error) {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := lockSection(ctx, tx, sectionID); err != nil {
+		return nil, err
+	}
+
+	promoted, err := fillSeats(ctx, tx, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, tx.Commit(ctx)
+}
+
+// Moves the longest waiting students into the section's open seats. The section must
+// already be locked by tx.
+func fillSeats(ctx context.Context, tx pgx.Tx, sectionID string) ([]*Enrollment, error) {
+	query := `
+		UPDATE enrollments e
+		SET status = 'enrolled', updated_at = now()
+		FROM sections s
+		WHERE s.id = e.section_id AND e.id IN (
+			SELECT w.id
+			FROM enrollments w
+			WHERE w.section_id = $1 AND w.status = 'waitlisted'
+			ORDER BY w.created_at, w.id
+			LIMIT GREATEST(
+				(SELECT capacity FROM sections WHERE id = $1) -
+				(SELECT count(*) FROM enrollments WHERE section_id = $1 AND status = 'enrolled'),
+				0
+			)
+		)
+		RETURNING e.id, e.section_id, e.student_user_id, e.status, s.course_id, s.term_id, 0, e.created_at, e.updated_at, e.dropped_at
+	`
+
+	rows, err := tx.Query(ctx, query, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var promoted []*Enrollment
+
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		promoted = append(promoted, enrollment)
+	}
+
+	return promoted, rows.Err()
+}
+
+func (s *EnrollmentPostgresStore) SetStatus(ctx context.Context, id string, status string) error {
+	_, err := s.db.pool.Exec(ctx, `UPDATE enrollments SET status = $1, updated_at = now() WHERE id = $2`, status, id)
+	return err
+}
+
+// Returns the prerequisites of course that completedCourseIDs does not include
+func missingPrerequisites(course *Course, completedCourseIDs []string) []string {
+	var missing []string
+
+	for _, id := range course.PrerequisiteIDs {
+		if !slices.Contains(completedCourseIDs, id) {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
+// Collects the student's enrollments, dropped ones included, for their data export
+func enrollmentDataExport(enrollmentStore EnrollmentStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		enrollments, err := enrollmentStore.List(ctx, &EnrollmentFilter{StudentUserID: userID})
+		return toAnySlice(enrollments), err
+	}
+}
+
+type EnrollmentService struct {
+	enrollmentStore EnrollmentStore
+	sectionStore    SectionStore
+	courseStore     CourseStore
+	calendarStore   CalendarStore
+	gpaStore        GPAStore
+	schoolStore     SchoolStore
+	memberStore     OrganizationMemberStore
+	auditService    *AuditService
+}
+
+func NewEnrollmentService(enrollmentStore EnrollmentStore, sectionStore SectionStore, courseStore CourseStore, calendarStore CalendarStore, gpaStore GPAStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *EnrollmentService {
+	return &EnrollmentService{
+		enrollmentStore: enrollmentStore,
+		sectionStore:    sectionStore,
+		courseStore:     courseStore,
+		calendarStore:   calendarStore,
+		gpaStore:        gpaStore,
+		schoolStore:     schoolStore,
+		memberStore:     memberStore,
+		auditService:    auditService,
+	}
+}
+
+// Succeeds if the session user is the student themselves or an admin of the school's organization
+func (s *EnrollmentService) authorizeStudent(ctx context.Context, school *School, studentUserID string) error {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if session.UserID == studentUserID {
+		_, err := requireMembership(ctx, s.memberStore, school.OrganizationID, RoleStudent)
+		return err
+	}
+
+	_, err := requireMembership(ctx, s.memberStore, school.OrganizationID, RoleAdmin)
+
+	return err
+}
+
+type EnrollRequest struct {
+	StudentUserID string `json:"studentUserId"`
+	// Join the waitlist instead of failing when the section is full
+	JoinWaitlist bool `json:"joinWaitlist"`
+}
+
+// Enrolls a student in a section. The term's enrollment window must be open, the student must
+// have completed the course's prerequisites and the section must not clash with the student's
+// other sections.
+func (s *EnrollmentService) Enroll(ctx context.Context, sectionID string, request *EnrollRequest) (*Enrollment, error) {
+	if request.StudentUserID == "" {
+		return nil, errors.New("student user id is required")
+	}
+
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeStudent(ctx, school, request.StudentUserID); err != nil {
+		return nil, err
+	}
+
+	student, err := s.memberStore.Get(ctx, school.OrganizationID, request.StudentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return nil, ErrInternal
+	}
+
+	if student == nil || student.Role != RoleStudent {
+		return nil, fmt.Errorf("user %s is not a student in this organization", request.StudentUserID)
+	}
+
+	term, err := s.calendarStore.GetTerm(ctx, section.TermID)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return nil, ErrInternal
+	}
+
+	if term == nil {
+		return nil, notFound("term")
+	}
+
+	if !term.EnrollmentOpen(DateOf(time.Now())) {
+		return nil, fmt.Errorf("enrollment for %s is closed", term.Name)
+	}
+
+	enrollment := &Enrollment{
+		SectionID:     section.ID,
+		StudentUserID: request.StudentUserID,
+		CourseID:      section.CourseID,
+		TermID:        section.TermID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	var checkErr error
+
+	err = s.enrollmentStore.Enroll(ctx, enrollment, request.JoinWaitlist, func(ctx context.Context) error {
+		checkErr = s.checkStudent(ctx, request.StudentUserID, section, term)
+		return checkErr
+	})
+
+	if err != nil {
+		if checkErr != nil || errors.Is(err, errSectionFull) || errors.Is(err, errAlreadyEnrolled) {
+			return nil, err
+		}
+
+		slog.Error("failed to enroll student", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "enrollment", enrollment.ID, nil, enrollment)
+
+	return enrollment, nil
+}
+
+// Checks the student's existing enrollments for a duplicate, missing prerequisites and
+// schedule conflicts with section
+func (s *EnrollmentService) checkStudent(ctx context.Context, studentUserID string, section *Section, term *Term) error {
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{StudentUserID: studentUserID})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return ErrInternal
+	}
+
+	var completed []string
+
+	for _, enrollment := range enrollments {
+		if enrollment.Status == EnrollmentStatusCompleted {
+			completed = append(completed, enrollment.CourseID)
+		}
+
+		if enrollment.IsActive() && enrollment.CourseID == section.CourseID && enrollment.TermID == section.TermID {
+			return errors.New("student is already enrolled in this course for the term")
+		}
+	}
+
+	course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+	if err != nil {
+		slog.Error("failed to get course", "error", err)
+		return ErrInternal
+	}
+
+	if course == nil {
+		return notFound("course")
+	}
+
+	if missing := missingPrerequisites(course, completed); len(missing) > 0 {
+		var codes []string
+
+		for _, id := range missing {
+			prerequisite, err := s.courseStore.GetByID(ctx, id)
+
+			if err != nil {
+				slog.Error("failed to get course", "error", err)
+				return ErrInternal
+			}
+
+			if prerequisite != nil {
+				codes = append(codes, prerequisite.Code)
+			}
+		}
+
+		return fmt.Errorf("missing prerequisites: %s", strings.Join(codes, ", "))
+	}
+
+	for _, enrollment := range enrollments {
+		if !enrollment.IsActive() {
+			continue
+		}
+
+		other, err := s.sectionStore.GetByID(ctx, enrollment.SectionID)
+
+		if err != nil {
+			slog.Error("failed to get section", "error", err)
+			return ErrInternal
+		}
+
+		otherTerm, err := s.calendarStore.GetTerm(ctx, enrollment.TermID)
+
+		if err != nil {
+			slog.Error("failed to get term", "error", err)
+			return ErrInternal
+		}
+
+		if other != nil && otherTerm != nil && sectionsConflict(section, term, other, otherTerm) {
+			return fmt.Errorf("schedule conflicts with section %s", other.Code)
+		}
+	}
+
+	return nil
+}
+
+// Returns the enrollment with its section and school
+func (s *EnrollmentService) enrollment(ctx context.Context, id string) (*Enrollment, *Section, *School, error) {
+	enrollment, err := s.enrollmentStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get enrollment", "error", err)
+		return nil, nil, nil, ErrInternal
+	}
+
+	if enrollment == nil {
+		return nil, nil, nil, notFound("enrollment")
+	}
+
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, enrollment.SectionID)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return enrollment, section, school, nil
+}
+
+func (s *EnrollmentService) GetByID(ctx context.Context, id string) (*Enrollment, error) {
+	enrollment, section, school, err := s.enrollment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if session, ok := SessionFromContext(ctx); ok && session.UserID == enrollment.StudentUserID {
+		err = s.authorizeStudent(ctx, school, enrollment.StudentUserID)
+	} else {
+		err = authorizeSectionStaff(ctx, s.memberStore, section, school)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// Drops a student from a section, or off its waitlist. Any seat this frees goes to the
+// student who has waited longest.
+func (s *EnrollmentService) Drop(ctx context.Context, id string) (*Enrollment, error) {
+	enrollment, _, school, err := s.enrollment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeStudent(ctx, school, enrollment.StudentUserID); err != nil {
+		return nil, err
+	}
+
+	if !enrollment.IsActive() {
+		return nil, fmt.Errorf("cannot drop an enrollment that is %s", enrollment.Status)
+	}
+
+	promoted, err := s.enrollmentStore.Drop(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to drop enrollment", "error", err)
+		return nil, ErrInternal
+	}
+
+	before := *enrollment
+	droppedAt := time.Now()
+
+	enrollment.Status = EnrollmentStatusDropped
+	enrollment.WaitlistPosition = 0
+	enrollment.DroppedAt = &droppedAt
+	enrollment.UpdatedAt = droppedAt
+
+	queueGPARecompute(ctx, s.gpaStore, enrollment.SectionID, enrollment.StudentUserID)
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "enrollment", id, &before, enrollment)
+	recordPromotions(ctx, s.auditService, school.OrganizationID, promoted)
+
+	return enrollment, nil
+}
+
+// Records waitlisted enrollments that were given a seat
+func recordPromotions(ctx context.Context, auditService *AuditService, organizationID string, promoted []*Enrollment) {
+	for _, enrollment := range promoted {
+		before := *enrollment
+		before.Status = EnrollmentStatusWaitlisted
+
+		auditService.Record(ctx, organizationID, AuditActionUpdate, "enrollment", enrollment.ID, &before, enrollment)
+	}
+}
+
+type CompleteEnrollmentRequest struct {
+	Passed bool `json:"passed"`
+}
+
+// Closes out an enrollment at the end of the term. Passing completes the course for
+// prerequisite checks.
+func (s *EnrollmentService) Complete(ctx context.Context, id string, request *CompleteEnrollmentRequest) (*Enrollment, error) {
+	enrollment, section, school, err := s.enrollment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	if enrollment.Status != EnrollmentStatusEnrolled {
+		return nil, fmt.Errorf("cannot complete an enrollment that is %s", enrollment.Status)
+	}
+
+	before := *enrollment
+
+	enrollment.Status = EnrollmentStatusFailed
+
+	if request.Passed {
+		enrollment.Status = EnrollmentStatusCompleted
+	}
+
+	if err := s.enrollmentStore.SetStatus(ctx, id, enrollment.Status); err != nil {
+		slog.Error("failed to complete enrollment", "error", err)
+		return nil, ErrInternal
+	}
+
+	enrollment.UpdatedAt = time.Now()
+
+	// Final grades count towards the cumulative GPA
+	queueGPARecompute(ctx, s.gpaStore, enrollment.SectionID, enrollment.StudentUserID)
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "enrollment", id, &before, enrollment)
+
+	return enrollment, nil
+}
+
+// Lists a section's roster and waitlist, along with past enrollments
+func (s *EnrollmentService) ListBySection(ctx context.Context, sectionID string) ([]*Enrollment, error) {
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	return s.list(ctx, &EnrollmentFilter{SectionID: sectionID})
+}
+
+func (s *EnrollmentService) ListByStudent(ctx context.Context, studentUserID string) ([]*Enrollment, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if session.UserID != studentUserID {
+		if err := requireAdminOfUser(ctx, s.memberStore, studentUserID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.list(ctx, &EnrollmentFilter{StudentUserID: studentUserID})
+}
+
+func (s *EnrollmentService) list(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+	enrollments, err := s.enrollmentStore.List(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	if enrollments == nil {
+		enrollments = []*Enrollment{}
+	}
+
+	return enrollments, nil
+}
+
+type EnrollmentHandler struct {
+	enrollmentService *EnrollmentService
+}
+
+func (h *EnrollmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request EnrollRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	enrollment, err := h.enrollmentService.Enroll(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, enrollment)
+}
+
+func (h *EnrollmentHandler) ListBySection(w http.ResponseWriter, r *http.Request) {
+	enrollments, err := h.enrollmentService.ListBySection(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollments)
+}
+
+func (h *EnrollmentHandler) ListByStudent(w http.ResponseWriter, r *http.Request) {
+	enrollments, err := h.enrollmentService.ListByStudent(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollments)
+}
+
+func (h *EnrollmentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := h.enrollmentService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *EnrollmentHandler) Drop(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := h.enrollmentService.Drop(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *EnrollmentHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	var request CompleteEnrollmentRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	enrollment, err := h.enrollmentService.Complete(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment)
+}
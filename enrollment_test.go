@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockEnrollmentStore struct {
+	EnrollFunc    func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error
+	GetByIDFunc   func(ctx context.Context, id string) (*Enrollment, error)
+	ListFunc      func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error)
+	DropFunc      func(ctx context.Context, id string) ([]*Enrollment, error)
+	FillSeatsFunc func(ctx context.Context, sectionID string) ([]*Enrollment, error)
+	SetStatusFunc func(ctx context.Context, id string, status string) error
+}
+
+func (m *MockEnrollmentStore) Enroll(ctx context.Context, enrollment *Enrollment, allowWaitlist bool, check func(ctx context.Context) error) error {
+	if check != nil {
+		if err := check(ctx); err != nil {
+			return err
+		}
+	}
+
+	if m.EnrollFunc != nil {
+		return m.EnrollFunc(ctx, enrollment, allowWaitlist)
+	}
+
+	enrollment.Status = EnrollmentStatusEnrolled
+
+	return nil
+}
+
+func (m *MockEnrollmentStore) GetByID(ctx context.Context, id string) (*Enrollment, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockEnrollmentStore) List(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockEnrollmentStore) Drop(ctx context.Context, id string) ([]*Enrollment, error) {
+	if m.DropFunc != nil {
+		return m.DropFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockEnrollmentStore) FillSeats(ctx context.Context, sectionID string) ([]*Enrollment, error) {
+	if m.FillSeatsFunc != nil {
+		return m.FillSeatsFunc(ctx, sectionID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockEnrollmentStore) SetStatus(ctx context.Context, id string, status string) error {
+	if m.SetStatusFunc != nil {
+		return m.SetStatusFunc(ctx, id, status)
+	}
+
+	return nil
+}
+
+// A term in progress today, so its enrollment window is open. Geometry meets in it on
+// Monday and Wednesday mornings, Algebra 2 on Monday afternoon.
+func enrollmentFixtures() (*MockSectionStore, *MockCalendarStore) {
+	today := DateOf(time.Now())
+
+	sections := map[string]*Section{
+		"geo-01":  {ID: "geo-01", CourseID: "geo", SchoolID: "school", TermID: "current", Code: "GEO-01", Capacity: 2, Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}}, Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "08:00", EndTime: "08:50"}, {Weekday: time.Wednesday, StartTime: "08:00", EndTime: "08:50"}}},
+		"alg2-01": {ID: "alg2-01", CourseID: "alg2", SchoolID: "school", TermID: "current", Code: "ALG2-01", Capacity: 2, Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "13:00", EndTime: "13:50"}}},
+		"alg1-01": {ID: "alg1-01", CourseID: "alg1", SchoolID: "school", TermID: "current", Code: "ALG1-01", Capacity: 2, Meetings: []SectionMeeting{{Weekday: time.Wednesday, StartTime: "08:30", EndTime: "09:20"}}},
+	}
+
+	sectionStore := &MockSectionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Section, error) {
+			return sections[id], nil
+		},
+	}
+
+	calendarStore := &MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", Name: "Current", StartDate: today.AddDays(-10), EndDate: today.AddDays(100)}, nil
+		},
+	}
+
+	return sectionStore, calendarStore
+}
+
+// A student who has passed Algebra 1
+func passedAlgebra() *MockEnrollmentStore {
+	return &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "past", SectionID: "old", StudentUserID: filter.StudentUserID, CourseID: "alg1", TermID: "last-year", Status: EnrollmentStatusCompleted}}, nil
+		},
+	}
+}
+
+func TestSectionMeeting_Overlaps_IgnoresTouchingMeetings(t *testing.T) {
+	first := SectionMeeting{Weekday: time.Monday, StartTime: "08:00", EndTime: "08:50"}
+
+	assert.False(t, first.Overlaps(SectionMeeting{Weekday: time.Monday, StartTime: "08:50", EndTime: "09:40"}))
+	assert.True(t, first.Overlaps(SectionMeeting{Weekday: time.Monday, StartTime: "08:45", EndTime: "09:40"}))
+	assert.False(t, first.Overlaps(SectionMeeting{Weekday: time.Tuesday, StartTime: "08:00", EndTime: "08:50"}))
+}
+
+func TestSectionPeriod_Overlaps_MatchesEveryDayPeriod(t *testing.T) {
+	first := SectionPeriod{Day: "A", Period: "1"}
+
+	assert.True(t, first.Overlaps(SectionPeriod{Day: "a", Period: "1"}))
+	assert.True(t, first.Overlaps(SectionPeriod{Period: "1"}))
+	assert.False(t, first.Overlaps(SectionPeriod{Day: "B", Period: "1"}))
+	assert.False(t, first.Overlaps(SectionPeriod{Day: "A", Period: "2"}))
+}
+
+func TestTerm_EnrollmentOpen_ClosesWithTermByDefault(t *testing.T) {
+	term := &Term{StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16), EnrollmentStartDate: NewDate(2025, time.August, 1)}
+
+	assert.False(t, term.EnrollmentOpen(NewDate(2025, time.July, 31)))
+	assert.True(t, term.EnrollmentOpen(NewDate(2026, time.January, 16)))
+	assert.False(t, term.EnrollmentOpen(NewDate(2026, time.January, 17)))
+}
+
+func TestEnrollmentService_Enroll_EnrollsStudent(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(passedAlgebra(), sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollment, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, EnrollmentStatusEnrolled, enrollment.Status)
+	assert.Equal(t, "geo", enrollment.CourseID)
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorForMissingPrerequisite(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollment, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "missing prerequisites: MATH101", err.Error())
+	assert.Nil(t, enrollment)
+}
+
+func TestEnrollmentService_Enroll_IgnoresFailedPrerequisite(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "past", CourseID: "alg1", TermID: "last-year", Status: EnrollmentStatusFailed}}, nil
+		},
+	}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "missing prerequisites: MATH101", err.Error())
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorForScheduleConflict(t *testing.T) {
+	store := passedAlgebra()
+	store.ListFunc = func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+		return []*Enrollment{
+			{ID: "past", CourseID: "alg1", TermID: "last-year", Status: EnrollmentStatusCompleted},
+			{ID: "current", SectionID: "alg1-01", CourseID: "alg1", TermID: "current", Status: EnrollmentStatusEnrolled},
+		}, nil
+	}
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(store, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "schedule conflicts with section ALG1-01", err.Error())
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorWhenAlreadyEnrolledInCourse(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "current", SectionID: "alg1-01", CourseID: "alg1", TermID: "current", Status: EnrollmentStatusWaitlisted}}, nil
+		},
+	}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "alg1-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "student is already enrolled in this course for the term", err.Error())
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorWhenWindowClosed(t *testing.T) {
+	sectionStore, _ := enrollmentFixtures()
+	today := DateOf(time.Now())
+
+	calendarStore := &MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", Name: "Current", StartDate: today.AddDays(-10), EndDate: today.AddDays(100), EnrollmentEndDate: today.AddDays(-1)}, nil
+		},
+	}
+
+	enrollmentService := NewEnrollmentService(passedAlgebra(), sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "enrollment for Current is closed", err.Error())
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorWhenFull(t *testing.T) {
+	store := passedAlgebra()
+	store.EnrollFunc = func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error {
+		assert.False(t, allowWaitlist)
+		return errSectionFull
+	}
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(store, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.ErrorIs(t, err, errSectionFull)
+}
+
+func TestEnrollmentService_Enroll_JoinsWaitlistWhenFull(t *testing.T) {
+	store := passedAlgebra()
+	store.EnrollFunc = func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error {
+		enrollment.Status = EnrollmentStatusWaitlisted
+		enrollment.WaitlistPosition = 3
+		return nil
+	}
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(store, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollment, err := enrollmentService.Enroll(sessionContext("admin"), "geo-01", &EnrollRequest{StudentUserID: "student", JoinWaitlist: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, EnrollmentStatusWaitlisted, enrollment.Status)
+	assert.Equal(t, 3, enrollment.WaitlistPosition)
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorForOtherStudent(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(passedAlgebra(), sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("other-student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorForNonStudent(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(passedAlgebra(), sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("admin"), "geo-01", &EnrollRequest{StudentUserID: "teacher"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "user teacher is not a student in this organization", err.Error())
+}
+
+func TestEnrollmentService_Enroll_ReturnsInternalErrorOnStoreFailure(t *testing.T) {
+	store := passedAlgebra()
+	store.EnrollFunc = func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error {
+		return errors.New("db error")
+	}
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(store, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.ErrorIs(t, err, ErrInternal)
+}
+
+func TestEnrollmentService_Enroll_ReturnsErrorForConcurrentDuplicate(t *testing.T) {
+	store := passedAlgebra()
+	store.EnrollFunc = func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error {
+		return errAlreadyEnrolled
+	}
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(store, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Enroll(sessionContext("student"), "geo-01", &EnrollRequest{StudentUserID: "student"})
+
+	assert.ErrorIs(t, err, errAlreadyEnrolled)
+	assert.NotErrorIs(t, err, ErrInternal)
+}
+
+func TestEnrollmentService_Drop_DropsAndReportsPromotions(t *testing.T) {
+	var recorded []string
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Enrollment, error) {
+			return &Enrollment{ID: id, SectionID: "geo-01", StudentUserID: "student", Status: EnrollmentStatusEnrolled}, nil
+		},
+		DropFunc: func(ctx context.Context, id string) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "next", SectionID: "geo-01", StudentUserID: "other-student", Status: EnrollmentStatusEnrolled}}, nil
+		},
+	}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollmentService.auditService = NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, entry *AuditEntry) error {
+			recorded = append(recorded, entry.EntityID)
+			return nil
+		},
+	}, orgMembers())
+
+	enrollment, err := enrollmentService.Drop(sessionContext("student"), "enrollment")
+
+	assert.NoError(t, err)
+	assert.Equal(t, EnrollmentStatusDropped, enrollment.Status)
+	assert.NotNil(t, enrollment.DroppedAt)
+	assert.Equal(t, []string{"enrollment", "next"}, recorded)
+}
+
+func TestEnrollmentService_Drop_ReturnsErrorForInactiveEnrollment(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Enrollment, error) {
+			return &Enrollment{ID: id, SectionID: "geo-01", StudentUserID: "student", Status: EnrollmentStatusCompleted}, nil
+		},
+	}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Drop(sessionContext("student"), "enrollment")
+
+	assert.Error(t, err)
+	assert.Equal(t, "cannot drop an enrollment that is completed", err.Error())
+}
+
+func TestEnrollmentService_Complete_AllowsSectionTeacher(t *testing.T) {
+	var status string
+
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Enrollment, error) {
+			return &Enrollment{ID: id, SectionID: "geo-01", StudentUserID: "student", Status: EnrollmentStatusEnrolled}, nil
+		},
+		SetStatusFunc: func(ctx context.Context, id string, newStatus string) error {
+			status = newStatus
+			return nil
+		},
+	}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollment, err := enrollmentService.Complete(sessionContext("teacher"), "enrollment", &CompleteEnrollmentRequest{Passed: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, EnrollmentStatusCompleted, enrollment.Status)
+	assert.Equal(t, EnrollmentStatusCompleted, status)
+}
+
+func TestEnrollmentService_Complete_ReturnsErrorForUnassignedTeacher(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Enrollment, error) {
+			return &Enrollment{ID: id, SectionID: "alg2-01", StudentUserID: "student", Status: EnrollmentStatusEnrolled}, nil
+		},
+	}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := enrollmentService.Complete(sessionContext("teacher"), "enrollment", &CompleteEnrollmentRequest{Passed: true})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestEnrollmentService_ListBySection_ReturnsErrorForStudent(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(&MockEnrollmentStore{}, sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollments, err := enrollmentService.ListBySection(sessionContext("student"), "geo-01")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, enrollments)
+}
+
+func TestEnrollmentService_ListByStudent_AllowsSelf(t *testing.T) {
+	sectionStore, calendarStore := enrollmentFixtures()
+	enrollmentService := NewEnrollmentService(passedAlgebra(), sectionStore, mathCatalog(), calendarStore, &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollments, err := enrollmentService.ListByStudent(sessionContext("student"), "student")
+
+	assert.NoError(t, err)
+	assert.Len(t, enrollments, 1)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const AuditActionErase = "erase"
+
+// The domain used for pseudonymized email addresses. .invalid is reserved and never resolves.
+const erasedEmailDomain = "erased.invalid"
+
+type EraseUserRequest struct {
+	// New owners for the organizations the user owns, keyed by organization id
+	OrganizationOwners map[string]string `json:"organizationOwners"`
+}
+
+type ErasureService struct {
+	userStore         UserStore
+	sessionStore      SessionStore
+	organizationStore OrganizationStore
+	memberStore       OrganizationMemberStore
+	exportStore       DataExportStore
+	blobStore         BlobStore
+	auditService      *AuditService
+	erasers           []UserEraser
+}
+
+// Removes personal data kept outside the user record when the user is erased
+type UserEraser func(ctx context.Context, userID string) error
+
+func NewErasureService(userStore UserStore, sessionStore SessionStore, organizationStore OrganizationStore, memberStore OrganizationMemberStore, exportStore DataExportStore, blobStore BlobStore, auditService *AuditService) *ErasureService {
+	return &ErasureService{
+		userStore:         userStore,
+		sessionStore:      sessionStore,
+		organizationStore: organizationStore,
+		memberStore:       memberStore,
+		exportStore:       exportStore,
+		blobStore:         blobStore,
+		auditService:      auditService,
+	}
+}
+
+// Adds a step to every erasure. Steps run in the order they were added, after the user record
+// is pseudonymized.
+func (s *ErasureService) AddEraser(erase UserEraser) {
+	s.erasers = append(s.erasers, erase)
+}
+
+// Replaces the user's personal details with placeholders derived from their id
+func pseudonymizeUser(user *User, now time.Time) {
+	user.FirstName = "Erased"
+	user.LastName = "User"
+	user.Email = "erased-" + user.ID + "@" + erasedEmailDomain
+	user.Password = ""
+	user.UpdatedAt = now
+	user.ErasedAt = &now
+}
+
+// Erases a user's personal data while keeping the records that reference them.
+// Organizations the user owns must be handed to another admin in the same request, otherwise
+// the erasure is refused. Users may erase themselves; anyone else must administer every
+// organization the user belongs to, since the account is shared by all of them.
+//
+// The user record is pseudonymized first, so they can no longer sign in, and every later step is
+// safe to repeat. If a step fails, erasing the user again finishes the job instead of being refused.
+func (s *ErasureService) Erase(ctx context.Context, userID string, request *EraseUserRequest) (*User, error) {
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if session.UserID != userID {
+		if err := requireAdminOfEveryOrganizationOf(ctx, s.memberStore, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.userStore.GetByID(ctx, userID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = s.userStore.GetDeletedByID(ctx, userID)
+	}
+
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if user == nil {
+		return nil, notFound("user")
+	}
+
+	if err := s.erase(ctx, user, request.OrganizationOwners); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Runs the erasure steps for the user without checking who asked for them, as purging does
+func (s *ErasureService) erase(ctx context.Context, user *User, newOwners map[string]string) error {
+	userID := user.ID
+
+	if user.ErasedAt == nil {
+		if err := s.reassignOwnedOrganizations(ctx, userID, newOwners); err != nil {
+			return err
+		}
+
+		pseudonymizeUser(user, time.Now())
+
+		if err := s.userStore.Pseudonymize(ctx, user); err != nil {
+			slog.Error("failed to pseudonymize user", "error", err)
+			return ErrInternal
+		}
+	}
+
+	for _, erase := range s.erasers {
+		if err := erase(ctx, userID); err != nil {
+			slog.Error("failed to erase user data", "error", err)
+			return ErrInternal
+		}
+	}
+
+	if err := s.sessionStore.DeleteByUser(ctx, userID); err != nil {
+		slog.Error("failed to revoke sessions", "error", err)
+		return ErrInternal
+	}
+
+	if err := s.auditService.auditStore.RedactUser(ctx, userID); err != nil {
+		slog.Error("failed to redact audit entries", "error", err)
+		return ErrInternal
+	}
+
+	if err := s.deleteExportArchives(ctx, userID); err != nil {
+		return err
+	}
+
+	// No before and after here, since the diff would put the erased details back in the log
+	s.auditService.RecordUser(ctx, AuditActionErase, userID, nil, nil)
+
+	return nil
+}
+
+func (s *ErasureService) reassignOwnedOrganizations(ctx context.Context, userID string, newOwners map[string]string) error {
+	owned, err := s.organizationStore.ListByOwner(ctx, userID)
+
+	if err != nil {
+		slog.Error("failed to list owned organizations", "error", err)
+		return ErrInternal
+	}
+
+	if err := s.checkNewOwners(ctx, userID, owned, newOwners); err != nil {
+		return err
+	}
+
+	for _, organization := range owned {
+		before := *organization
+
+		organization.OwnerUserID = newOwners[organization.ID]
+		organization.UpdatedAt = time.Now()
+
+		if err := s.organizationStore.Update(ctx, organization); err != nil {
+			slog.Error("failed to reassign organization", "error", err, "organizationId", organization.ID)
+			return ErrInternal
+		}
+
+		s.auditService.Record(ctx, organization.ID, AuditActionUpdate, "organization", organization.ID, &before, organization)
+	}
+
+	return nil
+}
+
+// Deletes the archives of the user's exports. Each archive is marked gone only after its blob is
+// deleted, so a failure leaves it to be deleted by the next attempt.
+func (s *ErasureService) deleteExportArchives(ctx context.Context, userID string) error {
+	exportIDs, err := s.exportStore.ListArchivesByUser(ctx, userID)
+
+	if err != nil {
+		slog.Error("failed to list data export archives", "error", err)
+		return ErrInternal
+	}
+
+	for _, id := range exportIDs {
+		if err := s.blobStore.Delete(ctx, dataExportArchiveKey(id)); err != nil {
+			slog.Error("failed to delete data export archive", "error", err, "exportId", id)
+			return ErrInternal
+		}
+	}
+
+	if _, err := s.exportStore.DeleteArchivesByUser(ctx, userID); err != nil {
+		slog.Error("failed to delete data export archives", "error", err)
+		return ErrInternal
+	}
+
+	return nil
+}
+
+// Checks that every owned organization is being handed to one of its other admins. Whoever
+// asked for the erasure may not hand an organization to themself.
+func (s *ErasureService) checkNewOwners(ctx context.Context, userID string, owned []*Organization, newOwners map[string]string) error {
+	var blocked []string
+	session, _ := SessionFromContext(ctx)
+
+	for _, organization := range owned {
+		newOwnerID := newOwners[organization.ID]
+
+		if newOwnerID == "" {
+			blocked = append(blocked, organization.ID)
+			continue
+		}
+
+		if newOwnerID == userID {
+			return fmt.Errorf("organization %s must be reassigned to another user", organization.ID)
+		}
+
+		if session != nil && newOwnerID == session.UserID {
+			return fmt.Errorf("organization %s cannot be reassigned to the user erasing its owner", organization.ID)
+		}
+
+		member, err := s.memberStore.Get(ctx, organization.ID, newOwnerID)
+
+		if err != nil {
+			slog.Error("failed to get organization member", "error", err)
+			return ErrInternal
+		}
+
+		if member == nil || member.Role != RoleAdmin {
+			return fmt.Errorf("new owner of organization %s must be one of its admins", organization.ID)
+		}
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("user owns organizations that must be reassigned first: %s", strings.Join(blocked, ", "))
+	}
+
+	return nil
+}
+
+type ErasureHandler struct {
+	erasureService *ErasureService
+}
+
+func (h *ErasureHandler) Erase(w http.ResponseWriter, r *http.Request) {
+	var request EraseUserRequest
+
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &request); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	user, err := h.erasureService.Erase(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type erasureMocks struct {
+	users         *MockUserStore
+	sessions      *MockSessionStore
+	organizations *MockOrganizationStore
+	members       *MockOrganizationMemberStore
+	exports       *MockDataExportStore
+	blobs         *MockBlobStore
+	audit         *MockAuditStore
+}
+
+func newErasureMocks() *erasureMocks {
+	members := orgMembers()
+	members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleTeacher}}, nil
+	}
+
+	return &erasureMocks{
+		users: &MockUserStore{
+			GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+				return &User{ID: id, FirstName: "John", LastName: "Doe", Email: "john.doe@example.com", Password: "hash"}, nil
+			},
+		},
+		sessions:      &MockSessionStore{},
+		organizations: &MockOrganizationStore{},
+		members:       members,
+		exports:       &MockDataExportStore{},
+		blobs:         &MockBlobStore{},
+		audit:         &MockAuditStore{},
+	}
+}
+
+func (m *erasureMocks) service() *ErasureService {
+	return NewErasureService(m.users, m.sessions, m.organizations, m.members, m.exports, m.blobs, NewAuditService(m.audit, m.members))
+}
+
+func TestPseudonymizeUser_ReplacesPersonalDetails(t *testing.T) {
+	user := &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com", Password: "hash"}
+
+	pseudonymizeUser(user, user.CreatedAt)
+
+	assert.Equal(t, "Erased", user.FirstName)
+	assert.Equal(t, "User", user.LastName)
+	assert.Equal(t, "erased-1@erased.invalid", user.Email)
+	assert.Empty(t, user.Password)
+	assert.NotNil(t, user.ErasedAt)
+}
+
+func TestErasureService_Erase_ReturnsErrorForNonAdmin(t *testing.T) {
+	mocks := newErasureMocks()
+
+	user, err := mocks.service().Erase(sessionContext("teacher"), "student", &EraseUserRequest{})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, user)
+}
+
+func TestErasureService_Erase_ReturnsErrorForAdminOfOnlySomeOrganizations(t *testing.T) {
+	mocks := newErasureMocks()
+	mocks.members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleTeacher}, {OrganizationID: "other-org", UserID: userID, Role: RoleAdmin}}, nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, user)
+}
+
+func TestErasureService_Erase_ErasesSelf(t *testing.T) {
+	mocks := newErasureMocks()
+
+	user, err := mocks.service().Erase(sessionContext("student"), "student", &EraseUserRequest{})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user.ErasedAt)
+}
+
+func TestErasureService_Erase_ResumesErasureOfErasedUser(t *testing.T) {
+	var revokedUserID string
+
+	mocks := newErasureMocks()
+	mocks.users.GetByIDFunc = func(ctx context.Context, id string) (*User, error) {
+		erased := &User{ID: id}
+		pseudonymizeUser(erased, erased.CreatedAt)
+		return erased, nil
+	}
+	mocks.users.PseudonymizeFunc = func(ctx context.Context, user *User) error {
+		t.Fatal("an erased user must not be pseudonymized again")
+		return nil
+	}
+	mocks.sessions.DeleteByUserFunc = func(ctx context.Context, userID string) error {
+		revokedUserID = userID
+		return nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "erased-teacher@erased.invalid", user.Email)
+	assert.Equal(t, "teacher", revokedUserID)
+}
+
+func TestErasureService_Erase_ErasesSoftDeletedUser(t *testing.T) {
+	mocks := newErasureMocks()
+	mocks.users.GetByIDFunc = func(ctx context.Context, id string) (*User, error) {
+		return nil, sql.ErrNoRows
+	}
+	mocks.users.GetDeletedByIDFunc = func(ctx context.Context, id string) (*User, error) {
+		return &User{ID: id, FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Erased", user.FirstName)
+}
+
+func TestErasureService_Erase_ReturnsNotFoundForMissingUser(t *testing.T) {
+	mocks := newErasureMocks()
+	mocks.users.GetByIDFunc = func(ctx context.Context, id string) (*User, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, user)
+}
+
+func TestErasureService_Erase_KeepsArchiveStoredWhenBlobDeleteFails(t *testing.T) {
+	markedGone := false
+
+	mocks := newErasureMocks()
+	mocks.exports.ListArchivesByUserFunc = func(ctx context.Context, userID string) ([]string, error) {
+		return []string{"1"}, nil
+	}
+	mocks.exports.DeleteArchivesByUserFunc = func(ctx context.Context, userID string) ([]string, error) {
+		markedGone = true
+		return []string{"1"}, nil
+	}
+	erasureService := mocks.service()
+	erasureService.blobStore = &failingBlobStore{MockBlobStore: mocks.blobs}
+
+	user, err := erasureService.Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Nil(t, user)
+	assert.False(t, markedGone)
+}
+
+func TestErasureService_Erase_BlocksWhenOwnedOrganizationIsNotReassigned(t *testing.T) {
+	pseudonymized := false
+
+	mocks := newErasureMocks()
+	mocks.organizations.ListByOwnerFunc = func(ctx context.Context, ownerUserID string) ([]*Organization, error) {
+		return []*Organization{{ID: "owned-1", OwnerUserID: ownerUserID}}, nil
+	}
+	mocks.users.PseudonymizeFunc = func(ctx context.Context, user *User) error {
+		pseudonymized = true
+		return nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "user owns organizations that must be reassigned first: owned-1", err.Error())
+	assert.Nil(t, user)
+	assert.False(t, pseudonymized)
+}
+
+func TestErasureService_Erase_ReturnsErrorForNewOwnerWhoIsNotAdmin(t *testing.T) {
+	mocks := newErasureMocks()
+	mocks.organizations.ListByOwnerFunc = func(ctx context.Context, ownerUserID string) ([]*Organization, error) {
+		return []*Organization{{ID: "org", OwnerUserID: ownerUserID}}, nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "other-admin", &EraseUserRequest{
+		OrganizationOwners: map[string]string{"org": "teacher"},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "new owner of organization org must be one of its admins", err.Error())
+	assert.Nil(t, user)
+}
+
+func TestErasureService_Erase_ReturnsErrorForCallerAsNewOwner(t *testing.T) {
+	mocks := newErasureMocks()
+	mocks.organizations.ListByOwnerFunc = func(ctx context.Context, ownerUserID string) ([]*Organization, error) {
+		return []*Organization{{ID: "org", OwnerUserID: ownerUserID}}, nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("admin"), "other-admin", &EraseUserRequest{
+		OrganizationOwners: map[string]string{"org": "admin"},
+	})
+
+	assert.EqualError(t, err, "organization org cannot be reassigned to the user erasing its owner")
+	assert.Nil(t, user)
+}
+
+func TestErasureService_Erase_PseudonymizesRevokesAndRedacts(t *testing.T) {
+	var pseudonymized *User
+	var reassigned *Organization
+	var revokedUserID, redactedUserID, exportsUserID string
+	var actions []string
+
+	mocks := newErasureMocks()
+	mocks.organizations.ListByOwnerFunc = func(ctx context.Context, ownerUserID string) ([]*Organization, error) {
+		return []*Organization{{ID: "org", OwnerUserID: ownerUserID}}, nil
+	}
+	mocks.organizations.UpdateFunc = func(ctx context.Context, organization *Organization) error {
+		reassigned = organization
+		return nil
+	}
+	mocks.users.PseudonymizeFunc = func(ctx context.Context, user *User) error {
+		pseudonymized = user
+		return nil
+	}
+	mocks.sessions.DeleteByUserFunc = func(ctx context.Context, userID string) error {
+		revokedUserID = userID
+		return nil
+	}
+	mocks.exports.ListArchivesByUserFunc = func(ctx context.Context, userID string) ([]string, error) {
+		return []string{"1"}, nil
+	}
+	mocks.exports.DeleteArchivesByUserFunc = func(ctx context.Context, userID string) ([]string, error) {
+		exportsUserID = userID
+		return []string{"1"}, nil
+	}
+	mocks.blobs.Put(context.Background(), dataExportArchiveKey("1"), strings.NewReader("archive"), &BlobMetadata{})
+	mocks.audit.RedactUserFunc = func(ctx context.Context, userID string) error {
+		redactedUserID = userID
+		return nil
+	}
+	mocks.audit.CreateFunc = func(ctx context.Context, entry *AuditEntry) error {
+		actions = append(actions, entry.Action)
+
+		if entry.Action == "user.erase" {
+			assert.Empty(t, entry.Changes)
+		}
+
+		return nil
+	}
+
+	user, err := mocks.service().Erase(sessionContext("other-admin"), "other-admin", &EraseUserRequest{
+		OrganizationOwners: map[string]string{"org": "admin"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "erased-other-admin@erased.invalid", user.Email)
+	assert.Equal(t, user, pseudonymized)
+	assert.Equal(t, "admin", reassigned.OwnerUserID)
+	assert.Equal(t, "other-admin", revokedUserID)
+	assert.Equal(t, "other-admin", redactedUserID)
+	assert.Equal(t, "other-admin", exportsUserID)
+	assert.Empty(t, mocks.blobs.blobs)
+	assert.Equal(t, []string{"organization.update", "user.erase"}, actions)
+}
+
+func TestErasureService_Erase_RunsErasers(t *testing.T) {
+	var erasedUserIDs []string
+	erasureService := newErasureMocks().service()
+	erasureService.AddEraser(func(ctx context.Context, userID string) error {
+		erasedUserIDs = append(erasedUserIDs, userID)
+		return nil
+	})
+
+	_, err := erasureService.Erase(sessionContext("admin"), "teacher", &EraseUserRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"teacher"}, erasedUserIDs)
+}
+
+// Fails every delete, as when the blob backend is unreachable
+type failingBlobStore struct {
+	*MockBlobStore
+}
+
+func (s *failingBlobStore) Delete(ctx context.Context, key string) error {
+	return errors.New("connection refused")
+}
@@ -0,0 +1,39 @@
+package main
+
+import "errors"
+
+var (
+	ErrInternal     = errors.New("an internal error occurred")
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("authentication required")
+	ErrForbidden    = errors.New("you do not have permission to perform this action")
+)
+
+// An error with its own message that still matches one of the errors above
+type kindError struct {
+	message string
+	kind    error
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// Returns an error reading "<entity> not found" that matches ErrNotFound
+func notFound(entity string) error {
+	return &kindError{message: entity + " not found", kind: ErrNotFound}
+}
+
+// Returns an error with the given message that matches ErrUnauthorized
+func unauthorized(message string) error {
+	return &kindError{message: message, kind: ErrUnauthorized}
+}
+
+// Returns an error with the given message that matches ErrForbidden
+func forbidden(message string) error {
+	return &kindError{message: message, kind: ErrForbidden}
+}
@@ -0,0 +1,1285 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GPAWeighted   = "weighted"
+	GPAUnweighted = "unweighted"
+)
+
+var gpaKinds = []string{GPAWeighted, GPAUnweighted}
+
+const (
+	gpaPollInterval    = 5 * time.Second
+	gpaQueueBatchSize  = 100
+	maxGPABoost        = 2
+	maxHonorRollsCount = 10
+)
+
+// A rung of a school's honor roll, awarded on a term's GPA
+type HonorRoll struct {
+	Name   string  `json:"name"`
+	MinGPA float64 `json:"minGpa"`
+	// Which GPA MinGPA applies to, weighted or unweighted
+	GPA string `json:"gpa"`
+	// Leaves off students with a course graded below this many grade points. Nil allows any.
+	MinGradePoints *float64 `json:"minGradePoints"`
+	// The fewest graded credits a student must carry in the term
+	MinCredits float64 `json:"minCredits"`
+}
+
+// How a school turns grades into GPAs, class rank and honor rolls
+type GPARules struct {
+	// Grade points added to courses of each level in the weighted GPA
+	Boosts map[string]float64 `json:"boosts"`
+	// Whether failing grades, which earn no grade points, still get the boost
+	BoostFailing bool `json:"boostFailing"`
+	// The GPA class rank compares, weighted or unweighted
+	RankBy string `json:"rankBy"`
+	// Checked in order. A student is listed on the first roll they qualify for.
+	HonorRolls []HonorRoll `json:"honorRolls"`
+}
+
+type GPAPolicy struct {
+	SchoolID string `json:"schoolId"`
+	GPARules
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Used by schools that have not configured a policy of their own
+func defaultGPARules() GPARules {
+	return GPARules{
+		Boosts: map[string]float64{CourseLevelHonors: 0.5, CourseLevelAP: 1},
+		RankBy: GPAWeighted,
+		HonorRolls: []HonorRoll{
+			{Name: "High Honors", MinGPA: 3.75, GPA: GPAUnweighted, MinGradePoints: floatPtr(3)},
+			{Name: "Honors", MinGPA: 3.25, GPA: GPAUnweighted, MinGradePoints: floatPtr(2)},
+		},
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+// A graded course as GPAs count it. Grade points are nil until the course has graded work.
+type GPACourse struct {
+	Level       string   `json:"level"`
+	Credits     float64  `json:"credits"`
+	GradePoints *float64 `json:"gradePoints"`
+}
+
+// A student's average in a section, kept up to date from the gradebook
+type CourseMark struct {
+	SectionID     string `json:"sectionId"`
+	StudentUserID string `json:"studentUserId"`
+	SchoolID      string `json:"schoolId"`
+	CourseID      string `json:"courseId"`
+	TermID        string `json:"termId"`
+	GPACourse
+	// The enrollment status: in progress while enrolled, final once completed or failed
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Grade point averages over a set of courses, weighted by credits. Both are nil when no
+// graded course carries credit.
+type GPA struct {
+	Unweighted *float64 `json:"unweighted"`
+	Weighted   *float64 `json:"weighted"`
+	// The credits of the graded courses averaged over
+	Credits float64 `json:"credits"`
+	// The lowest unweighted grade points among those courses
+	LowestGradePoints *float64 `json:"lowestGradePoints"`
+}
+
+// Returns the weighted or unweighted average
+func (g *GPA) Of(kind string) *float64 {
+	if kind == GPAWeighted {
+		return g.Weighted
+	}
+
+	return g.Unweighted
+}
+
+// A student's GPA for a term, or their cumulative GPA when TermID is empty
+type StudentGPA struct {
+	SchoolID      string `json:"schoolId"`
+	StudentUserID string `json:"studentUserId"`
+	TermID        string `json:"termId,omitempty"`
+	// The student's grade level at the school, when one is set
+	GradeLevel *int `json:"gradeLevel,omitempty"`
+	GPA
+	ComputedAt time.Time `json:"computedAt"`
+}
+
+type StudentGradeLevel struct {
+	SchoolID      string    `json:"schoolId"`
+	StudentUserID string    `json:"studentUserId"`
+	GradeLevel    int       `json:"gradeLevel"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// A mark waiting to be recomputed
+type QueuedMark struct {
+	SectionID     string
+	StudentUserID string
+	QueuedAt      time.Time
+}
+
+// Narrows a GPA listing. Empty fields match everything.
+type GPAFilter struct {
+	SchoolID      string
+	StudentUserID string
+	TermID        string
+	// Only cumulative GPAs, ignoring TermID
+	Cumulative bool
+	GradeLevel *int
+}
+
+type GPAPostgresStore struct {
+	db *PostgresDB
+}
+
+type GPAStore interface {
+	GetPolicy(ctx context.Context, schoolID string) (*GPAPolicy, error)
+	SavePolicy(ctx context.Context, policy *GPAPolicy) error
+	GetGradeLevel(ctx context.Context, schoolID string, studentUserID string) (*StudentGradeLevel, error)
+	SaveGradeLevel(ctx context.Context, level *StudentGradeLevel) error
+	// Queues the students' marks in a section for recomputing. With no students it queues
+	// everyone on the section's roster or with a mark in it.
+	Enqueue(ctx context.Context, sectionID string, studentUserIDs []string) error
+	// Queues every mark in the course's sections
+	EnqueueCourse(ctx context.Context, courseID string) error
+	// Queues every mark in the school's sections
+	EnqueueSchool(ctx context.Context, schoolID string) error
+	// Returns up to limit queued marks, longest waiting first
+	ListQueued(ctx context.Context, limit int) ([]*QueuedMark, error)
+	// Removes a mark from the queue unless it was queued again since it was listed
+	Dequeue(ctx context.Context, mark *QueuedMark) error
+	SaveMark(ctx context.Context, mark *CourseMark) error
+	DeleteMark(ctx context.Context, sectionID string, studentUserID string) error
+	ListMarks(ctx context.Context, schoolID string, studentUserID string) ([]*CourseMark, error)
+	// Replaces all of the student's GPAs at the school
+	SaveGPAs(ctx context.Context, schoolID string, studentUserID string, gpas []*StudentGPA) error
+	ListGPAs(ctx context.Context, filter *GPAFilter) ([]*StudentGPA, error)
+}
+
+func (s *GPAPostgresStore) GetPolicy(ctx context.Context, schoolID string) (*GPAPolicy, error) {
+	query := `
+		SELECT school_id, policy, updated_at
+		FROM gpa_policies
+		WHERE school_id = $1
+	`
+
+	var policy GPAPolicy
+
+	err := s.db.pool.QueryRow(ctx, query, schoolID).Scan(&policy.SchoolID, &policy.GPARules, &policy.UpdatedAt)
+
+	return noRowsAsNil(&policy, err)
+}
+
+func (s *GPAPostgresStore) SavePolicy(ctx context.Context, policy *GPAPolicy) error {
+	query := `
+		INSERT INTO gpa_policies (school_id, policy, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (school_id) DO UPDATE SET policy = excluded.policy, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, policy.SchoolID, policy.GPARules, policy.UpdatedAt)
+
+	return err
+}
+
+func (s *GPAPostgresStore) GetGradeLevel(ctx context.Context, schoolID string, studentUserID string) (*StudentGradeLevel, error) {
+	query := `
+		SELECT school_id, student_user_id, grade_level, updated_at
+		FROM student_grade_levels
+		WHERE school_id = $1 AND student_user_id = $2
+	`
+
+	var level StudentGradeLevel
+
+	err := s.db.pool.QueryRow(ctx, query, schoolID, studentUserID).Scan(&level.SchoolID, &level.StudentUserID, &level.GradeLevel, &level.UpdatedAt)
+
+	return noRowsAsNil(&level, err)
+}
+
+func (s *GPAPostgresStore) SaveGradeLevel(ctx context.Context, level *StudentGradeLevel) error {
+	query := `
+		INSERT INTO student_grade_levels (school_id, student_user_id, grade_level, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (school_id, student_user_id) DO UPDATE SET grade_level = excluded.grade_level, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, level.SchoolID, level.StudentUserID, level.GradeLevel, level.UpdatedAt)
+
+	return err
+}
+
+func (s *GPAPostgresStore) Enqueue(ctx context.Context, sectionID string, studentUserIDs []string) error {
+	if len(studentUserIDs) == 0 {
+		return s.enqueueSections(ctx, "id", sectionID)
+	}
+
+	query := `
+		INSERT INTO gpa_queue (section_id, student_user_id, queued_at)
+		SELECT $1, unnest($2::uuid[]), $3
+		ON CONFLICT (section_id, student_user_id) DO UPDATE SET queued_at = excluded.queued_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, sectionID, studentUserIDs, time.Now())
+
+	return err
+}
+
+func (s *GPAPostgresStore) EnqueueCourse(ctx context.Context, courseID string) error {
+	return s.enqueueSections(ctx, "course_id", courseID)
+}
+
+func (s *GPAPostgresStore) EnqueueSchool(ctx context.Context, schoolID string) error {
+	return s.enqueueSections(ctx, "school_id", schoolID)
+}
+
+// Queues the roster and existing marks of every section whose column equals value. The
+// statuses are those isRosterEnrollment accepts.
+func (s *GPAPostgresStore) enqueueSections(ctx context.Context, column string, value string) error {
+	query := `
+		INSERT INTO gpa_queue (section_id, student_user_id, queued_at)
+		SELECT section_id, student_user_id, $2
+		FROM (
+			SELECT e.section_id, e.student_user_id
+			FROM enrollments e
+			JOIN sections s ON s.id = e.section_id
+			WHERE s.` + column + ` = $1 AND e.status IN ('enrolled', 'completed', 'failed')
+			UNION
+			SELECT m.section_id, m.student_user_id
+			FROM course_marks m
+			JOIN sections s ON s.id = m.section_id
+			WHERE s.` + column + ` = $1
+		) marks
+		ON CONFLICT (section_id, student_user_id) DO UPDATE SET queued_at = excluded.queued_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, value, time.Now())
+
+	return err
+}
+
+func (s *GPAPostgresStore) ListQueued(ctx context.Context, limit int) ([]*QueuedMark, error) {
+	query := `
+		SELECT section_id, student_user_id, queued_at
+		FROM gpa_queue
+		ORDER BY queued_at
+		LIMIT $1
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var queued []*QueuedMark
+
+	for rows.Next() {
+		var mark QueuedMark
+
+		if err := rows.Scan(&mark.SectionID, &mark.StudentUserID, &mark.QueuedAt); err != nil {
+			return nil, err
+		}
+
+		queued = append(queued, &mark)
+	}
+
+	return queued, rows.Err()
+}
+
+func (s *GPAPostgresStore) Dequeue(ctx context.Context, mark *QueuedMark) error {
+	query := `DELETE FROM gpa_queue WHERE section_id = $1 AND student_user_id = $2 AND queued_at = $3`
+
+	_, err := s.db.pool.Exec(ctx, query, mark.SectionID, mark.StudentUserID, mark.QueuedAt)
+
+	return err
+}
+
+func (s *GPAPostgresStore) SaveMark(ctx context.Context, mark *CourseMark) error {
+	query := `
+		INSERT INTO course_marks (section_id, student_user_id, school_id, course_id, term_id, level, credits, grade_points, status, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (section_id, student_user_id) DO UPDATE
+		SET school_id = excluded.school_id, course_id = excluded.course_id, term_id = excluded.term_id, level = excluded.level,
+			credits = excluded.credits, grade_points = excluded.grade_points, status = excluded.status, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		mark.SectionID,
+		mark.StudentUserID,
+		mark.SchoolID,
+		mark.CourseID,
+		mark.TermID,
+		mark.Level,
+		mark.Credits,
+		mark.GradePoints,
+		mark.Status,
+		mark.UpdatedAt,
+	)
+
+	return err
+}
+
+func (s *GPAPostgresStore) DeleteMark(ctx context.Context, sectionID string, studentUserID string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM course_marks WHERE section_id = $1 AND student_user_id = $2`, sectionID, studentUserID)
+	return err
+}
+
+func (s *GPAPostgresStore) ListMarks(ctx context.Context, schoolID string, studentUserID string) ([]*CourseMark, error) {
+	query := `
+		SELECT section_id, student_user_id, school_id, course_id, term_id, level, credits, grade_points, status, updated_at
+		FROM course_marks
+		WHERE school_id = $1 AND student_user_id = $2
+		ORDER BY term_id, section_id
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var marks []*CourseMark
+
+	for rows.Next() {
+		var mark CourseMark
+
+		err := rows.Scan(
+			&mark.SectionID,
+			&mark.StudentUserID,
+			&mark.SchoolID,
+			&mark.CourseID,
+			&mark.TermID,
+			&mark.Level,
+			&mark.Credits,
+			&mark.GradePoints,
+			&mark.Status,
+			&mark.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		marks = append(marks, &mark)
+	}
+
+	return marks, rows.Err()
+}
+
+func (s *GPAPostgresStore) SaveGPAs(ctx context.Context, schoolID string, studentUserID string, gpas []*StudentGPA) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM student_gpas WHERE school_id = $1 AND student_user_id = $2`, schoolID, studentUserID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO student_gpas (school_id, student_user_id, term_id, unweighted, weighted, credits, lowest_grade_points, computed_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8)
+	`
+
+	for _, gpa := range gpas {
+		_, err := tx.Exec(ctx, query,
+			gpa.SchoolID,
+			gpa.StudentUserID,
+			gpa.TermID,
+			gpa.Unweighted,
+			gpa.Weighted,
+			gpa.Credits,
+			gpa.LowestGradePoints,
+			gpa.ComputedAt,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *GPAPostgresStore) ListGPAs(ctx context.Context, filter *GPAFilter) ([]*StudentGPA, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("g.school_id = $%d", filter.SchoolID)
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("g.student_user_id = $%d", filter.StudentUserID)
+	}
+
+	if filter.Cumulative {
+		conditions = append(conditions, "g.term_id IS NULL")
+	} else if filter.TermID != "" {
+		addCondition("g.term_id = $%d", filter.TermID)
+	}
+
+	if filter.GradeLevel != nil {
+		addCondition("l.grade_level = $%d", *filter.GradeLevel)
+	}
+
+	query := `
+		SELECT g.school_id, g.student_user_id, COALESCE(g.term_id::text, ''), l.grade_level, g.unweighted, g.weighted,
+			g.credits, g.lowest_grade_points, g.computed_at
+		FROM student_gpas g
+		LEFT JOIN student_grade_levels l ON l.school_id = g.school_id AND l.student_user_id = g.student_user_id
+	`
+
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	query += ` ORDER BY g.student_user_id, g.term_id NULLS FIRST`
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var gpas []*StudentGPA
+
+	for rows.Next() {
+		var gpa StudentGPA
+
+		err := rows.Scan(
+			&gpa.SchoolID,
+			&gpa.StudentUserID,
+			&gpa.TermID,
+			&gpa.GradeLevel,
+			&gpa.Unweighted,
+			&gpa.Weighted,
+			&gpa.Credits,
+			&gpa.LowestGradePoints,
+			&gpa.ComputedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		gpas = append(gpas, &gpa)
+	}
+
+	return gpas, rows.Err()
+}
+
+func validateGPARules(rules *GPARules) error {
+	for level, boost := range rules.Boosts {
+		if !slices.Contains(courseLevels, level) {
+			return fmt.Errorf("boosts must be for course levels %v", courseLevels)
+		}
+
+		if boost < 0 || boost > maxGPABoost {
+			return fmt.Errorf("boosts must be between 0 and %d", maxGPABoost)
+		}
+	}
+
+	if !slices.Contains(gpaKinds, rules.RankBy) {
+		return fmt.Errorf("rank by must be one of %v", gpaKinds)
+	}
+
+	if len(rules.HonorRolls) > maxHonorRollsCount {
+		return fmt.Errorf("a school can have at most %d honor rolls", maxHonorRollsCount)
+	}
+
+	seen := map[string]bool{}
+
+	for _, roll := range rules.HonorRolls {
+		if roll.Name == "" {
+			return errors.New("every honor roll needs a name")
+		}
+
+		if seen[strings.ToLower(roll.Name)] {
+			return fmt.Errorf("honor roll %s is defined more than once", roll.Name)
+		}
+
+		seen[strings.ToLower(roll.Name)] = true
+
+		if !slices.Contains(gpaKinds, roll.GPA) {
+			return fmt.Errorf("honor roll gpa must be one of %v", gpaKinds)
+		}
+
+		if roll.MinGPA < 0 || roll.MinCredits < 0 || (roll.MinGradePoints != nil && *roll.MinGradePoints < 0) {
+			return errors.New("honor roll minimums must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// Averages the graded courses worth credit. Courses earn their level's boost in the weighted
+// average, failing ones only if the rules say so.
+func (r *GPARules) Calculate(courses []GPACourse) GPA {
+	var gpa GPA
+	var unweighted, weighted float64
+
+	for _, course := range courses {
+		if course.GradePoints == nil || course.Credits <= 0 {
+			continue
+		}
+
+		points := *course.GradePoints
+		boosted := points
+
+		if points > 0 || r.BoostFailing {
+			boosted += r.Boosts[course.Level]
+		}
+
+		unweighted += points * course.Credits
+		weighted += boosted * course.Credits
+		gpa.Credits += course.Credits
+
+		if gpa.LowestGradePoints == nil || points < *gpa.LowestGradePoints {
+			gpa.LowestGradePoints = &points
+		}
+	}
+
+	if gpa.Credits > 0 {
+		gpa.Unweighted = floatPtr(unweighted / gpa.Credits)
+		gpa.Weighted = floatPtr(weighted / gpa.Credits)
+	}
+
+	return gpa
+}
+
+// Computes a student's GPA for every term they have marks in, counting courses in progress,
+// and their cumulative GPA, which counts only final grades
+func studentGPAs(rules *GPARules, schoolID string, studentUserID string, marks []*CourseMark, now time.Time) []*StudentGPA {
+	byTerm := map[string][]GPACourse{}
+	var final []GPACourse
+
+	for _, mark := range marks {
+		byTerm[mark.TermID] = append(byTerm[mark.TermID], mark.GPACourse)
+
+		if mark.Status == EnrollmentStatusCompleted || mark.Status == EnrollmentStatusFailed {
+			final = append(final, mark.GPACourse)
+		}
+	}
+
+	gpas := []*StudentGPA{{SchoolID: schoolID, StudentUserID: studentUserID, GPA: rules.Calculate(final), ComputedAt: now}}
+
+	for termID, courses := range byTerm {
+		gpas = append(gpas, &StudentGPA{SchoolID: schoolID, StudentUserID: studentUserID, TermID: termID, GPA: rules.Calculate(courses), ComputedAt: now})
+	}
+
+	slices.SortFunc(gpas[1:], func(a, b *StudentGPA) int {
+		return cmp.Compare(a.TermID, b.TermID)
+	})
+
+	return gpas
+}
+
+type ClassRankEntry struct {
+	StudentUserID string  `json:"studentUserId"`
+	GPA           float64 `json:"gpa"`
+	// Students with the same GPA share a rank, and the next rank skips past them
+	Rank int `json:"rank"`
+}
+
+type ClassRank struct {
+	SchoolID   string           `json:"schoolId"`
+	GradeLevel int              `json:"gradeLevel"`
+	RankBy     string           `json:"rankBy"`
+	Students   []ClassRankEntry `json:"students"`
+}
+
+// Ranks the students by their weighted or unweighted GPA, highest first. Students without
+// one are left out.
+func rankStudents(gpas []*StudentGPA, by string) []ClassRankEntry {
+	entries := []ClassRankEntry{}
+
+	for _, gpa := range gpas {
+		if value := gpa.Of(by); value != nil {
+			entries = append(entries, ClassRankEntry{StudentUserID: gpa.StudentUserID, GPA: *value})
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b ClassRankEntry) int {
+		return cmp.Or(cmp.Compare(b.GPA, a.GPA), cmp.Compare(a.StudentUserID, b.StudentUserID))
+	})
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+
+		if i > 0 && entries[i].GPA == entries[i-1].GPA {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+
+	return entries
+}
+
+type HonorRollEntry struct {
+	StudentUserID string  `json:"studentUserId"`
+	GradeLevel    *int    `json:"gradeLevel,omitempty"`
+	GPA           float64 `json:"gpa"`
+}
+
+type HonorRollList struct {
+	Name     string           `json:"name"`
+	Students []HonorRollEntry `json:"students"`
+}
+
+// Places each student on the first honor roll their term GPA qualifies them for. Every roll
+// is listed, highest GPA first.
+func honorRolls(rules *GPARules, gpas []*StudentGPA) []HonorRollList {
+	lists := make([]HonorRollList, len(rules.HonorRolls))
+
+	for i, roll := range rules.HonorRolls {
+		lists[i] = HonorRollList{Name: roll.Name, Students: []HonorRollEntry{}}
+	}
+
+	for _, gpa := range gpas {
+		for i, roll := range rules.HonorRolls {
+			value := gpa.Of(roll.GPA)
+
+			if value == nil || *value < roll.MinGPA || gpa.Credits < roll.MinCredits {
+				continue
+			}
+
+			if roll.MinGradePoints != nil && *gpa.LowestGradePoints < *roll.MinGradePoints {
+				continue
+			}
+
+			lists[i].Students = append(lists[i].Students, HonorRollEntry{StudentUserID: gpa.StudentUserID, GradeLevel: gpa.GradeLevel, GPA: *value})
+
+			break
+		}
+	}
+
+	for _, list := range lists {
+		slices.SortFunc(list.Students, func(a, b HonorRollEntry) int {
+			return cmp.Or(cmp.Compare(b.GPA, a.GPA), cmp.Compare(a.StudentUserID, b.StudentUserID))
+		})
+	}
+
+	return lists
+}
+
+// Queues the students' marks in a section for recomputing, or the whole roster's when no
+// students are given. A failure leaves the GPAs stale until the school's are recomputed, so
+// it is logged rather than failing the change that caused it.
+func queueGPARecompute(ctx context.Context, gpaStore GPAStore, sectionID string, studentUserIDs ...string) {
+	if err := gpaStore.Enqueue(ctx, sectionID, studentUserIDs); err != nil {
+		slog.Error("failed to queue GPA recompute", "error", err, "sectionId", sectionID)
+	}
+}
+
+type GPAService struct {
+	gpaStore         GPAStore
+	gradebookService *GradebookService
+	enrollmentStore  EnrollmentStore
+	sectionStore     SectionStore
+	courseStore      CourseStore
+	calendarStore    CalendarStore
+	schoolStore      SchoolStore
+	memberStore      OrganizationMemberStore
+	auditService     *AuditService
+}
+
+func NewGPAService(gpaStore GPAStore, gradebookService *GradebookService, enrollmentStore EnrollmentStore, sectionStore SectionStore, courseStore CourseStore, calendarStore CalendarStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *GPAService {
+	return &GPAService{
+		gpaStore:         gpaStore,
+		gradebookService: gradebookService,
+		enrollmentStore:  enrollmentStore,
+		sectionStore:     sectionStore,
+		courseStore:      courseStore,
+		calendarStore:    calendarStore,
+		schoolStore:      schoolStore,
+		memberStore:      memberStore,
+		auditService:     auditService,
+	}
+}
+
+// Returns the school's GPA policy, or the default one if it has not set its own
+func (s *GPAService) policy(ctx context.Context, schoolID string) (*GPAPolicy, error) {
+	policy, err := s.gpaStore.GetPolicy(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to get GPA policy", "error", err)
+		return nil, ErrInternal
+	}
+
+	if policy == nil {
+		policy = &GPAPolicy{SchoolID: schoolID, GPARules: defaultGPARules()}
+	}
+
+	return policy, nil
+}
+
+func (s *GPAService) GetPolicy(ctx context.Context, schoolID string) (*GPAPolicy, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	return s.policy(ctx, schoolID)
+}
+
+// Replaces the school's GPA policy and recomputes every GPA at the school
+func (s *GPAService) SavePolicy(ctx context.Context, policy *GPAPolicy) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, policy.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if policy.Boosts == nil {
+		policy.Boosts = map[string]float64{}
+	}
+
+	if policy.HonorRolls == nil {
+		policy.HonorRolls = []HonorRoll{}
+	}
+
+	if err := validateGPARules(&policy.GPARules); err != nil {
+		return err
+	}
+
+	before, err := s.policy(ctx, policy.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	policy.UpdatedAt = time.Now()
+
+	if err := s.gpaStore.SavePolicy(ctx, policy); err != nil {
+		slog.Error("failed to save GPA policy", "error", err)
+		return ErrInternal
+	}
+
+	if err := s.gpaStore.EnqueueSchool(ctx, policy.SchoolID); err != nil {
+		slog.Error("failed to queue GPA recompute", "error", err, "schoolId", policy.SchoolID)
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "gpa_policy", policy.SchoolID, before, policy)
+
+	return nil
+}
+
+// Sets the grade level a student is ranked in at the school
+func (s *GPAService) SetGradeLevel(ctx context.Context, level *StudentGradeLevel) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, level.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if level.GradeLevel < minGradeLevel || level.GradeLevel > maxGradeLevel {
+		return fmt.Errorf("grade level must be between %d and %d", minGradeLevel, maxGradeLevel)
+	}
+
+	member, err := s.memberStore.Get(ctx, school.OrganizationID, level.StudentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if member == nil || member.Role != RoleStudent {
+		return errors.New("student must be a student of the organization")
+	}
+
+	before, err := s.gpaStore.GetGradeLevel(ctx, level.SchoolID, level.StudentUserID)
+
+	if err != nil {
+		slog.Error("failed to get grade level", "error", err)
+		return ErrInternal
+	}
+
+	level.UpdatedAt = time.Now()
+
+	if err := s.gpaStore.SaveGradeLevel(ctx, level); err != nil {
+		slog.Error("failed to save grade level", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "student_grade_level", level.SchoolID+":"+level.StudentUserID, before, level)
+
+	return nil
+}
+
+// Queues every GPA at the school for recomputing, for when one may have gone stale
+func (s *GPAService) Recompute(ctx context.Context, schoolID string) error {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.gpaStore.EnqueueSchool(ctx, schoolID); err != nil {
+		slog.Error("failed to queue GPA recompute", "error", err, "schoolId", schoolID)
+		return ErrInternal
+	}
+
+	return nil
+}
+
+// Brings a student's mark in a section up to date with the gradebook, removing it when they
+// are no longer on the roster. Returns the section's school, or nothing if the section or its
+// school is gone.
+func (s *GPAService) recomputeMark(ctx context.Context, queued *QueuedMark) (string, error) {
+	section, err := s.sectionStore.GetByID(ctx, queued.SectionID)
+
+	if err != nil {
+		return "", fmt.Errorf("getting section: %w", err)
+	}
+
+	if section == nil {
+		return "", nil
+	}
+
+	school, err := s.schoolStore.GetByID(ctx, section.SchoolID)
+
+	if err != nil {
+		return "", fmt.Errorf("getting school: %w", err)
+	}
+
+	if school == nil {
+		return "", nil
+	}
+
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: section.ID, StudentUserID: queued.StudentUserID})
+
+	if err != nil {
+		return "", fmt.Errorf("listing enrollments: %w", err)
+	}
+
+	index := slices.IndexFunc(enrollments, isRosterEnrollment)
+
+	course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+	if err != nil {
+		return "", fmt.Errorf("getting course: %w", err)
+	}
+
+	if index < 0 || course == nil {
+		if err := s.gpaStore.DeleteMark(ctx, section.ID, queued.StudentUserID); err != nil {
+			return "", fmt.Errorf("deleting mark: %w", err)
+		}
+
+		return school.ID, nil
+	}
+
+	gradebook, err := s.gradebookService.build(ctx, section, school, []string{queued.StudentUserID}, queued.StudentUserID)
+
+	if err != nil {
+		return "", err
+	}
+
+	mark := &CourseMark{
+		SectionID:     section.ID,
+		StudentUserID: queued.StudentUserID,
+		SchoolID:      school.ID,
+		CourseID:      course.ID,
+		TermID:        section.TermID,
+		GPACourse:     GPACourse{Level: course.Level, Credits: course.Credits, GradePoints: gradebook.Students[0].Average.GradePoints},
+		Status:        enrollments[index].Status,
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := s.gpaStore.SaveMark(ctx, mark); err != nil {
+		return "", fmt.Errorf("saving mark: %w", err)
+	}
+
+	return school.ID, nil
+}
+
+// Recomputes the student's GPAs at the school from their marks
+func (s *GPAService) recomputeGPAs(ctx context.Context, schoolID string, studentUserID string) error {
+	policy, err := s.policy(ctx, schoolID)
+
+	if err != nil {
+		return err
+	}
+
+	marks, err := s.gpaStore.ListMarks(ctx, schoolID, studentUserID)
+
+	if err != nil {
+		return fmt.Errorf("listing marks: %w", err)
+	}
+
+	gpas := studentGPAs(&policy.GPARules, schoolID, studentUserID, marks, time.Now())
+
+	if err := s.gpaStore.SaveGPAs(ctx, schoolID, studentUserID, gpas); err != nil {
+		return fmt.Errorf("saving GPAs: %w", err)
+	}
+
+	return nil
+}
+
+// Recomputes a batch of queued marks and then the GPAs of the students they belong to. Only
+// those students are recomputed, and only their changed marks are rebuilt from the gradebook.
+// Marks that fail stay queued to be retried. Returns how many marks were recomputed.
+func (s *GPAService) ProcessQueue(ctx context.Context) (int, error) {
+	queued, err := s.gpaStore.ListQueued(ctx, gpaQueueBatchSize)
+
+	if err != nil {
+		slog.Error("failed to list queued GPA recomputes", "error", err)
+		return 0, ErrInternal
+	}
+
+	type student struct {
+		schoolID      string
+		studentUserID string
+	}
+
+	var students []student
+	failed := map[string]bool{}
+
+	for _, mark := range queued {
+		schoolID, err := s.recomputeMark(ctx, mark)
+
+		if err != nil {
+			slog.Error("failed to recompute mark", "error", err, "sectionId", mark.SectionID, "studentUserId", mark.StudentUserID)
+			failed[mark.StudentUserID] = true
+
+			continue
+		}
+
+		if schoolID != "" && !slices.Contains(students, student{schoolID, mark.StudentUserID}) {
+			students = append(students, student{schoolID, mark.StudentUserID})
+		}
+	}
+
+	for _, student := range students {
+		if err := s.recomputeGPAs(ctx, student.schoolID, student.studentUserID); err != nil {
+			slog.Error("failed to recompute GPAs", "error", err, "schoolId", student.schoolID, "studentUserId", student.studentUserID)
+			failed[student.studentUserID] = true
+		}
+	}
+
+	processed := 0
+
+	for _, mark := range queued {
+		// A student's marks are only done once their GPAs include them
+		if failed[mark.StudentUserID] {
+			continue
+		}
+
+		if err := s.gpaStore.Dequeue(ctx, mark); err != nil {
+			slog.Error("failed to dequeue GPA recompute", "error", err)
+			return processed, ErrInternal
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (s *GPAService) Run(ctx context.Context) {
+	ticker := time.NewTicker(gpaPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.ProcessQueue(ctx)
+
+			if err != nil || processed == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GPAService) listGPAs(ctx context.Context, filter *GPAFilter) ([]*StudentGPA, error) {
+	gpas, err := s.gpaStore.ListGPAs(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list GPAs", "error", err)
+		return nil, ErrInternal
+	}
+
+	return gpas, nil
+}
+
+// A student's GPAs at a school and where they rank in their grade
+type StudentStanding struct {
+	SchoolID      string        `json:"schoolId"`
+	StudentUserID string        `json:"studentUserId"`
+	GradeLevel    *int          `json:"gradeLevel"`
+	Cumulative    *StudentGPA   `json:"cumulative"`
+	Terms         []*StudentGPA `json:"terms"`
+	// Nil until the student has a grade level and a cumulative GPA
+	Rank      *int `json:"rank"`
+	ClassSize int  `json:"classSize"`
+}
+
+// Returns a student's GPAs and class rank. Students may read their own.
+func (s *GPAService) Standing(ctx context.Context, schoolID string, studentUserID string) (*StudentStanding, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	var err error
+
+	if session.UserID == studentUserID {
+		_, err = authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID)
+	} else {
+		_, err = authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := s.gpaStore.GetGradeLevel(ctx, schoolID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get grade level", "error", err)
+		return nil, ErrInternal
+	}
+
+	gpas, err := s.listGPAs(ctx, &GPAFilter{SchoolID: schoolID, StudentUserID: studentUserID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	standing := &StudentStanding{SchoolID: schoolID, StudentUserID: studentUserID, Terms: []*StudentGPA{}}
+
+	if level != nil {
+		standing.GradeLevel = &level.GradeLevel
+	}
+
+	for _, gpa := range gpas {
+		if gpa.TermID == "" {
+			standing.Cumulative = gpa
+		} else {
+			standing.Terms = append(standing.Terms, gpa)
+		}
+	}
+
+	if standing.GradeLevel == nil || standing.Cumulative == nil {
+		return standing, nil
+	}
+
+	policy, err := s.policy(ctx, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	class, err := s.listGPAs(ctx, &GPAFilter{SchoolID: schoolID, Cumulative: true, GradeLevel: standing.GradeLevel})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := rankStudents(class, policy.RankBy)
+	standing.ClassSize = len(ranked)
+
+	for _, entry := range ranked {
+		if entry.StudentUserID == studentUserID {
+			standing.Rank = &entry.Rank
+			break
+		}
+	}
+
+	return standing, nil
+}
+
+// Ranks the students of a grade level at the school by cumulative GPA
+func (s *GPAService) ClassRank(ctx context.Context, schoolID string, gradeLevel int) (*ClassRank, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	if gradeLevel < minGradeLevel || gradeLevel > maxGradeLevel {
+		return nil, fmt.Errorf("grade level must be between %d and %d", minGradeLevel, maxGradeLevel)
+	}
+
+	policy, err := s.policy(ctx, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gpas, err := s.listGPAs(ctx, &GPAFilter{SchoolID: schoolID, Cumulative: true, GradeLevel: &gradeLevel})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClassRank{SchoolID: schoolID, GradeLevel: gradeLevel, RankBy: policy.RankBy, Students: rankStudents(gpas, policy.RankBy)}, nil
+}
+
+// Lists the students on each of the school's honor rolls for a term
+func (s *GPAService) HonorRolls(ctx context.Context, schoolID string, termID string) ([]HonorRollList, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	term, err := s.calendarStore.GetTerm(ctx, termID)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return nil, ErrInternal
+	}
+
+	if term == nil || term.SchoolID != schoolID {
+		return nil, notFound("term")
+	}
+
+	policy, err := s.policy(ctx, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gpas, err := s.listGPAs(ctx, &GPAFilter{SchoolID: schoolID, TermID: termID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return honorRolls(&policy.GPARules, gpas), nil
+}
+
+type GPAHandler struct {
+	gpaService *GPAService
+}
+
+func (h *GPAHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.gpaService.GetPolicy(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *GPAHandler) SavePolicy(w http.ResponseWriter, r *http.Request) {
+	var policy GPAPolicy
+
+	if err := decodeJSON(r, &policy); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	policy.SchoolID = r.PathValue("id")
+
+	if err := h.gpaService.SavePolicy(r.Context(), &policy); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *GPAHandler) SetGradeLevel(w http.ResponseWriter, r *http.Request) {
+	var level StudentGradeLevel
+
+	if err := decodeJSON(r, &level); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	level.SchoolID = r.PathValue("id")
+	level.StudentUserID = r.PathValue("studentId")
+
+	if err := h.gpaService.SetGradeLevel(r.Context(), &level); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, level)
+}
+
+func (h *GPAHandler) Recompute(w http.ResponseWriter, r *http.Request) {
+	if err := h.gpaService.Recompute(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *GPAHandler) Standing(w http.ResponseWriter, r *http.Request) {
+	standing, err := h.gpaService.Standing(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, standing)
+}
+
+func (h *GPAHandler) ClassRank(w http.ResponseWriter, r *http.Request) {
+	gradeLevel, err := strconv.Atoi(r.URL.Query().Get("gradeLevel"))
+
+	if err != nil {
+		writeError(w, errors.New("gradeLevel must be a number"))
+		return
+	}
+
+	rank, err := h.gpaService.ClassRank(r.Context(), r.PathValue("id"), gradeLevel)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rank)
+}
+
+func (h *GPAHandler) HonorRolls(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.gpaService.HonorRolls(r.Context(), r.PathValue("id"), r.PathValue("termId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lists)
+}
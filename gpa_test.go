@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockGPAStore struct {
+	GetPolicyFunc      func(ctx context.Context, schoolID string) (*GPAPolicy, error)
+	SavePolicyFunc     func(ctx context.Context, policy *GPAPolicy) error
+	GetGradeLevelFunc  func(ctx context.Context, schoolID string, studentUserID string) (*StudentGradeLevel, error)
+	SaveGradeLevelFunc func(ctx context.Context, level *StudentGradeLevel) error
+	EnqueueFunc        func(ctx context.Context, sectionID string, studentUserIDs []string) error
+	EnqueueCourseFunc  func(ctx context.Context, courseID string) error
+	EnqueueSchoolFunc  func(ctx context.Context, schoolID string) error
+	ListQueuedFunc     func(ctx context.Context, limit int) ([]*QueuedMark, error)
+	DequeueFunc        func(ctx context.Context, mark *QueuedMark) error
+	SaveMarkFunc       func(ctx context.Context, mark *CourseMark) error
+	DeleteMarkFunc     func(ctx context.Context, sectionID string, studentUserID string) error
+	ListMarksFunc      func(ctx context.Context, schoolID string, studentUserID string) ([]*CourseMark, error)
+	SaveGPAsFunc       func(ctx context.Context, schoolID string, studentUserID string, gpas []*StudentGPA) error
+	ListGPAsFunc       func(ctx context.Context, filter *GPAFilter) ([]*StudentGPA, error)
+}
+
+func (m *MockGPAStore) GetPolicy(ctx context.Context, schoolID string) (*GPAPolicy, error) {
+	if m.GetPolicyFunc != nil {
+		return m.GetPolicyFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGPAStore) SavePolicy(ctx context.Context, policy *GPAPolicy) error {
+	if m.SavePolicyFunc != nil {
+		return m.SavePolicyFunc(ctx, policy)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) GetGradeLevel(ctx context.Context, schoolID string, studentUserID string) (*StudentGradeLevel, error) {
+	if m.GetGradeLevelFunc != nil {
+		return m.GetGradeLevelFunc(ctx, schoolID, studentUserID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGPAStore) SaveGradeLevel(ctx context.Context, level *StudentGradeLevel) error {
+	if m.SaveGradeLevelFunc != nil {
+		return m.SaveGradeLevelFunc(ctx, level)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) Enqueue(ctx context.Context, sectionID string, studentUserIDs []string) error {
+	if m.EnqueueFunc != nil {
+		return m.EnqueueFunc(ctx, sectionID, studentUserIDs)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) EnqueueCourse(ctx context.Context, courseID string) error {
+	if m.EnqueueCourseFunc != nil {
+		return m.EnqueueCourseFunc(ctx, courseID)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) EnqueueSchool(ctx context.Context, schoolID string) error {
+	if m.EnqueueSchoolFunc != nil {
+		return m.EnqueueSchoolFunc(ctx, schoolID)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) ListQueued(ctx context.Context, limit int) ([]*QueuedMark, error) {
+	if m.ListQueuedFunc != nil {
+		return m.ListQueuedFunc(ctx, limit)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGPAStore) Dequeue(ctx context.Context, mark *QueuedMark) error {
+	if m.DequeueFunc != nil {
+		return m.DequeueFunc(ctx, mark)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) SaveMark(ctx context.Context, mark *CourseMark) error {
+	if m.SaveMarkFunc != nil {
+		return m.SaveMarkFunc(ctx, mark)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) DeleteMark(ctx context.Context, sectionID string, studentUserID string) error {
+	if m.DeleteMarkFunc != nil {
+		return m.DeleteMarkFunc(ctx, sectionID, studentUserID)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) ListMarks(ctx context.Context, schoolID string, studentUserID string) ([]*CourseMark, error) {
+	if m.ListMarksFunc != nil {
+		return m.ListMarksFunc(ctx, schoolID, studentUserID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGPAStore) SaveGPAs(ctx context.Context, schoolID string, studentUserID string, gpas []*StudentGPA) error {
+	if m.SaveGPAsFunc != nil {
+		return m.SaveGPAsFunc(ctx, schoolID, studentUserID, gpas)
+	}
+
+	return nil
+}
+
+func (m *MockGPAStore) ListGPAs(ctx context.Context, filter *GPAFilter) ([]*StudentGPA, error) {
+	if m.ListGPAsFunc != nil {
+		return m.ListGPAsFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+// A GPA store that keeps marks in memory and records the GPAs saved and marks dequeued
+func markStore(queued ...*QueuedMark) (*MockGPAStore, map[string][]*StudentGPA, *[]*QueuedMark) {
+	marks := map[string]*CourseMark{}
+	saved := map[string][]*StudentGPA{}
+	var dequeued []*QueuedMark
+
+	return &MockGPAStore{
+		ListQueuedFunc: func(ctx context.Context, limit int) ([]*QueuedMark, error) {
+			return queued, nil
+		},
+		DequeueFunc: func(ctx context.Context, mark *QueuedMark) error {
+			dequeued = append(dequeued, mark)
+			return nil
+		},
+		SaveMarkFunc: func(ctx context.Context, mark *CourseMark) error {
+			marks[mark.SectionID] = mark
+			return nil
+		},
+		DeleteMarkFunc: func(ctx context.Context, sectionID string, studentUserID string) error {
+			delete(marks, sectionID)
+			return nil
+		},
+		ListMarksFunc: func(ctx context.Context, schoolID string, studentUserID string) ([]*CourseMark, error) {
+			var list []*CourseMark
+
+			for _, mark := range marks {
+				if mark.SchoolID == schoolID && mark.StudentUserID == studentUserID {
+					list = append(list, mark)
+				}
+			}
+
+			return list, nil
+		},
+		SaveGPAsFunc: func(ctx context.Context, schoolID string, studentUserID string, gpas []*StudentGPA) error {
+			saved[schoolID+":"+studentUserID] = gpas
+			return nil
+		},
+	}, saved, &dequeued
+}
+
+func gradedCourse(level string, credits float64, gradePoints float64) GPACourse {
+	return GPACourse{Level: level, Credits: credits, GradePoints: &gradePoints}
+}
+
+func TestGPARules_Calculate_WeightsByCreditsAndBoostsLevels(t *testing.T) {
+	rules := defaultGPARules()
+
+	gpa := rules.Calculate([]GPACourse{
+		gradedCourse(CourseLevelAP, 1, 4),
+		gradedCourse(CourseLevelHonors, 0.5, 3),
+		gradedCourse(CourseLevelStandard, 1, 2),
+		// Worth no credit, and not graded yet
+		gradedCourse(CourseLevelStandard, 0, 0),
+		{Level: CourseLevelAP, Credits: 1},
+	})
+
+	assert.Equal(t, 3.0, *gpa.Unweighted)
+	assert.Equal(t, 3.5, *gpa.Weighted)
+	assert.Equal(t, 2.5, gpa.Credits)
+	assert.Equal(t, 2.0, *gpa.LowestGradePoints)
+}
+
+func TestGPARules_Calculate_BoostsFailingGradesOnlyWhenConfigured(t *testing.T) {
+	rules := defaultGPARules()
+	courses := []GPACourse{gradedCourse(CourseLevelAP, 1, 0)}
+
+	assert.Equal(t, 0.0, *rules.Calculate(courses).Weighted)
+
+	rules.BoostFailing = true
+
+	assert.Equal(t, 1.0, *rules.Calculate(courses).Weighted)
+}
+
+func TestGPARules_Calculate_ReturnsNilWithoutGradedCredits(t *testing.T) {
+	rules := defaultGPARules()
+
+	gpa := rules.Calculate([]GPACourse{{Level: CourseLevelStandard, Credits: 1}})
+
+	assert.Nil(t, gpa.Unweighted)
+	assert.Nil(t, gpa.Weighted)
+}
+
+func TestStudentGPAs_CountsOnlyFinalGradesCumulatively(t *testing.T) {
+	rules := defaultGPARules()
+
+	gpas := studentGPAs(&rules, "school", "student", []*CourseMark{
+		{SectionID: "geo-01", TermID: "last-fall", GPACourse: gradedCourse(CourseLevelStandard, 1, 4), Status: EnrollmentStatusCompleted},
+		{SectionID: "bio-01", TermID: "last-fall", GPACourse: gradedCourse(CourseLevelStandard, 1, 0), Status: EnrollmentStatusFailed},
+		{SectionID: "alg2-01", TermID: "fall", GPACourse: gradedCourse(CourseLevelHonors, 1, 3), Status: EnrollmentStatusEnrolled},
+	}, time.Now())
+
+	assert.Len(t, gpas, 3)
+
+	assert.Equal(t, "", gpas[0].TermID)
+	assert.Equal(t, 2.0, *gpas[0].Unweighted)
+	assert.Equal(t, 2.0, gpas[0].Credits)
+
+	assert.Equal(t, "fall", gpas[1].TermID)
+	assert.Equal(t, 3.0, *gpas[1].Unweighted)
+	assert.Equal(t, 3.5, *gpas[1].Weighted)
+
+	assert.Equal(t, "last-fall", gpas[2].TermID)
+	assert.Equal(t, 2.0, *gpas[2].Unweighted)
+}
+
+func TestRankStudents_SharesRankBetweenTies(t *testing.T) {
+	gpas := []*StudentGPA{
+		{StudentUserID: "b", GPA: GPA{Weighted: floatPtr(3.5)}},
+		{StudentUserID: "c", GPA: GPA{Weighted: floatPtr(3.9)}},
+		{StudentUserID: "d"},
+		{StudentUserID: "a", GPA: GPA{Weighted: floatPtr(3.9)}},
+	}
+
+	ranked := rankStudents(gpas, GPAWeighted)
+
+	assert.Equal(t, []ClassRankEntry{
+		{StudentUserID: "a", GPA: 3.9, Rank: 1},
+		{StudentUserID: "c", GPA: 3.9, Rank: 1},
+		{StudentUserID: "b", GPA: 3.5, Rank: 3},
+	}, ranked)
+}
+
+func TestHonorRolls_PlacesStudentsOnFirstRollTheyQualifyFor(t *testing.T) {
+	rules := defaultGPARules()
+
+	lists := honorRolls(&rules, []*StudentGPA{
+		{StudentUserID: "straight-a", GPA: GPA{Unweighted: floatPtr(4), Credits: 3, LowestGradePoints: floatPtr(4)}},
+		{StudentUserID: "one-c", GPA: GPA{Unweighted: floatPtr(3.8), Credits: 5, LowestGradePoints: floatPtr(2)}},
+		{StudentUserID: "one-d", GPA: GPA{Unweighted: floatPtr(3.5), Credits: 4, LowestGradePoints: floatPtr(1)}},
+		{StudentUserID: "ungraded"},
+	})
+
+	assert.Len(t, lists, 2)
+	assert.Equal(t, "High Honors", lists[0].Name)
+	assert.Equal(t, []HonorRollEntry{{StudentUserID: "straight-a", GPA: 4}}, lists[0].Students)
+	assert.Equal(t, "Honors", lists[1].Name)
+	assert.Equal(t, []HonorRollEntry{{StudentUserID: "one-c", GPA: 3.8}}, lists[1].Students)
+}
+
+func TestValidateGPARules_ReturnsErrorForBoostOfUnknownLevel(t *testing.T) {
+	rules := defaultGPARules()
+	rules.Boosts["ib"] = 1
+
+	err := validateGPARules(&rules)
+
+	assert.Error(t, err)
+	assert.Equal(t, "boosts must be for course levels [standard honors ap]", err.Error())
+}
+
+func TestValidateGPARules_ReturnsErrorForDuplicateHonorRoll(t *testing.T) {
+	rules := defaultGPARules()
+	rules.HonorRolls[1].Name = "high honors"
+
+	err := validateGPARules(&rules)
+
+	assert.Error(t, err)
+	assert.Equal(t, "honor roll high honors is defined more than once", err.Error())
+}
+
+func TestGPAService_ProcessQueue_RecomputesMarksAndGPAs(t *testing.T) {
+	gpaStore, saved, dequeued := markStore(
+		&QueuedMark{SectionID: "geo-01", StudentUserID: "student"},
+		&QueuedMark{SectionID: "alg2-01", StudentUserID: "student"},
+	)
+
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	processed, err := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService).ProcessQueue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	assert.Len(t, *dequeued, 2)
+
+	gpas := saved["school:student"]
+	assert.Len(t, gpas, 3)
+
+	// Only completed Geometry counts cumulatively, while this fall's term GPA counts Algebra 2
+	// Honors as it stands
+	assert.Equal(t, 4.0, *gpas[0].Unweighted)
+	assert.Equal(t, "fall", gpas[1].TermID)
+	assert.Equal(t, 3.0, *gpas[1].Unweighted)
+	assert.Equal(t, 3.5, *gpas[1].Weighted)
+	assert.Equal(t, "last-fall", gpas[2].TermID)
+	assert.Equal(t, 4.0, *gpas[2].Weighted)
+}
+
+func TestGPAService_ProcessQueue_DeletesMarkOfDroppedStudent(t *testing.T) {
+	gpaStore, saved, _ := markStore(&QueuedMark{SectionID: "alg2-01", StudentUserID: "student"})
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+	gpaService.enrollmentStore = &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "2", SectionID: filter.SectionID, StudentUserID: filter.StudentUserID, Status: EnrollmentStatusDropped}}, nil
+		},
+	}
+
+	var deleted []string
+	gpaStore.DeleteMarkFunc = func(ctx context.Context, sectionID string, studentUserID string) error {
+		deleted = append(deleted, sectionID)
+		return nil
+	}
+
+	_, err := gpaService.ProcessQueue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alg2-01"}, deleted)
+	assert.Len(t, saved["school:student"], 1)
+	assert.Nil(t, saved["school:student"][0].Unweighted)
+}
+
+func TestGPAService_ProcessQueue_KeepsFailedMarksQueued(t *testing.T) {
+	gpaStore, _, dequeued := markStore(
+		&QueuedMark{SectionID: "geo-01", StudentUserID: "student"},
+		&QueuedMark{SectionID: "geo-01", StudentUserID: "other-student"},
+	)
+
+	gpaStore.SaveMarkFunc = func(ctx context.Context, mark *CourseMark) error {
+		if mark.StudentUserID == "student" {
+			return errors.New("db error")
+		}
+
+		return nil
+	}
+
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	processed, err := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService).ProcessQueue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Len(t, *dequeued, 1)
+	assert.Equal(t, "other-student", (*dequeued)[0].StudentUserID)
+}
+
+func TestGPAService_SavePolicy_QueuesSchoolRecompute(t *testing.T) {
+	var queued []string
+	gpaStore := &MockGPAStore{
+		EnqueueSchoolFunc: func(ctx context.Context, schoolID string) error {
+			queued = append(queued, schoolID)
+			return nil
+		},
+	}
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+
+	policy := &GPAPolicy{SchoolID: "school", GPARules: GPARules{Boosts: map[string]float64{CourseLevelAP: 1}, RankBy: GPAUnweighted}}
+	err := gpaService.SavePolicy(sessionContext("admin"), policy)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"school"}, queued)
+	assert.Equal(t, []HonorRoll{}, policy.HonorRolls)
+}
+
+func TestGPAService_SavePolicy_ReturnsErrorForTeacher(t *testing.T) {
+	gpaStore := &MockGPAStore{}
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+
+	err := gpaService.SavePolicy(sessionContext("teacher"), &GPAPolicy{SchoolID: "school", GPARules: defaultGPARules()})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGPAService_SetGradeLevel_ReturnsErrorForNonStudent(t *testing.T) {
+	gpaStore := &MockGPAStore{}
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+
+	err := gpaService.SetGradeLevel(sessionContext("admin"), &StudentGradeLevel{SchoolID: "school", StudentUserID: "teacher", GradeLevel: 11})
+
+	assert.Error(t, err)
+	assert.Equal(t, "student must be a student of the organization", err.Error())
+}
+
+// Cumulative GPAs of three eleventh graders, where "student" ranks second
+func eleventhGrade() *MockGPAStore {
+	eleventh := 11
+
+	return &MockGPAStore{
+		GetGradeLevelFunc: func(ctx context.Context, schoolID string, studentUserID string) (*StudentGradeLevel, error) {
+			return &StudentGradeLevel{SchoolID: schoolID, StudentUserID: studentUserID, GradeLevel: eleventh}, nil
+		},
+		ListGPAsFunc: func(ctx context.Context, filter *GPAFilter) ([]*StudentGPA, error) {
+			gpas := []*StudentGPA{
+				{SchoolID: "school", StudentUserID: "student", GradeLevel: &eleventh, GPA: GPA{Unweighted: floatPtr(3.6), Weighted: floatPtr(4.1)}},
+				{SchoolID: "school", StudentUserID: "student", TermID: "fall", GradeLevel: &eleventh, GPA: GPA{Unweighted: floatPtr(3.5), Weighted: floatPtr(4)}},
+				{SchoolID: "school", StudentUserID: "other-student", GradeLevel: &eleventh, GPA: GPA{Unweighted: floatPtr(3.9), Weighted: floatPtr(3.9)}},
+				{SchoolID: "school", StudentUserID: "third-student", GradeLevel: &eleventh, GPA: GPA{Unweighted: floatPtr(4), Weighted: floatPtr(4.2)}},
+			}
+
+			var matched []*StudentGPA
+
+			for _, gpa := range gpas {
+				if (filter.StudentUserID == "" || gpa.StudentUserID == filter.StudentUserID) && (!filter.Cumulative || gpa.TermID == "") {
+					matched = append(matched, gpa)
+				}
+			}
+
+			return matched, nil
+		},
+	}
+}
+
+func TestGPAService_Standing_RanksStudentWithinGrade(t *testing.T) {
+	gpaStore := eleventhGrade()
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+
+	standing, err := gpaService.Standing(sessionContext("student"), "school", "student")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 11, *standing.GradeLevel)
+	assert.Equal(t, 4.1, *standing.Cumulative.Weighted)
+	assert.Len(t, standing.Terms, 1)
+	assert.Equal(t, 2, *standing.Rank)
+	assert.Equal(t, 3, standing.ClassSize)
+}
+
+func TestGPAService_Standing_ReturnsErrorForOtherStudent(t *testing.T) {
+	gpaStore := eleventhGrade()
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+
+	_, err := gpaService.Standing(sessionContext("other-student"), "school", "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGPAService_ClassRank_RanksByPolicy(t *testing.T) {
+	gpaStore := eleventhGrade()
+	gpaStore.GetPolicyFunc = func(ctx context.Context, schoolID string) (*GPAPolicy, error) {
+		rules := defaultGPARules()
+		rules.RankBy = GPAUnweighted
+
+		return &GPAPolicy{SchoolID: schoolID, GPARules: rules}, nil
+	}
+
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	rank, err := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService).ClassRank(sessionContext("teacher"), "school", 11)
+
+	assert.NoError(t, err)
+	assert.Equal(t, GPAUnweighted, rank.RankBy)
+	assert.Equal(t, "third-student", rank.Students[0].StudentUserID)
+	assert.Equal(t, "other-student", rank.Students[1].StudentUserID)
+	assert.Equal(t, "student", rank.Students[2].StudentUserID)
+}
+
+func TestGPAService_HonorRolls_ReturnsNotFoundForTermOfOtherSchool(t *testing.T) {
+	gpaStore := &MockGPAStore{}
+	enrollmentStore, sectionStore, courseStore, calendarStore, gradebookStore := academicHistory()
+	auditService := NewAuditService(&MockAuditStore{}, orgMembers())
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, existingSchool(), orgMembers(), auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, existingSchool(), orgMembers(), auditService)
+
+	_, err := gpaService.HonorRolls(sessionContext("admin"), "other-school", "fall")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,1553 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// One letter grade of a grading scale, awarded from MinPercent up to the next band
+type GradeBand struct {
+	Letter      string  `json:"letter"`
+	MinPercent  float64 `json:"minPercent"`
+	GradePoints float64 `json:"gradePoints"`
+}
+
+type GradingScale struct {
+	SchoolID  string      `json:"schoolId"`
+	Bands     []GradeBand `json:"bands"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// Used by schools that have not configured a scale of their own
+var defaultGradeBands = []GradeBand{
+	{Letter: "A", MinPercent: 90, GradePoints: 4},
+	{Letter: "B", MinPercent: 80, GradePoints: 3},
+	{Letter: "C", MinPercent: 70, GradePoints: 2},
+	{Letter: "D", MinPercent: 60, GradePoints: 1},
+	{Letter: "F", MinPercent: 0, GradePoints: 0},
+}
+
+// Returns the band a percentage falls in. Bands must be sorted from highest to lowest.
+func (s *GradingScale) Band(percent float64) GradeBand {
+	for _, band := range s.Bands {
+		if percent >= band.MinPercent {
+			return band
+		}
+	}
+
+	return s.Bands[len(s.Bands)-1]
+}
+
+type GradeCategory struct {
+	ID        string  `json:"id"`
+	SectionID string  `json:"sectionId"`
+	Name      string  `json:"name"`
+	Weight    float64 `json:"weight"`
+	// How many of the category's lowest scores to leave out of the average
+	DropLowest int       `json:"dropLowest"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type Assignment struct {
+	ID             string     `json:"id"`
+	SectionID      string     `json:"sectionId"`
+	CategoryID     string     `json:"categoryId"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description"`
+	PointsPossible float64    `json:"pointsPossible"`
+	DueAt          *time.Time `json:"dueAt"`
+	// Percent taken off a late score for each day or part of a day it is late, up to
+	// MaxLatePenalty percent. A zero maximum means the penalty can reach 100%.
+	LatePenaltyPerDay float64 `json:"latePenaltyPerDay"`
+	MaxLatePenalty    float64 `json:"maxLatePenalty"`
+	// How many times a student may submit work. Zero allows any number of resubmissions.
+	MaxAttempts           int       `json:"maxAttempts"`
+	RejectLateSubmissions bool      `json:"rejectLateSubmissions"`
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+// Returns the percent taken off a score submitted at submittedAt
+func (a *Assignment) LatePenalty(submittedAt *time.Time) float64 {
+	if a.DueAt == nil || submittedAt == nil || !submittedAt.After(*a.DueAt) {
+		return 0
+	}
+
+	daysLate := math.Ceil(submittedAt.Sub(*a.DueAt).Hours() / 24)
+	maxPenalty := a.MaxLatePenalty
+
+	if maxPenalty == 0 {
+		maxPenalty = 100
+	}
+
+	return math.Min(daysLate*a.LatePenaltyPerDay, maxPenalty)
+}
+
+// A student's score on an assignment. Points is nil until the work is graded.
+type Grade struct {
+	AssignmentID   string     `json:"assignmentId"`
+	StudentUserID  string     `json:"studentUserId"`
+	Points         *float64   `json:"points"`
+	Excused        bool       `json:"excused"`
+	SubmittedAt    *time.Time `json:"submittedAt"`
+	Comment        string     `json:"comment"`
+	GradedByUserID string     `json:"gradedByUserId"`
+	// The submission the score was given for, if it was graded from one
+	SubmissionID string    `json:"submissionId,omitempty"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type GradebookPostgresStore struct {
+	db *PostgresDB
+}
+
+type GradebookStore interface {
+	GetGradingScale(ctx context.Context, schoolID string) (*GradingScale, error)
+	SaveGradingScale(ctx context.Context, scale *GradingScale) error
+	CreateCategory(ctx context.Context, category *GradeCategory) error
+	GetCategory(ctx context.Context, id string) (*GradeCategory, error)
+	ListCategories(ctx context.Context, sectionID string) ([]*GradeCategory, error)
+	UpdateCategory(ctx context.Context, category *GradeCategory) error
+	DeleteCategory(ctx context.Context, id string) error
+	CreateAssignment(ctx context.Context, assignment *Assignment) error
+	GetAssignment(ctx context.Context, id string) (*Assignment, error)
+	ListAssignments(ctx context.Context, sectionID string) ([]*Assignment, error)
+	UpdateAssignment(ctx context.Context, assignment *Assignment) error
+	DeleteAssignment(ctx context.Context, id string) error
+	GetGrade(ctx context.Context, assignmentID string, studentUserID string) (*Grade, error)
+	SaveGrade(ctx context.Context, grade *Grade) error
+	// Lists the grades on a section's assignments. An empty studentUserID lists every student's, and
+	// an empty sectionID lists the student's grades in every section.
+	ListGrades(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error)
+}
+
+func (s *GradebookPostgresStore) GetGradingScale(ctx context.Context, schoolID string) (*GradingScale, error) {
+	query := `
+		SELECT school_id, bands, updated_at
+		FROM grading_scales
+		WHERE school_id = $1
+	`
+
+	var scale GradingScale
+
+	err := s.db.pool.QueryRow(ctx, query, schoolID).Scan(&scale.SchoolID, &scale.Bands, &scale.UpdatedAt)
+
+	return noRowsAsNil(&scale, err)
+}
+
+func (s *GradebookPostgresStore) SaveGradingScale(ctx context.Context, scale *GradingScale) error {
+	query := `
+		INSERT INTO grading_scales (school_id, bands, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (school_id) DO UPDATE SET bands = excluded.bands, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, scale.SchoolID, scale.Bands, scale.UpdatedAt)
+
+	return err
+}
+
+const gradeCategoryColumns = `id, section_id, name, weight, drop_lowest, created_at, updated_at`
+
+func scanGradeCategory(row rowScanner) (*GradeCategory, error) {
+	var category GradeCategory
+
+	if err := row.Scan(&category.ID, &category.SectionID, &category.Name, &category.Weight, &category.DropLowest, &category.CreatedAt, &category.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (s *GradebookPostgresStore) CreateCategory(ctx context.Context, category *GradeCategory) error {
+	query := `
+		INSERT INTO grade_categories (section_id, name, weight, drop_lowest, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	return s.db.pool.QueryRow(ctx, query, category.SectionID, category.Name, category.Weight, category.DropLowest, category.CreatedAt, category.UpdatedAt).Scan(&category.ID)
+}
+
+func (s *GradebookPostgresStore) GetCategory(ctx context.Context, id string) (*GradeCategory, error) {
+	query := `SELECT ` + gradeCategoryColumns + ` FROM grade_categories WHERE id = $1`
+
+	return noRowsAsNil(scanGradeCategory(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *GradebookPostgresStore) ListCategories(ctx context.Context, sectionID string) ([]*GradeCategory, error) {
+	query := `SELECT ` + gradeCategoryColumns + ` FROM grade_categories WHERE section_id = $1 ORDER BY created_at, id`
+
+	rows, err := s.db.pool.Query(ctx, query, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var categories []*GradeCategory
+
+	for rows.Next() {
+		category, err := scanGradeCategory(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (s *GradebookPostgresStore) UpdateCategory(ctx context.Context, category *GradeCategory) error {
+	query := `
+		UPDATE grade_categories
+		SET name = $1, weight = $2, drop_lowest = $3, updated_at = $4
+		WHERE id = $5
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, category.Name, category.Weight, category.DropLowest, category.UpdatedAt, category.ID)
+
+	return err
+}
+
+func (s *GradebookPostgresStore) DeleteCategory(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM grade_categories WHERE id = $1`, id)
+	return err
+}
+
+const assignmentColumns = `id, section_id, category_id, title, description, points_possible, due_at, late_penalty_per_day, max_late_penalty, max_attempts, reject_late_submissions, created_at, updated_at`
+
+func scanAssignment(row rowScanner) (*Assignment, error) {
+	var assignment Assignment
+
+	err := row.Scan(
+		&assignment.ID,
+		&assignment.SectionID,
+		&assignment.CategoryID,
+		&assignment.Title,
+		&assignment.Description,
+		&assignment.PointsPossible,
+		&assignment.DueAt,
+		&assignment.LatePenaltyPerDay,
+		&assignment.MaxLatePenalty,
+		&assignment.MaxAttempts,
+		&assignment.RejectLateSubmissions,
+		&assignment.CreatedAt,
+		&assignment.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+func (s *GradebookPostgresStore) CreateAssignment(ctx context.Context, assignment *Assignment) error {
+	query := `
+		INSERT INTO assignments (section_id, category_id, title, description, points_possible, due_at, late_penalty_per_day, max_late_penalty, max_attempts, reject_late_submissions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		assignment.SectionID,
+		assignment.CategoryID,
+		assignment.Title,
+		assignment.Description,
+		assignment.PointsPossible,
+		assignment.DueAt,
+		assignment.LatePenaltyPerDay,
+		assignment.MaxLatePenalty,
+		assignment.MaxAttempts,
+		assignment.RejectLateSubmissions,
+		assignment.CreatedAt,
+		assignment.UpdatedAt,
+	)
+
+	return row.Scan(&assignment.ID)
+}
+
+func (s *GradebookPostgresStore) GetAssignment(ctx context.Context, id string) (*Assignment, error) {
+	query := `SELECT ` + assignmentColumns + ` FROM assignments WHERE id = $1`
+
+	return noRowsAsNil(scanAssignment(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *GradebookPostgresStore) ListAssignments(ctx context.Context, sectionID string) ([]*Assignment, error) {
+	query := `SELECT ` + assignmentColumns + ` FROM assignments WHERE section_id = $1 ORDER BY due_at NULLS LAST, created_at, id`
+
+	rows, err := s.db.pool.Query(ctx, query, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var assignments []*Assignment
+
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
+func (s *GradebookPostgresStore) UpdateAssignment(ctx context.Context, assignment *Assignment) error {
+	query := `
+		UPDATE assignments
+		SET category_id = $1, title = $2, description = $3, points_possible = $4, due_at = $5,
+			late_penalty_per_day = $6, max_late_penalty = $7, max_attempts = $8, reject_late_submissions = $9, updated_at = $10
+		WHERE id = $11
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		assignment.CategoryID,
+		assignment.Title,
+		assignment.Description,
+		assignment.PointsPossible,
+		assignment.DueAt,
+		assignment.LatePenaltyPerDay,
+		assignment.MaxLatePenalty,
+		assignment.MaxAttempts,
+		assignment.RejectLateSubmissions,
+		assignment.UpdatedAt,
+		assignment.ID,
+	)
+
+	return err
+}
+
+func (s *GradebookPostgresStore) DeleteAssignment(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM assignments WHERE id = $1`, id)
+	return err
+}
+
+const gradeColumns = `g.assignment_id, g.student_user_id, g.points, g.excused, g.submitted_at, g.comment, COALESCE(g.graded_by_user_id::text, ''), COALESCE(g.submission_id::text, ''), g.updated_at`
+
+func scanGrade(row rowScanner) (*Grade, error) {
+	var grade Grade
+
+	err := row.Scan(
+		&grade.AssignmentID,
+		&grade.StudentUserID,
+		&grade.Points,
+		&grade.Excused,
+		&grade.SubmittedAt,
+		&grade.Comment,
+		&grade.GradedByUserID,
+		&grade.SubmissionID,
+		&grade.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &grade, nil
+}
+
+func (s *GradebookPostgresStore) GetGrade(ctx context.Context, assignmentID string, studentUserID string) (*Grade, error) {
+	query := `SELECT ` + gradeColumns + ` FROM grades g WHERE g.assignment_id = $1 AND g.student_user_id = $2`
+
+	return noRowsAsNil(scanGrade(s.db.pool.QueryRow(ctx, query, assignmentID, studentUserID)))
+}
+
+func (s *GradebookPostgresStore) SaveGrade(ctx context.Context, grade *Grade) error {
+	query := `
+		INSERT INTO grades (assignment_id, student_user_id, points, excused, submitted_at, comment, graded_by_user_id, submission_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid, NULLIF($8, '')::uuid, $9)
+		ON CONFLICT (assignment_id, student_user_id) DO UPDATE
+		SET points = excluded.points, excused = excluded.excused, submitted_at = excluded.submitted_at,
+			comment = excluded.comment, graded_by_user_id = excluded.graded_by_user_id, submission_id = excluded.submission_id,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		grade.AssignmentID,
+		grade.StudentUserID,
+		grade.Points,
+		grade.Excused,
+		grade.SubmittedAt,
+		grade.Comment,
+		grade.GradedByUserID,
+		grade.SubmissionID,
+		grade.UpdatedAt,
+	)
+
+	return err
+}
+
+func (s *GradebookPostgresStore) ListGrades(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error) {
+	query := `
+		SELECT ` + gradeColumns + `
+		FROM grades g
+		JOIN assignments a ON a.id = g.assignment_id
+		WHERE ($1 = '' OR a.section_id::text = $1) AND ($2 = '' OR g.student_user_id::text = $2)
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, sectionID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var grades []*Grade
+
+	for rows.Next() {
+		grade, err := scanGrade(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		grades = append(grades, grade)
+	}
+
+	return grades, rows.Err()
+}
+
+func validateGradingScale(scale *GradingScale) error {
+	if len(scale.Bands) == 0 {
+		return errors.New("a grading scale needs at least one band")
+	}
+
+	letters := map[string]bool{}
+
+	for _, band := range scale.Bands {
+		if band.Letter == "" {
+			return errors.New("every band needs a letter")
+		}
+
+		if letters[band.Letter] {
+			return fmt.Errorf("letter %s is used more than once", band.Letter)
+		}
+
+		letters[band.Letter] = true
+
+		if band.MinPercent < 0 || band.MinPercent > 100 {
+			return errors.New("minimum percents must be between 0 and 100")
+		}
+
+		if band.GradePoints < 0 {
+			return errors.New("grade points must not be negative")
+		}
+	}
+
+	slices.SortFunc(scale.Bands, func(a, b GradeBand) int {
+		return cmp.Compare(b.MinPercent, a.MinPercent)
+	})
+
+	for i := 1; i < len(scale.Bands); i++ {
+		if scale.Bands[i].MinPercent == scale.Bands[i-1].MinPercent {
+			return errors.New("minimum percents must be distinct")
+		}
+	}
+
+	if scale.Bands[len(scale.Bands)-1].MinPercent != 0 {
+		return errors.New("the lowest band must start at 0")
+	}
+
+	return nil
+}
+
+func validateGradeCategory(category *GradeCategory) error {
+	if category.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if category.Weight <= 0 {
+		return errors.New("weight must be greater than zero")
+	}
+
+	if category.DropLowest < 0 {
+		return errors.New("drop lowest must not be negative")
+	}
+
+	return nil
+}
+
+func validateAssignment(assignment *Assignment) error {
+	if assignment.CategoryID == "" {
+		return errors.New("category id is required")
+	}
+
+	if assignment.Title == "" {
+		return errors.New("title is required")
+	}
+
+	if assignment.PointsPossible < 0 {
+		return errors.New("points possible must not be negative")
+	}
+
+	if assignment.LatePenaltyPerDay < 0 || assignment.LatePenaltyPerDay > 100 {
+		return errors.New("late penalty per day must be between 0 and 100")
+	}
+
+	if assignment.MaxLatePenalty < 0 || assignment.MaxLatePenalty > 100 {
+		return errors.New("max late penalty must be between 0 and 100")
+	}
+
+	if assignment.MaxAttempts < 0 {
+		return errors.New("max attempts must not be negative")
+	}
+
+	return nil
+}
+
+// One assignment in a student's row of the gradebook
+type GradebookCell struct {
+	AssignmentID string   `json:"assignmentId"`
+	Points       *float64 `json:"points"`
+	// Points after any late penalty. This is what counts toward the average.
+	AdjustedPoints *float64 `json:"adjustedPoints"`
+	Excused        bool     `json:"excused"`
+	Late           bool     `json:"late"`
+	// Past due with no score
+	Missing bool `json:"missing"`
+	// Left out of the average by the category's drop lowest rule
+	Dropped bool `json:"dropped"`
+}
+
+type CategoryAverage struct {
+	CategoryID string   `json:"categoryId"`
+	Percent    *float64 `json:"percent"`
+}
+
+// A student's running average. Percent is nil until something has been graded.
+type StudentAverage struct {
+	Percent     *float64          `json:"percent"`
+	Letter      string            `json:"letter"`
+	GradePoints *float64          `json:"gradePoints"`
+	Categories  []CategoryAverage `json:"categories"`
+}
+
+type GradebookRow struct {
+	StudentUserID string          `json:"studentUserId"`
+	Cells         []GradebookCell `json:"cells"`
+	Average       StudentAverage  `json:"average"`
+}
+
+type Gradebook struct {
+	SectionID   string           `json:"sectionId"`
+	Scale       *GradingScale    `json:"scale"`
+	Categories  []*GradeCategory `json:"categories"`
+	Assignments []*Assignment    `json:"assignments"`
+	Students    []GradebookRow   `json:"students"`
+}
+
+// Builds a student's row from their grades, keyed by assignment id. Only graded work counts,
+// so the average runs as the term goes. Categories are weighted against the other categories
+// that have graded work.
+func buildGradebookRow(studentUserID string, categories []*GradeCategory, assignments []*Assignment, grades map[string]*Grade, scale *GradingScale, now time.Time) GradebookRow {
+	row := GradebookRow{StudentUserID: studentUserID, Cells: make([]GradebookCell, len(assignments))}
+
+	type scored struct {
+		cell     *GradebookCell
+		earned   float64
+		possible float64
+	}
+
+	byCategory := map[string][]scored{}
+
+	for i, assignment := range assignments {
+		cell := &row.Cells[i]
+		cell.AssignmentID = assignment.ID
+
+		grade := grades[assignment.ID]
+
+		if grade != nil && grade.Excused {
+			cell.Excused = true
+			continue
+		}
+
+		if grade == nil || grade.Points == nil {
+			cell.Missing = assignment.DueAt != nil && now.After(*assignment.DueAt)
+			continue
+		}
+
+		penalty := assignment.LatePenalty(grade.SubmittedAt)
+		adjusted := *grade.Points * (1 - penalty/100)
+
+		cell.Points = grade.Points
+		cell.AdjustedPoints = &adjusted
+		cell.Late = grade.SubmittedAt != nil && assignment.DueAt != nil && grade.SubmittedAt.After(*assignment.DueAt)
+
+		byCategory[assignment.CategoryID] = append(byCategory[assignment.CategoryID], scored{cell: cell, earned: adjusted, possible: assignment.PointsPossible})
+	}
+
+	var weighted, totalWeight float64
+
+	for _, category := range categories {
+		items := byCategory[category.ID]
+		average := CategoryAverage{CategoryID: category.ID}
+
+		// Extra credit, with nothing possible, is never dropped
+		var droppable []scored
+
+		for _, item := range items {
+			if item.possible > 0 {
+				droppable = append(droppable, item)
+			}
+		}
+
+		slices.SortStableFunc(droppable, func(a, b scored) int {
+			return cmp.Compare(a.earned/a.possible, b.earned/b.possible)
+		})
+
+		// Always keep at least one score
+		for i := 0; i < category.DropLowest && i < len(droppable)-1; i++ {
+			droppable[i].cell.Dropped = true
+		}
+
+		var earned, possible float64
+
+		for _, item := range items {
+			if !item.cell.Dropped {
+				earned += item.earned
+				possible += item.possible
+			}
+		}
+
+		if possible > 0 {
+			percent := earned / possible * 100
+			average.Percent = &percent
+			weighted += percent * category.Weight
+			totalWeight += category.Weight
+		}
+
+		row.Average.Categories = append(row.Average.Categories, average)
+	}
+
+	if totalWeight > 0 {
+		percent := weighted / totalWeight
+		band := scale.Band(percent)
+
+		row.Average.Percent = &percent
+		row.Average.Letter = band.Letter
+		row.Average.GradePoints = &band.GradePoints
+	}
+
+	if row.Average.Categories == nil {
+		row.Average.Categories = []CategoryAverage{}
+	}
+
+	return row
+}
+
+// Collects the student's grades in every section for their data export
+func gradeDataExport(gradebookStore GradebookStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		grades, err := gradebookStore.ListGrades(ctx, "", userID)
+		return toAnySlice(grades), err
+	}
+}
+
+type GradebookService struct {
+	gradebookStore  GradebookStore
+	sectionStore    SectionStore
+	enrollmentStore EnrollmentStore
+	gpaStore        GPAStore
+	schoolStore     SchoolStore
+	memberStore     OrganizationMemberStore
+	auditService    *AuditService
+}
+
+func NewGradebookService(gradebookStore GradebookStore, sectionStore SectionStore, enrollmentStore EnrollmentStore, gpaStore GPAStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *GradebookService {
+	return &GradebookService{
+		gradebookStore:  gradebookStore,
+		sectionStore:    sectionStore,
+		enrollmentStore: enrollmentStore,
+		gpaStore:        gpaStore,
+		schoolStore:     schoolStore,
+		memberStore:     memberStore,
+		auditService:    auditService,
+	}
+}
+
+// Returns the school's grading scale, or the default scale if it has not set one
+func (s *GradebookService) scale(ctx context.Context, schoolID string) (*GradingScale, error) {
+	scale, err := s.gradebookStore.GetGradingScale(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to get grading scale", "error", err)
+		return nil, ErrInternal
+	}
+
+	if scale == nil {
+		scale = &GradingScale{SchoolID: schoolID, Bands: slices.Clone(defaultGradeBands)}
+	}
+
+	return scale, nil
+}
+
+func (s *GradebookService) GetGradingScale(ctx context.Context, schoolID string) (*GradingScale, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	return s.scale(ctx, schoolID)
+}
+
+func (s *GradebookService) SaveGradingScale(ctx context.Context, scale *GradingScale) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, scale.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateGradingScale(scale); err != nil {
+		return err
+	}
+
+	before, err := s.scale(ctx, scale.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	scale.UpdatedAt = time.Now()
+
+	if err := s.gradebookStore.SaveGradingScale(ctx, scale); err != nil {
+		slog.Error("failed to save grading scale", "error", err)
+		return ErrInternal
+	}
+
+	if err := s.gpaStore.EnqueueSchool(ctx, scale.SchoolID); err != nil {
+		slog.Error("failed to queue GPA recompute", "error", err, "schoolId", scale.SchoolID)
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "grading_scale", scale.SchoolID, before, scale)
+
+	return nil
+}
+
+// Returns the section and its school if the session user may manage the section's gradebook
+func (s *GradebookService) authorizeSection(ctx context.Context, sectionID string) (*Section, *School, error) {
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, nil, err
+	}
+
+	return section, school, nil
+}
+
+func (s *GradebookService) CreateCategory(ctx context.Context, category *GradeCategory) error {
+	_, school, err := s.authorizeSection(ctx, category.SectionID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateGradeCategory(category); err != nil {
+		return err
+	}
+
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+
+	if err := s.gradebookStore.CreateCategory(ctx, category); err != nil {
+		slog.Error("failed to create grade category", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "grade_category", category.ID, nil, category)
+
+	return nil
+}
+
+func (s *GradebookService) ListCategories(ctx context.Context, sectionID string) ([]*GradeCategory, error) {
+	if _, _, err := s.authorizeSection(ctx, sectionID); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.gradebookStore.ListCategories(ctx, sectionID)
+
+	if err != nil {
+		slog.Error("failed to list grade categories", "error", err)
+		return nil, ErrInternal
+	}
+
+	if categories == nil {
+		categories = []*GradeCategory{}
+	}
+
+	return categories, nil
+}
+
+// Returns the category and its school if the session user may manage its section's gradebook
+func (s *GradebookService) authorizeCategory(ctx context.Context, id string) (*GradeCategory, *School, error) {
+	category, err := s.gradebookStore.GetCategory(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get grade category", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if category == nil {
+		return nil, nil, notFound("grade category")
+	}
+
+	_, school, err := s.authorizeSection(ctx, category.SectionID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return category, school, nil
+}
+
+type UpdateGradeCategoryRequest struct {
+	Name       string   `json:"name"`
+	Weight     *float64 `json:"weight"`
+	DropLowest *int     `json:"dropLowest"`
+}
+
+func (s *GradebookService) UpdateCategory(ctx context.Context, id string, request *UpdateGradeCategoryRequest) (*GradeCategory, error) {
+	category, school, err := s.authorizeCategory(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *category
+
+	if request.Name != "" {
+		category.Name = request.Name
+	}
+
+	if request.Weight != nil {
+		category.Weight = *request.Weight
+	}
+
+	if request.DropLowest != nil {
+		category.DropLowest = *request.DropLowest
+	}
+
+	if err := validateGradeCategory(category); err != nil {
+		return nil, err
+	}
+
+	category.UpdatedAt = time.Now()
+
+	if err := s.gradebookStore.UpdateCategory(ctx, category); err != nil {
+		slog.Error("failed to update grade category", "error", err)
+		return nil, ErrInternal
+	}
+
+	queueGPARecompute(ctx, s.gpaStore, category.SectionID)
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "grade_category", id, &before, category)
+
+	return category, nil
+}
+
+func (s *GradebookService) DeleteCategory(ctx context.Context, id string) error {
+	category, school, err := s.authorizeCategory(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	assignments, err := s.gradebookStore.ListAssignments(ctx, category.SectionID)
+
+	if err != nil {
+		slog.Error("failed to list assignments", "error", err)
+		return ErrInternal
+	}
+
+	if slices.ContainsFunc(assignments, func(assignment *Assignment) bool { return assignment.CategoryID == id }) {
+		return errors.New("grade category still has assignments")
+	}
+
+	if err := s.gradebookStore.DeleteCategory(ctx, id); err != nil {
+		slog.Error("failed to delete grade category", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "grade_category", id, category, nil)
+
+	return nil
+}
+
+// Checks that the assignment's category belongs to its section
+func (s *GradebookService) checkCategory(ctx context.Context, assignment *Assignment) error {
+	category, err := s.gradebookStore.GetCategory(ctx, assignment.CategoryID)
+
+	if err != nil {
+		slog.Error("failed to get grade category", "error", err)
+		return ErrInternal
+	}
+
+	if category == nil || category.SectionID != assignment.SectionID {
+		return notFound("grade category")
+	}
+
+	return nil
+}
+
+func (s *GradebookService) CreateAssignment(ctx context.Context, assignment *Assignment) error {
+	_, school, err := s.authorizeSection(ctx, assignment.SectionID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateAssignment(assignment); err != nil {
+		return err
+	}
+
+	if err := s.checkCategory(ctx, assignment); err != nil {
+		return err
+	}
+
+	assignment.CreatedAt = time.Now()
+	assignment.UpdatedAt = time.Now()
+
+	if err := s.gradebookStore.CreateAssignment(ctx, assignment); err != nil {
+		slog.Error("failed to create assignment", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "assignment", assignment.ID, nil, assignment)
+
+	return nil
+}
+
+// Returns the assignment, its section and its school. Staff may always see an assignment;
+// students only when they are enrolled in its section.
+func (s *GradebookService) getAssignment(ctx context.Context, id string) (*Assignment, *Section, *School, error) {
+	assignment, err := s.gradebookStore.GetAssignment(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get assignment", "error", err)
+		return nil, nil, nil, ErrInternal
+	}
+
+	if assignment == nil {
+		return nil, nil, nil, notFound("assignment")
+	}
+
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, assignment.SectionID)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return assignment, section, school, nil
+}
+
+// Succeeds if the session user may manage the section's gradebook or is enrolled in it
+func (s *GradebookService) authorizeReader(ctx context.Context, section *Section, school *School) error {
+	err := authorizeSectionStaff(ctx, s.memberStore, section, school)
+
+	if !errors.Is(err, ErrForbidden) {
+		return err
+	}
+
+	session, _ := SessionFromContext(ctx)
+
+	if _, memberErr := requireMembership(ctx, s.memberStore, school.OrganizationID, RoleStudent); memberErr != nil {
+		return memberErr
+	}
+
+	enrolled, err := s.isEnrolled(ctx, section.ID, session.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	if !enrolled {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (s *GradebookService) GetAssignment(ctx context.Context, id string) (*Assignment, error) {
+	assignment, section, school, err := s.getAssignment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeReader(ctx, section, school); err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+func (s *GradebookService) ListAssignments(ctx context.Context, sectionID string) ([]*Assignment, error) {
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeReader(ctx, section, school); err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.gradebookStore.ListAssignments(ctx, sectionID)
+
+	if err != nil {
+		slog.Error("failed to list assignments", "error", err)
+		return nil, ErrInternal
+	}
+
+	if assignments == nil {
+		assignments = []*Assignment{}
+	}
+
+	return assignments, nil
+}
+
+type UpdateAssignmentRequest struct {
+	CategoryID            string     `json:"categoryId"`
+	Title                 string     `json:"title"`
+	Description           string     `json:"description"`
+	PointsPossible        *float64   `json:"pointsPossible"`
+	DueAt                 *time.Time `json:"dueAt"`
+	LatePenaltyPerDay     *float64   `json:"latePenaltyPerDay"`
+	MaxLatePenalty        *float64   `json:"maxLatePenalty"`
+	MaxAttempts           *int       `json:"maxAttempts"`
+	RejectLateSubmissions *bool      `json:"rejectLateSubmissions"`
+}
+
+func (s *GradebookService) UpdateAssignment(ctx context.Context, id string, request *UpdateAssignmentRequest) (*Assignment, error) {
+	assignment, section, school, err := s.getAssignment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	before := *assignment
+
+	if request.CategoryID != "" {
+		assignment.CategoryID = request.CategoryID
+
+		if err := s.checkCategory(ctx, assignment); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.Title != "" {
+		assignment.Title = request.Title
+	}
+
+	if request.Description != "" {
+		assignment.Description = request.Description
+	}
+
+	if request.PointsPossible != nil {
+		assignment.PointsPossible = *request.PointsPossible
+	}
+
+	if request.DueAt != nil {
+		assignment.DueAt = request.DueAt
+	}
+
+	if request.LatePenaltyPerDay != nil {
+		assignment.LatePenaltyPerDay = *request.LatePenaltyPerDay
+	}
+
+	if request.MaxLatePenalty != nil {
+		assignment.MaxLatePenalty = *request.MaxLatePenalty
+	}
+
+	if request.MaxAttempts != nil {
+		assignment.MaxAttempts = *request.MaxAttempts
+	}
+
+	if request.RejectLateSubmissions != nil {
+		assignment.RejectLateSubmissions = *request.RejectLateSubmissions
+	}
+
+	if err := validateAssignment(assignment); err != nil {
+		return nil, err
+	}
+
+	assignment.UpdatedAt = time.Now()
+
+	if err := s.gradebookStore.UpdateAssignment(ctx, assignment); err != nil {
+		slog.Error("failed to update assignment", "error", err)
+		return nil, ErrInternal
+	}
+
+	queueGPARecompute(ctx, s.gpaStore, section.ID)
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "assignment", id, &before, assignment)
+
+	return assignment, nil
+}
+
+// Deletes an assignment along with its grades
+func (s *GradebookService) DeleteAssignment(ctx context.Context, id string) error {
+	assignment, section, school, err := s.getAssignment(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return err
+	}
+
+	if err := s.gradebookStore.DeleteAssignment(ctx, id); err != nil {
+		slog.Error("failed to delete assignment", "error", err)
+		return ErrInternal
+	}
+
+	queueGPARecompute(ctx, s.gpaStore, section.ID)
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "assignment", id, assignment, nil)
+
+	return nil
+}
+
+type SaveGradeRequest struct {
+	Points      *float64   `json:"points"`
+	Excused     bool       `json:"excused"`
+	SubmittedAt *time.Time `json:"submittedAt"`
+	Comment     string     `json:"comment"`
+}
+
+// Records a student's score on an assignment, replacing any earlier one
+func (s *GradebookService) SaveGrade(ctx context.Context, assignmentID string, studentUserID string, request *SaveGradeRequest) (*Grade, error) {
+	return s.saveGrade(ctx, assignmentID, studentUserID, request, "")
+}
+
+// Saves a grade given for a submission. Without a submission id the grade stays linked to
+// the submission it was last given for.
+func (s *GradebookService) saveGrade(ctx context.Context, assignmentID string, studentUserID string, request *SaveGradeRequest, submissionID string) (*Grade, error) {
+	assignment, section, school, err := s.getAssignment(ctx, assignmentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	if request.Points != nil && *request.Points < 0 {
+		return nil, errors.New("points must not be negative")
+	}
+
+	enrolled, err := s.isEnrolled(ctx, section.ID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !enrolled {
+		return nil, errors.New("student is not enrolled in this section")
+	}
+
+	before, err := s.gradebookStore.GetGrade(ctx, assignmentID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get grade", "error", err)
+		return nil, ErrInternal
+	}
+
+	session, _ := SessionFromContext(ctx)
+
+	grade := &Grade{
+		AssignmentID:   assignment.ID,
+		StudentUserID:  studentUserID,
+		Points:         request.Points,
+		Excused:        request.Excused,
+		SubmittedAt:    request.SubmittedAt,
+		Comment:        request.Comment,
+		GradedByUserID: session.UserID,
+		SubmissionID:   submissionID,
+		UpdatedAt:      time.Now(),
+	}
+
+	if submissionID == "" && before != nil {
+		grade.SubmissionID = before.SubmissionID
+	}
+
+	if err := s.gradebookStore.SaveGrade(ctx, grade); err != nil {
+		slog.Error("failed to save grade", "error", err)
+		return nil, ErrInternal
+	}
+
+	queueGPARecompute(ctx, s.gpaStore, section.ID, studentUserID)
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "grade", assignmentID+":"+studentUserID, before, grade)
+
+	return grade, nil
+}
+
+// Reports whether the enrollment puts the student on the section's gradebook
+func isRosterEnrollment(enrollment *Enrollment) bool {
+	return enrollment.Status == EnrollmentStatusEnrolled || enrollment.Status == EnrollmentStatusCompleted || enrollment.Status == EnrollmentStatusFailed
+}
+
+// Returns the students on the section's roster
+func sectionRoster(ctx context.Context, enrollmentStore EnrollmentStore, sectionID string) ([]string, error) {
+	enrollments, err := enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: sectionID})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	var students []string
+
+	for _, enrollment := range enrollments {
+		if isRosterEnrollment(enrollment) && !slices.Contains(students, enrollment.StudentUserID) {
+			students = append(students, enrollment.StudentUserID)
+		}
+	}
+
+	return students, nil
+}
+
+// Reports whether the student is on the section's gradebook
+func (s *GradebookService) isEnrolled(ctx context.Context, sectionID string, studentUserID string) (bool, error) {
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: sectionID, StudentUserID: studentUserID})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return false, ErrInternal
+	}
+
+	return slices.ContainsFunc(enrollments, isRosterEnrollment), nil
+}
+
+// Builds the gradebook rows for the given students
+func (s *GradebookService) build(ctx context.Context, section *Section, school *School, studentUserIDs []string, studentUserID string) (*Gradebook, error) {
+	scale, err := s.scale(ctx, school.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.gradebookStore.ListCategories(ctx, section.ID)
+
+	if err != nil {
+		slog.Error("failed to list grade categories", "error", err)
+		return nil, ErrInternal
+	}
+
+	assignments, err := s.gradebookStore.ListAssignments(ctx, section.ID)
+
+	if err != nil {
+		slog.Error("failed to list assignments", "error", err)
+		return nil, ErrInternal
+	}
+
+	grades, err := s.gradebookStore.ListGrades(ctx, section.ID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to list grades", "error", err)
+		return nil, ErrInternal
+	}
+
+	byStudent := map[string]map[string]*Grade{}
+
+	for _, grade := range grades {
+		if byStudent[grade.StudentUserID] == nil {
+			byStudent[grade.StudentUserID] = map[string]*Grade{}
+		}
+
+		byStudent[grade.StudentUserID][grade.AssignmentID] = grade
+	}
+
+	gradebook := &Gradebook{
+		SectionID:   section.ID,
+		Scale:       scale,
+		Categories:  categories,
+		Assignments: assignments,
+		Students:    []GradebookRow{},
+	}
+
+	if gradebook.Categories == nil {
+		gradebook.Categories = []*GradeCategory{}
+	}
+
+	if gradebook.Assignments == nil {
+		gradebook.Assignments = []*Assignment{}
+	}
+
+	now := time.Now()
+
+	for _, id := range studentUserIDs {
+		gradebook.Students = append(gradebook.Students, buildGradebookRow(id, categories, assignments, byStudent[id], scale, now))
+	}
+
+	return gradebook, nil
+}
+
+// Returns every student's scores and running average in a section
+func (s *GradebookService) Gradebook(ctx context.Context, sectionID string) (*Gradebook, error) {
+	section, school, err := s.authorizeSection(ctx, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := sectionRoster(ctx, s.enrollmentStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.build(ctx, section, school, students, "")
+}
+
+// Returns one student's row of a section's gradebook. Students may read their own.
+func (s *GradebookService) StudentGrades(ctx context.Context, sectionID string, studentUserID string) (*Gradebook, error) {
+	section, school, err := getSectionAndSchool(ctx, s.sectionStore, s.schoolStore, sectionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if session.UserID == studentUserID {
+		err = s.authorizeReader(ctx, section, school)
+	} else {
+		err = authorizeSectionStaff(ctx, s.memberStore, section, school)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.build(ctx, section, school, []string{studentUserID}, studentUserID)
+}
+
+type GradebookHandler struct {
+	gradebookService *GradebookService
+}
+
+func (h *GradebookHandler) GetGradingScale(w http.ResponseWriter, r *http.Request) {
+	scale, err := h.gradebookService.GetGradingScale(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, scale)
+}
+
+func (h *GradebookHandler) SaveGradingScale(w http.ResponseWriter, r *http.Request) {
+	var scale GradingScale
+
+	if err := decodeJSON(r, &scale); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	scale.SchoolID = r.PathValue("id")
+
+	if err := h.gradebookService.SaveGradingScale(r.Context(), &scale); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, scale)
+}
+
+func (h *GradebookHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category GradeCategory
+
+	if err := decodeJSON(r, &category); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	category.SectionID = r.PathValue("id")
+
+	if err := h.gradebookService.CreateCategory(r.Context(), &category); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, category)
+}
+
+func (h *GradebookHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.gradebookService.ListCategories(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, categories)
+}
+
+func (h *GradebookHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var request UpdateGradeCategoryRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	category, err := h.gradebookService.UpdateCategory(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, category)
+}
+
+func (h *GradebookHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if err := h.gradebookService.DeleteCategory(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GradebookHandler) CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	var assignment Assignment
+
+	if err := decodeJSON(r, &assignment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	assignment.SectionID = r.PathValue("id")
+
+	if err := h.gradebookService.CreateAssignment(r.Context(), &assignment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, assignment)
+}
+
+func (h *GradebookHandler) ListAssignments(w http.ResponseWriter, r *http.Request) {
+	assignments, err := h.gradebookService.ListAssignments(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignments)
+}
+
+func (h *GradebookHandler) GetAssignment(w http.ResponseWriter, r *http.Request) {
+	assignment, err := h.gradebookService.GetAssignment(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignment)
+}
+
+func (h *GradebookHandler) UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	var request UpdateAssignmentRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	assignment, err := h.gradebookService.UpdateAssignment(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignment)
+}
+
+func (h *GradebookHandler) DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	if err := h.gradebookService.DeleteAssignment(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GradebookHandler) SaveGrade(w http.ResponseWriter, r *http.Request) {
+	var request SaveGradeRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	grade, err := h.gradebookService.SaveGrade(r.Context(), r.PathValue("id"), r.PathValue("studentId"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, grade)
+}
+
+func (h *GradebookHandler) Gradebook(w http.ResponseWriter, r *http.Request) {
+	gradebook, err := h.gradebookService.Gradebook(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gradebook)
+}
+
+func (h *GradebookHandler) StudentGrades(w http.ResponseWriter, r *http.Request) {
+	gradebook, err := h.gradebookService.StudentGrades(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gradebook)
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockGradebookStore struct {
+	GetGradingScaleFunc  func(ctx context.Context, schoolID string) (*GradingScale, error)
+	SaveGradingScaleFunc func(ctx context.Context, scale *GradingScale) error
+	CreateCategoryFunc   func(ctx context.Context, category *GradeCategory) error
+	GetCategoryFunc      func(ctx context.Context, id string) (*GradeCategory, error)
+	ListCategoriesFunc   func(ctx context.Context, sectionID string) ([]*GradeCategory, error)
+	UpdateCategoryFunc   func(ctx context.Context, category *GradeCategory) error
+	DeleteCategoryFunc   func(ctx context.Context, id string) error
+	CreateAssignmentFunc func(ctx context.Context, assignment *Assignment) error
+	GetAssignmentFunc    func(ctx context.Context, id string) (*Assignment, error)
+	ListAssignmentsFunc  func(ctx context.Context, sectionID string) ([]*Assignment, error)
+	UpdateAssignmentFunc func(ctx context.Context, assignment *Assignment) error
+	DeleteAssignmentFunc func(ctx context.Context, id string) error
+	GetGradeFunc         func(ctx context.Context, assignmentID string, studentUserID string) (*Grade, error)
+	SaveGradeFunc        func(ctx context.Context, grade *Grade) error
+	ListGradesFunc       func(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error)
+}
+
+func (m *MockGradebookStore) GetGradingScale(ctx context.Context, schoolID string) (*GradingScale, error) {
+	if m.GetGradingScaleFunc != nil {
+		return m.GetGradingScaleFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGradebookStore) SaveGradingScale(ctx context.Context, scale *GradingScale) error {
+	if m.SaveGradingScaleFunc != nil {
+		return m.SaveGradingScaleFunc(ctx, scale)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) CreateCategory(ctx context.Context, category *GradeCategory) error {
+	if m.CreateCategoryFunc != nil {
+		return m.CreateCategoryFunc(ctx, category)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) GetCategory(ctx context.Context, id string) (*GradeCategory, error) {
+	if m.GetCategoryFunc != nil {
+		return m.GetCategoryFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGradebookStore) ListCategories(ctx context.Context, sectionID string) ([]*GradeCategory, error) {
+	if m.ListCategoriesFunc != nil {
+		return m.ListCategoriesFunc(ctx, sectionID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGradebookStore) UpdateCategory(ctx context.Context, category *GradeCategory) error {
+	if m.UpdateCategoryFunc != nil {
+		return m.UpdateCategoryFunc(ctx, category)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) DeleteCategory(ctx context.Context, id string) error {
+	if m.DeleteCategoryFunc != nil {
+		return m.DeleteCategoryFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) CreateAssignment(ctx context.Context, assignment *Assignment) error {
+	if m.CreateAssignmentFunc != nil {
+		return m.CreateAssignmentFunc(ctx, assignment)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) GetAssignment(ctx context.Context, id string) (*Assignment, error) {
+	if m.GetAssignmentFunc != nil {
+		return m.GetAssignmentFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGradebookStore) ListAssignments(ctx context.Context, sectionID string) ([]*Assignment, error) {
+	if m.ListAssignmentsFunc != nil {
+		return m.ListAssignmentsFunc(ctx, sectionID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGradebookStore) UpdateAssignment(ctx context.Context, assignment *Assignment) error {
+	if m.UpdateAssignmentFunc != nil {
+		return m.UpdateAssignmentFunc(ctx, assignment)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) DeleteAssignment(ctx context.Context, id string) error {
+	if m.DeleteAssignmentFunc != nil {
+		return m.DeleteAssignmentFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) GetGrade(ctx context.Context, assignmentID string, studentUserID string) (*Grade, error) {
+	if m.GetGradeFunc != nil {
+		return m.GetGradeFunc(ctx, assignmentID, studentUserID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGradebookStore) SaveGrade(ctx context.Context, grade *Grade) error {
+	if m.SaveGradeFunc != nil {
+		return m.SaveGradeFunc(ctx, grade)
+	}
+
+	return nil
+}
+
+func (m *MockGradebookStore) ListGrades(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error) {
+	if m.ListGradesFunc != nil {
+		return m.ListGradesFunc(ctx, sectionID, studentUserID)
+	}
+
+	return nil, nil
+}
+
+func points(value float64) *float64 {
+	return &value
+}
+
+// Homework counts for 40% with its lowest score dropped, tests for 60%
+func geometryGradebook() ([]*GradeCategory, []*Assignment) {
+	categories := []*GradeCategory{
+		{ID: "homework", SectionID: "section", Name: "Homework", Weight: 40, DropLowest: 1},
+		{ID: "tests", SectionID: "section", Name: "Tests", Weight: 60},
+	}
+
+	assignments := []*Assignment{
+		{ID: "hw1", SectionID: "section", CategoryID: "homework", Title: "Homework 1", PointsPossible: 10},
+		{ID: "hw2", SectionID: "section", CategoryID: "homework", Title: "Homework 2", PointsPossible: 10},
+		{ID: "test1", SectionID: "section", CategoryID: "tests", Title: "Test 1", PointsPossible: 100},
+	}
+
+	return categories, assignments
+}
+
+// A section whose only enrolled student is "student"
+func enrolledStudent() *MockEnrollmentStore {
+	return &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			if filter.StudentUserID != "" && filter.StudentUserID != "student" {
+				return nil, nil
+			}
+
+			return []*Enrollment{{ID: "enrollment", SectionID: filter.SectionID, StudentUserID: "student", Status: EnrollmentStatusEnrolled}}, nil
+		},
+	}
+}
+
+func TestAssignment_LatePenalty_RoundsPartialDaysUpAndCaps(t *testing.T) {
+	due := time.Date(2025, time.October, 1, 23, 59, 0, 0, time.UTC)
+	assignment := &Assignment{DueAt: &due, LatePenaltyPerDay: 10, MaxLatePenalty: 30}
+
+	hourLate := due.Add(time.Hour)
+	weekLate := due.Add(7 * 24 * time.Hour)
+	early := due.Add(-time.Hour)
+
+	assert.Equal(t, 10.0, assignment.LatePenalty(&hourLate))
+	assert.Equal(t, 30.0, assignment.LatePenalty(&weekLate))
+	assert.Equal(t, 0.0, assignment.LatePenalty(&early))
+	assert.Equal(t, 0.0, assignment.LatePenalty(nil))
+}
+
+func TestGradingScale_Band_ReturnsLetterForPercent(t *testing.T) {
+	scale := &GradingScale{Bands: defaultGradeBands}
+
+	assert.Equal(t, "A", scale.Band(90).Letter)
+	assert.Equal(t, "B", scale.Band(89.99).Letter)
+	assert.Equal(t, "F", scale.Band(12).Letter)
+}
+
+func TestValidateGradingScale_SortsBands(t *testing.T) {
+	scale := &GradingScale{Bands: []GradeBand{{Letter: "F", MinPercent: 0}, {Letter: "P", MinPercent: 65, GradePoints: 1}}}
+
+	err := validateGradingScale(scale)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "P", scale.Bands[0].Letter)
+}
+
+func TestValidateGradingScale_ReturnsErrorWithoutZeroBand(t *testing.T) {
+	err := validateGradingScale(&GradingScale{Bands: []GradeBand{{Letter: "P", MinPercent: 65}}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "the lowest band must start at 0", err.Error())
+}
+
+func TestBuildGradebookRow_WeightsCategoriesAndDropsLowest(t *testing.T) {
+	categories, assignments := geometryGradebook()
+	grades := map[string]*Grade{
+		"hw1":   {AssignmentID: "hw1", Points: points(4)},
+		"hw2":   {AssignmentID: "hw2", Points: points(10)},
+		"test1": {AssignmentID: "test1", Points: points(80)},
+	}
+
+	row := buildGradebookRow("student", categories, assignments, grades, &GradingScale{Bands: defaultGradeBands}, time.Now())
+
+	assert.True(t, row.Cells[0].Dropped)
+	assert.InDelta(t, 88, *row.Average.Percent, 0.001)
+	assert.Equal(t, "B", row.Average.Letter)
+	assert.Equal(t, 3.0, *row.Average.GradePoints)
+}
+
+func TestBuildGradebookRow_KeepsOnlyScoreInCategory(t *testing.T) {
+	categories, assignments := geometryGradebook()
+	grades := map[string]*Grade{"hw1": {AssignmentID: "hw1", Points: points(5)}}
+
+	row := buildGradebookRow("student", categories, assignments, grades, &GradingScale{Bands: defaultGradeBands}, time.Now())
+
+	assert.False(t, row.Cells[0].Dropped)
+	assert.InDelta(t, 50, *row.Average.Percent, 0.001)
+	assert.Nil(t, row.Average.Categories[1].Percent)
+}
+
+func TestBuildGradebookRow_AppliesLatePenaltyAndFlagsMissing(t *testing.T) {
+	categories, assignments := geometryGradebook()
+	due := time.Now().Add(-48 * time.Hour)
+	submitted := due.Add(12 * time.Hour)
+
+	assignments[2].DueAt = &due
+	assignments[2].LatePenaltyPerDay = 10
+	assignments[1].DueAt = &due
+
+	grades := map[string]*Grade{
+		"hw1":   {AssignmentID: "hw1", Excused: true},
+		"test1": {AssignmentID: "test1", Points: points(90), SubmittedAt: &submitted},
+	}
+
+	row := buildGradebookRow("student", categories, assignments, grades, &GradingScale{Bands: defaultGradeBands}, time.Now())
+
+	assert.True(t, row.Cells[0].Excused)
+	assert.True(t, row.Cells[1].Missing)
+	assert.True(t, row.Cells[2].Late)
+	assert.InDelta(t, 81, *row.Cells[2].AdjustedPoints, 0.001)
+	assert.InDelta(t, 81, *row.Average.Percent, 0.001)
+}
+
+func TestBuildGradebookRow_LeavesAverageEmptyWithoutGrades(t *testing.T) {
+	categories, assignments := geometryGradebook()
+
+	row := buildGradebookRow("student", categories, assignments, nil, &GradingScale{Bands: defaultGradeBands}, time.Now())
+
+	assert.Nil(t, row.Average.Percent)
+	assert.Empty(t, row.Average.Letter)
+}
+
+func TestGradebookService_SaveGradingScale_ReturnsErrorForTeacher(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := gradebookService.SaveGradingScale(sessionContext("teacher"), &GradingScale{SchoolID: "school", Bands: defaultGradeBands})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGradebookService_CreateAssignment_ReturnsNotFoundForCategoryOfOtherSection(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{
+		GetCategoryFunc: func(ctx context.Context, id string) (*GradeCategory, error) {
+			return &GradeCategory{ID: id, SectionID: "other-section", Name: "Homework", Weight: 1}, nil
+		},
+	}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := gradebookService.CreateAssignment(sessionContext("teacher"), &Assignment{SectionID: "section", CategoryID: "homework", Title: "Homework 1", PointsPossible: 10})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGradebookService_CreateCategory_ReturnsErrorForStudent(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := gradebookService.CreateCategory(sessionContext("student"), &GradeCategory{SectionID: "section", Name: "Homework", Weight: 1})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGradebookService_DeleteCategory_ReturnsErrorWhenInUse(t *testing.T) {
+	categories, assignments := geometryGradebook()
+	gradebookService := NewGradebookService(&MockGradebookStore{
+		GetCategoryFunc: func(ctx context.Context, id string) (*GradeCategory, error) {
+			return categories[0], nil
+		},
+		ListAssignmentsFunc: func(ctx context.Context, sectionID string) ([]*Assignment, error) {
+			return assignments, nil
+		},
+	}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := gradebookService.DeleteCategory(sessionContext("teacher"), "homework")
+
+	assert.Error(t, err)
+	assert.Equal(t, "grade category still has assignments", err.Error())
+}
+
+func TestGradebookService_SaveGrade_ReturnsErrorForStudentNotEnrolled(t *testing.T) {
+	_, assignments := geometryGradebook()
+	gradebookService := NewGradebookService(&MockGradebookStore{
+		GetAssignmentFunc: func(ctx context.Context, id string) (*Assignment, error) {
+			return assignments[0], nil
+		},
+	}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := gradebookService.SaveGrade(sessionContext("teacher"), "hw1", "other-student", &SaveGradeRequest{Points: points(8)})
+
+	assert.Error(t, err)
+	assert.Equal(t, "student is not enrolled in this section", err.Error())
+}
+
+func TestGradebookService_SaveGrade_RecordsGrader(t *testing.T) {
+	var saved *Grade
+
+	_, assignments := geometryGradebook()
+	gradebookService := NewGradebookService(&MockGradebookStore{
+		GetAssignmentFunc: func(ctx context.Context, id string) (*Assignment, error) {
+			return assignments[0], nil
+		},
+		SaveGradeFunc: func(ctx context.Context, grade *Grade) error {
+			saved = grade
+			return nil
+		},
+	}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := gradebookService.SaveGrade(sessionContext("teacher"), "hw1", "student", &SaveGradeRequest{Points: points(8)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "teacher", saved.GradedByUserID)
+	assert.Equal(t, 8.0, *saved.Points)
+}
+
+func TestGradebookService_SaveGrade_QueuesGPARecompute(t *testing.T) {
+	var queued []string
+
+	_, assignments := geometryGradebook()
+	gradebookService := NewGradebookService(&MockGradebookStore{
+		GetAssignmentFunc: func(ctx context.Context, id string) (*Assignment, error) {
+			return assignments[0], nil
+		},
+	}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	gradebookService.gpaStore = &MockGPAStore{
+		EnqueueFunc: func(ctx context.Context, sectionID string, studentUserIDs []string) error {
+			queued = append(queued, studentUserIDs...)
+			return nil
+		},
+	}
+
+	_, err := gradebookService.SaveGrade(sessionContext("teacher"), "hw1", "student", &SaveGradeRequest{Points: points(8)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"student"}, queued)
+}
+
+func TestGradebookService_StudentGrades_AllowsEnrolledStudentToReadOwn(t *testing.T) {
+	categories, assignments := geometryGradebook()
+	gradebookService := NewGradebookService(&MockGradebookStore{
+		ListCategoriesFunc: func(ctx context.Context, sectionID string) ([]*GradeCategory, error) {
+			return categories, nil
+		},
+		ListAssignmentsFunc: func(ctx context.Context, sectionID string) ([]*Assignment, error) {
+			return assignments, nil
+		},
+		ListGradesFunc: func(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error) {
+			return []*Grade{{AssignmentID: "test1", StudentUserID: studentUserID, Points: points(95)}}, nil
+		},
+	}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	gradebook, err := gradebookService.StudentGrades(sessionContext("student"), "section", "student")
+
+	assert.NoError(t, err)
+	assert.Len(t, gradebook.Students, 1)
+	assert.Equal(t, "A", gradebook.Students[0].Average.Letter)
+}
+
+func TestGradebookService_StudentGrades_ReturnsErrorForOtherStudent(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := gradebookService.StudentGrades(sessionContext("other-student"), "section", "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGradebookService_Gradebook_ListsEnrolledStudents(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	gradebook, err := gradebookService.Gradebook(sessionContext("teacher"), "section")
+
+	assert.NoError(t, err)
+	assert.Len(t, gradebook.Students, 1)
+	assert.Equal(t, "student", gradebook.Students[0].StudentUserID)
+	assert.Equal(t, "A", gradebook.Scale.Bands[0].Letter)
+}
+
+func TestGradeDataExport_CollectsGradesInEverySection(t *testing.T) {
+	collect := gradeDataExport(&MockGradebookStore{
+		ListGradesFunc: func(ctx context.Context, sectionID string, studentUserID string) ([]*Grade, error) {
+			assert.Empty(t, sectionID)
+			assert.Equal(t, "student", studentUserID)
+			return []*Grade{{AssignmentID: "a"}, {AssignmentID: "b"}}, nil
+		},
+	})
+
+	grades, err := collect(context.Background(), "student")
+
+	assert.NoError(t, err)
+	assert.Len(t, grades, 2)
+}
@@ -0,0 +1,761 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	RequirementCredits   = "credits"
+	RequirementCourse    = "course"
+	RequirementTestScore = "test_score"
+)
+
+var requirementKinds = []string{RequirementCredits, RequirementCourse, RequirementTestScore}
+
+const (
+	RequirementSatisfied  = "satisfied"
+	RequirementInProgress = "in_progress"
+	RequirementMissing    = "missing"
+)
+
+const maxGraduationRequirements = 50
+
+// One thing a student must do to graduate, depending on its kind: earn credits in a subject,
+// pass a course, or score high enough on a test
+type GraduationRequirement struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// The subject courses must be in to count towards a credits requirement. Empty counts
+	// every subject.
+	Subject string  `json:"subject,omitempty"`
+	Credits float64 `json:"credits,omitempty"`
+	// Passing any one of these courses satisfies a course requirement
+	CourseIDs []string `json:"courseIds,omitempty"`
+	Test      string   `json:"test,omitempty"`
+	MinScore  float64  `json:"minScore,omitempty"`
+}
+
+type GraduationRequirements struct {
+	OrganizationID string                  `json:"organizationId"`
+	Requirements   []GraduationRequirement `json:"requirements"`
+	UpdatedAt      time.Time               `json:"updatedAt"`
+}
+
+type TestScore struct {
+	ID               string    `json:"id"`
+	OrganizationID   string    `json:"organizationId"`
+	StudentUserID    string    `json:"studentUserId"`
+	Test             string    `json:"test"`
+	Score            float64   `json:"score"`
+	TakenOn          Date      `json:"takenOn"`
+	RecordedByUserID string    `json:"recordedByUserId"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+type GraduationPostgresStore struct {
+	db *PostgresDB
+}
+
+type GraduationStore interface {
+	GetRequirements(ctx context.Context, organizationID string) (*GraduationRequirements, error)
+	SaveRequirements(ctx context.Context, requirements *GraduationRequirements) error
+	CreateTestScore(ctx context.Context, score *TestScore) error
+	GetTestScore(ctx context.Context, id string) (*TestScore, error)
+	ListTestScores(ctx context.Context, organizationID string, studentUserID string) ([]*TestScore, error)
+	DeleteTestScore(ctx context.Context, id string) error
+}
+
+func (s *GraduationPostgresStore) GetRequirements(ctx context.Context, organizationID string) (*GraduationRequirements, error) {
+	query := `
+		SELECT organization_id, requirements, updated_at
+		FROM graduation_requirements
+		WHERE organization_id = $1
+	`
+
+	var requirements GraduationRequirements
+
+	err := s.db.pool.QueryRow(ctx, query, organizationID).Scan(&requirements.OrganizationID, &requirements.Requirements, &requirements.UpdatedAt)
+
+	return noRowsAsNil(&requirements, err)
+}
+
+func (s *GraduationPostgresStore) SaveRequirements(ctx context.Context, requirements *GraduationRequirements) error {
+	query := `
+		INSERT INTO graduation_requirements (organization_id, requirements, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id) DO UPDATE SET requirements = excluded.requirements, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, requirements.OrganizationID, requirements.Requirements, requirements.UpdatedAt)
+
+	return err
+}
+
+const testScoreColumns = `id, organization_id, student_user_id, test, score, taken_on, COALESCE(recorded_by_user_id::text, ''), created_at`
+
+func scanTestScore(row rowScanner) (*TestScore, error) {
+	var score TestScore
+
+	err := row.Scan(
+		&score.ID,
+		&score.OrganizationID,
+		&score.StudentUserID,
+		&score.Test,
+		&score.Score,
+		&score.TakenOn,
+		&score.RecordedByUserID,
+		&score.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &score, nil
+}
+
+func (s *GraduationPostgresStore) CreateTestScore(ctx context.Context, score *TestScore) error {
+	query := `
+		INSERT INTO test_scores (organization_id, student_user_id, test, score, taken_on, recorded_by_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		score.OrganizationID,
+		score.StudentUserID,
+		score.Test,
+		score.Score,
+		score.TakenOn,
+		score.RecordedByUserID,
+		score.CreatedAt,
+	)
+
+	return row.Scan(&score.ID)
+}
+
+func (s *GraduationPostgresStore) GetTestScore(ctx context.Context, id string) (*TestScore, error) {
+	query := `SELECT ` + testScoreColumns + ` FROM test_scores WHERE id = $1`
+
+	return noRowsAsNil(scanTestScore(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *GraduationPostgresStore) ListTestScores(ctx context.Context, organizationID string, studentUserID string) ([]*TestScore, error) {
+	query := `
+		SELECT ` + testScoreColumns + `
+		FROM test_scores
+		WHERE organization_id = $1 AND student_user_id = $2
+		ORDER BY taken_on DESC, test, id
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var scores []*TestScore
+
+	for rows.Next() {
+		score, err := scanTestScore(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		scores = append(scores, score)
+	}
+
+	return scores, rows.Err()
+}
+
+func (s *GraduationPostgresStore) DeleteTestScore(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM test_scores WHERE id = $1`, id)
+	return err
+}
+
+// Checks the requirements against the organization's course catalog
+func validateGraduationRequirements(requirements []GraduationRequirement, courses []*Course) error {
+	if len(requirements) > maxGraduationRequirements {
+		return fmt.Errorf("an organization can have at most %d graduation requirements", maxGraduationRequirements)
+	}
+
+	seen := map[string]bool{}
+
+	for _, requirement := range requirements {
+		if requirement.Name == "" {
+			return errors.New("every graduation requirement needs a name")
+		}
+
+		if seen[strings.ToLower(requirement.Name)] {
+			return fmt.Errorf("graduation requirement %s is defined more than once", requirement.Name)
+		}
+
+		seen[strings.ToLower(requirement.Name)] = true
+
+		switch requirement.Kind {
+		case RequirementCredits:
+			if requirement.Credits <= 0 {
+				return fmt.Errorf("graduation requirement %s must require some credits", requirement.Name)
+			}
+		case RequirementCourse:
+			if len(requirement.CourseIDs) == 0 {
+				return fmt.Errorf("graduation requirement %s must list at least one course", requirement.Name)
+			}
+
+			for _, id := range requirement.CourseIDs {
+				if !slices.ContainsFunc(courses, func(course *Course) bool { return course.ID == id }) {
+					return fmt.Errorf("course %s is not a course of this organization", id)
+				}
+			}
+		case RequirementTestScore:
+			if requirement.Test == "" {
+				return fmt.Errorf("graduation requirement %s must name a test", requirement.Name)
+			}
+
+			if requirement.MinScore < 0 {
+				return errors.New("minimum scores must not be negative")
+			}
+		default:
+			return fmt.Errorf("graduation requirement kind must be one of %v", requirementKinds)
+		}
+	}
+
+	return nil
+}
+
+// A course a student took, as a degree audit counts it
+type AuditedCourse struct {
+	CourseID    string  `json:"courseId"`
+	CourseCode  string  `json:"courseCode"`
+	CourseTitle string  `json:"courseTitle"`
+	Subject     string  `json:"subject"`
+	Credits     float64 `json:"credits"`
+	SchoolID    string  `json:"schoolId"`
+	SectionID   string  `json:"sectionId"`
+	// The enrollment status: completed courses count, enrolled ones are in progress
+	Status string `json:"status"`
+}
+
+type RequirementProgress struct {
+	GraduationRequirement
+	Status string `json:"status"`
+	// Credits a credits requirement has from completed and current courses
+	CreditsEarned     float64 `json:"creditsEarned"`
+	CreditsInProgress float64 `json:"creditsInProgress"`
+	// The courses counting towards a credits or course requirement
+	Courses []AuditedCourse `json:"courses"`
+	// The student's best score on a test score requirement's test, if they took it
+	BestScore *float64 `json:"bestScore"`
+}
+
+type DegreeAudit struct {
+	OrganizationID    string                `json:"organizationId"`
+	StudentUserID     string                `json:"studentUserId"`
+	Status            string                `json:"status"`
+	CreditsEarned     float64               `json:"creditsEarned"`
+	CreditsInProgress float64               `json:"creditsInProgress"`
+	Requirements      []RequirementProgress `json:"requirements"`
+	AuditedAt         time.Time             `json:"auditedAt"`
+}
+
+// Ranks how far along a course is, so a course taken more than once counts at its best
+var auditedCourseProgress = map[string]int{EnrollmentStatusFailed: 0, EnrollmentStatusEnrolled: 1, EnrollmentStatusCompleted: 2}
+
+// Orders requirement statuses from worst to best
+var requirementStatusOrder = map[string]int{RequirementMissing: 0, RequirementInProgress: 1, RequirementSatisfied: 2}
+
+// Evaluates the student's coursework and test scores against the requirements. A course
+// counts towards every requirement it fits, and once however often it was taken.
+func auditRequirements(organizationID string, studentUserID string, requirements []GraduationRequirement, courses []AuditedCourse, scores []*TestScore, now time.Time) *DegreeAudit {
+	best := map[string]AuditedCourse{}
+
+	for _, course := range courses {
+		if existing, ok := best[course.CourseID]; !ok || auditedCourseProgress[course.Status] > auditedCourseProgress[existing.Status] {
+			best[course.CourseID] = course
+		}
+	}
+
+	taken := slices.SortedFunc(maps.Values(best), func(a, b AuditedCourse) int {
+		return cmp.Or(cmp.Compare(a.CourseCode, b.CourseCode), cmp.Compare(a.CourseID, b.CourseID))
+	})
+
+	audit := &DegreeAudit{
+		OrganizationID: organizationID,
+		StudentUserID:  studentUserID,
+		Status:         RequirementSatisfied,
+		Requirements:   []RequirementProgress{},
+		AuditedAt:      now,
+	}
+
+	for _, course := range taken {
+		switch course.Status {
+		case EnrollmentStatusCompleted:
+			audit.CreditsEarned += course.Credits
+		case EnrollmentStatusEnrolled:
+			audit.CreditsInProgress += course.Credits
+		}
+	}
+
+	for _, requirement := range requirements {
+		progress := RequirementProgress{GraduationRequirement: requirement, Status: RequirementMissing, Courses: []AuditedCourse{}}
+
+		switch requirement.Kind {
+		case RequirementCredits:
+			for _, course := range taken {
+				if course.Status == EnrollmentStatusFailed || (requirement.Subject != "" && !strings.EqualFold(course.Subject, requirement.Subject)) {
+					continue
+				}
+
+				if course.Status == EnrollmentStatusCompleted {
+					progress.CreditsEarned += course.Credits
+				} else {
+					progress.CreditsInProgress += course.Credits
+				}
+
+				progress.Courses = append(progress.Courses, course)
+			}
+
+			if progress.CreditsEarned >= requirement.Credits {
+				progress.Status = RequirementSatisfied
+			} else if progress.CreditsEarned+progress.CreditsInProgress >= requirement.Credits {
+				progress.Status = RequirementInProgress
+			}
+		case RequirementCourse:
+			for _, course := range taken {
+				if course.Status == EnrollmentStatusFailed || !slices.Contains(requirement.CourseIDs, course.CourseID) {
+					continue
+				}
+
+				progress.Courses = append(progress.Courses, course)
+
+				if course.Status == EnrollmentStatusCompleted {
+					progress.Status = RequirementSatisfied
+				} else if progress.Status == RequirementMissing {
+					progress.Status = RequirementInProgress
+				}
+			}
+		case RequirementTestScore:
+			for _, score := range scores {
+				if strings.EqualFold(score.Test, requirement.Test) && (progress.BestScore == nil || score.Score > *progress.BestScore) {
+					progress.BestScore = floatPtr(score.Score)
+				}
+			}
+
+			if progress.BestScore != nil && *progress.BestScore >= requirement.MinScore {
+				progress.Status = RequirementSatisfied
+			}
+		}
+
+		if requirementStatusOrder[progress.Status] < requirementStatusOrder[audit.Status] {
+			audit.Status = progress.Status
+		}
+
+		audit.Requirements = append(audit.Requirements, progress)
+	}
+
+	return audit
+}
+
+type GraduationService struct {
+	graduationStore GraduationStore
+	enrollmentStore EnrollmentStore
+	sectionStore    SectionStore
+	courseStore     CourseStore
+	schoolStore     SchoolStore
+	memberStore     OrganizationMemberStore
+	auditService    *AuditService
+}
+
+func NewGraduationService(graduationStore GraduationStore, enrollmentStore EnrollmentStore, sectionStore SectionStore, courseStore CourseStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *GraduationService {
+	return &GraduationService{
+		graduationStore: graduationStore,
+		enrollmentStore: enrollmentStore,
+		sectionStore:    sectionStore,
+		courseStore:     courseStore,
+		schoolStore:     schoolStore,
+		memberStore:     memberStore,
+		auditService:    auditService,
+	}
+}
+
+// Returns the organization's graduation requirements, which are empty until it sets some
+func (s *GraduationService) requirements(ctx context.Context, organizationID string) (*GraduationRequirements, error) {
+	requirements, err := s.graduationStore.GetRequirements(ctx, organizationID)
+
+	if err != nil {
+		slog.Error("failed to get graduation requirements", "error", err)
+		return nil, ErrInternal
+	}
+
+	if requirements == nil {
+		requirements = &GraduationRequirements{OrganizationID: organizationID, Requirements: []GraduationRequirement{}}
+	}
+
+	return requirements, nil
+}
+
+func (s *GraduationService) GetRequirements(ctx context.Context, organizationID string) (*GraduationRequirements, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID); err != nil {
+		return nil, err
+	}
+
+	return s.requirements(ctx, organizationID)
+}
+
+// Replaces the organization's graduation requirements
+func (s *GraduationService) SaveRequirements(ctx context.Context, requirements *GraduationRequirements) error {
+	if _, err := requireMembership(ctx, s.memberStore, requirements.OrganizationID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if requirements.Requirements == nil {
+		requirements.Requirements = []GraduationRequirement{}
+	}
+
+	courses, err := s.courseStore.ListByOrganization(ctx, requirements.OrganizationID)
+
+	if err != nil {
+		slog.Error("failed to list courses", "error", err)
+		return ErrInternal
+	}
+
+	if err := validateGraduationRequirements(requirements.Requirements, courses); err != nil {
+		return err
+	}
+
+	before, err := s.requirements(ctx, requirements.OrganizationID)
+
+	if err != nil {
+		return err
+	}
+
+	requirements.UpdatedAt = time.Now()
+
+	if err := s.graduationStore.SaveRequirements(ctx, requirements); err != nil {
+		slog.Error("failed to save graduation requirements", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, requirements.OrganizationID, AuditActionUpdate, "graduation_requirements", requirements.OrganizationID, before, requirements)
+
+	return nil
+}
+
+// Succeeds if the session user is the student or staff of the organization, and the student
+// is a student of it
+func (s *GraduationService) authorizeStudent(ctx context.Context, organizationID string, studentUserID string) error {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	roles := []string{RoleAdmin, RoleTeacher}
+
+	if session.UserID == studentUserID {
+		roles = nil
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, roles...); err != nil {
+		return err
+	}
+
+	member, err := s.memberStore.Get(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if member == nil || member.Role != RoleStudent {
+		return notFound("student")
+	}
+
+	return nil
+}
+
+func (s *GraduationService) RecordTestScore(ctx context.Context, score *TestScore) error {
+	member, err := requireMembership(ctx, s.memberStore, score.OrganizationID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	score.Test = strings.TrimSpace(score.Test)
+
+	if score.Test == "" {
+		return errors.New("test is required")
+	}
+
+	if score.Score < 0 {
+		return errors.New("score must not be negative")
+	}
+
+	if score.TakenOn.IsZero() {
+		return errors.New("taken on is required")
+	}
+
+	student, err := s.memberStore.Get(ctx, score.OrganizationID, score.StudentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if student == nil || student.Role != RoleStudent {
+		return errors.New("student must be a student of the organization")
+	}
+
+	score.RecordedByUserID = member.UserID
+	score.CreatedAt = time.Now()
+
+	if err := s.graduationStore.CreateTestScore(ctx, score); err != nil {
+		slog.Error("failed to create test score", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, score.OrganizationID, AuditActionCreate, "test_score", score.ID, nil, score)
+
+	return nil
+}
+
+func (s *GraduationService) listTestScores(ctx context.Context, organizationID string, studentUserID string) ([]*TestScore, error) {
+	scores, err := s.graduationStore.ListTestScores(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to list test scores", "error", err)
+		return nil, ErrInternal
+	}
+
+	if scores == nil {
+		scores = []*TestScore{}
+	}
+
+	return scores, nil
+}
+
+func (s *GraduationService) ListTestScores(ctx context.Context, organizationID string, studentUserID string) ([]*TestScore, error) {
+	if err := s.authorizeStudent(ctx, organizationID, studentUserID); err != nil {
+		return nil, err
+	}
+
+	return s.listTestScores(ctx, organizationID, studentUserID)
+}
+
+func (s *GraduationService) DeleteTestScore(ctx context.Context, id string) error {
+	score, err := s.graduationStore.GetTestScore(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get test score", "error", err)
+		return ErrInternal
+	}
+
+	if score == nil {
+		return notFound("test score")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, score.OrganizationID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.graduationStore.DeleteTestScore(ctx, id); err != nil {
+		slog.Error("failed to delete test score", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, score.OrganizationID, AuditActionDelete, "test_score", id, score, nil)
+
+	return nil
+}
+
+// Returns the courses the student took at the organization's schools, including those they
+// failed or are still taking
+func (s *GraduationService) coursework(ctx context.Context, organizationID string, studentUserID string) ([]AuditedCourse, error) {
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{
+		StudentUserID: studentUserID,
+		Statuses:      []string{EnrollmentStatusEnrolled, EnrollmentStatusCompleted, EnrollmentStatusFailed},
+	})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	schools := map[string]*School{}
+	var courses []AuditedCourse
+
+	for _, enrollment := range enrollments {
+		section, err := s.sectionStore.GetByID(ctx, enrollment.SectionID)
+
+		if err != nil {
+			slog.Error("failed to get section", "error", err)
+			return nil, ErrInternal
+		}
+
+		if section == nil {
+			continue
+		}
+
+		school, ok := schools[section.SchoolID]
+
+		if !ok {
+			if school, err = s.schoolStore.GetByID(ctx, section.SchoolID); err != nil {
+				slog.Error("failed to get school", "error", err)
+				return nil, ErrInternal
+			}
+
+			schools[section.SchoolID] = school
+		}
+
+		if school == nil || school.OrganizationID != organizationID {
+			continue
+		}
+
+		course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+		if err != nil {
+			slog.Error("failed to get course", "error", err)
+			return nil, ErrInternal
+		}
+
+		if course == nil {
+			continue
+		}
+
+		courses = append(courses, AuditedCourse{
+			CourseID:    course.ID,
+			CourseCode:  course.Code,
+			CourseTitle: course.Title,
+			Subject:     course.Subject,
+			Credits:     course.Credits,
+			SchoolID:    school.ID,
+			SectionID:   section.ID,
+			Status:      enrollment.Status,
+		})
+	}
+
+	return courses, nil
+}
+
+// Evaluates the student's coursework and test scores against the organization's graduation
+// requirements
+func (s *GraduationService) Audit(ctx context.Context, organizationID string, studentUserID string) (*DegreeAudit, error) {
+	if err := s.authorizeStudent(ctx, organizationID, studentUserID); err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.requirements(ctx, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	courses, err := s.coursework(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scores, err := s.listTestScores(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return auditRequirements(organizationID, studentUserID, requirements.Requirements, courses, scores, time.Now().UTC()), nil
+}
+
+type GraduationHandler struct {
+	graduationService *GraduationService
+}
+
+func (h *GraduationHandler) GetRequirements(w http.ResponseWriter, r *http.Request) {
+	requirements, err := h.graduationService.GetRequirements(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requirements)
+}
+
+func (h *GraduationHandler) SaveRequirements(w http.ResponseWriter, r *http.Request) {
+	var requirements GraduationRequirements
+
+	if err := decodeJSON(r, &requirements); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	requirements.OrganizationID = r.PathValue("id")
+
+	if err := h.graduationService.SaveRequirements(r.Context(), &requirements); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requirements)
+}
+
+func (h *GraduationHandler) RecordTestScore(w http.ResponseWriter, r *http.Request) {
+	var score TestScore
+
+	if err := decodeJSON(r, &score); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	score.OrganizationID = r.PathValue("id")
+	score.StudentUserID = r.PathValue("studentId")
+
+	if err := h.graduationService.RecordTestScore(r.Context(), &score); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, score)
+}
+
+func (h *GraduationHandler) ListTestScores(w http.ResponseWriter, r *http.Request) {
+	scores, err := h.graduationService.ListTestScores(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, scores)
+}
+
+func (h *GraduationHandler) DeleteTestScore(w http.ResponseWriter, r *http.Request) {
+	if err := h.graduationService.DeleteTestScore(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GraduationHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	audit, err := h.graduationService.Audit(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, audit)
+}
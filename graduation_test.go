@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockGraduationStore struct {
+	GetRequirementsFunc  func(ctx context.Context, organizationID string) (*GraduationRequirements, error)
+	SaveRequirementsFunc func(ctx context.Context, requirements *GraduationRequirements) error
+	CreateTestScoreFunc  func(ctx context.Context, score *TestScore) error
+	GetTestScoreFunc     func(ctx context.Context, id string) (*TestScore, error)
+	ListTestScoresFunc   func(ctx context.Context, organizationID string, studentUserID string) ([]*TestScore, error)
+	DeleteTestScoreFunc  func(ctx context.Context, id string) error
+}
+
+func (m *MockGraduationStore) GetRequirements(ctx context.Context, organizationID string) (*GraduationRequirements, error) {
+	if m.GetRequirementsFunc != nil {
+		return m.GetRequirementsFunc(ctx, organizationID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGraduationStore) SaveRequirements(ctx context.Context, requirements *GraduationRequirements) error {
+	if m.SaveRequirementsFunc != nil {
+		return m.SaveRequirementsFunc(ctx, requirements)
+	}
+
+	return nil
+}
+
+func (m *MockGraduationStore) CreateTestScore(ctx context.Context, score *TestScore) error {
+	if m.CreateTestScoreFunc != nil {
+		return m.CreateTestScoreFunc(ctx, score)
+	}
+
+	return nil
+}
+
+func (m *MockGraduationStore) GetTestScore(ctx context.Context, id string) (*TestScore, error) {
+	if m.GetTestScoreFunc != nil {
+		return m.GetTestScoreFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGraduationStore) ListTestScores(ctx context.Context, organizationID string, studentUserID string) ([]*TestScore, error) {
+	if m.ListTestScoresFunc != nil {
+		return m.ListTestScoresFunc(ctx, organizationID, studentUserID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGraduationStore) DeleteTestScore(ctx context.Context, id string) error {
+	if m.DeleteTestScoreFunc != nil {
+		return m.DeleteTestScoreFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// Every school is North High in org, except other-school, South High in other-org
+func twoOrganizationSchools() *MockSchoolStore {
+	return &MockSchoolStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*School, error) {
+			if id == "other-school" {
+				return &School{ID: id, OrganizationID: "other-org", Name: "South High"}, nil
+			}
+
+			return &School{ID: id, OrganizationID: "org", Name: "North High"}, nil
+		},
+	}
+}
+
+func TestAuditRequirements_EvaluatesEachKindOfRequirement(t *testing.T) {
+	requirements := []GraduationRequirement{
+		{Name: "Math", Kind: RequirementCredits, Subject: "mathematics", Credits: 2},
+		{Name: "Total", Kind: RequirementCredits, Credits: 4},
+		{Name: "Geometry", Kind: RequirementCourse, CourseIDs: []string{"geo"}},
+		{Name: "Algebra 2", Kind: RequirementCourse, CourseIDs: []string{"alg2", "alg2h"}},
+		{Name: "Exit exam", Kind: RequirementTestScore, Test: "State Exit", MinScore: 70},
+	}
+
+	courses := []AuditedCourse{
+		{CourseID: "geo", CourseCode: "MATH201", Subject: "Mathematics", Credits: 1, Status: EnrollmentStatusFailed},
+		{CourseID: "geo", CourseCode: "MATH201", Subject: "Mathematics", Credits: 1, Status: EnrollmentStatusCompleted},
+		{CourseID: "alg2h", CourseCode: "MATH302", Subject: "Mathematics", Credits: 1, Status: EnrollmentStatusEnrolled},
+		{CourseID: "eng", CourseCode: "ENG101", Subject: "English", Credits: 1, Status: EnrollmentStatusCompleted},
+		{CourseID: "bio", CourseCode: "SCI101", Subject: "Science", Credits: 1, Status: EnrollmentStatusFailed},
+	}
+
+	scores := []*TestScore{
+		{Test: "state exit", Score: 64},
+		{Test: "State Exit", Score: 72},
+		{Test: "SAT", Score: 1400},
+	}
+
+	audit := auditRequirements("org", "student", requirements, courses, scores, time.Now())
+
+	assert.Equal(t, RequirementMissing, audit.Status)
+	assert.Equal(t, 2.0, audit.CreditsEarned)
+	assert.Equal(t, 1.0, audit.CreditsInProgress)
+
+	math := audit.Requirements[0]
+	assert.Equal(t, RequirementInProgress, math.Status)
+	assert.Equal(t, 1.0, math.CreditsEarned)
+	assert.Equal(t, 1.0, math.CreditsInProgress)
+	assert.Len(t, math.Courses, 2)
+
+	// Two earned and one in progress fall short of four credits
+	assert.Equal(t, RequirementMissing, audit.Requirements[1].Status)
+
+	assert.Equal(t, RequirementSatisfied, audit.Requirements[2].Status)
+	assert.Equal(t, RequirementInProgress, audit.Requirements[3].Status)
+	assert.Equal(t, "alg2h", audit.Requirements[3].Courses[0].CourseID)
+
+	assert.Equal(t, RequirementSatisfied, audit.Requirements[4].Status)
+	assert.Equal(t, 72.0, *audit.Requirements[4].BestScore)
+}
+
+func TestAuditRequirements_IsSatisfiedWithoutRequirements(t *testing.T) {
+	audit := auditRequirements("org", "student", nil, nil, nil, time.Now())
+
+	assert.Equal(t, RequirementSatisfied, audit.Status)
+	assert.Empty(t, audit.Requirements)
+}
+
+func TestValidateGraduationRequirements_ReturnsErrorForCourseOfOtherOrganization(t *testing.T) {
+	courses, _ := mathCatalog().ListByOrganization(context.Background(), "org")
+
+	err := validateGraduationRequirements([]GraduationRequirement{{Name: "Physics", Kind: RequirementCourse, CourseIDs: []string{"phys"}}}, courses)
+
+	assert.Error(t, err)
+	assert.Equal(t, "course phys is not a course of this organization", err.Error())
+}
+
+func TestValidateGraduationRequirements_ReturnsErrorForDuplicateName(t *testing.T) {
+	err := validateGraduationRequirements([]GraduationRequirement{
+		{Name: "Math", Kind: RequirementCredits, Credits: 3},
+		{Name: "math", Kind: RequirementCredits, Credits: 4},
+	}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "graduation requirement math is defined more than once", err.Error())
+}
+
+func TestValidateGraduationRequirements_ReturnsErrorForUnknownKind(t *testing.T) {
+	err := validateGraduationRequirements([]GraduationRequirement{{Name: "Service", Kind: "hours"}}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "graduation requirement kind must be one of [credits course test_score]", err.Error())
+}
+
+func TestGraduationService_SaveRequirements_SavesForAdmin(t *testing.T) {
+	var saved *GraduationRequirements
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{
+		SaveRequirementsFunc: func(ctx context.Context, requirements *GraduationRequirements) error {
+			saved = requirements
+			return nil
+		},
+	}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := graduationService.SaveRequirements(sessionContext("admin"), &GraduationRequirements{
+		OrganizationID: "org",
+		Requirements:   []GraduationRequirement{{Name: "Geometry", Kind: RequirementCourse, CourseIDs: []string{"geo"}}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "org", saved.OrganizationID)
+	assert.False(t, saved.UpdatedAt.IsZero())
+}
+
+func TestGraduationService_SaveRequirements_ReturnsErrorForTeacher(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := graduationService.SaveRequirements(sessionContext("teacher"), &GraduationRequirements{OrganizationID: "org"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGraduationService_RecordTestScore_RecordsAdmin(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	score := &TestScore{OrganizationID: "org", StudentUserID: "student", Test: " SAT ", Score: 1400, TakenOn: NewDate(2025, time.October, 4)}
+
+	err := graduationService.RecordTestScore(sessionContext("admin"), score)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SAT", score.Test)
+	assert.Equal(t, "admin", score.RecordedByUserID)
+}
+
+func TestGraduationService_RecordTestScore_ReturnsErrorForNonStudent(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	score := &TestScore{OrganizationID: "org", StudentUserID: "teacher", Test: "SAT", Score: 1400, TakenOn: NewDate(2025, time.October, 4)}
+
+	err := graduationService.RecordTestScore(sessionContext("admin"), score)
+
+	assert.Error(t, err)
+	assert.Equal(t, "student must be a student of the organization", err.Error())
+}
+
+func TestGraduationService_DeleteTestScore_ReturnsErrorForTeacher(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{
+		GetTestScoreFunc: func(ctx context.Context, id string) (*TestScore, error) {
+			return &TestScore{ID: id, OrganizationID: "org", StudentUserID: "student", Test: "SAT"}, nil
+		},
+		DeleteTestScoreFunc: func(ctx context.Context, id string) error {
+			t.Fatal("test score should not be deleted")
+			return nil
+		},
+	}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := graduationService.DeleteTestScore(sessionContext("teacher"), "1")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGraduationService_Audit_CountsCourseworkAtOrganizationSchools(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{
+		GetRequirementsFunc: func(ctx context.Context, organizationID string) (*GraduationRequirements, error) {
+			return &GraduationRequirements{OrganizationID: organizationID, Requirements: []GraduationRequirement{
+				{Name: "Math", Kind: RequirementCredits, Subject: "Mathematics", Credits: 2},
+				{Name: "Algebra 1", Kind: RequirementCourse, CourseIDs: []string{"alg1"}},
+			}}, nil
+		},
+	}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	audit, err := graduationService.Audit(sessionContext("student"), "org", "student")
+
+	assert.NoError(t, err)
+	assert.Equal(t, RequirementMissing, audit.Status)
+	assert.Equal(t, 1.0, audit.CreditsEarned)
+	assert.Equal(t, 1.0, audit.CreditsInProgress)
+	assert.Equal(t, RequirementInProgress, audit.Requirements[0].Status)
+
+	// Algebra 1 was taken at another organization's school
+	assert.Equal(t, RequirementMissing, audit.Requirements[1].Status)
+	assert.Empty(t, audit.Requirements[1].Courses)
+}
+
+func TestGraduationService_Audit_AllowsTeacher(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	audit, err := graduationService.Audit(sessionContext("teacher"), "org", "student")
+
+	assert.NoError(t, err)
+	assert.Equal(t, RequirementSatisfied, audit.Status)
+}
+
+func TestGraduationService_Audit_ReturnsErrorForOtherStudent(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := graduationService.Audit(sessionContext("other-student"), "org", "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGraduationService_Audit_ReturnsNotFoundForNonStudent(t *testing.T) {
+	enrollmentStore, sectionStore, courseStore, _, _ := academicHistory()
+	courseStore.ListByOrganizationFunc = mathCatalog().ListByOrganizationFunc
+	graduationService := NewGraduationService(&MockGraduationStore{}, enrollmentStore, sectionStore, courseStore, twoOrganizationSchools(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := graduationService.Audit(sessionContext("admin"), "org", "teacher")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
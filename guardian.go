@@ -0,0 +1,1017 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	GuardianRelationshipParent        = "parent"
+	GuardianRelationshipStepParent    = "step_parent"
+	GuardianRelationshipGrandparent   = "grandparent"
+	GuardianRelationshipFosterParent  = "foster_parent"
+	GuardianRelationshipLegalGuardian = "legal_guardian"
+	GuardianRelationshipRelative      = "relative"
+	GuardianRelationshipOther         = "other"
+)
+
+var guardianRelationships = []string{
+	GuardianRelationshipParent,
+	GuardianRelationshipStepParent,
+	GuardianRelationshipGrandparent,
+	GuardianRelationshipFosterParent,
+	GuardianRelationshipLegalGuardian,
+	GuardianRelationshipRelative,
+	GuardianRelationshipOther,
+}
+
+// Links a guardian to a student of an organization. Guardians need not belong to the
+// organization; the link is what lets them follow the student's grades, attendance and
+// schedule, less whatever the link hides.
+type Guardianship struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organizationId"`
+	GuardianUserID string `json:"guardianUserId"`
+	StudentUserID  string `json:"studentUserId"`
+	Relationship   string `json:"relationship"`
+	// Has legal custody of the student
+	Custodial bool `json:"custodial"`
+	// May be contacted by the school about the student
+	ContactAllowed   bool `json:"contactAllowed"`
+	EmergencyContact bool `json:"emergencyContact"`
+	// May pick the student up from school
+	PickupAuthorized bool `json:"pickupAuthorized"`
+	// Kept out of the guardian's portal, such as under a court order
+	GradesHidden     bool      `json:"gradesHidden"`
+	AttendanceHidden bool      `json:"attendanceHidden"`
+	ScheduleHidden   bool      `json:"scheduleHidden"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// Narrows a guardianship listing. Empty fields match everything.
+type GuardianshipFilter struct {
+	OrganizationID string
+	GuardianUserID string
+	StudentUserID  string
+}
+
+type GuardianPostgresStore struct {
+	db *PostgresDB
+}
+
+type GuardianStore interface {
+	Create(ctx context.Context, guardianship *Guardianship) error
+	GetByID(ctx context.Context, id string) (*Guardianship, error)
+	List(ctx context.Context, filter *GuardianshipFilter) ([]*Guardianship, error)
+	Update(ctx context.Context, guardianship *Guardianship) error
+	Delete(ctx context.Context, id string) error
+}
+
+const guardianshipColumns = `
+	id, organization_id, guardian_user_id, student_user_id, relationship, custodial, contact_allowed, emergency_contact,
+	pickup_authorized, grades_hidden, attendance_hidden, schedule_hidden, created_at, updated_at
+`
+
+func scanGuardianship(row rowScanner) (*Guardianship, error) {
+	var guardianship Guardianship
+
+	err := row.Scan(
+		&guardianship.ID,
+		&guardianship.OrganizationID,
+		&guardianship.GuardianUserID,
+		&guardianship.StudentUserID,
+		&guardianship.Relationship,
+		&guardianship.Custodial,
+		&guardianship.ContactAllowed,
+		&guardianship.EmergencyContact,
+		&guardianship.PickupAuthorized,
+		&guardianship.GradesHidden,
+		&guardianship.AttendanceHidden,
+		&guardianship.ScheduleHidden,
+		&guardianship.CreatedAt,
+		&guardianship.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &guardianship, nil
+}
+
+func (s *GuardianPostgresStore) Create(ctx context.Context, guardianship *Guardianship) error {
+	query := `
+		INSERT INTO guardianships (
+			organization_id, guardian_user_id, student_user_id, relationship, custodial, contact_allowed, emergency_contact,
+			pickup_authorized, grades_hidden, attendance_hidden, schedule_hidden, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		guardianship.OrganizationID,
+		guardianship.GuardianUserID,
+		guardianship.StudentUserID,
+		guardianship.Relationship,
+		guardianship.Custodial,
+		guardianship.ContactAllowed,
+		guardianship.EmergencyContact,
+		guardianship.PickupAuthorized,
+		guardianship.GradesHidden,
+		guardianship.AttendanceHidden,
+		guardianship.ScheduleHidden,
+		guardianship.CreatedAt,
+		guardianship.UpdatedAt,
+	)
+
+	return row.Scan(&guardianship.ID)
+}
+
+func (s *GuardianPostgresStore) GetByID(ctx context.Context, id string) (*Guardianship, error) {
+	query := `SELECT ` + guardianshipColumns + ` FROM guardianships WHERE id = $1`
+
+	return noRowsAsNil(scanGuardianship(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *GuardianPostgresStore) List(ctx context.Context, filter *GuardianshipFilter) ([]*Guardianship, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.GuardianUserID != "" {
+		addCondition("guardian_user_id = $%d", filter.GuardianUserID)
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("student_user_id = $%d", filter.StudentUserID)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM guardianships
+		%s
+		ORDER BY created_at, id
+	`, guardianshipColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var guardianships []*Guardianship
+
+	for rows.Next() {
+		guardianship, err := scanGuardianship(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		guardianships = append(guardianships, guardianship)
+	}
+
+	return guardianships, rows.Err()
+}
+
+func (s *GuardianPostgresStore) Update(ctx context.Context, guardianship *Guardianship) error {
+	query := `
+		UPDATE guardianships
+		SET relationship = $1, custodial = $2, contact_allowed = $3, emergency_contact = $4, pickup_authorized = $5,
+			grades_hidden = $6, attendance_hidden = $7, schedule_hidden = $8, updated_at = $9
+		WHERE id = $10
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		guardianship.Relationship,
+		guardianship.Custodial,
+		guardianship.ContactAllowed,
+		guardianship.EmergencyContact,
+		guardianship.PickupAuthorized,
+		guardianship.GradesHidden,
+		guardianship.AttendanceHidden,
+		guardianship.ScheduleHidden,
+		guardianship.UpdatedAt,
+		guardianship.ID,
+	)
+
+	return err
+}
+
+func (s *GuardianPostgresStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM guardianships WHERE id = $1`, id)
+	return err
+}
+
+func validateGuardianship(guardianship *Guardianship) error {
+	if guardianship.OrganizationID == "" {
+		return errors.New("organization id is required")
+	}
+
+	if guardianship.GuardianUserID == "" {
+		return errors.New("guardian is required")
+	}
+
+	if guardianship.StudentUserID == "" {
+		return errors.New("student user id is required")
+	}
+
+	if guardianship.GuardianUserID == guardianship.StudentUserID {
+		return errors.New("students cannot be their own guardian")
+	}
+
+	if !slices.Contains(guardianRelationships, guardianship.Relationship) {
+		return fmt.Errorf("relationship must be one of %s", strings.Join(guardianRelationships, ", "))
+	}
+
+	if guardianship.EmergencyContact && !guardianship.ContactAllowed {
+		return errors.New("emergency contacts must allow contact")
+	}
+
+	return nil
+}
+
+// Collects the guardianships the user is the guardian or the student of for their data export
+func guardianshipDataExport(guardianStore GuardianStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		asGuardian, err := guardianStore.List(ctx, &GuardianshipFilter{GuardianUserID: userID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		asStudent, err := guardianStore.List(ctx, &GuardianshipFilter{StudentUserID: userID})
+
+		return toAnySlice(append(asGuardian, asStudent...)), err
+	}
+}
+
+type GuardianService struct {
+	guardianStore    GuardianStore
+	userStore        UserStore
+	memberStore      OrganizationMemberStore
+	schoolStore      SchoolStore
+	calendarStore    CalendarStore
+	sectionStore     SectionStore
+	enrollmentStore  EnrollmentStore
+	courseStore      CourseStore
+	attendanceStore  AttendanceStore
+	gradebookService *GradebookService
+	auditService     *AuditService
+}
+
+func NewGuardianService(guardianStore GuardianStore, userStore UserStore, memberStore OrganizationMemberStore, schoolStore SchoolStore, calendarStore CalendarStore, sectionStore SectionStore, enrollmentStore EnrollmentStore, courseStore CourseStore, attendanceStore AttendanceStore, gradebookService *GradebookService, auditService *AuditService) *GuardianService {
+	return &GuardianService{
+		guardianStore:    guardianStore,
+		userStore:        userStore,
+		memberStore:      memberStore,
+		schoolStore:      schoolStore,
+		calendarStore:    calendarStore,
+		sectionStore:     sectionStore,
+		enrollmentStore:  enrollmentStore,
+		courseStore:      courseStore,
+		attendanceStore:  attendanceStore,
+		gradebookService: gradebookService,
+		auditService:     auditService,
+	}
+}
+
+func (s *GuardianService) list(ctx context.Context, filter *GuardianshipFilter) ([]*Guardianship, error) {
+	guardianships, err := s.guardianStore.List(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list guardianships", "error", err)
+		return nil, ErrInternal
+	}
+
+	if guardianships == nil {
+		guardianships = []*Guardianship{}
+	}
+
+	return guardianships, nil
+}
+
+// Checks the user is a student of the organization
+func (s *GuardianService) checkStudent(ctx context.Context, organizationID string, studentUserID string) error {
+	member, err := s.memberStore.Get(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if member == nil || member.Role != RoleStudent {
+		return notFound("student")
+	}
+
+	return nil
+}
+
+type CreateGuardianshipRequest struct {
+	Guardianship
+	// Finds the guardian by email when no guardian user id is given
+	GuardianEmail string `json:"guardianEmail"`
+}
+
+// Links a guardian to a student of the organization
+func (s *GuardianService) Create(ctx context.Context, request *CreateGuardianshipRequest) (*Guardianship, error) {
+	guardianship := &request.Guardianship
+
+	if _, err := requireMembership(ctx, s.memberStore, guardianship.OrganizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if guardianship.GuardianUserID == "" && request.GuardianEmail != "" {
+		guardian, err := s.userStore.GetByEmail(ctx, strings.TrimSpace(request.GuardianEmail))
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to get user", "error", err)
+			return nil, ErrInternal
+		}
+
+		if guardian == nil {
+			return nil, notFound("guardian")
+		}
+
+		guardianship.GuardianUserID = guardian.ID
+	}
+
+	if err := validateGuardianship(guardianship); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkStudent(ctx, guardianship.OrganizationID, guardianship.StudentUserID); err != nil {
+		return nil, err
+	}
+
+	guardian, err := s.userStore.GetByID(ctx, guardianship.GuardianUserID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if guardian == nil {
+		return nil, notFound("guardian")
+	}
+
+	existing, err := s.list(ctx, &GuardianshipFilter{
+		OrganizationID: guardianship.OrganizationID,
+		GuardianUserID: guardianship.GuardianUserID,
+		StudentUserID:  guardianship.StudentUserID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) > 0 {
+		return nil, errors.New("guardian is already linked to the student")
+	}
+
+	guardianship.CreatedAt = time.Now()
+	guardianship.UpdatedAt = time.Now()
+
+	if err := s.guardianStore.Create(ctx, guardianship); err != nil {
+		slog.Error("failed to create guardianship", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, guardianship.OrganizationID, AuditActionCreate, "guardianship", guardianship.ID, nil, guardianship)
+
+	return guardianship, nil
+}
+
+// Lists the organization's guardianships. Staff may list any; students may list their own
+// guardians.
+func (s *GuardianService) List(ctx context.Context, organizationID string, filter *GuardianshipFilter) ([]*Guardianship, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	roles := []string{RoleAdmin, RoleTeacher}
+
+	if filter.StudentUserID != "" && filter.StudentUserID == session.UserID {
+		roles = nil
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, roles...); err != nil {
+		return nil, err
+	}
+
+	return s.list(ctx, &GuardianshipFilter{OrganizationID: organizationID, GuardianUserID: filter.GuardianUserID, StudentUserID: filter.StudentUserID})
+}
+
+// Returns the guardianship if the session user is its guardian or student, or belongs to its
+// organization with one of the given roles. Without roles, only staff and the two users it
+// links may read it.
+func (s *GuardianService) authorize(ctx context.Context, id string, roles ...string) (*Guardianship, error) {
+	guardianship, err := s.guardianStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get guardianship", "error", err)
+		return nil, ErrInternal
+	}
+
+	if guardianship == nil {
+		return nil, notFound("guardianship")
+	}
+
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if len(roles) == 0 {
+		if session.UserID == guardianship.GuardianUserID || session.UserID == guardianship.StudentUserID {
+			if session.IsImpersonation() && session.OrganizationID != guardianship.OrganizationID {
+				return nil, forbidden("impersonation is limited to its organization")
+			}
+
+			return guardianship, nil
+		}
+
+		roles = []string{RoleAdmin, RoleTeacher}
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, guardianship.OrganizationID, roles...); err != nil {
+		return nil, err
+	}
+
+	return guardianship, nil
+}
+
+func (s *GuardianService) GetByID(ctx context.Context, id string) (*Guardianship, error) {
+	return s.authorize(ctx, id)
+}
+
+type UpdateGuardianshipRequest struct {
+	Relationship     string `json:"relationship"`
+	Custodial        *bool  `json:"custodial"`
+	ContactAllowed   *bool  `json:"contactAllowed"`
+	EmergencyContact *bool  `json:"emergencyContact"`
+	PickupAuthorized *bool  `json:"pickupAuthorized"`
+	GradesHidden     *bool  `json:"gradesHidden"`
+	AttendanceHidden *bool  `json:"attendanceHidden"`
+	ScheduleHidden   *bool  `json:"scheduleHidden"`
+}
+
+func (s *GuardianService) Update(ctx context.Context, id string, request *UpdateGuardianshipRequest) (*Guardianship, error) {
+	guardianship, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *guardianship
+
+	if request.Relationship != "" {
+		guardianship.Relationship = request.Relationship
+	}
+
+	for _, field := range []struct {
+		value  *bool
+		target *bool
+	}{
+		{request.Custodial, &guardianship.Custodial},
+		{request.ContactAllowed, &guardianship.ContactAllowed},
+		{request.EmergencyContact, &guardianship.EmergencyContact},
+		{request.PickupAuthorized, &guardianship.PickupAuthorized},
+		{request.GradesHidden, &guardianship.GradesHidden},
+		{request.AttendanceHidden, &guardianship.AttendanceHidden},
+		{request.ScheduleHidden, &guardianship.ScheduleHidden},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+
+	if err := validateGuardianship(guardianship); err != nil {
+		return nil, err
+	}
+
+	guardianship.UpdatedAt = time.Now()
+
+	if err := s.guardianStore.Update(ctx, guardianship); err != nil {
+		slog.Error("failed to update guardianship", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, guardianship.OrganizationID, AuditActionUpdate, "guardianship", id, &before, guardianship)
+
+	return guardianship, nil
+}
+
+func (s *GuardianService) Delete(ctx context.Context, id string) error {
+	guardianship, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.guardianStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete guardianship", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, guardianship.OrganizationID, AuditActionDelete, "guardianship", id, guardianship, nil)
+
+	return nil
+}
+
+// A student as their guardian sees them in the portal
+type LinkedStudent struct {
+	Guardianship *Guardianship `json:"guardianship"`
+	FirstName    string        `json:"firstName"`
+	LastName     string        `json:"lastName"`
+}
+
+// Lists the students the guardian is linked to
+func (s *GuardianService) ListStudents(ctx context.Context, guardianUserID string) ([]*LinkedStudent, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if session.UserID != guardianUserID {
+		return nil, ErrForbidden
+	}
+
+	guardianships, err := s.list(ctx, &GuardianshipFilter{GuardianUserID: guardianUserID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	students := []*LinkedStudent{}
+
+	for _, guardianship := range guardianships {
+		if session.IsImpersonation() && session.OrganizationID != guardianship.OrganizationID {
+			continue
+		}
+
+		student, err := s.userStore.GetByID(ctx, guardianship.StudentUserID)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to get user", "error", err)
+			return nil, ErrInternal
+		}
+
+		if student == nil {
+			continue
+		}
+
+		students = append(students, &LinkedStudent{Guardianship: guardianship, FirstName: student.FirstName, LastName: student.LastName})
+	}
+
+	return students, nil
+}
+
+// Returns the guardianship if the session user is its guardian and its student still
+// belongs to the organization
+func (s *GuardianService) portal(ctx context.Context, id string) (*Guardianship, error) {
+	guardianship, err := s.authorize(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if session, _ := SessionFromContext(ctx); session.UserID != guardianship.GuardianUserID {
+		return nil, forbidden("only the guardian may use the guardian portal")
+	}
+
+	if err := s.checkStudent(ctx, guardianship.OrganizationID, guardianship.StudentUserID); err != nil {
+		return nil, err
+	}
+
+	return guardianship, nil
+}
+
+// A section the student is enrolled in, with what the portal shows about it
+type portalSection struct {
+	section *Section
+	school  *School
+	course  *Course
+	term    *Term
+}
+
+// Returns the sections the student is enrolled in at the organization's schools
+func (s *GuardianService) sections(ctx context.Context, guardianship *Guardianship) ([]*portalSection, error) {
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{StudentUserID: guardianship.StudentUserID, Statuses: []string{EnrollmentStatusEnrolled}})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	schools := map[string]*School{}
+	var sections []*portalSection
+
+	for _, enrollment := range enrollments {
+		section, err := s.sectionStore.GetByID(ctx, enrollment.SectionID)
+
+		if err != nil {
+			slog.Error("failed to get section", "error", err)
+			return nil, ErrInternal
+		}
+
+		if section == nil {
+			continue
+		}
+
+		school, ok := schools[section.SchoolID]
+
+		if !ok {
+			if school, err = s.schoolStore.GetByID(ctx, section.SchoolID); err != nil {
+				slog.Error("failed to get school", "error", err)
+				return nil, ErrInternal
+			}
+
+			schools[section.SchoolID] = school
+		}
+
+		if school == nil || school.OrganizationID != guardianship.OrganizationID {
+			continue
+		}
+
+		course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+		if err != nil {
+			slog.Error("failed to get course", "error", err)
+			return nil, ErrInternal
+		}
+
+		term, err := s.calendarStore.GetTerm(ctx, section.TermID)
+
+		if err != nil {
+			slog.Error("failed to get term", "error", err)
+			return nil, ErrInternal
+		}
+
+		if course == nil || term == nil {
+			continue
+		}
+
+		sections = append(sections, &portalSection{section: section, school: school, course: course, term: term})
+	}
+
+	slices.SortFunc(sections, func(a, b *portalSection) int {
+		return cmp.Or(a.term.StartDate.Compare(b.term.StartDate.Time), cmp.Compare(a.course.Code, b.course.Code), cmp.Compare(a.section.ID, b.section.ID))
+	})
+
+	return sections, nil
+}
+
+type GuardianCourseGrades struct {
+	SectionID   string `json:"sectionId"`
+	CourseCode  string `json:"courseCode"`
+	CourseTitle string `json:"courseTitle"`
+	TermName    string `json:"termName"`
+	// The student's row of the section's gradebook
+	Gradebook *Gradebook `json:"gradebook"`
+}
+
+// Returns the student's grades in the sections they are enrolled in
+func (s *GuardianService) Grades(ctx context.Context, id string) ([]*GuardianCourseGrades, error) {
+	guardianship, err := s.portal(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if guardianship.GradesHidden {
+		return nil, forbidden("grades are not shared with this guardian")
+	}
+
+	sections, err := s.sections(ctx, guardianship)
+
+	if err != nil {
+		return nil, err
+	}
+
+	grades := []*GuardianCourseGrades{}
+
+	for _, section := range sections {
+		gradebook, err := s.gradebookService.build(ctx, section.section, section.school, []string{guardianship.StudentUserID}, guardianship.StudentUserID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		grades = append(grades, &GuardianCourseGrades{
+			SectionID:   section.section.ID,
+			CourseCode:  section.course.Code,
+			CourseTitle: section.course.Title,
+			TermName:    section.term.Name,
+			Gradebook:   gradebook,
+		})
+	}
+
+	return grades, nil
+}
+
+type GuardianAttendance struct {
+	SchoolID   string              `json:"schoolId"`
+	SchoolName string              `json:"schoolName"`
+	Summary    *AttendanceSummary  `json:"summary"`
+	Records    []*AttendanceRecord `json:"records"`
+}
+
+// Returns the student's daily attendance between from and to at each school they are
+// enrolled at
+func (s *GuardianService) Attendance(ctx context.Context, id string, from Date, to Date) ([]*GuardianAttendance, error) {
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	if to.Sub(from.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return nil, fmt.Errorf("range must not exceed %d days", maxInstructionalDaysRange)
+	}
+
+	guardianship, err := s.portal(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if guardianship.AttendanceHidden {
+		return nil, forbidden("attendance is not shared with this guardian")
+	}
+
+	sections, err := s.sections(ctx, guardianship)
+
+	if err != nil {
+		return nil, err
+	}
+
+	attendance := []*GuardianAttendance{}
+
+	for _, section := range sections {
+		if slices.ContainsFunc(attendance, func(a *GuardianAttendance) bool { return a.SchoolID == section.school.ID }) {
+			continue
+		}
+
+		records, err := s.attendanceStore.ListRecords(ctx, &AttendanceFilter{SchoolID: section.school.ID, StudentUserID: guardianship.StudentUserID, From: from, To: to})
+
+		if err != nil {
+			slog.Error("failed to list attendance records", "error", err)
+			return nil, ErrInternal
+		}
+
+		if records == nil {
+			records = []*AttendanceRecord{}
+		}
+
+		summary := &AttendanceSummary{StudentUserID: guardianship.StudentUserID}
+
+		if summaries := summarizeAttendance(records); len(summaries) > 0 {
+			summary = summaries[0]
+		}
+
+		attendance = append(attendance, &GuardianAttendance{SchoolID: section.school.ID, SchoolName: section.school.Name, Summary: summary, Records: records})
+	}
+
+	return attendance, nil
+}
+
+type GuardianScheduleEntry struct {
+	SectionID    string           `json:"sectionId"`
+	SectionCode  string           `json:"sectionCode"`
+	CourseCode   string           `json:"courseCode"`
+	CourseTitle  string           `json:"courseTitle"`
+	SchoolName   string           `json:"schoolName"`
+	TermName     string           `json:"termName"`
+	StartDate    Date             `json:"startDate"`
+	EndDate      Date             `json:"endDate"`
+	Room         string           `json:"room"`
+	Meetings     []SectionMeeting `json:"meetings"`
+	Periods      []SectionPeriod  `json:"periods"`
+	TeacherNames []string         `json:"teacherNames"`
+}
+
+// Returns the student's current and upcoming classes
+func (s *GuardianService) Schedule(ctx context.Context, id string) ([]*GuardianScheduleEntry, error) {
+	guardianship, err := s.portal(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if guardianship.ScheduleHidden {
+		return nil, forbidden("the schedule is not shared with this guardian")
+	}
+
+	sections, err := s.sections(ctx, guardianship)
+
+	if err != nil {
+		return nil, err
+	}
+
+	today := DateOf(time.Now())
+	schedule := []*GuardianScheduleEntry{}
+
+	for _, section := range sections {
+		if section.term.EndDate.Before(today.Time) {
+			continue
+		}
+
+		entry := &GuardianScheduleEntry{
+			SectionID:    section.section.ID,
+			SectionCode:  section.section.Code,
+			CourseCode:   section.course.Code,
+			CourseTitle:  section.course.Title,
+			SchoolName:   section.school.Name,
+			TermName:     section.term.Name,
+			StartDate:    section.term.StartDate,
+			EndDate:      section.term.EndDate,
+			Room:         section.section.Room,
+			Meetings:     section.section.Meetings,
+			Periods:      section.section.Periods,
+			TeacherNames: []string{},
+		}
+
+		for _, teacher := range section.section.Teachers {
+			user, err := s.userStore.GetByID(ctx, teacher.UserID)
+
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				slog.Error("failed to get user", "error", err)
+				return nil, ErrInternal
+			}
+
+			if user != nil {
+				entry.TeacherNames = append(entry.TeacherNames, user.FirstName+" "+user.LastName)
+			}
+		}
+
+		schedule = append(schedule, entry)
+	}
+
+	return schedule, nil
+}
+
+type GuardianHandler struct {
+	guardianService *GuardianService
+}
+
+func (h *GuardianHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request CreateGuardianshipRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	request.ID = ""
+	request.OrganizationID = r.PathValue("id")
+
+	guardianship, err := h.guardianService.Create(r.Context(), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, guardianship)
+}
+
+func (h *GuardianHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter := &GuardianshipFilter{
+		GuardianUserID: r.URL.Query().Get("guardianUserId"),
+		StudentUserID:  r.URL.Query().Get("studentUserId"),
+	}
+
+	guardianships, err := h.guardianService.List(r.Context(), r.PathValue("id"), filter)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, guardianships)
+}
+
+func (h *GuardianHandler) Get(w http.ResponseWriter, r *http.Request) {
+	guardianship, err := h.guardianService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, guardianship)
+}
+
+func (h *GuardianHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateGuardianshipRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	guardianship, err := h.guardianService.Update(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, guardianship)
+}
+
+func (h *GuardianHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.guardianService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GuardianHandler) ListStudents(w http.ResponseWriter, r *http.Request) {
+	students, err := h.guardianService.ListStudents(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, students)
+}
+
+func (h *GuardianHandler) Grades(w http.ResponseWriter, r *http.Request) {
+	grades, err := h.guardianService.Grades(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, grades)
+}
+
+func (h *GuardianHandler) Attendance(w http.ResponseWriter, r *http.Request) {
+	to, err := dateQueryParam(r, "to", DateOf(time.Now()))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	from, err := dateQueryParam(r, "from", to.AddDays(-30))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	attendance, err := h.guardianService.Attendance(r.Context(), r.PathValue("id"), from, to)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, attendance)
+}
+
+func (h *GuardianHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.guardianService.Schedule(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
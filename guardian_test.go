@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockGuardianStore struct {
+	CreateFunc  func(ctx context.Context, guardianship *Guardianship) error
+	GetByIDFunc func(ctx context.Context, id string) (*Guardianship, error)
+	ListFunc    func(ctx context.Context, filter *GuardianshipFilter) ([]*Guardianship, error)
+	UpdateFunc  func(ctx context.Context, guardianship *Guardianship) error
+	DeleteFunc  func(ctx context.Context, id string) error
+}
+
+func (m *MockGuardianStore) Create(ctx context.Context, guardianship *Guardianship) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, guardianship)
+	}
+
+	return nil
+}
+
+func (m *MockGuardianStore) GetByID(ctx context.Context, id string) (*Guardianship, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGuardianStore) List(ctx context.Context, filter *GuardianshipFilter) ([]*Guardianship, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockGuardianStore) Update(ctx context.Context, guardianship *Guardianship) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, guardianship)
+	}
+
+	return nil
+}
+
+func (m *MockGuardianStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// The student's parent, who does not belong to the organization
+func parentOfStudent() *Guardianship {
+	return &Guardianship{ID: "guardianship", OrganizationID: "org", GuardianUserID: "parent", StudentUserID: "student", Relationship: GuardianRelationshipParent, ContactAllowed: true}
+}
+
+func linkedParent(guardianship *Guardianship) *MockGuardianStore {
+	return &MockGuardianStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Guardianship, error) {
+			if id != guardianship.ID {
+				return nil, nil
+			}
+
+			copied := *guardianship
+			return &copied, nil
+		},
+		ListFunc: func(ctx context.Context, filter *GuardianshipFilter) ([]*Guardianship, error) {
+			if filter.GuardianUserID != "" && filter.GuardianUserID != guardianship.GuardianUserID {
+				return nil, nil
+			}
+
+			return []*Guardianship{guardianship}, nil
+		},
+	}
+}
+
+func guardianUsers() *MockUserStore {
+	names := map[string][2]string{
+		"parent":  {"Pat", "Parent"},
+		"student": {"Sam", "Student"},
+		"teacher": {"Tess", "Teacher"},
+	}
+
+	return &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			name, ok := names[id]
+
+			if !ok {
+				return nil, sql.ErrNoRows
+			}
+
+			return &User{ID: id, FirstName: name[0], LastName: name[1]}, nil
+		},
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			if email != "pat@example.com" {
+				return nil, sql.ErrNoRows
+			}
+
+			return &User{ID: "parent", FirstName: "Pat", LastName: "Parent", Email: email}, nil
+		},
+	}
+}
+
+// A term that runs from a month ago to two months from now, so it is always current
+func currentTerm() *MockCalendarStore {
+	today := DateOf(time.Now())
+
+	return &MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", Name: "Fall", StartDate: today.AddDays(-30), EndDate: today.AddDays(60)}, nil
+		},
+	}
+}
+
+func TestValidateGuardianship_ReturnsErrorForStudentAsOwnGuardian(t *testing.T) {
+	guardianship := parentOfStudent()
+	guardianship.GuardianUserID = "student"
+
+	err := validateGuardianship(guardianship)
+
+	assert.Error(t, err)
+	assert.Equal(t, "students cannot be their own guardian", err.Error())
+}
+
+func TestValidateGuardianship_ReturnsErrorForEmergencyContactWithoutContact(t *testing.T) {
+	guardianship := parentOfStudent()
+	guardianship.ContactAllowed = false
+	guardianship.EmergencyContact = true
+
+	err := validateGuardianship(guardianship)
+
+	assert.Error(t, err)
+	assert.Equal(t, "emergency contacts must allow contact", err.Error())
+}
+
+func TestGuardianService_Create_FindsGuardianByEmail(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(&MockGuardianStore{}, guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianship, err := guardianService.Create(sessionContext("admin"), &CreateGuardianshipRequest{
+		Guardianship:  Guardianship{OrganizationID: "org", StudentUserID: "student", Relationship: GuardianRelationshipParent, PickupAuthorized: true},
+		GuardianEmail: "pat@example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "parent", guardianship.GuardianUserID)
+	assert.True(t, guardianship.PickupAuthorized)
+}
+
+func TestGuardianService_Create_ReturnsNotFoundForUnknownEmail(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(&MockGuardianStore{}, guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianship, err := guardianService.Create(sessionContext("admin"), &CreateGuardianshipRequest{
+		Guardianship:  Guardianship{OrganizationID: "org", StudentUserID: "student", Relationship: GuardianRelationshipParent},
+		GuardianEmail: "nobody@example.com",
+	})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, guardianship)
+}
+
+func TestGuardianService_Create_ReturnsErrorForTeacher(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(&MockGuardianStore{}, guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianship, err := guardianService.Create(sessionContext("teacher"), &CreateGuardianshipRequest{Guardianship: *parentOfStudent()})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, guardianship)
+}
+
+func TestGuardianService_Create_ReturnsErrorForNonStudent(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(&MockGuardianStore{}, guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+	request := &CreateGuardianshipRequest{Guardianship: *parentOfStudent()}
+	request.StudentUserID = "teacher"
+
+	guardianship, err := guardianService.Create(sessionContext("admin"), request)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, guardianship)
+}
+
+func TestGuardianService_Create_ReturnsErrorForExistingLink(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianship, err := guardianService.Create(sessionContext("admin"), &CreateGuardianshipRequest{Guardianship: *parentOfStudent()})
+
+	assert.Error(t, err)
+	assert.Equal(t, "guardian is already linked to the student", err.Error())
+	assert.Nil(t, guardianship)
+}
+
+func TestGuardianService_List_AllowsStudentToListOwnGuardians(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianships, err := guardianService.List(sessionContext("student"), "org", &GuardianshipFilter{StudentUserID: "student"})
+
+	assert.NoError(t, err)
+	assert.Len(t, guardianships, 1)
+}
+
+func TestGuardianService_List_ReturnsErrorForOtherStudent(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianships, err := guardianService.List(sessionContext("other-student"), "org", &GuardianshipFilter{StudentUserID: "student"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, guardianships)
+}
+
+func TestGuardianService_GetByID_AllowsGuardianOutsideOrganization(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	guardianship, err := guardianService.GetByID(sessionContext("parent"), "guardianship")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "student", guardianship.StudentUserID)
+}
+
+func TestGuardianService_Update_HidesGrades(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+	hidden := true
+
+	guardianship, err := guardianService.Update(sessionContext("admin"), "guardianship", &UpdateGuardianshipRequest{GradesHidden: &hidden})
+
+	assert.NoError(t, err)
+	assert.True(t, guardianship.GradesHidden)
+	assert.True(t, guardianship.ContactAllowed)
+}
+
+func TestGuardianService_ListStudents_ReturnsLinkedStudents(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	students, err := guardianService.ListStudents(sessionContext("parent"), "parent")
+
+	assert.NoError(t, err)
+	assert.Len(t, students, 1)
+	assert.Equal(t, "Sam", students[0].FirstName)
+}
+
+func TestGuardianService_ListStudents_SkipsDeletedStudents(t *testing.T) {
+	deleted := parentOfStudent()
+	deleted.StudentUserID = "deleted-student"
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(deleted), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	students, err := guardianService.ListStudents(sessionContext("parent"), "parent")
+
+	assert.NoError(t, err)
+	assert.Empty(t, students)
+}
+
+func TestGuardianService_ListStudents_ReturnsErrorForOtherUser(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	students, err := guardianService.ListStudents(sessionContext("admin"), "parent")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, students)
+}
+
+func TestGuardianService_Grades_ReturnsStudentsGradebook(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	grades, err := guardianService.Grades(sessionContext("parent"), "guardianship")
+
+	assert.NoError(t, err)
+	assert.Len(t, grades, 1)
+	assert.Equal(t, "MATH201", grades[0].CourseCode)
+	assert.Len(t, grades[0].Gradebook.Students, 1)
+	assert.Equal(t, "student", grades[0].Gradebook.Students[0].StudentUserID)
+}
+
+func TestGuardianService_Grades_ReturnsErrorWhenHidden(t *testing.T) {
+	guardianship := parentOfStudent()
+	guardianship.GradesHidden = true
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(guardianship), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	grades, err := guardianService.Grades(sessionContext("parent"), "guardianship")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, grades)
+}
+
+func TestGuardianService_Grades_ReturnsErrorForStudent(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	grades, err := guardianService.Grades(sessionContext("student"), "guardianship")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, grades)
+}
+
+func TestGuardianService_Grades_ReturnsErrorForFormerStudent(t *testing.T) {
+	guardianship := parentOfStudent()
+	guardianship.StudentUserID = "former-student"
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(guardianship), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	grades, err := guardianService.Grades(sessionContext("parent"), "guardianship")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, grades)
+}
+
+func TestGuardianService_Attendance_SummarizesDailyAttendance(t *testing.T) {
+	var filters []*AttendanceFilter
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{
+		ListRecordsFunc: func(ctx context.Context, filter *AttendanceFilter) ([]*AttendanceRecord, error) {
+			filters = append(filters, filter)
+			return attendanceDays("student", 3, 1), nil
+		},
+	}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	attendance, err := guardianService.Attendance(sessionContext("parent"), "guardianship", schoolDay, schoolDay.AddDays(6))
+
+	assert.NoError(t, err)
+	assert.Len(t, attendance, 1)
+	assert.Equal(t, "North High", attendance[0].SchoolName)
+	assert.Equal(t, 1, attendance[0].Summary.Absent)
+	assert.Len(t, attendance[0].Records, 4)
+	assert.Equal(t, "", filters[0].SectionID)
+	assert.Equal(t, "student", filters[0].StudentUserID)
+}
+
+func TestGuardianService_Schedule_NamesTeachers(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	schedule, err := guardianService.Schedule(sessionContext("parent"), "guardianship")
+
+	assert.NoError(t, err)
+	assert.Len(t, schedule, 1)
+	assert.Equal(t, []string{"Tess Teacher"}, schedule[0].TeacherNames)
+}
+
+func TestGuardianService_Schedule_SkipsDeletedTeachers(t *testing.T) {
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(parentOfStudent()), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+	users := guardianUsers()
+	getByID := users.GetByIDFunc
+	users.GetByIDFunc = func(ctx context.Context, id string) (*User, error) {
+		if id == "teacher" {
+			return nil, sql.ErrNoRows
+		}
+
+		return getByID(ctx, id)
+	}
+	guardianService.userStore = users
+
+	schedule, err := guardianService.Schedule(sessionContext("parent"), "guardianship")
+
+	assert.NoError(t, err)
+	assert.Len(t, schedule, 1)
+	assert.Empty(t, schedule[0].TeacherNames)
+}
+
+func TestGuardianService_Schedule_ReturnsErrorWhenHidden(t *testing.T) {
+	guardianship := parentOfStudent()
+	guardianship.ScheduleHidden = true
+	gradebookService := NewGradebookService(&MockGradebookStore{}, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	guardianService := NewGuardianService(linkedParent(guardianship), guardianUsers(), orgMembers(), existingSchool(), currentTerm(), existingSection(), enrolledStudent(), mathCatalog(), &MockAttendanceStore{}, gradebookService, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	schedule, err := guardianService.Schedule(sessionContext("parent"), "guardianship")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, schedule)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const impersonationDuration = time.Hour
+
+const (
+	AuditActionImpersonationStart = "impersonation.start"
+	AuditActionImpersonationStop  = "impersonation.stop"
+)
+
+// Rejects the request if the session in ctx belongs to an admin acting as another user
+func rejectImpersonation(ctx context.Context) error {
+	if session, ok := SessionFromContext(ctx); ok && session.IsImpersonation() {
+		return forbidden("action not allowed while impersonating a user")
+	}
+
+	return nil
+}
+
+type ImpersonationService struct {
+	sessionService *SessionService
+	memberStore    OrganizationMemberStore
+	auditStore     AuditStore
+}
+
+func NewImpersonationService(sessionService *SessionService, memberStore OrganizationMemberStore, auditStore AuditStore) *ImpersonationService {
+	return &ImpersonationService{sessionService: sessionService, memberStore: memberStore, auditStore: auditStore}
+}
+
+type StartImpersonationRequest struct {
+	OrganizationID string `json:"organizationId"`
+	UserID         string `json:"userId"`
+}
+
+// Issues a short-lived session acting as another member of an organization the actor administers
+func (s *ImpersonationService) Start(ctx context.Context, actor *Session, request *StartImpersonationRequest) (*CreateSessionResponse, error) {
+	if actor.IsImpersonation() {
+		return nil, errors.New("cannot start an impersonation while impersonating")
+	}
+
+	if request.OrganizationID == "" {
+		return nil, errors.New("organization id is required")
+	}
+
+	if request.UserID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	if request.UserID == actor.UserID {
+		return nil, errors.New("cannot impersonate yourself")
+	}
+
+	actorMember, err := s.memberStore.Get(ctx, request.OrganizationID, actor.UserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return nil, ErrInternal
+	}
+
+	if actorMember == nil || actorMember.Role != RoleAdmin {
+		return nil, forbidden("only organization admins can impersonate users")
+	}
+
+	targetMember, err := s.memberStore.Get(ctx, request.OrganizationID, request.UserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return nil, ErrInternal
+	}
+
+	if targetMember == nil {
+		return nil, notFound("organization member")
+	}
+
+	if targetMember.Role == RoleAdmin {
+		return nil, forbidden("cannot impersonate another organization admin")
+	}
+
+	entry := &AuditEntry{
+		OrganizationID:     request.OrganizationID,
+		ActorUserID:        request.UserID,
+		ImpersonatorUserID: actor.UserID,
+		Action:             AuditActionImpersonationStart,
+		EntityType:         "user",
+		EntityID:           request.UserID,
+		CreatedAt:          time.Now(),
+	}
+
+	entry.attachRequestMetadata(ctx)
+
+	if err := s.auditStore.Create(ctx, entry); err != nil {
+		slog.Error("failed to write audit entry", "error", err)
+		return nil, ErrInternal
+	}
+
+	return s.sessionService.issue(ctx, &Session{
+		UserID:             request.UserID,
+		ImpersonatorUserID: actor.UserID,
+		OrganizationID:     request.OrganizationID,
+	}, impersonationDuration)
+}
+
+// Ends an impersonation session. The impersonator's own session is left untouched.
+func (s *ImpersonationService) Stop(ctx context.Context, session *Session) error {
+	if !session.IsImpersonation() {
+		return errors.New("session is not an impersonation")
+	}
+
+	if err := s.sessionService.Delete(ctx, session.ID); err != nil {
+		return err
+	}
+
+	entry := &AuditEntry{
+		OrganizationID:     session.OrganizationID,
+		ActorUserID:        session.UserID,
+		ImpersonatorUserID: session.ImpersonatorUserID,
+		Action:             AuditActionImpersonationStop,
+		EntityType:         "user",
+		EntityID:           session.UserID,
+		CreatedAt:          time.Now(),
+	}
+
+	entry.attachRequestMetadata(ctx)
+
+	if err := s.auditStore.Create(ctx, entry); err != nil {
+		slog.Error("failed to write audit entry", "error", err)
+		return ErrInternal
+	}
+
+	return nil
+}
+
+type ImpersonationHandler struct {
+	impersonationService *ImpersonationService
+}
+
+func (h *ImpersonationHandler) Start(w http.ResponseWriter, r *http.Request) {
+	var request StartImpersonationRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	session, _ := SessionFromContext(r.Context())
+
+	response, err := h.impersonationService.Start(r.Context(), session, &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func (h *ImpersonationHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	session, _ := SessionFromContext(r.Context())
+
+	if err := h.impersonationService.Stop(r.Context(), session); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
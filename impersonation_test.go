@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockOrganizationMemberStore struct {
+	CreateFunc             func(ctx context.Context, member *OrganizationMember) error
+	GetFunc                func(ctx context.Context, organizationID string, userID string) (*OrganizationMember, error)
+	ListByUserFunc         func(ctx context.Context, userID string) ([]*OrganizationMember, error)
+	ListByOrganizationFunc func(ctx context.Context, organizationID string) ([]*OrganizationMember, error)
+	ListActivePageFunc     func(ctx context.Context, organizationID string, limit int, offset int) ([]*OrganizationMember, int, error)
+	DeleteFunc             func(ctx context.Context, organizationID string, userID string) error
+}
+
+func (m *MockOrganizationMemberStore) Create(ctx context.Context, member *OrganizationMember) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, member)
+	}
+
+	return nil
+}
+
+func (m *MockOrganizationMemberStore) Get(ctx context.Context, organizationID string, userID string) (*OrganizationMember, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, organizationID, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOrganizationMemberStore) ListByUser(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(ctx, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOrganizationMemberStore) ListByOrganization(ctx context.Context, organizationID string) ([]*OrganizationMember, error) {
+	if m.ListByOrganizationFunc != nil {
+		return m.ListByOrganizationFunc(ctx, organizationID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOrganizationMemberStore) ListActivePage(ctx context.Context, organizationID string, limit int, offset int) ([]*OrganizationMember, int, error) {
+	if m.ListActivePageFunc != nil {
+		return m.ListActivePageFunc(ctx, organizationID, limit, offset)
+	}
+
+	return nil, 0, nil
+}
+
+func (m *MockOrganizationMemberStore) Delete(ctx context.Context, organizationID string, userID string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, organizationID, userID)
+	}
+
+	return nil
+}
+
+// Returns a member store where user "admin" administers organization "org", user "teacher" teaches
+// there and users "student" and "other-student" study there
+func orgMembers() *MockOrganizationMemberStore {
+	return &MockOrganizationMemberStore{
+		GetFunc: func(ctx context.Context, organizationID string, userID string) (*OrganizationMember, error) {
+			if organizationID != "org" {
+				return nil, nil
+			}
+
+			switch userID {
+			case "admin", "other-admin":
+				return &OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: RoleAdmin}, nil
+			case "teacher":
+				return &OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: RoleTeacher}, nil
+			case "student", "other-student":
+				return &OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: RoleStudent}, nil
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+func TestImpersonationService_Start_ReturnsErrorForNonAdmin(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{})
+
+	response, err := impersonationService.Start(context.Background(), &Session{UserID: "teacher"}, &StartImpersonationRequest{
+		OrganizationID: "org",
+		UserID:         "student",
+	})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, response)
+}
+
+func TestImpersonationService_Start_ReturnsErrorForTargetOutsideOrganization(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{})
+
+	response, err := impersonationService.Start(context.Background(), &Session{UserID: "admin"}, &StartImpersonationRequest{
+		OrganizationID: "org",
+		UserID:         "stranger",
+	})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, response)
+}
+
+func TestImpersonationService_Start_ReturnsErrorForOtherAdmin(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{})
+
+	response, err := impersonationService.Start(context.Background(), &Session{UserID: "admin"}, &StartImpersonationRequest{
+		OrganizationID: "org",
+		UserID:         "other-admin",
+	})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, response)
+}
+
+func TestImpersonationService_Start_ReturnsErrorWhenAlreadyImpersonating(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{})
+
+	response, err := impersonationService.Start(context.Background(), &Session{UserID: "teacher", ImpersonatorUserID: "admin"}, &StartImpersonationRequest{
+		OrganizationID: "org",
+		UserID:         "teacher",
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "cannot start an impersonation while impersonating", err.Error())
+	assert.Nil(t, response)
+}
+
+func TestImpersonationService_Start_ReturnsErrorForFailingToWriteAuditEntry(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{
+		CreateFunc: func(ctx context.Context, entry *AuditEntry) error {
+			return errors.New("random error")
+		},
+	})
+
+	response, err := impersonationService.Start(context.Background(), &Session{UserID: "admin"}, &StartImpersonationRequest{
+		OrganizationID: "org",
+		UserID:         "teacher",
+	})
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Nil(t, response)
+}
+
+func TestImpersonationService_Start_IssuesSessionAndWritesAuditEntry(t *testing.T) {
+	var entry *AuditEntry
+
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	})
+
+	response, err := impersonationService.Start(context.Background(), &Session{UserID: "admin"}, &StartImpersonationRequest{
+		OrganizationID: "org",
+		UserID:         "teacher",
+	})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, "teacher", response.Session.UserID)
+	assert.Equal(t, "admin", response.Session.ImpersonatorUserID)
+	assert.Equal(t, "org", response.Session.OrganizationID)
+	assert.Equal(t, AuditActionImpersonationStart, entry.Action)
+	assert.Equal(t, "admin", entry.ImpersonatorUserID)
+	assert.Equal(t, "teacher", entry.EntityID)
+}
+
+func TestImpersonationService_Stop_ReturnsErrorForRegularSession(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{})
+
+	err := impersonationService.Stop(context.Background(), &Session{ID: "1", UserID: "admin"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "session is not an impersonation", err.Error())
+}
+
+func TestImpersonationService_Stop_DeletesSessionAndWritesAuditEntry(t *testing.T) {
+	var deletedID string
+	var entry *AuditEntry
+
+	sessionService := NewSessionService(&MockSessionStore{
+		DeleteFunc: func(ctx context.Context, id string) error {
+			deletedID = id
+			return nil
+		},
+	}, &MockUserStore{})
+	impersonationService := NewImpersonationService(sessionService, orgMembers(), &MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	})
+
+	err := impersonationService.Stop(context.Background(), &Session{ID: "1", UserID: "teacher", ImpersonatorUserID: "admin", OrganizationID: "org"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", deletedID)
+	assert.Equal(t, AuditActionImpersonationStop, entry.Action)
+}
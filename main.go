@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	// Schools name IANA time zones, which must load on hosts without a zone database
+	_ "time/tzdata"
 )
 
 func main() {
@@ -16,9 +20,341 @@ func main() {
 
 	defer db.pool.Close()
 
+	trustedProxies, err := trustedProxiesFromEnv()
+
+	if err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+
+	key := signingKey()
+	blobStore, err := blobStoreFromEnv(key)
+
+	if err != nil {
+		log.Fatalf("Failed to configure blob storage: %v", err)
+	}
+
+	userStore := &UserPostgresStore{db: db}
+	sessionStore := &SessionPostgresStore{db: db}
+	memberStore := &OrganizationMemberPostgresStore{db: db}
+	auditStore := &AuditPostgresStore{db: db}
+	organizationStore := &OrganizationPostgresStore{db: db}
+	schoolStore := &SchoolPostgresStore{db: db}
+	dataExportStore := &DataExportPostgresStore{db: db}
+	userImportStore := &UserImportPostgresStore{db: db}
+	calendarStore := &CalendarPostgresStore{db: db}
+	courseStore := &CoursePostgresStore{db: db}
+	sectionStore := &SectionPostgresStore{db: db}
+	enrollmentStore := &EnrollmentPostgresStore{db: db}
+	gradebookStore := &GradebookPostgresStore{db: db}
+	submissionStore := &SubmissionPostgresStore{db: db}
+	attendanceStore := &AttendancePostgresStore{db: db}
+	academicDocumentStore := &AcademicDocumentPostgresStore{db: db}
+	gpaStore := &GPAPostgresStore{db: db}
+	graduationStore := &GraduationPostgresStore{db: db}
+	scheduleStore := &SchedulePostgresStore{db: db}
+	bellStore := &BellPostgresStore{db: db}
+	resourceStore := &ResourcePostgresStore{db: db}
+	calendarFeedStore := &CalendarFeedPostgresStore{db: db}
+	guardianStore := &GuardianPostgresStore{db: db}
+	studentProfileStore := &StudentProfilePostgresStore{db: db}
+	staffProfileStore := &StaffProfilePostgresStore{db: db}
+	oneRosterStore := &OneRosterPostgresStore{db: db}
+
+	auditService := NewAuditService(auditStore, memberStore)
+	userService := NewUserService(userStore, memberStore, auditService)
+	userImportService := NewUserImportService(userImportStore, userService, memberStore)
+	sessionService := NewSessionService(sessionStore, userStore)
+	impersonationService := NewImpersonationService(sessionService, memberStore, auditStore)
+	organizationService := NewOrganizationService(organizationStore, memberStore, auditService)
+	schoolService := NewSchoolService(schoolStore, memberStore, auditService)
+	calendarService := NewCalendarService(calendarStore, schoolStore, memberStore, auditService)
+	bellService := NewBellService(bellStore, calendarStore, sectionStore, schoolStore, memberStore, auditService)
+	resourceService := NewResourceService(resourceStore, bellStore, calendarStore, sectionStore, schoolStore, memberStore, auditService)
+	courseService := NewCourseService(courseStore, sectionStore, gpaStore, memberStore, auditService)
+	sectionService := NewSectionService(sectionStore, enrollmentStore, courseStore, calendarStore, bellStore, schoolStore, memberStore, auditService)
+	enrollmentService := NewEnrollmentService(enrollmentStore, sectionStore, courseStore, calendarStore, gpaStore, schoolStore, memberStore, auditService)
+	gradebookService := NewGradebookService(gradebookStore, sectionStore, enrollmentStore, gpaStore, schoolStore, memberStore, auditService)
+	submissionService := NewSubmissionService(submissionStore, gradebookService, memberStore, blobStore, auditService)
+	gpaService := NewGPAService(gpaStore, gradebookService, enrollmentStore, sectionStore, courseStore, calendarStore, schoolStore, memberStore, auditService)
+	graduationService := NewGraduationService(graduationStore, enrollmentStore, sectionStore, courseStore, schoolStore, memberStore, auditService)
+	scheduleService := NewScheduleService(scheduleStore, sectionStore, enrollmentStore, courseStore, calendarStore, schoolStore, memberStore, auditService)
+	calendarFeedService := NewCalendarFeedService(calendarFeedStore, userStore, memberStore, schoolStore, calendarStore, bellStore, sectionStore, enrollmentStore, courseStore, gradebookStore)
+	attendanceService := NewAttendanceService(attendanceStore, sectionStore, enrollmentStore, calendarStore, bellStore, schoolStore, memberStore, auditService)
+	studentProfileService := NewStudentProfileService(studentProfileStore, schoolStore, memberStore, auditService)
+	staffProfileService := NewStaffProfileService(staffProfileStore, userStore, schoolStore, memberStore, auditService)
+	oneRosterService := NewOneRosterService(oneRosterStore, organizationStore, schoolStore, calendarStore, courseStore, sectionStore, enrollmentStore, userStore, memberStore, auditService)
+	guardianService := NewGuardianService(guardianStore, userStore, memberStore, schoolStore, calendarStore, sectionStore, enrollmentStore, courseStore, attendanceStore, gradebookService, auditService)
+	academicDocumentService := NewAcademicDocumentService(academicDocumentStore, gradebookService, gpaService, attendanceService, enrollmentStore, sectionStore, courseStore, calendarStore, schoolStore, userStore, memberStore, blobStore, documentSigningKey(), auditService)
+	dataExportService := NewDataExportService(dataExportStore, userStore, memberStore, auditService, blobStore)
+	dataExportService.AddSection("guardianships", guardianshipDataExport(guardianStore))
+	dataExportService.AddSection("student profiles", studentProfileDataExport(studentProfileStore))
+	dataExportService.AddSection("staff profiles", staffProfileDataExport(staffProfileStore))
+	dataExportService.AddSection("enrollments", enrollmentDataExport(enrollmentStore))
+	dataExportService.AddSection("grades", gradeDataExport(gradebookStore))
+	dataExportService.AddSection("submissions", submissionDataExport(submissionStore))
+	dataExportService.AddSection("attendance", attendanceDataExport(attendanceStore))
+	dataExportService.AddSection("academic documents", academicDocumentDataExport(academicDocumentStore))
+	erasureService := NewErasureService(userStore, sessionStore, organizationStore, memberStore, dataExportStore, blobStore, auditService)
+	erasureService.AddEraser(calendarFeedStore.Delete)
+	erasureService.AddEraser(studentProfileStore.DeleteProfiles)
+	erasureService.AddEraser(staffProfileStore.DeleteByUser)
+	purgeService := NewPurgeService(userStore, organizationStore, schoolStore, erasureService, softDeleteRetention())
+
+	if len(os.Args) > 1 && os.Args[1] == "import-users" {
+		if err := runUserImportCommand(context.Background(), os.Args[2:], userService, organizationStore, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	userHandler := &UserHandler{userService: userService}
+	userImportHandler := &UserImportHandler{userImportService: userImportService}
+	sessionHandler := &SessionHandler{sessionService: sessionService}
+	impersonationHandler := &ImpersonationHandler{impersonationService: impersonationService}
+	auditHandler := &AuditHandler{auditService: auditService}
+	organizationHandler := &OrganizationHandler{organizationService: organizationService}
+	schoolHandler := &SchoolHandler{schoolService: schoolService}
+	calendarHandler := &CalendarHandler{calendarService: calendarService}
+	bellHandler := &BellHandler{bellService: bellService}
+	resourceHandler := &ResourceHandler{resourceService: resourceService}
+	courseHandler := &CourseHandler{courseService: courseService}
+	sectionHandler := &SectionHandler{sectionService: sectionService}
+	enrollmentHandler := &EnrollmentHandler{enrollmentService: enrollmentService}
+	gradebookHandler := &GradebookHandler{gradebookService: gradebookService}
+	submissionHandler := &SubmissionHandler{submissionService: submissionService}
+	gpaHandler := &GPAHandler{gpaService: gpaService}
+	graduationHandler := &GraduationHandler{graduationService: graduationService}
+	scheduleHandler := &ScheduleHandler{scheduleService: scheduleService}
+	calendarFeedHandler := &CalendarFeedHandler{feedService: calendarFeedService}
+	studentProfileHandler := &StudentProfileHandler{profileService: studentProfileService}
+	staffProfileHandler := &StaffProfileHandler{profileService: staffProfileService}
+	oneRosterHandler := &OneRosterHandler{oneRosterService: oneRosterService}
+	guardianHandler := &GuardianHandler{guardianService: guardianService}
+	attendanceHandler := &AttendanceHandler{attendanceService: attendanceService}
+	academicDocumentHandler := &AcademicDocumentHandler{documentService: academicDocumentService}
+	blobHandler := &BlobHandler{blobStore: blobStore, signingKey: key}
+	dataExportHandler := &DataExportHandler{dataExportService: dataExportService}
+	erasureHandler := &ErasureHandler{erasureService: erasureService}
+
 	mux.Handle("GET /health", http.HandlerFunc(HealthHandler))
 
-	muxWithMiddleware := AttachGlobalMiddleware(mux, AttachContentTypeJSON)
+	mux.Handle("POST /users", http.HandlerFunc(userHandler.Create))
+	mux.Handle("GET /users/{id}", RequireSession(userHandler.Get))
+	mux.Handle("PATCH /users/{id}", RequireSession(userHandler.Update))
+	mux.Handle("PUT /users/{id}/password", RequireSession(userHandler.UpdatePassword))
+	mux.Handle("PUT /users/{id}/email", RequireSession(userHandler.UpdateEmail))
+	mux.Handle("DELETE /users/{id}", RequireSession(userHandler.Delete))
+	mux.Handle("POST /users/{id}/restore", RequireSession(userHandler.Restore))
+	mux.Handle("POST /users/{id}/exports", RequireSession(dataExportHandler.Create))
+	mux.Handle("POST /users/{id}/erasure", RequireSession(erasureHandler.Erase))
+
+	mux.Handle("GET /exports/{id}", RequireSession(dataExportHandler.Get))
+	mux.Handle("GET /blobs/{key...}", http.HandlerFunc(blobHandler.Download))
+
+	mux.Handle("POST /sessions", http.HandlerFunc(sessionHandler.Create))
+	mux.Handle("GET /sessions/current", RequireSession(sessionHandler.Current))
+	mux.Handle("DELETE /sessions/current", RequireSession(sessionHandler.Delete))
+
+	mux.Handle("POST /impersonations", RequireSession(impersonationHandler.Start))
+	mux.Handle("DELETE /impersonations/current", RequireSession(impersonationHandler.Stop))
+
+	mux.Handle("POST /organizations", RequireSession(organizationHandler.Create))
+	mux.Handle("GET /organizations/{id}", RequireSession(organizationHandler.Get))
+	mux.Handle("PATCH /organizations/{id}", RequireSession(organizationHandler.Update))
+	mux.Handle("DELETE /organizations/{id}", RequireSession(organizationHandler.Delete))
+	mux.Handle("POST /organizations/{id}/restore", RequireSession(organizationHandler.Restore))
+	mux.Handle("POST /organizations/{id}/user-imports", RequireSession(userImportHandler.Create))
+	mux.Handle("GET /organizations/{id}/user-imports/{importId}", RequireSession(userImportHandler.Get))
+
+	mux.Handle("POST /organizations/{organizationId}/schools", RequireSession(schoolHandler.Create))
+	mux.Handle("GET /organizations/{organizationId}/schools", RequireSession(schoolHandler.List))
+	mux.Handle("GET /schools/{id}", RequireSession(schoolHandler.Get))
+	mux.Handle("PATCH /schools/{id}", RequireSession(schoolHandler.Update))
+	mux.Handle("DELETE /schools/{id}", RequireSession(schoolHandler.Delete))
+	mux.Handle("POST /schools/{id}/restore", RequireSession(schoolHandler.Restore))
+
+	mux.Handle("POST /schools/{id}/academic-years", RequireSession(calendarHandler.CreateAcademicYear))
+	mux.Handle("GET /schools/{id}/academic-years", RequireSession(calendarHandler.ListAcademicYears))
+	mux.Handle("DELETE /academic-years/{id}", RequireSession(calendarHandler.DeleteAcademicYear))
+	mux.Handle("POST /schools/{id}/terms", RequireSession(calendarHandler.CreateTerm))
+	mux.Handle("GET /schools/{id}/terms", RequireSession(calendarHandler.ListTerms))
+	mux.Handle("GET /schools/{id}/terms/active", RequireSession(calendarHandler.ActiveTerms))
+	mux.Handle("DELETE /terms/{id}", RequireSession(calendarHandler.DeleteTerm))
+	mux.Handle("POST /schools/{id}/calendar-events", RequireSession(calendarHandler.CreateEvent))
+	mux.Handle("GET /schools/{id}/calendar-events", RequireSession(calendarHandler.ListEvents))
+	mux.Handle("DELETE /calendar-events/{id}", RequireSession(calendarHandler.DeleteEvent))
+	mux.Handle("GET /schools/{id}/instructional-days", RequireSession(calendarHandler.InstructionalDays))
+
+	mux.Handle("POST /schools/{id}/bell-schedules", RequireSession(bellHandler.CreateSchedule))
+	mux.Handle("GET /schools/{id}/bell-schedules", RequireSession(bellHandler.ListSchedules))
+	mux.Handle("GET /bell-schedules/{id}", RequireSession(bellHandler.GetSchedule))
+	mux.Handle("PATCH /bell-schedules/{id}", RequireSession(bellHandler.UpdateSchedule))
+	mux.Handle("DELETE /bell-schedules/{id}", RequireSession(bellHandler.DeleteSchedule))
+	mux.Handle("GET /schools/{id}/bell-rotation", RequireSession(bellHandler.GetRotation))
+	mux.Handle("PUT /schools/{id}/bell-rotation", RequireSession(bellHandler.SaveRotation))
+	mux.Handle("POST /schools/{id}/bell-overrides", RequireSession(bellHandler.CreateOverride))
+	mux.Handle("GET /schools/{id}/bell-overrides", RequireSession(bellHandler.ListOverrides))
+	mux.Handle("DELETE /bell-overrides/{id}", RequireSession(bellHandler.DeleteOverride))
+	mux.Handle("GET /schools/{id}/school-days", RequireSession(bellHandler.Days))
+
+	mux.Handle("POST /schools/{id}/resources", RequireSession(resourceHandler.CreateResource))
+	mux.Handle("GET /schools/{id}/resources", RequireSession(resourceHandler.ListResources))
+	mux.Handle("GET /resources/{id}", RequireSession(resourceHandler.GetResource))
+	mux.Handle("PATCH /resources/{id}", RequireSession(resourceHandler.UpdateResource))
+	mux.Handle("DELETE /resources/{id}", RequireSession(resourceHandler.DeleteResource))
+	mux.Handle("GET /resources/{id}/slots", RequireSession(resourceHandler.Slots))
+	mux.Handle("POST /resources/{id}/bookings", RequireSession(resourceHandler.CreateBooking))
+	mux.Handle("GET /schools/{id}/bookings", RequireSession(resourceHandler.ListBookings))
+	mux.Handle("GET /bookings/{id}", RequireSession(resourceHandler.GetBooking))
+	mux.Handle("POST /bookings/{id}/review", RequireSession(resourceHandler.ReviewBooking))
+	mux.Handle("POST /bookings/{id}/cancellation", RequireSession(resourceHandler.CancelBooking))
+
+	mux.Handle("POST /organizations/{organizationId}/courses", RequireSession(courseHandler.Create))
+	mux.Handle("GET /organizations/{organizationId}/courses", RequireSession(courseHandler.List))
+	mux.Handle("GET /courses/{id}", RequireSession(courseHandler.Get))
+	mux.Handle("PATCH /courses/{id}", RequireSession(courseHandler.Update))
+	mux.Handle("DELETE /courses/{id}", RequireSession(courseHandler.Delete))
+
+	mux.Handle("POST /schools/{id}/sections", RequireSession(sectionHandler.Create))
+	mux.Handle("GET /schools/{id}/sections", RequireSession(sectionHandler.List))
+	mux.Handle("GET /sections/{id}", RequireSession(sectionHandler.Get))
+	mux.Handle("PATCH /sections/{id}", RequireSession(sectionHandler.Update))
+	mux.Handle("DELETE /sections/{id}", RequireSession(sectionHandler.Delete))
+	mux.Handle("GET /sections/{id}/timetable", RequireSession(bellHandler.SectionTimetable))
+
+	mux.Handle("GET /terms/{id}/course-requests", RequireSession(scheduleHandler.ListCourseRequests))
+	mux.Handle("GET /terms/{id}/course-requests/{studentId}", RequireSession(scheduleHandler.ListCourseRequests))
+	mux.Handle("PUT /terms/{id}/course-requests/{studentId}", RequireSession(scheduleHandler.SaveCourseRequests))
+	mux.Handle("POST /terms/{id}/schedule-builds", RequireSession(scheduleHandler.Build))
+	mux.Handle("GET /terms/{id}/schedule-builds", RequireSession(scheduleHandler.ListBuilds))
+	mux.Handle("GET /schedule-builds/{id}", RequireSession(scheduleHandler.GetBuild))
+	mux.Handle("POST /schedule-builds/{id}/apply", RequireSession(scheduleHandler.Apply))
+
+	mux.Handle("POST /sections/{id}/enrollments", RequireSession(enrollmentHandler.Create))
+	mux.Handle("GET /sections/{id}/enrollments", RequireSession(enrollmentHandler.ListBySection))
+	mux.Handle("GET /users/{id}/enrollments", RequireSession(enrollmentHandler.ListByStudent))
+	mux.Handle("POST /users/{id}/calendar-feed", RequireSession(calendarFeedHandler.Create))
+	mux.Handle("GET /users/{id}/calendar-feed", RequireSession(calendarFeedHandler.Get))
+	mux.Handle("DELETE /users/{id}/calendar-feed", RequireSession(calendarFeedHandler.Delete))
+	mux.Handle("GET /calendar-feeds/{token}/calendar.ics", http.HandlerFunc(calendarFeedHandler.Feed))
+	mux.Handle("GET /enrollments/{id}", RequireSession(enrollmentHandler.Get))
+	mux.Handle("DELETE /enrollments/{id}", RequireSession(enrollmentHandler.Drop))
+	mux.Handle("POST /enrollments/{id}/completion", RequireSession(enrollmentHandler.Complete))
+
+	mux.Handle("GET /schools/{id}/grading-scale", RequireSession(gradebookHandler.GetGradingScale))
+	mux.Handle("PUT /schools/{id}/grading-scale", RequireSession(gradebookHandler.SaveGradingScale))
+	mux.Handle("POST /sections/{id}/grade-categories", RequireSession(gradebookHandler.CreateCategory))
+	mux.Handle("GET /sections/{id}/grade-categories", RequireSession(gradebookHandler.ListCategories))
+	mux.Handle("PATCH /grade-categories/{id}", RequireSession(gradebookHandler.UpdateCategory))
+	mux.Handle("DELETE /grade-categories/{id}", RequireSession(gradebookHandler.DeleteCategory))
+	mux.Handle("POST /sections/{id}/assignments", RequireSession(gradebookHandler.CreateAssignment))
+	mux.Handle("GET /sections/{id}/assignments", RequireSession(gradebookHandler.ListAssignments))
+	mux.Handle("GET /assignments/{id}", RequireSession(gradebookHandler.GetAssignment))
+	mux.Handle("PATCH /assignments/{id}", RequireSession(gradebookHandler.UpdateAssignment))
+	mux.Handle("DELETE /assignments/{id}", RequireSession(gradebookHandler.DeleteAssignment))
+	mux.Handle("PUT /assignments/{id}/grades/{studentId}", RequireSession(gradebookHandler.SaveGrade))
+	mux.Handle("GET /sections/{id}/gradebook", RequireSession(gradebookHandler.Gradebook))
+	mux.Handle("GET /sections/{id}/grades/{studentId}", RequireSession(gradebookHandler.StudentGrades))
+
+	mux.Handle("GET /schools/{id}/gpa-policy", RequireSession(gpaHandler.GetPolicy))
+	mux.Handle("PUT /schools/{id}/gpa-policy", RequireSession(gpaHandler.SavePolicy))
+	mux.Handle("PUT /schools/{id}/grade-levels/{studentId}", RequireSession(gpaHandler.SetGradeLevel))
+	mux.Handle("POST /schools/{id}/gpa-recompute", RequireSession(gpaHandler.Recompute))
+	mux.Handle("GET /schools/{id}/gpas/{studentId}", RequireSession(gpaHandler.Standing))
+	mux.Handle("GET /schools/{id}/class-rank", RequireSession(gpaHandler.ClassRank))
+	mux.Handle("GET /schools/{id}/honor-rolls/{termId}", RequireSession(gpaHandler.HonorRolls))
+
+	mux.Handle("GET /organizations/{id}/graduation-requirements", RequireSession(graduationHandler.GetRequirements))
+	mux.Handle("PUT /organizations/{id}/graduation-requirements", RequireSession(graduationHandler.SaveRequirements))
+	mux.Handle("POST /organizations/{id}/test-scores/{studentId}", RequireSession(graduationHandler.RecordTestScore))
+	mux.Handle("GET /organizations/{id}/test-scores/{studentId}", RequireSession(graduationHandler.ListTestScores))
+	mux.Handle("DELETE /test-scores/{id}", RequireSession(graduationHandler.DeleteTestScore))
+	mux.Handle("GET /organizations/{id}/degree-audits/{studentId}", RequireSession(graduationHandler.Audit))
+
+	mux.Handle("POST /assignments/{id}/submissions", RequireSession(submissionHandler.Create))
+	mux.Handle("GET /assignments/{id}/submissions", RequireSession(submissionHandler.List))
+	mux.Handle("GET /submissions/{id}", RequireSession(submissionHandler.Get))
+	mux.Handle("GET /submissions/{id}/files/{fileId}", RequireSession(submissionHandler.DownloadFile))
+	mux.Handle("PUT /submissions/{id}/feedback", RequireSession(submissionHandler.Feedback))
+
+	mux.Handle("GET /schools/{id}/attendance-codes", RequireSession(attendanceHandler.GetCodes))
+	mux.Handle("PUT /schools/{id}/attendance-codes", RequireSession(attendanceHandler.SaveCodes))
+	mux.Handle("GET /schools/{id}/attendance/{date}", RequireSession(attendanceHandler.DailyAttendance))
+	mux.Handle("PUT /schools/{id}/attendance/{date}", RequireSession(attendanceHandler.TakeDailyAttendance))
+	mux.Handle("GET /sections/{id}/attendance/{date}", RequireSession(attendanceHandler.SectionAttendance))
+	mux.Handle("PUT /sections/{id}/attendance/{date}", RequireSession(attendanceHandler.TakeSectionAttendance))
+	mux.Handle("GET /schools/{id}/attendance-summaries/{studentId}", RequireSession(attendanceHandler.StudentSummary))
+	mux.Handle("GET /schools/{id}/chronic-absenteeism", RequireSession(attendanceHandler.ChronicAbsenteeism))
+
+	mux.Handle("GET /organizations/{id}/student-profiles", RequireSession(studentProfileHandler.ListProfiles))
+	mux.Handle("GET /organizations/{id}/students/{studentId}/profile", RequireSession(studentProfileHandler.GetProfile))
+	mux.Handle("PUT /organizations/{id}/students/{studentId}/profile", RequireSession(studentProfileHandler.SaveProfile))
+	mux.Handle("GET /organizations/{id}/students/{studentId}/school-enrollments", RequireSession(studentProfileHandler.ListSchoolEnrollments))
+	mux.Handle("POST /organizations/{id}/students/{studentId}/school-enrollments", RequireSession(studentProfileHandler.CreateSchoolEnrollment))
+	mux.Handle("PATCH /school-enrollments/{id}", RequireSession(studentProfileHandler.UpdateSchoolEnrollment))
+	mux.Handle("DELETE /school-enrollments/{id}", RequireSession(studentProfileHandler.DeleteSchoolEnrollment))
+
+	mux.Handle("GET /organizations/{id}/staff-profiles", RequireSession(staffProfileHandler.ListProfiles))
+	mux.Handle("GET /organizations/{id}/staff/{userId}/profile", RequireSession(staffProfileHandler.GetProfile))
+	mux.Handle("PUT /organizations/{id}/staff/{userId}/profile", RequireSession(staffProfileHandler.SaveProfile))
+	mux.Handle("GET /organizations/{id}/staff/{userId}/certifications", RequireSession(staffProfileHandler.ListCertifications))
+	mux.Handle("POST /organizations/{id}/staff/{userId}/certifications", RequireSession(staffProfileHandler.CreateCertification))
+	mux.Handle("PATCH /staff-certifications/{id}", RequireSession(staffProfileHandler.UpdateCertification))
+	mux.Handle("DELETE /staff-certifications/{id}", RequireSession(staffProfileHandler.DeleteCertification))
+	mux.Handle("GET /organizations/{id}/certification-alerts", RequireSession(staffProfileHandler.CertificationAlerts))
+	mux.Handle("GET /organizations/{id}/staff/{userId}/school-assignments", RequireSession(staffProfileHandler.ListAssignments))
+	mux.Handle("POST /organizations/{id}/staff/{userId}/school-assignments", RequireSession(staffProfileHandler.CreateAssignment))
+	mux.Handle("PATCH /staff-assignments/{id}", RequireSession(staffProfileHandler.UpdateAssignment))
+	mux.Handle("DELETE /staff-assignments/{id}", RequireSession(staffProfileHandler.DeleteAssignment))
+	mux.Handle("GET /schools/{id}/staff-assignments", RequireSession(staffProfileHandler.ListSchoolAssignments))
+
+	mux.Handle("POST /organizations/{id}/oneroster/import", RequireSession(oneRosterHandler.Import))
+	mux.Handle("GET /organizations/{id}/oneroster/export", RequireSession(oneRosterHandler.Export))
+	mux.Handle("POST /organizations/{id}/oneroster/clients", RequireSession(oneRosterHandler.CreateClient))
+	mux.Handle("GET /organizations/{id}/oneroster/clients", RequireSession(oneRosterHandler.ListClients))
+	mux.Handle("DELETE /oneroster-clients/{id}", RequireSession(oneRosterHandler.DeleteClient))
+
+	mux.Handle("POST /organizations/{id}/guardianships", RequireSession(guardianHandler.Create))
+	mux.Handle("GET /organizations/{id}/guardianships", RequireSession(guardianHandler.List))
+	mux.Handle("GET /guardianships/{id}", RequireSession(guardianHandler.Get))
+	mux.Handle("PATCH /guardianships/{id}", RequireSession(guardianHandler.Update))
+	mux.Handle("DELETE /guardianships/{id}", RequireSession(guardianHandler.Delete))
+	mux.Handle("GET /users/{id}/students", RequireSession(guardianHandler.ListStudents))
+	mux.Handle("GET /guardianships/{id}/grades", RequireSession(guardianHandler.Grades))
+	mux.Handle("GET /guardianships/{id}/attendance", RequireSession(guardianHandler.Attendance))
+	mux.Handle("GET /guardianships/{id}/schedule", RequireSession(guardianHandler.Schedule))
+
+	mux.Handle("POST /schools/{id}/academic-documents", RequireSession(academicDocumentHandler.Create))
+	mux.Handle("GET /schools/{id}/academic-documents", RequireSession(academicDocumentHandler.List))
+	mux.Handle("GET /academic-documents/{id}", RequireSession(academicDocumentHandler.Get))
+	mux.Handle("PATCH /academic-documents/{id}", RequireSession(academicDocumentHandler.Update))
+	mux.Handle("DELETE /academic-documents/{id}", RequireSession(academicDocumentHandler.Delete))
+	mux.Handle("POST /academic-documents/{id}/finalize", RequireSession(academicDocumentHandler.Finalize))
+	mux.Handle("GET /academic-documents/{id}/pdf", RequireSession(academicDocumentHandler.PDF))
+
+	mux.Handle("GET /audit", RequireSession(auditHandler.List))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go purgeService.Run(ctx)
+	go dataExportService.Run(ctx)
+	go userImportService.Run(ctx)
+	go gpaService.Run(ctx)
+
+	// OneRoster vendors authenticate with their own access tokens rather than sessions, so the
+	// REST API is served outside the session middleware
+	oneRosterMux := http.NewServeMux()
+	requireOneRosterToken := RequireOneRosterToken(oneRosterService)
+
+	oneRosterMux.Handle("POST /ims/oneroster/oauth/token", http.HandlerFunc(oneRosterHandler.Token))
+	oneRosterMux.Handle("GET "+oneRosterRosteringPath+"/{collection}", requireOneRosterToken(oneRosterHandler.List))
+	oneRosterMux.Handle("GET "+oneRosterRosteringPath+"/{collection}/{sourcedId}", requireOneRosterToken(oneRosterHandler.Get))
+
+	root := http.NewServeMux()
+	root.Handle("/ims/oneroster/", AttachGlobalMiddleware(oneRosterMux, AttachRequestMetadata(trustedProxies), AttachContentTypeJSON))
+	root.Handle("/", AttachGlobalMiddleware(mux, AttachSession(sessionService), AttachRequestMetadata(trustedProxies), AttachContentTypeJSON))
 
-	http.ListenAndServe(":8080", muxWithMiddleware)
+	http.ListenAndServe(":8080", root)
 }
@@ -0,0 +1,1348 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ScheduleBuildDraft   = "draft"
+	ScheduleBuildApplied = "applied"
+)
+
+// Why the builder could not give a student a course they asked for
+const (
+	UnsatisfiedNoSection = "no_section"
+	UnsatisfiedFull      = "full"
+	UnsatisfiedConflict  = "conflict"
+)
+
+const (
+	maxSchedulePeriods = 20
+	maxScheduleRooms   = 500
+	maxCourseRequests  = 15
+	// How many times the builder sweeps the sections looking for better periods
+	scheduleImprovementRounds = 10
+	// Bounds the search for each student's schedule, which is exponential in the worst case
+	studentScheduleSearchLimit = 10000
+)
+
+var errScheduleBuildApplied = errors.New("schedule build has already been applied")
+
+type CourseRequest struct {
+	TermID        string `json:"termId"`
+	StudentUserID string `json:"studentUserId"`
+	CourseID      string `json:"courseId"`
+	SchoolID      string `json:"schoolId"`
+	// Requests are fitted in priority order, starting at 1, when not all of them can be
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// A slot of the timetable that sections are placed in
+type SchedulePeriod struct {
+	Name     string           `json:"name"`
+	Meetings []SectionMeeting `json:"meetings"`
+}
+
+type ScheduleRoom struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+}
+
+// A placement decided by hand, which the builder keeps. An empty room lets the builder choose.
+type SchedulePin struct {
+	SectionID string `json:"sectionId"`
+	Period    string `json:"period"`
+	Room      string `json:"room,omitempty"`
+}
+
+// What a build places sections into. Without rooms, sections keep the rooms they have.
+type ScheduleBuildInput struct {
+	Periods []SchedulePeriod `json:"periods"`
+	Rooms   []ScheduleRoom   `json:"rooms"`
+	Pins    []SchedulePin    `json:"pins"`
+}
+
+type SectionPlacement struct {
+	SectionID string `json:"sectionId"`
+	CourseID  string `json:"courseId"`
+	Period    string `json:"period"`
+	Room      string `json:"room,omitempty"`
+	Pinned    bool   `json:"pinned"`
+}
+
+type StudentSchedule struct {
+	StudentUserID string `json:"studentUserId"`
+	// Every section the student would attend, including ones they are already enrolled in
+	SectionIDs []string `json:"sectionIds"`
+}
+
+type UnsatisfiedRequest struct {
+	StudentUserID string `json:"studentUserId"`
+	CourseID      string `json:"courseId"`
+	Reason        string `json:"reason"`
+}
+
+type MasterSchedule struct {
+	Placements []SectionPlacement `json:"placements"`
+	// Sections no period had a free teacher and room for
+	UnplacedSectionIDs []string             `json:"unplacedSectionIds"`
+	Students           []StudentSchedule    `json:"students"`
+	Unsatisfied        []UnsatisfiedRequest `json:"unsatisfied"`
+	RequestCount       int                  `json:"requestCount"`
+	SatisfiedCount     int                  `json:"satisfiedCount"`
+}
+
+// A master schedule proposed for a term. Applying it moves the sections into their periods and
+// rooms and enrolls the students.
+type ScheduleBuild struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"schoolId"`
+	TermID   string `json:"termId"`
+	Status   string `json:"status"`
+	ScheduleBuildInput
+	Result          *MasterSchedule `json:"result"`
+	CreatedByUserID string          `json:"createdByUserId"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	AppliedAt       *time.Time      `json:"appliedAt,omitempty"`
+	AppliedByUserID string          `json:"appliedByUserId,omitempty"`
+}
+
+type SchedulePostgresStore struct {
+	db *PostgresDB
+}
+
+type ScheduleStore interface {
+	// Replaces the student's course requests for the term
+	SaveCourseRequests(ctx context.Context, termID string, studentUserID string, requests []*CourseRequest) error
+	// Lists the term's course requests, only the student's when studentUserID is set
+	ListCourseRequests(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error)
+	CreateBuild(ctx context.Context, build *ScheduleBuild) error
+	GetBuild(ctx context.Context, id string) (*ScheduleBuild, error)
+	ListBuilds(ctx context.Context, termID string) ([]*ScheduleBuild, error)
+	// Marks a draft build applied. Returns errScheduleBuildApplied if it already was.
+	MarkApplied(ctx context.Context, build *ScheduleBuild) error
+}
+
+func (s *SchedulePostgresStore) SaveCourseRequests(ctx context.Context, termID string, studentUserID string, requests []*CourseRequest) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM course_requests WHERE term_id = $1 AND student_user_id = $2`, termID, studentUserID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO course_requests (term_id, student_user_id, course_id, school_id, priority, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	for _, request := range requests {
+		if _, err := tx.Exec(ctx, query, termID, studentUserID, request.CourseID, request.SchoolID, request.Priority, request.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *SchedulePostgresStore) ListCourseRequests(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error) {
+	query := `
+		SELECT term_id, student_user_id, course_id, school_id, priority, created_at
+		FROM course_requests
+		WHERE term_id = $1 AND ($2 = '' OR student_user_id::text = $2)
+		ORDER BY student_user_id, priority
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, termID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var requests []*CourseRequest
+
+	for rows.Next() {
+		var request CourseRequest
+
+		if err := rows.Scan(&request.TermID, &request.StudentUserID, &request.CourseID, &request.SchoolID, &request.Priority, &request.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, &request)
+	}
+
+	return requests, rows.Err()
+}
+
+const scheduleBuildColumns = `
+	id, school_id, term_id, status, input, result, COALESCE(created_by_user_id::text, ''), created_at, applied_at,
+	COALESCE(applied_by_user_id::text, '')
+`
+
+func scanScheduleBuild(row rowScanner) (*ScheduleBuild, error) {
+	var build ScheduleBuild
+
+	err := row.Scan(
+		&build.ID,
+		&build.SchoolID,
+		&build.TermID,
+		&build.Status,
+		&build.ScheduleBuildInput,
+		&build.Result,
+		&build.CreatedByUserID,
+		&build.CreatedAt,
+		&build.AppliedAt,
+		&build.AppliedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &build, nil
+}
+
+func (s *SchedulePostgresStore) CreateBuild(ctx context.Context, build *ScheduleBuild) error {
+	query := `
+		INSERT INTO schedule_builds (school_id, term_id, status, input, result, created_by_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		build.SchoolID,
+		build.TermID,
+		build.Status,
+		build.ScheduleBuildInput,
+		build.Result,
+		build.CreatedByUserID,
+		build.CreatedAt,
+	)
+
+	return row.Scan(&build.ID)
+}
+
+func (s *SchedulePostgresStore) GetBuild(ctx context.Context, id string) (*ScheduleBuild, error) {
+	query := `SELECT ` + scheduleBuildColumns + ` FROM schedule_builds WHERE id = $1`
+
+	return noRowsAsNil(scanScheduleBuild(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *SchedulePostgresStore) ListBuilds(ctx context.Context, termID string) ([]*ScheduleBuild, error) {
+	query := `SELECT ` + scheduleBuildColumns + ` FROM schedule_builds WHERE term_id = $1 ORDER BY created_at DESC, id`
+
+	rows, err := s.db.pool.Query(ctx, query, termID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var builds []*ScheduleBuild
+
+	for rows.Next() {
+		build, err := scanScheduleBuild(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		builds = append(builds, build)
+	}
+
+	return builds, rows.Err()
+}
+
+func (s *SchedulePostgresStore) MarkApplied(ctx context.Context, build *ScheduleBuild) error {
+	query := `
+		UPDATE schedule_builds
+		SET status = $1, applied_at = $2, applied_by_user_id = NULLIF($3, '')::uuid
+		WHERE id = $4 AND status = 'draft'
+	`
+
+	tag, err := s.db.pool.Exec(ctx, query, build.Status, build.AppliedAt, build.AppliedByUserID, build.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errScheduleBuildApplied
+	}
+
+	return nil
+}
+
+// Checks the build input against the term's sections
+func validateScheduleBuildInput(input *ScheduleBuildInput, sections []*Section) error {
+	if len(input.Periods) == 0 || len(input.Periods) > maxSchedulePeriods {
+		return fmt.Errorf("a schedule needs between 1 and %d periods", maxSchedulePeriods)
+	}
+
+	if len(input.Rooms) > maxScheduleRooms {
+		return fmt.Errorf("a schedule can have at most %d rooms", maxScheduleRooms)
+	}
+
+	periods := map[string]bool{}
+
+	for _, period := range input.Periods {
+		if period.Name == "" {
+			return errors.New("every period needs a name")
+		}
+
+		if periods[strings.ToLower(period.Name)] {
+			return fmt.Errorf("period %s is defined more than once", period.Name)
+		}
+
+		periods[strings.ToLower(period.Name)] = true
+
+		if len(period.Meetings) == 0 {
+			return fmt.Errorf("period %s must meet at least once a week", period.Name)
+		}
+
+		if err := validateMeetings("period", period.Meetings); err != nil {
+			return err
+		}
+	}
+
+	rooms := map[string]bool{}
+
+	for _, room := range input.Rooms {
+		if room.Name == "" {
+			return errors.New("every room needs a name")
+		}
+
+		if rooms[strings.ToLower(room.Name)] {
+			return fmt.Errorf("room %s is defined more than once", room.Name)
+		}
+
+		rooms[strings.ToLower(room.Name)] = true
+
+		if room.Capacity <= 0 {
+			return errors.New("room capacity must be greater than zero")
+		}
+	}
+
+	pinned := map[string]bool{}
+
+	for _, pin := range input.Pins {
+		if !slices.ContainsFunc(sections, func(section *Section) bool { return section.ID == pin.SectionID }) {
+			return fmt.Errorf("pinned section %s is not a section of this term", pin.SectionID)
+		}
+
+		if pinned[pin.SectionID] {
+			return fmt.Errorf("section %s is pinned more than once", pin.SectionID)
+		}
+
+		pinned[pin.SectionID] = true
+
+		if !periods[strings.ToLower(pin.Period)] {
+			return fmt.Errorf("pinned period %s is not one of the schedule's periods", pin.Period)
+		}
+
+		if pin.Room != "" && !rooms[strings.ToLower(pin.Room)] {
+			return fmt.Errorf("pinned room %s is not one of the schedule's rooms", pin.Room)
+		}
+	}
+
+	return nil
+}
+
+// The state of a build in progress. Sections, periods and rooms are referred to by index.
+type scheduleBuilder struct {
+	periods []SchedulePeriod
+	// Rooms from smallest to largest, so sections take the smallest room they fit in
+	rooms []ScheduleRoom
+	// Whether two periods share any time. Every period conflicts with itself.
+	conflicts [][]bool
+	sections  []*Section
+	// Course id to the indexes of its sections
+	courseSections map[string][]int
+	// How many students requested both of two courses
+	coRequests map[string]map[string]int
+	// How many students requested each course
+	demand map[string]int
+	// The period and room of each section, -1 when it has none
+	period []int
+	room   []int
+	pinned []bool
+}
+
+func newScheduleBuilder(input *ScheduleBuildInput, sections []*Section, requests []*CourseRequest) *scheduleBuilder {
+	b := &scheduleBuilder{
+		periods:        input.Periods,
+		rooms:          slices.Clone(input.Rooms),
+		sections:       sections,
+		courseSections: map[string][]int{},
+		coRequests:     map[string]map[string]int{},
+		demand:         map[string]int{},
+		period:         make([]int, len(sections)),
+		room:           make([]int, len(sections)),
+		pinned:         make([]bool, len(sections)),
+	}
+
+	slices.SortFunc(b.rooms, func(a, c ScheduleRoom) int {
+		return cmp.Or(cmp.Compare(a.Capacity, c.Capacity), cmp.Compare(a.Name, c.Name))
+	})
+
+	b.conflicts = make([][]bool, len(b.periods))
+
+	for p := range b.periods {
+		b.conflicts[p] = make([]bool, len(b.periods))
+
+		for q := range b.periods {
+			b.conflicts[p][q] = p == q || meetingsOverlap(b.periods[p].Meetings, b.periods[q].Meetings)
+		}
+	}
+
+	for i, section := range sections {
+		b.courseSections[section.CourseID] = append(b.courseSections[section.CourseID], i)
+		b.period[i] = -1
+		b.room[i] = -1
+	}
+
+	for _, courses := range requestsByStudent(requests) {
+		for _, course := range courses {
+			b.demand[course]++
+
+			for _, other := range courses {
+				if other == course {
+					continue
+				}
+
+				if b.coRequests[course] == nil {
+					b.coRequests[course] = map[string]int{}
+				}
+
+				b.coRequests[course][other]++
+			}
+		}
+	}
+
+	return b
+}
+
+func meetingsOverlap(a []SectionMeeting, b []SectionMeeting) bool {
+	for _, aMeeting := range a {
+		for _, bMeeting := range b {
+			if aMeeting.Overlaps(bMeeting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns each student's requested course ids in priority order
+func requestsByStudent(requests []*CourseRequest) map[string][]string {
+	sorted := slices.Clone(requests)
+
+	slices.SortFunc(sorted, func(a, b *CourseRequest) int {
+		return cmp.Or(cmp.Compare(a.StudentUserID, b.StudentUserID), cmp.Compare(a.Priority, b.Priority), cmp.Compare(a.CourseID, b.CourseID))
+	})
+
+	byStudent := map[string][]string{}
+
+	for _, request := range sorted {
+		byStudent[request.StudentUserID] = append(byStudent[request.StudentUserID], request.CourseID)
+	}
+
+	return byStudent
+}
+
+func (b *scheduleBuilder) periodIndex(name string) int {
+	return slices.IndexFunc(b.periods, func(period SchedulePeriod) bool { return strings.EqualFold(period.Name, name) })
+}
+
+func (b *scheduleBuilder) roomIndex(name string) int {
+	return slices.IndexFunc(b.rooms, func(room ScheduleRoom) bool { return strings.EqualFold(room.Name, name) })
+}
+
+func sharesTeacher(a *Section, b *Section) bool {
+	for _, teacher := range a.Teachers {
+		if slices.ContainsFunc(b.Teachers, func(other SectionTeacher) bool { return other.UserID == teacher.UserID }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the room section i would get in period p, or -1 when the build has no rooms. ok is
+// false when a teacher of the section is busy or no room it fits in is free.
+func (b *scheduleBuilder) fit(i int, p int, room int) (int, bool) {
+	taken := make([]bool, len(b.rooms))
+
+	for j, section := range b.sections {
+		if j == i || b.period[j] < 0 || !b.conflicts[p][b.period[j]] {
+			continue
+		}
+
+		if sharesTeacher(b.sections[i], section) {
+			return -1, false
+		}
+
+		if b.room[j] >= 0 {
+			taken[b.room[j]] = true
+		}
+	}
+
+	if len(b.rooms) == 0 {
+		return -1, true
+	}
+
+	if room >= 0 {
+		return room, !taken[room]
+	}
+
+	for r, candidate := range b.rooms {
+		if !taken[r] && candidate.Capacity >= b.sections[i].Capacity {
+			return r, true
+		}
+	}
+
+	return -1, false
+}
+
+// Scores placing section i in period p by the requests it would make clash. A course that
+// requested alongside this one has all its sections at that time would clash for every
+// student wanting both, so each clashing section counts its share of the students. Sections
+// of the same course count too, as spreading them out gives students choices.
+func (b *scheduleBuilder) cost(i int, p int) float64 {
+	course := b.sections[i].CourseID
+	cost := 0.0
+
+	clashing := func(courseID string) float64 {
+		count := 0
+
+		for _, j := range b.courseSections[courseID] {
+			if j != i && b.period[j] >= 0 && b.conflicts[p][b.period[j]] {
+				count++
+			}
+		}
+
+		return float64(count) / float64(len(b.courseSections[courseID]))
+	}
+
+	for other, students := range b.coRequests[course] {
+		if len(b.courseSections[other]) > 0 {
+			cost += float64(students) * clashing(other)
+		}
+	}
+
+	cost += float64(b.demand[course]) * clashing(course)
+
+	// Breaks ties towards emptier periods
+	for j := range b.sections {
+		if j != i && b.period[j] == p {
+			cost += 0.001
+		}
+	}
+
+	return cost
+}
+
+// Moves section i into the cheapest period it fits in, leaving it unplaced if none
+func (b *scheduleBuilder) place(i int) {
+	b.period[i], b.room[i] = -1, -1
+	best := -1.0
+
+	for p := range b.periods {
+		room, ok := b.fit(i, p, -1)
+
+		if !ok {
+			continue
+		}
+
+		if cost := b.cost(i, p); best < 0 || cost < best {
+			best = cost
+			b.period[i], b.room[i] = p, room
+		}
+	}
+}
+
+// Places the pinned sections where they were pinned, failing if two of them clash
+func (b *scheduleBuilder) placePins(pins []SchedulePin) error {
+	for _, pin := range pins {
+		i := slices.IndexFunc(b.sections, func(section *Section) bool { return section.ID == pin.SectionID })
+		p := b.periodIndex(pin.Period)
+		room := -1
+
+		if pin.Room != "" {
+			room = b.roomIndex(pin.Room)
+		}
+
+		room, ok := b.fit(i, p, room)
+
+		if !ok {
+			return fmt.Errorf("pinned section %s has no free teacher or room in period %s", b.sections[i].Code, pin.Period)
+		}
+
+		b.period[i], b.room[i], b.pinned[i] = p, room, true
+	}
+
+	return nil
+}
+
+// Places the sections that are not pinned, hardest first, then keeps moving them to cheaper
+// periods until none gets cheaper
+func (b *scheduleBuilder) placeSections() {
+	var order []int
+
+	for i := range b.sections {
+		if !b.pinned[i] {
+			order = append(order, i)
+		}
+	}
+
+	// Courses with few sections leave students the least choice, so they go first
+	slices.SortStableFunc(order, func(i, j int) int {
+		a, c := b.sections[i].CourseID, b.sections[j].CourseID
+
+		return cmp.Or(
+			cmp.Compare(len(b.courseSections[a]), len(b.courseSections[c])),
+			cmp.Compare(b.demand[c], b.demand[a]),
+			cmp.Compare(b.sections[i].ID, b.sections[j].ID),
+		)
+	})
+
+	for _, i := range order {
+		b.place(i)
+	}
+
+	for range scheduleImprovementRounds {
+		moved := false
+
+		for _, i := range order {
+			period, room := b.period[i], b.room[i]
+			before := -1.0
+
+			if period >= 0 {
+				before = b.cost(i, period)
+			}
+
+			b.place(i)
+
+			if b.period[i] < 0 || (before >= 0 && b.cost(i, b.period[i]) >= before) {
+				b.period[i], b.room[i] = period, room
+				continue
+			}
+
+			if b.period[i] != period {
+				moved = true
+			}
+		}
+
+		if !moved {
+			break
+		}
+	}
+}
+
+// Finds the sections a student takes for their requests, given the periods they are already
+// busy in. Returns a section index per request, or -1 for requests it could not fit.
+func (b *scheduleBuilder) fitStudent(courses []string, busy []int, seats []int) []int {
+	options := make([][]int, len(courses))
+
+	for k, course := range courses {
+		for _, j := range b.courseSections[course] {
+			if b.period[j] >= 0 && seats[j] > 0 {
+				options[k] = append(options[k], j)
+			}
+		}
+
+		// Fuller sections last, to balance class sizes
+		slices.SortStableFunc(options[k], func(i, j int) int {
+			return cmp.Compare(seats[j], seats[i])
+		})
+	}
+
+	chosen := make([]int, len(courses))
+	best := make([]int, len(courses))
+	bestCount := -1
+	nodes := 0
+
+	free := func(p int) bool {
+		for q, count := range busy {
+			if count > 0 && b.conflicts[p][q] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var search func(k int, count int)
+
+	search = func(k int, count int) {
+		nodes++
+
+		if nodes > studentScheduleSearchLimit || count+len(courses)-k <= bestCount {
+			return
+		}
+
+		if k == len(courses) {
+			bestCount = count
+			copy(best, chosen)
+
+			return
+		}
+
+		// Taking a section before skipping the request favors earlier requests among
+		// schedules that fit as many
+		for _, j := range options[k] {
+			if seats[j] == 0 || !free(b.period[j]) {
+				continue
+			}
+
+			chosen[k] = j
+			seats[j]--
+			busy[b.period[j]]++
+
+			search(k+1, count+1)
+
+			seats[j]++
+			busy[b.period[j]]--
+		}
+
+		chosen[k] = -1
+		search(k+1, count)
+	}
+
+	search(0, 0)
+
+	if bestCount < 0 {
+		for k := range best {
+			best[k] = -1
+		}
+	}
+
+	return best
+}
+
+// Builds a master schedule for the term's sections: places each section in a period and room,
+// then fits each student's course requests around the sections they are already enrolled in.
+// enrolled maps students to the sections they are enrolled in.
+func buildMasterSchedule(input *ScheduleBuildInput, sections []*Section, requests []*CourseRequest, enrolled map[string][]string) (*MasterSchedule, error) {
+	b := newScheduleBuilder(input, sections, requests)
+
+	if err := b.placePins(input.Pins); err != nil {
+		return nil, err
+	}
+
+	b.placeSections()
+
+	schedule := &MasterSchedule{
+		Placements:         []SectionPlacement{},
+		UnplacedSectionIDs: []string{},
+		Students:           []StudentSchedule{},
+		Unsatisfied:        []UnsatisfiedRequest{},
+	}
+
+	sectionIndex := map[string]int{}
+	seats := make([]int, len(sections))
+
+	for i, section := range sections {
+		sectionIndex[section.ID] = i
+		seats[i] = section.Capacity
+
+		if b.period[i] < 0 {
+			schedule.UnplacedSectionIDs = append(schedule.UnplacedSectionIDs, section.ID)
+			continue
+		}
+
+		placement := SectionPlacement{SectionID: section.ID, CourseID: section.CourseID, Period: b.periods[b.period[i]].Name, Pinned: b.pinned[i]}
+
+		if b.room[i] >= 0 {
+			placement.Room = b.rooms[b.room[i]].Name
+		}
+
+		schedule.Placements = append(schedule.Placements, placement)
+	}
+
+	for _, sectionIDs := range enrolled {
+		for _, id := range sectionIDs {
+			if i, ok := sectionIndex[id]; ok {
+				seats[i]--
+			}
+		}
+	}
+
+	byStudent := requestsByStudent(requests)
+	students := slices.Collect(maps.Keys(byStudent))
+
+	// Students asking for the most go first, while there is the most room to fit them
+	slices.SortFunc(students, func(a, c string) int {
+		return cmp.Or(cmp.Compare(len(byStudent[c]), len(byStudent[a])), cmp.Compare(a, c))
+	})
+
+	for _, student := range students {
+		busy := make([]int, len(b.periods))
+		studentSchedule := StudentSchedule{StudentUserID: student, SectionIDs: []string{}}
+		taking := map[string]bool{}
+
+		for _, id := range enrolled[student] {
+			i, ok := sectionIndex[id]
+
+			if !ok {
+				continue
+			}
+
+			studentSchedule.SectionIDs = append(studentSchedule.SectionIDs, id)
+			taking[sections[i].CourseID] = true
+
+			if b.period[i] >= 0 {
+				busy[b.period[i]]++
+			}
+		}
+
+		var courses []string
+
+		for _, course := range byStudent[student] {
+			schedule.RequestCount++
+
+			if taking[course] {
+				schedule.SatisfiedCount++
+			} else {
+				courses = append(courses, course)
+			}
+		}
+
+		for k, j := range b.fitStudent(courses, busy, seats) {
+			if j >= 0 {
+				seats[j]--
+				studentSchedule.SectionIDs = append(studentSchedule.SectionIDs, sections[j].ID)
+				schedule.SatisfiedCount++
+
+				continue
+			}
+
+			reason := UnsatisfiedNoSection
+
+			for _, i := range b.courseSections[courses[k]] {
+				if b.period[i] < 0 {
+					continue
+				}
+
+				if seats[i] > 0 {
+					reason = UnsatisfiedConflict
+					break
+				}
+
+				reason = UnsatisfiedFull
+			}
+
+			schedule.Unsatisfied = append(schedule.Unsatisfied, UnsatisfiedRequest{StudentUserID: student, CourseID: courses[k], Reason: reason})
+		}
+
+		schedule.Students = append(schedule.Students, studentSchedule)
+	}
+
+	slices.SortFunc(schedule.Students, func(a, c StudentSchedule) int {
+		return cmp.Compare(a.StudentUserID, c.StudentUserID)
+	})
+
+	return schedule, nil
+}
+
+type ScheduleService struct {
+	scheduleStore   ScheduleStore
+	sectionStore    SectionStore
+	enrollmentStore EnrollmentStore
+	courseStore     CourseStore
+	calendarStore   CalendarStore
+	schoolStore     SchoolStore
+	memberStore     OrganizationMemberStore
+	auditService    *AuditService
+}
+
+func NewScheduleService(scheduleStore ScheduleStore, sectionStore SectionStore, enrollmentStore EnrollmentStore, courseStore CourseStore, calendarStore CalendarStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *ScheduleService {
+	return &ScheduleService{
+		scheduleStore:   scheduleStore,
+		sectionStore:    sectionStore,
+		enrollmentStore: enrollmentStore,
+		courseStore:     courseStore,
+		calendarStore:   calendarStore,
+		schoolStore:     schoolStore,
+		memberStore:     memberStore,
+		auditService:    auditService,
+	}
+}
+
+// Returns the term and its school if the session user belongs to the school's organization
+// with one of the given roles
+func (s *ScheduleService) authorizeTerm(ctx context.Context, termID string, roles ...string) (*Term, *School, error) {
+	term, err := s.calendarStore.GetTerm(ctx, termID)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if term == nil {
+		return nil, nil, notFound("term")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, term.SchoolID, roles...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return term, school, nil
+}
+
+// Authorizes the session user to act on a student's course requests: the student themselves,
+// or staff with one of the given roles
+func (s *ScheduleService) authorizeRequests(ctx context.Context, termID string, studentUserID string, roles ...string) (*Term, *School, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, nil, ErrUnauthorized
+	}
+
+	if session.UserID == studentUserID {
+		roles = nil
+	}
+
+	return s.authorizeTerm(ctx, termID, roles...)
+}
+
+func (s *ScheduleService) listCourseRequests(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error) {
+	requests, err := s.scheduleStore.ListCourseRequests(ctx, termID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to list course requests", "error", err)
+		return nil, ErrInternal
+	}
+
+	if requests == nil {
+		requests = []*CourseRequest{}
+	}
+
+	return requests, nil
+}
+
+// Replaces the courses a student requests for the term, most wanted first
+func (s *ScheduleService) SaveCourseRequests(ctx context.Context, termID string, studentUserID string, courseIDs []string) ([]*CourseRequest, error) {
+	_, school, err := s.authorizeRequests(ctx, termID, studentUserID, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(courseIDs) > maxCourseRequests {
+		return nil, fmt.Errorf("a student can request at most %d courses", maxCourseRequests)
+	}
+
+	student, err := s.memberStore.Get(ctx, school.OrganizationID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return nil, ErrInternal
+	}
+
+	if student == nil || student.Role != RoleStudent {
+		return nil, errors.New("student must be a student of the organization")
+	}
+
+	requests := []*CourseRequest{}
+
+	for i, courseID := range courseIDs {
+		if slices.Contains(courseIDs[:i], courseID) {
+			return nil, fmt.Errorf("course %s is requested more than once", courseID)
+		}
+
+		course, err := s.courseStore.GetByID(ctx, courseID)
+
+		if err != nil {
+			slog.Error("failed to get course", "error", err)
+			return nil, ErrInternal
+		}
+
+		if course == nil || course.OrganizationID != school.OrganizationID {
+			return nil, fmt.Errorf("course %s is not a course of this organization", courseID)
+		}
+
+		requests = append(requests, &CourseRequest{
+			TermID:        termID,
+			StudentUserID: studentUserID,
+			CourseID:      courseID,
+			SchoolID:      school.ID,
+			Priority:      i + 1,
+			CreatedAt:     time.Now(),
+		})
+	}
+
+	before, err := s.listCourseRequests(ctx, termID, studentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleStore.SaveCourseRequests(ctx, termID, studentUserID, requests); err != nil {
+		slog.Error("failed to save course requests", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "course_requests", termID+":"+studentUserID, before, requests)
+
+	return requests, nil
+}
+
+// Lists the term's course requests, or only the student's when studentUserID is set
+func (s *ScheduleService) ListCourseRequests(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error) {
+	if _, _, err := s.authorizeRequests(ctx, termID, studentUserID, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	return s.listCourseRequests(ctx, termID, studentUserID)
+}
+
+// Builds a master schedule for the term from its sections and course requests. The build is
+// a draft until it is applied.
+func (s *ScheduleService) Build(ctx context.Context, termID string, input *ScheduleBuildInput) (*ScheduleBuild, error) {
+	term, school, err := s.authorizeTerm(ctx, termID, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Rooms == nil {
+		input.Rooms = []ScheduleRoom{}
+	}
+
+	if input.Pins == nil {
+		input.Pins = []SchedulePin{}
+	}
+
+	sections, err := s.sectionStore.List(ctx, &SectionFilter{SchoolID: school.ID, TermID: term.ID})
+
+	if err != nil {
+		slog.Error("failed to list sections", "error", err)
+		return nil, ErrInternal
+	}
+
+	if err := validateScheduleBuildInput(input, sections); err != nil {
+		return nil, err
+	}
+
+	requests, err := s.listCourseRequests(ctx, term.ID, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled := map[string][]string{}
+
+	for _, section := range sections {
+		roster, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: section.ID, Statuses: []string{EnrollmentStatusEnrolled}})
+
+		if err != nil {
+			slog.Error("failed to list enrollments", "error", err)
+			return nil, ErrInternal
+		}
+
+		for _, enrollment := range roster {
+			enrolled[enrollment.StudentUserID] = append(enrolled[enrollment.StudentUserID], section.ID)
+		}
+	}
+
+	result, err := buildMasterSchedule(input, sections, requests, enrolled)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, _ := SessionFromContext(ctx)
+
+	build := &ScheduleBuild{
+		SchoolID:           school.ID,
+		TermID:             term.ID,
+		Status:             ScheduleBuildDraft,
+		ScheduleBuildInput: *input,
+		Result:             result,
+		CreatedByUserID:    session.UserID,
+		CreatedAt:          time.Now(),
+	}
+
+	if err := s.scheduleStore.CreateBuild(ctx, build); err != nil {
+		slog.Error("failed to create schedule build", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "schedule_build", build.ID, nil, build)
+
+	return build, nil
+}
+
+// Returns the build and its school if the session user administers the school
+func (s *ScheduleService) authorizeBuild(ctx context.Context, id string) (*ScheduleBuild, *School, error) {
+	build, err := s.scheduleStore.GetBuild(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get schedule build", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if build == nil {
+		return nil, nil, notFound("schedule build")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, build.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return build, school, nil
+}
+
+func (s *ScheduleService) GetBuild(ctx context.Context, id string) (*ScheduleBuild, error) {
+	build, _, err := s.authorizeBuild(ctx, id)
+	return build, err
+}
+
+func (s *ScheduleService) ListBuilds(ctx context.Context, termID string) ([]*ScheduleBuild, error) {
+	if _, _, err := s.authorizeTerm(ctx, termID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	builds, err := s.scheduleStore.ListBuilds(ctx, termID)
+
+	if err != nil {
+		slog.Error("failed to list schedule builds", "error", err)
+		return nil, ErrInternal
+	}
+
+	if builds == nil {
+		builds = []*ScheduleBuild{}
+	}
+
+	return builds, nil
+}
+
+// Moves the build's sections into their periods and rooms and enrolls its students in their
+// sections. Students are enrolled only where seats remain, as enrollments may have changed
+// since the build.
+func (s *ScheduleService) Apply(ctx context.Context, id string) (*ScheduleBuild, error) {
+	build, school, err := s.authorizeBuild(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if build.Status != ScheduleBuildDraft {
+		return nil, errScheduleBuildApplied
+	}
+
+	session, _ := SessionFromContext(ctx)
+	now := time.Now()
+
+	build.Status = ScheduleBuildApplied
+	build.AppliedAt = &now
+	build.AppliedByUserID = session.UserID
+
+	// Claiming the build first keeps two admins from applying it at once
+	if err := s.scheduleStore.MarkApplied(ctx, build); err != nil {
+		if errors.Is(err, errScheduleBuildApplied) {
+			return nil, err
+		}
+
+		slog.Error("failed to mark schedule build applied", "error", err)
+		return nil, ErrInternal
+	}
+
+	periods := map[string][]SectionMeeting{}
+
+	for _, period := range build.Periods {
+		periods[period.Name] = period.Meetings
+	}
+
+	courses := map[string]string{}
+
+	for _, placement := range build.Result.Placements {
+		courses[placement.SectionID] = placement.CourseID
+
+		section, err := s.sectionStore.GetByID(ctx, placement.SectionID)
+
+		if err != nil {
+			slog.Error("failed to get section", "error", err)
+			return nil, ErrInternal
+		}
+
+		if section == nil {
+			continue
+		}
+
+		before := *section
+		section.Meetings = periods[placement.Period]
+		// The build's periods replace any bell schedule periods the section met in
+		section.Periods = []SectionPeriod{}
+
+		if placement.Room != "" {
+			section.Room = placement.Room
+		}
+
+		section.UpdatedAt = time.Now()
+
+		if err := s.sectionStore.Update(ctx, section); err != nil {
+			slog.Error("failed to update section", "error", err)
+			return nil, ErrInternal
+		}
+
+		s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "section", section.ID, &before, section)
+	}
+
+	for _, student := range build.Result.Students {
+		enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{StudentUserID: student.StudentUserID, Statuses: activeEnrollmentStatuses})
+
+		if err != nil {
+			slog.Error("failed to list enrollments", "error", err)
+			return nil, ErrInternal
+		}
+
+		for _, sectionID := range student.SectionIDs {
+			if slices.ContainsFunc(enrollments, func(enrollment *Enrollment) bool { return enrollment.SectionID == sectionID }) {
+				continue
+			}
+
+			enrollment := &Enrollment{
+				SectionID:     sectionID,
+				StudentUserID: student.StudentUserID,
+				CourseID:      courses[sectionID],
+				TermID:        build.TermID,
+				CreatedAt:     time.Now(),
+				UpdatedAt:     time.Now(),
+			}
+
+			if err := s.enrollmentStore.Enroll(ctx, enrollment, false, nil); err != nil {
+				if errors.Is(err, errSectionFull) {
+					slog.Warn("section filled before schedule build was applied", "buildId", build.ID, "sectionId", sectionID, "studentUserId", student.StudentUserID)
+					continue
+				}
+
+				if errors.Is(err, errAlreadyEnrolled) {
+					continue
+				}
+
+				slog.Error("failed to enroll student", "error", err)
+				return nil, ErrInternal
+			}
+
+			s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "enrollment", enrollment.ID, nil, enrollment)
+		}
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "schedule_build", build.ID, nil, build)
+
+	return build, nil
+}
+
+type ScheduleHandler struct {
+	scheduleService *ScheduleService
+}
+
+type SaveCourseRequestsRequest struct {
+	// The requested courses, most wanted first
+	CourseIDs []string `json:"courseIds"`
+}
+
+func (h *ScheduleHandler) SaveCourseRequests(w http.ResponseWriter, r *http.Request) {
+	var request SaveCourseRequestsRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	requests, err := h.scheduleService.SaveCourseRequests(r.Context(), r.PathValue("id"), r.PathValue("studentId"), request.CourseIDs)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requests)
+}
+
+func (h *ScheduleHandler) ListCourseRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.scheduleService.ListCourseRequests(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requests)
+}
+
+func (h *ScheduleHandler) Build(w http.ResponseWriter, r *http.Request) {
+	var input ScheduleBuildInput
+
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	build, err := h.scheduleService.Build(r.Context(), r.PathValue("id"), &input)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, build)
+}
+
+func (h *ScheduleHandler) ListBuilds(w http.ResponseWriter, r *http.Request) {
+	builds, err := h.scheduleService.ListBuilds(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, builds)
+}
+
+func (h *ScheduleHandler) GetBuild(w http.ResponseWriter, r *http.Request) {
+	build, err := h.scheduleService.GetBuild(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, build)
+}
+
+func (h *ScheduleHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	build, err := h.scheduleService.Apply(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, build)
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockScheduleStore struct {
+	SaveCourseRequestsFunc func(ctx context.Context, termID string, studentUserID string, requests []*CourseRequest) error
+	ListCourseRequestsFunc func(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error)
+	CreateBuildFunc        func(ctx context.Context, build *ScheduleBuild) error
+	GetBuildFunc           func(ctx context.Context, id string) (*ScheduleBuild, error)
+	ListBuildsFunc         func(ctx context.Context, termID string) ([]*ScheduleBuild, error)
+	MarkAppliedFunc        func(ctx context.Context, build *ScheduleBuild) error
+}
+
+func (m *MockScheduleStore) SaveCourseRequests(ctx context.Context, termID string, studentUserID string, requests []*CourseRequest) error {
+	if m.SaveCourseRequestsFunc != nil {
+		return m.SaveCourseRequestsFunc(ctx, termID, studentUserID, requests)
+	}
+
+	return nil
+}
+
+func (m *MockScheduleStore) ListCourseRequests(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error) {
+	if m.ListCourseRequestsFunc != nil {
+		return m.ListCourseRequestsFunc(ctx, termID, studentUserID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockScheduleStore) CreateBuild(ctx context.Context, build *ScheduleBuild) error {
+	if m.CreateBuildFunc != nil {
+		return m.CreateBuildFunc(ctx, build)
+	}
+
+	return nil
+}
+
+func (m *MockScheduleStore) GetBuild(ctx context.Context, id string) (*ScheduleBuild, error) {
+	if m.GetBuildFunc != nil {
+		return m.GetBuildFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockScheduleStore) ListBuilds(ctx context.Context, termID string) ([]*ScheduleBuild, error) {
+	if m.ListBuildsFunc != nil {
+		return m.ListBuildsFunc(ctx, termID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockScheduleStore) MarkApplied(ctx context.Context, build *ScheduleBuild) error {
+	if m.MarkAppliedFunc != nil {
+		return m.MarkAppliedFunc(ctx, build)
+	}
+
+	return nil
+}
+
+// Two periods a day on Monday and Wednesday
+func twoPeriods() *ScheduleBuildInput {
+	return &ScheduleBuildInput{
+		Periods: []SchedulePeriod{
+			{Name: "1", Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "08:00", EndTime: "08:50"}, {Weekday: time.Wednesday, StartTime: "08:00", EndTime: "08:50"}}},
+			{Name: "2", Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "09:00", EndTime: "09:50"}, {Weekday: time.Wednesday, StartTime: "09:00", EndTime: "09:50"}}},
+		},
+	}
+}
+
+func scheduledSection(id string, courseID string, teacher string, capacity int) *Section {
+	return &Section{ID: id, CourseID: courseID, SchoolID: "school", TermID: "fall", Code: id, Capacity: capacity, Teachers: []SectionTeacher{{UserID: teacher, Role: SectionTeacherPrimary}}}
+}
+
+func requestsFor(studentUserID string, courseIDs ...string) []*CourseRequest {
+	var requests []*CourseRequest
+
+	for i, courseID := range courseIDs {
+		requests = append(requests, &CourseRequest{TermID: "fall", StudentUserID: studentUserID, CourseID: courseID, Priority: i + 1})
+	}
+
+	return requests
+}
+
+func placementOf(schedule *MasterSchedule, sectionID string) SectionPlacement {
+	for _, placement := range schedule.Placements {
+		if placement.SectionID == sectionID {
+			return placement
+		}
+	}
+
+	return SectionPlacement{}
+}
+
+func TestBuildMasterSchedule_KeepsCoRequestedCoursesApart(t *testing.T) {
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 30),
+		scheduledSection("bio-01", "bio", "other-teacher", 30),
+	}
+
+	requests := append(requestsFor("student", "geo", "bio"), requestsFor("other-student", "bio", "geo")...)
+
+	schedule, err := buildMasterSchedule(twoPeriods(), sections, requests, nil)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, placementOf(schedule, "geo-01").Period, placementOf(schedule, "bio-01").Period)
+	assert.Equal(t, 4, schedule.RequestCount)
+	assert.Equal(t, 4, schedule.SatisfiedCount)
+	assert.Empty(t, schedule.Unsatisfied)
+	assert.Equal(t, []StudentSchedule{
+		{StudentUserID: "other-student", SectionIDs: []string{"bio-01", "geo-01"}},
+		{StudentUserID: "student", SectionIDs: []string{"geo-01", "bio-01"}},
+	}, schedule.Students)
+}
+
+func TestBuildMasterSchedule_NeverDoubleBooksTeacher(t *testing.T) {
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 30),
+		scheduledSection("geo-02", "geo", "teacher", 30),
+		scheduledSection("geo-03", "geo", "teacher", 30),
+	}
+
+	schedule, err := buildMasterSchedule(twoPeriods(), sections, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, schedule.Placements, 2)
+	assert.NotEqual(t, schedule.Placements[0].Period, schedule.Placements[1].Period)
+	assert.Equal(t, []string{"geo-03"}, schedule.UnplacedSectionIDs)
+}
+
+func TestBuildMasterSchedule_TreatsOverlappingPeriodsAsClashing(t *testing.T) {
+	input := twoPeriods()
+	input.Periods = append(input.Periods, SchedulePeriod{Name: "Block", Meetings: []SectionMeeting{{Weekday: time.Monday, StartTime: "08:00", EndTime: "09:50"}}})
+	input.Pins = []SchedulePin{{SectionID: "geo-01", Period: "Block"}}
+
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 30),
+		scheduledSection("alg1-01", "alg1", "teacher", 30),
+	}
+
+	schedule, err := buildMasterSchedule(input, sections, nil, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, placementOf(schedule, "geo-01").Pinned)
+	assert.Equal(t, []string{"alg1-01"}, schedule.UnplacedSectionIDs)
+}
+
+func TestBuildMasterSchedule_AssignsSmallestRoomSectionFits(t *testing.T) {
+	input := twoPeriods()
+	input.Rooms = []ScheduleRoom{{Name: "Lab", Capacity: 40}, {Name: "101", Capacity: 20}, {Name: "102", Capacity: 30}}
+	input.Pins = []SchedulePin{{SectionID: "bio-01", Period: "1", Room: "Lab"}}
+
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 25),
+		scheduledSection("bio-01", "bio", "other-teacher", 20),
+	}
+
+	schedule, err := buildMasterSchedule(input, sections, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, SectionPlacement{SectionID: "bio-01", CourseID: "bio", Period: "1", Room: "Lab", Pinned: true}, placementOf(schedule, "bio-01"))
+	assert.Equal(t, "102", placementOf(schedule, "geo-01").Room)
+}
+
+func TestBuildMasterSchedule_ReturnsErrorForClashingPins(t *testing.T) {
+	input := twoPeriods()
+	input.Pins = []SchedulePin{{SectionID: "geo-01", Period: "1"}, {SectionID: "geo-02", Period: "1"}}
+
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 30),
+		scheduledSection("geo-02", "geo", "teacher", 30),
+	}
+
+	_, err := buildMasterSchedule(input, sections, nil, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "pinned section geo-02 has no free teacher or room in period 1", err.Error())
+}
+
+func TestBuildMasterSchedule_ReportsWhyRequestsWentUnsatisfied(t *testing.T) {
+	input := twoPeriods()
+	input.Periods = input.Periods[:1]
+
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 1),
+		scheduledSection("bio-01", "bio", "other-teacher", 30),
+	}
+
+	requests := append(requestsFor("student", "geo", "chem"), requestsFor("other-student", "geo", "bio")...)
+
+	schedule, err := buildMasterSchedule(input, sections, requests, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, schedule.RequestCount)
+	assert.Equal(t, 1, schedule.SatisfiedCount)
+
+	// Both students want geometry's only seat and other-student, asking for as many courses,
+	// comes first. Biology meets when geometry does.
+	assert.ElementsMatch(t, []UnsatisfiedRequest{
+		{StudentUserID: "other-student", CourseID: "bio", Reason: UnsatisfiedConflict},
+		{StudentUserID: "student", CourseID: "geo", Reason: UnsatisfiedFull},
+		{StudentUserID: "student", CourseID: "chem", Reason: UnsatisfiedNoSection},
+	}, schedule.Unsatisfied)
+}
+
+func TestBuildMasterSchedule_KeepsExistingEnrollments(t *testing.T) {
+	sections := []*Section{
+		scheduledSection("geo-01", "geo", "teacher", 1),
+		scheduledSection("geo-02", "geo", "other-teacher", 1),
+	}
+
+	requests := append(requestsFor("student", "geo"), requestsFor("other-student", "geo")...)
+
+	schedule, err := buildMasterSchedule(twoPeriods(), sections, requests, map[string][]string{"student": {"geo-01"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, schedule.SatisfiedCount)
+	assert.Equal(t, []StudentSchedule{
+		{StudentUserID: "other-student", SectionIDs: []string{"geo-02"}},
+		{StudentUserID: "student", SectionIDs: []string{"geo-01"}},
+	}, schedule.Students)
+}
+
+func TestValidateScheduleBuildInput_ReturnsErrorForDuplicatePeriod(t *testing.T) {
+	input := twoPeriods()
+	input.Periods[1].Name = "1"
+
+	err := validateScheduleBuildInput(input, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "period 1 is defined more than once", err.Error())
+}
+
+func TestValidateScheduleBuildInput_ReturnsErrorForPinOfOtherSection(t *testing.T) {
+	input := twoPeriods()
+	input.Pins = []SchedulePin{{SectionID: "elsewhere", Period: "1"}}
+
+	err := validateScheduleBuildInput(input, []*Section{scheduledSection("geo-01", "geo", "teacher", 30)})
+
+	assert.Error(t, err)
+	assert.Equal(t, "pinned section elsewhere is not a section of this term", err.Error())
+}
+
+func TestScheduleService_SaveCourseRequests_PrioritizesInOrder(t *testing.T) {
+	var saved []*CourseRequest
+	scheduleService := NewScheduleService(&MockScheduleStore{
+		SaveCourseRequestsFunc: func(ctx context.Context, termID string, studentUserID string, requests []*CourseRequest) error {
+			saved = requests
+			return nil
+		},
+	}, &MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := scheduleService.SaveCourseRequests(sessionContext("student"), "fall", "student", []string{"alg2", "geo"})
+
+	assert.NoError(t, err)
+	assert.Len(t, saved, 2)
+	assert.Equal(t, "alg2", saved[0].CourseID)
+	assert.Equal(t, 1, saved[0].Priority)
+	assert.Equal(t, 2, saved[1].Priority)
+	assert.Equal(t, "school", saved[1].SchoolID)
+}
+
+func TestScheduleService_SaveCourseRequests_ReturnsErrorForOtherStudent(t *testing.T) {
+	scheduleService := NewScheduleService(&MockScheduleStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := scheduleService.SaveCourseRequests(sessionContext("other-student"), "fall", "student", []string{"geo"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestScheduleService_SaveCourseRequests_ReturnsErrorForUnknownCourse(t *testing.T) {
+	scheduleService := NewScheduleService(&MockScheduleStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := scheduleService.SaveCourseRequests(sessionContext("admin"), "fall", "student", []string{"geo", "chem"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "course chem is not a course of this organization", err.Error())
+}
+
+func TestScheduleService_Build_StoresDraft(t *testing.T) {
+	var created *ScheduleBuild
+	scheduleService := NewScheduleService(&MockScheduleStore{
+		ListCourseRequestsFunc: func(ctx context.Context, termID string, studentUserID string) ([]*CourseRequest, error) {
+			return requestsFor("student", "geo"), nil
+		},
+		CreateBuildFunc: func(ctx context.Context, build *ScheduleBuild) error {
+			created = build
+			return nil
+		},
+	}, &MockSectionStore{
+		ListFunc: func(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+			return []*Section{scheduledSection("geo-01", "geo", "teacher", 30)}, nil
+		},
+	}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	build, err := scheduleService.Build(sessionContext("admin"), "fall", twoPeriods())
+
+	assert.NoError(t, err)
+	assert.Equal(t, created, build)
+	assert.Equal(t, ScheduleBuildDraft, build.Status)
+	assert.Equal(t, "admin", build.CreatedByUserID)
+	assert.Equal(t, 1, build.Result.SatisfiedCount)
+}
+
+func TestScheduleService_Build_ReturnsErrorForTeacher(t *testing.T) {
+	scheduleService := NewScheduleService(&MockScheduleStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := scheduleService.Build(sessionContext("teacher"), "fall", twoPeriods())
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func draftBuild() *ScheduleBuild {
+	return &ScheduleBuild{
+		ID:                 "build",
+		SchoolID:           "school",
+		TermID:             "fall",
+		Status:             ScheduleBuildDraft,
+		ScheduleBuildInput: *twoPeriods(),
+		Result: &MasterSchedule{
+			Placements: []SectionPlacement{{SectionID: "geo-01", CourseID: "geo", Period: "2", Room: "102"}},
+			Students:   []StudentSchedule{{StudentUserID: "student", SectionIDs: []string{"geo-01"}}, {StudentUserID: "other-student", SectionIDs: []string{"geo-01"}}},
+		},
+	}
+}
+
+func TestScheduleService_Apply_PlacesSectionsAndEnrollsStudents(t *testing.T) {
+	var updated *Section
+	var enrolled []string
+
+	scheduleService := NewScheduleService(&MockScheduleStore{
+		GetBuildFunc: func(ctx context.Context, id string) (*ScheduleBuild, error) {
+			return draftBuild(), nil
+		},
+	}, &MockSectionStore{
+		GetByIDFunc: existingSection().GetByIDFunc,
+		UpdateFunc: func(ctx context.Context, section *Section) error {
+			updated = section
+			return nil
+		},
+	}, &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			if filter.StudentUserID == "student" {
+				return []*Enrollment{{ID: "1", SectionID: "geo-01", StudentUserID: "student", Status: EnrollmentStatusEnrolled}}, nil
+			}
+
+			return nil, nil
+		},
+		EnrollFunc: func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error {
+			enrolled = append(enrolled, enrollment.StudentUserID)
+			return nil
+		},
+	}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	build, err := scheduleService.Apply(sessionContext("admin"), "build")
+
+	assert.NoError(t, err)
+	assert.Equal(t, ScheduleBuildApplied, build.Status)
+	assert.Equal(t, "admin", build.AppliedByUserID)
+	assert.Equal(t, twoPeriods().Periods[1].Meetings, updated.Meetings)
+	assert.Equal(t, "102", updated.Room)
+	assert.Equal(t, []string{"other-student"}, enrolled)
+}
+
+func TestScheduleService_Apply_ReturnsErrorWhenAlreadyApplied(t *testing.T) {
+	scheduleService := NewScheduleService(&MockScheduleStore{
+		GetBuildFunc: func(ctx context.Context, id string) (*ScheduleBuild, error) {
+			build := draftBuild()
+			build.Status = ScheduleBuildApplied
+
+			return build, nil
+		},
+	}, &MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := scheduleService.Apply(sessionContext("admin"), "build")
+
+	assert.ErrorIs(t, err, errScheduleBuildApplied)
+}
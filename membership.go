@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleTeacher = "teacher"
+	RoleStudent = "student"
+)
+
+type OrganizationMember struct {
+	OrganizationID string    `json:"organizationId"`
+	UserID         string    `json:"userId"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type OrganizationMemberPostgresStore struct {
+	db *PostgresDB
+}
+
+type OrganizationMemberStore interface {
+	Create(ctx context.Context, member *OrganizationMember) error
+	Get(ctx context.Context, organizationID string, userID string) (*OrganizationMember, error)
+	ListByUser(ctx context.Context, userID string) ([]*OrganizationMember, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*OrganizationMember, error)
+	// Returns a page of the organization's members whose accounts exist and are not erased, in
+	// the order ListByOrganization uses, along with how many such members there are
+	ListActivePage(ctx context.Context, organizationID string, limit int, offset int) ([]*OrganizationMember, int, error)
+	Delete(ctx context.Context, organizationID string, userID string) error
+}
+
+func (s *OrganizationMemberPostgresStore) Create(ctx context.Context, member *OrganizationMember) error {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, member.OrganizationID, member.UserID, member.Role, member.CreatedAt)
+
+	return err
+}
+
+func (s *OrganizationMemberPostgresStore) Get(ctx context.Context, organizationID string, userID string) (*OrganizationMember, error) {
+	query := `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id AND o.deleted_at IS NULL
+		WHERE m.organization_id = $1 AND m.user_id = $2
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, organizationID, userID)
+
+	var member OrganizationMember
+
+	if err := row.Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (s *OrganizationMemberPostgresStore) ListByUser(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+	query := `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id AND o.deleted_at IS NULL
+		WHERE m.user_id = $1
+		ORDER BY m.created_at
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var members []*OrganizationMember
+
+	for rows.Next() {
+		var member OrganizationMember
+
+		if err := rows.Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
+func (s *OrganizationMemberPostgresStore) ListByOrganization(ctx context.Context, organizationID string) ([]*OrganizationMember, error) {
+	query := `
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_members
+		WHERE organization_id = $1
+		ORDER BY created_at, user_id
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var members []*OrganizationMember
+
+	for rows.Next() {
+		var member OrganizationMember
+
+		if err := rows.Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
+func (s *OrganizationMemberPostgresStore) ListActivePage(ctx context.Context, organizationID string, limit int, offset int) ([]*OrganizationMember, int, error) {
+	query := `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at, count(*) OVER ()
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1 AND u.deleted_at IS NULL AND u.erased_at IS NULL
+		ORDER BY m.created_at, m.user_id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	var members []*OrganizationMember
+	total := 0
+
+	for rows.Next() {
+		var member OrganizationMember
+
+		if err := rows.Scan(&member.OrganizationID, &member.UserID, &member.Role, &member.CreatedAt, &total); err != nil {
+			return nil, 0, err
+		}
+
+		members = append(members, &member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(members) == 0 && offset > 0 {
+		// A page past the end has no rows to carry the count
+		err := s.db.pool.QueryRow(ctx, `
+			SELECT count(*)
+			FROM organization_members m
+			JOIN users u ON u.id = m.user_id
+			WHERE m.organization_id = $1 AND u.deleted_at IS NULL AND u.erased_at IS NULL
+		`, organizationID).Scan(&total)
+
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return members, total, nil
+}
+
+func (s *OrganizationMemberPostgresStore) Delete(ctx context.Context, organizationID string, userID string) error {
+	query := `
+		DELETE FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, organizationID, userID)
+
+	return err
+}
+
+// Returns the membership of the session user in ctx if they hold one of the given roles in the organization.
+// Impersonation sessions are confined to the organization they were issued for.
+func requireMembership(ctx context.Context, memberStore OrganizationMemberStore, organizationID string, roles ...string) (*OrganizationMember, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if session.IsImpersonation() && session.OrganizationID != organizationID {
+		return nil, forbidden("impersonation is limited to its organization")
+	}
+
+	member, err := memberStore.Get(ctx, organizationID, session.UserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return nil, ErrInternal
+	}
+
+	if member == nil || (len(roles) > 0 && !slices.Contains(roles, member.Role)) {
+		return nil, ErrForbidden
+	}
+
+	return member, nil
+}
+
+// Succeeds if the session user in ctx administers every organization the given user belongs to,
+// for actions on the account that reach into all of them. Users outside every organization have
+// no admin.
+func requireAdminOfEveryOrganizationOf(ctx context.Context, memberStore OrganizationMemberStore, userID string) error {
+	members, err := memberStore.ListByUser(ctx, userID)
+
+	if err != nil {
+		slog.Error("failed to list organization members", "error", err)
+		return ErrInternal
+	}
+
+	if len(members) == 0 {
+		if _, ok := SessionFromContext(ctx); !ok {
+			return ErrUnauthorized
+		}
+
+		return ErrForbidden
+	}
+
+	for _, member := range members {
+		if _, err := requireMembership(ctx, memberStore, member.OrganizationID, RoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Succeeds if the session user in ctx administers an organization the given user belongs to
+func requireAdminOfUser(ctx context.Context, memberStore OrganizationMemberStore, userID string) error {
+	members, err := memberStore.ListByUser(ctx, userID)
+
+	if err != nil {
+		slog.Error("failed to list organization members", "error", err)
+		return ErrInternal
+	}
+
+	for _, member := range members {
+		if _, err := requireMembership(ctx, memberStore, member.OrganizationID, RoleAdmin); err == nil {
+			return nil
+		}
+	}
+
+	if _, ok := SessionFromContext(ctx); !ok {
+		return ErrUnauthorized
+	}
+
+	return ErrForbidden
+}
@@ -1,6 +1,18 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
 
 // Attaches middleware to the passed in ServeMux
 func AttachGlobalMiddleware(r *http.ServeMux, middlewares ...func(next http.Handler) http.Handler) http.Handler {
@@ -21,3 +33,135 @@ func AttachContentTypeJSON(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+type RequestMetadata struct {
+	RequestID string
+	IPAddress string
+	UserAgent string
+}
+
+type requestMetadataContextKey struct{}
+
+// Returns the request metadata attached to the context by AttachRequestMetadata
+func RequestMetadataFromContext(ctx context.Context) RequestMetadata {
+	metadata, _ := ctx.Value(requestMetadataContextKey{}).(RequestMetadata)
+	return metadata
+}
+
+// Returns the addresses of the reverse proxies whose X-Forwarded-For header is believed, read from
+// DIVINITY_TRUSTED_PROXIES as a comma separated list of IP addresses and CIDR prefixes
+func trustedProxiesFromEnv() ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	for _, value := range strings.Split(os.Getenv("DIVINITY_TRUSTED_PROXIES"), ",") {
+		value = strings.TrimSpace(value)
+
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+
+		proxies = append(proxies, prefix.Masked())
+	}
+
+	return proxies, nil
+}
+
+// Returns the address of the client that made the request. X-Forwarded-For is only believed
+// when the request came through a trusted proxy, and then only as far back as the proxies it
+// lists are trusted too: the first address from the right that is not a trusted proxy is the
+// client, since anything before it could have been sent by the client itself.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	isTrusted := func(value string) bool {
+		addr, err := netip.ParseAddr(value)
+
+		if err != nil {
+			return false
+		}
+
+		addr = addr.Unmap()
+
+		return slices.ContainsFunc(trustedProxies, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+	}
+
+	if !isTrusted(ip) {
+		return ip
+	}
+
+	var forwarded []string
+
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, value := range strings.Split(header, ",") {
+			forwarded = append(forwarded, strings.TrimSpace(value))
+		}
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if forwarded[i] == "" {
+			continue
+		}
+
+		ip = forwarded[i]
+
+		if !isTrusted(ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// Request IDs taken from clients end up in logs and the audit log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Attaches the request ID, client IP address and user agent to the request context.
+// The request ID is taken from X-Request-Id when it matches requestIDPattern, otherwise one is
+// generated, and it is echoed back in the response.
+// The client IP address is taken from X-Forwarded-For only on requests from trustedProxies.
+func AttachRequestMetadata(trustedProxies []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get("X-Request-Id")
+
+			if !requestIDPattern.MatchString(requestID) {
+				b := make([]byte, 16)
+				rand.Read(b)
+				requestID = hex.EncodeToString(b)
+			}
+
+			w.Header().Set("X-Request-Id", requestID)
+
+			ctx := context.WithValue(r.Context(), requestMetadataContextKey{}, RequestMetadata{
+				RequestID: requestID,
+				IPAddress: clientIP(r, trustedProxies),
+				UserAgent: r.UserAgent(),
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
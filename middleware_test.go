@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP_IgnoresForwardedForFromUntrustedPeer(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+	assert.Equal(t, "203.0.113.7", clientIP(r, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+}
+
+func TestClientIP_TakesRightmostUntrustedForwardedAddress(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.3")
+
+	assert.Equal(t, "203.0.113.7", clientIP(r, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+}
+
+func TestTrustedProxiesFromEnv_ParsesAddressesAndPrefixes(t *testing.T) {
+	t.Setenv("DIVINITY_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+
+	proxies, err := trustedProxiesFromEnv()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.1/32")}, proxies)
+
+	t.Setenv("DIVINITY_TRUSTED_PROXIES", "proxy.internal")
+
+	_, err = trustedProxiesFromEnv()
+
+	assert.Error(t, err)
+}
+
+func TestAttachRequestMetadata_KeepsSafeRequestID(t *testing.T) {
+	var metadata RequestMetadata
+	handler := AttachRequestMetadata(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadata, _ = r.Context().Value(requestMetadataContextKey{}).(RequestMetadata)
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "lb-7f3a.2_1")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, "lb-7f3a.2_1", metadata.RequestID)
+	assert.Equal(t, "lb-7f3a.2_1", w.Header().Get("X-Request-Id"))
+}
+
+func TestAttachRequestMetadata_ReplacesUnsafeRequestID(t *testing.T) {
+	for _, requestID := range []string{"id\r\nX-Injected: 1", "<script>", strings.Repeat("a", 129)} {
+		var metadata RequestMetadata
+		handler := AttachRequestMetadata(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			metadata, _ = r.Context().Value(requestMetadataContextKey{}).(RequestMetadata)
+		}))
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Request-Id", requestID)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		assert.Regexp(t, "^[0-9a-f]{32}$", metadata.RequestID)
+		assert.Equal(t, metadata.RequestID, w.Header().Get("X-Request-Id"))
+	}
+}
@@ -0,0 +1,20 @@
+ALTER TABLE audit_entries
+    ALTER COLUMN actor_user_id DROP NOT NULL,
+    ADD COLUMN changes JSONB,
+    ADD COLUMN request_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address TEXT NOT NULL DEFAULT '',
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS audit_entries_organization_created_at_idx ON audit_entries (organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_entries_actor_created_at_idx ON audit_entries (actor_user_id, created_at DESC);
+
+-- Audit entries are append-only: reject any attempt to change or remove them.
+CREATE OR REPLACE FUNCTION reject_audit_entry_modification() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_entries_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_entry_modification();
@@ -0,0 +1,36 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE organizations ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE schools ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- A deleted user's email address may be reused, so uniqueness only applies to live rows.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_live_idx ON users (email) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS organizations_deleted_at_idx ON organizations (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS schools_deleted_at_idx ON schools (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Purged rows take their sessions, memberships and schools with them. Audit entries keep
+-- the ids of purged rows as history, so they no longer reference the live tables.
+ALTER TABLE sessions
+    DROP CONSTRAINT sessions_user_id_fkey,
+    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    DROP CONSTRAINT sessions_impersonator_user_id_fkey,
+    ADD CONSTRAINT sessions_impersonator_user_id_fkey FOREIGN KEY (impersonator_user_id) REFERENCES users (id) ON DELETE CASCADE,
+    DROP CONSTRAINT sessions_organization_id_fkey,
+    ADD CONSTRAINT sessions_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE;
+
+ALTER TABLE organization_members
+    DROP CONSTRAINT organization_members_organization_id_fkey,
+    ADD CONSTRAINT organization_members_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE,
+    DROP CONSTRAINT organization_members_user_id_fkey,
+    ADD CONSTRAINT organization_members_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE schools
+    DROP CONSTRAINT IF EXISTS schools_organization_id_fkey,
+    ADD CONSTRAINT schools_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE;
+
+ALTER TABLE audit_entries
+    DROP CONSTRAINT audit_entries_organization_id_fkey,
+    DROP CONSTRAINT audit_entries_actor_user_id_fkey,
+    DROP CONSTRAINT audit_entries_impersonator_user_id_fkey;
@@ -0,0 +1,29 @@
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMPTZ;
+
+-- Erasure may scrub personal data from the audit log, see AuditPostgresStore.RedactUser. Deletes
+-- are still rejected outright, and updates are checked row by row below.
+DROP TRIGGER audit_entries_append_only ON audit_entries;
+
+CREATE TRIGGER audit_entries_append_only
+    BEFORE DELETE OR TRUNCATE ON audit_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_entry_modification();
+
+-- An update may only clear changes, ip_address and user_agent, and only in a transaction that
+-- set divinity.audit_redaction. Every other column must stay as it was.
+CREATE OR REPLACE FUNCTION check_audit_entry_redaction() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('divinity.audit_redaction', true) IS DISTINCT FROM 'on'
+        OR to_jsonb(NEW) - 'changes' - 'ip_address' - 'user_agent' <> to_jsonb(OLD) - 'changes' - 'ip_address' - 'user_agent'
+        OR (NEW.changes IS NOT NULL AND NEW.changes IS DISTINCT FROM OLD.changes)
+        OR (NEW.ip_address <> '' AND NEW.ip_address <> OLD.ip_address)
+        OR (NEW.user_agent <> '' AND NEW.user_agent <> OLD.user_agent) THEN
+        RAISE EXCEPTION 'audit_entries is append-only';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_entries_redaction
+    BEFORE UPDATE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION check_audit_entry_redaction();
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS academic_years (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CHECK (start_date <= end_date)
+);
+
+CREATE INDEX IF NOT EXISTS academic_years_school_id_idx ON academic_years (school_id, start_date);
+
+-- Terms nest inside an academic year. A year with terms cannot be deleted until they are removed.
+-- Purging a school deletes its terms first, see schoolPurgeSteps.
+CREATE TABLE IF NOT EXISTS terms (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    academic_year_id UUID NOT NULL REFERENCES academic_years (id) ON DELETE RESTRICT,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CHECK (start_date <= end_date)
+);
+
+CREATE INDEX IF NOT EXISTS terms_school_id_idx ON terms (school_id, start_date);
+
+CREATE TABLE IF NOT EXISTS calendar_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CHECK (start_date <= end_date)
+);
+
+CREATE INDEX IF NOT EXISTS calendar_events_school_id_idx ON calendar_events (school_id, start_date);
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS courses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    credits NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    grade_levels INT[] NOT NULL DEFAULT '{}',
+    -- Checked by CourseService. An array keeps the catalog in one row per course.
+    prerequisite_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS courses_organization_code_idx ON courses (organization_id, lower(code));
+
+-- A course or term with sections cannot be deleted until they are removed. Purging a school
+-- deletes its sections first, see schoolPurgeSteps.
+CREATE TABLE IF NOT EXISTS sections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    course_id UUID NOT NULL REFERENCES courses (id) ON DELETE RESTRICT,
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    term_id UUID NOT NULL REFERENCES terms (id) ON DELETE RESTRICT,
+    code TEXT NOT NULL,
+    capacity INT NOT NULL CHECK (capacity > 0),
+    room TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sections_course_term_code_idx ON sections (course_id, term_id, lower(code));
+CREATE INDEX IF NOT EXISTS sections_school_term_idx ON sections (school_id, term_id);
+
+CREATE TABLE IF NOT EXISTS section_teachers (
+    section_id UUID NOT NULL REFERENCES sections (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    PRIMARY KEY (section_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS section_teachers_user_id_idx ON section_teachers (user_id);
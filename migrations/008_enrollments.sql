@@ -0,0 +1,23 @@
+ALTER TABLE terms
+    ADD COLUMN enrollment_start_date DATE,
+    ADD COLUMN enrollment_end_date DATE;
+
+ALTER TABLE sections ADD COLUMN meetings JSONB NOT NULL DEFAULT '[]';
+
+-- The student key cascades, but purging never deletes a student with enrollments: PurgeService
+-- erases them instead. Enrollments go when their school is purged, see schoolPurgeSteps.
+CREATE TABLE IF NOT EXISTS enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    section_id UUID NOT NULL REFERENCES sections (id) ON DELETE RESTRICT,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    dropped_at TIMESTAMPTZ
+);
+
+-- A student holds at most one seat or waitlist place per section. Seat counts are kept
+-- honest by locking the section row, see EnrollmentPostgresStore.
+CREATE UNIQUE INDEX IF NOT EXISTS enrollments_active_idx ON enrollments (section_id, student_user_id) WHERE status IN ('enrolled', 'waitlisted');
+CREATE INDEX IF NOT EXISTS enrollments_section_status_idx ON enrollments (section_id, status, created_at);
+CREATE INDEX IF NOT EXISTS enrollments_student_user_id_idx ON enrollments (student_user_id);
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS grading_scales (
+    school_id UUID PRIMARY KEY REFERENCES schools (id) ON DELETE CASCADE,
+    bands JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS grade_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    section_id UUID NOT NULL REFERENCES sections (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    weight DOUBLE PRECISION NOT NULL,
+    drop_lowest INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS grade_categories_section_id_idx ON grade_categories (section_id);
+
+CREATE TABLE IF NOT EXISTS assignments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    section_id UUID NOT NULL REFERENCES sections (id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES grade_categories (id) ON DELETE RESTRICT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    points_possible DOUBLE PRECISION NOT NULL,
+    due_at TIMESTAMPTZ,
+    late_penalty_per_day DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_late_penalty DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS assignments_section_id_idx ON assignments (section_id);
+
+CREATE TABLE IF NOT EXISTS grades (
+    assignment_id UUID NOT NULL REFERENCES assignments (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    points DOUBLE PRECISION,
+    excused BOOLEAN NOT NULL DEFAULT FALSE,
+    submitted_at TIMESTAMPTZ,
+    comment TEXT NOT NULL DEFAULT '',
+    graded_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (assignment_id, student_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS grades_student_user_id_idx ON grades (student_user_id);
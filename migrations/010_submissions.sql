@@ -0,0 +1,21 @@
+ALTER TABLE assignments
+    ADD COLUMN max_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN reject_late_submissions BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    assignment_id UUID NOT NULL REFERENCES assignments (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    -- File metadata. The contents are in the blob store under submissions/<assignment>/<student>/<file id>.
+    files JSONB NOT NULL DEFAULT '[]',
+    late BOOLEAN NOT NULL DEFAULT FALSE,
+    submitted_at TIMESTAMPTZ NOT NULL,
+    feedback TEXT NOT NULL DEFAULT '',
+    feedback_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    feedback_at TIMESTAMPTZ,
+    UNIQUE (assignment_id, student_user_id, attempt)
+);
+
+ALTER TABLE grades ADD COLUMN submission_id UUID REFERENCES submissions (id) ON DELETE SET NULL;
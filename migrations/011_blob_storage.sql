@@ -0,0 +1,5 @@
+-- Export archives now live in the blob store under exports/<id>.zip. Archives still in the
+-- table are dropped rather than copied, so those exports have to be requested again.
+ALTER TABLE data_exports
+    DROP COLUMN archive,
+    ADD COLUMN archive_stored BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS attendance_codes (
+    school_id UUID PRIMARY KEY REFERENCES schools (id) ON DELETE CASCADE,
+    codes JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS attendance_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    -- NULL for daily attendance
+    section_id UUID REFERENCES sections (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    code TEXT NOT NULL,
+    -- The type of the code when it was recorded, so editing a school's codes leaves
+    -- earlier attendance rates alone
+    type TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    recorded_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT attendance_records_student_date_section_key UNIQUE NULLS NOT DISTINCT (student_user_id, date, section_id)
+);
+
+CREATE INDEX IF NOT EXISTS attendance_records_school_date_idx ON attendance_records (school_id, date);
+CREATE INDEX IF NOT EXISTS attendance_records_section_date_idx ON attendance_records (section_id, date);
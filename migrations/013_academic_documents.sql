@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS academic_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    -- The term a report card covers. NULL for transcripts. Purging a school deletes its documents
+    -- before its terms, see schoolPurgeSteps.
+    term_id UUID REFERENCES terms (id) ON DELETE RESTRICT,
+    status TEXT NOT NULL,
+    version INT NOT NULL DEFAULT 0,
+    comment TEXT NOT NULL DEFAULT '',
+    course_comments JSONB NOT NULL DEFAULT '{}',
+    -- Compiled when the document is finalized. Drafts are compiled whenever they are read.
+    content JSONB,
+    pdf_checksum TEXT NOT NULL DEFAULT '',
+    signature TEXT NOT NULL DEFAULT '',
+    created_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    finalized_at TIMESTAMPTZ,
+    finalized_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL
+);
+
+-- A student has at most one draft of each document at a school
+CREATE UNIQUE INDEX IF NOT EXISTS academic_documents_draft_idx ON academic_documents (school_id, student_user_id, kind, term_id) NULLS NOT DISTINCT WHERE status = 'draft';
+CREATE INDEX IF NOT EXISTS academic_documents_student_user_id_idx ON academic_documents (student_user_id);
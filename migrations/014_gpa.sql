@@ -0,0 +1,58 @@
+ALTER TABLE courses ADD COLUMN level TEXT NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS gpa_policies (
+    school_id UUID PRIMARY KEY REFERENCES schools (id) ON DELETE CASCADE,
+    policy JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- The grade level each student is in at a school, which class rank compares within
+CREATE TABLE IF NOT EXISTS student_grade_levels (
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    grade_level INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (school_id, student_user_id)
+);
+
+-- A student's average in a section as GPAs count it, kept up to date from the gradebook
+CREATE TABLE IF NOT EXISTS course_marks (
+    section_id UUID NOT NULL REFERENCES sections (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    term_id UUID NOT NULL REFERENCES terms (id) ON DELETE CASCADE,
+    level TEXT NOT NULL,
+    credits DOUBLE PRECISION NOT NULL,
+    grade_points DOUBLE PRECISION,
+    status TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (section_id, student_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS course_marks_school_student_idx ON course_marks (school_id, student_user_id);
+
+-- Marks waiting to be recomputed after their grades changed
+CREATE TABLE IF NOT EXISTS gpa_queue (
+    section_id UUID NOT NULL REFERENCES sections (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    queued_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (section_id, student_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS gpa_queue_queued_at_idx ON gpa_queue (queued_at);
+
+-- Each student's GPA per term, and cumulatively where term_id is NULL
+CREATE TABLE IF NOT EXISTS student_gpas (
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    term_id UUID REFERENCES terms (id) ON DELETE CASCADE,
+    unweighted DOUBLE PRECISION,
+    weighted DOUBLE PRECISION,
+    credits DOUBLE PRECISION NOT NULL,
+    lowest_grade_points DOUBLE PRECISION,
+    computed_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT student_gpas_school_student_term_key UNIQUE NULLS NOT DISTINCT (school_id, student_user_id, term_id)
+);
+
+CREATE INDEX IF NOT EXISTS student_gpas_school_term_idx ON student_gpas (school_id, term_id);
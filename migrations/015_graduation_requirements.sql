@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS graduation_requirements (
+    organization_id UUID PRIMARY KEY REFERENCES organizations (id) ON DELETE CASCADE,
+    requirements JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Scores students earned on standardized and exit exams, which graduation requirements can ask for
+CREATE TABLE IF NOT EXISTS test_scores (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    test TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    taken_on DATE NOT NULL,
+    recorded_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS test_scores_organization_student_idx ON test_scores (organization_id, student_user_id);
@@ -0,0 +1,26 @@
+-- The courses students ask to take in a term, which the master schedule builder tries to fit
+CREATE TABLE IF NOT EXISTS course_requests (
+    term_id UUID NOT NULL REFERENCES terms (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses (id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    priority INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (term_id, student_user_id, course_id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_builds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    term_id UUID NOT NULL REFERENCES terms (id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    -- The periods, rooms and pinned placements the build was given
+    input JSONB NOT NULL,
+    result JSONB NOT NULL,
+    created_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    applied_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS schedule_builds_term_id_idx ON schedule_builds (term_id, created_at);
@@ -0,0 +1,32 @@
+-- A school's named periods and their times, e.g. a regular day, an early-release day or a block day
+CREATE TABLE IF NOT EXISTS bell_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    periods JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS bell_schedules_school_name_idx ON bell_schedules (school_id, lower(name));
+
+-- The cycle of days (e.g. A and B) a school runs through, one per instructional day from start_date
+CREATE TABLE IF NOT EXISTS bell_rotations (
+    school_id UUID PRIMARY KEY REFERENCES schools (id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    days JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Dates that run a different bell schedule than their rotation day, such as early release
+CREATE TABLE IF NOT EXISTS bell_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    bell_schedule_id UUID NOT NULL REFERENCES bell_schedules (id) ON DELETE RESTRICT,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (school_id, date)
+);
+
+ALTER TABLE sections ADD COLUMN IF NOT EXISTS periods JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,39 @@
+-- Rooms and equipment, such as gyms, labs and laptop carts, that a school's staff can book
+CREATE TABLE IF NOT EXISTS resources (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    capacity INT NOT NULL DEFAULT 0,
+    -- Bookings of restricted resources wait for an administrator's approval
+    restricted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS resources_school_name_idx ON resources (school_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    resource_id UUID NOT NULL REFERENCES resources (id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    period TEXT NOT NULL DEFAULT '',
+    start_time TEXT NOT NULL DEFAULT '',
+    end_time TEXT NOT NULL DEFAULT '',
+    repeat TEXT NOT NULL DEFAULT '',
+    until DATE,
+    -- The dates and times the booking resolved to when it was made
+    occurrences JSONB NOT NULL,
+    end_date DATE NOT NULL,
+    status TEXT NOT NULL,
+    requested_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_by_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS bookings_resource_dates_idx ON bookings (resource_id, start_date, end_date);
+CREATE INDEX IF NOT EXISTS bookings_school_status_idx ON bookings (school_id, status, start_date);
@@ -0,0 +1,22 @@
+-- Links between students and the parents or guardians who may follow their schooling
+CREATE TABLE IF NOT EXISTS guardianships (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    guardian_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    relationship TEXT NOT NULL,
+    custodial BOOLEAN NOT NULL DEFAULT FALSE,
+    contact_allowed BOOLEAN NOT NULL DEFAULT FALSE,
+    emergency_contact BOOLEAN NOT NULL DEFAULT FALSE,
+    pickup_authorized BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Parts of the student's record kept out of the guardian's portal
+    grades_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    attendance_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    schedule_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (organization_id, guardian_user_id, student_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS guardianships_guardian_idx ON guardianships (guardian_user_id);
+CREATE INDEX IF NOT EXISTS guardianships_student_idx ON guardianships (organization_id, student_user_id);
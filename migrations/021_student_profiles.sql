@@ -0,0 +1,33 @@
+-- What an organization keeps on record about each of its students
+CREATE TABLE IF NOT EXISTS student_profiles (
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- The organization's own number for the student, such as a state or district id
+    student_number TEXT NOT NULL DEFAULT '',
+    date_of_birth DATE,
+    grade_level TEXT NOT NULL DEFAULT '',
+    home_language TEXT NOT NULL DEFAULT '',
+    emergency_contacts JSONB NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS student_profiles_number_idx ON student_profiles (organization_id, student_number)
+    WHERE student_number <> '';
+
+-- The periods a student attended each school, from entry to withdrawal
+CREATE TABLE IF NOT EXISTS school_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    student_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    grade_level TEXT NOT NULL DEFAULT '',
+    entry_date DATE NOT NULL,
+    entry_code TEXT NOT NULL,
+    withdrawal_date DATE,
+    withdrawal_code TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS school_enrollments_student_idx ON school_enrollments (organization_id, student_user_id, entry_date);
@@ -0,0 +1,47 @@
+-- What an organization keeps on record about its teachers and administrators
+CREATE TABLE IF NOT EXISTS staff_profiles (
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    employee_number TEXT NOT NULL DEFAULT '',
+    job_title TEXT NOT NULL DEFAULT '',
+    hire_date DATE,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS staff_profiles_number_idx ON staff_profiles (organization_id, employee_number)
+    WHERE employee_number <> '';
+
+CREATE TABLE IF NOT EXISTS staff_certifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    number TEXT NOT NULL DEFAULT '',
+    issuer TEXT NOT NULL DEFAULT '',
+    issued_on DATE,
+    -- Certifications without an expiry date never lapse
+    expires_on DATE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS staff_certifications_user_idx ON staff_certifications (organization_id, user_id);
+CREATE INDEX IF NOT EXISTS staff_certifications_expiry_idx ON staff_certifications (organization_id, expires_on);
+
+-- The schools a staff member works at, with the share of a full-time position at each
+CREATE TABLE IF NOT EXISTS staff_assignments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    job_title TEXT NOT NULL DEFAULT '',
+    fte NUMERIC(4, 3) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS staff_assignments_user_idx ON staff_assignments (organization_id, user_id);
+CREATE INDEX IF NOT EXISTS staff_assignments_school_idx ON staff_assignments (school_id, start_date);
@@ -0,0 +1,12 @@
+-- Links OneRoster sourcedIds to the records imported from them, so a bundle can be imported
+-- again to update what it created
+CREATE TABLE IF NOT EXISTS oneroster_sourced_ids (
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    sourced_id TEXT NOT NULL,
+    -- Academic sessions become a year or term at each school that uses them, so they are keyed
+    -- by school too. Empty for every other type.
+    school_id TEXT NOT NULL DEFAULT '',
+    local_id UUID NOT NULL,
+    PRIMARY KEY (organization_id, type, sourced_id, school_id)
+);
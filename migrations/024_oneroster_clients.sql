@@ -0,0 +1,21 @@
+-- Vendors that read an organization's roster through the OneRoster REST API. Clients sign in
+-- with the OAuth 2 client credentials grant; only a hash of each secret is kept.
+CREATE TABLE IF NOT EXISTS oneroster_clients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    secret_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS oneroster_clients_organization_id_idx ON oneroster_clients (organization_id);
+
+CREATE TABLE IF NOT EXISTS oneroster_tokens (
+    token_hash TEXT PRIMARY KEY,
+    client_id UUID NOT NULL REFERENCES oneroster_clients (id) ON DELETE CASCADE,
+    scope TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS oneroster_tokens_client_id_idx ON oneroster_tokens (client_id);
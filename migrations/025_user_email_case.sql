@@ -0,0 +1,6 @@
+-- Emails are compared without regard to case, see normalizeEmail. Creating the index fails if two
+-- live users share an email that differs only by case; merge or rename those accounts first.
+DROP INDEX IF EXISTS users_email_live_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_live_idx ON users (lower(email)) WHERE deleted_at IS NULL;
+
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
@@ -0,0 +1,16 @@
+-- User imports are queued and run in the background, since hashing every row's password can take
+-- minutes. The uploaded file holds plaintext passwords and is dropped once the import has run.
+CREATE TABLE IF NOT EXISTS user_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    requested_by_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    data BYTEA,
+    report JSONB,
+    created_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS user_imports_organization_id_idx ON user_imports (organization_id);
+CREATE INDEX IF NOT EXISTS user_imports_pending_idx ON user_imports (created_at) WHERE status = 'pending';
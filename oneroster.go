@@ -0,0 +1,2592 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxOneRosterBundleSize = 50 << 20
+	// Limits how far a single file may inflate when unzipped
+	maxOneRosterFileSize = 200 << 20
+	// Seats given to classes the import creates, raised to fit the students the bundle enrolls
+	defaultOneRosterClassCapacity = 30
+	oneRosterStatusDeleted        = "tobedeleted"
+)
+
+// The kinds of record a sourcedId can stand for
+const (
+	OneRosterTypeOrg             = "org"
+	OneRosterTypeAcademicSession = "academicSession"
+	OneRosterTypeCourse          = "course"
+	OneRosterTypeClass           = "class"
+	OneRosterTypeUser            = "user"
+	OneRosterTypeEnrollment      = "enrollment"
+)
+
+// What an import does with a record
+const (
+	OneRosterActionCreate    = "create"
+	OneRosterActionUpdate    = "update"
+	OneRosterActionUnchanged = "unchanged"
+	OneRosterActionDelete    = "delete"
+	OneRosterActionSkip      = "skip"
+)
+
+// The OneRoster 1.2 CSV files the import reads and the export writes, with their columns in order
+var oneRosterColumns = map[string][]string{
+	"orgs.csv":             {"sourcedId", "status", "dateLastModified", "name", "type", "identifier", "parentSourcedId"},
+	"academicSessions.csv": {"sourcedId", "status", "dateLastModified", "title", "type", "startDate", "endDate", "parentSourcedId", "schoolYear"},
+	"courses.csv":          {"sourcedId", "status", "dateLastModified", "schoolYearSourcedId", "title", "courseCode", "grades", "orgSourcedId", "subjects", "subjectCodes"},
+	"classes.csv": {
+		"sourcedId", "status", "dateLastModified", "title", "grades", "courseSourcedId", "classCode", "classType", "location",
+		"schoolSourcedId", "termSourcedIds", "subjects", "subjectCodes", "periods",
+	},
+	"users.csv": {
+		"sourcedId", "status", "dateLastModified", "enabledUser", "username", "userIds", "givenName", "familyName", "middleName",
+		"identifier", "email", "sms", "phone", "agentSourcedIds", "grades", "password", "userMasterIdentifier", "resourceSourcedIds",
+		"preferredGivenName", "preferredMiddleName", "preferredFamilyName", "primaryOrgSourcedId", "pronouns",
+	},
+	"roles.csv":       {"sourcedId", "status", "dateLastModified", "userSourcedId", "roleType", "role", "beginDate", "endDate", "orgSourcedId", "userProfileSourcedId"},
+	"enrollments.csv": {"sourcedId", "status", "dateLastModified", "classSourcedId", "schoolSourcedId", "userSourcedId", "role", "primary", "beginDate", "endDate"},
+}
+
+var oneRosterFiles = []string{"orgs.csv", "academicSessions.csv", "courses.csv", "classes.csv", "users.csv", "roles.csv", "enrollments.csv"}
+
+// Every data file a 1.2 manifest lists, so the export can mark the ones it leaves out as absent
+var oneRosterManifestFiles = []string{
+	"academicSessions", "categories", "classes", "classResources", "courses", "courseResources", "demographics", "enrollments",
+	"lineItemLearningObjectiveIds", "lineItems", "lineItemScoreScales", "orgs", "resources", "resultLearningObjectiveIds",
+	"results", "resultScoreScales", "roles", "scoreScales", "userProfiles", "userResources", "users",
+}
+
+var oneRosterSessionTypes = []string{"schoolYear", "semester", "term", "gradingPeriod"}
+
+// OneRoster roles and the organization role each is imported as. Guardians and other relatives
+// are imported as users without a membership.
+var oneRosterRoles = map[string]string{
+	"administrator":         RoleAdmin,
+	"districtAdministrator": RoleAdmin,
+	"siteAdministrator":     RoleAdmin,
+	"systemAdministrator":   RoleAdmin,
+	"principal":             RoleAdmin,
+	"teacher":               RoleTeacher,
+	"aide":                  RoleTeacher,
+	"proctor":               RoleTeacher,
+	"student":               RoleStudent,
+	"guardian":              "",
+	"parent":                "",
+	"relative":              "",
+}
+
+var oneRosterRoleNames = map[string]string{RoleAdmin: "administrator", RoleTeacher: "teacher", RoleStudent: "student"}
+
+// Converts OneRoster grade codes to course grade levels. Codes outside pre-kindergarten to
+// grade 12, such as IT or PS, have no course grade level and are dropped.
+func oneRosterGradeLevels(codes []string) []int {
+	levels := []int{}
+
+	for _, code := range codes {
+		var level int
+
+		switch code {
+		case "PK":
+			level = -1
+		case "KG":
+			level = 0
+		default:
+			n, err := strconv.Atoi(code)
+
+			if err != nil || len(code) != 2 || n < 1 || n > maxGradeLevel {
+				continue
+			}
+
+			level = n
+		}
+
+		if !slices.Contains(levels, level) {
+			levels = append(levels, level)
+		}
+	}
+
+	slices.Sort(levels)
+
+	return levels
+}
+
+func oneRosterGradeCodes(levels []int) []string {
+	codes := make([]string, 0, len(levels))
+
+	for _, level := range levels {
+		switch level {
+		case -1:
+			codes = append(codes, "PK")
+		case 0:
+			codes = append(codes, "KG")
+		default:
+			codes = append(codes, fmt.Sprintf("%02d", level))
+		}
+	}
+
+	return codes
+}
+
+// OneRoster has one "term" type for trimesters, quarters and the like, so its title decides
+func oneRosterTermType(session *oneRosterSession) string {
+	switch session.kind {
+	case "gradingPeriod":
+		return TermTypeGradingPeriod
+	case "term":
+		title := strings.ToLower(session.title)
+
+		if strings.Contains(title, "trimester") {
+			return TermTypeTrimester
+		}
+
+		if strings.Contains(title, "quarter") {
+			return TermTypeQuarter
+		}
+	}
+
+	return TermTypeSemester
+}
+
+func oneRosterSessionType(termType string) string {
+	switch termType {
+	case TermTypeSemester:
+		return "semester"
+	case TermTypeGradingPeriod:
+		return "gradingPeriod"
+	}
+
+	return "term"
+}
+
+// A data row of a bundle file, keyed by column name
+type oneRosterRow struct {
+	file   string
+	line   int
+	values map[string]string
+}
+
+func (r *oneRosterRow) get(column string) string {
+	return strings.TrimSpace(r.values[column])
+}
+
+// Splits a field holding a comma separated list
+func (r *oneRosterRow) list(column string) []string {
+	var values []string
+
+	for _, value := range strings.Split(r.get(column), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func (r *oneRosterRow) deleted() bool {
+	return strings.EqualFold(r.get("status"), oneRosterStatusDeleted)
+}
+
+// Reads the files of a OneRoster CSV bundle that the import understands, keyed by file name.
+// Files may sit in a folder inside the archive.
+func readOneRosterBundle(data []byte) (map[string][]*oneRosterRow, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return nil, errors.New("bundle must be a zip archive")
+	}
+
+	files := map[string][]*oneRosterRow{}
+
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+
+		if _, ok := oneRosterColumns[name]; !ok || file.FileInfo().IsDir() {
+			continue
+		}
+
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("bundle has more than one %s", name)
+		}
+
+		body, err := file.Open()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(body, maxOneRosterFileSize+1))
+		body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if len(content) > maxOneRosterFileSize {
+			return nil, fmt.Errorf("%s must not exceed %d MB", name, maxOneRosterFileSize>>20)
+		}
+
+		rows, err := readOneRosterCSV(name, content)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files[name] = rows
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("bundle has none of the files %s", strings.Join(oneRosterFiles, ", "))
+	}
+
+	return files, nil
+}
+
+func readOneRosterCSV(name string, content []byte) ([]*oneRosterRow, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	header, err := reader.Read()
+
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	// Spreadsheet programs often save a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	if !slices.Contains(header, "sourcedId") {
+		return nil, fmt.Errorf("%s has no sourcedId column", name)
+	}
+
+	var rows []*oneRosterRow
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]string, len(header))
+
+		for i, column := range header {
+			values[column] = record[i]
+		}
+
+		rows = append(rows, &oneRosterRow{file: name, line: line, values: values})
+	}
+
+	return rows, nil
+}
+
+// Writes a OneRoster bundle with a manifest and every file the export produces, keyed by file
+// name with each row keyed by column
+func writeOneRosterBundle(files map[string][]map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	manifest := [][]string{{"propertyName", "value"}, {"manifest.version", "1.0"}, {"oneroster.version", "1.2"}}
+
+	for _, name := range oneRosterManifestFiles {
+		mode := "absent"
+
+		if slices.Contains(oneRosterFiles, name+".csv") {
+			mode = "bulk"
+		}
+
+		manifest = append(manifest, []string{"file." + name, mode})
+	}
+
+	manifest = append(manifest, []string{"source.systemName", "Divinity"}, []string{"source.systemCode", ""})
+
+	if err := writeOneRosterCSV(archive, "manifest.csv", manifest); err != nil {
+		return nil, err
+	}
+
+	for _, name := range oneRosterFiles {
+		columns := oneRosterColumns[name]
+		records := [][]string{columns}
+
+		for _, row := range files[name] {
+			record := make([]string, len(columns))
+
+			for i, column := range columns {
+				record[i] = row[column]
+			}
+
+			records = append(records, record)
+		}
+
+		if err := writeOneRosterCSV(archive, name, records); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeOneRosterCSV(archive *zip.Writer, name string, records [][]string) error {
+	w, err := archive.Create(name)
+
+	if err != nil {
+		return err
+	}
+
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+// Links a OneRoster sourcedId to the local record imported from it
+type OneRosterSourcedID struct {
+	OrganizationID string `json:"organizationId"`
+	Type           string `json:"type"`
+	SourcedID      string `json:"sourcedId"`
+	// Set for academic sessions, which become a year or term at each school that uses them
+	SchoolID string `json:"schoolId"`
+	LocalID  string `json:"localId"`
+}
+
+type OneRosterPostgresStore struct {
+	db *PostgresDB
+}
+
+type OneRosterStore interface {
+	ListSourcedIDs(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error)
+	// Links the sourcedId to a local record, replacing any earlier link
+	SaveSourcedID(ctx context.Context, sourcedID *OneRosterSourcedID) error
+	CreateClient(ctx context.Context, client *OneRosterClient) error
+	GetClient(ctx context.Context, id string) (*OneRosterClient, error)
+	ListClients(ctx context.Context, organizationID string) ([]*OneRosterClient, error)
+	// Deletes the client and the access tokens issued to it
+	DeleteClient(ctx context.Context, id string) error
+	// Stores the access token, clearing out the client's expired ones
+	CreateToken(ctx context.Context, token *OneRosterAccessToken) error
+	// Returns the access token along with its client's organization
+	GetTokenByHash(ctx context.Context, tokenHash string) (*OneRosterAccessToken, error)
+}
+
+func (s *OneRosterPostgresStore) ListSourcedIDs(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error) {
+	query := `
+		SELECT organization_id, type, sourced_id, school_id, local_id
+		FROM oneroster_sourced_ids
+		WHERE organization_id = $1
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var sourcedIDs []*OneRosterSourcedID
+
+	for rows.Next() {
+		var sourcedID OneRosterSourcedID
+
+		if err := rows.Scan(&sourcedID.OrganizationID, &sourcedID.Type, &sourcedID.SourcedID, &sourcedID.SchoolID, &sourcedID.LocalID); err != nil {
+			return nil, err
+		}
+
+		sourcedIDs = append(sourcedIDs, &sourcedID)
+	}
+
+	return sourcedIDs, rows.Err()
+}
+
+func (s *OneRosterPostgresStore) SaveSourcedID(ctx context.Context, sourcedID *OneRosterSourcedID) error {
+	query := `
+		INSERT INTO oneroster_sourced_ids (organization_id, type, sourced_id, school_id, local_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id, type, sourced_id, school_id) DO UPDATE SET local_id = excluded.local_id
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, sourcedID.OrganizationID, sourcedID.Type, sourcedID.SourcedID, sourcedID.SchoolID, sourcedID.LocalID)
+
+	return err
+}
+
+// One record an import creates, updates, deletes or leaves alone
+type OneRosterChange struct {
+	Type      string `json:"type"`
+	SourcedID string `json:"sourcedId"`
+	// The school an academic session is imported at
+	SchoolSourcedID string `json:"schoolSourcedId,omitempty"`
+	Action          string `json:"action"`
+	// Empty for records a dry run would create
+	LocalID string `json:"localId,omitempty"`
+	// The fields an update changes
+	Fields  []string `json:"fields,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// A bundle row the import cannot use. Nothing is imported while any remain.
+type OneRosterRowError struct {
+	File      string `json:"file"`
+	Line      int    `json:"line,omitempty"`
+	SourcedID string `json:"sourcedId"`
+	Message   string `json:"message"`
+}
+
+type OneRosterImportResult struct {
+	DryRun  bool `json:"dryRun"`
+	Applied bool `json:"applied"`
+	// Counts of changes by type and action
+	Summary map[string]map[string]int `json:"summary"`
+	Changes []*OneRosterChange        `json:"changes"`
+	Errors  []*OneRosterRowError      `json:"errors"`
+}
+
+func (r *OneRosterImportResult) summarize() {
+	r.Summary = map[string]map[string]int{}
+
+	for _, change := range r.Changes {
+		if r.Summary[change.Type] == nil {
+			r.Summary[change.Type] = map[string]int{}
+		}
+
+		r.Summary[change.Type][change.Action]++
+	}
+}
+
+// An academic session row, checked and parsed
+type oneRosterSession struct {
+	row       *oneRosterRow
+	title     string
+	kind      string
+	startDate Date
+	endDate   Date
+	parent    string
+	used      bool
+}
+
+type oneRosterKey struct {
+	kind      string
+	sourcedID string
+	school    string
+}
+
+// A teacher of a class being imported. The user may not be saved yet.
+type oneRosterTeacher struct {
+	user *User
+	role string
+}
+
+// Works out what importing a bundle changes. Records are matched by the sourcedIds of earlier
+// imports, falling back to natural keys such as course codes, and each change queues a step
+// that applies it. Steps read ids when they run, so they can refer to records created by the
+// steps before them.
+type oneRosterPlan struct {
+	service       *OneRosterService
+	organization  *Organization
+	sessionUserID string
+	timezone      string
+	files         map[string][]*oneRosterRow
+	localIDs      map[oneRosterKey]string
+	result        *OneRosterImportResult
+	steps         []func(ctx context.Context) error
+
+	// The sourcedId of the row that matched each existing record
+	claimed         map[string]string
+	existingSchools []*School
+	schools         map[string]*School
+	sessions        map[string]*oneRosterSession
+	years           map[oneRosterKey]*AcademicYear
+	terms           map[oneRosterKey]*Term
+	// Years and terms at each school by school sourcedId, including those being created
+	schoolYears    map[string][]*AcademicYear
+	schoolTerms    map[string][]*Term
+	courses        map[string]*Course
+	courseCodes    map[string]string
+	users          map[string]*User
+	emails         map[string]string
+	memberRoles    map[string]string
+	sections       map[string]*Section
+	classChanges   map[string]*OneRosterChange
+	outsideClasses []string
+	teachers       map[string][]*oneRosterTeacher
+	enrollments    map[string][]*Enrollment
+	seats          map[string]int
+	enrolled       map[string]string
+}
+
+func newOneRosterPlan(service *OneRosterService, organization *Organization, sessionUserID string, timezone string, files map[string][]*oneRosterRow, links []*OneRosterSourcedID) *oneRosterPlan {
+	localIDs := make(map[oneRosterKey]string, len(links))
+
+	for _, link := range links {
+		localIDs[oneRosterKey{link.Type, link.SourcedID, link.SchoolID}] = link.LocalID
+	}
+
+	return &oneRosterPlan{
+		service:       service,
+		organization:  organization,
+		sessionUserID: sessionUserID,
+		timezone:      timezone,
+		files:         files,
+		localIDs:      localIDs,
+		result:        &OneRosterImportResult{Changes: []*OneRosterChange{}, Errors: []*OneRosterRowError{}},
+		claimed:       map[string]string{},
+		schools:       map[string]*School{},
+		sessions:      map[string]*oneRosterSession{},
+		years:         map[oneRosterKey]*AcademicYear{},
+		terms:         map[oneRosterKey]*Term{},
+		schoolYears:   map[string][]*AcademicYear{},
+		schoolTerms:   map[string][]*Term{},
+		courses:       map[string]*Course{},
+		courseCodes:   map[string]string{},
+		users:         map[string]*User{},
+		emails:        map[string]string{},
+		memberRoles:   map[string]string{},
+		sections:      map[string]*Section{},
+		classChanges:  map[string]*OneRosterChange{},
+		teachers:      map[string][]*oneRosterTeacher{},
+		enrollments:   map[string][]*Enrollment{},
+		seats:         map[string]int{},
+		enrolled:      map[string]string{},
+	}
+}
+
+func (p *oneRosterPlan) build(ctx context.Context) error {
+	if err := p.planOrgs(ctx); err != nil {
+		return err
+	}
+
+	p.indexSessions()
+
+	if err := p.planCourses(ctx); err != nil {
+		return err
+	}
+
+	if err := p.planUsers(ctx); err != nil {
+		return err
+	}
+
+	if err := p.planClasses(ctx); err != nil {
+		return err
+	}
+
+	if err := p.planEnrollments(ctx); err != nil {
+		return err
+	}
+
+	for _, row := range p.files["academicSessions.csv"] {
+		if session := p.sessions[row.get("sourcedId")]; session != nil && !session.used {
+			p.change(OneRosterTypeAcademicSession, row.get("sourcedId"), OneRosterActionSkip, "").Message = "not used by any class"
+		}
+	}
+
+	return nil
+}
+
+func (p *oneRosterPlan) fail(row *oneRosterRow, format string, args ...any) {
+	p.result.Errors = append(p.result.Errors, &OneRosterRowError{File: row.file, Line: row.line, SourcedID: row.get("sourcedId"), Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *oneRosterPlan) change(kind string, sourcedID string, action string, localID string) *OneRosterChange {
+	change := &OneRosterChange{Type: kind, SourcedID: sourcedID, Action: action, LocalID: localID}
+	p.result.Changes = append(p.result.Changes, change)
+
+	return change
+}
+
+func (p *oneRosterPlan) step(apply func(ctx context.Context) error) {
+	p.steps = append(p.steps, apply)
+}
+
+func (p *oneRosterPlan) link(ctx context.Context, kind string, sourcedID string, schoolID string, localID string) error {
+	return p.service.oneRosterStore.SaveSourcedID(ctx, &OneRosterSourcedID{
+		OrganizationID: p.organization.ID,
+		Type:           kind,
+		SourcedID:      sourcedID,
+		SchoolID:       schoolID,
+		LocalID:        localID,
+	})
+}
+
+// Records the change to an existing record and queues saving it, when anything changed, and
+// linking it to its sourcedId. Later planning may still turn the change into an update.
+func (p *oneRosterPlan) update(kind string, sourcedID string, localID string, fields []string, save func(ctx context.Context) error) *OneRosterChange {
+	action := OneRosterActionUnchanged
+
+	if len(fields) > 0 {
+		action = OneRosterActionUpdate
+	}
+
+	change := p.change(kind, sourcedID, action, localID)
+	change.Fields = fields
+
+	p.step(func(ctx context.Context) error {
+		if change.Action == OneRosterActionUpdate {
+			if err := save(ctx); err != nil {
+				return err
+			}
+		}
+
+		return p.link(ctx, kind, sourcedID, "", localID)
+	})
+
+	return change
+}
+
+// Returns the file's rows that have a sourcedId, reporting the rest and any repeats
+func (p *oneRosterPlan) rows(file string) []*oneRosterRow {
+	seen := map[string]bool{}
+	var rows []*oneRosterRow
+
+	for _, row := range p.files[file] {
+		sourcedID := row.get("sourcedId")
+
+		switch {
+		case sourcedID == "":
+			p.fail(row, "sourcedId is required")
+		case seen[sourcedID]:
+			p.fail(row, "sourcedId %s appears more than once", sourcedID)
+		default:
+			seen[sourcedID] = true
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Reports whether the existing record is free for the row to match, failing the row when
+// another row matched it already
+func (p *oneRosterPlan) claim(row *oneRosterRow, localID string) bool {
+	if other, ok := p.claimed[localID]; ok {
+		p.fail(row, "matches the same record as %s", other)
+		return false
+	}
+
+	p.claimed[localID] = row.get("sourcedId")
+
+	return true
+}
+
+func (p *oneRosterPlan) planOrgs(ctx context.Context) error {
+	schools, err := p.service.schoolStore.ListByOrganization(ctx, p.organization.ID)
+
+	if err != nil {
+		return err
+	}
+
+	p.existingSchools = schools
+
+	for _, row := range p.rows("orgs.csv") {
+		sourcedID := row.get("sourcedId")
+
+		if row.deleted() {
+			p.change(OneRosterTypeOrg, sourcedID, OneRosterActionSkip, "").Message = "deleting orgs is not supported"
+			continue
+		}
+
+		switch row.get("type") {
+		case "district":
+			// Districts stand for the organization being imported into
+			p.change(OneRosterTypeOrg, sourcedID, OneRosterActionUnchanged, p.organization.ID)
+			p.step(func(ctx context.Context) error {
+				return p.link(ctx, OneRosterTypeOrg, sourcedID, "", p.organization.ID)
+			})
+		case "school":
+			if err := p.planSchool(ctx, row); err != nil {
+				return err
+			}
+		default:
+			p.change(OneRosterTypeOrg, sourcedID, OneRosterActionSkip, "").Message = fmt.Sprintf("orgs of type %q are not imported", row.get("type"))
+		}
+	}
+
+	return nil
+}
+
+// Returns the school imported from sourcedID earlier, or nil
+func (p *oneRosterPlan) findSchool(ctx context.Context, sourcedID string) (*School, error) {
+	id := p.localIDs[oneRosterKey{OneRosterTypeOrg, sourcedID, ""}]
+
+	if id == "" {
+		return nil, nil
+	}
+
+	school, err := p.service.schoolStore.GetByID(ctx, id)
+
+	if err != nil || school == nil || school.OrganizationID != p.organization.ID {
+		return nil, err
+	}
+
+	return school, nil
+}
+
+func (p *oneRosterPlan) planSchool(ctx context.Context, row *oneRosterRow) error {
+	sourcedID := row.get("sourcedId")
+	name := row.get("name")
+
+	if name == "" {
+		p.fail(row, "name is required")
+		return nil
+	}
+
+	school, err := p.findSchool(ctx, sourcedID)
+
+	if err != nil {
+		return err
+	}
+
+	if school == nil {
+		for _, existing := range p.existingSchools {
+			if _, ok := p.claimed[existing.ID]; !ok && strings.EqualFold(existing.Name, name) {
+				copied := *existing
+				school = &copied
+				break
+			}
+		}
+	}
+
+	if school == nil {
+		school = &School{OrganizationID: p.organization.ID, Name: name, Timezone: p.timezone}
+
+		if err := validateSchool(school); err != nil {
+			p.fail(row, "%v", err)
+			return nil
+		}
+
+		change := p.change(OneRosterTypeOrg, sourcedID, OneRosterActionCreate, "")
+
+		p.step(func(ctx context.Context) error {
+			school.CreatedAt = time.Now()
+			school.UpdatedAt = time.Now()
+
+			if err := p.service.schoolStore.Create(ctx, school); err != nil {
+				return err
+			}
+
+			change.LocalID = school.ID
+
+			return p.link(ctx, OneRosterTypeOrg, sourcedID, "", school.ID)
+		})
+	} else {
+		if !p.claim(row, school.ID) {
+			return nil
+		}
+
+		var fields []string
+
+		if school.Name != name {
+			school.Name = name
+			fields = append(fields, "name")
+		}
+
+		p.update(OneRosterTypeOrg, sourcedID, school.ID, fields, func(ctx context.Context) error {
+			school.UpdatedAt = time.Now()
+			return p.service.schoolStore.Update(ctx, school)
+		})
+	}
+
+	p.schools[sourcedID] = school
+
+	return nil
+}
+
+// Returns the school by sourcedId from orgs.csv or an earlier import, or nil
+func (p *oneRosterPlan) resolveSchool(ctx context.Context, sourcedID string) (*School, error) {
+	if school, ok := p.schools[sourcedID]; ok {
+		return school, nil
+	}
+
+	school, err := p.findSchool(ctx, sourcedID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.schools[sourcedID] = school
+
+	return school, nil
+}
+
+// Checks the academic sessions. Years and terms are planned as classes use them.
+func (p *oneRosterPlan) indexSessions() {
+	for _, row := range p.rows("academicSessions.csv") {
+		sourcedID := row.get("sourcedId")
+
+		if row.deleted() {
+			p.change(OneRosterTypeAcademicSession, sourcedID, OneRosterActionSkip, "").Message = "deleting academic sessions is not supported"
+			continue
+		}
+
+		session := &oneRosterSession{row: row, title: row.get("title"), kind: row.get("type"), parent: row.get("parentSourcedId")}
+
+		if session.title == "" {
+			p.fail(row, "title is required")
+			continue
+		}
+
+		if !slices.Contains(oneRosterSessionTypes, session.kind) {
+			p.fail(row, "type must be one of %v", oneRosterSessionTypes)
+			continue
+		}
+
+		var err error
+
+		if session.startDate, err = ParseDate(row.get("startDate")); err != nil {
+			p.fail(row, "startDate: %v", err)
+			continue
+		}
+
+		if session.endDate, err = ParseDate(row.get("endDate")); err != nil {
+			p.fail(row, "endDate: %v", err)
+			continue
+		}
+
+		if err := validateDateRange(session.startDate, session.endDate); err != nil {
+			p.fail(row, "%v", err)
+			continue
+		}
+
+		p.sessions[sourcedID] = session
+	}
+}
+
+func (p *oneRosterPlan) yearsAt(ctx context.Context, schoolSourcedID string, school *School) ([]*AcademicYear, error) {
+	if years, ok := p.schoolYears[schoolSourcedID]; ok || school.ID == "" {
+		return years, nil
+	}
+
+	years, err := p.service.calendarStore.ListAcademicYears(ctx, school.ID)
+	p.schoolYears[schoolSourcedID] = years
+
+	return years, err
+}
+
+func (p *oneRosterPlan) termsAt(ctx context.Context, schoolSourcedID string, school *School) ([]*Term, error) {
+	if terms, ok := p.schoolTerms[schoolSourcedID]; ok || school.ID == "" {
+		return terms, nil
+	}
+
+	terms, err := p.service.calendarStore.ListTerms(ctx, school.ID)
+	p.schoolTerms[schoolSourcedID] = terms
+
+	return terms, err
+}
+
+// Returns the academic year the school year session becomes at the school, planning it if
+// needed. Returns nil after failing from when there is none.
+func (p *oneRosterPlan) ensureYear(ctx context.Context, sourcedID string, schoolSourcedID string, school *School, from *oneRosterRow) (*AcademicYear, error) {
+	key := oneRosterKey{OneRosterTypeAcademicSession, sourcedID, schoolSourcedID}
+
+	if year, ok := p.years[key]; ok {
+		return year, nil
+	}
+
+	p.years[key] = nil
+	session := p.sessions[sourcedID]
+
+	if session != nil {
+		session.used = true
+	}
+
+	years, err := p.yearsAt(ctx, schoolSourcedID, school)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var year *AcademicYear
+
+	if id := p.localIDs[oneRosterKey{OneRosterTypeAcademicSession, sourcedID, school.ID}]; id != "" && school.ID != "" {
+		if year, err = p.service.calendarStore.GetAcademicYear(ctx, id); err != nil {
+			return nil, err
+		}
+
+		if year != nil && year.SchoolID != school.ID {
+			year = nil
+		}
+	}
+
+	if year == nil && session == nil {
+		p.fail(from, "academic session %s is not in academicSessions.csv", sourcedID)
+		return nil, nil
+	}
+
+	if year == nil {
+		for _, existing := range years {
+			if existing.ID != "" && strings.EqualFold(existing.Name, session.title) {
+				year = existing
+				break
+			}
+		}
+	}
+
+	if year != nil {
+		change := p.change(OneRosterTypeAcademicSession, sourcedID, OneRosterActionUnchanged, year.ID)
+		change.SchoolSourcedID = schoolSourcedID
+
+		if session != nil && (!year.StartDate.Equal(session.startDate.Time) || !year.EndDate.Equal(session.endDate.Time)) {
+			change.Message = "dates differ from the bundle and are left as is"
+		}
+
+		yearID, schoolID := year.ID, school.ID
+
+		p.step(func(ctx context.Context) error {
+			return p.link(ctx, OneRosterTypeAcademicSession, sourcedID, schoolID, yearID)
+		})
+	} else {
+		year = &AcademicYear{Name: session.title, StartDate: session.startDate, EndDate: session.endDate}
+
+		if err := validateAcademicYear(year, years); err != nil {
+			p.fail(session.row, "at school %s: %v", schoolSourcedID, err)
+			return nil, nil
+		}
+
+		change := p.change(OneRosterTypeAcademicSession, sourcedID, OneRosterActionCreate, "")
+		change.SchoolSourcedID = schoolSourcedID
+
+		p.step(func(ctx context.Context) error {
+			year.SchoolID = school.ID
+			year.CreatedAt = time.Now()
+
+			if err := p.service.calendarStore.CreateAcademicYear(ctx, year); err != nil {
+				return err
+			}
+
+			change.LocalID = year.ID
+
+			return p.link(ctx, OneRosterTypeAcademicSession, sourcedID, school.ID, year.ID)
+		})
+
+		p.schoolYears[schoolSourcedID] = append(years, year)
+	}
+
+	p.years[key] = year
+
+	return year, nil
+}
+
+// Returns the term the session becomes at the school, planning it and its school year if
+// needed. Returns nil after failing from when there is none.
+func (p *oneRosterPlan) ensureTerm(ctx context.Context, sourcedID string, schoolSourcedID string, school *School, from *oneRosterRow) (*Term, error) {
+	key := oneRosterKey{OneRosterTypeAcademicSession, sourcedID, schoolSourcedID}
+
+	if term, ok := p.terms[key]; ok {
+		return term, nil
+	}
+
+	p.terms[key] = nil
+	session := p.sessions[sourcedID]
+
+	if session != nil {
+		session.used = true
+	}
+
+	terms, err := p.termsAt(ctx, schoolSourcedID, school)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var term *Term
+
+	if id := p.localIDs[oneRosterKey{OneRosterTypeAcademicSession, sourcedID, school.ID}]; id != "" && school.ID != "" {
+		if term, err = p.service.calendarStore.GetTerm(ctx, id); err != nil {
+			return nil, err
+		}
+
+		if term != nil && term.SchoolID != school.ID {
+			term = nil
+		}
+	}
+
+	if term == nil && session == nil {
+		p.fail(from, "academic session %s is not in academicSessions.csv", sourcedID)
+		return nil, nil
+	}
+
+	if term == nil && session.kind == "schoolYear" {
+		p.fail(from, "classes must be in a term, semester or grading period rather than school year %s", sourcedID)
+		return nil, nil
+	}
+
+	if term == nil {
+		for _, existing := range terms {
+			if existing.ID != "" && strings.EqualFold(existing.Name, session.title) {
+				term = existing
+				break
+			}
+		}
+	}
+
+	if term != nil {
+		change := p.change(OneRosterTypeAcademicSession, sourcedID, OneRosterActionUnchanged, term.ID)
+		change.SchoolSourcedID = schoolSourcedID
+
+		if session != nil && (!term.StartDate.Equal(session.startDate.Time) || !term.EndDate.Equal(session.endDate.Time)) {
+			change.Message = "dates differ from the bundle and are left as is"
+		}
+
+		termID, schoolID := term.ID, school.ID
+
+		p.step(func(ctx context.Context) error {
+			return p.link(ctx, OneRosterTypeAcademicSession, sourcedID, schoolID, termID)
+		})
+
+		p.terms[key] = term
+
+		return term, nil
+	}
+
+	// Grading periods may sit inside a semester, so walk up to the school year
+	yearSourcedID := session.parent
+
+	for seen := map[string]bool{}; yearSourcedID != "" && !seen[yearSourcedID]; {
+		seen[yearSourcedID] = true
+		parent := p.sessions[yearSourcedID]
+
+		if parent == nil || parent.kind == "schoolYear" {
+			break
+		}
+
+		yearSourcedID = parent.parent
+	}
+
+	if yearSourcedID == "" {
+		p.fail(session.row, "parentSourcedId must lead to a school year")
+		return nil, nil
+	}
+
+	year, err := p.ensureYear(ctx, yearSourcedID, schoolSourcedID, school, session.row)
+
+	if err != nil || year == nil {
+		return nil, err
+	}
+
+	term = &Term{AcademicYearID: year.ID, Name: session.title, Type: oneRosterTermType(session), StartDate: session.startDate, EndDate: session.endDate}
+
+	if err := validateTerm(term, year, terms); err != nil {
+		p.fail(session.row, "at school %s: %v", schoolSourcedID, err)
+		return nil, nil
+	}
+
+	change := p.change(OneRosterTypeAcademicSession, sourcedID, OneRosterActionCreate, "")
+	change.SchoolSourcedID = schoolSourcedID
+
+	p.step(func(ctx context.Context) error {
+		term.SchoolID = school.ID
+		term.AcademicYearID = year.ID
+		term.CreatedAt = time.Now()
+
+		if err := p.service.calendarStore.CreateTerm(ctx, term); err != nil {
+			return err
+		}
+
+		change.LocalID = term.ID
+
+		return p.link(ctx, OneRosterTypeAcademicSession, sourcedID, school.ID, term.ID)
+	})
+
+	p.schoolTerms[schoolSourcedID] = append(terms, term)
+	p.terms[key] = term
+
+	return term, nil
+}
+
+// Returns the course imported from sourcedID earlier, or nil
+func (p *oneRosterPlan) findCourse(ctx context.Context, sourcedID string) (*Course, error) {
+	id := p.localIDs[oneRosterKey{OneRosterTypeCourse, sourcedID, ""}]
+
+	if id == "" {
+		return nil, nil
+	}
+
+	course, err := p.service.courseStore.GetByID(ctx, id)
+
+	if err != nil || course == nil || course.OrganizationID != p.organization.ID {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func (p *oneRosterPlan) planCourses(ctx context.Context) error {
+	for _, row := range p.rows("courses.csv") {
+		sourcedID := row.get("sourcedId")
+
+		if row.deleted() {
+			p.change(OneRosterTypeCourse, sourcedID, OneRosterActionSkip, "").Message = "deleting courses is not supported"
+			continue
+		}
+
+		code, title := row.get("courseCode"), row.get("title")
+
+		if code == "" || title == "" {
+			p.fail(row, "courseCode and title are required")
+			continue
+		}
+
+		if other, ok := p.courseCodes[strings.ToLower(code)]; ok {
+			p.fail(row, "courseCode %s is also used by %s", code, other)
+			continue
+		}
+
+		p.courseCodes[strings.ToLower(code)] = sourcedID
+
+		course, err := p.findCourse(ctx, sourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		holder, err := p.service.courseStore.GetByCode(ctx, p.organization.ID, code)
+
+		if err != nil {
+			return err
+		}
+
+		if course == nil {
+			course = holder
+		} else if holder != nil && holder.ID != course.ID {
+			p.fail(row, "courseCode %s belongs to another course", code)
+			continue
+		}
+
+		subject := ""
+
+		if subjects := row.list("subjects"); len(subjects) > 0 {
+			subject = subjects[0]
+		}
+
+		gradeLevels := oneRosterGradeLevels(row.list("grades"))
+
+		if course == nil {
+			course = &Course{
+				OrganizationID:  p.organization.ID,
+				Code:            code,
+				Title:           title,
+				Subject:         subject,
+				Level:           CourseLevelStandard,
+				GradeLevels:     gradeLevels,
+				PrerequisiteIDs: []string{},
+			}
+
+			if err := validateCourse(course); err != nil {
+				p.fail(row, "%v", err)
+				continue
+			}
+
+			change := p.change(OneRosterTypeCourse, sourcedID, OneRosterActionCreate, "")
+
+			p.step(func(ctx context.Context) error {
+				course.CreatedAt = time.Now()
+				course.UpdatedAt = time.Now()
+
+				if err := p.service.courseStore.Create(ctx, course); err != nil {
+					return err
+				}
+
+				change.LocalID = course.ID
+
+				return p.link(ctx, OneRosterTypeCourse, sourcedID, "", course.ID)
+			})
+		} else {
+			if !p.claim(row, course.ID) {
+				continue
+			}
+
+			var fields []string
+
+			if course.Code != code {
+				course.Code = code
+				fields = append(fields, "code")
+			}
+
+			if course.Title != title {
+				course.Title = title
+				fields = append(fields, "title")
+			}
+
+			if course.Subject != subject {
+				course.Subject = subject
+				fields = append(fields, "subject")
+			}
+
+			current := slices.Clone(course.GradeLevels)
+			slices.Sort(current)
+
+			if !slices.Equal(current, gradeLevels) {
+				course.GradeLevels = gradeLevels
+				fields = append(fields, "gradeLevels")
+			}
+
+			p.update(OneRosterTypeCourse, sourcedID, course.ID, fields, func(ctx context.Context) error {
+				course.UpdatedAt = time.Now()
+				return p.service.courseStore.Update(ctx, course)
+			})
+		}
+
+		p.courses[sourcedID] = course
+	}
+
+	return nil
+}
+
+// Returns the course by sourcedId from courses.csv or an earlier import, or nil
+func (p *oneRosterPlan) resolveCourse(ctx context.Context, sourcedID string) (*Course, error) {
+	if course, ok := p.courses[sourcedID]; ok {
+		return course, nil
+	}
+
+	course, err := p.findCourse(ctx, sourcedID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.courses[sourcedID] = course
+
+	return course, nil
+}
+
+// Picks each user's organization role from roles.csv, preferring their primary role
+func (p *oneRosterPlan) indexRoles() map[string]string {
+	roles := map[string]string{}
+	primary := map[string]bool{}
+
+	for _, row := range p.rows("roles.csv") {
+		if row.deleted() {
+			continue
+		}
+
+		userSourcedID := row.get("userSourcedId")
+		role, ok := oneRosterRoles[row.get("role")]
+
+		if userSourcedID == "" || !ok {
+			p.fail(row, "userSourcedId and a known role are required")
+			continue
+		}
+
+		isPrimary := row.get("roleType") == "primary"
+
+		if _, seen := roles[userSourcedID]; !seen || (isPrimary && !primary[userSourcedID]) {
+			roles[userSourcedID] = role
+			primary[userSourcedID] = isPrimary
+		}
+	}
+
+	return roles
+}
+
+// Returns the user imported from sourcedID earlier, or nil
+func (p *oneRosterPlan) findUser(ctx context.Context, sourcedID string) (*User, error) {
+	id := p.localIDs[oneRosterKey{OneRosterTypeUser, sourcedID, ""}]
+
+	if id == "" {
+		return nil, nil
+	}
+
+	user, err := p.service.userStore.GetByID(ctx, id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+func (p *oneRosterPlan) planUsers(ctx context.Context) error {
+	roles := p.indexRoles()
+
+	for _, row := range p.rows("users.csv") {
+		sourcedID := row.get("sourcedId")
+		email := row.get("email")
+
+		user, err := p.findUser(ctx, sourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		linked := user != nil
+
+		if user == nil && email != "" {
+			if user, err = p.service.userStore.GetByEmail(ctx, email); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
+		if row.deleted() {
+			if err := p.planRemoval(ctx, row, user); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		role, ok := roles[sourcedID]
+
+		if !ok {
+			// OneRoster 1.1 bundles carry the role on the user
+			if role, ok = oneRosterRoles[row.get("role")]; !ok {
+				p.fail(row, "user needs a role in roles.csv")
+				continue
+			}
+		}
+
+		if email != "" {
+			if other, ok := p.emails[strings.ToLower(email)]; ok {
+				p.fail(row, "email %s is also used by %s", email, other)
+				continue
+			}
+
+			p.emails[strings.ToLower(email)] = sourcedID
+		}
+
+		firstName, lastName := row.get("givenName"), row.get("familyName")
+
+		if user == nil {
+			switch {
+			case firstName == "" || lastName == "":
+				p.fail(row, "givenName and familyName are required")
+				continue
+			case email == "":
+				p.fail(row, "email is required to create a user")
+				continue
+			case !emailPattern.MatchString(email):
+				p.fail(row, "invalid email %s", email)
+				continue
+			}
+
+			user = &User{FirstName: firstName, LastName: lastName, Email: normalizeEmail(email)}
+			password := row.get("password")
+			change := p.change(OneRosterTypeUser, sourcedID, OneRosterActionCreate, "")
+
+			p.step(func(ctx context.Context) error {
+				// Users imported without a password cannot sign in until one is set
+				if password != "" {
+					hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+					if err != nil {
+						return err
+					}
+
+					user.Password = string(hashedPassword)
+				}
+
+				user.CreatedAt = time.Now()
+				user.UpdatedAt = time.Now()
+
+				if err := p.service.userStore.Create(ctx, user); err != nil {
+					return err
+				}
+
+				change.LocalID = user.ID
+
+				if role != "" {
+					member := &OrganizationMember{OrganizationID: p.organization.ID, UserID: user.ID, Role: role, CreatedAt: time.Now()}
+
+					if err := p.service.memberStore.Create(ctx, member); err != nil {
+						return err
+					}
+				}
+
+				return p.link(ctx, OneRosterTypeUser, sourcedID, "", user.ID)
+			})
+
+			p.users[sourcedID] = user
+			p.memberRoles[sourcedID] = role
+
+			continue
+		}
+
+		if user.ErasedAt != nil {
+			p.fail(row, "user has been erased")
+			continue
+		}
+
+		if !p.claim(row, user.ID) {
+			continue
+		}
+
+		var fields []string
+
+		// Users found by email may belong to other organizations too, so only users this
+		// organization imported before have their names updated
+		if linked && firstName != "" && lastName != "" && (user.FirstName != firstName || user.LastName != lastName) {
+			user.FirstName, user.LastName = firstName, lastName
+			fields = append(fields, "name")
+		}
+
+		member, err := p.service.memberStore.Get(ctx, p.organization.ID, user.ID)
+
+		if err != nil {
+			return err
+		}
+
+		message := ""
+		memberRole := role
+
+		switch {
+		case member == nil && role != "":
+			fields = append(fields, "role")
+		case member == nil:
+		case role == "" || member.Role == role:
+			memberRole = member.Role
+		case user.ID == p.sessionUserID || user.ID == p.organization.OwnerUserID:
+			memberRole = member.Role
+			message = fmt.Sprintf("keeps the %s role of the organization's owner or the importing administrator", member.Role)
+		default:
+			fields = append(fields, "role")
+		}
+
+		change := p.update(OneRosterTypeUser, sourcedID, user.ID, fields, func(ctx context.Context) error {
+			if slices.Contains(fields, "name") {
+				user.UpdatedAt = time.Now()
+
+				if err := p.service.userStore.Update(ctx, user); err != nil {
+					return err
+				}
+			}
+
+			if !slices.Contains(fields, "role") {
+				return nil
+			}
+
+			if member != nil {
+				if err := p.service.memberStore.Delete(ctx, p.organization.ID, user.ID); err != nil {
+					return err
+				}
+			}
+
+			return p.service.memberStore.Create(ctx, &OrganizationMember{OrganizationID: p.organization.ID, UserID: user.ID, Role: role, CreatedAt: time.Now()})
+		})
+
+		change.Message = message
+		p.users[sourcedID] = user
+		p.memberRoles[sourcedID] = memberRole
+	}
+
+	return nil
+}
+
+// Plans removing a user marked for deletion from the organization. The user account stays.
+func (p *oneRosterPlan) planRemoval(ctx context.Context, row *oneRosterRow, user *User) error {
+	sourcedID := row.get("sourcedId")
+
+	if user == nil {
+		p.change(OneRosterTypeUser, sourcedID, OneRosterActionSkip, "").Message = "no such user"
+		return nil
+	}
+
+	member, err := p.service.memberStore.Get(ctx, p.organization.ID, user.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if member == nil {
+		p.change(OneRosterTypeUser, sourcedID, OneRosterActionSkip, user.ID).Message = "not a member of the organization"
+		return nil
+	}
+
+	if user.ID == p.sessionUserID || user.ID == p.organization.OwnerUserID {
+		p.fail(row, "the organization's owner and the importing administrator cannot be removed")
+		return nil
+	}
+
+	p.change(OneRosterTypeUser, sourcedID, OneRosterActionDelete, user.ID)
+	p.step(func(ctx context.Context) error {
+		return p.service.memberStore.Delete(ctx, p.organization.ID, user.ID)
+	})
+
+	return nil
+}
+
+// Returns the user by sourcedId from users.csv or an earlier import, or nil
+func (p *oneRosterPlan) resolveUser(ctx context.Context, sourcedID string) (*User, error) {
+	if user, ok := p.users[sourcedID]; ok {
+		return user, nil
+	}
+
+	user, err := p.findUser(ctx, sourcedID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user != nil && user.ErasedAt != nil {
+		user = nil
+	}
+
+	p.users[sourcedID] = user
+
+	return user, nil
+}
+
+// Returns the role the user holds in the organization once the import is applied
+func (p *oneRosterPlan) memberRole(ctx context.Context, sourcedID string, user *User) (string, error) {
+	if role, ok := p.memberRoles[sourcedID]; ok {
+		return role, nil
+	}
+
+	member, err := p.service.memberStore.Get(ctx, p.organization.ID, user.ID)
+
+	if err != nil {
+		return "", err
+	}
+
+	role := ""
+
+	if member != nil {
+		role = member.Role
+	}
+
+	p.memberRoles[sourcedID] = role
+
+	return role, nil
+}
+
+// Returns the class imported from sourcedID earlier, or nil
+func (p *oneRosterPlan) findSection(ctx context.Context, sourcedID string) (*Section, error) {
+	id := p.localIDs[oneRosterKey{OneRosterTypeClass, sourcedID, ""}]
+
+	if id == "" {
+		return nil, nil
+	}
+
+	section, err := p.service.sectionStore.GetByID(ctx, id)
+
+	if err != nil || section == nil {
+		return nil, err
+	}
+
+	school, err := p.service.schoolStore.GetByID(ctx, section.SchoolID)
+
+	if err != nil || school == nil || school.OrganizationID != p.organization.ID {
+		return nil, err
+	}
+
+	return section, nil
+}
+
+func (p *oneRosterPlan) planClasses(ctx context.Context) error {
+	for _, row := range p.rows("classes.csv") {
+		sourcedID := row.get("sourcedId")
+
+		if row.deleted() {
+			p.change(OneRosterTypeClass, sourcedID, OneRosterActionSkip, "").Message = "deleting classes is not supported"
+			continue
+		}
+
+		code := cmp.Or(row.get("classCode"), row.get("title"))
+		courseSourcedID, schoolSourcedID, termSourcedIDs := row.get("courseSourcedId"), row.get("schoolSourcedId"), row.list("termSourcedIds")
+
+		if code == "" {
+			p.fail(row, "classCode or title is required")
+			continue
+		}
+
+		if courseSourcedID == "" || schoolSourcedID == "" || len(termSourcedIDs) == 0 {
+			p.fail(row, "courseSourcedId, schoolSourcedId and termSourcedIds are required")
+			continue
+		}
+
+		school, err := p.resolveSchool(ctx, schoolSourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		if school == nil {
+			p.fail(row, "school %s is not in orgs.csv", schoolSourcedID)
+			continue
+		}
+
+		course, err := p.resolveCourse(ctx, courseSourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		if course == nil {
+			p.fail(row, "course %s is not in courses.csv", courseSourcedID)
+			continue
+		}
+
+		term, err := p.ensureTerm(ctx, termSourcedIDs[0], schoolSourcedID, school, row)
+
+		if err != nil {
+			return err
+		}
+
+		if term == nil {
+			continue
+		}
+
+		section, err := p.findSection(ctx, sourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		if section == nil && school.ID != "" && course.ID != "" && term.ID != "" {
+			sections, err := p.service.sectionStore.List(ctx, &SectionFilter{SchoolID: school.ID, TermID: term.ID, CourseID: course.ID})
+
+			if err != nil {
+				return err
+			}
+
+			for _, existing := range sections {
+				if _, ok := p.claimed[existing.ID]; !ok && strings.EqualFold(existing.Code, code) {
+					section = existing
+					break
+				}
+			}
+		}
+
+		var change *OneRosterChange
+
+		if section == nil {
+			section = &Section{
+				Code:     code,
+				Room:     row.get("location"),
+				Capacity: defaultOneRosterClassCapacity,
+				Teachers: []SectionTeacher{},
+				Meetings: []SectionMeeting{},
+				Periods:  []SectionPeriod{},
+			}
+
+			change = p.change(OneRosterTypeClass, sourcedID, OneRosterActionCreate, "")
+
+			p.step(func(ctx context.Context) error {
+				section.CourseID, section.SchoolID, section.TermID = course.ID, school.ID, term.ID
+				section.Teachers = p.sectionTeachers(sourcedID)
+				section.CreatedAt = time.Now()
+				section.UpdatedAt = time.Now()
+
+				if err := p.service.sectionStore.Create(ctx, section); err != nil {
+					return err
+				}
+
+				change.LocalID = section.ID
+
+				return p.link(ctx, OneRosterTypeClass, sourcedID, "", section.ID)
+			})
+		} else {
+			if !p.claim(row, section.ID) {
+				continue
+			}
+
+			if section.SchoolID != school.ID || section.CourseID != course.ID || section.TermID != term.ID {
+				p.fail(row, "classes cannot move to another school, course or term")
+				continue
+			}
+
+			var fields []string
+
+			if section.Code != code {
+				section.Code = code
+				fields = append(fields, "code")
+			}
+
+			if room := row.get("location"); section.Room != room {
+				section.Room = room
+				fields = append(fields, "room")
+			}
+
+			p.teachers[sourcedID] = existingTeachers(section)
+			change = p.update(OneRosterTypeClass, sourcedID, section.ID, fields, p.saveSection(sourcedID, section))
+		}
+
+		if len(termSourcedIDs) > 1 {
+			change.Message = "only the first term is imported"
+		}
+
+		p.sections[sourcedID] = section
+		p.classChanges[sourcedID] = change
+	}
+
+	return nil
+}
+
+func existingTeachers(section *Section) []*oneRosterTeacher {
+	teachers := make([]*oneRosterTeacher, 0, len(section.Teachers))
+
+	for _, teacher := range section.Teachers {
+		teachers = append(teachers, &oneRosterTeacher{user: &User{ID: teacher.UserID}, role: teacher.Role})
+	}
+
+	return teachers
+}
+
+// Returns the class's teachers once the users they refer to are saved
+func (p *oneRosterPlan) sectionTeachers(classSourcedID string) []SectionTeacher {
+	teachers := []SectionTeacher{}
+
+	for _, teacher := range p.teachers[classSourcedID] {
+		teachers = append(teachers, SectionTeacher{UserID: teacher.user.ID, Role: teacher.role})
+	}
+
+	return teachers
+}
+
+func (p *oneRosterPlan) saveSection(classSourcedID string, section *Section) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		section.Teachers = p.sectionTeachers(classSourcedID)
+		section.UpdatedAt = time.Now()
+
+		return p.service.sectionStore.Update(ctx, section)
+	}
+}
+
+// Returns the class by sourcedId from classes.csv or an earlier import, or nil
+func (p *oneRosterPlan) resolveSection(ctx context.Context, sourcedID string) (*Section, error) {
+	if section, ok := p.sections[sourcedID]; ok {
+		return section, nil
+	}
+
+	section, err := p.findSection(ctx, sourcedID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if section != nil {
+		p.teachers[sourcedID] = existingTeachers(section)
+	}
+
+	p.sections[sourcedID] = section
+
+	return section, nil
+}
+
+// Notes that enrollments change a field of the class
+func (p *oneRosterPlan) touchClass(classSourcedID string, field string) {
+	change := p.classChanges[classSourcedID]
+
+	if change == nil {
+		// The class is not in classes.csv, so it gets its own change
+		change = &OneRosterChange{Type: OneRosterTypeClass, SourcedID: classSourcedID, Action: OneRosterActionUnchanged, LocalID: p.sections[classSourcedID].ID}
+		p.classChanges[classSourcedID] = change
+		p.outsideClasses = append(p.outsideClasses, classSourcedID)
+	}
+
+	if change.Action == OneRosterActionCreate {
+		return
+	}
+
+	change.Action = OneRosterActionUpdate
+
+	if !slices.Contains(change.Fields, field) {
+		change.Fields = append(change.Fields, field)
+	}
+}
+
+// Returns the class's enrollments that hold a seat or waitlist place
+func (p *oneRosterPlan) activeEnrollments(ctx context.Context, classSourcedID string, section *Section) ([]*Enrollment, error) {
+	if enrollments, ok := p.enrollments[classSourcedID]; ok || section.ID == "" {
+		return enrollments, nil
+	}
+
+	enrollments, err := p.service.enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: section.ID, Statuses: activeEnrollmentStatuses})
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.enrollments[classSourcedID] = enrollments
+
+	for _, enrollment := range enrollments {
+		if enrollment.Status == EnrollmentStatusEnrolled {
+			p.seats[classSourcedID]++
+		}
+	}
+
+	return enrollments, nil
+}
+
+func (p *oneRosterPlan) planEnrollments(ctx context.Context) error {
+	start := len(p.steps)
+
+	for _, row := range p.rows("enrollments.csv") {
+		classSourcedID, userSourcedID, role := row.get("classSourcedId"), row.get("userSourcedId"), row.get("role")
+
+		if classSourcedID == "" || userSourcedID == "" || role == "" {
+			p.fail(row, "classSourcedId, userSourcedId and role are required")
+			continue
+		}
+
+		section, err := p.resolveSection(ctx, classSourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		if section == nil {
+			p.fail(row, "class %s is not in classes.csv", classSourcedID)
+			continue
+		}
+
+		user, err := p.resolveUser(ctx, userSourcedID)
+
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			p.fail(row, "user %s is not in users.csv", userSourcedID)
+			continue
+		}
+
+		key := classSourcedID + "/" + userSourcedID
+
+		if other, ok := p.enrolled[key]; ok {
+			p.fail(row, "user %s is already enrolled in class %s by %s", userSourcedID, classSourcedID, other)
+			continue
+		}
+
+		p.enrolled[key] = row.get("sourcedId")
+
+		memberRole, err := p.memberRole(ctx, userSourcedID, user)
+
+		if err != nil {
+			return err
+		}
+
+		switch role {
+		case "student":
+			if memberRole != RoleStudent {
+				p.fail(row, "user %s is not a student of the organization", userSourcedID)
+				continue
+			}
+
+			if err := p.planStudent(ctx, row, section, user); err != nil {
+				return err
+			}
+		case "teacher", "aide", "proctor", "administrator":
+			if memberRole != RoleTeacher && memberRole != RoleAdmin {
+				p.fail(row, "user %s is not a teacher or administrator of the organization", userSourcedID)
+				continue
+			}
+
+			p.planTeacher(row, user, role == "teacher" && strings.EqualFold(row.get("primary"), "true"))
+		default:
+			p.change(OneRosterTypeEnrollment, row.get("sourcedId"), OneRosterActionSkip, "").Message = fmt.Sprintf("enrollments with role %q are not imported", role)
+		}
+	}
+
+	for classSourcedID := range p.teachers {
+		primaries := 0
+
+		for _, teacher := range p.teachers[classSourcedID] {
+			if teacher.role == SectionTeacherPrimary {
+				primaries++
+			}
+		}
+
+		if primaries > 1 {
+			p.result.Errors = append(p.result.Errors, &OneRosterRowError{File: "enrollments.csv", SourcedID: classSourcedID, Message: "a class can have only one primary teacher"})
+		}
+	}
+
+	// Classes outside classes.csv are saved before the enrollments, which may need their seats
+	enrollmentSteps := slices.Clone(p.steps[start:])
+	p.steps = p.steps[:start]
+
+	for _, classSourcedID := range p.outsideClasses {
+		change := p.classChanges[classSourcedID]
+		p.result.Changes = append(p.result.Changes, change)
+		p.step(p.saveSection(classSourcedID, p.sections[classSourcedID]))
+	}
+
+	p.steps = append(p.steps, enrollmentSteps...)
+
+	return nil
+}
+
+func (p *oneRosterPlan) planStudent(ctx context.Context, row *oneRosterRow, section *Section, user *User) error {
+	sourcedID, classSourcedID := row.get("sourcedId"), row.get("classSourcedId")
+
+	enrollments, err := p.activeEnrollments(ctx, classSourcedID, section)
+
+	if err != nil {
+		return err
+	}
+
+	var current *Enrollment
+
+	for _, enrollment := range enrollments {
+		if user.ID != "" && enrollment.StudentUserID == user.ID {
+			current = enrollment
+			break
+		}
+	}
+
+	if row.deleted() {
+		if current == nil {
+			p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionSkip, "").Message = "the student is not enrolled"
+			return nil
+		}
+
+		p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionDelete, current.ID)
+		p.step(func(ctx context.Context) error {
+			_, err := p.service.enrollmentStore.Drop(ctx, current.ID)
+			return err
+		})
+
+		return nil
+	}
+
+	if current != nil {
+		p.update(OneRosterTypeEnrollment, sourcedID, current.ID, nil, nil)
+		return nil
+	}
+
+	p.seats[classSourcedID]++
+
+	if p.seats[classSourcedID] > section.Capacity {
+		section.Capacity = p.seats[classSourcedID]
+		p.touchClass(classSourcedID, "capacity")
+	}
+
+	enrollment := &Enrollment{}
+	change := p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionCreate, "")
+
+	p.step(func(ctx context.Context) error {
+		enrollment.SectionID = section.ID
+		enrollment.StudentUserID = user.ID
+		enrollment.CreatedAt = time.Now()
+		enrollment.UpdatedAt = time.Now()
+
+		if err := p.service.enrollmentStore.Enroll(ctx, enrollment, false, nil); err != nil {
+			return err
+		}
+
+		change.LocalID = enrollment.ID
+
+		return p.link(ctx, OneRosterTypeEnrollment, sourcedID, "", enrollment.ID)
+	})
+
+	return nil
+}
+
+// Plans a change to the class's teachers, which are saved with the class
+func (p *oneRosterPlan) planTeacher(row *oneRosterRow, user *User, primary bool) {
+	sourcedID, classSourcedID := row.get("sourcedId"), row.get("classSourcedId")
+	teachers := p.teachers[classSourcedID]
+
+	index := slices.IndexFunc(teachers, func(teacher *oneRosterTeacher) bool {
+		return teacher.user == user || (user.ID != "" && teacher.user.ID == user.ID)
+	})
+
+	role := SectionTeacherCoTeacher
+
+	if primary {
+		role = SectionTeacherPrimary
+	}
+
+	switch {
+	case row.deleted() && index < 0:
+		p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionSkip, "").Message = "the teacher does not teach the class"
+	case row.deleted():
+		p.teachers[classSourcedID] = slices.Delete(teachers, index, index+1)
+		p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionDelete, "")
+		p.touchClass(classSourcedID, "teachers")
+	case index < 0:
+		p.teachers[classSourcedID] = append(teachers, &oneRosterTeacher{user: user, role: role})
+		p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionCreate, "")
+		p.touchClass(classSourcedID, "teachers")
+	case teachers[index].role != role:
+		teachers[index].role = role
+		p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionUpdate, "").Fields = []string{"primary"}
+		p.touchClass(classSourcedID, "teachers")
+	default:
+		p.change(OneRosterTypeEnrollment, sourcedID, OneRosterActionUnchanged, "")
+	}
+}
+
+type OneRosterService struct {
+	oneRosterStore    OneRosterStore
+	organizationStore OrganizationStore
+	schoolStore       SchoolStore
+	calendarStore     CalendarStore
+	courseStore       CourseStore
+	sectionStore      SectionStore
+	enrollmentStore   EnrollmentStore
+	userStore         UserStore
+	memberStore       OrganizationMemberStore
+	auditService      *AuditService
+}
+
+func NewOneRosterService(oneRosterStore OneRosterStore, organizationStore OrganizationStore, schoolStore SchoolStore, calendarStore CalendarStore, courseStore CourseStore, sectionStore SectionStore, enrollmentStore EnrollmentStore, userStore UserStore, memberStore OrganizationMemberStore, auditService *AuditService) *OneRosterService {
+	return &OneRosterService{
+		oneRosterStore:    oneRosterStore,
+		organizationStore: organizationStore,
+		schoolStore:       schoolStore,
+		calendarStore:     calendarStore,
+		courseStore:       courseStore,
+		sectionStore:      sectionStore,
+		enrollmentStore:   enrollmentStore,
+		userStore:         userStore,
+		memberStore:       memberStore,
+		auditService:      auditService,
+	}
+}
+
+type OneRosterImportOptions struct {
+	// Reports what the import would change without changing anything
+	DryRun bool
+	// The IANA time zone of schools the import creates, UTC when empty
+	Timezone string
+}
+
+func (s *OneRosterService) authorize(ctx context.Context, organizationID string) (*OrganizationMember, *Organization, error) {
+	member, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	organization, err := s.organizationStore.GetByID(ctx, organizationID)
+
+	if err != nil {
+		slog.Error("failed to get organization", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if organization == nil {
+		return nil, nil, notFound("organization")
+	}
+
+	return member, organization, nil
+}
+
+// Imports a OneRoster 1.2 CSV bundle into the organization, creating or updating schools,
+// academic sessions, courses, classes, users and enrollments. Records are matched by the
+// sourcedIds of earlier imports, so a bundle can be imported again to bring changes across.
+// Nothing is changed while any row has errors.
+func (s *OneRosterService) Import(ctx context.Context, organizationID string, bundle []byte, options *OneRosterImportOptions) (*OneRosterImportResult, error) {
+	member, organization, err := s.authorize(ctx, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	timezone := cmp.Or(options.Timezone, "UTC")
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", timezone)
+	}
+
+	files, err := readOneRosterBundle(bundle)
+
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.oneRosterStore.ListSourcedIDs(ctx, organizationID)
+
+	if err != nil {
+		slog.Error("failed to list OneRoster sourcedIds", "error", err)
+		return nil, ErrInternal
+	}
+
+	plan := newOneRosterPlan(s, organization, member.UserID, timezone, files, links)
+
+	if err := plan.build(ctx); err != nil {
+		slog.Error("failed to plan OneRoster import", "error", err)
+		return nil, ErrInternal
+	}
+
+	result := plan.result
+	result.DryRun = options.DryRun
+	result.summarize()
+
+	if options.DryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	for _, apply := range plan.steps {
+		// Steps that ran stay applied. Each record is linked to its sourcedId as it is saved,
+		// so importing the bundle again carries on where this one stopped.
+		if err := apply(ctx); err != nil {
+			slog.Error("failed to apply OneRoster import", "error", err)
+			return nil, ErrInternal
+		}
+	}
+
+	result.Applied = true
+
+	s.auditService.Record(ctx, organizationID, AuditActionImport, "organization", organizationID, nil, result.Summary)
+
+	return result, nil
+}
+
+// Returns the organization's schools, academic years and terms, courses, classes, members and
+// enrollments as a OneRoster 1.2 CSV bundle
+func (s *OneRosterService) Export(ctx context.Context, organizationID string) ([]byte, error) {
+	_, organization, err := s.authorize(ctx, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	roster, err := s.loadRoster(ctx, organization, oneRosterAllParts)
+
+	if err != nil {
+		slog.Error("failed to load OneRoster roster", "error", err)
+		return nil, ErrInternal
+	}
+
+	bundle, err := buildOneRosterBundle(roster)
+
+	if err != nil {
+		slog.Error("failed to build OneRoster bundle", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, organizationID, AuditActionExport, "organization", organizationID, nil, nil)
+
+	return bundle, nil
+}
+
+// The records of an organization that OneRoster covers, as exports and the REST API present them
+type oneRosterRoster struct {
+	organization *Organization
+	sourcedIDs   map[string]string
+	schools      []*School
+	years        []*AcademicYear
+	terms        []*Term
+	courses      []*Course
+	sections     []*Section
+	// Students holding a seat in each section, by section id
+	enrollments map[string][]*Enrollment
+	// Members whose accounts still exist, with their users by id
+	members []*OrganizationMember
+	users   map[string]*User
+}
+
+// Returns the sourcedId of a local record. Records that were never imported go by their own id.
+func (r *oneRosterRoster) sourcedID(localID string) string {
+	return cmp.Or(r.sourcedIDs[localID], localID)
+}
+
+func (r *oneRosterRoster) course(id string) *Course {
+	for _, course := range r.courses {
+		if course.ID == id {
+			return course
+		}
+	}
+
+	return nil
+}
+
+func (r *oneRosterRoster) year(id string) *AcademicYear {
+	for _, year := range r.years {
+		if year.ID == id {
+			return year
+		}
+	}
+
+	return nil
+}
+
+// The parts of a roster beyond its organization and schools, so that requests load only what
+// they present
+type oneRosterParts int
+
+const (
+	oneRosterSessions oneRosterParts = 1 << iota
+	oneRosterCourses
+	oneRosterClasses
+	oneRosterUsers
+	oneRosterEnrollments
+
+	oneRosterAllParts = oneRosterSessions | oneRosterCourses | oneRosterClasses | oneRosterUsers | oneRosterEnrollments
+)
+
+func (s *OneRosterService) loadRoster(ctx context.Context, organization *Organization, parts oneRosterParts) (*oneRosterRoster, error) {
+	links, err := s.oneRosterStore.ListSourcedIDs(ctx, organization.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	roster := &oneRosterRoster{
+		organization: organization,
+		sourcedIDs:   make(map[string]string, len(links)),
+		enrollments:  map[string][]*Enrollment{},
+		users:        map[string]*User{},
+	}
+
+	for _, link := range links {
+		roster.sourcedIDs[link.LocalID] = link.SourcedID
+	}
+
+	// Classes are the sections of known courses, and enrollments are seats in classes
+	if parts&oneRosterEnrollments != 0 {
+		parts |= oneRosterClasses | oneRosterUsers
+	}
+
+	if parts&oneRosterClasses != 0 {
+		parts |= oneRosterCourses
+	}
+
+	if parts&oneRosterCourses != 0 {
+		if roster.courses, err = s.courseStore.ListByOrganization(ctx, organization.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if roster.schools, err = s.schoolStore.ListByOrganization(ctx, organization.ID); err != nil {
+		return nil, err
+	}
+
+	for _, school := range roster.schools {
+		if parts&oneRosterSessions != 0 {
+			years, err := s.calendarStore.ListAcademicYears(ctx, school.ID)
+
+			if err != nil {
+				return nil, err
+			}
+
+			terms, err := s.calendarStore.ListTerms(ctx, school.ID)
+
+			if err != nil {
+				return nil, err
+			}
+
+			roster.years = append(roster.years, years...)
+			roster.terms = append(roster.terms, terms...)
+		}
+
+		if parts&oneRosterClasses != 0 {
+			sections, err := s.sectionStore.List(ctx, &SectionFilter{SchoolID: school.ID})
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, section := range sections {
+				if roster.course(section.CourseID) != nil {
+					roster.sections = append(roster.sections, section)
+				}
+			}
+		}
+	}
+
+	if parts&oneRosterUsers != 0 {
+		members, err := s.memberStore.ListByOrganization(ctx, organization.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.addRosterMembers(ctx, roster, members); err != nil {
+			return nil, err
+		}
+	}
+
+	if parts&oneRosterEnrollments != 0 {
+		if err := s.addRosterEnrollments(ctx, roster); err != nil {
+			return nil, err
+		}
+	}
+
+	return roster, nil
+}
+
+// Adds the members whose accounts still exist, along with their users, to the roster
+func (s *OneRosterService) addRosterMembers(ctx context.Context, roster *oneRosterRoster, members []*OrganizationMember) error {
+	userIDs := make([]string, 0, len(members))
+
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+
+	users, err := s.userStore.ListByIDs(ctx, userIDs)
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.ErasedAt == nil {
+			roster.users[user.ID] = user
+		}
+	}
+
+	for _, member := range members {
+		if roster.users[member.UserID] != nil {
+			roster.members = append(roster.members, member)
+		}
+	}
+
+	return nil
+}
+
+// Adds the students holding a seat in each class to the roster. Seats and teaching assignments
+// of users the roster leaves out are dropped, so that no enrollment points at a missing user.
+func (s *OneRosterService) addRosterEnrollments(ctx context.Context, roster *oneRosterRoster) error {
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{OrganizationID: roster.organization.ID, Statuses: []string{EnrollmentStatusEnrolled}})
+
+	if err != nil {
+		return err
+	}
+
+	sectionIDs := map[string]bool{}
+
+	for _, section := range roster.sections {
+		sectionIDs[section.ID] = true
+		section.Teachers = slices.DeleteFunc(section.Teachers, func(teacher SectionTeacher) bool { return roster.users[teacher.UserID] == nil })
+	}
+
+	for _, enrollment := range enrollments {
+		if sectionIDs[enrollment.SectionID] && roster.users[enrollment.StudentUserID] != nil {
+			roster.enrollments[enrollment.SectionID] = append(roster.enrollments[enrollment.SectionID], enrollment)
+		}
+	}
+
+	return nil
+}
+
+func buildOneRosterBundle(roster *oneRosterRoster) ([]byte, error) {
+	files := map[string][]map[string]string{}
+	add := func(file string, row map[string]string) {
+		files[file] = append(files[file], row)
+	}
+
+	district := roster.sourcedID(roster.organization.ID)
+	add("orgs.csv", map[string]string{"sourcedId": district, "name": roster.organization.Name, "type": "district"})
+
+	for _, school := range roster.schools {
+		add("orgs.csv", map[string]string{"sourcedId": roster.sourcedID(school.ID), "name": school.Name, "type": "school", "parentSourcedId": district})
+	}
+
+	// Sessions imported to several schools share a sourcedId and are written once
+	sessionsWritten := map[string]bool{}
+
+	for _, year := range roster.years {
+		if id := roster.sourcedID(year.ID); !sessionsWritten[id] {
+			sessionsWritten[id] = true
+			add("academicSessions.csv", map[string]string{
+				"sourcedId":  id,
+				"title":      year.Name,
+				"type":       "schoolYear",
+				"startDate":  year.StartDate.String(),
+				"endDate":    year.EndDate.String(),
+				"schoolYear": strconv.Itoa(year.EndDate.Year()),
+			})
+		}
+	}
+
+	for _, term := range roster.terms {
+		id := roster.sourcedID(term.ID)
+
+		if sessionsWritten[id] {
+			continue
+		}
+
+		sessionsWritten[id] = true
+		schoolYear := ""
+
+		if year := roster.year(term.AcademicYearID); year != nil {
+			schoolYear = strconv.Itoa(year.EndDate.Year())
+		}
+
+		add("academicSessions.csv", map[string]string{
+			"sourcedId":       id,
+			"title":           term.Name,
+			"type":            oneRosterSessionType(term.Type),
+			"startDate":       term.StartDate.String(),
+			"endDate":         term.EndDate.String(),
+			"parentSourcedId": roster.sourcedID(term.AcademicYearID),
+			"schoolYear":      schoolYear,
+		})
+	}
+
+	for _, course := range roster.courses {
+		add("courses.csv", map[string]string{
+			"sourcedId":    roster.sourcedID(course.ID),
+			"title":        course.Title,
+			"courseCode":   course.Code,
+			"grades":       strings.Join(oneRosterGradeCodes(course.GradeLevels), ","),
+			"orgSourcedId": district,
+			"subjects":     course.Subject,
+		})
+	}
+
+	for _, section := range roster.sections {
+		classSourcedID, schoolSourcedID := roster.sourcedID(section.ID), roster.sourcedID(section.SchoolID)
+		course := roster.course(section.CourseID)
+
+		add("classes.csv", map[string]string{
+			"sourcedId":       classSourcedID,
+			"title":           course.Title,
+			"grades":          strings.Join(oneRosterGradeCodes(course.GradeLevels), ","),
+			"courseSourcedId": roster.sourcedID(course.ID),
+			"classCode":       section.Code,
+			"classType":       "scheduled",
+			"location":        section.Room,
+			"schoolSourcedId": schoolSourcedID,
+			"termSourcedIds":  roster.sourcedID(section.TermID),
+			"subjects":        course.Subject,
+			"periods":         strings.Join(sectionPeriodNames(section), ","),
+		})
+
+		for _, teacher := range section.Teachers {
+			userSourcedID := roster.sourcedID(teacher.UserID)
+
+			add("enrollments.csv", map[string]string{
+				"sourcedId":       classSourcedID + "-" + userSourcedID,
+				"classSourcedId":  classSourcedID,
+				"schoolSourcedId": schoolSourcedID,
+				"userSourcedId":   userSourcedID,
+				"role":            "teacher",
+				"primary":         strconv.FormatBool(teacher.Role == SectionTeacherPrimary),
+			})
+		}
+
+		for _, enrollment := range roster.enrollments[section.ID] {
+			add("enrollments.csv", map[string]string{
+				"sourcedId":       roster.sourcedID(enrollment.ID),
+				"classSourcedId":  classSourcedID,
+				"schoolSourcedId": schoolSourcedID,
+				"userSourcedId":   roster.sourcedID(enrollment.StudentUserID),
+				"role":            "student",
+				"primary":         "false",
+			})
+		}
+	}
+
+	for _, member := range roster.members {
+		user := roster.users[member.UserID]
+		userSourcedID := roster.sourcedID(user.ID)
+
+		add("users.csv", map[string]string{
+			"sourcedId":           userSourcedID,
+			"enabledUser":         "true",
+			"username":            user.Email,
+			"givenName":           user.FirstName,
+			"familyName":          user.LastName,
+			"email":               user.Email,
+			"primaryOrgSourcedId": district,
+		})
+		add("roles.csv", map[string]string{
+			"sourcedId":     userSourcedID + "-" + district,
+			"userSourcedId": userSourcedID,
+			"roleType":      "primary",
+			"role":          oneRosterRoleNames[member.Role],
+			"orgSourcedId":  district,
+		})
+	}
+
+	return writeOneRosterBundle(files)
+}
+
+// Returns the distinct periods the section meets in
+func sectionPeriodNames(section *Section) []string {
+	var periods []string
+
+	for _, period := range section.Periods {
+		if !slices.Contains(periods, period.Period) {
+			periods = append(periods, period.Period)
+		}
+	}
+
+	return periods
+}
+
+type OneRosterHandler struct {
+	oneRosterService *OneRosterService
+}
+
+// Takes the bundle as a zip archive in the request body. dryRun=true reports the changes
+// without making them, and timezone sets the time zone of schools the import creates.
+func (h *OneRosterHandler) Import(w http.ResponseWriter, r *http.Request) {
+	options := &OneRosterImportOptions{Timezone: r.URL.Query().Get("timezone")}
+
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		var err error
+
+		if options.DryRun, err = strconv.ParseBool(value); err != nil {
+			writeError(w, errors.New("dryRun must be true or false"))
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxOneRosterBundleSize)
+	bundle, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			writeError(w, fmt.Errorf("bundles must not exceed %d MB", maxOneRosterBundleSize>>20))
+			return
+		}
+
+		writeError(w, errors.New("invalid request body"))
+		return
+	}
+
+	result, err := h.oneRosterService.Import(r.Context(), r.PathValue("id"), bundle, options)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+
+	if len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, result)
+}
+
+func (h *OneRosterHandler) Export(w http.ResponseWriter, r *http.Request) {
+	bundle, err := h.oneRosterService.Export(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "oneroster.zip"}))
+	w.Write(bundle)
+}
@@ -0,0 +1,1267 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	oneRosterRosteringPath = "/ims/oneroster/rostering/v1p2"
+	oneRosterTokenDuration = time.Hour
+	// Page size when a request names no limit, and the largest it may name
+	defaultOneRosterPageSize = 100
+	maxOneRosterPageSize     = 1000
+)
+
+// OAuth 2 scopes a client may ask for. Both cover every endpoint served here; roster.readonly
+// also covers demographics, which are not served.
+const (
+	OneRosterScopeRosterCore = "https://purl.imsglobal.org/spec/or/v1p2/scope/roster-core.readonly"
+	OneRosterScopeRoster     = "https://purl.imsglobal.org/spec/or/v1p2/scope/roster.readonly"
+)
+
+var oneRosterScopes = []string{OneRosterScopeRosterCore, OneRosterScopeRoster}
+
+var oneRosterClientIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// A vendor allowed to read an organization's roster through the OneRoster REST API. Its id is
+// the OAuth client_id. Only the secret's hash is stored; the secret itself is shown once, when
+// the client is created.
+type OneRosterClient struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organizationId"`
+	Name           string    `json:"name"`
+	SecretHash     string    `json:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type OneRosterAccessToken struct {
+	TokenHash      string
+	ClientID       string
+	OrganizationID string
+	// The granted scopes, separated by spaces
+	Scope     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func scanOneRosterClient(row rowScanner) (*OneRosterClient, error) {
+	var client OneRosterClient
+
+	if err := row.Scan(&client.ID, &client.OrganizationID, &client.Name, &client.SecretHash, &client.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+func scanOneRosterAccessToken(row rowScanner) (*OneRosterAccessToken, error) {
+	var token OneRosterAccessToken
+
+	if err := row.Scan(&token.TokenHash, &token.ClientID, &token.OrganizationID, &token.Scope, &token.ExpiresAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (s *OneRosterPostgresStore) CreateClient(ctx context.Context, client *OneRosterClient) error {
+	query := `
+		INSERT INTO oneroster_clients (organization_id, name, secret_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	return s.db.pool.QueryRow(ctx, query, client.OrganizationID, client.Name, client.SecretHash, client.CreatedAt).Scan(&client.ID)
+}
+
+func (s *OneRosterPostgresStore) GetClient(ctx context.Context, id string) (*OneRosterClient, error) {
+	query := `SELECT id, organization_id, name, secret_hash, created_at FROM oneroster_clients WHERE id = $1`
+
+	return noRowsAsNil(scanOneRosterClient(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *OneRosterPostgresStore) ListClients(ctx context.Context, organizationID string) ([]*OneRosterClient, error) {
+	query := `
+		SELECT id, organization_id, name, secret_hash, created_at
+		FROM oneroster_clients
+		WHERE organization_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var clients []*OneRosterClient
+
+	for rows.Next() {
+		client, err := scanOneRosterClient(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+func (s *OneRosterPostgresStore) DeleteClient(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM oneroster_clients WHERE id = $1`, id)
+	return err
+}
+
+func (s *OneRosterPostgresStore) CreateToken(ctx context.Context, token *OneRosterAccessToken) error {
+	query := `
+		WITH expired AS (
+			DELETE FROM oneroster_tokens WHERE client_id = $2 AND expires_at < $5
+		)
+		INSERT INTO oneroster_tokens (token_hash, client_id, scope, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, token.TokenHash, token.ClientID, token.Scope, token.ExpiresAt, token.CreatedAt)
+
+	return err
+}
+
+func (s *OneRosterPostgresStore) GetTokenByHash(ctx context.Context, tokenHash string) (*OneRosterAccessToken, error) {
+	query := `
+		SELECT t.token_hash, t.client_id, c.organization_id, t.scope, t.expires_at, t.created_at
+		FROM oneroster_tokens t
+		JOIN oneroster_clients c ON c.id = t.client_id
+		WHERE t.token_hash = $1
+	`
+
+	return noRowsAsNil(scanOneRosterAccessToken(s.db.pool.QueryRow(ctx, query, tokenHash)))
+}
+
+// A OneRoster resource as the REST API renders it
+type oneRosterResource map[string]any
+
+// A collection the REST API serves, with the keys its responses wrap resources in
+type oneRosterCollection struct {
+	plural   string
+	singular string
+	// The fields a request may filter, sort or select by
+	fields []string
+	// The parts of the roster build presents
+	parts oneRosterParts
+	build func(roster *oneRosterRoster) []oneRosterResource
+	// Loads one page of the collection in the store, for requests that neither filter nor sort
+	page func(s *OneRosterService, ctx context.Context, roster *oneRosterRoster, limit int, offset int) ([]oneRosterResource, int, error)
+}
+
+var oneRosterCollections = map[string]*oneRosterCollection{
+	"orgs": {
+		plural:   "orgs",
+		singular: "org",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "name", "type", "identifier", "parent", "children"},
+		build:    (*oneRosterRoster).orgResources,
+	},
+	"schools": {
+		plural:   "orgs",
+		singular: "org",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "name", "type", "identifier", "parent", "children"},
+		build: func(roster *oneRosterRoster) []oneRosterResource {
+			return slices.DeleteFunc(roster.orgResources(), func(org oneRosterResource) bool { return org["type"] != "school" })
+		},
+	},
+	"academicSessions": {
+		plural:   "academicSessions",
+		singular: "academicSession",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "title", "startDate", "endDate", "type", "parent", "children", "schoolYear"},
+		parts:    oneRosterSessions,
+		build:    (*oneRosterRoster).sessionResources,
+	},
+	"courses": {
+		plural:   "courses",
+		singular: "course",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "title", "courseCode", "grades", "subjects", "org"},
+		parts:    oneRosterCourses,
+		build:    (*oneRosterRoster).courseResources,
+	},
+	"classes": {
+		plural:   "classes",
+		singular: "class",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "title", "classCode", "classType", "location", "grades", "subjects", "course", "school", "terms", "periods"},
+		parts:    oneRosterClasses,
+		build:    (*oneRosterRoster).classResources,
+	},
+	"users": {
+		plural:   "users",
+		singular: "user",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "enabledUser", "username", "userIds", "givenName", "familyName", "roles", "email", "primaryOrg"},
+		parts:    oneRosterUsers,
+		build:    (*oneRosterRoster).userResources,
+		page:     (*OneRosterService).userPage,
+	},
+	"enrollments": {
+		plural:   "enrollments",
+		singular: "enrollment",
+		fields:   []string{"sourcedId", "status", "dateLastModified", "user", "class", "school", "role", "primary"},
+		parts:    oneRosterEnrollments,
+		build:    (*oneRosterRoster).enrollmentResources,
+	},
+}
+
+// The collection each type of GUIDRef points into
+var oneRosterRefCollections = map[string]string{
+	OneRosterTypeOrg:             "orgs",
+	OneRosterTypeAcademicSession: "academicSessions",
+	OneRosterTypeCourse:          "courses",
+	OneRosterTypeClass:           "classes",
+	OneRosterTypeUser:            "users",
+}
+
+func oneRosterTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// Returns a GUIDRef to the record
+func (r *oneRosterRoster) ref(kind string, localID string) oneRosterResource {
+	sourcedID := r.sourcedID(localID)
+
+	return oneRosterResource{
+		"href":      oneRosterRosteringPath + "/" + oneRosterRefCollections[kind] + "/" + url.PathEscape(sourcedID),
+		"sourcedId": sourcedID,
+		"type":      kind,
+	}
+}
+
+func (r *oneRosterRoster) orgResources() []oneRosterResource {
+	district := r.organization.ID
+	children := []oneRosterResource{}
+
+	for _, school := range r.schools {
+		children = append(children, r.ref(OneRosterTypeOrg, school.ID))
+	}
+
+	orgs := []oneRosterResource{{
+		"sourcedId":        r.sourcedID(district),
+		"status":           "active",
+		"dateLastModified": oneRosterTimestamp(r.organization.UpdatedAt),
+		"name":             r.organization.Name,
+		"type":             "district",
+		"identifier":       "",
+		"children":         children,
+	}}
+
+	for _, school := range r.schools {
+		orgs = append(orgs, oneRosterResource{
+			"sourcedId":        r.sourcedID(school.ID),
+			"status":           "active",
+			"dateLastModified": oneRosterTimestamp(school.UpdatedAt),
+			"name":             school.Name,
+			"type":             "school",
+			"identifier":       "",
+			"parent":           r.ref(OneRosterTypeOrg, district),
+			"children":         []oneRosterResource{},
+		})
+	}
+
+	return orgs
+}
+
+func (r *oneRosterRoster) sessionResources() []oneRosterResource {
+	sessions := []oneRosterResource{}
+	// Sessions imported to several schools share a sourcedId and are listed once
+	bySourcedID := map[string]oneRosterResource{}
+
+	for _, year := range r.years {
+		sourcedID := r.sourcedID(year.ID)
+
+		if bySourcedID[sourcedID] != nil {
+			continue
+		}
+
+		session := oneRosterResource{
+			"sourcedId":        sourcedID,
+			"status":           "active",
+			"dateLastModified": oneRosterTimestamp(year.CreatedAt),
+			"title":            year.Name,
+			"startDate":        year.StartDate.String(),
+			"endDate":          year.EndDate.String(),
+			"type":             "schoolYear",
+			"children":         []oneRosterResource{},
+			"schoolYear":       strconv.Itoa(year.EndDate.Year()),
+		}
+
+		bySourcedID[sourcedID] = session
+		sessions = append(sessions, session)
+	}
+
+	for _, term := range r.terms {
+		sourcedID := r.sourcedID(term.ID)
+
+		if bySourcedID[sourcedID] != nil {
+			continue
+		}
+
+		session := oneRosterResource{
+			"sourcedId":        sourcedID,
+			"status":           "active",
+			"dateLastModified": oneRosterTimestamp(term.CreatedAt),
+			"title":            term.Name,
+			"startDate":        term.StartDate.String(),
+			"endDate":          term.EndDate.String(),
+			"type":             oneRosterSessionType(term.Type),
+			"parent":           r.ref(OneRosterTypeAcademicSession, term.AcademicYearID),
+			"children":         []oneRosterResource{},
+		}
+
+		if year := r.year(term.AcademicYearID); year != nil {
+			session["schoolYear"] = strconv.Itoa(year.EndDate.Year())
+		}
+
+		if parent := bySourcedID[r.sourcedID(term.AcademicYearID)]; parent != nil {
+			parent["children"] = append(parent["children"].([]oneRosterResource), r.ref(OneRosterTypeAcademicSession, term.ID))
+		}
+
+		bySourcedID[sourcedID] = session
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
+func (r *oneRosterRoster) courseResources() []oneRosterResource {
+	courses := make([]oneRosterResource, 0, len(r.courses))
+
+	for _, course := range r.courses {
+		courses = append(courses, oneRosterResource{
+			"sourcedId":        r.sourcedID(course.ID),
+			"status":           "active",
+			"dateLastModified": oneRosterTimestamp(course.UpdatedAt),
+			"title":            course.Title,
+			"courseCode":       course.Code,
+			"grades":           oneRosterGradeCodes(course.GradeLevels),
+			"subjects":         courseSubjects(course),
+			"org":              r.ref(OneRosterTypeOrg, r.organization.ID),
+		})
+	}
+
+	return courses
+}
+
+func courseSubjects(course *Course) []string {
+	if course.Subject == "" {
+		return []string{}
+	}
+
+	return []string{course.Subject}
+}
+
+func (r *oneRosterRoster) classResources() []oneRosterResource {
+	classes := make([]oneRosterResource, 0, len(r.sections))
+
+	for _, section := range r.sections {
+		course := r.course(section.CourseID)
+
+		classes = append(classes, oneRosterResource{
+			"sourcedId":        r.sourcedID(section.ID),
+			"status":           "active",
+			"dateLastModified": oneRosterTimestamp(section.UpdatedAt),
+			"title":            course.Title,
+			"classCode":        section.Code,
+			"classType":        "scheduled",
+			"location":         section.Room,
+			"grades":           oneRosterGradeCodes(course.GradeLevels),
+			"subjects":         courseSubjects(course),
+			"course":           r.ref(OneRosterTypeCourse, course.ID),
+			"school":           r.ref(OneRosterTypeOrg, section.SchoolID),
+			"terms":            []oneRosterResource{r.ref(OneRosterTypeAcademicSession, section.TermID)},
+			"periods":          append([]string{}, sectionPeriodNames(section)...),
+		})
+	}
+
+	return classes
+}
+
+func (r *oneRosterRoster) userResources() []oneRosterResource {
+	users := make([]oneRosterResource, 0, len(r.members))
+	district := r.ref(OneRosterTypeOrg, r.organization.ID)
+
+	for _, member := range r.members {
+		user := r.users[member.UserID]
+
+		users = append(users, oneRosterResource{
+			"sourcedId":        r.sourcedID(user.ID),
+			"status":           "active",
+			"dateLastModified": oneRosterTimestamp(user.UpdatedAt),
+			"enabledUser":      true,
+			"username":         user.Email,
+			"userIds":          []oneRosterResource{},
+			"givenName":        user.FirstName,
+			"familyName":       user.LastName,
+			"roles":            []oneRosterResource{{"roleType": "primary", "role": oneRosterRoleNames[member.Role], "org": district}},
+			"email":            user.Email,
+			"primaryOrg":       district,
+		})
+	}
+
+	return users
+}
+
+func (r *oneRosterRoster) enrollmentResources() []oneRosterResource {
+	enrollments := []oneRosterResource{}
+
+	for _, section := range r.sections {
+		class, school := r.ref(OneRosterTypeClass, section.ID), r.ref(OneRosterTypeOrg, section.SchoolID)
+
+		for _, teacher := range section.Teachers {
+			enrollments = append(enrollments, oneRosterResource{
+				"sourcedId":        r.sourcedID(section.ID) + "-" + r.sourcedID(teacher.UserID),
+				"status":           "active",
+				"dateLastModified": oneRosterTimestamp(section.UpdatedAt),
+				"user":             r.ref(OneRosterTypeUser, teacher.UserID),
+				"class":            class,
+				"school":           school,
+				"role":             "teacher",
+				"primary":          teacher.Role == SectionTeacherPrimary,
+			})
+		}
+
+		for _, enrollment := range r.enrollments[section.ID] {
+			enrollments = append(enrollments, oneRosterResource{
+				"sourcedId":        r.sourcedID(enrollment.ID),
+				"status":           "active",
+				"dateLastModified": oneRosterTimestamp(enrollment.UpdatedAt),
+				"user":             r.ref(OneRosterTypeUser, enrollment.StudentUserID),
+				"class":            class,
+				"school":           school,
+				"role":             "student",
+				"primary":          false,
+			})
+		}
+	}
+
+	return enrollments
+}
+
+// Returns the values at the dotted path, flattening lists. Values that are not text are
+// formatted as they would be in JSON.
+func oneRosterValues(value any, path []string) []string {
+	switch value := value.(type) {
+	case oneRosterResource:
+		if len(path) == 0 {
+			return nil
+		}
+
+		return oneRosterValues(value[path[0]], path[1:])
+	case []oneRosterResource:
+		var values []string
+
+		for _, item := range value {
+			values = append(values, oneRosterValues(item, path)...)
+		}
+
+		return values
+	case []string:
+		if len(path) > 0 {
+			return nil
+		}
+
+		return value
+	case string:
+		if len(path) > 0 {
+			return nil
+		}
+
+		return []string{value}
+	case bool:
+		if len(path) > 0 {
+			return nil
+		}
+
+		return []string{strconv.FormatBool(value)}
+	}
+
+	return nil
+}
+
+// A request the OneRoster API rejects, with the status code minor the specification gives it
+type oneRosterRequestError struct {
+	codeMinor string
+	message   string
+}
+
+func (e *oneRosterRequestError) Error() string {
+	return e.message
+}
+
+type oneRosterPredicate struct {
+	path     []string
+	operator string
+	value    string
+}
+
+func (p *oneRosterPredicate) matches(resource oneRosterResource) bool {
+	values := oneRosterValues(resource, p.path)
+
+	if p.operator == "!=" {
+		return !slices.Contains(values, p.value)
+	}
+
+	for _, value := range values {
+		var ok bool
+
+		switch p.operator {
+		case "=":
+			ok = value == p.value
+		case "~":
+			ok = strings.Contains(strings.ToLower(value), strings.ToLower(p.value))
+		case ">":
+			ok = value > p.value
+		case ">=":
+			ok = value >= p.value
+		case "<":
+			ok = value < p.value
+		case "<=":
+			ok = value <= p.value
+		}
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A filter such as familyName~'smi' AND role='student'. Predicates are joined by AND or by
+// OR, not both.
+type oneRosterFilter struct {
+	predicates []*oneRosterPredicate
+	or         bool
+}
+
+var oneRosterOperators = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+func parseOneRosterFilter(filter string) (*oneRosterFilter, error) {
+	invalid := func(format string, args ...any) error {
+		return &oneRosterRequestError{codeMinor: "invalid_filter_field", message: "invalid filter: " + fmt.Sprintf(format, args...)}
+	}
+
+	parsed := &oneRosterFilter{}
+	joiner := ""
+	rest := strings.TrimSpace(filter)
+
+	for {
+		end := strings.IndexFunc(rest, func(r rune) bool {
+			return !(r == '.' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		})
+
+		if end <= 0 {
+			return nil, invalid("expected a field name at %q", rest)
+		}
+
+		predicate := &oneRosterPredicate{path: strings.Split(rest[:end], ".")}
+		rest = strings.TrimSpace(rest[end:])
+
+		for _, operator := range oneRosterOperators {
+			if strings.HasPrefix(rest, operator) {
+				predicate.operator = operator
+				break
+			}
+		}
+
+		if predicate.operator == "" {
+			return nil, invalid("expected an operator at %q", rest)
+		}
+
+		rest = strings.TrimSpace(rest[len(predicate.operator):])
+		value, ok := strings.CutPrefix(rest, "'")
+
+		if !ok {
+			return nil, invalid("values must be in single quotes")
+		}
+
+		end = strings.Index(value, "'")
+
+		if end < 0 {
+			return nil, invalid("unterminated value %q", rest)
+		}
+
+		predicate.value = value[:end]
+		parsed.predicates = append(parsed.predicates, predicate)
+		rest = strings.TrimSpace(value[end+1:])
+
+		if rest == "" {
+			return parsed, nil
+		}
+
+		word, next, _ := strings.Cut(rest, " ")
+
+		if word != "AND" && word != "OR" {
+			return nil, invalid("expected AND or OR at %q", rest)
+		}
+
+		if joiner != "" && word != joiner {
+			return nil, invalid("AND and OR cannot be mixed")
+		}
+
+		joiner = word
+		parsed.or = word == "OR"
+		rest = strings.TrimSpace(next)
+	}
+}
+
+func (f *oneRosterFilter) matches(resource oneRosterResource) bool {
+	for _, predicate := range f.predicates {
+		if predicate.matches(resource) == f.or {
+			return f.or
+		}
+	}
+
+	return !f.or
+}
+
+// The filtering, sorting, paging and field selection a collection request asks for
+type OneRosterQuery struct {
+	Filter     string
+	Sort       string
+	Descending bool
+	Limit      int
+	Offset     int
+	Fields     []string
+}
+
+func parseOneRosterQuery(values url.Values) (*OneRosterQuery, error) {
+	query := &OneRosterQuery{Filter: values.Get("filter"), Sort: values.Get("sort"), Limit: defaultOneRosterPageSize}
+
+	switch values.Get("orderBy") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return nil, &oneRosterRequestError{codeMinor: "invalid_sort_field", message: "orderBy must be asc or desc"}
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+
+		if err != nil || limit < 1 || limit > maxOneRosterPageSize {
+			return nil, &oneRosterRequestError{codeMinor: "invaliddata", message: fmt.Sprintf("limit must be between 1 and %d", maxOneRosterPageSize)}
+		}
+
+		query.Limit = limit
+	}
+
+	if value := values.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+
+		if err != nil || offset < 0 {
+			return nil, &oneRosterRequestError{codeMinor: "invaliddata", message: "offset must be a whole number"}
+		}
+
+		query.Offset = offset
+	}
+
+	for _, field := range strings.Split(values.Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			query.Fields = append(query.Fields, field)
+		}
+	}
+
+	return query, nil
+}
+
+// Applies the query to the collection's resources, returning the requested page and the number
+// of resources that match
+func (q *OneRosterQuery) apply(collection *oneRosterCollection, resources []oneRosterResource) ([]oneRosterResource, int, error) {
+	if q.Filter != "" {
+		filter, err := parseOneRosterFilter(q.Filter)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, predicate := range filter.predicates {
+			if !slices.Contains(collection.fields, predicate.path[0]) {
+				return nil, 0, &oneRosterRequestError{codeMinor: "invalid_filter_field", message: fmt.Sprintf("%s cannot be filtered by %s", collection.plural, predicate.path[0])}
+			}
+		}
+
+		resources = slices.DeleteFunc(resources, func(resource oneRosterResource) bool { return !filter.matches(resource) })
+	}
+
+	if q.Sort != "" {
+		if !slices.Contains(collection.fields, q.Sort) {
+			return nil, 0, &oneRosterRequestError{codeMinor: "invalid_sort_field", message: fmt.Sprintf("%s cannot be sorted by %s", collection.plural, q.Sort)}
+		}
+
+		key := func(resource oneRosterResource) string {
+			if values := oneRosterValues(resource, []string{q.Sort}); len(values) > 0 {
+				return values[0]
+			}
+
+			return ""
+		}
+
+		slices.SortStableFunc(resources, func(a oneRosterResource, b oneRosterResource) int {
+			if q.Descending {
+				return strings.Compare(key(b), key(a))
+			}
+
+			return strings.Compare(key(a), key(b))
+		})
+	}
+
+	for _, field := range q.Fields {
+		if !slices.Contains(collection.fields, field) {
+			return nil, 0, &oneRosterRequestError{codeMinor: "invalid_selection_field", message: fmt.Sprintf("%s have no field %s", collection.plural, field)}
+		}
+	}
+
+	total := len(resources)
+	start := min(q.Offset, total)
+	page := resources[start:min(start+q.Limit, total)]
+
+	return selectOneRosterFields(page, q.Fields), total, nil
+}
+
+// Keeps only the named fields of each resource, or every field when none are named
+func selectOneRosterFields(resources []oneRosterResource, fields []string) []oneRosterResource {
+	if len(fields) == 0 {
+		return resources
+	}
+
+	selected := make([]oneRosterResource, 0, len(resources))
+
+	for _, resource := range resources {
+		kept := oneRosterResource{}
+
+		for _, field := range fields {
+			if value, ok := resource[field]; ok {
+				kept[field] = value
+			}
+		}
+
+		selected = append(selected, kept)
+	}
+
+	return selected
+}
+
+type CreateOneRosterClientRequest struct {
+	Name string `json:"name"`
+}
+
+type CreateOneRosterClientResponse struct {
+	Client *OneRosterClient `json:"client"`
+	// Shown only in this response
+	ClientSecret string `json:"clientSecret"`
+}
+
+// Registers a vendor that may read the organization's roster through the REST API
+func (s *OneRosterService) CreateClient(ctx context.Context, organizationID string, request *CreateOneRosterClientRequest) (*CreateOneRosterClientResponse, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(request.Name)
+
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	secret, secretHash, err := newSessionToken()
+
+	if err != nil {
+		slog.Error("failed to generate OneRoster client secret", "error", err)
+		return nil, ErrInternal
+	}
+
+	client := &OneRosterClient{OrganizationID: organizationID, Name: name, SecretHash: secretHash, CreatedAt: time.Now()}
+
+	if err := s.oneRosterStore.CreateClient(ctx, client); err != nil {
+		slog.Error("failed to create OneRoster client", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, organizationID, AuditActionCreate, "oneroster_client", client.ID, nil, client)
+
+	return &CreateOneRosterClientResponse{Client: client, ClientSecret: secret}, nil
+}
+
+func (s *OneRosterService) ListClients(ctx context.Context, organizationID string) ([]*OneRosterClient, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.oneRosterStore.ListClients(ctx, organizationID)
+
+	if err != nil {
+		slog.Error("failed to list OneRoster clients", "error", err)
+		return nil, ErrInternal
+	}
+
+	return clients, nil
+}
+
+// Deletes the client along with the access tokens issued to it
+func (s *OneRosterService) DeleteClient(ctx context.Context, id string) error {
+	client, err := s.oneRosterStore.GetClient(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get OneRoster client", "error", err)
+		return ErrInternal
+	}
+
+	if client == nil {
+		return notFound("client")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, client.OrganizationID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.oneRosterStore.DeleteClient(ctx, id); err != nil {
+		slog.Error("failed to delete OneRoster client", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, client.OrganizationID, AuditActionDelete, "oneroster_client", id, client, nil)
+
+	return nil
+}
+
+type OneRosterTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// Issues an access token for the OAuth 2 client credentials grant. Clients that ask for no
+// scope are granted roster-core.readonly.
+func (s *OneRosterService) IssueToken(ctx context.Context, clientID string, clientSecret string, scope string) (*OneRosterTokenResponse, error) {
+	if !oneRosterClientIDPattern.MatchString(clientID) || clientSecret == "" {
+		return nil, unauthorized("invalid client credentials")
+	}
+
+	client, err := s.oneRosterStore.GetClient(ctx, clientID)
+
+	if err != nil {
+		slog.Error("failed to get OneRoster client", "error", err)
+		return nil, ErrInternal
+	}
+
+	if client == nil || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashSessionToken(clientSecret))) != 1 {
+		return nil, unauthorized("invalid client credentials")
+	}
+
+	scopes := strings.Fields(scope)
+
+	if len(scopes) == 0 {
+		scopes = []string{OneRosterScopeRosterCore}
+	}
+
+	for _, requested := range scopes {
+		if !slices.Contains(oneRosterScopes, requested) {
+			return nil, fmt.Errorf("unknown scope %s", requested)
+		}
+	}
+
+	token, tokenHash, err := newSessionToken()
+
+	if err != nil {
+		slog.Error("failed to generate OneRoster access token", "error", err)
+		return nil, ErrInternal
+	}
+
+	accessToken := &OneRosterAccessToken{
+		TokenHash:      tokenHash,
+		ClientID:       client.ID,
+		OrganizationID: client.OrganizationID,
+		Scope:          strings.Join(scopes, " "),
+		CreatedAt:      time.Now(),
+	}
+	accessToken.ExpiresAt = accessToken.CreatedAt.Add(oneRosterTokenDuration)
+
+	if err := s.oneRosterStore.CreateToken(ctx, accessToken); err != nil {
+		slog.Error("failed to create OneRoster access token", "error", err)
+		return nil, ErrInternal
+	}
+
+	return &OneRosterTokenResponse{
+		AccessToken: token,
+		TokenType:   "bearer",
+		ExpiresIn:   int(oneRosterTokenDuration / time.Second),
+		Scope:       accessToken.Scope,
+	}, nil
+}
+
+// Returns the unexpired access token
+func (s *OneRosterService) AuthenticateToken(ctx context.Context, token string) (*OneRosterAccessToken, error) {
+	accessToken, err := s.oneRosterStore.GetTokenByHash(ctx, hashSessionToken(token))
+
+	if err != nil {
+		slog.Error("failed to get OneRoster access token", "error", err)
+		return nil, ErrInternal
+	}
+
+	if accessToken == nil || time.Now().After(accessToken.ExpiresAt) {
+		return nil, unauthorized("invalid or expired access token")
+	}
+
+	return accessToken, nil
+}
+
+func (s *OneRosterService) rosterFor(ctx context.Context, token *OneRosterAccessToken, parts oneRosterParts) (*oneRosterRoster, error) {
+	organization, err := s.organizationStore.GetByID(ctx, token.OrganizationID)
+
+	if err != nil {
+		slog.Error("failed to get organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	if organization == nil {
+		return nil, unauthorized("the organization no longer exists")
+	}
+
+	roster, err := s.loadRoster(ctx, organization, parts)
+
+	if err != nil {
+		slog.Error("failed to load OneRoster roster", "error", err)
+		return nil, ErrInternal
+	}
+
+	return roster, nil
+}
+
+// Returns a page of the collection from the roster of the token's organization, along with the
+// number of resources matching the query
+func (s *OneRosterService) List(ctx context.Context, token *OneRosterAccessToken, name string, query *OneRosterQuery) ([]oneRosterResource, int, error) {
+	collection := oneRosterCollections[name]
+
+	if collection == nil {
+		return nil, 0, notFound(name)
+	}
+
+	// Filters and sorts apply to the resources as rendered, so only plain pages go to the store
+	if collection.page != nil && query.Filter == "" && query.Sort == "" {
+		return s.listPage(ctx, token, collection, query)
+	}
+
+	roster, err := s.rosterFor(ctx, token, collection.parts)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return query.apply(collection, collection.build(roster))
+}
+
+func (s *OneRosterService) listPage(ctx context.Context, token *OneRosterAccessToken, collection *oneRosterCollection, query *OneRosterQuery) ([]oneRosterResource, int, error) {
+	roster, err := s.rosterFor(ctx, token, 0)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resources, total, err := collection.page(s, ctx, roster, query.Limit, query.Offset)
+
+	if err != nil {
+		slog.Error("failed to load OneRoster page", "error", err, "collection", collection.plural)
+		return nil, 0, ErrInternal
+	}
+
+	// The page is already cut, so apply only checks and selects the fields
+	page := *query
+	page.Offset = 0
+	resources, _, err = page.apply(collection, resources)
+
+	return resources, total, err
+}
+
+// Returns a page of users, paging the organization's members in the store and loading only
+// their users
+func (s *OneRosterService) userPage(ctx context.Context, roster *oneRosterRoster, limit int, offset int) ([]oneRosterResource, int, error) {
+	members, total, err := s.memberStore.ListActivePage(ctx, roster.organization.ID, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.addRosterMembers(ctx, roster, members); err != nil {
+		return nil, 0, err
+	}
+
+	return roster.userResources(), total, nil
+}
+
+// Returns the collection's resource with the sourcedId, keeping only the named fields if any
+func (s *OneRosterService) Get(ctx context.Context, token *OneRosterAccessToken, name string, sourcedID string, fields []string) (oneRosterResource, error) {
+	collection := oneRosterCollections[name]
+
+	if collection == nil {
+		return nil, notFound(name)
+	}
+
+	for _, field := range fields {
+		if !slices.Contains(collection.fields, field) {
+			return nil, &oneRosterRequestError{codeMinor: "invalid_selection_field", message: fmt.Sprintf("%s have no field %s", collection.plural, field)}
+		}
+	}
+
+	roster, err := s.rosterFor(ctx, token, collection.parts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range collection.build(roster) {
+		if resource["sourcedId"] == sourcedID {
+			return selectOneRosterFields([]oneRosterResource{resource}, fields)[0], nil
+		}
+	}
+
+	return nil, notFound(collection.singular)
+}
+
+type oneRosterTokenContextKey struct{}
+
+// Returns the access token attached to the request context by RequireOneRosterToken
+func OneRosterTokenFromContext(ctx context.Context) (*OneRosterAccessToken, bool) {
+	token, ok := ctx.Value(oneRosterTokenContextKey{}).(*OneRosterAccessToken)
+	return token, ok
+}
+
+// Rejects requests that do not carry a valid OneRoster access token and attaches the token to
+// the context of those that do
+func RequireOneRosterToken(oneRosterService *OneRosterService) func(next http.HandlerFunc) http.Handler {
+	return func(next http.HandlerFunc) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			if !ok || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="oneroster"`)
+				writeOneRosterError(w, ErrUnauthorized)
+				return
+			}
+
+			accessToken, err := oneRosterService.AuthenticateToken(r.Context(), token)
+
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="oneroster", error="invalid_token"`)
+				writeOneRosterError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), oneRosterTokenContextKey{}, accessToken)))
+		})
+	}
+}
+
+type oneRosterCodeMinorField struct {
+	Name  string `json:"imsx_codeMinorFieldName"`
+	Value string `json:"imsx_codeMinorFieldValue"`
+}
+
+type oneRosterCodeMinor struct {
+	Fields []oneRosterCodeMinorField `json:"imsx_codeMinorField"`
+}
+
+// The status payload OneRoster returns with errors
+type oneRosterStatusInfo struct {
+	CodeMajor   string             `json:"imsx_codeMajor"`
+	Severity    string             `json:"imsx_severity"`
+	Description string             `json:"imsx_description"`
+	CodeMinor   oneRosterCodeMinor `json:"imsx_CodeMinor"`
+}
+
+func writeOneRosterError(w http.ResponseWriter, err error) {
+	status := statusForError(err)
+	codeMinor := "invaliddata"
+
+	var requestError *oneRosterRequestError
+
+	switch {
+	case errors.As(err, &requestError):
+		codeMinor = requestError.codeMinor
+	case status == http.StatusNotFound:
+		codeMinor = "unknownobject"
+	case status == http.StatusUnauthorized:
+		codeMinor = "unauthorisedrequest"
+	case status == http.StatusForbidden:
+		codeMinor = "forbidden"
+	case status == http.StatusInternalServerError:
+		codeMinor = "internal_server_error"
+	}
+
+	writeJSON(w, status, oneRosterStatusInfo{
+		CodeMajor:   "failure",
+		Severity:    "error",
+		Description: err.Error(),
+		CodeMinor:   oneRosterCodeMinor{Fields: []oneRosterCodeMinorField{{Name: "TargetEndSystem", Value: codeMinor}}},
+	})
+}
+
+// Writes an OAuth 2 error response (RFC 6749 section 5.2)
+func writeOAuthError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func (h *OneRosterHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var request CreateOneRosterClientRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response, err := h.oneRosterService.CreateClient(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func (h *OneRosterHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.oneRosterService.ListClients(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, clients)
+}
+
+func (h *OneRosterHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	if err := h.oneRosterService.DeleteClient(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// The OAuth 2 token endpoint. Clients authenticate with HTTP Basic or with client_id and
+// client_secret form fields.
+func (h *OneRosterHandler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+	case "":
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "grant_type is required")
+		return
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only the client_credentials grant is supported")
+		return
+	}
+
+	clientID, clientSecret, basic := r.BasicAuth()
+
+	if !basic {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	response, err := h.oneRosterService.IssueToken(r.Context(), clientID, clientSecret, r.PostForm.Get("scope"))
+
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oneroster"`)
+		}
+
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", err.Error())
+	case errors.Is(err, ErrInternal):
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+	case err != nil:
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+	default:
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// Serves a collection page. The total count is in X-Total-Count and links to the other pages
+// are in the Link header.
+func (h *OneRosterHandler) List(w http.ResponseWriter, r *http.Request) {
+	token, _ := OneRosterTokenFromContext(r.Context())
+	query, err := parseOneRosterQuery(r.URL.Query())
+
+	if err != nil {
+		writeOneRosterError(w, err)
+		return
+	}
+
+	name := r.PathValue("collection")
+	resources, total, err := h.oneRosterService.List(r.Context(), token, name, query)
+
+	if err != nil {
+		writeOneRosterError(w, err)
+		return
+	}
+
+	page := func(offset int) string {
+		values := r.URL.Query()
+		values.Set("offset", strconv.Itoa(offset))
+		values.Set("limit", strconv.Itoa(query.Limit))
+
+		return r.URL.Path + "?" + values.Encode()
+	}
+
+	last := max(total-1, 0) / query.Limit * query.Limit
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, page(0)), fmt.Sprintf(`<%s>; rel="last"`, page(last))}
+
+	if query.Offset+query.Limit < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, page(query.Offset+query.Limit)))
+	}
+
+	if query.Offset > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, page(max(query.Offset-query.Limit, 0))))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	writeJSON(w, http.StatusOK, map[string][]oneRosterResource{oneRosterCollections[name].plural: resources})
+}
+
+func (h *OneRosterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	token, _ := OneRosterTokenFromContext(r.Context())
+	query, err := parseOneRosterQuery(r.URL.Query())
+
+	if err != nil {
+		writeOneRosterError(w, err)
+		return
+	}
+
+	name := r.PathValue("collection")
+	resource, err := h.oneRosterService.Get(r.Context(), token, name, r.PathValue("sourcedId"), query.Fields)
+
+	if err != nil {
+		writeOneRosterError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]oneRosterResource{oneRosterCollections[name].singular: resource})
+}
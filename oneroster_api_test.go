@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testOneRosterClientID = "6f1c3f4e-2b7a-4d0e-9a55-0c6d2b1e8f90"
+
+// The roster of an organization with one school and one Algebra class taught by "teacher" with
+// "student" enrolled. rosterTokens holds the access token "token" for it.
+func rosterTokens() *MockOneRosterStore {
+	return &MockOneRosterStore{
+		ListSourcedIDsFunc: func(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error) {
+			return []*OneRosterSourcedID{{OrganizationID: organizationID, Type: OneRosterTypeUser, SourcedID: "u-student", LocalID: "student"}}, nil
+		},
+		GetTokenByHashFunc: func(ctx context.Context, tokenHash string) (*OneRosterAccessToken, error) {
+			if tokenHash != hashSessionToken("token") {
+				return nil, nil
+			}
+
+			return &OneRosterAccessToken{TokenHash: tokenHash, ClientID: testOneRosterClientID, OrganizationID: "org", Scope: OneRosterScopeRosterCore, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+}
+
+func rosterSchools() *MockSchoolStore {
+	return &MockSchoolStore{
+		ListByOrganizationFunc: func(ctx context.Context, organizationID string) ([]*School, error) {
+			return []*School{{ID: "north", OrganizationID: organizationID, Name: "North High"}}, nil
+		},
+	}
+}
+
+func rosterCalendar() *MockCalendarStore {
+	return &MockCalendarStore{
+		ListAcademicYearsFunc: func(ctx context.Context, schoolID string) ([]*AcademicYear, error) {
+			return []*AcademicYear{{ID: "year", SchoolID: schoolID, Name: "2025-2026", StartDate: NewDate(2025, time.August, 1), EndDate: NewDate(2026, time.June, 30)}}, nil
+		},
+		ListTermsFunc: func(ctx context.Context, schoolID string) ([]*Term, error) {
+			return []*Term{{ID: "fall", SchoolID: schoolID, AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 1), EndDate: NewDate(2025, time.December, 19)}}, nil
+		},
+	}
+}
+
+func rosterCourses() *MockCourseStore {
+	return &MockCourseStore{
+		ListByOrganizationFunc: func(ctx context.Context, organizationID string) ([]*Course, error) {
+			return []*Course{{ID: "algebra", OrganizationID: organizationID, Code: "ALG1", Title: "Algebra I", GradeLevels: []int{9}}}, nil
+		},
+	}
+}
+
+func rosterSections() *MockSectionStore {
+	return &MockSectionStore{
+		ListFunc: func(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+			return []*Section{{
+				ID:       "algebra-1",
+				CourseID: "algebra",
+				SchoolID: filter.SchoolID,
+				TermID:   "fall",
+				Code:     "ALG1-1",
+				Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}},
+			}}, nil
+		},
+	}
+}
+
+func rosterEnrollments() *MockEnrollmentStore {
+	return &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "seat", SectionID: "algebra-1", StudentUserID: "student", Status: EnrollmentStatusEnrolled}}, nil
+		},
+	}
+}
+
+func rosterUsers() *MockUserStore {
+	return &MockUserStore{
+		ListByIDsFunc: func(ctx context.Context, ids []string) ([]*User, error) {
+			names := map[string][2]string{"admin": {"Ada", "Lovelace"}, "teacher": {"Grace", "Hopper"}, "student": {"Alan", "Turing"}}
+			var users []*User
+
+			for _, id := range ids {
+				users = append(users, &User{ID: id, FirstName: names[id][0], LastName: names[id][1], Email: id + "@example.com"})
+			}
+
+			return users, nil
+		},
+	}
+}
+
+func rosterMembers() *MockOrganizationMemberStore {
+	memberStore := orgMembers()
+	memberStore.ListByOrganizationFunc = func(ctx context.Context, organizationID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{
+			{OrganizationID: organizationID, UserID: "admin", Role: RoleAdmin},
+			{OrganizationID: organizationID, UserID: "teacher", Role: RoleTeacher},
+			{OrganizationID: organizationID, UserID: "student", Role: RoleStudent},
+		}, nil
+	}
+
+	return memberStore
+}
+
+func oneRosterAPIMux(oneRosterService *OneRosterService) *http.ServeMux {
+	handler := &OneRosterHandler{oneRosterService: oneRosterService}
+	requireToken := RequireOneRosterToken(oneRosterService)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /ims/oneroster/oauth/token", http.HandlerFunc(handler.Token))
+	mux.Handle("GET "+oneRosterRosteringPath+"/{collection}", requireToken(handler.List))
+	mux.Handle("GET "+oneRosterRosteringPath+"/{collection}/{sourcedId}", requireToken(handler.Get))
+
+	return mux
+}
+
+func oneRosterGet(mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", oneRosterRosteringPath+path, nil)
+	r.Header.Set("Authorization", "Bearer token")
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestParseOneRosterFilter_ParsesPredicates(t *testing.T) {
+	filter, err := parseOneRosterFilter(`familyName~'tur' AND roles.role='student'`)
+
+	assert.NoError(t, err)
+	assert.False(t, filter.or)
+	assert.Equal(t, []*oneRosterPredicate{
+		{path: []string{"familyName"}, operator: "~", value: "tur"},
+		{path: []string{"roles", "role"}, operator: "=", value: "student"},
+	}, filter.predicates)
+}
+
+func TestParseOneRosterFilter_ReturnsErrorForMixedJoiners(t *testing.T) {
+	_, err := parseOneRosterFilter(`role='student' AND status='active' OR role='teacher'`)
+
+	assert.EqualError(t, err, "invalid filter: AND and OR cannot be mixed")
+}
+
+func TestParseOneRosterFilter_ReturnsErrorForUnquotedValue(t *testing.T) {
+	_, err := parseOneRosterFilter(`role=student`)
+
+	assert.EqualError(t, err, "invalid filter: values must be in single quotes")
+}
+
+func TestOneRosterService_IssueToken_ReturnsErrorForWrongSecret(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		GetClientFunc: func(ctx context.Context, id string) (*OneRosterClient, error) {
+			return &OneRosterClient{ID: id, OrganizationID: "org", SecretHash: hashSessionToken("secret")}, nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	response, err := oneRosterService.IssueToken(context.Background(), testOneRosterClientID, "guess", "")
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Nil(t, response)
+}
+
+func TestOneRosterService_IssueToken_ReturnsErrorForUnknownScope(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		GetClientFunc: func(ctx context.Context, id string) (*OneRosterClient, error) {
+			return &OneRosterClient{ID: id, OrganizationID: "org", SecretHash: hashSessionToken("secret")}, nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := oneRosterService.IssueToken(context.Background(), testOneRosterClientID, "secret", "https://purl.imsglobal.org/spec/or/v1p2/scope/gradebook.delete")
+
+	assert.EqualError(t, err, "unknown scope https://purl.imsglobal.org/spec/or/v1p2/scope/gradebook.delete")
+}
+
+func TestOneRosterService_IssueToken_IssuesToken(t *testing.T) {
+	var stored *OneRosterAccessToken
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		GetClientFunc: func(ctx context.Context, id string) (*OneRosterClient, error) {
+			return &OneRosterClient{ID: id, OrganizationID: "org", SecretHash: hashSessionToken("secret")}, nil
+		},
+		CreateTokenFunc: func(ctx context.Context, token *OneRosterAccessToken) error {
+			stored = token
+			return nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	response, err := oneRosterService.IssueToken(context.Background(), testOneRosterClientID, "secret", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, hashSessionToken(response.AccessToken), stored.TokenHash)
+	assert.Equal(t, "org", stored.OrganizationID)
+	assert.Equal(t, OneRosterScopeRosterCore, response.Scope)
+	assert.Equal(t, 3600, response.ExpiresIn)
+}
+
+func TestOneRosterService_AuthenticateToken_ReturnsErrorForExpiredToken(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		GetTokenByHashFunc: func(ctx context.Context, tokenHash string) (*OneRosterAccessToken, error) {
+			return &OneRosterAccessToken{TokenHash: tokenHash, OrganizationID: "org", ExpiresAt: time.Now().Add(-time.Minute)}, nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	token, err := oneRosterService.AuthenticateToken(context.Background(), "token")
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Nil(t, token)
+}
+
+func TestOneRosterService_CreateClient_ReturnsErrorForTeacher(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	response, err := oneRosterService.CreateClient(sessionContext("teacher"), "org", &CreateOneRosterClientRequest{Name: "Reading app"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, response)
+}
+
+func TestOneRosterService_CreateClient_ReturnsSecretOnce(t *testing.T) {
+	var stored *OneRosterClient
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		CreateClientFunc: func(ctx context.Context, client *OneRosterClient) error {
+			client.ID = testOneRosterClientID
+			stored = client
+			return nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	response, err := oneRosterService.CreateClient(sessionContext("admin"), "org", &CreateOneRosterClientRequest{Name: " Reading app "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Reading app", stored.Name)
+	assert.Equal(t, hashSessionToken(response.ClientSecret), stored.SecretHash)
+}
+
+func TestOneRosterService_DeleteClient_ReturnsErrorForOtherOrganization(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		GetClientFunc: func(ctx context.Context, id string) (*OneRosterClient, error) {
+			return &OneRosterClient{ID: id, OrganizationID: "other-org"}, nil
+		},
+		DeleteClientFunc: func(ctx context.Context, id string) error {
+			t.Fatal("client of another organization deleted")
+			return nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := oneRosterService.DeleteClient(sessionContext("admin"), testOneRosterClientID)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestOneRosterHandler_Token_IssuesTokenForBasicAuth(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		GetClientFunc: func(ctx context.Context, id string) (*OneRosterClient, error) {
+			return &OneRosterClient{ID: id, OrganizationID: "org", SecretHash: hashSessionToken("secret")}, nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	r := httptest.NewRequest("POST", "/ims/oneroster/oauth/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(testOneRosterClientID, "secret")
+
+	w := httptest.NewRecorder()
+	oneRosterAPIMux(oneRosterService).ServeHTTP(w, r)
+
+	var response OneRosterTokenResponse
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "bearer", response.TokenType)
+	assert.NotEmpty(t, response.AccessToken)
+}
+
+func TestOneRosterHandler_Token_ReturnsErrorForOtherGrant(t *testing.T) {
+	r := httptest.NewRequest("POST", "/ims/oneroster/oauth/token", strings.NewReader("grant_type=password"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	oneRosterAPIMux(NewOneRosterService(&MockOneRosterStore{}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"unsupported_grant_type"`)
+}
+
+func TestOneRosterHandler_List_ReturnsErrorWithoutToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	oneRosterAPIMux(NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))).ServeHTTP(w, httptest.NewRequest("GET", oneRosterRosteringPath+"/users", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"imsx_codeMinorFieldValue":"unauthorisedrequest"`)
+}
+
+func TestOneRosterHandler_List_FiltersSortsAndPages(t *testing.T) {
+	w := oneRosterGet(oneRosterAPIMux(NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))), "/users?"+url.Values{
+		"filter":  {"roles.role='teacher' OR roles.role='student'"},
+		"sort":    {"familyName"},
+		"orderBy": {"desc"},
+		"limit":   {"1"},
+		"fields":  {"sourcedId,familyName"},
+	}.Encode())
+
+	var response map[string][]map[string]any
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []map[string]any{{"sourcedId": "u-student", "familyName": "Turing"}}, response["users"])
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	assert.Contains(t, w.Header().Get("Link"), `offset=1`)
+	assert.Contains(t, w.Header().Get("Link"), `rel="next"`)
+}
+
+func TestOneRosterHandler_List_PagesUnfilteredUsersInStore(t *testing.T) {
+	oneRosterService := NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	memberStore := oneRosterService.memberStore.(*MockOrganizationMemberStore)
+	memberStore.ListByOrganizationFunc = func(ctx context.Context, organizationID string) ([]*OrganizationMember, error) {
+		t.Fatal("unfiltered pages must not load every member")
+		return nil, nil
+	}
+	memberStore.ListActivePageFunc = func(ctx context.Context, organizationID string, limit int, offset int) ([]*OrganizationMember, int, error) {
+		assert.Equal(t, 1, limit)
+		assert.Equal(t, 2, offset)
+		return []*OrganizationMember{{OrganizationID: organizationID, UserID: "student", Role: RoleStudent}}, 3, nil
+	}
+
+	w := oneRosterGet(oneRosterAPIMux(oneRosterService), "/users?limit=1&offset=2&fields=sourcedId")
+
+	var response map[string][]map[string]any
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []map[string]any{{"sourcedId": "u-student"}}, response["users"])
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+}
+
+func TestOneRosterHandler_List_LeavesOutEnrollmentsOfErasedUsers(t *testing.T) {
+	oneRosterService := NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	oneRosterService.userStore = &MockUserStore{
+		ListByIDsFunc: func(ctx context.Context, ids []string) ([]*User, error) {
+			erasedAt := time.Now()
+			return []*User{{ID: "admin"}, {ID: "teacher", ErasedAt: &erasedAt}}, nil
+		},
+	}
+
+	w := oneRosterGet(oneRosterAPIMux(oneRosterService), "/enrollments")
+
+	var response map[string][]map[string]any
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Empty(t, response["enrollments"])
+}
+
+func TestOneRosterHandler_List_ReturnsErrorForUnknownFilterField(t *testing.T) {
+	w := oneRosterGet(oneRosterAPIMux(NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))), "/classes?filter="+url.QueryEscape("teacher='x'"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"imsx_codeMinorFieldValue":"invalid_filter_field"`)
+}
+
+func TestOneRosterHandler_List_ServesSchoolsAsOrgs(t *testing.T) {
+	w := oneRosterGet(oneRosterAPIMux(NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))), "/schools")
+
+	var response map[string][]map[string]any
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Len(t, response["orgs"], 1)
+	assert.Equal(t, "North High", response["orgs"][0]["name"])
+}
+
+func TestOneRosterHandler_Get_ReturnsEnrollment(t *testing.T) {
+	w := oneRosterGet(oneRosterAPIMux(NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))), "/enrollments/seat")
+
+	var response map[string]map[string]any
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "student", response["enrollment"]["role"])
+	assert.Equal(t, map[string]any{"href": oneRosterRosteringPath + "/users/u-student", "sourcedId": "u-student", "type": "user"}, response["enrollment"]["user"])
+}
+
+func TestOneRosterHandler_Get_ReturnsErrorForUnknownSourcedID(t *testing.T) {
+	w := oneRosterGet(oneRosterAPIMux(NewOneRosterService(rosterTokens(), existingOrganization(), rosterSchools(), rosterCalendar(), rosterCourses(), rosterSections(), rosterEnrollments(), rosterUsers(), rosterMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))), "/classes/missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"imsx_codeMinorFieldValue":"unknownobject"`)
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockOneRosterStore struct {
+	ListSourcedIDsFunc func(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error)
+	SaveSourcedIDFunc  func(ctx context.Context, sourcedID *OneRosterSourcedID) error
+	CreateClientFunc   func(ctx context.Context, client *OneRosterClient) error
+	GetClientFunc      func(ctx context.Context, id string) (*OneRosterClient, error)
+	ListClientsFunc    func(ctx context.Context, organizationID string) ([]*OneRosterClient, error)
+	DeleteClientFunc   func(ctx context.Context, id string) error
+	CreateTokenFunc    func(ctx context.Context, token *OneRosterAccessToken) error
+	GetTokenByHashFunc func(ctx context.Context, tokenHash string) (*OneRosterAccessToken, error)
+}
+
+func (m *MockOneRosterStore) ListSourcedIDs(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error) {
+	if m.ListSourcedIDsFunc != nil {
+		return m.ListSourcedIDsFunc(ctx, organizationID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOneRosterStore) SaveSourcedID(ctx context.Context, sourcedID *OneRosterSourcedID) error {
+	if m.SaveSourcedIDFunc != nil {
+		return m.SaveSourcedIDFunc(ctx, sourcedID)
+	}
+
+	return nil
+}
+
+func (m *MockOneRosterStore) CreateClient(ctx context.Context, client *OneRosterClient) error {
+	if m.CreateClientFunc != nil {
+		return m.CreateClientFunc(ctx, client)
+	}
+
+	return nil
+}
+
+func (m *MockOneRosterStore) GetClient(ctx context.Context, id string) (*OneRosterClient, error) {
+	if m.GetClientFunc != nil {
+		return m.GetClientFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOneRosterStore) ListClients(ctx context.Context, organizationID string) ([]*OneRosterClient, error) {
+	if m.ListClientsFunc != nil {
+		return m.ListClientsFunc(ctx, organizationID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOneRosterStore) DeleteClient(ctx context.Context, id string) error {
+	if m.DeleteClientFunc != nil {
+		return m.DeleteClientFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockOneRosterStore) CreateToken(ctx context.Context, token *OneRosterAccessToken) error {
+	if m.CreateTokenFunc != nil {
+		return m.CreateTokenFunc(ctx, token)
+	}
+
+	return nil
+}
+
+func (m *MockOneRosterStore) GetTokenByHash(ctx context.Context, tokenHash string) (*OneRosterAccessToken, error) {
+	if m.GetTokenByHashFunc != nil {
+		return m.GetTokenByHashFunc(ctx, tokenHash)
+	}
+
+	return nil, nil
+}
+
+// Zips the files into a bundle
+func oneRosterBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		w, err := archive.Create(name)
+		assert.NoError(t, err)
+
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+// A bundle with one school, one class taught by Ada and one student enrolled in it
+func sampleOneRosterBundle(t *testing.T) []byte {
+	return oneRosterBundle(t, map[string]string{
+		"orgs.csv": "sourcedId,status,dateLastModified,name,type,identifier,parentSourcedId\n" +
+			"d1,,,Unified District,district,,\n" +
+			"s1,,,North High,school,,d1\n",
+		"academicSessions.csv": "sourcedId,status,dateLastModified,title,type,startDate,endDate,parentSourcedId,schoolYear\n" +
+			"y1,,,2025-2026,schoolYear,2025-08-01,2026-06-30,,2026\n" +
+			"t1,,,Fall,semester,2025-08-01,2025-12-20,y1,2026\n",
+		"courses.csv": "sourcedId,status,dateLastModified,schoolYearSourcedId,title,courseCode,grades,orgSourcedId,subjects,subjectCodes\n" +
+			"c1,,,y1,Algebra I,ALG1,\"09,10\",d1,Math,\n",
+		"classes.csv": "sourcedId,status,dateLastModified,title,grades,courseSourcedId,classCode,classType,location,schoolSourcedId,termSourcedIds,subjects,subjectCodes,periods\n" +
+			"k1,,,Algebra I - 1,09,c1,ALG1-1,scheduled,Room 12,s1,t1,Math,,1\n",
+		"users.csv": "sourcedId,status,dateLastModified,enabledUser,username,userIds,givenName,familyName,middleName,identifier,email\n" +
+			"u1,,,true,ada,,Ada,Lovelace,,,ada@example.com\n" +
+			"u2,,,true,grace,,Grace,Hopper,,,grace@example.com\n",
+		"roles.csv": "sourcedId,status,dateLastModified,userSourcedId,roleType,role,beginDate,endDate,orgSourcedId,userProfileSourcedId\n" +
+			"r1,,,u1,primary,teacher,,,s1,\n" +
+			"r2,,,u2,primary,student,,,s1,\n",
+		"enrollments.csv": "sourcedId,status,dateLastModified,classSourcedId,schoolSourcedId,userSourcedId,role,primary,beginDate,endDate\n" +
+			"e1,,,k1,s1,u1,teacher,true,,\n" +
+			"e2,,,k1,s1,u2,student,false,,\n",
+	})
+}
+
+func TestOneRosterGradeLevels_ConvertsGradeCodes(t *testing.T) {
+	levels := oneRosterGradeLevels([]string{"10", "KG", "PK", "IT", "09", "10", "13"})
+
+	assert.Equal(t, []int{-1, 0, 9, 10}, levels)
+	assert.Equal(t, []string{"PK", "KG", "09", "10"}, oneRosterGradeCodes(levels))
+}
+
+func TestReadOneRosterBundle_ReturnsErrorWithoutKnownFiles(t *testing.T) {
+	_, err := readOneRosterBundle(oneRosterBundle(t, map[string]string{"notes.txt": "hello"}))
+
+	assert.Error(t, err)
+}
+
+func TestOneRosterService_Import_ReturnsErrorForTeacher(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	result, err := oneRosterService.Import(sessionContext("teacher"), "org", sampleOneRosterBundle(t), &OneRosterImportOptions{})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, result)
+}
+
+func TestOneRosterService_Import_DryRunReportsChangesWithoutSaving(t *testing.T) {
+	saved := 0
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		SaveSourcedIDFunc: func(ctx context.Context, sourcedID *OneRosterSourcedID) error {
+			saved++
+			return nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	oneRosterService.userStore = &MockUserStore{
+		CreateFunc: func(ctx context.Context, user *User) error {
+			saved++
+			return nil
+		},
+	}
+
+	result, err := oneRosterService.Import(sessionContext("admin"), "org", sampleOneRosterBundle(t), &OneRosterImportOptions{DryRun: true})
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.False(t, result.Applied)
+	assert.Equal(t, 0, saved)
+	assert.Equal(t, map[string]map[string]int{
+		OneRosterTypeOrg:             {OneRosterActionUnchanged: 1, OneRosterActionCreate: 1},
+		OneRosterTypeAcademicSession: {OneRosterActionCreate: 2},
+		OneRosterTypeCourse:          {OneRosterActionCreate: 1},
+		OneRosterTypeUser:            {OneRosterActionCreate: 2},
+		OneRosterTypeClass:           {OneRosterActionCreate: 1},
+		OneRosterTypeEnrollment:      {OneRosterActionCreate: 2},
+	}, result.Summary)
+}
+
+func TestOneRosterService_Import_CreatesRecords(t *testing.T) {
+	ids := 0
+	newID := func(prefix string) string {
+		ids++
+		return fmt.Sprintf("%s-%d", prefix, ids)
+	}
+
+	links := map[string]string{}
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		SaveSourcedIDFunc: func(ctx context.Context, sourcedID *OneRosterSourcedID) error {
+			links[sourcedID.Type+"/"+sourcedID.SourcedID] = sourcedID.LocalID
+			return nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	oneRosterService.schoolStore = &MockSchoolStore{
+		CreateFunc: func(ctx context.Context, school *School) error {
+			school.ID = newID("school")
+			return nil
+		},
+	}
+	var term *Term
+	oneRosterService.calendarStore = &MockCalendarStore{
+		CreateAcademicYearFunc: func(ctx context.Context, year *AcademicYear) error {
+			year.ID = newID("year")
+			return nil
+		},
+		CreateTermFunc: func(ctx context.Context, created *Term) error {
+			created.ID = newID("term")
+			term = created
+			return nil
+		},
+	}
+	var course *Course
+	oneRosterService.courseStore = &MockCourseStore{
+		CreateFunc: func(ctx context.Context, created *Course) error {
+			created.ID = newID("course")
+			course = created
+			return nil
+		},
+	}
+	var section *Section
+	oneRosterService.sectionStore = &MockSectionStore{
+		CreateFunc: func(ctx context.Context, created *Section) error {
+			created.ID = newID("section")
+			section = created
+			return nil
+		},
+	}
+	var members []*OrganizationMember
+	memberStore := orgMembers()
+	memberStore.CreateFunc = func(ctx context.Context, member *OrganizationMember) error {
+		members = append(members, member)
+		return nil
+	}
+	oneRosterService.memberStore = memberStore
+	oneRosterService.userStore = &MockUserStore{
+		CreateFunc: func(ctx context.Context, user *User) error {
+			user.ID = newID("user")
+			return nil
+		},
+	}
+	var enrolled *Enrollment
+	oneRosterService.enrollmentStore = &MockEnrollmentStore{
+		EnrollFunc: func(ctx context.Context, enrollment *Enrollment, allowWaitlist bool) error {
+			enrollment.ID = newID("enrollment")
+			enrollment.Status = EnrollmentStatusEnrolled
+			enrolled = enrollment
+			return nil
+		},
+	}
+
+	result, err := oneRosterService.Import(sessionContext("admin"), "org", sampleOneRosterBundle(t), &OneRosterImportOptions{})
+
+	assert.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.Equal(t, "year-5", term.AcademicYearID)
+	assert.Equal(t, []int{9, 10}, course.GradeLevels)
+	assert.Equal(t, "ALG1-1", section.Code)
+	assert.Equal(t, "Room 12", section.Room)
+	assert.Equal(t, []SectionTeacher{{UserID: "user-3", Role: SectionTeacherPrimary}}, section.Teachers)
+	assert.Equal(t, &Enrollment{ID: "enrollment-8", SectionID: "section-7", StudentUserID: "user-4", Status: EnrollmentStatusEnrolled, CreatedAt: enrolled.CreatedAt, UpdatedAt: enrolled.UpdatedAt}, enrolled)
+	assert.Equal(t, RoleTeacher, members[0].Role)
+	assert.Equal(t, RoleStudent, members[1].Role)
+	assert.Equal(t, "org", links["org/d1"])
+	assert.Equal(t, "section-7", links["class/k1"])
+	assert.Equal(t, "enrollment-8", links["enrollment/e2"])
+}
+
+func TestOneRosterService_Import_UpdatesLinkedCourse(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		ListSourcedIDsFunc: func(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error) {
+			return []*OneRosterSourcedID{{OrganizationID: organizationID, Type: OneRosterTypeCourse, SourcedID: "c1", LocalID: "algebra"}}, nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	var updated *Course
+	oneRosterService.courseStore = &MockCourseStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Course, error) {
+			return &Course{ID: id, OrganizationID: "org", Code: "ALG1", Title: "Algebra", Subject: "Math", Level: CourseLevelStandard, GradeLevels: []int{10, 9}}, nil
+		},
+		UpdateFunc: func(ctx context.Context, course *Course) error {
+			updated = course
+			return nil
+		},
+	}
+
+	result, err := oneRosterService.Import(sessionContext("admin"), "org", oneRosterBundle(t, map[string]string{
+		"courses.csv": "sourcedId,title,courseCode,grades,subjects\n" +
+			"c1,Algebra I,ALG1,\"09,10\",Math\n",
+	}), &OneRosterImportOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*OneRosterChange{{Type: OneRosterTypeCourse, SourcedID: "c1", Action: OneRosterActionUpdate, LocalID: "algebra", Fields: []string{"title"}}}, result.Changes)
+	assert.Equal(t, "Algebra I", updated.Title)
+}
+
+func TestOneRosterService_Import_ReportsRowErrorsWithoutSaving(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	oneRosterService.courseStore = &MockCourseStore{
+		CreateFunc: func(ctx context.Context, course *Course) error {
+			t.Fatal("course created despite row errors")
+			return nil
+		},
+	}
+
+	result, err := oneRosterService.Import(sessionContext("admin"), "org", oneRosterBundle(t, map[string]string{
+		"courses.csv": "sourcedId,title,courseCode\n" +
+			"c1,Algebra I,ALG1\n",
+		"classes.csv": "sourcedId,title,courseSourcedId,classCode,schoolSourcedId,termSourcedIds\n" +
+			"k1,Algebra I - 1,c1,ALG1-1,s9,t1\n",
+	}), &OneRosterImportOptions{})
+
+	assert.NoError(t, err)
+	assert.False(t, result.Applied)
+	assert.Equal(t, []*OneRosterRowError{{File: "classes.csv", Line: 2, SourcedID: "k1", Message: "school s9 is not in orgs.csv"}}, result.Errors)
+}
+
+func TestOneRosterService_Export_WritesBundle(t *testing.T) {
+	oneRosterService := NewOneRosterService(&MockOneRosterStore{
+		ListSourcedIDsFunc: func(ctx context.Context, organizationID string) ([]*OneRosterSourcedID, error) {
+			return []*OneRosterSourcedID{{OrganizationID: organizationID, Type: OneRosterTypeCourse, SourcedID: "c1", LocalID: "algebra"}}, nil
+		},
+	}, existingOrganization(), &MockSchoolStore{}, &MockCalendarStore{}, &MockCourseStore{}, &MockSectionStore{}, &MockEnrollmentStore{}, &MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	oneRosterService.courseStore = &MockCourseStore{
+		ListByOrganizationFunc: func(ctx context.Context, organizationID string) ([]*Course, error) {
+			return []*Course{{ID: "algebra", OrganizationID: organizationID, Code: "ALG1", Title: "Algebra I", GradeLevels: []int{9, 10}}}, nil
+		},
+	}
+	memberStore := orgMembers()
+	memberStore.ListByOrganizationFunc = func(ctx context.Context, organizationID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: organizationID, UserID: "teacher", Role: RoleTeacher}}, nil
+	}
+	oneRosterService.memberStore = memberStore
+	oneRosterService.userStore = &MockUserStore{
+		ListByIDsFunc: func(ctx context.Context, ids []string) ([]*User, error) {
+			return []*User{{ID: ids[0], FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"}}, nil
+		},
+	}
+
+	bundle, err := oneRosterService.Export(sessionContext("admin"), "org")
+	assert.NoError(t, err)
+
+	files, err := readOneRosterBundle(bundle)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "c1", files["courses.csv"][0].get("sourcedId"))
+	assert.Equal(t, "09,10", files["courses.csv"][0].get("grades"))
+	assert.Equal(t, "org", files["courses.csv"][0].get("orgSourcedId"))
+	assert.Equal(t, "ada@example.com", files["users.csv"][0].get("email"))
+	assert.Equal(t, "teacher", files["roles.csv"][0].get("role"))
+	assert.Equal(t, "district", files["orgs.csv"][0].get("type"))
+}
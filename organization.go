@@ -1,11 +1,464 @@
 package main
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
 
 type Organization struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	OwnerUserID string    `json:"ownerUserId"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	OwnerUserID string     `json:"ownerUserId"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+type OrganizationPostgresStore struct {
+	db *PostgresDB
+}
+
+type OrganizationStore interface {
+	Create(ctx context.Context, organization *Organization) error
+	GetByID(ctx context.Context, id string) (*Organization, error)
+	Update(ctx context.Context, organization *Organization) error
+	Delete(ctx context.Context, id string) error
+	GetDeletedByID(ctx context.Context, id string) (*Organization, error)
+	Restore(ctx context.Context, id string) error
+	// Returns the ids of organizations soft deleted before the given time
+	ListDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	// Permanently removes the organization, its schools and everything that belongs to them
+	Purge(ctx context.Context, id string) error
+	ListByOwner(ctx context.Context, ownerUserID string) ([]*Organization, error)
+}
+
+func (s *OrganizationPostgresStore) Create(ctx context.Context, organization *Organization) error {
+	query := `
+		INSERT INTO organizations (name, owner_user_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, organization.Name, organization.OwnerUserID, organization.CreatedAt, organization.UpdatedAt)
+
+	if err := row.Scan(&organization.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *OrganizationPostgresStore) GetByID(ctx context.Context, id string) (*Organization, error) {
+	query := `
+		SELECT id, name, owner_user_id, created_at, updated_at
+		FROM organizations
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, id)
+
+	var organization Organization
+
+	if err := row.Scan(&organization.ID, &organization.Name, &organization.OwnerUserID, &organization.CreatedAt, &organization.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+func (s *OrganizationPostgresStore) Update(ctx context.Context, organization *Organization) error {
+	query := `
+		UPDATE organizations
+		SET name = $1, owner_user_id = $2, updated_at = $3
+		WHERE id = $4 AND deleted_at IS NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		organization.Name,
+		organization.OwnerUserID,
+		organization.UpdatedAt,
+		organization.ID,
+	)
+
+	return err
+}
+
+// Soft deletes the organization. The row is kept until Purge removes it.
+func (s *OrganizationPostgresStore) Delete(ctx context.Context, id string) error {
+	query := `
+		UPDATE organizations
+		SET deleted_at = now()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, id)
+
+	return err
+}
+
+func (s *OrganizationPostgresStore) GetDeletedByID(ctx context.Context, id string) (*Organization, error) {
+	query := `
+		SELECT id, name, owner_user_id, created_at, updated_at, deleted_at
+		FROM organizations
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, id)
+
+	var organization Organization
+
+	if err := row.Scan(&organization.ID, &organization.Name, &organization.OwnerUserID, &organization.CreatedAt, &organization.UpdatedAt, &organization.DeletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+func (s *OrganizationPostgresStore) Restore(ctx context.Context, id string) error {
+	query := `
+		UPDATE organizations
+		SET deleted_at = NULL, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, id)
+
+	return err
+}
+
+func (s *OrganizationPostgresStore) ListDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	return s.db.queryIDs(ctx, `SELECT id FROM organizations WHERE deleted_at < $1`, deletedBefore)
+}
+
+func (s *OrganizationPostgresStore) Purge(ctx context.Context, id string) error {
+	schoolIDs, err := s.db.queryIDs(ctx, `SELECT id FROM schools WHERE organization_id = $1`, id)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	for _, schoolID := range schoolIDs {
+		if err := purgeSchoolRows(ctx, tx, schoolID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM organizations WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *OrganizationPostgresStore) ListByOwner(ctx context.Context, ownerUserID string) ([]*Organization, error) {
+	query := `
+		SELECT id, name, owner_user_id, created_at, updated_at
+		FROM organizations
+		WHERE owner_user_id = $1 AND deleted_at IS NULL
+		ORDER BY name
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, ownerUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var organizations []*Organization
+
+	for rows.Next() {
+		var organization Organization
+
+		if err := rows.Scan(&organization.ID, &organization.Name, &organization.OwnerUserID, &organization.CreatedAt, &organization.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		organizations = append(organizations, &organization)
+	}
+
+	return organizations, rows.Err()
+}
+
+type OrganizationService struct {
+	organizationStore OrganizationStore
+	memberStore       OrganizationMemberStore
+	auditService      *AuditService
+}
+
+func NewOrganizationService(organizationStore OrganizationStore, memberStore OrganizationMemberStore, auditService *AuditService) *OrganizationService {
+	return &OrganizationService{organizationStore: organizationStore, memberStore: memberStore, auditService: auditService}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// Creates an organization owned and administered by the session user
+func (s *OrganizationService) Create(ctx context.Context, request *CreateOrganizationRequest) (*Organization, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	if request.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	organization := &Organization{
+		Name:        request.Name,
+		OwnerUserID: session.UserID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.organizationStore.Create(ctx, organization); err != nil {
+		slog.Error("failed to create organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	err := s.memberStore.Create(ctx, &OrganizationMember{
+		OrganizationID: organization.ID,
+		UserID:         session.UserID,
+		Role:           RoleAdmin,
+		CreatedAt:      organization.CreatedAt,
+	})
+
+	if err != nil {
+		slog.Error("failed to add organization owner as admin", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, organization.ID, AuditActionCreate, "organization", organization.ID, nil, organization)
+
+	return organization, nil
+}
+
+func (s *OrganizationService) GetByID(ctx context.Context, id string) (*Organization, error) {
+	if _, err := requireMembership(ctx, s.memberStore, id); err != nil {
+		return nil, err
+	}
+
+	organization, err := s.organizationStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	if organization == nil {
+		return nil, notFound("organization")
+	}
+
+	return organization, nil
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *OrganizationService) Update(ctx context.Context, id string, request *UpdateOrganizationRequest) (*Organization, error) {
+	if _, err := requireMembership(ctx, s.memberStore, id, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	existingOrganization, err := s.organizationStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	if existingOrganization == nil {
+		return nil, notFound("organization")
+	}
+
+	before := *existingOrganization
+
+	if request.Name != "" {
+		existingOrganization.Name = request.Name
+	}
+
+	existingOrganization.UpdatedAt = time.Now()
+
+	if err := s.organizationStore.Update(ctx, existingOrganization); err != nil {
+		slog.Error("failed to update organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, id, AuditActionUpdate, "organization", id, &before, existingOrganization)
+
+	return existingOrganization, nil
+}
+
+// Deletes an organization. Only its owner may do so.
+func (s *OrganizationService) Delete(ctx context.Context, id string) error {
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, id, RoleAdmin); err != nil {
+		return err
+	}
+
+	organization, err := s.organizationStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get organization", "error", err)
+		return ErrInternal
+	}
+
+	if organization == nil {
+		return notFound("organization")
+	}
+
+	if session, _ := SessionFromContext(ctx); session.UserID != organization.OwnerUserID {
+		return forbidden("only the owner can delete an organization")
+	}
+
+	if err := s.organizationStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete organization", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, id, AuditActionDelete, "organization", id, organization, nil)
+
+	return nil
+}
+
+// Restores a soft deleted organization. Only its owner may do so.
+func (s *OrganizationService) Restore(ctx context.Context, id string) (*Organization, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	organization, err := s.organizationStore.GetDeletedByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get deleted organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	if organization == nil {
+		return nil, notFound("deleted organization")
+	}
+
+	if session.UserID != organization.OwnerUserID {
+		return nil, forbidden("only the owner can restore an organization")
+	}
+
+	if err := s.organizationStore.Restore(ctx, id); err != nil {
+		slog.Error("failed to restore organization", "error", err)
+		return nil, ErrInternal
+	}
+
+	before := *organization
+	organization.DeletedAt = nil
+
+	s.auditService.Record(ctx, id, AuditActionRestore, "organization", id, &before, organization)
+
+	return organization, nil
+}
+
+type OrganizationHandler struct {
+	organizationService *OrganizationService
+}
+
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request CreateOrganizationRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	organization, err := h.organizationService.Create(r.Context(), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, organization)
+}
+
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	organization, err := h.organizationService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, organization)
+}
+
+func (h *OrganizationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateOrganizationRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	organization, err := h.organizationService.Update(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, organization)
+}
+
+func (h *OrganizationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.organizationService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	organization, err := h.organizationService.Restore(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, organization)
 }
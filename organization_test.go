@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockOrganizationStore struct {
+	CreateFunc            func(ctx context.Context, organization *Organization) error
+	GetByIDFunc           func(ctx context.Context, id string) (*Organization, error)
+	UpdateFunc            func(ctx context.Context, organization *Organization) error
+	DeleteFunc            func(ctx context.Context, id string) error
+	GetDeletedByIDFunc    func(ctx context.Context, id string) (*Organization, error)
+	RestoreFunc           func(ctx context.Context, id string) error
+	ListDeletedBeforeFunc func(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	PurgeFunc             func(ctx context.Context, id string) error
+	ListByOwnerFunc       func(ctx context.Context, ownerUserID string) ([]*Organization, error)
+}
+
+func (m *MockOrganizationStore) Create(ctx context.Context, organization *Organization) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, organization)
+	}
+
+	return nil
+}
+
+func (m *MockOrganizationStore) GetByID(ctx context.Context, id string) (*Organization, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOrganizationStore) Update(ctx context.Context, organization *Organization) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, organization)
+	}
+
+	return nil
+}
+
+func (m *MockOrganizationStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockOrganizationStore) GetDeletedByID(ctx context.Context, id string) (*Organization, error) {
+	if m.GetDeletedByIDFunc != nil {
+		return m.GetDeletedByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOrganizationStore) Restore(ctx context.Context, id string) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockOrganizationStore) ListDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	if m.ListDeletedBeforeFunc != nil {
+		return m.ListDeletedBeforeFunc(ctx, deletedBefore)
+	}
+
+	return nil, nil
+}
+
+func (m *MockOrganizationStore) Purge(ctx context.Context, id string) error {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockOrganizationStore) ListByOwner(ctx context.Context, ownerUserID string) ([]*Organization, error) {
+	if m.ListByOwnerFunc != nil {
+		return m.ListByOwnerFunc(ctx, ownerUserID)
+	}
+
+	return nil, nil
+}
+
+func existingOrganization() *MockOrganizationStore {
+	return &MockOrganizationStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Organization, error) {
+			return &Organization{ID: id, Name: "District", OwnerUserID: "admin"}, nil
+		},
+	}
+}
+
+func sessionContext(userID string) context.Context {
+	return contextWithSession(context.Background(), &Session{UserID: userID})
+}
+
+func TestOrganizationService_Create_ReturnsErrorWithoutSession(t *testing.T) {
+	organizationService := NewOrganizationService(&MockOrganizationStore{}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
+
+	organization, err := organizationService.Create(context.Background(), &CreateOrganizationRequest{Name: "District"})
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Nil(t, organization)
+}
+
+func TestOrganizationService_Create_ReturnsErrorForMissingName(t *testing.T) {
+	organizationService := NewOrganizationService(&MockOrganizationStore{}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
+
+	organization, err := organizationService.Create(sessionContext("admin"), &CreateOrganizationRequest{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "name is required", err.Error())
+	assert.Nil(t, organization)
+}
+
+func TestOrganizationService_Create_AddsOwnerAsAdminAndRecordsAudit(t *testing.T) {
+	var member *OrganizationMember
+	var entry *AuditEntry
+
+	organizationService := NewOrganizationService(&MockOrganizationStore{
+		CreateFunc: func(ctx context.Context, organization *Organization) error {
+			organization.ID = "org"
+			return nil
+		},
+	}, &MockOrganizationMemberStore{
+		CreateFunc: func(ctx context.Context, m *OrganizationMember) error {
+			member = m
+			return nil
+		},
+	}, NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	}, &MockOrganizationMemberStore{}))
+
+	organization, err := organizationService.Create(sessionContext("admin"), &CreateOrganizationRequest{Name: "District"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", organization.OwnerUserID)
+	assert.Equal(t, RoleAdmin, member.Role)
+	assert.Equal(t, "org", member.OrganizationID)
+	assert.Equal(t, "organization.create", entry.Action)
+	assert.Equal(t, "admin", entry.ActorUserID)
+}
+
+func TestOrganizationService_Update_ReturnsErrorForNonAdmin(t *testing.T) {
+	organizationService := NewOrganizationService(existingOrganization(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	organization, err := organizationService.Update(sessionContext("teacher"), "org", &UpdateOrganizationRequest{Name: "New"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, organization)
+}
+
+func TestOrganizationService_Update_ReturnsErrorForFailingToUpdate(t *testing.T) {
+	store := existingOrganization()
+	store.UpdateFunc = func(ctx context.Context, organization *Organization) error {
+		return errors.New("random error")
+	}
+
+	organizationService := NewOrganizationService(store, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	organization, err := organizationService.Update(sessionContext("admin"), "org", &UpdateOrganizationRequest{Name: "New"})
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Nil(t, organization)
+}
+
+func TestOrganizationService_Update_RecordsChangedName(t *testing.T) {
+	var entry *AuditEntry
+
+	organizationService := NewOrganizationService(existingOrganization(), orgMembers(), NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	}, orgMembers()))
+
+	organization, err := organizationService.Update(sessionContext("admin"), "org", &UpdateOrganizationRequest{Name: "New"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "New", organization.Name)
+	assert.Equal(t, AuditChange{Before: "District", After: "New"}, entry.Changes["name"])
+}
+
+func TestOrganizationService_Delete_ReturnsErrorForAdminWhoIsNotOwner(t *testing.T) {
+	organizationService := NewOrganizationService(existingOrganization(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := organizationService.Delete(sessionContext("other-admin"), "org")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Equal(t, "only the owner can delete an organization", err.Error())
+}
+
+func TestOrganizationService_Delete_ReturnsNoErrorForOwner(t *testing.T) {
+	deleted := false
+
+	store := existingOrganization()
+	store.DeleteFunc = func(ctx context.Context, id string) error {
+		deleted = true
+		return nil
+	}
+
+	organizationService := NewOrganizationService(store, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := organizationService.Delete(sessionContext("admin"), "org")
+
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+}
+
+func deletedOrganization() *MockOrganizationStore {
+	return &MockOrganizationStore{
+		GetDeletedByIDFunc: func(ctx context.Context, id string) (*Organization, error) {
+			deletedAt := time.Now()
+			return &Organization{ID: id, Name: "District", OwnerUserID: "admin", DeletedAt: &deletedAt}, nil
+		},
+	}
+}
+
+func TestOrganizationService_Restore_ReturnsErrorForNonOwner(t *testing.T) {
+	organizationService := NewOrganizationService(deletedOrganization(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	organization, err := organizationService.Restore(sessionContext("other-admin"), "org")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, organization)
+}
+
+func TestOrganizationService_Restore_ReturnsErrorForOrganizationNotFound(t *testing.T) {
+	organizationService := NewOrganizationService(&MockOrganizationStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	organization, err := organizationService.Restore(sessionContext("admin"), "org")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, organization)
+}
+
+func TestOrganizationService_Restore_RecordsAuditEntryForOwner(t *testing.T) {
+	var entry *AuditEntry
+
+	organizationService := NewOrganizationService(deletedOrganization(), orgMembers(), NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, e *AuditEntry) error {
+			entry = e
+			return nil
+		},
+	}, orgMembers()))
+
+	organization, err := organizationService.Restore(sessionContext("admin"), "org")
+
+	assert.NoError(t, err)
+	assert.Nil(t, organization.DeletedAt)
+	assert.Equal(t, "organization.restore", entry.Action)
+}
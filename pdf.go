@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// US Letter, in points
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 54
+)
+
+// Writes simple text documents as PDF: lines of Helvetica flowing top to bottom onto as many
+// pages as they need. Only characters in Latin-1 can be shown, others are replaced by "?".
+type pdfWriter struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+	// Printed at the bottom of every page
+	footer string
+}
+
+func newPDFWriter(footer string) *pdfWriter {
+	return &pdfWriter{footer: footer}
+}
+
+// Escapes text for a PDF string literal, encoding it as Latin-1
+func pdfEscape(text string) string {
+	var b strings.Builder
+
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || r == utf8.RuneError || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+
+	return b.String()
+}
+
+// Roughly how wide text is in Helvetica. Glyphs average about half the font size.
+func pdfTextWidth(text string, size float64) float64 {
+	return float64(utf8.RuneCountInString(text)) * size * 0.5
+}
+
+// Splits text into lines that fit width, breaking between words where it can
+func pdfWrap(text string, size float64, width float64) []string {
+	maxRunes := max(int(width/(size*0.5)), 1)
+
+	var lines []string
+	var line []rune
+
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+
+		if len(line) > 0 && len(line)+1+len(runes) > maxRunes {
+			lines = append(lines, string(line))
+			line = nil
+		}
+
+		for len(runes) > maxRunes {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+
+			lines = append(lines, string(runes[:maxRunes]))
+			runes = runes[maxRunes:]
+		}
+
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+
+		line = append(line, runes...)
+	}
+
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+
+	return lines
+}
+
+// Starts a new page when fewer than height points are left on the current one
+func (w *pdfWriter) reserve(height float64) {
+	if w.page != nil && w.y-height >= pdfMargin {
+		return
+	}
+
+	w.page = &bytes.Buffer{}
+	w.pages = append(w.pages, w.page)
+	w.y = pdfPageHeight - pdfMargin
+
+	if w.footer != "" {
+		w.text(pdfMargin, pdfMargin/2, 8, false, w.footer)
+	}
+}
+
+func (w *pdfWriter) text(x float64, y float64, size float64, bold bool, text string) {
+	font := "F1"
+
+	if bold {
+		font = "F2"
+	}
+
+	fmt.Fprintf(w.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(text))
+}
+
+// Writes text across the page, wrapping it onto further lines as needed
+func (w *pdfWriter) Line(size float64, bold bool, text string) {
+	for _, line := range pdfWrap(text, size, pdfPageWidth-2*pdfMargin) {
+		w.reserve(size * 1.4)
+		w.y -= size * 1.4
+		w.text(pdfMargin, w.y, size, bold, line)
+	}
+}
+
+// A column of a table row, starting at X points from the left margin
+type pdfCell struct {
+	X    float64
+	Text string
+}
+
+// Writes one line of cells. Cells are cut short rather than wrapped so rows stay aligned.
+func (w *pdfWriter) Row(size float64, bold bool, cells ...pdfCell) {
+	w.reserve(size * 1.4)
+	w.y -= size * 1.4
+
+	for i, cell := range cells {
+		width := float64(pdfPageWidth - 2*pdfMargin)
+
+		if i+1 < len(cells) {
+			width = cells[i+1].X - cell.X - size
+		}
+
+		text := cell.Text
+
+		for text != "" && pdfTextWidth(text, size) > width {
+			_, last := utf8.DecodeLastRuneInString(text)
+			text = text[:len(text)-last]
+		}
+
+		w.text(pdfMargin+cell.X, w.y, size, bold, text)
+	}
+}
+
+// Leaves a blank gap of height points
+func (w *pdfWriter) Space(height float64) {
+	w.reserve(height)
+	w.y -= height
+}
+
+// Returns the finished document
+func (w *pdfWriter) Bytes() []byte {
+	if len(w.pages) == 0 {
+		w.reserve(0)
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 to 4 are the catalog, the page tree and the two fonts. Each page is then a
+	// page object followed by its content stream.
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(w.pages))
+
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.Len(), page.Bytes()))
+	}
+
+	xref := out.Len()
+
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPDFEscape_EscapesDelimitersAndReplacesUnsupportedRunes(t *testing.T) {
+	assert.Equal(t, "Ren\xe9e \\(AP\\) \\\\ ?", pdfEscape("Renée (AP) \\ 漢"))
+}
+
+func TestPDFWrap_BreaksBetweenWordsAndSplitsLongWords(t *testing.T) {
+	lines := pdfWrap("the quick brown fox abcdefghijklmnop", 10, 50)
+
+	assert.Equal(t, []string{"the quick", "brown fox", "abcdefghij", "klmnop"}, lines)
+}
+
+func TestPDFWriter_Bytes_WritesValidCrossReferenceTable(t *testing.T) {
+	pdf := newPDFWriter("footer")
+
+	for i := 0; i < 60; i++ {
+		pdf.Line(12, false, fmt.Sprintf("Line %d", i))
+	}
+
+	data := pdf.Bytes()
+
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+
+	// 60 lines of 12 point text need two pages
+	assert.Contains(t, string(data), "/Count 2")
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	assert.True(t, bytes.HasPrefix(data[xref:], []byte("xref\n0 9\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	assert.Len(t, entries, 8)
+
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestPDFWriter_Bytes_WritesOnePageWhenEmpty(t *testing.T) {
+	data := newPDFWriter("").Bytes()
+
+	assert.Contains(t, string(data), "/Count 1")
+}
@@ -26,3 +26,28 @@ func ConnectToPostgres() (*PostgresDB, error) {
 
 	return &PostgresDB{pool: pool}, nil
 }
+
+// Runs a query that returns one id per row and collects the ids
+func (db *PostgresDB) queryIDs(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	defaultSoftDeleteRetention = 30 * 24 * time.Hour
+	purgeInterval              = time.Hour
+)
+
+// Returns how long soft deleted rows are kept, read from DIVINITY_SOFT_DELETE_RETENTION
+// as a Go duration such as "720h"
+func softDeleteRetention() time.Duration {
+	value := os.Getenv("DIVINITY_SOFT_DELETE_RETENTION")
+
+	if value == "" {
+		return defaultSoftDeleteRetention
+	}
+
+	retention, err := time.ParseDuration(value)
+
+	if err != nil || retention <= 0 {
+		slog.Warn("invalid DIVINITY_SOFT_DELETE_RETENTION, using default", "value", value, "default", defaultSoftDeleteRetention)
+		return defaultSoftDeleteRetention
+	}
+
+	return retention
+}
+
+type PurgeService struct {
+	userStore         UserStore
+	organizationStore OrganizationStore
+	schoolStore       SchoolStore
+	erasureService    *ErasureService
+	retention         time.Duration
+}
+
+func NewPurgeService(userStore UserStore, organizationStore OrganizationStore, schoolStore SchoolStore, erasureService *ErasureService, retention time.Duration) *PurgeService {
+	return &PurgeService{userStore: userStore, organizationStore: organizationStore, schoolStore: schoolStore, erasureService: erasureService, retention: retention}
+}
+
+// Permanently removes rows soft deleted longer ago than the retention window.
+// Schools go first, then organizations, then users, so that nothing still referenced is removed.
+// Schools and organizations are purged one at a time: one that fails is logged and left for the
+// next run without holding up the rest, and the run reports ErrInternal at the end.
+//
+// Education records are kept when their student is purged. Such users are erased instead of
+// removed, and their records only go when the school or organization they belong to is purged.
+func (s *PurgeService) Purge(ctx context.Context, now time.Time) error {
+	deletedBefore := now.Add(-s.retention)
+
+	schools, schoolsFailed := purgeEach(ctx, "school", deletedBefore, s.schoolStore.ListDeletedBefore, s.schoolStore.Purge)
+	organizations, organizationsFailed := purgeEach(ctx, "organization", deletedBefore, s.organizationStore.ListDeletedBefore, s.organizationStore.Purge)
+	failed := schoolsFailed || organizationsFailed
+
+	kept, err := s.userStore.ListExpiredWithEducationRecords(ctx, deletedBefore)
+
+	if err != nil {
+		slog.Error("failed to list users with education records", "error", err)
+		failed = true
+	}
+
+	erased := 0
+
+	for _, user := range kept {
+		if err := s.erasureService.erase(ctx, user, nil); err != nil {
+			slog.Error("failed to erase user", "error", err, "userId", user.ID)
+			failed = true
+			continue
+		}
+
+		erased++
+	}
+
+	users, err := s.userStore.PurgeDeleted(ctx, deletedBefore)
+
+	if err != nil {
+		slog.Error("failed to purge users", "error", err)
+		failed = true
+	}
+
+	slog.Info("purged soft deleted rows", "schools", schools, "organizations", organizations, "users", users, "erasedUsers", erased)
+
+	if failed {
+		return ErrInternal
+	}
+
+	return nil
+}
+
+// Purges every row list returns, one at a time. Returns how many were purged and whether any
+// step failed.
+func purgeEach(ctx context.Context, entityType string, deletedBefore time.Time, list func(ctx context.Context, deletedBefore time.Time) ([]string, error), purge func(ctx context.Context, id string) error) (int, bool) {
+	ids, err := list(ctx, deletedBefore)
+
+	if err != nil {
+		slog.Error("failed to list deleted rows", "error", err, "entityType", entityType)
+		return 0, true
+	}
+
+	purged := 0
+	failed := false
+
+	for _, id := range ids {
+		if err := purge(ctx, id); err != nil {
+			slog.Error("failed to purge", "error", err, "entityType", entityType, "entityId", id)
+			failed = true
+			continue
+		}
+
+		purged++
+	}
+
+	return purged, failed
+}
+
+// Purges on an interval until ctx is cancelled
+func (s *PurgeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Purge(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeleteRetention_ReturnsDefaultWhenUnset(t *testing.T) {
+	t.Setenv("DIVINITY_SOFT_DELETE_RETENTION", "")
+
+	assert.Equal(t, defaultSoftDeleteRetention, softDeleteRetention())
+}
+
+func TestSoftDeleteRetention_ReturnsDefaultForInvalidValue(t *testing.T) {
+	t.Setenv("DIVINITY_SOFT_DELETE_RETENTION", "a week")
+
+	assert.Equal(t, defaultSoftDeleteRetention, softDeleteRetention())
+}
+
+func TestSoftDeleteRetention_ParsesDuration(t *testing.T) {
+	t.Setenv("DIVINITY_SOFT_DELETE_RETENTION", "48h")
+
+	assert.Equal(t, 48*time.Hour, softDeleteRetention())
+}
+
+func TestPurgeService_Purge_UsesRetentionWindowForEveryStore(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expected := now.Add(-24 * time.Hour)
+	var order []string
+
+	mocks := newErasureMocks()
+	purgeService := NewPurgeService(&MockUserStore{
+		PurgeDeletedFunc: func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+			assert.Equal(t, expected, deletedBefore)
+			order = append(order, "users")
+			return 1, nil
+		},
+	}, &MockOrganizationStore{
+		ListDeletedBeforeFunc: func(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+			assert.Equal(t, expected, deletedBefore)
+			return []string{"org"}, nil
+		},
+		PurgeFunc: func(ctx context.Context, id string) error {
+			order = append(order, "organization "+id)
+			return nil
+		},
+	}, &MockSchoolStore{
+		ListDeletedBeforeFunc: func(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+			assert.Equal(t, expected, deletedBefore)
+			return []string{"school"}, nil
+		},
+		PurgeFunc: func(ctx context.Context, id string) error {
+			order = append(order, "school "+id)
+			return nil
+		},
+	}, mocks.service(), 24*time.Hour)
+
+	err := purgeService.Purge(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"school school", "organization org", "users"}, order)
+}
+
+func TestPurgeService_Purge_ContinuesPastFailures(t *testing.T) {
+	var purged []string
+	usersPurged := false
+
+	purgeService := NewPurgeService(&MockUserStore{
+		PurgeDeletedFunc: func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+			usersPurged = true
+			return 0, nil
+		},
+	}, &MockOrganizationStore{
+		ListDeletedBeforeFunc: func(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+			return nil, errors.New("random error")
+		},
+	}, &MockSchoolStore{
+		ListDeletedBeforeFunc: func(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+			return []string{"blocked", "school"}, nil
+		},
+		PurgeFunc: func(ctx context.Context, id string) error {
+			if id == "blocked" {
+				return errors.New("violates foreign key constraint")
+			}
+
+			purged = append(purged, id)
+			return nil
+		},
+	}, newErasureMocks().service(), time.Hour)
+
+	err := purgeService.Purge(context.Background(), time.Now())
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Equal(t, []string{"school"}, purged)
+	assert.True(t, usersPurged)
+}
+
+func TestPurgeService_Purge_ErasesUsersWithEducationRecords(t *testing.T) {
+	var pseudonymized *User
+
+	mocks := newErasureMocks()
+	mocks.users.ListExpiredWithEducationRecordsFunc = func(ctx context.Context, deletedBefore time.Time) ([]*User, error) {
+		return []*User{{ID: "student", FirstName: "Sam", LastName: "Student", Email: "sam@example.com"}}, nil
+	}
+	mocks.users.PseudonymizeFunc = func(ctx context.Context, user *User) error {
+		pseudonymized = user
+		return nil
+	}
+
+	purgeService := NewPurgeService(mocks.users, mocks.organizations, &MockSchoolStore{}, mocks.service(), time.Hour)
+
+	err := purgeService.Purge(context.Background(), time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "erased-student@erased.invalid", pseudonymized.Email)
+}
@@ -0,0 +1,1267 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ResourceKindRoom      = "room"
+	ResourceKindEquipment = "equipment"
+)
+
+var resourceKinds = []string{ResourceKindRoom, ResourceKindEquipment}
+
+const (
+	BookingStatusPending   = "pending"
+	BookingStatusApproved  = "approved"
+	BookingStatusRejected  = "rejected"
+	BookingStatusCancelled = "cancelled"
+)
+
+// Bookings that hold their resource
+var activeBookingStatuses = []string{BookingStatusPending, BookingStatusApproved}
+
+const (
+	// Every instructional day
+	BookingRepeatDaily  = "daily"
+	BookingRepeatWeekly = "weekly"
+)
+
+var bookingRepeats = []string{BookingRepeatDaily, BookingRepeatWeekly}
+
+var errBookingReviewed = errors.New("booking has already been reviewed")
+
+// A room or piece of equipment staff can book. Rooms are matched to sections by name, so a
+// room's bookings conflict with the classes held in it.
+type Resource struct {
+	ID       string `json:"id"`
+	SchoolID string `json:"schoolId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Capacity int    `json:"capacity"`
+	// Bookings by anyone but an administrator wait for approval
+	Restricted bool      `json:"restricted"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type BookingOccurrence struct {
+	Date      Date   `json:"date"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+// Reports whether the two occurrences share a date and some of their time
+func (o BookingOccurrence) Overlaps(other BookingOccurrence) bool {
+	return o.Date.Equal(other.Date.Time) && o.StartTime < other.EndTime && other.StartTime < o.EndTime
+}
+
+// A reservation of a resource, either for a bell schedule period or between explicit times,
+// starting on Date and repeating until Until. Occurrences holds the times it resolved to
+// when it was made.
+type Booking struct {
+	ID                string              `json:"id"`
+	ResourceID        string              `json:"resourceId"`
+	SchoolID          string              `json:"schoolId"`
+	Title             string              `json:"title"`
+	Date              Date                `json:"date"`
+	Period            string              `json:"period"`
+	StartTime         string              `json:"startTime"`
+	EndTime           string              `json:"endTime"`
+	Repeat            string              `json:"repeat"`
+	Until             Date                `json:"until"`
+	Occurrences       []BookingOccurrence `json:"occurrences"`
+	Status            string              `json:"status"`
+	RequestedByUserID string              `json:"requestedByUserId"`
+	ReviewedByUserID  string              `json:"reviewedByUserId,omitempty"`
+	ReviewedAt        *time.Time          `json:"reviewedAt,omitempty"`
+	ReviewNote        string              `json:"reviewNote"`
+	CreatedAt         time.Time           `json:"createdAt"`
+}
+
+// The last date the booking occupies
+func (b *Booking) EndDate() Date {
+	if len(b.Occurrences) == 0 {
+		return b.Date
+	}
+
+	return b.Occurrences[len(b.Occurrences)-1].Date
+}
+
+// Narrows a booking listing. Empty fields match everything; bookings are matched on the
+// dates between their first and last occurrence.
+type BookingFilter struct {
+	SchoolID   string
+	ResourceID string
+	Statuses   []string
+	From       Date
+	To         Date
+}
+
+type ResourcePostgresStore struct {
+	db *PostgresDB
+}
+
+type ResourceStore interface {
+	CreateResource(ctx context.Context, resource *Resource) error
+	GetResource(ctx context.Context, id string) (*Resource, error)
+	ListResources(ctx context.Context, schoolID string) ([]*Resource, error)
+	UpdateResource(ctx context.Context, resource *Resource) error
+	DeleteResource(ctx context.Context, id string) error
+	CreateBooking(ctx context.Context, booking *Booking) error
+	GetBooking(ctx context.Context, id string) (*Booking, error)
+	ListBookings(ctx context.Context, filter *BookingFilter) ([]*Booking, error)
+	// Saves the booking's review, returning errBookingReviewed if it is no longer pending
+	ReviewBooking(ctx context.Context, booking *Booking) error
+	CancelBooking(ctx context.Context, id string) error
+}
+
+const resourceColumns = `id, school_id, name, kind, capacity, restricted, created_at, updated_at`
+
+func scanResource(row rowScanner) (*Resource, error) {
+	var resource Resource
+
+	err := row.Scan(&resource.ID, &resource.SchoolID, &resource.Name, &resource.Kind, &resource.Capacity, &resource.Restricted, &resource.CreatedAt, &resource.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &resource, nil
+}
+
+func (s *ResourcePostgresStore) CreateResource(ctx context.Context, resource *Resource) error {
+	query := `
+		INSERT INTO resources (school_id, name, kind, capacity, restricted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		resource.SchoolID,
+		resource.Name,
+		resource.Kind,
+		resource.Capacity,
+		resource.Restricted,
+		resource.CreatedAt,
+		resource.UpdatedAt,
+	)
+
+	return row.Scan(&resource.ID)
+}
+
+func (s *ResourcePostgresStore) GetResource(ctx context.Context, id string) (*Resource, error) {
+	query := `SELECT ` + resourceColumns + ` FROM resources WHERE id = $1`
+
+	return noRowsAsNil(scanResource(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *ResourcePostgresStore) ListResources(ctx context.Context, schoolID string) ([]*Resource, error) {
+	query := `SELECT ` + resourceColumns + ` FROM resources WHERE school_id = $1 ORDER BY kind, name, id`
+
+	rows, err := s.db.pool.Query(ctx, query, schoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var resources []*Resource
+
+	for rows.Next() {
+		resource, err := scanResource(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, rows.Err()
+}
+
+func (s *ResourcePostgresStore) UpdateResource(ctx context.Context, resource *Resource) error {
+	query := `
+		UPDATE resources
+		SET name = $1, kind = $2, capacity = $3, restricted = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, resource.Name, resource.Kind, resource.Capacity, resource.Restricted, resource.UpdatedAt, resource.ID)
+
+	return err
+}
+
+func (s *ResourcePostgresStore) DeleteResource(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM resources WHERE id = $1`, id)
+	return err
+}
+
+const bookingColumns = `
+	id, resource_id, school_id, title, start_date, period, start_time, end_time, repeat, until, occurrences, status,
+	COALESCE(requested_by_user_id::text, ''), COALESCE(reviewed_by_user_id::text, ''), reviewed_at, review_note, created_at
+`
+
+func scanBooking(row rowScanner) (*Booking, error) {
+	var booking Booking
+
+	err := row.Scan(
+		&booking.ID,
+		&booking.ResourceID,
+		&booking.SchoolID,
+		&booking.Title,
+		&booking.Date,
+		&booking.Period,
+		&booking.StartTime,
+		&booking.EndTime,
+		&booking.Repeat,
+		&booking.Until,
+		&booking.Occurrences,
+		&booking.Status,
+		&booking.RequestedByUserID,
+		&booking.ReviewedByUserID,
+		&booking.ReviewedAt,
+		&booking.ReviewNote,
+		&booking.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+func (s *ResourcePostgresStore) CreateBooking(ctx context.Context, booking *Booking) error {
+	query := `
+		INSERT INTO bookings (
+			resource_id, school_id, title, start_date, period, start_time, end_time, repeat, until, occurrences, end_date,
+			status, requested_by_user_id, reviewed_by_user_id, reviewed_at, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, '')::uuid, NULLIF($14, '')::uuid, $15, $16)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		booking.ResourceID,
+		booking.SchoolID,
+		booking.Title,
+		booking.Date,
+		booking.Period,
+		booking.StartTime,
+		booking.EndTime,
+		booking.Repeat,
+		booking.Until,
+		booking.Occurrences,
+		booking.EndDate(),
+		booking.Status,
+		booking.RequestedByUserID,
+		booking.ReviewedByUserID,
+		booking.ReviewedAt,
+		booking.CreatedAt,
+	)
+
+	return row.Scan(&booking.ID)
+}
+
+func (s *ResourcePostgresStore) GetBooking(ctx context.Context, id string) (*Booking, error) {
+	query := `SELECT ` + bookingColumns + ` FROM bookings WHERE id = $1`
+
+	return noRowsAsNil(scanBooking(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *ResourcePostgresStore) ListBookings(ctx context.Context, filter *BookingFilter) ([]*Booking, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("school_id = $%d", filter.SchoolID)
+	}
+
+	if filter.ResourceID != "" {
+		addCondition("resource_id = $%d", filter.ResourceID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		addCondition("status = ANY($%d)", filter.Statuses)
+	}
+
+	if !filter.From.IsZero() {
+		addCondition("end_date >= $%d", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		addCondition("start_date <= $%d", filter.To)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM bookings
+		%s
+		ORDER BY start_date, start_time, created_at, id
+	`, bookingColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var bookings []*Booking
+
+	for rows.Next() {
+		booking, err := scanBooking(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, rows.Err()
+}
+
+func (s *ResourcePostgresStore) ReviewBooking(ctx context.Context, booking *Booking) error {
+	query := `
+		UPDATE bookings
+		SET status = $1, reviewed_by_user_id = NULLIF($2, '')::uuid, reviewed_at = $3, review_note = $4
+		WHERE id = $5 AND status = 'pending'
+	`
+
+	tag, err := s.db.pool.Exec(ctx, query, booking.Status, booking.ReviewedByUserID, booking.ReviewedAt, booking.ReviewNote, booking.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errBookingReviewed
+	}
+
+	return nil
+}
+
+func (s *ResourcePostgresStore) CancelBooking(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `UPDATE bookings SET status = 'cancelled' WHERE id = $1`, id)
+	return err
+}
+
+func validateResource(resource *Resource, existing []*Resource) error {
+	resource.Name = strings.TrimSpace(resource.Name)
+
+	if resource.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if !slices.Contains(resourceKinds, resource.Kind) {
+		return fmt.Errorf("kind must be one of %v", resourceKinds)
+	}
+
+	if resource.Capacity < 0 {
+		return errors.New("capacity must not be negative")
+	}
+
+	for _, other := range existing {
+		if other.ID != resource.ID && strings.EqualFold(other.Name, resource.Name) {
+			return fmt.Errorf("the school already has a resource named %s", other.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateBooking(booking *Booking) error {
+	booking.Title = strings.TrimSpace(booking.Title)
+
+	if booking.Title == "" {
+		return errors.New("title is required")
+	}
+
+	if booking.Date.IsZero() {
+		return errors.New("date is required")
+	}
+
+	if booking.Period != "" {
+		if booking.StartTime != "" || booking.EndTime != "" {
+			return errors.New("a booking is for a period or between times, not both")
+		}
+	} else {
+		_, startErr := time.Parse(clockLayout, booking.StartTime)
+		_, endErr := time.Parse(clockLayout, booking.EndTime)
+
+		if startErr != nil || endErr != nil {
+			return errors.New("a booking needs a period or start and end times as HH:MM")
+		}
+
+		if booking.EndTime <= booking.StartTime {
+			return errors.New("booking must end after it starts")
+		}
+	}
+
+	if booking.Repeat == "" {
+		if !booking.Until.IsZero() {
+			return errors.New("until is only allowed on repeating bookings")
+		}
+
+		return nil
+	}
+
+	if !slices.Contains(bookingRepeats, booking.Repeat) {
+		return fmt.Errorf("repeat must be one of %v", bookingRepeats)
+	}
+
+	if booking.Until.IsZero() {
+		return errors.New("until is required for repeating bookings")
+	}
+
+	if booking.Until.Before(booking.Date.Time) {
+		return errors.New("until must not be before the date")
+	}
+
+	if booking.Until.Sub(booking.Date.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return fmt.Errorf("a booking cannot repeat for more than %d days", maxInstructionalDaysRange)
+	}
+
+	return nil
+}
+
+// Resolves the booking to the times it occupies on the given school days, which start on the
+// booking's date. Daily bookings repeat on instructional days and weekly bookings on the
+// same weekday. Period bookings take the period's times each day and skip repeats on days
+// the period does not run.
+func bookingOccurrences(booking *Booking, days []SchoolDay) ([]BookingOccurrence, error) {
+	occurrences := []BookingOccurrence{}
+
+	for _, day := range days {
+		first := day.Date.Equal(booking.Date.Time)
+
+		switch {
+		case first:
+		case booking.Repeat == BookingRepeatDaily && day.Instructional:
+		case booking.Repeat == BookingRepeatWeekly && day.Date.Weekday() == booking.Date.Weekday():
+		default:
+			continue
+		}
+
+		if booking.Period == "" {
+			occurrences = append(occurrences, BookingOccurrence{Date: day.Date, StartTime: booking.StartTime, EndTime: booking.EndTime})
+			continue
+		}
+
+		period, ok := (&BellSchedule{Periods: day.Periods}).Period(booking.Period)
+
+		if !ok {
+			if first {
+				return nil, fmt.Errorf("period %s does not run on %s", booking.Period, day.Date)
+			}
+
+			continue
+		}
+
+		occurrences = append(occurrences, BookingOccurrence{Date: day.Date, StartTime: period.StartTime, EndTime: period.EndTime})
+	}
+
+	return occurrences, nil
+}
+
+// A time a resource is taken, by a booking or a section meeting in the room
+type ResourceSlot struct {
+	Date      Date   `json:"date"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Title     string `json:"title"`
+	BookingID string `json:"bookingId,omitempty"`
+	SectionID string `json:"sectionId,omitempty"`
+	// The booking's status. Empty for sections.
+	Status string `json:"status,omitempty"`
+}
+
+// Returns the first slot that overlaps one of the occurrences
+func findBookingConflict(occurrences []BookingOccurrence, slots []ResourceSlot) (*ResourceSlot, bool) {
+	for _, occurrence := range occurrences {
+		for _, slot := range slots {
+			if occurrence.Overlaps(BookingOccurrence{Date: slot.Date, StartTime: slot.StartTime, EndTime: slot.EndTime}) {
+				return &slot, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+type ResourceService struct {
+	resourceStore ResourceStore
+	bellStore     BellStore
+	calendarStore CalendarStore
+	sectionStore  SectionStore
+	schoolStore   SchoolStore
+	memberStore   OrganizationMemberStore
+	auditService  *AuditService
+}
+
+func NewResourceService(resourceStore ResourceStore, bellStore BellStore, calendarStore CalendarStore, sectionStore SectionStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *ResourceService {
+	return &ResourceService{
+		resourceStore: resourceStore,
+		bellStore:     bellStore,
+		calendarStore: calendarStore,
+		sectionStore:  sectionStore,
+		schoolStore:   schoolStore,
+		memberStore:   memberStore,
+		auditService:  auditService,
+	}
+}
+
+func (s *ResourceService) listResources(ctx context.Context, schoolID string) ([]*Resource, error) {
+	resources, err := s.resourceStore.ListResources(ctx, schoolID)
+
+	if err != nil {
+		slog.Error("failed to list resources", "error", err)
+		return nil, ErrInternal
+	}
+
+	if resources == nil {
+		resources = []*Resource{}
+	}
+
+	return resources, nil
+}
+
+func (s *ResourceService) CreateResource(ctx context.Context, resource *Resource) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, resource.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.listResources(ctx, resource.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateResource(resource, existing); err != nil {
+		return err
+	}
+
+	resource.CreatedAt = time.Now()
+	resource.UpdatedAt = time.Now()
+
+	if err := s.resourceStore.CreateResource(ctx, resource); err != nil {
+		slog.Error("failed to create resource", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "resource", resource.ID, nil, resource)
+
+	return nil
+}
+
+func (s *ResourceService) ListResources(ctx context.Context, schoolID string) ([]*Resource, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	return s.listResources(ctx, schoolID)
+}
+
+// Returns the resource and its school if the session user belongs to the school's
+// organization with one of the given roles
+func (s *ResourceService) authorizeResource(ctx context.Context, id string, roles ...string) (*Resource, *School, error) {
+	resource, err := s.resourceStore.GetResource(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get resource", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if resource == nil {
+		return nil, nil, notFound("resource")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, resource.SchoolID, roles...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource, school, nil
+}
+
+func (s *ResourceService) GetResource(ctx context.Context, id string) (*Resource, error) {
+	resource, _, err := s.authorizeResource(ctx, id)
+	return resource, err
+}
+
+type UpdateResourceRequest struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Capacity   *int   `json:"capacity"`
+	Restricted *bool  `json:"restricted"`
+}
+
+// Updates the resource. Existing bookings keep their status when restriction changes.
+func (s *ResourceService) UpdateResource(ctx context.Context, id string, request *UpdateResourceRequest) (*Resource, error) {
+	resource, school, err := s.authorizeResource(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *resource
+
+	if request.Name != "" {
+		resource.Name = request.Name
+	}
+
+	if request.Kind != "" {
+		resource.Kind = request.Kind
+	}
+
+	if request.Capacity != nil {
+		resource.Capacity = *request.Capacity
+	}
+
+	if request.Restricted != nil {
+		resource.Restricted = *request.Restricted
+	}
+
+	existing, err := s.listResources(ctx, resource.SchoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateResource(resource, existing); err != nil {
+		return nil, err
+	}
+
+	resource.UpdatedAt = time.Now()
+
+	if err := s.resourceStore.UpdateResource(ctx, resource); err != nil {
+		slog.Error("failed to update resource", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "resource", id, &before, resource)
+
+	return resource, nil
+}
+
+// Deletes a resource without upcoming bookings
+func (s *ResourceService) DeleteResource(ctx context.Context, id string) error {
+	resource, school, err := s.authorizeResource(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	upcoming, err := s.resourceStore.ListBookings(ctx, &BookingFilter{ResourceID: id, Statuses: activeBookingStatuses, From: DateOf(time.Now())})
+
+	if err != nil {
+		slog.Error("failed to list bookings", "error", err)
+		return ErrInternal
+	}
+
+	if len(upcoming) > 0 {
+		return errors.New("resource still has upcoming bookings")
+	}
+
+	if err := s.resourceStore.DeleteResource(ctx, id); err != nil {
+		slog.Error("failed to delete resource", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "resource", id, resource, nil)
+
+	return nil
+}
+
+// Returns the times the resource is taken between from and to, inclusive: its pending and
+// approved bookings other than excludeBookingID and, for rooms, the sections meeting in it
+func (s *ResourceService) slots(ctx context.Context, resource *Resource, from Date, to Date, excludeBookingID string) ([]ResourceSlot, error) {
+	bookings, err := s.resourceStore.ListBookings(ctx, &BookingFilter{ResourceID: resource.ID, Statuses: activeBookingStatuses, From: from, To: to})
+
+	if err != nil {
+		slog.Error("failed to list bookings", "error", err)
+		return nil, ErrInternal
+	}
+
+	slots := []ResourceSlot{}
+
+	for _, booking := range bookings {
+		if booking.ID == excludeBookingID {
+			continue
+		}
+
+		for _, occurrence := range booking.Occurrences {
+			if occurrence.Date.Within(from, to) {
+				slots = append(slots, ResourceSlot{Date: occurrence.Date, StartTime: occurrence.StartTime, EndTime: occurrence.EndTime, Title: booking.Title, BookingID: booking.ID, Status: booking.Status})
+			}
+		}
+	}
+
+	if resource.Kind == ResourceKindRoom {
+		sectionSlots, err := s.sectionSlots(ctx, resource, from, to)
+
+		if err != nil {
+			return nil, err
+		}
+
+		slots = append(slots, sectionSlots...)
+	}
+
+	slices.SortStableFunc(slots, func(a, b ResourceSlot) int {
+		return cmp.Or(a.Date.Compare(b.Date.Time), strings.Compare(a.StartTime, b.StartTime))
+	})
+
+	return slots, nil
+}
+
+// Returns when the school's sections held in the room meet between from and to
+func (s *ResourceService) sectionSlots(ctx context.Context, room *Resource, from Date, to Date) ([]ResourceSlot, error) {
+	sections, err := s.sectionStore.List(ctx, &SectionFilter{SchoolID: room.SchoolID})
+
+	if err != nil {
+		slog.Error("failed to list sections", "error", err)
+		return nil, ErrInternal
+	}
+
+	sections = slices.DeleteFunc(sections, func(section *Section) bool {
+		return !strings.EqualFold(strings.TrimSpace(section.Room), room.Name)
+	})
+
+	if len(sections) == 0 {
+		return nil, nil
+	}
+
+	calendar, err := loadBellCalendar(ctx, s.bellStore, s.calendarStore, room.SchoolID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	days := calendar.days(from, to)
+	terms := map[string]*Term{}
+	slots := []ResourceSlot{}
+
+	for _, section := range sections {
+		term, ok := terms[section.TermID]
+
+		if !ok {
+			term, err = s.calendarStore.GetTerm(ctx, section.TermID)
+
+			if err != nil {
+				slog.Error("failed to get term", "error", err)
+				return nil, ErrInternal
+			}
+
+			terms[section.TermID] = term
+		}
+
+		if term == nil {
+			continue
+		}
+
+		inTerm := slices.DeleteFunc(slices.Clone(days), func(day SchoolDay) bool {
+			return !day.Date.Within(term.StartDate, term.EndDate)
+		})
+
+		for _, occurrence := range sectionOccurrences(section, inTerm) {
+			slots = append(slots, ResourceSlot{Date: occurrence.Date, StartTime: occurrence.StartTime, EndTime: occurrence.EndTime, Title: "section " + section.Code, SectionID: section.ID})
+		}
+	}
+
+	return slots, nil
+}
+
+// Returns an error describing the first slot the occurrences conflict with, if any
+func (s *ResourceService) checkConflicts(ctx context.Context, resource *Resource, occurrences []BookingOccurrence, excludeBookingID string) error {
+	if len(occurrences) == 0 {
+		return nil
+	}
+
+	slots, err := s.slots(ctx, resource, occurrences[0].Date, occurrences[len(occurrences)-1].Date, excludeBookingID)
+
+	if err != nil {
+		return err
+	}
+
+	if slot, ok := findBookingConflict(occurrences, slots); ok {
+		return fmt.Errorf("%s is taken by %s on %s from %s to %s", resource.Name, slot.Title, slot.Date, slot.StartTime, slot.EndTime)
+	}
+
+	return nil
+}
+
+// Books the resource for the session user. Bookings of restricted resources wait for an
+// administrator's approval unless an administrator makes them.
+func (s *ResourceService) CreateBooking(ctx context.Context, booking *Booking) error {
+	resource, school, err := s.authorizeResource(ctx, booking.ResourceID)
+
+	if err != nil {
+		return err
+	}
+
+	member, err := requireMembership(ctx, s.memberStore, school.OrganizationID, RoleAdmin, RoleTeacher)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateBooking(booking); err != nil {
+		return err
+	}
+
+	until := booking.Date
+
+	if booking.Repeat != "" {
+		until = booking.Until
+	}
+
+	calendar, err := loadBellCalendar(ctx, s.bellStore, s.calendarStore, resource.SchoolID)
+
+	if err != nil {
+		return err
+	}
+
+	occurrences, err := bookingOccurrences(booking, calendar.days(booking.Date, until))
+
+	if err != nil {
+		return err
+	}
+
+	if len(occurrences) == 0 {
+		return errors.New("booking does not occur on any day")
+	}
+
+	if err := s.checkConflicts(ctx, resource, occurrences, ""); err != nil {
+		return err
+	}
+
+	booking.SchoolID = resource.SchoolID
+	booking.Occurrences = occurrences
+	booking.RequestedByUserID = member.UserID
+	booking.ReviewedByUserID = ""
+	booking.ReviewedAt = nil
+	booking.ReviewNote = ""
+	booking.Status = BookingStatusApproved
+	booking.CreatedAt = time.Now()
+
+	if resource.Restricted {
+		if member.Role == RoleAdmin {
+			booking.ReviewedByUserID = member.UserID
+			booking.ReviewedAt = &booking.CreatedAt
+		} else {
+			booking.Status = BookingStatusPending
+		}
+	}
+
+	if err := s.resourceStore.CreateBooking(ctx, booking); err != nil {
+		slog.Error("failed to create booking", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "booking", booking.ID, nil, booking)
+
+	return nil
+}
+
+// Returns the booking and its school if the session user belongs to the school's organization
+// with one of the given roles
+func (s *ResourceService) authorizeBooking(ctx context.Context, id string, roles ...string) (*Booking, *School, error) {
+	booking, err := s.resourceStore.GetBooking(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get booking", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if booking == nil {
+		return nil, nil, notFound("booking")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, booking.SchoolID, roles...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return booking, school, nil
+}
+
+func (s *ResourceService) GetBooking(ctx context.Context, id string) (*Booking, error) {
+	booking, _, err := s.authorizeBooking(ctx, id)
+	return booking, err
+}
+
+// Lists a school's bookings, narrowed by filter. Administrators use it to find bookings
+// waiting for approval.
+func (s *ResourceService) ListBookings(ctx context.Context, schoolID string, filter *BookingFilter) ([]*Booking, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	filter.SchoolID = schoolID
+
+	if filter.ResourceID != "" {
+		if _, _, err := s.authorizeResource(ctx, filter.ResourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	bookings, err := s.resourceStore.ListBookings(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list bookings", "error", err)
+		return nil, ErrInternal
+	}
+
+	if bookings == nil {
+		bookings = []*Booking{}
+	}
+
+	return bookings, nil
+}
+
+// Returns the times the resource is taken between from and to, inclusive
+func (s *ResourceService) Slots(ctx context.Context, resourceID string, from Date, to Date) ([]ResourceSlot, error) {
+	resource, _, err := s.authorizeResource(ctx, resourceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateDateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	if to.Sub(from.Time) > maxInstructionalDaysRange*24*time.Hour {
+		return nil, fmt.Errorf("range must not exceed %d days", maxInstructionalDaysRange)
+	}
+
+	return s.slots(ctx, resource, from, to, "")
+}
+
+type ReviewBookingRequest struct {
+	Approve bool   `json:"approve"`
+	Note    string `json:"note"`
+}
+
+// Approves or rejects a pending booking. Approval checks conflicts again, since sections may
+// have moved into the room while the booking waited.
+func (s *ResourceService) ReviewBooking(ctx context.Context, id string, request *ReviewBookingRequest) (*Booking, error) {
+	booking, school, err := s.authorizeBooking(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.Status != BookingStatusPending {
+		return nil, errBookingReviewed
+	}
+
+	session, _ := SessionFromContext(ctx)
+	before := *booking
+
+	if request.Approve {
+		resource, err := s.resourceStore.GetResource(ctx, booking.ResourceID)
+
+		if err != nil {
+			slog.Error("failed to get resource", "error", err)
+			return nil, ErrInternal
+		}
+
+		if resource == nil {
+			return nil, notFound("resource")
+		}
+
+		if err := s.checkConflicts(ctx, resource, booking.Occurrences, booking.ID); err != nil {
+			return nil, err
+		}
+
+		booking.Status = BookingStatusApproved
+	} else {
+		booking.Status = BookingStatusRejected
+	}
+
+	now := time.Now()
+	booking.ReviewedByUserID = session.UserID
+	booking.ReviewedAt = &now
+	booking.ReviewNote = strings.TrimSpace(request.Note)
+
+	if err := s.resourceStore.ReviewBooking(ctx, booking); err != nil {
+		if errors.Is(err, errBookingReviewed) {
+			return nil, err
+		}
+
+		slog.Error("failed to review booking", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "booking", id, &before, booking)
+
+	return booking, nil
+}
+
+// Cancels a pending or approved booking. Staff may cancel their own bookings; administrators
+// may cancel any.
+func (s *ResourceService) CancelBooking(ctx context.Context, id string) error {
+	booking, err := s.resourceStore.GetBooking(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get booking", "error", err)
+		return ErrInternal
+	}
+
+	if booking == nil {
+		return notFound("booking")
+	}
+
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	roles := []string{RoleAdmin}
+
+	if session.UserID == booking.RequestedByUserID {
+		roles = nil
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, booking.SchoolID, roles...)
+
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(activeBookingStatuses, booking.Status) {
+		return fmt.Errorf("booking is already %s", booking.Status)
+	}
+
+	if err := s.resourceStore.CancelBooking(ctx, id); err != nil {
+		slog.Error("failed to cancel booking", "error", err)
+		return ErrInternal
+	}
+
+	after := *booking
+	after.Status = BookingStatusCancelled
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "booking", id, booking, &after)
+
+	return nil
+}
+
+type ResourceHandler struct {
+	resourceService *ResourceService
+}
+
+func (h *ResourceHandler) CreateResource(w http.ResponseWriter, r *http.Request) {
+	var resource Resource
+
+	if err := decodeJSON(r, &resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resource.ID = ""
+	resource.SchoolID = r.PathValue("id")
+
+	if err := h.resourceService.CreateResource(r.Context(), &resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resource)
+}
+
+func (h *ResourceHandler) ListResources(w http.ResponseWriter, r *http.Request) {
+	resources, err := h.resourceService.ListResources(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resources)
+}
+
+func (h *ResourceHandler) GetResource(w http.ResponseWriter, r *http.Request) {
+	resource, err := h.resourceService.GetResource(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resource)
+}
+
+func (h *ResourceHandler) UpdateResource(w http.ResponseWriter, r *http.Request) {
+	var request UpdateResourceRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resource, err := h.resourceService.UpdateResource(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resource)
+}
+
+func (h *ResourceHandler) DeleteResource(w http.ResponseWriter, r *http.Request) {
+	if err := h.resourceService.DeleteResource(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Lists when the resource is taken between the from and to query parameters, defaulting to
+// today
+func (h *ResourceHandler) Slots(w http.ResponseWriter, r *http.Request) {
+	from, err := dateQueryParam(r, "from", DateOf(time.Now()))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := dateQueryParam(r, "to", from)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	slots, err := h.resourceService.Slots(r.Context(), r.PathValue("id"), from, to)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, slots)
+}
+
+func (h *ResourceHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	var booking Booking
+
+	if err := decodeJSON(r, &booking); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	booking.ID = ""
+	booking.ResourceID = r.PathValue("id")
+
+	if err := h.resourceService.CreateBooking(r.Context(), &booking); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, booking)
+}
+
+// Lists the school's bookings, narrowed by the resourceId, status, from and to query parameters
+func (h *ResourceHandler) ListBookings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, err := dateQueryParam(r, "from", Date{})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := dateQueryParam(r, "to", Date{})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	filter := &BookingFilter{ResourceID: query.Get("resourceId"), From: from, To: to}
+
+	if status := query.Get("status"); status != "" {
+		filter.Statuses = []string{status}
+	}
+
+	bookings, err := h.resourceService.ListBookings(r.Context(), r.PathValue("id"), filter)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bookings)
+}
+
+func (h *ResourceHandler) GetBooking(w http.ResponseWriter, r *http.Request) {
+	booking, err := h.resourceService.GetBooking(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, booking)
+}
+
+func (h *ResourceHandler) ReviewBooking(w http.ResponseWriter, r *http.Request) {
+	var request ReviewBookingRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	booking, err := h.resourceService.ReviewBooking(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, booking)
+}
+
+func (h *ResourceHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	if err := h.resourceService.CancelBooking(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockResourceStore struct {
+	CreateResourceFunc func(ctx context.Context, resource *Resource) error
+	GetResourceFunc    func(ctx context.Context, id string) (*Resource, error)
+	ListResourcesFunc  func(ctx context.Context, schoolID string) ([]*Resource, error)
+	UpdateResourceFunc func(ctx context.Context, resource *Resource) error
+	DeleteResourceFunc func(ctx context.Context, id string) error
+	CreateBookingFunc  func(ctx context.Context, booking *Booking) error
+	GetBookingFunc     func(ctx context.Context, id string) (*Booking, error)
+	ListBookingsFunc   func(ctx context.Context, filter *BookingFilter) ([]*Booking, error)
+	ReviewBookingFunc  func(ctx context.Context, booking *Booking) error
+	CancelBookingFunc  func(ctx context.Context, id string) error
+}
+
+func (m *MockResourceStore) CreateResource(ctx context.Context, resource *Resource) error {
+	if m.CreateResourceFunc != nil {
+		return m.CreateResourceFunc(ctx, resource)
+	}
+
+	return nil
+}
+
+func (m *MockResourceStore) GetResource(ctx context.Context, id string) (*Resource, error) {
+	if m.GetResourceFunc != nil {
+		return m.GetResourceFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockResourceStore) ListResources(ctx context.Context, schoolID string) ([]*Resource, error) {
+	if m.ListResourcesFunc != nil {
+		return m.ListResourcesFunc(ctx, schoolID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockResourceStore) UpdateResource(ctx context.Context, resource *Resource) error {
+	if m.UpdateResourceFunc != nil {
+		return m.UpdateResourceFunc(ctx, resource)
+	}
+
+	return nil
+}
+
+func (m *MockResourceStore) DeleteResource(ctx context.Context, id string) error {
+	if m.DeleteResourceFunc != nil {
+		return m.DeleteResourceFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockResourceStore) CreateBooking(ctx context.Context, booking *Booking) error {
+	if m.CreateBookingFunc != nil {
+		return m.CreateBookingFunc(ctx, booking)
+	}
+
+	return nil
+}
+
+func (m *MockResourceStore) GetBooking(ctx context.Context, id string) (*Booking, error) {
+	if m.GetBookingFunc != nil {
+		return m.GetBookingFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockResourceStore) ListBookings(ctx context.Context, filter *BookingFilter) ([]*Booking, error) {
+	if m.ListBookingsFunc != nil {
+		return m.ListBookingsFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockResourceStore) ReviewBooking(ctx context.Context, booking *Booking) error {
+	if m.ReviewBookingFunc != nil {
+		return m.ReviewBookingFunc(ctx, booking)
+	}
+
+	return nil
+}
+
+func (m *MockResourceStore) CancelBooking(ctx context.Context, id string) error {
+	if m.CancelBookingFunc != nil {
+		return m.CancelBookingFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// The gym, where geometry meets in period 1 on A days, and the restricted chemistry lab
+func schoolResources() *MockResourceStore {
+	return &MockResourceStore{
+		GetResourceFunc: func(ctx context.Context, id string) (*Resource, error) {
+			switch id {
+			case "gym":
+				return &Resource{ID: id, SchoolID: "school", Name: "Gym", Kind: ResourceKindRoom}, nil
+			case "lab":
+				return &Resource{ID: id, SchoolID: "school", Name: "Chemistry Lab", Kind: ResourceKindRoom, Restricted: true}, nil
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+// Sections held in the gym on A days and in B12 every day
+func roomSections() *MockSectionStore {
+	sectionStore, _ := attendanceFixtures()
+	sectionStore.ListFunc = func(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+		return []*Section{
+			{ID: "geo", CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Room: "gym ", Periods: []SectionPeriod{{Day: "A", Period: "1"}}},
+			{ID: "alg", CourseID: "alg1", SchoolID: "school", TermID: "fall", Code: "02", Room: "B12", Periods: []SectionPeriod{{Period: "2"}}},
+		}, nil
+	}
+
+	return sectionStore
+}
+
+func TestBookingOccurrences_RepeatsPeriodOnInstructionalDays(t *testing.T) {
+	days := []SchoolDay{
+		{Date: schoolDay, Instructional: true, Periods: []BellPeriod{{Name: "1", StartTime: "08:00", EndTime: "09:30"}}},
+		{Date: schoolDay.AddDays(1), Instructional: true, Periods: []BellPeriod{{Name: "2", StartTime: "09:40", EndTime: "11:10"}}},
+		{Date: schoolDay.AddDays(2), Instructional: true, Periods: []BellPeriod{{Name: "1", StartTime: "08:00", EndTime: "08:50"}}},
+		{Date: schoolDay.AddDays(5)},
+	}
+
+	occurrences, err := bookingOccurrences(&Booking{Date: schoolDay, Period: "1", Repeat: BookingRepeatDaily}, days)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []BookingOccurrence{
+		{Date: schoolDay, StartTime: "08:00", EndTime: "09:30"},
+		{Date: schoolDay.AddDays(2), StartTime: "08:00", EndTime: "08:50"},
+	}, occurrences)
+}
+
+func TestBookingOccurrences_RepeatsWeeklyOnSameWeekday(t *testing.T) {
+	days := []SchoolDay{}
+
+	for i := range 15 {
+		days = append(days, SchoolDay{Date: schoolDay.AddDays(i)})
+	}
+
+	occurrences, err := bookingOccurrences(&Booking{Date: schoolDay, StartTime: "15:00", EndTime: "17:00", Repeat: BookingRepeatWeekly}, days)
+
+	assert.NoError(t, err)
+	assert.Len(t, occurrences, 3)
+	assert.Equal(t, holiday, occurrences[1].Date)
+}
+
+func TestBookingOccurrences_ReturnsErrorWhenPeriodDoesNotRunOnDate(t *testing.T) {
+	_, err := bookingOccurrences(&Booking{Date: holiday, Period: "1"}, []SchoolDay{{Date: holiday}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "period 1 does not run on 2025-10-13", err.Error())
+}
+
+func TestValidateBooking_ReturnsErrorForPeriodAndTimes(t *testing.T) {
+	err := validateBooking(&Booking{Title: "Assembly", Date: schoolDay, Period: "1", StartTime: "08:00", EndTime: "09:00"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "a booking is for a period or between times, not both", err.Error())
+}
+
+func TestValidateBooking_ReturnsErrorForRepeatWithoutUntil(t *testing.T) {
+	err := validateBooking(&Booking{Title: "Practice", Date: schoolDay, StartTime: "15:00", EndTime: "17:00", Repeat: BookingRepeatWeekly})
+
+	assert.Error(t, err)
+	assert.Equal(t, "until is required for repeating bookings", err.Error())
+}
+
+func TestResourceService_CreateBooking_ApprovesUnrestrictedResource(t *testing.T) {
+	var created *Booking
+	resourceStore := schoolResources()
+	resourceStore.CreateBookingFunc = func(ctx context.Context, booking *Booking) error {
+		created = booking
+		return nil
+	}
+
+	booking := &Booking{ResourceID: "gym", Title: "Assembly", Date: schoolDay.AddDays(1), Period: "1"}
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CreateBooking(sessionContext("teacher"), booking)
+
+	assert.NoError(t, err)
+	assert.Equal(t, BookingStatusApproved, created.Status)
+	assert.Equal(t, "teacher", created.RequestedByUserID)
+	assert.Equal(t, []BookingOccurrence{{Date: schoolDay.AddDays(1), StartTime: "08:00", EndTime: "09:30"}}, created.Occurrences)
+}
+
+func TestResourceService_CreateBooking_ReturnsErrorForSectionInRoom(t *testing.T) {
+	resourceStore := schoolResources()
+	resourceStore.CreateBookingFunc = func(ctx context.Context, booking *Booking) error {
+		t.Fatal("booking should not be created")
+		return nil
+	}
+
+	// Geometry meets in the gym in period 1 on the A day of the 8th
+	booking := &Booking{ResourceID: "gym", Title: "Assembly", Date: schoolDay.AddDays(1), Period: "1", Repeat: BookingRepeatDaily, Until: schoolDay.AddDays(2)}
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CreateBooking(sessionContext("teacher"), booking)
+
+	assert.Error(t, err)
+	assert.Equal(t, "Gym is taken by section 01 on 2025-10-08 from 08:00 to 08:50", err.Error())
+}
+
+func TestResourceService_CreateBooking_ReturnsErrorForOverlappingBooking(t *testing.T) {
+	resourceStore := schoolResources()
+	resourceStore.ListBookingsFunc = func(ctx context.Context, filter *BookingFilter) ([]*Booking, error) {
+		return []*Booking{{ID: "practice", ResourceID: "gym", Title: "Basketball practice", Status: BookingStatusPending, Occurrences: []BookingOccurrence{{Date: schoolDay, StartTime: "15:00", EndTime: "17:00"}}}}, nil
+	}
+
+	booking := &Booking{ResourceID: "gym", Title: "Open gym", Date: schoolDay, StartTime: "16:30", EndTime: "18:00"}
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CreateBooking(sessionContext("teacher"), booking)
+
+	assert.Error(t, err)
+	assert.Equal(t, "Gym is taken by Basketball practice on 2025-10-06 from 15:00 to 17:00", err.Error())
+}
+
+func TestResourceService_CreateBooking_WaitsForApprovalOfRestrictedResource(t *testing.T) {
+	booking := &Booking{ResourceID: "lab", Title: "Titration lab", Date: schoolDay, StartTime: "13:00", EndTime: "14:00"}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(schoolResources(), abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CreateBooking(sessionContext("teacher"), booking)
+
+	assert.NoError(t, err)
+	assert.Equal(t, BookingStatusPending, booking.Status)
+	assert.Nil(t, booking.ReviewedAt)
+}
+
+func TestResourceService_CreateBooking_ApprovesRestrictedResourceForAdmin(t *testing.T) {
+	booking := &Booking{ResourceID: "lab", Title: "Safety inspection", Date: schoolDay, StartTime: "13:00", EndTime: "14:00"}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(schoolResources(), abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CreateBooking(sessionContext("admin"), booking)
+
+	assert.NoError(t, err)
+	assert.Equal(t, BookingStatusApproved, booking.Status)
+	assert.Equal(t, "admin", booking.ReviewedByUserID)
+}
+
+func TestResourceService_CreateBooking_ReturnsErrorForStudent(t *testing.T) {
+	booking := &Booking{ResourceID: "gym", Title: "Party", Date: schoolDay, StartTime: "15:00", EndTime: "16:00"}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(schoolResources(), abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CreateBooking(sessionContext("student"), booking)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestResourceService_ReviewBooking_ApprovesPendingBooking(t *testing.T) {
+	var reviewed *Booking
+	resourceStore := schoolResources()
+	resourceStore.GetBookingFunc = func(ctx context.Context, id string) (*Booking, error) {
+		return &Booking{ID: id, ResourceID: "lab", SchoolID: "school", Title: "Titration lab", Status: BookingStatusPending, RequestedByUserID: "teacher",
+			Occurrences: []BookingOccurrence{{Date: schoolDay, StartTime: "13:00", EndTime: "14:00"}}}, nil
+	}
+	resourceStore.ListBookingsFunc = func(ctx context.Context, filter *BookingFilter) ([]*Booking, error) {
+		// The booking itself is among the lab's active bookings
+		booking, _ := resourceStore.GetBookingFunc(ctx, "booking")
+		return []*Booking{booking}, nil
+	}
+	resourceStore.ReviewBookingFunc = func(ctx context.Context, booking *Booking) error {
+		reviewed = booking
+		return nil
+	}
+
+	_, calendarStore := attendanceFixtures()
+	_, err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).ReviewBooking(sessionContext("admin"), "booking", &ReviewBookingRequest{Approve: true, Note: " Goggles required "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, BookingStatusApproved, reviewed.Status)
+	assert.Equal(t, "admin", reviewed.ReviewedByUserID)
+	assert.Equal(t, "Goggles required", reviewed.ReviewNote)
+}
+
+func TestResourceService_ReviewBooking_ReturnsErrorForTeacher(t *testing.T) {
+	resourceStore := schoolResources()
+	resourceStore.GetBookingFunc = func(ctx context.Context, id string) (*Booking, error) {
+		return &Booking{ID: id, ResourceID: "lab", SchoolID: "school", Status: BookingStatusPending, RequestedByUserID: "teacher"}, nil
+	}
+
+	_, calendarStore := attendanceFixtures()
+	_, err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).ReviewBooking(sessionContext("teacher"), "booking", &ReviewBookingRequest{Approve: true})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestResourceService_CancelBooking_AllowsRequester(t *testing.T) {
+	cancelled := ""
+	resourceStore := schoolResources()
+	resourceStore.GetBookingFunc = func(ctx context.Context, id string) (*Booking, error) {
+		return &Booking{ID: id, ResourceID: "gym", SchoolID: "school", Status: BookingStatusApproved, RequestedByUserID: "teacher"}, nil
+	}
+	resourceStore.CancelBookingFunc = func(ctx context.Context, id string) error {
+		cancelled = id
+		return nil
+	}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CancelBooking(sessionContext("teacher"), "booking")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "booking", cancelled)
+}
+
+func TestResourceService_CancelBooking_ReturnsErrorForOtherMember(t *testing.T) {
+	resourceStore := schoolResources()
+	resourceStore.GetBookingFunc = func(ctx context.Context, id string) (*Booking, error) {
+		return &Booking{ID: id, ResourceID: "gym", SchoolID: "school", Status: BookingStatusApproved, RequestedByUserID: "admin"}, nil
+	}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).CancelBooking(sessionContext("teacher"), "booking")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestResourceService_DeleteResource_ReturnsErrorForUpcomingBookings(t *testing.T) {
+	resourceStore := schoolResources()
+	resourceStore.ListBookingsFunc = func(ctx context.Context, filter *BookingFilter) ([]*Booking, error) {
+		return []*Booking{{ID: "booking"}}, nil
+	}
+	resourceStore.DeleteResourceFunc = func(ctx context.Context, id string) error {
+		t.Fatal("resource should not be deleted")
+		return nil
+	}
+
+	_, calendarStore := attendanceFixtures()
+	err := NewResourceService(resourceStore, abRotation(), calendarStore, roomSections(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())).DeleteResource(sessionContext("admin"), "gym")
+
+	assert.Error(t, err)
+	assert.Equal(t, "resource still has upcoming bookings", err.Error())
+}
+
+func TestValidateResource_ReturnsErrorForDuplicateName(t *testing.T) {
+	err := validateResource(&Resource{Name: "gym", Kind: ResourceKindRoom}, []*Resource{{ID: "gym", Name: "Gym"}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "the school already has a resource named Gym", err.Error())
+}
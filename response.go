@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Writes v as the JSON response body with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Writes err as a JSON error response, picking the status code from the error
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusForError(err), ErrorResponse{Error: err.Error()})
+}
+
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrInternal):
+		return http.StatusInternalServerError
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// Decodes the JSON request body into v
+func decodeJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.New("invalid request body")
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm       = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat      = "20060102T150405Z"
+	sigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// The longest a presigned S3 URL may be valid for
+	s3MaxPresignDuration = 7 * 24 * time.Hour
+)
+
+// Signs requests with AWS Signature Version 4
+type sigV4Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	service         string
+}
+
+// Escapes s as SigV4 requires: everything but unreserved characters is percent encoded,
+// and slashes too unless keepSlash is set
+func sigV4Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sigV4CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var pairs []string
+
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+
+		for _, value := range values {
+			pairs = append(pairs, sigV4Escape(key, false)+"="+sigV4Escape(value, false))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// Returns the canonical headers block and the signed headers list. The host is always
+// signed, along with the content type, range and every x-amz- header.
+func sigV4CanonicalHeaders(host string, header http.Header) (string, string) {
+	values := map[string]string{"host": host}
+
+	for name, value := range header {
+		name = strings.ToLower(name)
+
+		if name == "content-type" || name == "content-md5" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, len(value))
+
+			for i := range value {
+				trimmed[i] = strings.Join(strings.Fields(value[i]), " ")
+			}
+
+			values[name] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *sigV4Signer) scope(at time.Time) string {
+	return at.Format("20060102") + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+// Signs the canonical request made from the parts, returning the hex signature
+func (s *sigV4Signer) signature(at time.Time, method string, u *url.URL, query url.Values, canonicalHeaders string, signedHeaders string, payloadHash string) string {
+	canonicalRequest := strings.Join([]string{
+		method,
+		sigV4Escape(u.Path, true),
+		sigV4CanonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		at.Format(sigV4TimeFormat),
+		s.scope(at),
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), at.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// Adds the x-amz-date, x-amz-content-sha256 and Authorization headers to r
+func (s *sigV4Signer) sign(r *http.Request, payloadHash string, at time.Time) {
+	at = at.UTC()
+
+	r.Header.Set("X-Amz-Date", at.Format(sigV4TimeFormat))
+	r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := r.Host
+
+	if host == "" {
+		host = r.URL.Host
+	}
+
+	canonicalHeaders, signedHeaders := sigV4CanonicalHeaders(host, r.Header)
+	signature := s.signature(at, r.Method, r.URL, r.URL.Query(), canonicalHeaders, signedHeaders, payloadHash)
+
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s,SignedHeaders=%s,Signature=%s", sigV4Algorithm, s.accessKeyID, s.scope(at), signedHeaders, signature))
+}
+
+// Returns u with query parameters that authorize a request with the method for the duration
+func (s *sigV4Signer) presign(method string, u *url.URL, expires time.Duration, at time.Time) string {
+	at = at.UTC()
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+s.scope(at))
+	query.Set("X-Amz-Date", at.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalHeaders, signedHeaders := sigV4CanonicalHeaders(u.Host, nil)
+	signature := s.signature(at, method, u, query, canonicalHeaders, signedHeaders, sigV4UnsignedPayload)
+
+	signed := *u
+	signed.RawQuery = sigV4CanonicalQuery(query) + "&X-Amz-Signature=" + signature
+
+	return signed.String()
+}
+
+type S3Config struct {
+	// Base URL of the S3 compatible service, such as "https://s3.us-east-1.amazonaws.com"
+	// or "http://localhost:9000" for MinIO
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Stores blobs in a bucket of an S3 compatible service, addressed path style so it works
+// with MinIO and other stand-ins as well as AWS
+type S3BlobStore struct {
+	endpoint *url.URL
+	bucket   string
+	signer   *sigV4Signer
+	client   *http.Client
+}
+
+func NewS3BlobStore(config *S3Config) (*S3BlobStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("s3 blob store needs an endpoint, bucket, access key id and secret access key")
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+
+	region := config.Region
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3BlobStore{
+		endpoint: endpoint,
+		bucket:   config.Bucket,
+		signer:   &sigV4Signer{accessKeyID: config.AccessKeyID, secretAccessKey: config.SecretAccessKey, region: region, service: "s3"},
+		client:   &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+func (s *S3BlobStore) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = s.endpoint.Path + "/" + s.bucket + "/" + key
+	u.RawPath = sigV4Escape(u.Path, true)
+
+	return &u
+}
+
+// Sends a signed request and returns the response. Statuses other than 2xx and 404 are
+// turned into errors. A body must come with its size, which S3 requires up front.
+func (s *S3BlobStore) do(ctx context.Context, method string, key string, body io.Reader, size int64, header http.Header, payloadHash string) (*http.Response, error) {
+	r, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		r.ContentLength = size
+
+		if size == 0 {
+			r.Body = http.NoBody
+		}
+	}
+
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	s.signer.sign(r, payloadHash, time.Now())
+
+	response, err := s.client.Do(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= 300 && response.StatusCode != http.StatusNotFound {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		response.Body.Close()
+
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, response.Status, message)
+	}
+
+	return response, nil
+}
+
+// The service verifies the body against x-amz-content-sha256, so corrupted uploads are rejected.
+// The checksum is also kept as object metadata so downloads can be verified.
+func (s *S3BlobStore) Put(ctx context.Context, key string, body io.Reader, meta *BlobMetadata) error {
+	header := http.Header{}
+	header.Set("Content-Type", meta.ContentType)
+	header.Set("X-Amz-Meta-Sha256", meta.Checksum)
+
+	response, err := s.do(ctx, http.MethodPut, key, io.LimitReader(body, meta.Size), meta.Size, header, meta.Checksum)
+
+	if err != nil {
+		return err
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("s3 bucket %s not found", s.bucket)
+	}
+
+	return nil
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, *BlobMetadata, error) {
+	response, err := s.do(ctx, http.MethodGet, key, nil, 0, nil, sha256Hex(""))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, nil, nil
+	}
+
+	meta := &BlobMetadata{
+		Size:        response.ContentLength,
+		ContentType: response.Header.Get("Content-Type"),
+		Checksum:    response.Header.Get("X-Amz-Meta-Sha256"),
+	}
+
+	return verifyBlob(response.Body, meta), meta, nil
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	response, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil, sha256Hex(""))
+
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Presigns a GET for the object. S3 caps presigned URLs at seven days.
+func (s *S3BlobStore) SignedURL(ctx context.Context, key string, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	expires := expiresAt.Sub(now).Truncate(time.Second)
+
+	if expires <= 0 {
+		return "", errors.New("signed url would already be expired")
+	}
+
+	expires = min(expires, s3MaxPresignDuration)
+
+	u := s.objectURL(key)
+	u.RawQuery = url.Values{"response-content-disposition": {"attachment"}}.Encode()
+
+	return s.signer.presign(http.MethodGet, u, expires, now), nil
+}
@@ -1,16 +1,555 @@
 package main
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
 
 type School struct {
-	ID             string    `json:"id"`
-	OrganizationID string    `json:"organizationId"`
-	Name           string    `json:"name"`
-	Address        string    `json:"address"`
-	City           string    `json:"city"`
-	State          string    `json:"state"`
-	Zip            string    `json:"zip"`
-	Phone          string    `json:"phone"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+	ID             string `json:"id"`
+	OrganizationID string `json:"organizationId"`
+	Name           string `json:"name"`
+	Address        string `json:"address"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	Zip            string `json:"zip"`
+	Phone          string `json:"phone"`
+	// The IANA time zone the school's bell schedules and calendar are in, e.g. America/Chicago
+	Timezone  string     `json:"timezone"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type SchoolPostgresStore struct {
+	db *PostgresDB
+}
+
+type SchoolStore interface {
+	Create(ctx context.Context, school *School) error
+	GetByID(ctx context.Context, id string) (*School, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*School, error)
+	Update(ctx context.Context, school *School) error
+	Delete(ctx context.Context, id string) error
+	GetDeletedByID(ctx context.Context, id string) (*School, error)
+	Restore(ctx context.Context, id string) error
+	// Returns the ids of schools soft deleted before the given time
+	ListDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	// Permanently removes the school and everything that belongs to it
+	Purge(ctx context.Context, id string) error
+}
+
+func (s *SchoolPostgresStore) Create(ctx context.Context, school *School) error {
+	query := `
+		INSERT INTO schools (organization_id, name, address, city, state, zip, phone, timezone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		school.OrganizationID,
+		school.Name,
+		school.Address,
+		school.City,
+		school.State,
+		school.Zip,
+		school.Phone,
+		school.Timezone,
+		school.CreatedAt,
+		school.UpdatedAt,
+	)
+
+	if err := row.Scan(&school.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SchoolPostgresStore) GetByID(ctx context.Context, id string) (*School, error) {
+	query := `
+		SELECT id, organization_id, name, address, city, state, zip, phone, timezone, created_at, updated_at
+		FROM schools
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, id)
+
+	var school School
+
+	if err := row.Scan(&school.ID, &school.OrganizationID, &school.Name, &school.Address, &school.City, &school.State, &school.Zip, &school.Phone, &school.Timezone, &school.CreatedAt, &school.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &school, nil
+}
+
+func (s *SchoolPostgresStore) ListByOrganization(ctx context.Context, organizationID string) ([]*School, error) {
+	query := `
+		SELECT id, organization_id, name, address, city, state, zip, phone, timezone, created_at, updated_at
+		FROM schools
+		WHERE organization_id = $1 AND deleted_at IS NULL
+		ORDER BY name
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, organizationID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var schools []*School
+
+	for rows.Next() {
+		var school School
+
+		if err := rows.Scan(&school.ID, &school.OrganizationID, &school.Name, &school.Address, &school.City, &school.State, &school.Zip, &school.Phone, &school.Timezone, &school.CreatedAt, &school.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		schools = append(schools, &school)
+	}
+
+	return schools, rows.Err()
+}
+
+func (s *SchoolPostgresStore) Update(ctx context.Context, school *School) error {
+	query := `
+		UPDATE schools
+		SET name = $1, address = $2, city = $3, state = $4, zip = $5, phone = $6, timezone = $7, updated_at = $8
+		WHERE id = $9 AND deleted_at IS NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		school.Name,
+		school.Address,
+		school.City,
+		school.State,
+		school.Zip,
+		school.Phone,
+		school.Timezone,
+		school.UpdatedAt,
+		school.ID,
+	)
+
+	return err
+}
+
+// Soft deletes the school. The row is kept until Purge removes it.
+func (s *SchoolPostgresStore) Delete(ctx context.Context, id string) error {
+	query := `
+		UPDATE schools
+		SET deleted_at = now()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, id)
+
+	return err
+}
+
+func (s *SchoolPostgresStore) GetDeletedByID(ctx context.Context, id string) (*School, error) {
+	query := `
+		SELECT id, organization_id, name, address, city, state, zip, phone, timezone, created_at, updated_at, deleted_at
+		FROM schools
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, id)
+
+	var school School
+
+	if err := row.Scan(&school.ID, &school.OrganizationID, &school.Name, &school.Address, &school.City, &school.State, &school.Zip, &school.Phone, &school.Timezone, &school.CreatedAt, &school.UpdatedAt, &school.DeletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &school, nil
+}
+
+func (s *SchoolPostgresStore) Restore(ctx context.Context, id string) error {
+	query := `
+		UPDATE schools
+		SET deleted_at = NULL, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, id)
+
+	return err
+}
+
+func (s *SchoolPostgresStore) ListDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	return s.db.queryIDs(ctx, `SELECT id FROM schools WHERE deleted_at < $1`, deletedBefore)
+}
+
+func (s *SchoolPostgresStore) Purge(ctx context.Context, id string) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := purgeSchoolRows(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM schools WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Deletes, children first, the rows under a school that a RESTRICT key would otherwise stop the
+// school's cascade from removing. Everything else under the school cascades when it is deleted,
+// education records included: purging a school takes its students' records there with it.
+var schoolPurgeSteps = []string{
+	`DELETE FROM enrollments WHERE section_id IN (SELECT id FROM sections WHERE school_id = $1)`,
+	// Assignments reference their section's grade categories, which the section cascade removes first
+	`DELETE FROM assignments WHERE section_id IN (SELECT id FROM sections WHERE school_id = $1)`,
+	`DELETE FROM bell_overrides WHERE school_id = $1`,
+	// Sections reference terms and, when the organization goes too, courses
+	`DELETE FROM sections WHERE school_id = $1`,
+	// Report cards reference their term
+	`DELETE FROM academic_documents WHERE school_id = $1`,
+	// Terms reference academic years
+	`DELETE FROM terms WHERE school_id = $1`,
+}
+
+func purgeSchoolRows(ctx context.Context, tx pgx.Tx, schoolID string) error {
+	for _, step := range schoolPurgeSteps {
+		if _, err := tx.Exec(ctx, step, schoolID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type SchoolService struct {
+	schoolStore  SchoolStore
+	memberStore  OrganizationMemberStore
+	auditService *AuditService
+}
+
+func NewSchoolService(schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *SchoolService {
+	return &SchoolService{schoolStore: schoolStore, memberStore: memberStore, auditService: auditService}
+}
+
+func validateSchool(school *School) error {
+	if school.OrganizationID == "" {
+		return errors.New("organization id is required")
+	}
+
+	if school.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if _, err := time.LoadLocation(school.Timezone); err != nil || school.Timezone == "" {
+		return fmt.Errorf("unknown time zone %q", school.Timezone)
+	}
+
+	return nil
+}
+
+// Returns the school's time zone, or UTC if it cannot be loaded
+func (s *School) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func (s *SchoolService) Create(ctx context.Context, school *School) error {
+	if school.Timezone == "" {
+		school.Timezone = "UTC"
+	}
+
+	if err := validateSchool(school); err != nil {
+		return err
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, school.OrganizationID, RoleAdmin); err != nil {
+		return err
+	}
+
+	school.CreatedAt = time.Now()
+	school.UpdatedAt = time.Now()
+
+	if err := s.schoolStore.Create(ctx, school); err != nil {
+		slog.Error("failed to create school", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "school", school.ID, nil, school)
+
+	return nil
+}
+
+// Returns the school if the session user belongs to its organization with one of the given roles
+func authorizeSchool(ctx context.Context, schoolStore SchoolStore, memberStore OrganizationMemberStore, id string, roles ...string) (*School, error) {
+	school, err := schoolStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get school", "error", err)
+		return nil, ErrInternal
+	}
+
+	if school == nil {
+		return nil, notFound("school")
+	}
+
+	if _, err := requireMembership(ctx, memberStore, school.OrganizationID, roles...); err != nil {
+		return nil, err
+	}
+
+	return school, nil
+}
+
+func (s *SchoolService) authorize(ctx context.Context, id string, roles ...string) (*School, error) {
+	return authorizeSchool(ctx, s.schoolStore, s.memberStore, id, roles...)
+}
+
+func (s *SchoolService) GetByID(ctx context.Context, id string) (*School, error) {
+	return s.authorize(ctx, id)
+}
+
+func (s *SchoolService) ListByOrganization(ctx context.Context, organizationID string) ([]*School, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID); err != nil {
+		return nil, err
+	}
+
+	schools, err := s.schoolStore.ListByOrganization(ctx, organizationID)
+
+	if err != nil {
+		slog.Error("failed to list schools", "error", err)
+		return nil, ErrInternal
+	}
+
+	if schools == nil {
+		schools = []*School{}
+	}
+
+	return schools, nil
+}
+
+type UpdateSchoolRequest struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	City     string `json:"city"`
+	State    string `json:"state"`
+	Zip      string `json:"zip"`
+	Phone    string `json:"phone"`
+	Timezone string `json:"timezone"`
+}
+
+func (s *SchoolService) Update(ctx context.Context, id string, request *UpdateSchoolRequest) (*School, error) {
+	existingSchool, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *existingSchool
+
+	if request.Name != "" {
+		existingSchool.Name = request.Name
+	}
+
+	if request.Address != "" {
+		existingSchool.Address = request.Address
+	}
+
+	if request.City != "" {
+		existingSchool.City = request.City
+	}
+
+	if request.State != "" {
+		existingSchool.State = request.State
+	}
+
+	if request.Zip != "" {
+		existingSchool.Zip = request.Zip
+	}
+
+	if request.Phone != "" {
+		existingSchool.Phone = request.Phone
+	}
+
+	if request.Timezone != "" {
+		if _, err := time.LoadLocation(request.Timezone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", request.Timezone)
+		}
+
+		existingSchool.Timezone = request.Timezone
+	}
+
+	existingSchool.UpdatedAt = time.Now()
+
+	if err := s.schoolStore.Update(ctx, existingSchool); err != nil {
+		slog.Error("failed to update school", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, existingSchool.OrganizationID, AuditActionUpdate, "school", id, &before, existingSchool)
+
+	return existingSchool, nil
+}
+
+func (s *SchoolService) Delete(ctx context.Context, id string) error {
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	school, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.schoolStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete school", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "school", id, school, nil)
+
+	return nil
+}
+
+func (s *SchoolService) Restore(ctx context.Context, id string) (*School, error) {
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	school, err := s.schoolStore.GetDeletedByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get deleted school", "error", err)
+		return nil, ErrInternal
+	}
+
+	if school == nil {
+		return nil, notFound("deleted school")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, school.OrganizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := s.schoolStore.Restore(ctx, id); err != nil {
+		slog.Error("failed to restore school", "error", err)
+		return nil, ErrInternal
+	}
+
+	before := *school
+	school.DeletedAt = nil
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionRestore, "school", id, &before, school)
+
+	return school, nil
+}
+
+type SchoolHandler struct {
+	schoolService *SchoolService
+}
+
+func (h *SchoolHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var school School
+
+	if err := decodeJSON(r, &school); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	school.OrganizationID = r.PathValue("organizationId")
+
+	if err := h.schoolService.Create(r.Context(), &school); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, school)
+}
+
+func (h *SchoolHandler) List(w http.ResponseWriter, r *http.Request) {
+	schools, err := h.schoolService.ListByOrganization(r.Context(), r.PathValue("organizationId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schools)
+}
+
+func (h *SchoolHandler) Get(w http.ResponseWriter, r *http.Request) {
+	school, err := h.schoolService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, school)
+}
+
+func (h *SchoolHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateSchoolRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	school, err := h.schoolService.Update(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, school)
+}
+
+func (h *SchoolHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.schoolService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SchoolHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	school, err := h.schoolService.Restore(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, school)
 }
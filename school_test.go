@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockSchoolStore struct {
+	CreateFunc             func(ctx context.Context, school *School) error
+	GetByIDFunc            func(ctx context.Context, id string) (*School, error)
+	ListByOrganizationFunc func(ctx context.Context, organizationID string) ([]*School, error)
+	UpdateFunc             func(ctx context.Context, school *School) error
+	DeleteFunc             func(ctx context.Context, id string) error
+	GetDeletedByIDFunc     func(ctx context.Context, id string) (*School, error)
+	RestoreFunc            func(ctx context.Context, id string) error
+	ListDeletedBeforeFunc  func(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	PurgeFunc              func(ctx context.Context, id string) error
+}
+
+func (m *MockSchoolStore) Create(ctx context.Context, school *School) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, school)
+	}
+
+	return nil
+}
+
+func (m *MockSchoolStore) GetByID(ctx context.Context, id string) (*School, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSchoolStore) ListByOrganization(ctx context.Context, organizationID string) ([]*School, error) {
+	if m.ListByOrganizationFunc != nil {
+		return m.ListByOrganizationFunc(ctx, organizationID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSchoolStore) Update(ctx context.Context, school *School) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, school)
+	}
+
+	return nil
+}
+
+func (m *MockSchoolStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockSchoolStore) GetDeletedByID(ctx context.Context, id string) (*School, error) {
+	if m.GetDeletedByIDFunc != nil {
+		return m.GetDeletedByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSchoolStore) Restore(ctx context.Context, id string) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockSchoolStore) ListDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	if m.ListDeletedBeforeFunc != nil {
+		return m.ListDeletedBeforeFunc(ctx, deletedBefore)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSchoolStore) Purge(ctx context.Context, id string) error {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func existingSchool() *MockSchoolStore {
+	return &MockSchoolStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*School, error) {
+			return &School{ID: id, OrganizationID: "org", Name: "North High"}, nil
+		},
+	}
+}
+
+func TestValidateSchool_ReturnsErrorForMissingName(t *testing.T) {
+	err := validateSchool(&School{OrganizationID: "org"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "name is required", err.Error())
+}
+
+func TestValidateSchool_ReturnsErrorForMissingOrganization(t *testing.T) {
+	err := validateSchool(&School{Name: "North High"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "organization id is required", err.Error())
+}
+
+func TestValidateSchool_ReturnsErrorForUnknownTimezone(t *testing.T) {
+	err := validateSchool(&School{OrganizationID: "org", Name: "North High", Timezone: "Mars/Olympus_Mons"})
+
+	assert.Error(t, err)
+	assert.Equal(t, `unknown time zone "Mars/Olympus_Mons"`, err.Error())
+}
+
+func TestSchoolService_Create_ReturnsErrorForNonAdmin(t *testing.T) {
+	schoolService := NewSchoolService(&MockSchoolStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := schoolService.Create(sessionContext("teacher"), &School{OrganizationID: "org", Name: "North High"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestSchoolService_Create_ReturnsErrorForFailingToCreate(t *testing.T) {
+	schoolService := NewSchoolService(&MockSchoolStore{
+		CreateFunc: func(ctx context.Context, school *School) error {
+			return errors.New("random error")
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := schoolService.Create(sessionContext("admin"), &School{OrganizationID: "org", Name: "North High"})
+
+	assert.ErrorIs(t, err, ErrInternal)
+}
+
+func TestSchoolService_Create_ReturnsNoErrorForAdmin(t *testing.T) {
+	schoolService := NewSchoolService(&MockSchoolStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	school := &School{OrganizationID: "org", Name: "North High"}
+	err := schoolService.Create(sessionContext("admin"), school)
+
+	assert.NoError(t, err)
+	assert.False(t, school.CreatedAt.IsZero())
+	assert.Equal(t, "UTC", school.Timezone)
+}
+
+func TestSchoolService_GetByID_ReturnsErrorForSchoolNotFound(t *testing.T) {
+	schoolService := NewSchoolService(&MockSchoolStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	school, err := schoolService.GetByID(sessionContext("admin"), "1")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "school not found", err.Error())
+	assert.Nil(t, school)
+}
+
+func TestSchoolService_GetByID_ReturnsErrorForNonMember(t *testing.T) {
+	schoolService := NewSchoolService(existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	school, err := schoolService.GetByID(sessionContext("stranger"), "1")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, school)
+}
+
+func TestSchoolService_Update_UpdatesOnlyProvidedFields(t *testing.T) {
+	schoolService := NewSchoolService(existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	school, err := schoolService.Update(sessionContext("admin"), "1", &UpdateSchoolRequest{City: "Springfield"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "North High", school.Name)
+	assert.Equal(t, "Springfield", school.City)
+}
+
+func TestSchoolService_Delete_ReturnsErrorWhileImpersonating(t *testing.T) {
+	schoolService := NewSchoolService(existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	ctx := contextWithSession(context.Background(), &Session{UserID: "teacher", ImpersonatorUserID: "admin", OrganizationID: "org"})
+	err := schoolService.Delete(ctx, "1")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestSchoolService_Restore_ReturnsErrorForNonAdmin(t *testing.T) {
+	schoolService := NewSchoolService(&MockSchoolStore{
+		GetDeletedByIDFunc: func(ctx context.Context, id string) (*School, error) {
+			return &School{ID: id, OrganizationID: "org", Name: "North High"}, nil
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	school, err := schoolService.Restore(sessionContext("teacher"), "1")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, school)
+}
+
+func TestSchoolService_Restore_ReturnsSchoolForAdmin(t *testing.T) {
+	schoolService := NewSchoolService(&MockSchoolStore{
+		GetDeletedByIDFunc: func(ctx context.Context, id string) (*School, error) {
+			deletedAt := time.Now()
+			return &School{ID: id, OrganizationID: "org", Name: "North High", DeletedAt: &deletedAt}, nil
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	school, err := schoolService.Restore(sessionContext("admin"), "1")
+
+	assert.NoError(t, err)
+	assert.Nil(t, school.DeletedAt)
+}
@@ -0,0 +1,816 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	SectionTeacherPrimary   = "primary"
+	SectionTeacherCoTeacher = "co_teacher"
+)
+
+var sectionTeacherRoles = []string{SectionTeacherPrimary, SectionTeacherCoTeacher}
+
+const clockLayout = "15:04"
+
+// A weekly class meeting. Times are HH:MM on the school's local clock.
+type SectionMeeting struct {
+	Weekday   time.Weekday `json:"weekday"`
+	StartTime string       `json:"startTime"`
+	EndTime   string       `json:"endTime"`
+}
+
+// Reports whether the two meetings share a weekday and some of their time
+func (m SectionMeeting) Overlaps(other SectionMeeting) bool {
+	// HH:MM compares correctly as a string
+	return m.Weekday == other.Weekday && m.StartTime < other.EndTime && other.StartTime < m.EndTime
+}
+
+// A bell schedule period the section meets in. Day names the rotation day; an empty day
+// meets every day the period runs.
+type SectionPeriod struct {
+	Day    string `json:"day"`
+	Period string `json:"period"`
+}
+
+// Reports whether the two periods can fall on the same day
+func (p SectionPeriod) Overlaps(other SectionPeriod) bool {
+	sameDay := p.Day == "" || other.Day == "" || strings.EqualFold(p.Day, other.Day)
+	return sameDay && strings.EqualFold(p.Period, other.Period)
+}
+
+type SectionTeacher struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+}
+
+// A course offered at a school in a term
+type Section struct {
+	ID        string           `json:"id"`
+	CourseID  string           `json:"courseId"`
+	SchoolID  string           `json:"schoolId"`
+	TermID    string           `json:"termId"`
+	Code      string           `json:"code"`
+	Teachers  []SectionTeacher `json:"teachers"`
+	Meetings  []SectionMeeting `json:"meetings"`
+	Periods   []SectionPeriod  `json:"periods"`
+	Capacity  int              `json:"capacity"`
+	Room      string           `json:"room"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// Narrows a section listing. Empty fields match everything.
+type SectionFilter struct {
+	SchoolID      string
+	TermID        string
+	CourseID      string
+	TeacherUserID string
+}
+
+type SectionPostgresStore struct {
+	db *PostgresDB
+}
+
+type SectionStore interface {
+	Create(ctx context.Context, section *Section) error
+	GetByID(ctx context.Context, id string) (*Section, error)
+	List(ctx context.Context, filter *SectionFilter) ([]*Section, error)
+	Update(ctx context.Context, section *Section) error
+	Delete(ctx context.Context, id string) error
+}
+
+const sectionColumns = `
+	s.id, s.course_id, s.school_id, s.term_id, s.code, s.meetings, s.periods, s.capacity, s.room, s.created_at, s.updated_at,
+	COALESCE((
+		SELECT json_agg(json_build_object('userId', t.user_id, 'role', t.role) ORDER BY t.role DESC, t.user_id)
+		FROM section_teachers t
+		WHERE t.section_id = s.id
+	), '[]')
+`
+
+func scanSection(row rowScanner) (*Section, error) {
+	var section Section
+
+	err := row.Scan(
+		&section.ID,
+		&section.CourseID,
+		&section.SchoolID,
+		&section.TermID,
+		&section.Code,
+		&section.Meetings,
+		&section.Periods,
+		&section.Capacity,
+		&section.Room,
+		&section.CreatedAt,
+		&section.UpdatedAt,
+		&section.Teachers,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &section, nil
+}
+
+func (s *SectionPostgresStore) Create(ctx context.Context, section *Section) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO sections (course_id, school_id, term_id, code, meetings, periods, capacity, room, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	row := tx.QueryRow(ctx, query,
+		section.CourseID,
+		section.SchoolID,
+		section.TermID,
+		section.Code,
+		section.Meetings,
+		section.Periods,
+		section.Capacity,
+		section.Room,
+		section.CreatedAt,
+		section.UpdatedAt,
+	)
+
+	if err := row.Scan(&section.ID); err != nil {
+		return err
+	}
+
+	for _, teacher := range section.Teachers {
+		if _, err := tx.Exec(ctx, `INSERT INTO section_teachers (section_id, user_id, role) VALUES ($1, $2, $3)`, section.ID, teacher.UserID, teacher.Role); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *SectionPostgresStore) GetByID(ctx context.Context, id string) (*Section, error) {
+	query := `SELECT ` + sectionColumns + ` FROM sections s WHERE s.id = $1`
+
+	return noRowsAsNil(scanSection(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *SectionPostgresStore) List(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("s.school_id = $%d", filter.SchoolID)
+	}
+
+	if filter.TermID != "" {
+		addCondition("s.term_id = $%d", filter.TermID)
+	}
+
+	if filter.CourseID != "" {
+		addCondition("s.course_id = $%d", filter.CourseID)
+	}
+
+	if filter.TeacherUserID != "" {
+		addCondition("EXISTS (SELECT 1 FROM section_teachers t WHERE t.section_id = s.id AND t.user_id = $%d)", filter.TeacherUserID)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM sections s
+		%s
+		ORDER BY s.term_id, s.course_id, s.code
+	`, sectionColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var sections []*Section
+
+	for rows.Next() {
+		section, err := scanSection(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections, rows.Err()
+}
+
+// Updates the section and replaces its teacher assignments
+func (s *SectionPostgresStore) Update(ctx context.Context, section *Section) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE sections
+		SET code = $1, meetings = $2, periods = $3, capacity = $4, room = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	if _, err := tx.Exec(ctx, query, section.Code, section.Meetings, section.Periods, section.Capacity, section.Room, section.UpdatedAt, section.ID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM section_teachers WHERE section_id = $1`, section.ID); err != nil {
+		return err
+	}
+
+	for _, teacher := range section.Teachers {
+		if _, err := tx.Exec(ctx, `INSERT INTO section_teachers (section_id, user_id, role) VALUES ($1, $2, $3)`, section.ID, teacher.UserID, teacher.Role); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *SectionPostgresStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM sections WHERE id = $1`, id)
+	return err
+}
+
+func validateSection(section *Section) error {
+	if section.CourseID == "" {
+		return errors.New("course id is required")
+	}
+
+	if section.TermID == "" {
+		return errors.New("term id is required")
+	}
+
+	if section.Code == "" {
+		return errors.New("code is required")
+	}
+
+	if section.Capacity <= 0 {
+		return errors.New("capacity must be greater than zero")
+	}
+
+	seen := map[string]bool{}
+	primaries := 0
+
+	for _, teacher := range section.Teachers {
+		if teacher.UserID == "" {
+			return errors.New("teacher user id is required")
+		}
+
+		if !slices.Contains(sectionTeacherRoles, teacher.Role) {
+			return fmt.Errorf("teacher role must be one of %v", sectionTeacherRoles)
+		}
+
+		if seen[teacher.UserID] {
+			return fmt.Errorf("teacher %s is assigned more than once", teacher.UserID)
+		}
+
+		seen[teacher.UserID] = true
+
+		if teacher.Role == SectionTeacherPrimary {
+			primaries++
+		}
+	}
+
+	if primaries > 1 {
+		return errors.New("a section can have only one primary teacher")
+	}
+
+	for i, period := range section.Periods {
+		if period.Period == "" {
+			return errors.New("every section period needs a period name")
+		}
+
+		for _, other := range section.Periods[:i] {
+			if period.Overlaps(other) {
+				return fmt.Errorf("section meets in period %s more than once", period.Period)
+			}
+		}
+	}
+
+	return validateMeetings("section", section.Meetings)
+}
+
+// Checks that meetings are well formed and do not overlap each other. owner names what they
+// belong to in errors.
+func validateMeetings(owner string, meetings []SectionMeeting) error {
+	for i, meeting := range meetings {
+		if meeting.Weekday < time.Sunday || meeting.Weekday > time.Saturday {
+			return errors.New("meeting weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+
+		_, startErr := time.Parse(clockLayout, meeting.StartTime)
+		_, endErr := time.Parse(clockLayout, meeting.EndTime)
+
+		if startErr != nil || endErr != nil {
+			return errors.New("meeting times must be HH:MM")
+		}
+
+		if meeting.EndTime <= meeting.StartTime {
+			return errors.New("meeting must end after it starts")
+		}
+
+		for _, other := range meetings[:i] {
+			if meeting.Overlaps(other) {
+				return fmt.Errorf("%s meetings must not overlap", owner)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Reports whether two sections meet at the same time, either in the same weekly meeting
+// time or the same bell schedule period. Sections in terms that do not overlap never conflict.
+func sectionsConflict(a *Section, aTerm *Term, b *Section, bTerm *Term) bool {
+	if !datesOverlap(aTerm.StartDate, aTerm.EndDate, bTerm.StartDate, bTerm.EndDate) {
+		return false
+	}
+
+	for _, aMeeting := range a.Meetings {
+		for _, bMeeting := range b.Meetings {
+			if aMeeting.Overlaps(bMeeting) {
+				return true
+			}
+		}
+	}
+
+	for _, aPeriod := range a.Periods {
+		for _, bPeriod := range b.Periods {
+			if aPeriod.Overlaps(bPeriod) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns the section and its school
+func getSectionAndSchool(ctx context.Context, sectionStore SectionStore, schoolStore SchoolStore, id string) (*Section, *School, error) {
+	section, err := sectionStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get section", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if section == nil {
+		return nil, nil, notFound("section")
+	}
+
+	school, err := schoolStore.GetByID(ctx, section.SchoolID)
+
+	if err != nil {
+		slog.Error("failed to get school", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if school == nil {
+		return nil, nil, notFound("section")
+	}
+
+	return section, school, nil
+}
+
+// Succeeds if the session user administers the school's organization or teaches the section
+func authorizeSectionStaff(ctx context.Context, memberStore OrganizationMemberStore, section *Section, school *School) error {
+	member, err := requireMembership(ctx, memberStore, school.OrganizationID, RoleAdmin, RoleTeacher)
+
+	if err != nil {
+		return err
+	}
+
+	if member.Role == RoleAdmin {
+		return nil
+	}
+
+	for _, teacher := range section.Teachers {
+		if teacher.UserID == member.UserID {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
+
+type SectionService struct {
+	sectionStore    SectionStore
+	enrollmentStore EnrollmentStore
+	courseStore     CourseStore
+	calendarStore   CalendarStore
+	bellStore       BellStore
+	schoolStore     SchoolStore
+	memberStore     OrganizationMemberStore
+	auditService    *AuditService
+}
+
+func NewSectionService(sectionStore SectionStore, enrollmentStore EnrollmentStore, courseStore CourseStore, calendarStore CalendarStore, bellStore BellStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *SectionService {
+	return &SectionService{
+		sectionStore:    sectionStore,
+		enrollmentStore: enrollmentStore,
+		courseStore:     courseStore,
+		calendarStore:   calendarStore,
+		bellStore:       bellStore,
+		schoolStore:     schoolStore,
+		memberStore:     memberStore,
+		auditService:    auditService,
+	}
+}
+
+// Checks that every assigned teacher teaches or administers in the school's organization
+func (s *SectionService) checkTeachers(ctx context.Context, school *School, teachers []SectionTeacher) error {
+	for _, teacher := range teachers {
+		member, err := s.memberStore.Get(ctx, school.OrganizationID, teacher.UserID)
+
+		if err != nil {
+			slog.Error("failed to get organization member", "error", err)
+			return ErrInternal
+		}
+
+		if member == nil || (member.Role != RoleTeacher && member.Role != RoleAdmin) {
+			return fmt.Errorf("user %s is not a teacher in this organization", teacher.UserID)
+		}
+	}
+
+	return nil
+}
+
+// Checks that no other section of the same course and term uses the section's code
+func (s *SectionService) checkCode(ctx context.Context, section *Section) error {
+	siblings, err := s.sectionStore.List(ctx, &SectionFilter{CourseID: section.CourseID, TermID: section.TermID})
+
+	if err != nil {
+		slog.Error("failed to list sections", "error", err)
+		return ErrInternal
+	}
+
+	for _, sibling := range siblings {
+		if sibling.ID != section.ID && strings.EqualFold(sibling.Code, section.Code) {
+			return fmt.Errorf("section code %s is already in use for this course and term", section.Code)
+		}
+	}
+
+	return nil
+}
+
+func (s *SectionService) Create(ctx context.Context, section *Section) error {
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, section.SchoolID, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	if section.Teachers == nil {
+		section.Teachers = []SectionTeacher{}
+	}
+
+	if section.Meetings == nil {
+		section.Meetings = []SectionMeeting{}
+	}
+
+	if section.Periods == nil {
+		section.Periods = []SectionPeriod{}
+	}
+
+	if err := validateSection(section); err != nil {
+		return err
+	}
+
+	course, err := s.courseStore.GetByID(ctx, section.CourseID)
+
+	if err != nil {
+		slog.Error("failed to get course", "error", err)
+		return ErrInternal
+	}
+
+	if course == nil || course.OrganizationID != school.OrganizationID {
+		return notFound("course")
+	}
+
+	term, err := s.calendarStore.GetTerm(ctx, section.TermID)
+
+	if err != nil {
+		slog.Error("failed to get term", "error", err)
+		return ErrInternal
+	}
+
+	if term == nil || term.SchoolID != school.ID {
+		return notFound("term")
+	}
+
+	if err := s.checkTeachers(ctx, school, section.Teachers); err != nil {
+		return err
+	}
+
+	if err := checkSectionPeriods(ctx, s.bellStore, section); err != nil {
+		return err
+	}
+
+	if err := s.checkCode(ctx, section); err != nil {
+		return err
+	}
+
+	section.CreatedAt = time.Now()
+	section.UpdatedAt = time.Now()
+
+	if err := s.sectionStore.Create(ctx, section); err != nil {
+		slog.Error("failed to create section", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "section", section.ID, nil, section)
+
+	return nil
+}
+
+// Returns the section and its school if the session user belongs to the school's organization
+// with one of the given roles
+func (s *SectionService) authorize(ctx context.Context, id string, roles ...string) (*Section, *School, error) {
+	section, err := s.sectionStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get section", "error", err)
+		return nil, nil, ErrInternal
+	}
+
+	if section == nil {
+		return nil, nil, notFound("section")
+	}
+
+	school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, section.SchoolID, roles...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return section, school, nil
+}
+
+func (s *SectionService) GetByID(ctx context.Context, id string) (*Section, error) {
+	section, _, err := s.authorize(ctx, id)
+	return section, err
+}
+
+// Lists the sections of a school, narrowed by the term, course and teacher in filter
+func (s *SectionService) List(ctx context.Context, schoolID string, filter *SectionFilter) ([]*Section, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID); err != nil {
+		return nil, err
+	}
+
+	filter.SchoolID = schoolID
+
+	sections, err := s.sectionStore.List(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list sections", "error", err)
+		return nil, ErrInternal
+	}
+
+	if sections == nil {
+		sections = []*Section{}
+	}
+
+	return sections, nil
+}
+
+type UpdateSectionRequest struct {
+	Code     string `json:"code"`
+	Capacity *int   `json:"capacity"`
+	Room     string `json:"room"`
+	// Replaces the teacher assignments when present. An empty list removes them all.
+	Teachers []SectionTeacher `json:"teachers"`
+	// Replaces the meetings when present
+	Meetings []SectionMeeting `json:"meetings"`
+	// Replaces the bell schedule periods when present
+	Periods []SectionPeriod `json:"periods"`
+}
+
+func (s *SectionService) Update(ctx context.Context, id string, request *UpdateSectionRequest) (*Section, error) {
+	existingSection, school, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *existingSection
+
+	if request.Code != "" {
+		existingSection.Code = request.Code
+	}
+
+	if request.Capacity != nil {
+		existingSection.Capacity = *request.Capacity
+	}
+
+	if request.Room != "" {
+		existingSection.Room = request.Room
+	}
+
+	if request.Teachers != nil {
+		existingSection.Teachers = request.Teachers
+
+		if err := s.checkTeachers(ctx, school, request.Teachers); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.Meetings != nil {
+		existingSection.Meetings = request.Meetings
+	}
+
+	if request.Periods != nil {
+		existingSection.Periods = request.Periods
+	}
+
+	if err := validateSection(existingSection); err != nil {
+		return nil, err
+	}
+
+	if request.Periods != nil {
+		if err := checkSectionPeriods(ctx, s.bellStore, existingSection); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkCode(ctx, existingSection); err != nil {
+		return nil, err
+	}
+
+	if existingSection.Capacity < before.Capacity {
+		enrolled, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: id, Statuses: []string{EnrollmentStatusEnrolled}})
+
+		if err != nil {
+			slog.Error("failed to list enrollments", "error", err)
+			return nil, ErrInternal
+		}
+
+		if len(enrolled) > existingSection.Capacity {
+			return nil, fmt.Errorf("capacity cannot be less than the %d students enrolled", len(enrolled))
+		}
+	}
+
+	existingSection.UpdatedAt = time.Now()
+
+	if err := s.sectionStore.Update(ctx, existingSection); err != nil {
+		slog.Error("failed to update section", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionUpdate, "section", id, &before, existingSection)
+
+	if existingSection.Capacity > before.Capacity {
+		promoted, err := s.enrollmentStore.FillSeats(ctx, id)
+
+		if err != nil {
+			slog.Error("failed to promote waitlisted students", "error", err, "sectionId", id)
+		}
+
+		recordPromotions(ctx, s.auditService, school.OrganizationID, promoted)
+	}
+
+	return existingSection, nil
+}
+
+func (s *SectionService) Delete(ctx context.Context, id string) error {
+	section, school, err := s.authorize(ctx, id, RoleAdmin)
+
+	if err != nil {
+		return err
+	}
+
+	enrollments, err := s.enrollmentStore.List(ctx, &EnrollmentFilter{SectionID: id})
+
+	if err != nil {
+		slog.Error("failed to list enrollments", "error", err)
+		return ErrInternal
+	}
+
+	if len(enrollments) > 0 {
+		return errors.New("section still has enrollments")
+	}
+
+	if err := s.sectionStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete section", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionDelete, "section", id, section, nil)
+
+	return nil
+}
+
+type SectionHandler struct {
+	sectionService *SectionService
+}
+
+func (h *SectionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var section Section
+
+	if err := decodeJSON(r, &section); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	section.ID = ""
+	section.SchoolID = r.PathValue("id")
+
+	if err := h.sectionService.Create(r.Context(), &section); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, section)
+}
+
+func (h *SectionHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := &SectionFilter{
+		TermID:        query.Get("termId"),
+		CourseID:      query.Get("courseId"),
+		TeacherUserID: query.Get("teacherId"),
+	}
+
+	sections, err := h.sectionService.List(r.Context(), r.PathValue("id"), filter)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sections)
+}
+
+func (h *SectionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	section, err := h.sectionService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, section)
+}
+
+func (h *SectionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateSectionRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	section, err := h.sectionService.Update(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, section)
+}
+
+func (h *SectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.sectionService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockSectionStore struct {
+	CreateFunc  func(ctx context.Context, section *Section) error
+	GetByIDFunc func(ctx context.Context, id string) (*Section, error)
+	ListFunc    func(ctx context.Context, filter *SectionFilter) ([]*Section, error)
+	UpdateFunc  func(ctx context.Context, section *Section) error
+	DeleteFunc  func(ctx context.Context, id string) error
+}
+
+func (m *MockSectionStore) Create(ctx context.Context, section *Section) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, section)
+	}
+
+	return nil
+}
+
+func (m *MockSectionStore) GetByID(ctx context.Context, id string) (*Section, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSectionStore) List(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSectionStore) Update(ctx context.Context, section *Section) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, section)
+	}
+
+	return nil
+}
+
+func (m *MockSectionStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func fallTerm() *MockCalendarStore {
+	return &MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "school", AcademicYearID: "year", Name: "Fall", Type: TermTypeSemester, StartDate: NewDate(2025, time.August, 25), EndDate: NewDate(2026, time.January, 16)}, nil
+		},
+	}
+}
+
+func existingSection() *MockSectionStore {
+	return &MockSectionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Section, error) {
+			return &Section{ID: id, CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30, Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}}}, nil
+		},
+	}
+}
+
+func TestValidateSection_ReturnsErrorForTwoPrimaryTeachers(t *testing.T) {
+	err := validateSection(&Section{CourseID: "geo", TermID: "fall", Code: "01", Capacity: 30, Teachers: []SectionTeacher{
+		{UserID: "teacher", Role: SectionTeacherPrimary},
+		{UserID: "admin", Role: SectionTeacherPrimary},
+	}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "a section can have only one primary teacher", err.Error())
+}
+
+func TestValidateSection_ReturnsErrorForZeroCapacity(t *testing.T) {
+	err := validateSection(&Section{CourseID: "geo", TermID: "fall", Code: "01"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "capacity must be greater than zero", err.Error())
+}
+
+func TestSectionService_Create_CreatesSection(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{
+		CreateFunc: func(ctx context.Context, section *Section) error {
+			section.ID = "section"
+			return nil
+		},
+	}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30, Room: "B12", Teachers: []SectionTeacher{{UserID: "teacher", Role: SectionTeacherPrimary}}}
+	err := sectionService.Create(sessionContext("admin"), section)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "section", section.ID)
+}
+
+func TestSectionService_Create_ReturnsErrorForPeriodNotInBellSchedules(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), abRotation(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30, Periods: []SectionPeriod{{Day: "A", Period: "7"}}}
+	err := sectionService.Create(sessionContext("admin"), section)
+
+	assert.Error(t, err)
+	assert.Equal(t, "period 7 is not in any of the school's bell schedules", err.Error())
+}
+
+func TestSectionService_Update_ReturnsErrorForDayNotInRotation(t *testing.T) {
+	sectionService := NewSectionService(existingSection(), &MockEnrollmentStore{}, mathCatalog(), fallTerm(), abRotation(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := sectionService.Update(sessionContext("admin"), "section", &UpdateSectionRequest{Periods: []SectionPeriod{{Day: "C", Period: "1"}}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "C is not a day of the school's rotation", err.Error())
+}
+
+func TestSectionService_Create_ReturnsErrorForNonTeacher(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30, Teachers: []SectionTeacher{{UserID: "stranger", Role: SectionTeacherPrimary}}}
+	err := sectionService.Create(sessionContext("admin"), section)
+
+	assert.Error(t, err)
+	assert.Equal(t, "user stranger is not a teacher in this organization", err.Error())
+}
+
+func TestSectionService_Create_ReturnsNotFoundForTermOfOtherSchool(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), &MockCalendarStore{
+		GetTermFunc: func(ctx context.Context, id string) (*Term, error) {
+			return &Term{ID: id, SchoolID: "other-school"}, nil
+		},
+	}, &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30}
+	err := sectionService.Create(sessionContext("admin"), section)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSectionService_Create_ReturnsNotFoundForUnknownCourse(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "physics", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30}
+	err := sectionService.Create(sessionContext("admin"), section)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSectionService_Create_ReturnsErrorForDuplicateCode(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{
+		ListFunc: func(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+			return []*Section{{ID: "other", CourseID: filter.CourseID, TermID: filter.TermID, Code: "01"}}, nil
+		},
+	}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30}
+	err := sectionService.Create(sessionContext("admin"), section)
+
+	assert.Error(t, err)
+	assert.Equal(t, "section code 01 is already in use for this course and term", err.Error())
+}
+
+func TestSectionService_Create_ReturnsErrorForTeacherSession(t *testing.T) {
+	sectionService := NewSectionService(&MockSectionStore{}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section := &Section{CourseID: "geo", SchoolID: "school", TermID: "fall", Code: "01", Capacity: 30}
+	err := sectionService.Create(sessionContext("teacher"), section)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestSectionService_Update_ReplacesTeachers(t *testing.T) {
+	sectionService := NewSectionService(existingSection(), &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	section, err := sectionService.Update(sessionContext("admin"), "section", &UpdateSectionRequest{Teachers: []SectionTeacher{{UserID: "admin", Role: SectionTeacherPrimary}, {UserID: "teacher", Role: SectionTeacherCoTeacher}}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30, section.Capacity)
+	assert.Len(t, section.Teachers, 2)
+	assert.Equal(t, "admin", section.Teachers[0].UserID)
+}
+
+func TestSectionService_List_ScopesFilterToSchool(t *testing.T) {
+	var listed *SectionFilter
+
+	sectionService := NewSectionService(&MockSectionStore{
+		ListFunc: func(ctx context.Context, filter *SectionFilter) ([]*Section, error) {
+			listed = filter
+			return nil, nil
+		},
+	}, &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	sections, err := sectionService.List(sessionContext("teacher"), "school", &SectionFilter{SchoolID: "other-school", TermID: "fall"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*Section{}, sections)
+	assert.Equal(t, "school", listed.SchoolID)
+	assert.Equal(t, "fall", listed.TermID)
+}
+
+func TestSectionService_Delete_ReturnsErrorForTeacher(t *testing.T) {
+	sectionService := NewSectionService(existingSection(), &MockEnrollmentStore{}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := sectionService.Delete(sessionContext("teacher"), "section")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestSectionService_Update_ReturnsErrorWhenCapacityBelowEnrolled(t *testing.T) {
+	sectionService := NewSectionService(existingSection(), &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "1"}, {ID: "2"}, {ID: "3"}}, nil
+		},
+	}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	capacity := 2
+
+	section, err := sectionService.Update(sessionContext("admin"), "section", &UpdateSectionRequest{Capacity: &capacity})
+
+	assert.Error(t, err)
+	assert.Equal(t, "capacity cannot be less than the 3 students enrolled", err.Error())
+	assert.Nil(t, section)
+}
+
+func TestSectionService_Update_FillsSeatsWhenCapacityGrows(t *testing.T) {
+	filled := ""
+
+	sectionService := NewSectionService(existingSection(), &MockEnrollmentStore{
+		FillSeatsFunc: func(ctx context.Context, sectionID string) ([]*Enrollment, error) {
+			filled = sectionID
+			return nil, nil
+		},
+	}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	capacity := 35
+
+	_, err := sectionService.Update(sessionContext("admin"), "section", &UpdateSectionRequest{Capacity: &capacity})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "section", filled)
+}
+
+func TestValidateSection_ReturnsErrorForOverlappingMeetings(t *testing.T) {
+	err := validateSection(&Section{CourseID: "geo", TermID: "fall", Code: "01", Capacity: 30, Meetings: []SectionMeeting{
+		{Weekday: time.Monday, StartTime: "08:00", EndTime: "09:00"},
+		{Weekday: time.Monday, StartTime: "08:30", EndTime: "09:30"},
+	}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "section meetings must not overlap", err.Error())
+}
+
+func TestSectionService_Delete_ReturnsErrorWhenEnrollmentsExist(t *testing.T) {
+	sectionService := NewSectionService(existingSection(), &MockEnrollmentStore{
+		ListFunc: func(ctx context.Context, filter *EnrollmentFilter) ([]*Enrollment, error) {
+			return []*Enrollment{{ID: "1"}}, nil
+		},
+	}, mathCatalog(), fallTerm(), &MockBellStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := sectionService.Delete(sessionContext("admin"), "section")
+
+	assert.Error(t, err)
+	assert.Equal(t, "section still has enrollments", err.Error())
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sessionDuration = 24 * time.Hour
+
+type Session struct {
+	ID                 string    `json:"id"`
+	UserID             string    `json:"userId"`
+	ImpersonatorUserID string    `json:"impersonatorUserId,omitempty"`
+	OrganizationID     string    `json:"organizationId,omitempty"`
+	TokenHash          string    `json:"-"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// Reports whether the session was issued to an admin acting as another user
+func (s *Session) IsImpersonation() bool {
+	return s.ImpersonatorUserID != ""
+}
+
+type SessionPostgresStore struct {
+	db *PostgresDB
+}
+
+type SessionStore interface {
+	Create(ctx context.Context, session *Session) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error)
+	Delete(ctx context.Context, id string) error
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+func (s *SessionPostgresStore) Create(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO sessions (user_id, impersonator_user_id, organization_id, token_hash, expires_at, created_at)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		session.UserID,
+		session.ImpersonatorUserID,
+		session.OrganizationID,
+		session.TokenHash,
+		session.ExpiresAt,
+		session.CreatedAt,
+	)
+
+	if err := row.Scan(&session.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionPostgresStore) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	query := `
+		SELECT s.id, s.user_id, COALESCE(s.impersonator_user_id::text, ''), COALESCE(s.organization_id::text, ''), s.token_hash, s.expires_at, s.created_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL
+		WHERE s.token_hash = $1
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, tokenHash)
+
+	var session Session
+
+	if err := row.Scan(&session.ID, &session.UserID, &session.ImpersonatorUserID, &session.OrganizationID, &session.TokenHash, &session.ExpiresAt, &session.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *SessionPostgresStore) Delete(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM sessions
+		WHERE id = $1
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, id)
+
+	return err
+}
+
+// Deletes every session the user holds, including impersonations they started
+func (s *SessionPostgresStore) DeleteByUser(ctx context.Context, userID string) error {
+	query := `
+		DELETE FROM sessions
+		WHERE user_id = $1 OR impersonator_user_id = $1
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, userID)
+
+	return err
+}
+
+type sessionContextKey struct{}
+
+func contextWithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// Returns the session attached to the request context by AttachSession
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(*Session)
+	return session, ok
+}
+
+// Generates a random session token and returns it along with its stored hash
+func newSessionToken() (string, string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, hashSessionToken(token), nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type SessionService struct {
+	sessionStore SessionStore
+	userStore    UserStore
+}
+
+func NewSessionService(sessionStore SessionStore, userStore UserStore) *SessionService {
+	return &SessionService{sessionStore: sessionStore, userStore: userStore}
+}
+
+type CreateSessionRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type CreateSessionResponse struct {
+	Token   string   `json:"token"`
+	Session *Session `json:"session"`
+}
+
+func (s *SessionService) Create(ctx context.Context, request *CreateSessionRequest) (*CreateSessionResponse, error) {
+	if request.Email == "" || request.Password == "" {
+		return nil, errors.New("email and password are required")
+	}
+
+	user, err := s.userStore.GetByEmail(ctx, request.Email)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) != nil {
+		return nil, unauthorized("invalid email or password")
+	}
+
+	return s.issue(ctx, &Session{UserID: user.ID}, sessionDuration)
+}
+
+// Stores a new session and returns it along with its plaintext token
+func (s *SessionService) issue(ctx context.Context, session *Session, duration time.Duration) (*CreateSessionResponse, error) {
+	token, tokenHash, err := newSessionToken()
+
+	if err != nil {
+		slog.Error("failed to generate session token", "error", err)
+		return nil, ErrInternal
+	}
+
+	session.TokenHash = tokenHash
+	session.CreatedAt = time.Now()
+	session.ExpiresAt = session.CreatedAt.Add(duration)
+
+	if err := s.sessionStore.Create(ctx, session); err != nil {
+		slog.Error("failed to create session", "error", err)
+		return nil, ErrInternal
+	}
+
+	return &CreateSessionResponse{Token: token, Session: session}, nil
+}
+
+// Returns the unexpired session for the given token
+func (s *SessionService) Authenticate(ctx context.Context, token string) (*Session, error) {
+	session, err := s.sessionStore.GetByTokenHash(ctx, hashSessionToken(token))
+
+	if err != nil {
+		slog.Error("failed to get session", "error", err)
+		return nil, ErrInternal
+	}
+
+	if session == nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrUnauthorized
+	}
+
+	return session, nil
+}
+
+func (s *SessionService) Delete(ctx context.Context, id string) error {
+	if err := s.sessionStore.Delete(ctx, id); err != nil {
+		slog.Error("failed to delete session", "error", err)
+		return ErrInternal
+	}
+
+	return nil
+}
+
+type CurrentSessionResponse struct {
+	Session      *Session `json:"session"`
+	User         *User    `json:"user"`
+	Impersonator *User    `json:"impersonator,omitempty"`
+}
+
+// Returns the session along with the acting user and, when impersonating, the impersonator
+func (s *SessionService) Current(ctx context.Context, session *Session) (*CurrentSessionResponse, error) {
+	user, err := s.userStore.GetByID(ctx, session.UserID)
+
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if user == nil {
+		return nil, notFound("user")
+	}
+
+	user.Password = ""
+	response := &CurrentSessionResponse{Session: session, User: user}
+
+	if session.IsImpersonation() {
+		impersonator, err := s.userStore.GetByID(ctx, session.ImpersonatorUserID)
+
+		if err != nil {
+			slog.Error("failed to get impersonator", "error", err)
+			return nil, ErrInternal
+		}
+
+		if impersonator != nil {
+			impersonator.Password = ""
+			response.Impersonator = impersonator
+		}
+	}
+
+	return response, nil
+}
+
+// Authenticates the bearer token, if any, and attaches the session to the request context.
+// Responses to impersonated requests carry the X-Impersonated-By header.
+func AttachSession(sessionService *SessionService) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			if !ok || token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			session, err := sessionService.Authenticate(r.Context(), token)
+
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			if session.IsImpersonation() {
+				w.Header().Set("X-Impersonated-By", session.ImpersonatorUserID)
+			}
+
+			next.ServeHTTP(w, r.WithContext(contextWithSession(r.Context(), session)))
+		})
+	}
+}
+
+// Rejects requests that do not carry a valid session
+func RequireSession(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := SessionFromContext(r.Context()); !ok {
+			writeError(w, ErrUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type SessionHandler struct {
+	sessionService *SessionService
+}
+
+func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request CreateSessionRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response, err := h.sessionService.Create(r.Context(), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func (h *SessionHandler) Current(w http.ResponseWriter, r *http.Request) {
+	session, _ := SessionFromContext(r.Context())
+
+	response, err := h.sessionService.Current(r.Context(), session)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *SessionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	session, _ := SessionFromContext(r.Context())
+
+	if err := h.sessionService.Delete(r.Context(), session.ID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockSessionStore struct {
+	CreateFunc         func(ctx context.Context, session *Session) error
+	GetByTokenHashFunc func(ctx context.Context, tokenHash string) (*Session, error)
+	DeleteFunc         func(ctx context.Context, id string) error
+	DeleteByUserFunc   func(ctx context.Context, userID string) error
+}
+
+func (m *MockSessionStore) Create(ctx context.Context, session *Session) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, session)
+	}
+
+	return nil
+}
+
+func (m *MockSessionStore) GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	if m.GetByTokenHashFunc != nil {
+		return m.GetByTokenHashFunc(ctx, tokenHash)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSessionStore) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockSessionStore) DeleteByUser(ctx context.Context, userID string) error {
+	if m.DeleteByUserFunc != nil {
+		return m.DeleteByUserFunc(ctx, userID)
+	}
+
+	return nil
+}
+
+func hashPassword(t *testing.T, password string) string {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	return string(hashed)
+}
+
+func TestSessionService_Create_ReturnsErrorForMissingCredentials(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+
+	response, err := sessionService.Create(context.Background(), &CreateSessionRequest{Email: "john.doe@example.com"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "email and password are required", err.Error())
+	assert.Nil(t, response)
+}
+
+func TestSessionService_Create_ReturnsErrorForUnknownEmail(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+
+	response, err := sessionService.Create(context.Background(), &CreateSessionRequest{
+		Email:    "john.doe@example.com",
+		Password: "password",
+	})
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, "invalid email or password", err.Error())
+	assert.Nil(t, response)
+}
+
+func TestSessionService_Create_ReturnsErrorForWrongPassword(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			return &User{ID: "1", Email: email, Password: hashPassword(t, "password")}, nil
+		},
+	})
+
+	response, err := sessionService.Create(context.Background(), &CreateSessionRequest{
+		Email:    "john.doe@example.com",
+		Password: "wrong",
+	})
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Nil(t, response)
+}
+
+func TestSessionService_Create_ReturnsTokenForValidCredentials(t *testing.T) {
+	var stored *Session
+
+	sessionService := NewSessionService(&MockSessionStore{
+		CreateFunc: func(ctx context.Context, session *Session) error {
+			session.ID = "session-1"
+			stored = session
+			return nil
+		},
+	}, &MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			return &User{ID: "1", Email: email, Password: hashPassword(t, "password")}, nil
+		},
+	})
+
+	response, err := sessionService.Create(context.Background(), &CreateSessionRequest{
+		Email:    "john.doe@example.com",
+		Password: "password",
+	})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, "1", response.Session.UserID)
+	assert.Equal(t, hashSessionToken(response.Token), stored.TokenHash)
+	assert.False(t, response.Session.IsImpersonation())
+}
+
+func TestSessionService_Create_ReturnsErrorForFailingToCreateSession(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{
+		CreateFunc: func(ctx context.Context, session *Session) error {
+			return errors.New("random error")
+		},
+	}, &MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			return &User{ID: "1", Email: email, Password: hashPassword(t, "password")}, nil
+		},
+	})
+
+	response, err := sessionService.Create(context.Background(), &CreateSessionRequest{
+		Email:    "john.doe@example.com",
+		Password: "password",
+	})
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Nil(t, response)
+}
+
+func TestSessionService_Authenticate_ReturnsErrorForExpiredSession(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{
+		GetByTokenHashFunc: func(ctx context.Context, tokenHash string) (*Session, error) {
+			return &Session{ID: "1", UserID: "1", ExpiresAt: time.Now().Add(-time.Minute)}, nil
+		},
+	}, &MockUserStore{})
+
+	session, err := sessionService.Authenticate(context.Background(), "token")
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Nil(t, session)
+}
+
+func TestSessionService_Authenticate_ReturnsErrorForUnknownToken(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{})
+
+	session, err := sessionService.Authenticate(context.Background(), "token")
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Nil(t, session)
+}
+
+func TestSessionService_Authenticate_ReturnsSessionForValidToken(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{
+		GetByTokenHashFunc: func(ctx context.Context, tokenHash string) (*Session, error) {
+			assert.Equal(t, hashSessionToken("token"), tokenHash)
+			return &Session{ID: "1", UserID: "1", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}, &MockUserStore{})
+
+	session, err := sessionService.Authenticate(context.Background(), "token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", session.UserID)
+}
+
+func TestSessionService_Current_IncludesImpersonator(t *testing.T) {
+	sessionService := NewSessionService(&MockSessionStore{}, &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: id, Password: "hash"}, nil
+		},
+	})
+
+	response, err := sessionService.Current(context.Background(), &Session{UserID: "2", ImpersonatorUserID: "1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2", response.User.ID)
+	assert.Equal(t, "1", response.Impersonator.ID)
+	assert.Empty(t, response.User.Password)
+	assert.Empty(t, response.Impersonator.Password)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Returns the key used to sign download links, read from DIVINITY_SIGNING_KEY.
+// Without it a random key is used, so links stop working when the server restarts.
+func signingKey() []byte {
+	if key := os.Getenv("DIVINITY_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+
+	slog.Warn("DIVINITY_SIGNING_KEY is not set, signed links will not survive a restart")
+
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	return key
+}
+
+// Returns the key used to sign finalized report cards and transcripts, read from
+// DIVINITY_DOCUMENT_SIGNING_KEY. It is never made up, since documents must verify for as long as
+// they are kept: without it, nil is returned and documents cannot be finalized.
+func documentSigningKey() []byte {
+	if key := os.Getenv("DIVINITY_DOCUMENT_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+
+	slog.Warn("DIVINITY_DOCUMENT_SIGNING_KEY is not set, report cards and transcripts cannot be finalized")
+
+	return nil
+}
+
+func urlSignature(key []byte, path string, expires string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path + "\n" + expires))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns path with expires and signature query parameters that grant access until expiresAt
+func signURL(key []byte, path string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", urlSignature(key, path, expires))
+
+	return path + "?" + query.Encode()
+}
+
+// Checks that the request URL was produced by signURL and has not expired
+func verifySignedURL(key []byte, r *http.Request, now time.Time) error {
+	expires := r.URL.Query().Get("expires")
+	signature := r.URL.Query().Get("signature")
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+
+	if err != nil || signature == "" {
+		return unauthorized("missing or malformed link signature")
+	}
+
+	expected := urlSignature(key, r.URL.Path, expires)
+
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return unauthorized("invalid link signature")
+	}
+
+	if now.Unix() > expiresAt {
+		return unauthorized("link has expired")
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignedURL_AcceptsUnexpiredLink(t *testing.T) {
+	now := time.Now()
+	r := httptest.NewRequest("GET", signURL([]byte("key"), "/exports/1/download", now.Add(time.Hour)), nil)
+
+	assert.NoError(t, verifySignedURL([]byte("key"), r, now))
+}
+
+func TestVerifySignedURL_RejectsExpiredLink(t *testing.T) {
+	now := time.Now()
+	r := httptest.NewRequest("GET", signURL([]byte("key"), "/exports/1/download", now.Add(-time.Minute)), nil)
+
+	err := verifySignedURL([]byte("key"), r, now)
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, "link has expired", err.Error())
+}
+
+func TestVerifySignedURL_RejectsLinkForAnotherPath(t *testing.T) {
+	now := time.Now()
+	signed := signURL([]byte("key"), "/exports/1/download", now.Add(time.Hour))
+	r := httptest.NewRequest("GET", strings.Replace(signed, "/exports/1/", "/exports/2/", 1), nil)
+
+	err := verifySignedURL([]byte("key"), r, now)
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, "invalid link signature", err.Error())
+}
+
+func TestVerifySignedURL_RejectsLinkSignedWithAnotherKey(t *testing.T) {
+	now := time.Now()
+	r := httptest.NewRequest("GET", signURL([]byte("other"), "/exports/1/download", now.Add(time.Hour)), nil)
+
+	assert.ErrorIs(t, verifySignedURL([]byte("key"), r, now), ErrUnauthorized)
+}
+
+func TestVerifySignedURL_RejectsUnsignedLink(t *testing.T) {
+	r := httptest.NewRequest("GET", "/exports/1/download", nil)
+
+	err := verifySignedURL([]byte("key"), r, time.Now())
+
+	assert.Equal(t, "missing or malformed link signature", err.Error())
+}
@@ -0,0 +1,1265 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Certifications expiring within this many days are flagged by default
+	defaultCertificationAlertDays = 60
+	maxCertificationAlertDays     = 366
+)
+
+// What an organization keeps on record about one of its teachers or administrators
+type StaffProfile struct {
+	OrganizationID string `json:"organizationId"`
+	UserID         string `json:"userId"`
+	// The organization's own number for the staff member, unique within it
+	EmployeeNumber string    `json:"employeeNumber"`
+	JobTitle       string    `json:"jobTitle"`
+	HireDate       Date      `json:"hireDate"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// A license or certificate a staff member holds, such as a teaching license. An empty expiry
+// date means it never lapses.
+type StaffCertification struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organizationId"`
+	UserID         string    `json:"userId"`
+	Name           string    `json:"name"`
+	Number         string    `json:"number"`
+	Issuer         string    `json:"issuer"`
+	IssuedOn       Date      `json:"issuedOn"`
+	ExpiresOn      Date      `json:"expiresOn"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// A staff member's work at a school. FTE is the share of a full-time position, and an empty
+// end date means the assignment is ongoing.
+type StaffAssignment struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organizationId"`
+	UserID         string    `json:"userId"`
+	SchoolID       string    `json:"schoolId"`
+	JobTitle       string    `json:"jobTitle"`
+	FTE            float64   `json:"fte"`
+	StartDate      Date      `json:"startDate"`
+	EndDate        Date      `json:"endDate"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+func (a *StaffAssignment) activeOn(date Date) bool {
+	return !date.Before(a.StartDate.Time) && (a.EndDate.IsZero() || !date.After(a.EndDate.Time))
+}
+
+// Narrows a profile listing. Empty fields match everything.
+type StaffProfileFilter struct {
+	OrganizationID string
+	UserID         string
+}
+
+// Narrows a certification listing. Empty fields match everything.
+type StaffCertificationFilter struct {
+	OrganizationID string
+	UserID         string
+	// Matches certifications expiring on or before this date
+	ExpiresBy Date
+}
+
+// Narrows an assignment listing. Empty fields match everything.
+type StaffAssignmentFilter struct {
+	OrganizationID string
+	UserID         string
+	SchoolID       string
+}
+
+type StaffProfilePostgresStore struct {
+	db *PostgresDB
+}
+
+type StaffProfileStore interface {
+	GetProfile(ctx context.Context, organizationID string, userID string) (*StaffProfile, error)
+	GetProfileByEmployeeNumber(ctx context.Context, organizationID string, employeeNumber string) (*StaffProfile, error)
+	ListProfiles(ctx context.Context, filter *StaffProfileFilter) ([]*StaffProfile, error)
+	// Creates or replaces the staff member's profile
+	SaveProfile(ctx context.Context, profile *StaffProfile) error
+	// Deletes the user's profiles and certifications in every organization
+	DeleteByUser(ctx context.Context, userID string) error
+	CreateCertification(ctx context.Context, certification *StaffCertification) error
+	GetCertification(ctx context.Context, id string) (*StaffCertification, error)
+	ListCertifications(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error)
+	UpdateCertification(ctx context.Context, certification *StaffCertification) error
+	DeleteCertification(ctx context.Context, id string) error
+	CreateAssignment(ctx context.Context, assignment *StaffAssignment) error
+	GetAssignment(ctx context.Context, id string) (*StaffAssignment, error)
+	ListAssignments(ctx context.Context, filter *StaffAssignmentFilter) ([]*StaffAssignment, error)
+	UpdateAssignment(ctx context.Context, assignment *StaffAssignment) error
+	DeleteAssignment(ctx context.Context, id string) error
+}
+
+const staffProfileColumns = `organization_id, user_id, employee_number, job_title, hire_date, updated_at`
+
+func scanStaffProfile(row rowScanner) (*StaffProfile, error) {
+	var profile StaffProfile
+
+	err := row.Scan(&profile.OrganizationID, &profile.UserID, &profile.EmployeeNumber, &profile.JobTitle, &profile.HireDate, &profile.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (s *StaffProfilePostgresStore) GetProfile(ctx context.Context, organizationID string, userID string) (*StaffProfile, error) {
+	query := `SELECT ` + staffProfileColumns + ` FROM staff_profiles WHERE organization_id = $1 AND user_id = $2`
+
+	return noRowsAsNil(scanStaffProfile(s.db.pool.QueryRow(ctx, query, organizationID, userID)))
+}
+
+func (s *StaffProfilePostgresStore) GetProfileByEmployeeNumber(ctx context.Context, organizationID string, employeeNumber string) (*StaffProfile, error) {
+	query := `SELECT ` + staffProfileColumns + ` FROM staff_profiles WHERE organization_id = $1 AND employee_number = $2`
+
+	return noRowsAsNil(scanStaffProfile(s.db.pool.QueryRow(ctx, query, organizationID, employeeNumber)))
+}
+
+func (s *StaffProfilePostgresStore) ListProfiles(ctx context.Context, filter *StaffProfileFilter) ([]*StaffProfile, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.UserID != "" {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM staff_profiles
+		%s
+		ORDER BY employee_number, user_id
+	`, staffProfileColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var profiles []*StaffProfile
+
+	for rows.Next() {
+		profile, err := scanStaffProfile(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+func (s *StaffProfilePostgresStore) SaveProfile(ctx context.Context, profile *StaffProfile) error {
+	query := `
+		INSERT INTO staff_profiles (organization_id, user_id, employee_number, job_title, hire_date, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET
+			employee_number = excluded.employee_number,
+			job_title = excluded.job_title,
+			hire_date = excluded.hire_date,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, profile.OrganizationID, profile.UserID, profile.EmployeeNumber, profile.JobTitle, profile.HireDate, profile.UpdatedAt)
+
+	return err
+}
+
+func (s *StaffProfilePostgresStore) DeleteByUser(ctx context.Context, userID string) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM staff_certifications WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM staff_profiles WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const staffCertificationColumns = `id, organization_id, user_id, name, number, issuer, issued_on, expires_on, created_at, updated_at`
+
+func scanStaffCertification(row rowScanner) (*StaffCertification, error) {
+	var certification StaffCertification
+
+	err := row.Scan(
+		&certification.ID,
+		&certification.OrganizationID,
+		&certification.UserID,
+		&certification.Name,
+		&certification.Number,
+		&certification.Issuer,
+		&certification.IssuedOn,
+		&certification.ExpiresOn,
+		&certification.CreatedAt,
+		&certification.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &certification, nil
+}
+
+func (s *StaffProfilePostgresStore) CreateCertification(ctx context.Context, certification *StaffCertification) error {
+	query := `
+		INSERT INTO staff_certifications (organization_id, user_id, name, number, issuer, issued_on, expires_on, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		certification.OrganizationID,
+		certification.UserID,
+		certification.Name,
+		certification.Number,
+		certification.Issuer,
+		certification.IssuedOn,
+		certification.ExpiresOn,
+		certification.CreatedAt,
+		certification.UpdatedAt,
+	)
+
+	return row.Scan(&certification.ID)
+}
+
+func (s *StaffProfilePostgresStore) GetCertification(ctx context.Context, id string) (*StaffCertification, error) {
+	query := `SELECT ` + staffCertificationColumns + ` FROM staff_certifications WHERE id = $1`
+
+	return noRowsAsNil(scanStaffCertification(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *StaffProfilePostgresStore) ListCertifications(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.UserID != "" {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+
+	if !filter.ExpiresBy.IsZero() {
+		addCondition("expires_on <= $%d", filter.ExpiresBy)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM staff_certifications
+		%s
+		ORDER BY expires_on NULLS LAST, name, id
+	`, staffCertificationColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var certifications []*StaffCertification
+
+	for rows.Next() {
+		certification, err := scanStaffCertification(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certifications = append(certifications, certification)
+	}
+
+	return certifications, rows.Err()
+}
+
+func (s *StaffProfilePostgresStore) UpdateCertification(ctx context.Context, certification *StaffCertification) error {
+	query := `
+		UPDATE staff_certifications
+		SET name = $1, number = $2, issuer = $3, issued_on = $4, expires_on = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		certification.Name,
+		certification.Number,
+		certification.Issuer,
+		certification.IssuedOn,
+		certification.ExpiresOn,
+		certification.UpdatedAt,
+		certification.ID,
+	)
+
+	return err
+}
+
+func (s *StaffProfilePostgresStore) DeleteCertification(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM staff_certifications WHERE id = $1`, id)
+	return err
+}
+
+const staffAssignmentColumns = `id, organization_id, user_id, school_id, job_title, fte, start_date, end_date, created_at, updated_at`
+
+func scanStaffAssignment(row rowScanner) (*StaffAssignment, error) {
+	var assignment StaffAssignment
+
+	err := row.Scan(
+		&assignment.ID,
+		&assignment.OrganizationID,
+		&assignment.UserID,
+		&assignment.SchoolID,
+		&assignment.JobTitle,
+		&assignment.FTE,
+		&assignment.StartDate,
+		&assignment.EndDate,
+		&assignment.CreatedAt,
+		&assignment.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+func (s *StaffProfilePostgresStore) CreateAssignment(ctx context.Context, assignment *StaffAssignment) error {
+	query := `
+		INSERT INTO staff_assignments (organization_id, user_id, school_id, job_title, fte, start_date, end_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		assignment.OrganizationID,
+		assignment.UserID,
+		assignment.SchoolID,
+		assignment.JobTitle,
+		assignment.FTE,
+		assignment.StartDate,
+		assignment.EndDate,
+		assignment.CreatedAt,
+		assignment.UpdatedAt,
+	)
+
+	return row.Scan(&assignment.ID)
+}
+
+func (s *StaffProfilePostgresStore) GetAssignment(ctx context.Context, id string) (*StaffAssignment, error) {
+	query := `SELECT ` + staffAssignmentColumns + ` FROM staff_assignments WHERE id = $1`
+
+	return noRowsAsNil(scanStaffAssignment(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *StaffProfilePostgresStore) ListAssignments(ctx context.Context, filter *StaffAssignmentFilter) ([]*StaffAssignment, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.UserID != "" {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("school_id = $%d", filter.SchoolID)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM staff_assignments
+		%s
+		ORDER BY start_date, created_at, id
+	`, staffAssignmentColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var assignments []*StaffAssignment
+
+	for rows.Next() {
+		assignment, err := scanStaffAssignment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
+func (s *StaffProfilePostgresStore) UpdateAssignment(ctx context.Context, assignment *StaffAssignment) error {
+	query := `
+		UPDATE staff_assignments
+		SET job_title = $1, fte = $2, start_date = $3, end_date = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, assignment.JobTitle, assignment.FTE, assignment.StartDate, assignment.EndDate, assignment.UpdatedAt, assignment.ID)
+
+	return err
+}
+
+func (s *StaffProfilePostgresStore) DeleteAssignment(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM staff_assignments WHERE id = $1`, id)
+	return err
+}
+
+func validateStaffCertification(certification *StaffCertification) error {
+	certification.Name = strings.TrimSpace(certification.Name)
+	certification.Number = strings.TrimSpace(certification.Number)
+	certification.Issuer = strings.TrimSpace(certification.Issuer)
+
+	if certification.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if !certification.IssuedOn.IsZero() && !certification.ExpiresOn.IsZero() && certification.ExpiresOn.Before(certification.IssuedOn.Time) {
+		return errors.New("expiry date must not be before issue date")
+	}
+
+	return nil
+}
+
+// Checks the assignment and that the staff member's assignments, with this one, never add up
+// to more than a full-time position at once
+func validateStaffAssignment(assignment *StaffAssignment, existing []*StaffAssignment) error {
+	assignment.JobTitle = strings.TrimSpace(assignment.JobTitle)
+
+	if assignment.SchoolID == "" {
+		return errors.New("school id is required")
+	}
+
+	if assignment.StartDate.IsZero() {
+		return errors.New("start date is required")
+	}
+
+	if !assignment.EndDate.IsZero() && assignment.EndDate.Before(assignment.StartDate.Time) {
+		return errors.New("end date must not be before start date")
+	}
+
+	if assignment.FTE <= 0 || assignment.FTE > 1 {
+		return errors.New("fte must be more than 0 and at most 1")
+	}
+
+	assignments := []*StaffAssignment{assignment}
+
+	for _, other := range existing {
+		if other.ID != assignment.ID {
+			assignments = append(assignments, other)
+		}
+	}
+
+	// The total only rises where an assignment starts, so checking those dates is enough
+	for _, start := range assignments {
+		if !assignment.activeOn(start.StartDate) {
+			continue
+		}
+
+		total := 0.0
+
+		for _, other := range assignments {
+			if other.activeOn(start.StartDate) {
+				total += other.FTE
+			}
+		}
+
+		// Allow for rounding in sums such as 0.1 + 0.2
+		if total > 1.0001 {
+			return fmt.Errorf("assignments would add up to %s FTE on %s", strconv.FormatFloat(total, 'f', -1, 64), start.StartDate)
+		}
+	}
+
+	return nil
+}
+
+// Collects the staff member's profiles, certifications and assignments for their data export
+func staffProfileDataExport(staffProfileStore StaffProfileStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		profiles, err := staffProfileStore.ListProfiles(ctx, &StaffProfileFilter{UserID: userID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		certifications, err := staffProfileStore.ListCertifications(ctx, &StaffCertificationFilter{UserID: userID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		assignments, err := staffProfileStore.ListAssignments(ctx, &StaffAssignmentFilter{UserID: userID})
+
+		return append(append(toAnySlice(profiles), toAnySlice(certifications)...), toAnySlice(assignments)...), err
+	}
+}
+
+type StaffProfileService struct {
+	profileStore StaffProfileStore
+	userStore    UserStore
+	schoolStore  SchoolStore
+	memberStore  OrganizationMemberStore
+	auditService *AuditService
+}
+
+func NewStaffProfileService(profileStore StaffProfileStore, userStore UserStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *StaffProfileService {
+	return &StaffProfileService{profileStore: profileStore, userStore: userStore, schoolStore: schoolStore, memberStore: memberStore, auditService: auditService}
+}
+
+// Checks the session user may act on the staff member's records: the staff member themselves
+// when allowSelf is set, or a member of the organization with one of the given roles. The user
+// must be a teacher or administrator there.
+func (s *StaffProfileService) authorizeStaff(ctx context.Context, organizationID string, userID string, allowSelf bool, roles ...string) error {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if allowSelf && session.UserID == userID {
+		roles = nil
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, roles...); err != nil {
+		return err
+	}
+
+	member, err := s.memberStore.Get(ctx, organizationID, userID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if member == nil || (member.Role != RoleAdmin && member.Role != RoleTeacher) {
+		return notFound("staff member")
+	}
+
+	return nil
+}
+
+// Returns the staff member's profile, blank if none has been recorded. Staff may read their own.
+func (s *StaffProfileService) GetProfile(ctx context.Context, organizationID string, userID string) (*StaffProfile, error) {
+	if err := s.authorizeStaff(ctx, organizationID, userID, true, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.profileStore.GetProfile(ctx, organizationID, userID)
+
+	if err != nil {
+		slog.Error("failed to get staff profile", "error", err)
+		return nil, ErrInternal
+	}
+
+	if profile == nil {
+		profile = &StaffProfile{OrganizationID: organizationID, UserID: userID}
+	}
+
+	return profile, nil
+}
+
+func (s *StaffProfileService) ListProfiles(ctx context.Context, organizationID string) ([]*StaffProfile, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.profileStore.ListProfiles(ctx, &StaffProfileFilter{OrganizationID: organizationID})
+
+	if err != nil {
+		slog.Error("failed to list staff profiles", "error", err)
+		return nil, ErrInternal
+	}
+
+	if profiles == nil {
+		profiles = []*StaffProfile{}
+	}
+
+	return profiles, nil
+}
+
+// Replaces the staff member's profile
+func (s *StaffProfileService) SaveProfile(ctx context.Context, profile *StaffProfile) error {
+	if err := s.authorizeStaff(ctx, profile.OrganizationID, profile.UserID, false, RoleAdmin); err != nil {
+		return err
+	}
+
+	profile.EmployeeNumber = strings.TrimSpace(profile.EmployeeNumber)
+	profile.JobTitle = strings.TrimSpace(profile.JobTitle)
+
+	if profile.EmployeeNumber != "" {
+		holder, err := s.profileStore.GetProfileByEmployeeNumber(ctx, profile.OrganizationID, profile.EmployeeNumber)
+
+		if err != nil {
+			slog.Error("failed to get staff profile", "error", err)
+			return ErrInternal
+		}
+
+		if holder != nil && holder.UserID != profile.UserID {
+			return fmt.Errorf("employee number %s belongs to another staff member", profile.EmployeeNumber)
+		}
+	}
+
+	before, err := s.profileStore.GetProfile(ctx, profile.OrganizationID, profile.UserID)
+
+	if err != nil {
+		slog.Error("failed to get staff profile", "error", err)
+		return ErrInternal
+	}
+
+	profile.UpdatedAt = time.Now()
+
+	if err := s.profileStore.SaveProfile(ctx, profile); err != nil {
+		slog.Error("failed to save staff profile", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, profile.OrganizationID, AuditActionUpdate, "staff_profile", profile.UserID, before, profile)
+
+	return nil
+}
+
+func (s *StaffProfileService) listCertifications(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error) {
+	certifications, err := s.profileStore.ListCertifications(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list staff certifications", "error", err)
+		return nil, ErrInternal
+	}
+
+	if certifications == nil {
+		certifications = []*StaffCertification{}
+	}
+
+	return certifications, nil
+}
+
+// Lists the staff member's certifications, soonest to expire first. Staff may list their own.
+func (s *StaffProfileService) ListCertifications(ctx context.Context, organizationID string, userID string) ([]*StaffCertification, error) {
+	if err := s.authorizeStaff(ctx, organizationID, userID, true, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return s.listCertifications(ctx, &StaffCertificationFilter{OrganizationID: organizationID, UserID: userID})
+}
+
+func (s *StaffProfileService) CreateCertification(ctx context.Context, certification *StaffCertification) error {
+	if err := s.authorizeStaff(ctx, certification.OrganizationID, certification.UserID, false, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := validateStaffCertification(certification); err != nil {
+		return err
+	}
+
+	certification.CreatedAt = time.Now()
+	certification.UpdatedAt = time.Now()
+
+	if err := s.profileStore.CreateCertification(ctx, certification); err != nil {
+		slog.Error("failed to create staff certification", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, certification.OrganizationID, AuditActionCreate, "staff_certification", certification.ID, nil, certification)
+
+	return nil
+}
+
+func (s *StaffProfileService) authorizeCertification(ctx context.Context, id string) (*StaffCertification, error) {
+	certification, err := s.profileStore.GetCertification(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get staff certification", "error", err)
+		return nil, ErrInternal
+	}
+
+	if certification == nil {
+		return nil, notFound("certification")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, certification.OrganizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return certification, nil
+}
+
+// Changes the certification. An empty string for a date clears it.
+type UpdateStaffCertificationRequest struct {
+	Name      string  `json:"name"`
+	Number    *string `json:"number"`
+	Issuer    *string `json:"issuer"`
+	IssuedOn  *Date   `json:"issuedOn"`
+	ExpiresOn *Date   `json:"expiresOn"`
+}
+
+func (s *StaffProfileService) UpdateCertification(ctx context.Context, id string, request *UpdateStaffCertificationRequest) (*StaffCertification, error) {
+	certification, err := s.authorizeCertification(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *certification
+
+	if request.Name != "" {
+		certification.Name = request.Name
+	}
+
+	if request.Number != nil {
+		certification.Number = *request.Number
+	}
+
+	if request.Issuer != nil {
+		certification.Issuer = *request.Issuer
+	}
+
+	if request.IssuedOn != nil {
+		certification.IssuedOn = *request.IssuedOn
+	}
+
+	if request.ExpiresOn != nil {
+		certification.ExpiresOn = *request.ExpiresOn
+	}
+
+	if err := validateStaffCertification(certification); err != nil {
+		return nil, err
+	}
+
+	certification.UpdatedAt = time.Now()
+
+	if err := s.profileStore.UpdateCertification(ctx, certification); err != nil {
+		slog.Error("failed to update staff certification", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, certification.OrganizationID, AuditActionUpdate, "staff_certification", id, &before, certification)
+
+	return certification, nil
+}
+
+func (s *StaffProfileService) DeleteCertification(ctx context.Context, id string) error {
+	certification, err := s.authorizeCertification(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.profileStore.DeleteCertification(ctx, id); err != nil {
+		slog.Error("failed to delete staff certification", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, certification.OrganizationID, AuditActionDelete, "staff_certification", id, certification, nil)
+
+	return nil
+}
+
+// A certification that has lapsed or is about to
+type CertificationAlert struct {
+	Certification *StaffCertification `json:"certification"`
+	StaffName     string              `json:"staffName"`
+	// Negative once the certification has lapsed
+	DaysLeft int  `json:"daysLeft"`
+	Expired  bool `json:"expired"`
+}
+
+// Returns the organization's certifications that have expired or expire within the given
+// number of days of today, soonest first
+func (s *StaffProfileService) CertificationAlerts(ctx context.Context, organizationID string, withinDays int, today Date) ([]*CertificationAlert, error) {
+	if withinDays < 0 || withinDays > maxCertificationAlertDays {
+		return nil, fmt.Errorf("days must be between 0 and %d", maxCertificationAlertDays)
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	certifications, err := s.listCertifications(ctx, &StaffCertificationFilter{OrganizationID: organizationID, ExpiresBy: today.AddDays(withinDays)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Nil for staff who have since been deleted, whose certifications are left out
+	users := map[string]*User{}
+	alerts := []*CertificationAlert{}
+
+	for _, certification := range certifications {
+		if certification.ExpiresOn.IsZero() {
+			continue
+		}
+
+		user, ok := users[certification.UserID]
+
+		if !ok {
+			user, err = s.userStore.GetByID(ctx, certification.UserID)
+
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				slog.Error("failed to get user", "error", err)
+				return nil, ErrInternal
+			}
+
+			users[certification.UserID] = user
+		}
+
+		if user == nil {
+			continue
+		}
+
+		daysLeft := int(certification.ExpiresOn.Sub(today.Time).Hours() / 24)
+
+		alerts = append(alerts, &CertificationAlert{
+			Certification: certification,
+			StaffName:     user.FirstName + " " + user.LastName,
+			DaysLeft:      daysLeft,
+			Expired:       daysLeft < 0,
+		})
+	}
+
+	slices.SortStableFunc(alerts, func(a, b *CertificationAlert) int {
+		return cmp.Or(cmp.Compare(a.DaysLeft, b.DaysLeft), cmp.Compare(a.StaffName, b.StaffName))
+	})
+
+	return alerts, nil
+}
+
+func (s *StaffProfileService) listAssignments(ctx context.Context, filter *StaffAssignmentFilter) ([]*StaffAssignment, error) {
+	assignments, err := s.profileStore.ListAssignments(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list staff assignments", "error", err)
+		return nil, ErrInternal
+	}
+
+	if assignments == nil {
+		assignments = []*StaffAssignment{}
+	}
+
+	return assignments, nil
+}
+
+// Lists the schools the staff member works at. Any member of the organization may list them.
+func (s *StaffProfileService) ListAssignments(ctx context.Context, organizationID string, userID string) ([]*StaffAssignment, error) {
+	if err := s.authorizeStaff(ctx, organizationID, userID, true); err != nil {
+		return nil, err
+	}
+
+	return s.listAssignments(ctx, &StaffAssignmentFilter{OrganizationID: organizationID, UserID: userID})
+}
+
+// Lists the staff assigned to the school, past and present
+func (s *StaffProfileService) ListSchoolAssignments(ctx context.Context, schoolID string) ([]*StaffAssignment, error) {
+	if _, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, schoolID, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	return s.listAssignments(ctx, &StaffAssignmentFilter{SchoolID: schoolID})
+}
+
+// Assigns the staff member to a school of the organization
+func (s *StaffProfileService) CreateAssignment(ctx context.Context, assignment *StaffAssignment) error {
+	if err := s.authorizeStaff(ctx, assignment.OrganizationID, assignment.UserID, false, RoleAdmin); err != nil {
+		return err
+	}
+
+	if assignment.SchoolID != "" {
+		school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, assignment.SchoolID, RoleAdmin)
+
+		if err != nil {
+			return err
+		}
+
+		if school.OrganizationID != assignment.OrganizationID {
+			return notFound("school")
+		}
+	}
+
+	existing, err := s.listAssignments(ctx, &StaffAssignmentFilter{OrganizationID: assignment.OrganizationID, UserID: assignment.UserID})
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateStaffAssignment(assignment, existing); err != nil {
+		return err
+	}
+
+	assignment.CreatedAt = time.Now()
+	assignment.UpdatedAt = time.Now()
+
+	if err := s.profileStore.CreateAssignment(ctx, assignment); err != nil {
+		slog.Error("failed to create staff assignment", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, assignment.OrganizationID, AuditActionCreate, "staff_assignment", assignment.ID, nil, assignment)
+
+	return nil
+}
+
+func (s *StaffProfileService) authorizeAssignment(ctx context.Context, id string) (*StaffAssignment, error) {
+	assignment, err := s.profileStore.GetAssignment(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get staff assignment", "error", err)
+		return nil, ErrInternal
+	}
+
+	if assignment == nil {
+		return nil, notFound("assignment")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, assignment.OrganizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+// Changes the assignment. An empty string for the end date makes it ongoing again.
+type UpdateStaffAssignmentRequest struct {
+	JobTitle  *string  `json:"jobTitle"`
+	FTE       *float64 `json:"fte"`
+	StartDate *Date    `json:"startDate"`
+	EndDate   *Date    `json:"endDate"`
+}
+
+func (s *StaffProfileService) UpdateAssignment(ctx context.Context, id string, request *UpdateStaffAssignmentRequest) (*StaffAssignment, error) {
+	assignment, err := s.authorizeAssignment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *assignment
+
+	if request.JobTitle != nil {
+		assignment.JobTitle = *request.JobTitle
+	}
+
+	if request.FTE != nil {
+		assignment.FTE = *request.FTE
+	}
+
+	if request.StartDate != nil {
+		assignment.StartDate = *request.StartDate
+	}
+
+	if request.EndDate != nil {
+		assignment.EndDate = *request.EndDate
+	}
+
+	existing, err := s.listAssignments(ctx, &StaffAssignmentFilter{OrganizationID: assignment.OrganizationID, UserID: assignment.UserID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateStaffAssignment(assignment, existing); err != nil {
+		return nil, err
+	}
+
+	assignment.UpdatedAt = time.Now()
+
+	if err := s.profileStore.UpdateAssignment(ctx, assignment); err != nil {
+		slog.Error("failed to update staff assignment", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, assignment.OrganizationID, AuditActionUpdate, "staff_assignment", id, &before, assignment)
+
+	return assignment, nil
+}
+
+func (s *StaffProfileService) DeleteAssignment(ctx context.Context, id string) error {
+	assignment, err := s.authorizeAssignment(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.profileStore.DeleteAssignment(ctx, id); err != nil {
+		slog.Error("failed to delete staff assignment", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, assignment.OrganizationID, AuditActionDelete, "staff_assignment", id, assignment, nil)
+
+	return nil
+}
+
+type StaffProfileHandler struct {
+	profileService *StaffProfileService
+}
+
+func (h *StaffProfileHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.profileService.ListProfiles(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+func (h *StaffProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.profileService.GetProfile(r.Context(), r.PathValue("id"), r.PathValue("userId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (h *StaffProfileHandler) SaveProfile(w http.ResponseWriter, r *http.Request) {
+	var profile StaffProfile
+
+	if err := decodeJSON(r, &profile); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	profile.OrganizationID = r.PathValue("id")
+	profile.UserID = r.PathValue("userId")
+
+	if err := h.profileService.SaveProfile(r.Context(), &profile); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (h *StaffProfileHandler) ListCertifications(w http.ResponseWriter, r *http.Request) {
+	certifications, err := h.profileService.ListCertifications(r.Context(), r.PathValue("id"), r.PathValue("userId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, certifications)
+}
+
+func (h *StaffProfileHandler) CreateCertification(w http.ResponseWriter, r *http.Request) {
+	var certification StaffCertification
+
+	if err := decodeJSON(r, &certification); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	certification.ID = ""
+	certification.OrganizationID = r.PathValue("id")
+	certification.UserID = r.PathValue("userId")
+
+	if err := h.profileService.CreateCertification(r.Context(), &certification); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, certification)
+}
+
+func (h *StaffProfileHandler) UpdateCertification(w http.ResponseWriter, r *http.Request) {
+	var request UpdateStaffCertificationRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	certification, err := h.profileService.UpdateCertification(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, certification)
+}
+
+func (h *StaffProfileHandler) DeleteCertification(w http.ResponseWriter, r *http.Request) {
+	if err := h.profileService.DeleteCertification(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StaffProfileHandler) CertificationAlerts(w http.ResponseWriter, r *http.Request) {
+	days := defaultCertificationAlertDays
+
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+
+		if days, err = strconv.Atoi(value); err != nil {
+			writeError(w, errors.New("days must be a whole number"))
+			return
+		}
+	}
+
+	alerts, err := h.profileService.CertificationAlerts(r.Context(), r.PathValue("id"), days, DateOf(time.Now()))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+func (h *StaffProfileHandler) ListAssignments(w http.ResponseWriter, r *http.Request) {
+	assignments, err := h.profileService.ListAssignments(r.Context(), r.PathValue("id"), r.PathValue("userId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignments)
+}
+
+func (h *StaffProfileHandler) ListSchoolAssignments(w http.ResponseWriter, r *http.Request) {
+	assignments, err := h.profileService.ListSchoolAssignments(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignments)
+}
+
+func (h *StaffProfileHandler) CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	var assignment StaffAssignment
+
+	if err := decodeJSON(r, &assignment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	assignment.ID = ""
+	assignment.OrganizationID = r.PathValue("id")
+	assignment.UserID = r.PathValue("userId")
+
+	if err := h.profileService.CreateAssignment(r.Context(), &assignment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, assignment)
+}
+
+func (h *StaffProfileHandler) UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	var request UpdateStaffAssignmentRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	assignment, err := h.profileService.UpdateAssignment(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assignment)
+}
+
+func (h *StaffProfileHandler) DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	if err := h.profileService.DeleteAssignment(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
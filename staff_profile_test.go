@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockStaffProfileStore struct {
+	GetProfileFunc                 func(ctx context.Context, organizationID string, userID string) (*StaffProfile, error)
+	GetProfileByEmployeeNumberFunc func(ctx context.Context, organizationID string, employeeNumber string) (*StaffProfile, error)
+	ListProfilesFunc               func(ctx context.Context, filter *StaffProfileFilter) ([]*StaffProfile, error)
+	SaveProfileFunc                func(ctx context.Context, profile *StaffProfile) error
+	DeleteByUserFunc               func(ctx context.Context, userID string) error
+	CreateCertificationFunc        func(ctx context.Context, certification *StaffCertification) error
+	GetCertificationFunc           func(ctx context.Context, id string) (*StaffCertification, error)
+	ListCertificationsFunc         func(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error)
+	UpdateCertificationFunc        func(ctx context.Context, certification *StaffCertification) error
+	DeleteCertificationFunc        func(ctx context.Context, id string) error
+	CreateAssignmentFunc           func(ctx context.Context, assignment *StaffAssignment) error
+	GetAssignmentFunc              func(ctx context.Context, id string) (*StaffAssignment, error)
+	ListAssignmentsFunc            func(ctx context.Context, filter *StaffAssignmentFilter) ([]*StaffAssignment, error)
+	UpdateAssignmentFunc           func(ctx context.Context, assignment *StaffAssignment) error
+	DeleteAssignmentFunc           func(ctx context.Context, id string) error
+}
+
+func (m *MockStaffProfileStore) GetProfile(ctx context.Context, organizationID string, userID string) (*StaffProfile, error) {
+	if m.GetProfileFunc != nil {
+		return m.GetProfileFunc(ctx, organizationID, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) GetProfileByEmployeeNumber(ctx context.Context, organizationID string, employeeNumber string) (*StaffProfile, error) {
+	if m.GetProfileByEmployeeNumberFunc != nil {
+		return m.GetProfileByEmployeeNumberFunc(ctx, organizationID, employeeNumber)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) ListProfiles(ctx context.Context, filter *StaffProfileFilter) ([]*StaffProfile, error) {
+	if m.ListProfilesFunc != nil {
+		return m.ListProfilesFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) SaveProfile(ctx context.Context, profile *StaffProfile) error {
+	if m.SaveProfileFunc != nil {
+		return m.SaveProfileFunc(ctx, profile)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) DeleteByUser(ctx context.Context, userID string) error {
+	if m.DeleteByUserFunc != nil {
+		return m.DeleteByUserFunc(ctx, userID)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) CreateCertification(ctx context.Context, certification *StaffCertification) error {
+	if m.CreateCertificationFunc != nil {
+		return m.CreateCertificationFunc(ctx, certification)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) GetCertification(ctx context.Context, id string) (*StaffCertification, error) {
+	if m.GetCertificationFunc != nil {
+		return m.GetCertificationFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) ListCertifications(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error) {
+	if m.ListCertificationsFunc != nil {
+		return m.ListCertificationsFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) UpdateCertification(ctx context.Context, certification *StaffCertification) error {
+	if m.UpdateCertificationFunc != nil {
+		return m.UpdateCertificationFunc(ctx, certification)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) DeleteCertification(ctx context.Context, id string) error {
+	if m.DeleteCertificationFunc != nil {
+		return m.DeleteCertificationFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) CreateAssignment(ctx context.Context, assignment *StaffAssignment) error {
+	if m.CreateAssignmentFunc != nil {
+		return m.CreateAssignmentFunc(ctx, assignment)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) GetAssignment(ctx context.Context, id string) (*StaffAssignment, error) {
+	if m.GetAssignmentFunc != nil {
+		return m.GetAssignmentFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) ListAssignments(ctx context.Context, filter *StaffAssignmentFilter) ([]*StaffAssignment, error) {
+	if m.ListAssignmentsFunc != nil {
+		return m.ListAssignmentsFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStaffProfileStore) UpdateAssignment(ctx context.Context, assignment *StaffAssignment) error {
+	if m.UpdateAssignmentFunc != nil {
+		return m.UpdateAssignmentFunc(ctx, assignment)
+	}
+
+	return nil
+}
+
+func (m *MockStaffProfileStore) DeleteAssignment(ctx context.Context, id string) error {
+	if m.DeleteAssignmentFunc != nil {
+		return m.DeleteAssignmentFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// The teacher works 0.6 FTE at North High from August 2025 with no end date
+func partTimeTeacher() *MockStaffProfileStore {
+	assignment := &StaffAssignment{
+		ID: "north", OrganizationID: "org", UserID: "teacher", SchoolID: "school", JobTitle: "Math Teacher",
+		FTE: 0.6, StartDate: NewDate(2025, time.August, 1),
+	}
+
+	return &MockStaffProfileStore{
+		GetAssignmentFunc: func(ctx context.Context, id string) (*StaffAssignment, error) {
+			if id != assignment.ID {
+				return nil, nil
+			}
+
+			copied := *assignment
+			return &copied, nil
+		},
+		ListAssignmentsFunc: func(ctx context.Context, filter *StaffAssignmentFilter) ([]*StaffAssignment, error) {
+			return []*StaffAssignment{assignment}, nil
+		},
+	}
+}
+
+func staffUsers() *MockUserStore {
+	return &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: id, FirstName: "Ada", LastName: "Lovelace"}, nil
+		},
+	}
+}
+
+func TestValidateStaffCertification_ReturnsErrorForExpiryBeforeIssue(t *testing.T) {
+	err := validateStaffCertification(&StaffCertification{Name: "Teaching License", IssuedOn: schoolDay, ExpiresOn: schoolDay.AddDays(-1)})
+
+	assert.Error(t, err)
+	assert.Equal(t, "expiry date must not be before issue date", err.Error())
+}
+
+func TestValidateStaffAssignment_ReturnsErrorForFTEOutOfRange(t *testing.T) {
+	err := validateStaffAssignment(&StaffAssignment{SchoolID: "school", FTE: 1.5, StartDate: schoolDay}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "fte must be more than 0 and at most 1", err.Error())
+}
+
+func TestValidateStaffAssignment_ReturnsErrorWhenTotalExceedsFullTime(t *testing.T) {
+	existing, _ := partTimeTeacher().ListAssignments(context.Background(), &StaffAssignmentFilter{})
+
+	err := validateStaffAssignment(&StaffAssignment{SchoolID: "other-school", FTE: 0.5, StartDate: schoolDay}, existing)
+
+	assert.Error(t, err)
+	assert.Equal(t, "assignments would add up to 1.1 FTE on 2025-10-06", err.Error())
+}
+
+func TestValidateStaffAssignment_ReturnsErrorWhenLaterAssignmentStartsWithinRange(t *testing.T) {
+	existing, _ := partTimeTeacher().ListAssignments(context.Background(), &StaffAssignmentFilter{})
+
+	err := validateStaffAssignment(&StaffAssignment{SchoolID: "other-school", FTE: 0.5, StartDate: NewDate(2025, time.June, 1)}, existing)
+
+	assert.Error(t, err)
+	assert.Equal(t, "assignments would add up to 1.1 FTE on 2025-08-01", err.Error())
+}
+
+func TestValidateStaffAssignment_AllowsSplitAcrossSchools(t *testing.T) {
+	existing, _ := partTimeTeacher().ListAssignments(context.Background(), &StaffAssignmentFilter{})
+
+	err := validateStaffAssignment(&StaffAssignment{SchoolID: "other-school", FTE: 0.4, StartDate: schoolDay}, existing)
+
+	assert.NoError(t, err)
+}
+
+func TestValidateStaffAssignment_AllowsAssignmentEndingBeforeOthersStart(t *testing.T) {
+	existing, _ := partTimeTeacher().ListAssignments(context.Background(), &StaffAssignmentFilter{})
+
+	err := validateStaffAssignment(&StaffAssignment{SchoolID: "other-school", FTE: 1, StartDate: NewDate(2024, time.August, 1), EndDate: NewDate(2025, time.July, 31)}, existing)
+
+	assert.NoError(t, err)
+}
+
+func TestStaffProfileService_GetProfile_AllowsStaffMember(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	profile, err := profileService.GetProfile(sessionContext("teacher"), "org", "teacher")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "teacher", profile.UserID)
+}
+
+func TestStaffProfileService_GetProfile_ReturnsErrorForStudent(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	profile, err := profileService.GetProfile(sessionContext("admin"), "org", "student")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, profile)
+}
+
+func TestStaffProfileService_SaveProfile_ReturnsErrorForTeacher(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := profileService.SaveProfile(sessionContext("teacher"), &StaffProfile{OrganizationID: "org", UserID: "teacher", JobTitle: "Principal"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestStaffProfileService_SaveProfile_ReturnsErrorForTakenEmployeeNumber(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{
+		GetProfileByEmployeeNumberFunc: func(ctx context.Context, organizationID string, employeeNumber string) (*StaffProfile, error) {
+			return &StaffProfile{OrganizationID: organizationID, UserID: "admin", EmployeeNumber: employeeNumber}, nil
+		},
+	}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := profileService.SaveProfile(sessionContext("admin"), &StaffProfile{OrganizationID: "org", UserID: "teacher", EmployeeNumber: " E-7 "})
+
+	assert.Error(t, err)
+	assert.Equal(t, "employee number E-7 belongs to another staff member", err.Error())
+}
+
+func TestStaffProfileService_SaveProfile_SavesProfile(t *testing.T) {
+	var saved *StaffProfile
+	profileService := NewStaffProfileService(&MockStaffProfileStore{
+		SaveProfileFunc: func(ctx context.Context, profile *StaffProfile) error {
+			saved = profile
+			return nil
+		},
+	}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := profileService.SaveProfile(sessionContext("admin"), &StaffProfile{OrganizationID: "org", UserID: "teacher", EmployeeNumber: "E-7", JobTitle: "Math Teacher", HireDate: NewDate(2020, time.August, 3)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Math Teacher", saved.JobTitle)
+	assert.False(t, saved.UpdatedAt.IsZero())
+}
+
+func TestStaffProfileService_UpdateCertification_ClearsExpiry(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{
+		GetCertificationFunc: func(ctx context.Context, id string) (*StaffCertification, error) {
+			return &StaffCertification{ID: id, OrganizationID: "org", UserID: "teacher", Name: "Teaching License", ExpiresOn: schoolDay}, nil
+		},
+	}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	certification, err := profileService.UpdateCertification(sessionContext("admin"), "license", &UpdateStaffCertificationRequest{ExpiresOn: &Date{}})
+
+	assert.NoError(t, err)
+	assert.True(t, certification.ExpiresOn.IsZero())
+}
+
+func TestStaffProfileService_CertificationAlerts_FlagsExpiredAndExpiring(t *testing.T) {
+	var requested *StaffCertificationFilter
+	profileService := NewStaffProfileService(&MockStaffProfileStore{
+		ListCertificationsFunc: func(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error) {
+			requested = filter
+
+			return []*StaffCertification{
+				{ID: "cpr", OrganizationID: "org", UserID: "teacher", Name: "CPR", ExpiresOn: schoolDay.AddDays(-3)},
+				{ID: "license", OrganizationID: "org", UserID: "admin", Name: "Administrator License", ExpiresOn: schoolDay.AddDays(30)},
+			}, nil
+		},
+	}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	alerts, err := profileService.CertificationAlerts(sessionContext("admin"), "org", 60, schoolDay)
+
+	assert.NoError(t, err)
+	assert.Equal(t, schoolDay.AddDays(60), requested.ExpiresBy)
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, "cpr", alerts[0].Certification.ID)
+	assert.True(t, alerts[0].Expired)
+	assert.Equal(t, -3, alerts[0].DaysLeft)
+	assert.Equal(t, 30, alerts[1].DaysLeft)
+	assert.Equal(t, "Ada Lovelace", alerts[1].StaffName)
+}
+
+func TestStaffProfileService_CertificationAlerts_SkipsDeletedStaff(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{
+		ListCertificationsFunc: func(ctx context.Context, filter *StaffCertificationFilter) ([]*StaffCertification, error) {
+			return []*StaffCertification{
+				{ID: "cpr", OrganizationID: "org", UserID: "deleted", Name: "CPR", ExpiresOn: schoolDay.AddDays(-3)},
+				{ID: "license", OrganizationID: "org", UserID: "admin", Name: "Administrator License", ExpiresOn: schoolDay.AddDays(30)},
+			}, nil
+		},
+	}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	profileService.userStore = &MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			if id == "deleted" {
+				return nil, sql.ErrNoRows
+			}
+
+			return &User{ID: id, FirstName: "Ada", LastName: "Lovelace"}, nil
+		},
+	}
+
+	alerts, err := profileService.CertificationAlerts(sessionContext("admin"), "org", 60, schoolDay)
+
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "license", alerts[0].Certification.ID)
+}
+
+func TestStaffProfileService_CertificationAlerts_ReturnsErrorForTeacher(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	alerts, err := profileService.CertificationAlerts(sessionContext("teacher"), "org", 60, schoolDay)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, alerts)
+}
+
+func TestStaffProfileService_CreateAssignment_AddsSecondSchool(t *testing.T) {
+	profileService := NewStaffProfileService(partTimeTeacher(), staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	assignment := &StaffAssignment{OrganizationID: "org", UserID: "teacher", SchoolID: "south", JobTitle: "Math Teacher", FTE: 0.4, StartDate: schoolDay}
+
+	err := profileService.CreateAssignment(sessionContext("admin"), assignment)
+
+	assert.NoError(t, err)
+	assert.False(t, assignment.CreatedAt.IsZero())
+}
+
+func TestStaffProfileService_CreateAssignment_ReturnsErrorForSchoolOfOtherOrganization(t *testing.T) {
+	profileService := NewStaffProfileService(&MockStaffProfileStore{}, staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	profileService.schoolStore = &MockSchoolStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*School, error) {
+			return &School{ID: id, OrganizationID: "other-org", Name: "South High"}, nil
+		},
+	}
+
+	err := profileService.CreateAssignment(sessionContext("admin"), &StaffAssignment{OrganizationID: "org", UserID: "teacher", SchoolID: "south", FTE: 1, StartDate: schoolDay})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestStaffProfileService_UpdateAssignment_RaisesFTEWithoutCountingItself(t *testing.T) {
+	profileService := NewStaffProfileService(partTimeTeacher(), staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	full := 1.0
+
+	assignment, err := profileService.UpdateAssignment(sessionContext("admin"), "north", &UpdateStaffAssignmentRequest{FTE: &full})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, assignment.FTE)
+}
+
+func TestStaffProfileService_ListSchoolAssignments_AllowsTeacher(t *testing.T) {
+	profileService := NewStaffProfileService(partTimeTeacher(), staffUsers(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	assignments, err := profileService.ListSchoolAssignments(sessionContext("teacher"), "school")
+
+	assert.NoError(t, err)
+	assert.Len(t, assignments, 1)
+}
@@ -0,0 +1,853 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Grade levels, as coded by CEDS and OneRoster
+var gradeLevels = []string{
+	"IT", "PR", "PK", "TK", "KG",
+	"01", "02", "03", "04", "05", "06", "07", "08", "09", "10", "11", "12", "13",
+	"PS", "UG", "Other",
+}
+
+// Someone to call about a student who need not have an account, unlike a guardian
+type EmergencyContact struct {
+	Name         string `json:"name"`
+	Relationship string `json:"relationship"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+}
+
+// What an organization keeps on record about one of its students
+type StudentProfile struct {
+	OrganizationID string `json:"organizationId"`
+	UserID         string `json:"userId"`
+	// The organization's own number for the student, unique within it
+	StudentNumber     string             `json:"studentNumber"`
+	DateOfBirth       Date               `json:"dateOfBirth"`
+	GradeLevel        string             `json:"gradeLevel"`
+	HomeLanguage      string             `json:"homeLanguage"`
+	EmergencyContacts []EmergencyContact `json:"emergencyContacts"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+}
+
+// A period a student attended a school. An empty withdrawal date means they still do.
+type SchoolEnrollment struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organizationId"`
+	StudentUserID  string    `json:"studentUserId"`
+	SchoolID       string    `json:"schoolId"`
+	GradeLevel     string    `json:"gradeLevel"`
+	EntryDate      Date      `json:"entryDate"`
+	EntryCode      string    `json:"entryCode"`
+	WithdrawalDate Date      `json:"withdrawalDate"`
+	WithdrawalCode string    `json:"withdrawalCode"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Narrows a student profile listing. Empty fields match everything.
+type StudentProfileFilter struct {
+	OrganizationID string
+	UserID         string
+	GradeLevel     string
+}
+
+// Narrows a school enrollment listing. Empty fields match everything.
+type SchoolEnrollmentFilter struct {
+	OrganizationID string
+	StudentUserID  string
+	SchoolID       string
+}
+
+type StudentProfilePostgresStore struct {
+	db *PostgresDB
+}
+
+type StudentProfileStore interface {
+	GetProfile(ctx context.Context, organizationID string, userID string) (*StudentProfile, error)
+	GetProfileByStudentNumber(ctx context.Context, organizationID string, studentNumber string) (*StudentProfile, error)
+	ListProfiles(ctx context.Context, filter *StudentProfileFilter) ([]*StudentProfile, error)
+	// Creates or replaces the student's profile
+	SaveProfile(ctx context.Context, profile *StudentProfile) error
+	// Deletes the user's profiles in every organization
+	DeleteProfiles(ctx context.Context, userID string) error
+	CreateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error
+	GetSchoolEnrollment(ctx context.Context, id string) (*SchoolEnrollment, error)
+	ListSchoolEnrollments(ctx context.Context, filter *SchoolEnrollmentFilter) ([]*SchoolEnrollment, error)
+	UpdateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error
+	DeleteSchoolEnrollment(ctx context.Context, id string) error
+}
+
+const studentProfileColumns = `organization_id, user_id, student_number, date_of_birth, grade_level, home_language, emergency_contacts, updated_at`
+
+func scanStudentProfile(row rowScanner) (*StudentProfile, error) {
+	var profile StudentProfile
+
+	err := row.Scan(
+		&profile.OrganizationID,
+		&profile.UserID,
+		&profile.StudentNumber,
+		&profile.DateOfBirth,
+		&profile.GradeLevel,
+		&profile.HomeLanguage,
+		&profile.EmergencyContacts,
+		&profile.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (s *StudentProfilePostgresStore) GetProfile(ctx context.Context, organizationID string, userID string) (*StudentProfile, error) {
+	query := `SELECT ` + studentProfileColumns + ` FROM student_profiles WHERE organization_id = $1 AND user_id = $2`
+
+	return noRowsAsNil(scanStudentProfile(s.db.pool.QueryRow(ctx, query, organizationID, userID)))
+}
+
+func (s *StudentProfilePostgresStore) GetProfileByStudentNumber(ctx context.Context, organizationID string, studentNumber string) (*StudentProfile, error) {
+	query := `SELECT ` + studentProfileColumns + ` FROM student_profiles WHERE organization_id = $1 AND student_number = $2`
+
+	return noRowsAsNil(scanStudentProfile(s.db.pool.QueryRow(ctx, query, organizationID, studentNumber)))
+}
+
+func (s *StudentProfilePostgresStore) ListProfiles(ctx context.Context, filter *StudentProfileFilter) ([]*StudentProfile, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.UserID != "" {
+		addCondition("user_id = $%d", filter.UserID)
+	}
+
+	if filter.GradeLevel != "" {
+		addCondition("grade_level = $%d", filter.GradeLevel)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM student_profiles
+		%s
+		ORDER BY organization_id, student_number, user_id
+	`, studentProfileColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var profiles []*StudentProfile
+
+	for rows.Next() {
+		profile, err := scanStudentProfile(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+func (s *StudentProfilePostgresStore) SaveProfile(ctx context.Context, profile *StudentProfile) error {
+	query := `
+		INSERT INTO student_profiles (organization_id, user_id, student_number, date_of_birth, grade_level, home_language, emergency_contacts, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET
+			student_number = excluded.student_number,
+			date_of_birth = excluded.date_of_birth,
+			grade_level = excluded.grade_level,
+			home_language = excluded.home_language,
+			emergency_contacts = excluded.emergency_contacts,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		profile.OrganizationID,
+		profile.UserID,
+		profile.StudentNumber,
+		profile.DateOfBirth,
+		profile.GradeLevel,
+		profile.HomeLanguage,
+		profile.EmergencyContacts,
+		profile.UpdatedAt,
+	)
+
+	return err
+}
+
+func (s *StudentProfilePostgresStore) DeleteProfiles(ctx context.Context, userID string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM student_profiles WHERE user_id = $1`, userID)
+	return err
+}
+
+const schoolEnrollmentColumns = `
+	id, organization_id, student_user_id, school_id, grade_level, entry_date, entry_code, withdrawal_date, withdrawal_code,
+	created_at, updated_at
+`
+
+func scanSchoolEnrollment(row rowScanner) (*SchoolEnrollment, error) {
+	var enrollment SchoolEnrollment
+
+	err := row.Scan(
+		&enrollment.ID,
+		&enrollment.OrganizationID,
+		&enrollment.StudentUserID,
+		&enrollment.SchoolID,
+		&enrollment.GradeLevel,
+		&enrollment.EntryDate,
+		&enrollment.EntryCode,
+		&enrollment.WithdrawalDate,
+		&enrollment.WithdrawalCode,
+		&enrollment.CreatedAt,
+		&enrollment.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+func (s *StudentProfilePostgresStore) CreateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error {
+	query := `
+		INSERT INTO school_enrollments (
+			organization_id, student_user_id, school_id, grade_level, entry_date, entry_code, withdrawal_date, withdrawal_code,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		enrollment.OrganizationID,
+		enrollment.StudentUserID,
+		enrollment.SchoolID,
+		enrollment.GradeLevel,
+		enrollment.EntryDate,
+		enrollment.EntryCode,
+		enrollment.WithdrawalDate,
+		enrollment.WithdrawalCode,
+		enrollment.CreatedAt,
+		enrollment.UpdatedAt,
+	)
+
+	return row.Scan(&enrollment.ID)
+}
+
+func (s *StudentProfilePostgresStore) GetSchoolEnrollment(ctx context.Context, id string) (*SchoolEnrollment, error) {
+	query := `SELECT ` + schoolEnrollmentColumns + ` FROM school_enrollments WHERE id = $1`
+
+	return noRowsAsNil(scanSchoolEnrollment(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *StudentProfilePostgresStore) ListSchoolEnrollments(ctx context.Context, filter *SchoolEnrollmentFilter) ([]*SchoolEnrollment, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationID != "" {
+		addCondition("organization_id = $%d", filter.OrganizationID)
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("student_user_id = $%d", filter.StudentUserID)
+	}
+
+	if filter.SchoolID != "" {
+		addCondition("school_id = $%d", filter.SchoolID)
+	}
+
+	where := ""
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM school_enrollments
+		%s
+		ORDER BY entry_date, created_at, id
+	`, schoolEnrollmentColumns, where)
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var enrollments []*SchoolEnrollment
+
+	for rows.Next() {
+		enrollment, err := scanSchoolEnrollment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		enrollments = append(enrollments, enrollment)
+	}
+
+	return enrollments, rows.Err()
+}
+
+func (s *StudentProfilePostgresStore) UpdateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error {
+	query := `
+		UPDATE school_enrollments
+		SET grade_level = $1, entry_date = $2, entry_code = $3, withdrawal_date = $4, withdrawal_code = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		enrollment.GradeLevel,
+		enrollment.EntryDate,
+		enrollment.EntryCode,
+		enrollment.WithdrawalDate,
+		enrollment.WithdrawalCode,
+		enrollment.UpdatedAt,
+		enrollment.ID,
+	)
+
+	return err
+}
+
+func (s *StudentProfilePostgresStore) DeleteSchoolEnrollment(ctx context.Context, id string) error {
+	_, err := s.db.pool.Exec(ctx, `DELETE FROM school_enrollments WHERE id = $1`, id)
+	return err
+}
+
+func validateGradeLevel(gradeLevel string) error {
+	if gradeLevel != "" && !slices.Contains(gradeLevels, gradeLevel) {
+		return fmt.Errorf("grade level must be one of %s", strings.Join(gradeLevels, ", "))
+	}
+
+	return nil
+}
+
+// Trims the profile's fields and checks them
+func validateStudentProfile(profile *StudentProfile, today Date) error {
+	profile.StudentNumber = strings.TrimSpace(profile.StudentNumber)
+	profile.HomeLanguage = strings.TrimSpace(profile.HomeLanguage)
+
+	if err := validateGradeLevel(profile.GradeLevel); err != nil {
+		return err
+	}
+
+	if profile.DateOfBirth.After(today.Time) {
+		return errors.New("date of birth must not be in the future")
+	}
+
+	if profile.EmergencyContacts == nil {
+		profile.EmergencyContacts = []EmergencyContact{}
+	}
+
+	for i := range profile.EmergencyContacts {
+		contact := &profile.EmergencyContacts[i]
+		contact.Name = strings.TrimSpace(contact.Name)
+		contact.Phone = strings.TrimSpace(contact.Phone)
+		contact.Email = strings.TrimSpace(contact.Email)
+
+		if contact.Name == "" {
+			return fmt.Errorf("emergency contact %d needs a name", i+1)
+		}
+
+		if contact.Phone == "" && contact.Email == "" {
+			return fmt.Errorf("emergency contact %s needs a phone number or email", contact.Name)
+		}
+	}
+
+	return nil
+}
+
+// Checks the enrollment against the student's other enrollments at the same school, which
+// must not overlap it
+func validateSchoolEnrollment(enrollment *SchoolEnrollment, existing []*SchoolEnrollment) error {
+	enrollment.EntryCode = strings.TrimSpace(enrollment.EntryCode)
+	enrollment.WithdrawalCode = strings.TrimSpace(enrollment.WithdrawalCode)
+
+	if enrollment.SchoolID == "" {
+		return errors.New("school id is required")
+	}
+
+	if enrollment.EntryDate.IsZero() {
+		return errors.New("entry date is required")
+	}
+
+	if enrollment.EntryCode == "" {
+		return errors.New("entry code is required")
+	}
+
+	if err := validateGradeLevel(enrollment.GradeLevel); err != nil {
+		return err
+	}
+
+	if enrollment.WithdrawalDate.IsZero() != (enrollment.WithdrawalCode == "") {
+		return errors.New("withdrawal date and withdrawal code go together")
+	}
+
+	if !enrollment.WithdrawalDate.IsZero() && enrollment.WithdrawalDate.Before(enrollment.EntryDate.Time) {
+		return errors.New("withdrawal date must not be before entry date")
+	}
+
+	// Open enrollments run indefinitely
+	end := func(e *SchoolEnrollment) Date {
+		if e.WithdrawalDate.IsZero() {
+			return NewDate(9999, time.December, 31)
+		}
+
+		return e.WithdrawalDate
+	}
+
+	for _, other := range existing {
+		if other.ID == enrollment.ID || other.SchoolID != enrollment.SchoolID {
+			continue
+		}
+
+		if datesOverlap(enrollment.EntryDate, end(enrollment), other.EntryDate, end(other)) {
+			return fmt.Errorf("overlaps the enrollment starting %s", other.EntryDate)
+		}
+	}
+
+	return nil
+}
+
+// Collects the student's profiles and school enrollments for their data export
+func studentProfileDataExport(studentProfileStore StudentProfileStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		profiles, err := studentProfileStore.ListProfiles(ctx, &StudentProfileFilter{UserID: userID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		enrollments, err := studentProfileStore.ListSchoolEnrollments(ctx, &SchoolEnrollmentFilter{StudentUserID: userID})
+
+		return append(toAnySlice(profiles), toAnySlice(enrollments)...), err
+	}
+}
+
+type StudentProfileService struct {
+	profileStore StudentProfileStore
+	schoolStore  SchoolStore
+	memberStore  OrganizationMemberStore
+	auditService *AuditService
+}
+
+func NewStudentProfileService(profileStore StudentProfileStore, schoolStore SchoolStore, memberStore OrganizationMemberStore, auditService *AuditService) *StudentProfileService {
+	return &StudentProfileService{profileStore: profileStore, schoolStore: schoolStore, memberStore: memberStore, auditService: auditService}
+}
+
+// Checks the session user may act on the student's records: the student themselves, or a
+// member of the organization with one of the given roles. The user must be a student there.
+func (s *StudentProfileService) authorizeStudent(ctx context.Context, organizationID string, studentUserID string, allowSelf bool, roles ...string) error {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if allowSelf && session.UserID == studentUserID {
+		roles = nil
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, roles...); err != nil {
+		return err
+	}
+
+	student, err := s.memberStore.Get(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get organization member", "error", err)
+		return ErrInternal
+	}
+
+	if student == nil || student.Role != RoleStudent {
+		return notFound("student")
+	}
+
+	return nil
+}
+
+// Returns the student's profile, blank if none has been recorded. Students may read their own.
+func (s *StudentProfileService) GetProfile(ctx context.Context, organizationID string, studentUserID string) (*StudentProfile, error) {
+	if err := s.authorizeStudent(ctx, organizationID, studentUserID, true, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.profileStore.GetProfile(ctx, organizationID, studentUserID)
+
+	if err != nil {
+		slog.Error("failed to get student profile", "error", err)
+		return nil, ErrInternal
+	}
+
+	if profile == nil {
+		profile = &StudentProfile{OrganizationID: organizationID, UserID: studentUserID, EmergencyContacts: []EmergencyContact{}}
+	}
+
+	return profile, nil
+}
+
+func (s *StudentProfileService) ListProfiles(ctx context.Context, organizationID string, filter *StudentProfileFilter) ([]*StudentProfile, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.profileStore.ListProfiles(ctx, &StudentProfileFilter{OrganizationID: organizationID, GradeLevel: filter.GradeLevel})
+
+	if err != nil {
+		slog.Error("failed to list student profiles", "error", err)
+		return nil, ErrInternal
+	}
+
+	if profiles == nil {
+		profiles = []*StudentProfile{}
+	}
+
+	return profiles, nil
+}
+
+// Replaces the student's profile
+func (s *StudentProfileService) SaveProfile(ctx context.Context, profile *StudentProfile) error {
+	if err := s.authorizeStudent(ctx, profile.OrganizationID, profile.UserID, false, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := validateStudentProfile(profile, DateOf(time.Now())); err != nil {
+		return err
+	}
+
+	if profile.StudentNumber != "" {
+		holder, err := s.profileStore.GetProfileByStudentNumber(ctx, profile.OrganizationID, profile.StudentNumber)
+
+		if err != nil {
+			slog.Error("failed to get student profile", "error", err)
+			return ErrInternal
+		}
+
+		if holder != nil && holder.UserID != profile.UserID {
+			return fmt.Errorf("student number %s belongs to another student", profile.StudentNumber)
+		}
+	}
+
+	before, err := s.profileStore.GetProfile(ctx, profile.OrganizationID, profile.UserID)
+
+	if err != nil {
+		slog.Error("failed to get student profile", "error", err)
+		return ErrInternal
+	}
+
+	profile.UpdatedAt = time.Now()
+
+	if err := s.profileStore.SaveProfile(ctx, profile); err != nil {
+		slog.Error("failed to save student profile", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, profile.OrganizationID, AuditActionUpdate, "student_profile", profile.UserID, before, profile)
+
+	return nil
+}
+
+func (s *StudentProfileService) listSchoolEnrollments(ctx context.Context, organizationID string, studentUserID string) ([]*SchoolEnrollment, error) {
+	enrollments, err := s.profileStore.ListSchoolEnrollments(ctx, &SchoolEnrollmentFilter{OrganizationID: organizationID, StudentUserID: studentUserID})
+
+	if err != nil {
+		slog.Error("failed to list school enrollments", "error", err)
+		return nil, ErrInternal
+	}
+
+	if enrollments == nil {
+		enrollments = []*SchoolEnrollment{}
+	}
+
+	return enrollments, nil
+}
+
+// Returns the schools the student has attended in the organization, oldest first. Students
+// may read their own.
+func (s *StudentProfileService) ListSchoolEnrollments(ctx context.Context, organizationID string, studentUserID string) ([]*SchoolEnrollment, error) {
+	if err := s.authorizeStudent(ctx, organizationID, studentUserID, true, RoleAdmin, RoleTeacher); err != nil {
+		return nil, err
+	}
+
+	return s.listSchoolEnrollments(ctx, organizationID, studentUserID)
+}
+
+// Records the student's entry to a school of the organization
+func (s *StudentProfileService) CreateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error {
+	if err := s.authorizeStudent(ctx, enrollment.OrganizationID, enrollment.StudentUserID, false, RoleAdmin); err != nil {
+		return err
+	}
+
+	if enrollment.SchoolID != "" {
+		school, err := authorizeSchool(ctx, s.schoolStore, s.memberStore, enrollment.SchoolID, RoleAdmin)
+
+		if err != nil {
+			return err
+		}
+
+		if school.OrganizationID != enrollment.OrganizationID {
+			return notFound("school")
+		}
+	}
+
+	existing, err := s.listSchoolEnrollments(ctx, enrollment.OrganizationID, enrollment.StudentUserID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateSchoolEnrollment(enrollment, existing); err != nil {
+		return err
+	}
+
+	enrollment.CreatedAt = time.Now()
+	enrollment.UpdatedAt = time.Now()
+
+	if err := s.profileStore.CreateSchoolEnrollment(ctx, enrollment); err != nil {
+		slog.Error("failed to create school enrollment", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, enrollment.OrganizationID, AuditActionCreate, "school_enrollment", enrollment.ID, nil, enrollment)
+
+	return nil
+}
+
+func (s *StudentProfileService) authorizeSchoolEnrollment(ctx context.Context, id string) (*SchoolEnrollment, error) {
+	enrollment, err := s.profileStore.GetSchoolEnrollment(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get school enrollment", "error", err)
+		return nil, ErrInternal
+	}
+
+	if enrollment == nil {
+		return nil, notFound("school enrollment")
+	}
+
+	if _, err := requireMembership(ctx, s.memberStore, enrollment.OrganizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// Changes the enrollment. An empty string for a date clears it, so a withdrawal can be undone.
+type UpdateSchoolEnrollmentRequest struct {
+	GradeLevel     *string `json:"gradeLevel"`
+	EntryDate      *Date   `json:"entryDate"`
+	EntryCode      string  `json:"entryCode"`
+	WithdrawalDate *Date   `json:"withdrawalDate"`
+	WithdrawalCode *string `json:"withdrawalCode"`
+}
+
+func (s *StudentProfileService) UpdateSchoolEnrollment(ctx context.Context, id string, request *UpdateSchoolEnrollmentRequest) (*SchoolEnrollment, error) {
+	enrollment, err := s.authorizeSchoolEnrollment(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := *enrollment
+
+	if request.GradeLevel != nil {
+		enrollment.GradeLevel = *request.GradeLevel
+	}
+
+	if request.EntryDate != nil {
+		enrollment.EntryDate = *request.EntryDate
+	}
+
+	if request.EntryCode != "" {
+		enrollment.EntryCode = request.EntryCode
+	}
+
+	if request.WithdrawalDate != nil {
+		enrollment.WithdrawalDate = *request.WithdrawalDate
+	}
+
+	if request.WithdrawalCode != nil {
+		enrollment.WithdrawalCode = *request.WithdrawalCode
+	}
+
+	existing, err := s.listSchoolEnrollments(ctx, enrollment.OrganizationID, enrollment.StudentUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSchoolEnrollment(enrollment, existing); err != nil {
+		return nil, err
+	}
+
+	enrollment.UpdatedAt = time.Now()
+
+	if err := s.profileStore.UpdateSchoolEnrollment(ctx, enrollment); err != nil {
+		slog.Error("failed to update school enrollment", "error", err)
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, enrollment.OrganizationID, AuditActionUpdate, "school_enrollment", id, &before, enrollment)
+
+	return enrollment, nil
+}
+
+func (s *StudentProfileService) DeleteSchoolEnrollment(ctx context.Context, id string) error {
+	enrollment, err := s.authorizeSchoolEnrollment(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.profileStore.DeleteSchoolEnrollment(ctx, id); err != nil {
+		slog.Error("failed to delete school enrollment", "error", err)
+		return ErrInternal
+	}
+
+	s.auditService.Record(ctx, enrollment.OrganizationID, AuditActionDelete, "school_enrollment", id, enrollment, nil)
+
+	return nil
+}
+
+type StudentProfileHandler struct {
+	profileService *StudentProfileService
+}
+
+func (h *StudentProfileHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.profileService.ListProfiles(r.Context(), r.PathValue("id"), &StudentProfileFilter{GradeLevel: r.URL.Query().Get("gradeLevel")})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+func (h *StudentProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.profileService.GetProfile(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (h *StudentProfileHandler) SaveProfile(w http.ResponseWriter, r *http.Request) {
+	var profile StudentProfile
+
+	if err := decodeJSON(r, &profile); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	profile.OrganizationID = r.PathValue("id")
+	profile.UserID = r.PathValue("studentId")
+
+	if err := h.profileService.SaveProfile(r.Context(), &profile); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (h *StudentProfileHandler) ListSchoolEnrollments(w http.ResponseWriter, r *http.Request) {
+	enrollments, err := h.profileService.ListSchoolEnrollments(r.Context(), r.PathValue("id"), r.PathValue("studentId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollments)
+}
+
+func (h *StudentProfileHandler) CreateSchoolEnrollment(w http.ResponseWriter, r *http.Request) {
+	var enrollment SchoolEnrollment
+
+	if err := decodeJSON(r, &enrollment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	enrollment.ID = ""
+	enrollment.OrganizationID = r.PathValue("id")
+	enrollment.StudentUserID = r.PathValue("studentId")
+
+	if err := h.profileService.CreateSchoolEnrollment(r.Context(), &enrollment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, enrollment)
+}
+
+func (h *StudentProfileHandler) UpdateSchoolEnrollment(w http.ResponseWriter, r *http.Request) {
+	var request UpdateSchoolEnrollmentRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	enrollment, err := h.profileService.UpdateSchoolEnrollment(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *StudentProfileHandler) DeleteSchoolEnrollment(w http.ResponseWriter, r *http.Request) {
+	if err := h.profileService.DeleteSchoolEnrollment(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockStudentProfileStore struct {
+	GetProfileFunc                func(ctx context.Context, organizationID string, userID string) (*StudentProfile, error)
+	GetProfileByStudentNumberFunc func(ctx context.Context, organizationID string, studentNumber string) (*StudentProfile, error)
+	ListProfilesFunc              func(ctx context.Context, filter *StudentProfileFilter) ([]*StudentProfile, error)
+	SaveProfileFunc               func(ctx context.Context, profile *StudentProfile) error
+	DeleteProfilesFunc            func(ctx context.Context, userID string) error
+	CreateSchoolEnrollmentFunc    func(ctx context.Context, enrollment *SchoolEnrollment) error
+	GetSchoolEnrollmentFunc       func(ctx context.Context, id string) (*SchoolEnrollment, error)
+	ListSchoolEnrollmentsFunc     func(ctx context.Context, filter *SchoolEnrollmentFilter) ([]*SchoolEnrollment, error)
+	UpdateSchoolEnrollmentFunc    func(ctx context.Context, enrollment *SchoolEnrollment) error
+	DeleteSchoolEnrollmentFunc    func(ctx context.Context, id string) error
+}
+
+func (m *MockStudentProfileStore) GetProfile(ctx context.Context, organizationID string, userID string) (*StudentProfile, error) {
+	if m.GetProfileFunc != nil {
+		return m.GetProfileFunc(ctx, organizationID, userID)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStudentProfileStore) GetProfileByStudentNumber(ctx context.Context, organizationID string, studentNumber string) (*StudentProfile, error) {
+	if m.GetProfileByStudentNumberFunc != nil {
+		return m.GetProfileByStudentNumberFunc(ctx, organizationID, studentNumber)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStudentProfileStore) ListProfiles(ctx context.Context, filter *StudentProfileFilter) ([]*StudentProfile, error) {
+	if m.ListProfilesFunc != nil {
+		return m.ListProfilesFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStudentProfileStore) SaveProfile(ctx context.Context, profile *StudentProfile) error {
+	if m.SaveProfileFunc != nil {
+		return m.SaveProfileFunc(ctx, profile)
+	}
+
+	return nil
+}
+
+func (m *MockStudentProfileStore) DeleteProfiles(ctx context.Context, userID string) error {
+	if m.DeleteProfilesFunc != nil {
+		return m.DeleteProfilesFunc(ctx, userID)
+	}
+
+	return nil
+}
+
+func (m *MockStudentProfileStore) CreateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error {
+	if m.CreateSchoolEnrollmentFunc != nil {
+		return m.CreateSchoolEnrollmentFunc(ctx, enrollment)
+	}
+
+	return nil
+}
+
+func (m *MockStudentProfileStore) GetSchoolEnrollment(ctx context.Context, id string) (*SchoolEnrollment, error) {
+	if m.GetSchoolEnrollmentFunc != nil {
+		return m.GetSchoolEnrollmentFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStudentProfileStore) ListSchoolEnrollments(ctx context.Context, filter *SchoolEnrollmentFilter) ([]*SchoolEnrollment, error) {
+	if m.ListSchoolEnrollmentsFunc != nil {
+		return m.ListSchoolEnrollmentsFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockStudentProfileStore) UpdateSchoolEnrollment(ctx context.Context, enrollment *SchoolEnrollment) error {
+	if m.UpdateSchoolEnrollmentFunc != nil {
+		return m.UpdateSchoolEnrollmentFunc(ctx, enrollment)
+	}
+
+	return nil
+}
+
+func (m *MockStudentProfileStore) DeleteSchoolEnrollment(ctx context.Context, id string) error {
+	if m.DeleteSchoolEnrollmentFunc != nil {
+		return m.DeleteSchoolEnrollmentFunc(ctx, id)
+	}
+
+	return nil
+}
+
+// The student attended North High from August 2024 until withdrawing in June 2025
+func withdrawnStudent() *MockStudentProfileStore {
+	enrollment := &SchoolEnrollment{
+		ID: "north", OrganizationID: "org", StudentUserID: "student", SchoolID: "school", GradeLevel: "09",
+		EntryDate: NewDate(2024, time.August, 26), EntryCode: "E1",
+		WithdrawalDate: NewDate(2025, time.June, 13), WithdrawalCode: "W1",
+	}
+
+	return &MockStudentProfileStore{
+		GetSchoolEnrollmentFunc: func(ctx context.Context, id string) (*SchoolEnrollment, error) {
+			if id != enrollment.ID {
+				return nil, nil
+			}
+
+			copied := *enrollment
+			return &copied, nil
+		},
+		ListSchoolEnrollmentsFunc: func(ctx context.Context, filter *SchoolEnrollmentFilter) ([]*SchoolEnrollment, error) {
+			return []*SchoolEnrollment{enrollment}, nil
+		},
+	}
+}
+
+func TestValidateStudentProfile_ReturnsErrorForUnknownGradeLevel(t *testing.T) {
+	err := validateStudentProfile(&StudentProfile{GradeLevel: "9"}, schoolDay)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "grade level must be one of")
+}
+
+func TestValidateStudentProfile_ReturnsErrorForFutureDateOfBirth(t *testing.T) {
+	err := validateStudentProfile(&StudentProfile{DateOfBirth: schoolDay.AddDays(1)}, schoolDay)
+
+	assert.Error(t, err)
+	assert.Equal(t, "date of birth must not be in the future", err.Error())
+}
+
+func TestValidateStudentProfile_ReturnsErrorForUnreachableContact(t *testing.T) {
+	err := validateStudentProfile(&StudentProfile{EmergencyContacts: []EmergencyContact{{Name: " Aunt May ", Relationship: "aunt"}}}, schoolDay)
+
+	assert.Error(t, err)
+	assert.Equal(t, "emergency contact Aunt May needs a phone number or email", err.Error())
+}
+
+func TestValidateSchoolEnrollment_ReturnsErrorForWithdrawalWithoutCode(t *testing.T) {
+	err := validateSchoolEnrollment(&SchoolEnrollment{SchoolID: "school", EntryDate: schoolDay, EntryCode: "E1", WithdrawalDate: schoolDay.AddDays(30)}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "withdrawal date and withdrawal code go together", err.Error())
+}
+
+func TestValidateSchoolEnrollment_ReturnsErrorForOverlapAtSameSchool(t *testing.T) {
+	existing, _ := withdrawnStudent().ListSchoolEnrollments(context.Background(), &SchoolEnrollmentFilter{})
+
+	err := validateSchoolEnrollment(&SchoolEnrollment{SchoolID: "school", EntryDate: NewDate(2025, time.January, 6), EntryCode: "R1"}, existing)
+
+	assert.Error(t, err)
+	assert.Equal(t, "overlaps the enrollment starting 2024-08-26", err.Error())
+}
+
+func TestValidateSchoolEnrollment_AllowsOverlapAtOtherSchool(t *testing.T) {
+	existing, _ := withdrawnStudent().ListSchoolEnrollments(context.Background(), &SchoolEnrollmentFilter{})
+
+	err := validateSchoolEnrollment(&SchoolEnrollment{SchoolID: "other-school", EntryDate: NewDate(2025, time.January, 6), EntryCode: "E2"}, existing)
+
+	assert.NoError(t, err)
+}
+
+func TestStudentProfileService_GetProfile_ReturnsBlankProfile(t *testing.T) {
+	profileService := NewStudentProfileService(&MockStudentProfileStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	profile, err := profileService.GetProfile(sessionContext("student"), "org", "student")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "student", profile.UserID)
+	assert.Empty(t, profile.EmergencyContacts)
+}
+
+func TestStudentProfileService_GetProfile_ReturnsErrorForOtherStudent(t *testing.T) {
+	profileService := NewStudentProfileService(&MockStudentProfileStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	profile, err := profileService.GetProfile(sessionContext("other-student"), "org", "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, profile)
+}
+
+func TestStudentProfileService_GetProfile_ReturnsErrorForNonStudent(t *testing.T) {
+	profileService := NewStudentProfileService(&MockStudentProfileStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	profile, err := profileService.GetProfile(sessionContext("admin"), "org", "teacher")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, profile)
+}
+
+func TestStudentProfileService_SaveProfile_ReturnsErrorForStudent(t *testing.T) {
+	profileService := NewStudentProfileService(&MockStudentProfileStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := profileService.SaveProfile(sessionContext("student"), &StudentProfile{OrganizationID: "org", UserID: "student", GradeLevel: "10"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestStudentProfileService_SaveProfile_ReturnsErrorForTakenStudentNumber(t *testing.T) {
+	profileService := NewStudentProfileService(&MockStudentProfileStore{
+		GetProfileByStudentNumberFunc: func(ctx context.Context, organizationID string, studentNumber string) (*StudentProfile, error) {
+			return &StudentProfile{OrganizationID: organizationID, UserID: "other-student", StudentNumber: studentNumber}, nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := profileService.SaveProfile(sessionContext("admin"), &StudentProfile{OrganizationID: "org", UserID: "student", StudentNumber: " 1001 "})
+
+	assert.Error(t, err)
+	assert.Equal(t, "student number 1001 belongs to another student", err.Error())
+}
+
+func TestStudentProfileService_SaveProfile_SavesProfile(t *testing.T) {
+	var saved *StudentProfile
+	profileService := NewStudentProfileService(&MockStudentProfileStore{
+		SaveProfileFunc: func(ctx context.Context, profile *StudentProfile) error {
+			saved = profile
+			return nil
+		},
+	}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	err := profileService.SaveProfile(sessionContext("admin"), &StudentProfile{
+		OrganizationID: "org", UserID: "student", StudentNumber: "1001", DateOfBirth: NewDate(2010, time.March, 14), GradeLevel: "10", HomeLanguage: "Spanish",
+		EmergencyContacts: []EmergencyContact{{Name: "Aunt May", Relationship: "aunt", Phone: "555-0100"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Spanish", saved.HomeLanguage)
+	assert.False(t, saved.UpdatedAt.IsZero())
+}
+
+func TestStudentProfileService_CreateSchoolEnrollment_RecordsReentry(t *testing.T) {
+	profileService := NewStudentProfileService(withdrawnStudent(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	enrollment := &SchoolEnrollment{OrganizationID: "org", StudentUserID: "student", SchoolID: "school", GradeLevel: "10", EntryDate: NewDate(2025, time.August, 25), EntryCode: "R1"}
+
+	err := profileService.CreateSchoolEnrollment(sessionContext("admin"), enrollment)
+
+	assert.NoError(t, err)
+	assert.False(t, enrollment.CreatedAt.IsZero())
+}
+
+func TestStudentProfileService_CreateSchoolEnrollment_ReturnsErrorForSchoolOfOtherOrganization(t *testing.T) {
+	profileService := NewStudentProfileService(&MockStudentProfileStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	profileService.schoolStore = &MockSchoolStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*School, error) {
+			return &School{ID: id, OrganizationID: "other-org", Name: "South High"}, nil
+		},
+	}
+
+	err := profileService.CreateSchoolEnrollment(sessionContext("admin"), &SchoolEnrollment{OrganizationID: "org", StudentUserID: "student", SchoolID: "south", EntryDate: schoolDay, EntryCode: "E1"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestStudentProfileService_UpdateSchoolEnrollment_UndoesWithdrawal(t *testing.T) {
+	profileService := NewStudentProfileService(withdrawnStudent(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	cleared := ""
+
+	enrollment, err := profileService.UpdateSchoolEnrollment(sessionContext("admin"), "north", &UpdateSchoolEnrollmentRequest{WithdrawalDate: &Date{}, WithdrawalCode: &cleared})
+
+	assert.NoError(t, err)
+	assert.True(t, enrollment.WithdrawalDate.IsZero())
+	assert.Empty(t, enrollment.WithdrawalCode)
+}
+
+func TestStudentProfileService_ListSchoolEnrollments_AllowsStudent(t *testing.T) {
+	profileService := NewStudentProfileService(withdrawnStudent(), existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	enrollments, err := profileService.ListSchoolEnrollments(sessionContext("student"), "org", "student")
+
+	assert.NoError(t, err)
+	assert.Len(t, enrollments, 1)
+}
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	maxSubmissionFiles      = 10
+	maxSubmissionFileSize   = 25 << 20
+	maxSubmissionUploadSize = 50 << 20
+)
+
+// A file attached to a submission. Its contents live in the blob store.
+type SubmissionFile struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+}
+
+// One attempt by a student at an assignment. Attempts are numbered from 1.
+type Submission struct {
+	ID               string           `json:"id"`
+	AssignmentID     string           `json:"assignmentId"`
+	StudentUserID    string           `json:"studentUserId"`
+	Attempt          int              `json:"attempt"`
+	Text             string           `json:"text"`
+	Files            []SubmissionFile `json:"files"`
+	Late             bool             `json:"late"`
+	SubmittedAt      time.Time        `json:"submittedAt"`
+	Feedback         string           `json:"feedback"`
+	FeedbackByUserID string           `json:"feedbackByUserId"`
+	FeedbackAt       *time.Time       `json:"feedbackAt"`
+	// The gradebook score, when it was given for this submission
+	Grade *Grade `json:"grade,omitempty"`
+}
+
+type SubmissionFilter struct {
+	AssignmentID  string
+	StudentUserID string
+}
+
+// Returns the blob key of a submission file. Files are stored before their submission row
+// exists, so the key is built from the assignment and student rather than the submission.
+func submissionFileKey(submission *Submission, fileID string) string {
+	return "submissions/" + submission.AssignmentID + "/" + submission.StudentUserID + "/" + fileID
+}
+
+type SubmissionPostgresStore struct {
+	db *PostgresDB
+}
+
+type SubmissionStore interface {
+	// Creates the submission as the student's next attempt, setting its ID and Attempt
+	Create(ctx context.Context, submission *Submission) error
+	GetByID(ctx context.Context, id string) (*Submission, error)
+	List(ctx context.Context, filter *SubmissionFilter) ([]*Submission, error)
+	SaveFeedback(ctx context.Context, submission *Submission) error
+}
+
+const submissionColumns = `id, assignment_id, student_user_id, attempt, text, files, late, submitted_at, feedback, COALESCE(feedback_by_user_id::text, ''), feedback_at`
+
+func scanSubmission(row rowScanner) (*Submission, error) {
+	var submission Submission
+
+	err := row.Scan(
+		&submission.ID,
+		&submission.AssignmentID,
+		&submission.StudentUserID,
+		&submission.Attempt,
+		&submission.Text,
+		&submission.Files,
+		&submission.Late,
+		&submission.SubmittedAt,
+		&submission.Feedback,
+		&submission.FeedbackByUserID,
+		&submission.FeedbackAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+func (s *SubmissionPostgresStore) Create(ctx context.Context, submission *Submission) error {
+	query := `
+		INSERT INTO submissions (assignment_id, student_user_id, attempt, text, files, late, submitted_at)
+		SELECT $1::uuid, $2::uuid, COALESCE(MAX(attempt), 0) + 1, $3, $4, $5, $6
+		FROM submissions
+		WHERE assignment_id = $1 AND student_user_id = $2
+		RETURNING id, attempt
+	`
+
+	row := s.db.pool.QueryRow(ctx, query,
+		submission.AssignmentID,
+		submission.StudentUserID,
+		submission.Text,
+		submission.Files,
+		submission.Late,
+		submission.SubmittedAt,
+	)
+
+	return row.Scan(&submission.ID, &submission.Attempt)
+}
+
+func (s *SubmissionPostgresStore) GetByID(ctx context.Context, id string) (*Submission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM submissions WHERE id = $1`
+
+	return noRowsAsNil(scanSubmission(s.db.pool.QueryRow(ctx, query, id)))
+}
+
+func (s *SubmissionPostgresStore) List(ctx context.Context, filter *SubmissionFilter) ([]*Submission, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.AssignmentID != "" {
+		addCondition("assignment_id = $%d", filter.AssignmentID)
+	}
+
+	if filter.StudentUserID != "" {
+		addCondition("student_user_id = $%d", filter.StudentUserID)
+	}
+
+	query := `SELECT ` + submissionColumns + ` FROM submissions`
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY student_user_id, attempt"
+
+	rows, err := s.db.pool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var submissions []*Submission
+
+	for rows.Next() {
+		submission, err := scanSubmission(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		submissions = append(submissions, submission)
+	}
+
+	return submissions, rows.Err()
+}
+
+func (s *SubmissionPostgresStore) SaveFeedback(ctx context.Context, submission *Submission) error {
+	query := `
+		UPDATE submissions
+		SET feedback = $1, feedback_by_user_id = $2, feedback_at = $3
+		WHERE id = $4
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, submission.Feedback, submission.FeedbackByUserID, submission.FeedbackAt, submission.ID)
+
+	return err
+}
+
+// A file uploaded with a submission
+type SubmissionUpload struct {
+	Name        string
+	ContentType string
+	Body        io.Reader
+}
+
+type SubmitRequest struct {
+	Text  string             `json:"text"`
+	Files []SubmissionUpload `json:"-"`
+}
+
+type SubmissionFeedbackRequest struct {
+	Points   *float64 `json:"points"`
+	Excused  bool     `json:"excused"`
+	Feedback string   `json:"feedback"`
+}
+
+// Collects the student's submissions for their data export. Files are listed but not copied.
+func submissionDataExport(submissionStore SubmissionStore) DataExportCollector {
+	return func(ctx context.Context, userID string) ([]any, error) {
+		submissions, err := submissionStore.List(ctx, &SubmissionFilter{StudentUserID: userID})
+		return toAnySlice(submissions), err
+	}
+}
+
+type SubmissionService struct {
+	submissionStore  SubmissionStore
+	gradebookService *GradebookService
+	memberStore      OrganizationMemberStore
+	blobStore        BlobStore
+	auditService     *AuditService
+}
+
+func NewSubmissionService(submissionStore SubmissionStore, gradebookService *GradebookService, memberStore OrganizationMemberStore, blobStore BlobStore, auditService *AuditService) *SubmissionService {
+	return &SubmissionService{
+		submissionStore:  submissionStore,
+		gradebookService: gradebookService,
+		memberStore:      memberStore,
+		blobStore:        blobStore,
+		auditService:     auditService,
+	}
+}
+
+// Turns work in as the session user's next attempt at an assignment. Work handed in after
+// the due date is flagged late, or refused if the assignment rejects late submissions.
+func (s *SubmissionService) Submit(ctx context.Context, assignmentID string, request *SubmitRequest) (*Submission, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	assignment, section, school, err := s.gradebookService.getAssignment(ctx, assignmentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled, err := s.gradebookService.isEnrolled(ctx, section.ID, session.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !enrolled {
+		return nil, forbidden("only students enrolled in the section can submit work")
+	}
+
+	if strings.TrimSpace(request.Text) == "" && len(request.Files) == 0 {
+		return nil, errors.New("a submission needs text or at least one file")
+	}
+
+	if len(request.Files) > maxSubmissionFiles {
+		return nil, fmt.Errorf("a submission can have at most %d files", maxSubmissionFiles)
+	}
+
+	now := time.Now()
+	late := assignment.DueAt != nil && now.After(*assignment.DueAt)
+
+	if late && assignment.RejectLateSubmissions {
+		return nil, errors.New("assignment is past due and no longer accepts submissions")
+	}
+
+	if assignment.MaxAttempts > 0 {
+		previous, err := s.submissionStore.List(ctx, &SubmissionFilter{AssignmentID: assignmentID, StudentUserID: session.UserID})
+
+		if err != nil {
+			slog.Error("failed to list submissions", "error", err)
+			return nil, ErrInternal
+		}
+
+		if len(previous) >= assignment.MaxAttempts {
+			return nil, fmt.Errorf("assignment allows %d attempts and none are left", assignment.MaxAttempts)
+		}
+	}
+
+	submission := &Submission{
+		AssignmentID:  assignmentID,
+		StudentUserID: session.UserID,
+		Text:          request.Text,
+		Files:         []SubmissionFile{},
+		Late:          late,
+		SubmittedAt:   now,
+	}
+
+	if err := s.storeFiles(ctx, submission, request.Files); err != nil {
+		return nil, err
+	}
+
+	if err := s.submissionStore.Create(ctx, submission); err != nil {
+		slog.Error("failed to create submission", "error", err)
+		s.deleteFiles(ctx, submission)
+
+		return nil, ErrInternal
+	}
+
+	s.auditService.Record(ctx, school.OrganizationID, AuditActionCreate, "submission", submission.ID, nil, submission)
+
+	return submission, nil
+}
+
+// Writes the uploads to the blob store and adds them to the submission
+func (s *SubmissionService) storeFiles(ctx context.Context, submission *Submission, uploads []SubmissionUpload) error {
+	for _, upload := range uploads {
+		name := path.Base(strings.ReplaceAll(upload.Name, "\\", "/"))
+
+		if name == "." || name == "/" {
+			s.deleteFiles(ctx, submission)
+			return errors.New("every file needs a name")
+		}
+
+		file := SubmissionFile{ID: newBlobID(), Name: name}
+		meta, err := putBlob(ctx, s.blobStore, submissionFileKey(submission, file.ID), upload.Body, upload.ContentType, maxSubmissionFileSize)
+
+		if err != nil {
+			s.deleteFiles(ctx, submission)
+
+			if errors.Is(err, errBlobTooLarge) {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+
+			slog.Error("failed to store submission file", "error", err)
+
+			return ErrInternal
+		}
+
+		file.ContentType = meta.ContentType
+		file.Size = meta.Size
+		file.Checksum = meta.Checksum
+		submission.Files = append(submission.Files, file)
+	}
+
+	return nil
+}
+
+// Removes the submission's files from the blob store, logging failures
+func (s *SubmissionService) deleteFiles(ctx context.Context, submission *Submission) {
+	for _, file := range submission.Files {
+		if err := s.blobStore.Delete(ctx, submissionFileKey(submission, file.ID)); err != nil {
+			slog.Error("failed to delete submission file", "error", err)
+		}
+	}
+}
+
+// Returns the submission if the session user handed it in or teaches its section
+func (s *SubmissionService) authorize(ctx context.Context, id string) (*Submission, *Section, *School, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, nil, nil, ErrUnauthorized
+	}
+
+	submission, err := s.submissionStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get submission", "error", err)
+		return nil, nil, nil, ErrInternal
+	}
+
+	if submission == nil {
+		return nil, nil, nil, notFound("submission")
+	}
+
+	_, section, school, err := s.gradebookService.getAssignment(ctx, submission.AssignmentID)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if session.UserID != submission.StudentUserID {
+		if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+			return nil, nil, nil, notFound("submission")
+		}
+	}
+
+	return submission, section, school, nil
+}
+
+// Attaches the gradebook score to the submission if it was given for it
+func (s *SubmissionService) attachGrade(ctx context.Context, submission *Submission) error {
+	grade, err := s.gradebookService.gradebookStore.GetGrade(ctx, submission.AssignmentID, submission.StudentUserID)
+
+	if err != nil {
+		slog.Error("failed to get grade", "error", err)
+		return ErrInternal
+	}
+
+	if grade != nil && grade.SubmissionID == submission.ID {
+		submission.Grade = grade
+	}
+
+	return nil
+}
+
+func (s *SubmissionService) Get(ctx context.Context, id string) (*Submission, error) {
+	submission, _, _, err := s.authorize(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachGrade(ctx, submission); err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// Lists an assignment's submissions. Section staff see every student's; students see their own.
+func (s *SubmissionService) List(ctx context.Context, assignmentID string) ([]*Submission, error) {
+	session, ok := SessionFromContext(ctx)
+
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	_, section, school, err := s.gradebookService.getAssignment(ctx, assignmentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &SubmissionFilter{AssignmentID: assignmentID}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		if !errors.Is(err, ErrForbidden) {
+			return nil, err
+		}
+
+		if err := s.gradebookService.authorizeReader(ctx, section, school); err != nil {
+			return nil, err
+		}
+
+		filter.StudentUserID = session.UserID
+	}
+
+	submissions, err := s.submissionStore.List(ctx, filter)
+
+	if err != nil {
+		slog.Error("failed to list submissions", "error", err)
+		return nil, ErrInternal
+	}
+
+	if submissions == nil {
+		submissions = []*Submission{}
+	}
+
+	return submissions, nil
+}
+
+// Opens a file of a submission. The caller closes the returned reader.
+func (s *SubmissionService) OpenFile(ctx context.Context, id string, fileID string) (*SubmissionFile, io.ReadCloser, error) {
+	submission, _, _, err := s.authorize(ctx, id)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range submission.Files {
+		if file.ID != fileID {
+			continue
+		}
+
+		body, _, err := s.blobStore.Get(ctx, submissionFileKey(submission, file.ID))
+
+		if err != nil {
+			slog.Error("failed to get submission file", "error", err)
+			return nil, nil, ErrInternal
+		}
+
+		if body == nil {
+			return nil, nil, notFound("file")
+		}
+
+		return &file, body, nil
+	}
+
+	return nil, nil, notFound("file")
+}
+
+// Records a teacher's feedback on a submission and puts its score in the gradebook. Late
+// penalties are worked out from when the submission was handed in.
+func (s *SubmissionService) Feedback(ctx context.Context, id string, request *SubmissionFeedbackRequest) (*Submission, error) {
+	submission, section, school, err := s.authorize(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSectionStaff(ctx, s.memberStore, section, school); err != nil {
+		return nil, err
+	}
+
+	grade, err := s.gradebookService.saveGrade(ctx, submission.AssignmentID, submission.StudentUserID, &SaveGradeRequest{
+		Points:      request.Points,
+		Excused:     request.Excused,
+		SubmittedAt: &submission.SubmittedAt,
+		Comment:     request.Feedback,
+	}, submission.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, _ := SessionFromContext(ctx)
+	now := time.Now()
+
+	submission.Feedback = request.Feedback
+	submission.FeedbackByUserID = session.UserID
+	submission.FeedbackAt = &now
+
+	if err := s.submissionStore.SaveFeedback(ctx, submission); err != nil {
+		slog.Error("failed to save submission feedback", "error", err)
+		return nil, ErrInternal
+	}
+
+	submission.Grade = grade
+
+	return submission, nil
+}
+
+type SubmissionHandler struct {
+	submissionService *SubmissionService
+}
+
+// Accepts either a JSON body with text or a multipart form with a text field and any
+// number of files fields
+func (h *SubmissionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var request SubmitRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSubmissionUploadSize)
+
+		if err := r.ParseMultipartForm(8 << 20); err != nil {
+			var tooLarge *http.MaxBytesError
+
+			if errors.As(err, &tooLarge) {
+				writeError(w, fmt.Errorf("uploads must not exceed %d MB", maxSubmissionUploadSize>>20))
+				return
+			}
+
+			writeError(w, errors.New("invalid multipart form"))
+			return
+		}
+
+		defer r.MultipartForm.RemoveAll()
+
+		request.Text = r.FormValue("text")
+
+		for _, header := range r.MultipartForm.File["files"] {
+			file, err := header.Open()
+
+			if err != nil {
+				writeError(w, errors.New("invalid multipart form"))
+				return
+			}
+
+			defer file.Close()
+
+			request.Files = append(request.Files, SubmissionUpload{
+				Name:        header.Filename,
+				ContentType: header.Header.Get("Content-Type"),
+				Body:        file,
+			})
+		}
+	} else if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	submission, err := h.submissionService.Submit(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, submission)
+}
+
+func (h *SubmissionHandler) List(w http.ResponseWriter, r *http.Request) {
+	submissions, err := h.submissionService.List(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, submissions)
+}
+
+func (h *SubmissionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	submission, err := h.submissionService.Get(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, submission)
+}
+
+func (h *SubmissionHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	file, body, err := h.submissionService.OpenFile(r.Context(), r.PathValue("id"), r.PathValue("fileId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	defer body.Close()
+
+	serveBlob(w, body, &BlobMetadata{Size: file.Size, ContentType: file.ContentType, Checksum: file.Checksum}, file.Name)
+}
+
+func (h *SubmissionHandler) Feedback(w http.ResponseWriter, r *http.Request) {
+	var request SubmissionFeedbackRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	submission, err := h.submissionService.Feedback(r.Context(), r.PathValue("id"), &request)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, submission)
+}
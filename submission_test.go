@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockSubmissionStore struct {
+	CreateFunc       func(ctx context.Context, submission *Submission) error
+	GetByIDFunc      func(ctx context.Context, id string) (*Submission, error)
+	ListFunc         func(ctx context.Context, filter *SubmissionFilter) ([]*Submission, error)
+	SaveFeedbackFunc func(ctx context.Context, submission *Submission) error
+}
+
+func (m *MockSubmissionStore) Create(ctx context.Context, submission *Submission) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, submission)
+	}
+
+	submission.ID = "submission"
+	submission.Attempt = 1
+
+	return nil
+}
+
+func (m *MockSubmissionStore) GetByID(ctx context.Context, id string) (*Submission, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSubmissionStore) List(ctx context.Context, filter *SubmissionFilter) ([]*Submission, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+
+	return nil, nil
+}
+
+func (m *MockSubmissionStore) SaveFeedback(ctx context.Context, submission *Submission) error {
+	if m.SaveFeedbackFunc != nil {
+		return m.SaveFeedbackFunc(ctx, submission)
+	}
+
+	return nil
+}
+
+// A gradebook store holding one essay assignment in "section"
+func essayAssignment(assignment *Assignment) *MockGradebookStore {
+	assignment.ID = "essay"
+	assignment.SectionID = "section"
+	assignment.CategoryID = "homework"
+	assignment.Title = "Essay"
+	assignment.PointsPossible = 10
+
+	return &MockGradebookStore{
+		GetAssignmentFunc: func(ctx context.Context, id string) (*Assignment, error) {
+			if id != assignment.ID {
+				return nil, nil
+			}
+
+			return assignment, nil
+		},
+	}
+}
+
+func TestSubmissionService_Submit_StoresFiles(t *testing.T) {
+	blobs := &MockBlobStore{}
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{}, gradebookService, orgMembers(), blobs, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	submission, err := submissionService.Submit(sessionContext("student"), "essay", &SubmitRequest{
+		Files: []SubmissionUpload{{Name: `C:\Users\me\essay.txt`, ContentType: "text/plain", Body: strings.NewReader("my essay")}},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, submission.Files, 1)
+	assert.Equal(t, "essay.txt", submission.Files[0].Name)
+	assert.Equal(t, int64(8), submission.Files[0].Size)
+	assert.Equal(t, []byte("my essay"), blobs.blobs[submissionFileKey(submission, submission.Files[0].ID)])
+	assert.False(t, submission.Late)
+}
+
+func TestSubmissionService_Submit_FlagsLateWork(t *testing.T) {
+	due := time.Now().Add(-time.Hour)
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{DueAt: &due}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	submission, err := submissionService.Submit(sessionContext("student"), "essay", &SubmitRequest{Text: "sorry"})
+
+	assert.NoError(t, err)
+	assert.True(t, submission.Late)
+}
+
+func TestSubmissionService_Submit_ReturnsErrorWhenLateWorkRejected(t *testing.T) {
+	due := time.Now().Add(-time.Hour)
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{DueAt: &due, RejectLateSubmissions: true}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := submissionService.Submit(sessionContext("student"), "essay", &SubmitRequest{Text: "sorry"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "assignment is past due and no longer accepts submissions", err.Error())
+}
+
+func TestSubmissionService_Submit_ReturnsErrorWhenAttemptsUsedUp(t *testing.T) {
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{MaxAttempts: 1}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{
+		ListFunc: func(ctx context.Context, filter *SubmissionFilter) ([]*Submission, error) {
+			return []*Submission{{ID: "first", Attempt: 1}}, nil
+		},
+	}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := submissionService.Submit(sessionContext("student"), "essay", &SubmitRequest{Text: "again"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "assignment allows 1 attempts and none are left", err.Error())
+}
+
+func TestSubmissionService_Submit_ReturnsErrorForStudentNotEnrolled(t *testing.T) {
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := submissionService.Submit(sessionContext("other-student"), "essay", &SubmitRequest{Text: "hi"})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestSubmissionService_Submit_RemovesFilesWhenSaveFails(t *testing.T) {
+	blobs := &MockBlobStore{}
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{
+		CreateFunc: func(ctx context.Context, submission *Submission) error {
+			return io.ErrUnexpectedEOF
+		},
+	}, gradebookService, orgMembers(), blobs, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := submissionService.Submit(sessionContext("student"), "essay", &SubmitRequest{
+		Files: []SubmissionUpload{{Name: "essay.txt", Body: strings.NewReader("my essay")}},
+	})
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Empty(t, blobs.blobs)
+}
+
+func TestSubmissionService_Get_HidesSubmissionFromOtherStudent(t *testing.T) {
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Submission, error) {
+			return &Submission{ID: id, AssignmentID: "essay", StudentUserID: "student"}, nil
+		},
+	}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := submissionService.Get(sessionContext("other-student"), "submission")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSubmissionService_Feedback_GradesWithLatePenalty(t *testing.T) {
+	var saved *Grade
+
+	due := time.Now().Add(-48 * time.Hour)
+	gradebookStore := essayAssignment(&Assignment{DueAt: &due, LatePenaltyPerDay: 10})
+	gradebookStore.SaveGradeFunc = func(ctx context.Context, grade *Grade) error {
+		saved = grade
+		return nil
+	}
+
+	gradebookService := NewGradebookService(gradebookStore, existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Submission, error) {
+			return &Submission{ID: id, AssignmentID: "essay", StudentUserID: "student", SubmittedAt: due.Add(time.Hour), Late: true}, nil
+		},
+	}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	submission, err := submissionService.Feedback(sessionContext("teacher"), "submission", &SubmissionFeedbackRequest{Points: points(9), Feedback: "Good work"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "submission", saved.SubmissionID)
+	assert.Equal(t, due.Add(time.Hour), *saved.SubmittedAt)
+	assert.Equal(t, "Good work", saved.Comment)
+	assert.Equal(t, "teacher", submission.FeedbackByUserID)
+	assert.Same(t, saved, submission.Grade)
+}
+
+func TestSubmissionService_Feedback_ReturnsErrorForStudent(t *testing.T) {
+	gradebookService := NewGradebookService(essayAssignment(&Assignment{}), existingSection(), enrolledStudent(), &MockGPAStore{}, existingSchool(), orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+	submissionService := NewSubmissionService(&MockSubmissionStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Submission, error) {
+			return &Submission{ID: id, AssignmentID: "essay", StudentUserID: "student"}, nil
+		},
+	}, gradebookService, orgMembers(), &MockBlobStore{}, NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	_, err := submissionService.Feedback(sessionContext("student"), "submission", &SubmissionFeedbackRequest{Points: points(10)})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
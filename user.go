@@ -5,20 +5,24 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"net/http"
 	"regexp"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID        string    `json:"id"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        string     `json:"id"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Email     string     `json:"email"`
+	Password  string     `json:"password"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	ErasedAt  *time.Time `json:"erasedAt,omitempty"`
 }
 
 type UserPostgresStore struct {
@@ -28,9 +32,17 @@ type UserPostgresStore struct {
 type UserStore interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id string) (*User, error)
+	// Lists the users with the ids, leaving out deleted ones
+	ListByIDs(ctx context.Context, ids []string) ([]*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id string) error
+	GetDeletedByID(ctx context.Context, id string) (*User, error)
+	Restore(ctx context.Context, id string) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ListExpiredWithEducationRecords(ctx context.Context, deletedBefore time.Time) ([]*User, error)
+	Pseudonymize(ctx context.Context, user *User) error
+	CreateBatch(ctx context.Context, organizationID string, users []*User, roles []string) error
 }
 
 func (s *UserPostgresStore) Create(ctx context.Context, user *User) error {
@@ -51,34 +63,64 @@ func (s *UserPostgresStore) Create(ctx context.Context, user *User) error {
 
 func (s *UserPostgresStore) GetByID(ctx context.Context, id string) (*User, error) {
 	query := `
-		SELECT id, first_name, last_name, email, password, created_at, updated_at
+		SELECT id, first_name, last_name, email, password, created_at, updated_at, erased_at
 		FROM users
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	row := s.db.pool.QueryRow(ctx, query, id)
 
 	var user User
 
-	if err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
+	if err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.ErasedAt); err != nil {
 		return nil, err
 	}
 
 	return &user, nil
 }
 
+func (s *UserPostgresStore) ListByIDs(ctx context.Context, ids []string) ([]*User, error) {
+	query := `
+		SELECT id, first_name, last_name, email, password, created_at, updated_at, erased_at
+		FROM users
+		WHERE id = ANY($1) AND deleted_at IS NULL
+	`
+
+	rows, err := s.db.pool.Query(ctx, query, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var users []*User
+
+	for rows.Next() {
+		var user User
+
+		if err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.ErasedAt); err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
 func (s *UserPostgresStore) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, first_name, last_name, email, password, created_at, updated_at
+		SELECT id, first_name, last_name, email, password, created_at, updated_at, erased_at
 		FROM users
-		WHERE email = $1
+		WHERE lower(email) = lower($1) AND deleted_at IS NULL
 	`
 
 	row := s.db.pool.QueryRow(ctx, query, email)
 
 	var user User
 
-	if err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
+	if err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.ErasedAt); err != nil {
 		return nil, err
 	}
 
@@ -89,7 +131,7 @@ func (s *UserPostgresStore) Update(ctx context.Context, user *User) error {
 	query := `
 		UPDATE users
 		SET first_name = $1, last_name = $2, email = $3, password = $4, updated_at = $5
-		WHERE id = $6
+		WHERE id = $6 AND deleted_at IS NULL
 	`
 
 	_, err := s.db.pool.Exec(ctx, query,
@@ -104,10 +146,46 @@ func (s *UserPostgresStore) Update(ctx context.Context, user *User) error {
 	return err
 }
 
+// Soft deletes the user. The row is kept until PurgeDeleted removes it.
 func (s *UserPostgresStore) Delete(ctx context.Context, id string) error {
 	query := `
-		DELETE FROM users
-		WHERE id = $1
+		UPDATE users
+		SET deleted_at = now()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, id)
+
+	return err
+}
+
+func (s *UserPostgresStore) GetDeletedByID(ctx context.Context, id string) (*User, error) {
+	query := `
+		SELECT id, first_name, last_name, email, password, created_at, updated_at, deleted_at, erased_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, id)
+
+	var user User
+
+	if err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.ErasedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *UserPostgresStore) Restore(ctx context.Context, id string) error {
+	query := `
+		UPDATE users
+		SET deleted_at = NULL, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL
 	`
 
 	_, err := s.db.pool.Exec(ctx, query, id)
@@ -115,12 +193,106 @@ func (s *UserPostgresStore) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// Whether the user in the enclosing query has education records. These outlive the account:
+// purging a user who has any erases them instead, so the records keep a pseudonymous owner.
+const userHasEducationRecords = `(
+	EXISTS (SELECT 1 FROM enrollments WHERE enrollments.student_user_id = users.id)
+	OR EXISTS (SELECT 1 FROM grades WHERE grades.student_user_id = users.id)
+	OR EXISTS (SELECT 1 FROM submissions WHERE submissions.student_user_id = users.id)
+	OR EXISTS (SELECT 1 FROM attendance_records WHERE attendance_records.student_user_id = users.id)
+	OR EXISTS (SELECT 1 FROM course_marks WHERE course_marks.student_user_id = users.id)
+	OR EXISTS (SELECT 1 FROM test_scores WHERE test_scores.student_user_id = users.id)
+	OR EXISTS (SELECT 1 FROM academic_documents WHERE academic_documents.student_user_id = users.id)
+)`
+
+// Permanently removes users soft deleted before the given time.
+// Users who still own an organization or have education records are kept.
+func (s *UserPostgresStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM users
+		WHERE deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM organizations WHERE organizations.owner_user_id = users.id)
+			AND NOT ` + userHasEducationRecords
+
+	tag, err := s.db.pool.Exec(ctx, query, deletedBefore)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Returns the users soft deleted before the given time that PurgeDeleted keeps for their
+// education records and that have not been erased yet
+func (s *UserPostgresStore) ListExpiredWithEducationRecords(ctx context.Context, deletedBefore time.Time) ([]*User, error) {
+	query := `
+		SELECT id, first_name, last_name, email, password, created_at, updated_at, deleted_at, erased_at
+		FROM users
+		WHERE deleted_at < $1 AND erased_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM organizations WHERE organizations.owner_user_id = users.id)
+			AND ` + userHasEducationRecords
+
+	rows, err := s.db.pool.Query(ctx, query, deletedBefore)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var users []*User
+
+	for rows.Next() {
+		var user User
+
+		if err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.ErasedAt); err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+// Overwrites the user's personal details and password, whether or not the user is soft deleted
+func (s *UserPostgresStore) Pseudonymize(ctx context.Context, user *User) error {
+	query := `
+		UPDATE users
+		SET first_name = $1, last_name = $2, email = $3, password = $4, updated_at = $5, erased_at = $6
+		WHERE id = $7
+	`
+
+	_, err := s.db.pool.Exec(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.Email,
+		user.Password,
+		user.UpdatedAt,
+		user.ErasedAt,
+		user.ID,
+	)
+
+	return err
+}
+
 type UserService struct {
-	userStore UserStore
+	userStore    UserStore
+	memberStore  OrganizationMemberStore
+	auditService *AuditService
+}
+
+func NewUserService(userStore UserStore, memberStore OrganizationMemberStore, auditService *AuditService) *UserService {
+	return &UserService{userStore: userStore, memberStore: memberStore, auditService: auditService}
 }
 
-func NewUserService(userStore UserStore) *UserService {
-	return &UserService{userStore: userStore}
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// Emails are stored trimmed and lowercased, and users_email_live_idx keeps them unique without
+// regard to case
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 func validateUser(user *User) error {
@@ -140,9 +312,7 @@ func validateUser(user *User) error {
 		return errors.New("email is required")
 	}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-
-	if !emailRegex.MatchString(user.Email) {
+	if !emailPattern.MatchString(user.Email) {
 		return errors.New("invalid email format")
 	}
 
@@ -152,6 +322,7 @@ func validateUser(user *User) error {
 func (s *UserService) Create(ctx context.Context, user *User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
+	user.Email = normalizeEmail(user.Email)
 
 	if err := validateUser(user); err != nil {
 		return err
@@ -161,7 +332,7 @@ func (s *UserService) Create(ctx context.Context, user *User) error {
 
 	if err != nil {
 		slog.Error("failed to hash password", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	user.Password = string(hashedPassword)
@@ -170,7 +341,7 @@ func (s *UserService) Create(ctx context.Context, user *User) error {
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		slog.Error("failed to check for existing user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	if existingUser != nil {
@@ -181,9 +352,11 @@ func (s *UserService) Create(ctx context.Context, user *User) error {
 
 	if err != nil {
 		slog.Error("failed to create user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
+	s.auditService.RecordUser(ctx, AuditActionCreate, user.ID, nil, user)
+
 	return nil
 }
 
@@ -192,11 +365,11 @@ func (s *UserService) GetByID(ctx context.Context, id string) (*User, error) {
 
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
-		return nil, errors.New("an internal error occurred")
+		return nil, ErrInternal
 	}
 
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, notFound("user")
 	}
 
 	return user, nil
@@ -207,11 +380,11 @@ func (s *UserService) GetByEmail(ctx context.Context, email string) (*User, erro
 
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
-		return nil, errors.New("an internal error occurred")
+		return nil, ErrInternal
 	}
 
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, notFound("user")
 	}
 
 	return user, nil
@@ -227,13 +400,15 @@ func (s *UserService) Update(ctx context.Context, id string, request *UpdateUser
 
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	if existingUser == nil {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
+	before := *existingUser
+
 	if request.FirstName == "" {
 		existingUser.FirstName = request.FirstName
 	}
@@ -248,9 +423,11 @@ func (s *UserService) Update(ctx context.Context, id string, request *UpdateUser
 
 	if err != nil {
 		slog.Error("failed to update user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
+	s.auditService.RecordUser(ctx, AuditActionUpdate, id, &before, existingUser)
+
 	return err
 }
 
@@ -259,15 +436,19 @@ type UpdatePasswordRequest struct {
 }
 
 func (s *UserService) UpdatePassword(ctx context.Context, id string, request *UpdatePasswordRequest) error {
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
 	existingUser, err := s.userStore.GetByID(ctx, id)
 
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	if existingUser == nil {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
 	if request.Password == "" {
@@ -278,17 +459,21 @@ func (s *UserService) UpdatePassword(ctx context.Context, id string, request *Up
 
 	if err != nil {
 		slog.Error("failed to hash password", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
+	before := *existingUser
+
 	existingUser.Password = string(hashedPassword)
 	err = s.userStore.Update(ctx, existingUser)
 
 	if err != nil {
 		slog.Error("failed to update user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
+	s.auditService.RecordUser(ctx, AuditActionUpdate, id, &before, existingUser)
+
 	return nil
 }
 
@@ -297,17 +482,23 @@ type UpdateEmailRequest struct {
 }
 
 func (s *UserService) UpdateEmail(ctx context.Context, id string, request *UpdateEmailRequest) error {
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
 	existingUser, err := s.userStore.GetByID(ctx, id)
 
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	if existingUser == nil {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
+	request.Email = normalizeEmail(request.Email)
+
 	if request.Email == "" {
 		return errors.New("email is required")
 	}
@@ -316,13 +507,15 @@ func (s *UserService) UpdateEmail(ctx context.Context, id string, request *Updat
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		slog.Error("failed to check for existing user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	if existingEmailUser != nil {
 		return errors.New("user with this email already exists")
 	}
 
+	before := *existingUser
+
 	existingUser.Email = request.Email
 	existingUser.UpdatedAt = time.Now()
 
@@ -330,30 +523,226 @@ func (s *UserService) UpdateEmail(ctx context.Context, id string, request *Updat
 
 	if err != nil {
 		slog.Error("failed to update user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
+	s.auditService.RecordUser(ctx, AuditActionUpdate, id, &before, existingUser)
+
 	return nil
 }
 
 func (s *UserService) Delete(ctx context.Context, id string) error {
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
 	user, err := s.userStore.GetByID(ctx, id)
 
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
 	}
 
 	if user == nil {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
 	err = s.userStore.Delete(ctx, id)
 
 	if err != nil {
 		slog.Error("failed to delete user", "error", err)
-		return errors.New("an internal error occurred")
+		return ErrInternal
+	}
+
+	s.auditService.RecordUser(ctx, AuditActionDelete, id, user, nil)
+
+	return nil
+}
+
+// Restores a soft deleted user. Only admins of an organization the user belongs to may do so,
+// and only while no other user has taken the email address.
+func (s *UserService) Restore(ctx context.Context, id string) (*User, error) {
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userStore.GetDeletedByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get deleted user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if user == nil {
+		return nil, notFound("deleted user")
+	}
+
+	if err := requireAdminOfUser(ctx, s.memberStore, id); err != nil {
+		return nil, err
+	}
+
+	existingEmailUser, err := s.userStore.GetByEmail(ctx, user.Email)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to check for existing user", "error", err)
+		return nil, ErrInternal
+	}
+
+	if existingEmailUser != nil {
+		return nil, errors.New("user with this email already exists")
+	}
+
+	if err := s.userStore.Restore(ctx, id); err != nil {
+		slog.Error("failed to restore user", "error", err)
+		return nil, ErrInternal
+	}
+
+	before := *user
+	user.DeletedAt = nil
+
+	s.auditService.RecordUser(ctx, AuditActionRestore, id, &before, user)
+
+	return user, nil
+}
+
+type UserHandler struct {
+	userService *UserService
+}
+
+// Rejects requests from anyone other than the user identified by the id path value
+func requireSelf(r *http.Request) error {
+	session, ok := SessionFromContext(r.Context())
+
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if session.UserID != r.PathValue("id") {
+		return ErrForbidden
 	}
 
 	return nil
 }
+
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var user User
+
+	if err := decodeJSON(r, &user); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.userService.Create(r.Context(), &user); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user.Password = ""
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if err := requireSelf(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user, err := h.userService.GetByID(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user.Password = ""
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var request UpdateUserRequest
+
+	if err := requireSelf(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.userService.Update(r.Context(), r.PathValue("id"), &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
+	var request UpdatePasswordRequest
+
+	if err := requireSelf(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.userService.UpdatePassword(r.Context(), r.PathValue("id"), &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) UpdateEmail(w http.ResponseWriter, r *http.Request) {
+	var request UpdateEmailRequest
+
+	if err := requireSelf(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.userService.UpdateEmail(r.Context(), r.PathValue("id"), &request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := requireSelf(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.userService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userService.Restore(r.Context(), r.PathValue("id"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user.Password = ""
+	writeJSON(w, http.StatusOK, user)
+}
@@ -0,0 +1,676 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxUserImportSize = 5 << 20
+	maxUserImportRows = 5000
+	// Users saved per transaction
+	userImportBatchSize    = 100
+	userImportPollInterval = 10 * time.Second
+)
+
+// Where a queued user import stands
+const (
+	UserImportPending    = "pending"
+	UserImportProcessing = "processing"
+	UserImportCompleted  = "completed"
+	UserImportFailed     = "failed"
+)
+
+// What happened to each row of a user import
+const (
+	UserImportStatusCreated = "created"
+	// The row would be created; dry runs only
+	UserImportStatusValid     = "valid"
+	UserImportStatusInvalid   = "invalid"
+	UserImportStatusDuplicate = "duplicate"
+	// The row was valid but its batch could not be saved
+	UserImportStatusFailed = "failed"
+)
+
+var userImportColumns = []string{"firstName", "lastName", "email", "password", "role"}
+
+var userImportRoles = []string{RoleAdmin, RoleTeacher, RoleStudent}
+
+type UserImportRow struct {
+	Line   int      `json:"line"`
+	Email  string   `json:"email"`
+	Status string   `json:"status"`
+	UserID string   `json:"userId,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type UserImportReport struct {
+	DryRun  bool `json:"dryRun"`
+	Total   int  `json:"total"`
+	Created int  `json:"created"`
+	// Rows that were not, or in a dry run would not be, created
+	Rejected int              `json:"rejected"`
+	Rows     []*UserImportRow `json:"rows"`
+}
+
+// An import queued by an organization admin. The report is set once the import has run.
+type UserImport struct {
+	ID                string            `json:"id"`
+	OrganizationID    string            `json:"organizationId"`
+	RequestedByUserID string            `json:"requestedByUserId"`
+	Status            string            `json:"status"`
+	Error             string            `json:"error,omitempty"`
+	Report            *UserImportReport `json:"report,omitempty"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	CompletedAt       *time.Time        `json:"completedAt,omitempty"`
+}
+
+type UserImportPostgresStore struct {
+	db *PostgresDB
+}
+
+type UserImportStore interface {
+	// Saves the import as pending along with the uploaded file
+	Create(ctx context.Context, userImport *UserImport, data []byte) error
+	GetByID(ctx context.Context, id string) (*UserImport, error)
+	ClaimPending(ctx context.Context) (*UserImport, []byte, error)
+	// Saves the report and drops the uploaded file
+	Complete(ctx context.Context, id string, report *UserImportReport, completedAt time.Time) error
+	// Drops the uploaded file
+	Fail(ctx context.Context, id string, message string) error
+}
+
+const userImportJobColumns = `id, organization_id, requested_by_user_id, status, error, report, created_at, completed_at`
+
+func scanUserImport(row interface{ Scan(dest ...any) error }, extra ...any) (*UserImport, error) {
+	var userImport UserImport
+
+	dest := []any{
+		&userImport.ID,
+		&userImport.OrganizationID,
+		&userImport.RequestedByUserID,
+		&userImport.Status,
+		&userImport.Error,
+		&userImport.Report,
+		&userImport.CreatedAt,
+		&userImport.CompletedAt,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &userImport, nil
+}
+
+func (s *UserImportPostgresStore) Create(ctx context.Context, userImport *UserImport, data []byte) error {
+	query := `
+		INSERT INTO user_imports (organization_id, requested_by_user_id, status, data, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	row := s.db.pool.QueryRow(ctx, query, userImport.OrganizationID, userImport.RequestedByUserID, userImport.Status, data, userImport.CreatedAt)
+
+	return row.Scan(&userImport.ID)
+}
+
+func (s *UserImportPostgresStore) GetByID(ctx context.Context, id string) (*UserImport, error) {
+	query := `
+		SELECT ` + userImportJobColumns + `
+		FROM user_imports
+		WHERE id = $1
+	`
+
+	return scanUserImport(s.db.pool.QueryRow(ctx, query, id))
+}
+
+// Marks the oldest pending import as processing and returns it with its file, or nil if there is
+// none. Concurrent workers never claim the same import.
+func (s *UserImportPostgresStore) ClaimPending(ctx context.Context) (*UserImport, []byte, error) {
+	query := `
+		UPDATE user_imports
+		SET status = 'processing'
+		WHERE id = (
+			SELECT id FROM user_imports
+			WHERE status = 'pending'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + userImportJobColumns + `, data`
+
+	var data []byte
+	userImport, err := scanUserImport(s.db.pool.QueryRow(ctx, query), &data)
+
+	return userImport, data, err
+}
+
+func (s *UserImportPostgresStore) Complete(ctx context.Context, id string, report *UserImportReport, completedAt time.Time) error {
+	query := `
+		UPDATE user_imports
+		SET status = 'completed', report = $1, data = NULL, completed_at = $2
+		WHERE id = $3
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, report, completedAt, id)
+
+	return err
+}
+
+func (s *UserImportPostgresStore) Fail(ctx context.Context, id string, message string) error {
+	query := `
+		UPDATE user_imports
+		SET status = 'failed', error = $1, data = NULL
+		WHERE id = $2
+	`
+
+	_, err := s.db.pool.Exec(ctx, query, message, id)
+
+	return err
+}
+
+// A row ready to be created
+type userImportCandidate struct {
+	row  *UserImportRow
+	user *User
+	role string
+}
+
+// Creates the users in one transaction, each as a member of the organization with the role at
+// the same index, so either every user in the batch is saved or none is
+func (s *UserPostgresStore) CreateBatch(ctx context.Context, organizationID string, users []*User, roles []string) error {
+	tx, err := s.db.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO users (first_name, last_name, email, password, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	for i, user := range users {
+		row := tx.QueryRow(ctx, query, user.FirstName, user.LastName, user.Email, user.Password, user.CreatedAt, user.UpdatedAt)
+
+		if err := row.Scan(&user.ID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)`, organizationID, user.ID, roles[i], user.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reads the import's rows, keyed by column name. Headers are matched without regard to case.
+func readUserImportCSV(data []byte) ([]map[string]string, []int, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("file is empty")
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	columns := make([]string, len(header))
+
+	for i, name := range header {
+		for _, column := range userImportColumns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				columns[i] = column
+			}
+		}
+	}
+
+	for _, column := range userImportColumns {
+		if !slices.Contains(columns, column) {
+			return nil, nil, fmt.Errorf("missing column %s, expected %s", column, strings.Join(userImportColumns, ", "))
+		}
+	}
+
+	var rows []map[string]string
+	var lines []int
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		if len(rows) == maxUserImportRows {
+			return nil, nil, fmt.Errorf("file must not have more than %d rows", maxUserImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := map[string]string{}
+
+		for i, value := range record {
+			if i < len(columns) && columns[i] != "" {
+				row[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+
+	return rows, lines, nil
+}
+
+// Validates every row, rejecting duplicate emails within the file and emails already taken, then
+// creates the valid rows in batches and records them in the audit log. Emails are normalized before
+// they are checked or saved. Rows are reported in file order.
+func (s *UserService) importUsers(ctx context.Context, organizationID string, data []byte, dryRun bool) (*UserImportReport, error) {
+	records, lines, err := readUserImportCSV(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{DryRun: dryRun, Total: len(records), Rows: []*UserImportRow{}}
+	firstLines := map[string]int{}
+	var candidates []*userImportCandidate
+
+	for i, record := range records {
+		row := &UserImportRow{Line: lines[i], Email: record["email"]}
+		report.Rows = append(report.Rows, row)
+
+		user := &User{FirstName: record["firstName"], LastName: record["lastName"], Email: normalizeEmail(record["email"]), Password: record["password"]}
+		role := strings.ToLower(record["role"])
+
+		if err := validateUser(user); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+
+		if !slices.Contains(userImportRoles, role) {
+			row.Errors = append(row.Errors, fmt.Sprintf("role must be one of %s", strings.Join(userImportRoles, ", ")))
+		}
+
+		if len(row.Errors) > 0 {
+			row.Status = UserImportStatusInvalid
+			continue
+		}
+
+		if line, ok := firstLines[user.Email]; ok {
+			row.Status = UserImportStatusDuplicate
+			row.Errors = append(row.Errors, fmt.Sprintf("email is also on line %d", line))
+			continue
+		}
+
+		firstLines[user.Email] = row.Line
+
+		existingUser, err := s.userStore.GetByEmail(ctx, user.Email)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to check for existing user", "error", err)
+			return nil, ErrInternal
+		}
+
+		if existingUser != nil {
+			row.Status = UserImportStatusDuplicate
+			row.Errors = append(row.Errors, "user with this email already exists")
+			continue
+		}
+
+		row.Status = UserImportStatusValid
+		candidates = append(candidates, &userImportCandidate{row: row, user: user, role: role})
+	}
+
+	if !dryRun {
+		for batch := range slices.Chunk(candidates, userImportBatchSize) {
+			s.createImportBatch(ctx, organizationID, batch)
+		}
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case UserImportStatusCreated:
+			report.Created++
+		case UserImportStatusValid:
+		default:
+			report.Rejected++
+		}
+	}
+
+	if !dryRun {
+		s.auditService.Record(ctx, organizationID, AuditActionImport, "organization", organizationID, nil, map[string]int{"created": report.Created, "rejected": report.Rejected})
+	}
+
+	return report, nil
+}
+
+func (s *UserService) createImportBatch(ctx context.Context, organizationID string, batch []*userImportCandidate) {
+	fail := func(message string) {
+		for _, candidate := range batch {
+			candidate.row.Status = UserImportStatusFailed
+			candidate.row.Errors = append(candidate.row.Errors, message)
+		}
+	}
+
+	users := make([]*User, 0, len(batch))
+	roles := make([]string, 0, len(batch))
+
+	for _, candidate := range batch {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(candidate.user.Password), bcrypt.DefaultCost)
+
+		if err != nil {
+			slog.Error("failed to hash password", "error", err)
+			fail(ErrInternal.Error())
+			return
+		}
+
+		candidate.user.Password = string(hashedPassword)
+		candidate.user.CreatedAt = time.Now()
+		candidate.user.UpdatedAt = candidate.user.CreatedAt
+		users = append(users, candidate.user)
+		roles = append(roles, candidate.role)
+	}
+
+	if err := s.userStore.CreateBatch(ctx, organizationID, users, roles); err != nil {
+		// Another request may have taken an email since it was checked
+		slog.Error("failed to create users", "error", err)
+		fail("the batch of users holding this row could not be saved")
+		return
+	}
+
+	for _, candidate := range batch {
+		candidate.row.Status = UserImportStatusCreated
+		candidate.row.UserID = candidate.user.ID
+
+		s.auditService.Record(ctx, organizationID, AuditActionCreate, "user", candidate.user.ID, nil, candidate.user)
+	}
+}
+
+// Runs the import-users command, which imports users from a CSV file into an organization and
+// prints the report as JSON. There is no session, so audit entries have no actor and carry the
+// command's name as their user agent:
+//
+//	divinity import-users -organization <id> [-dry-run] users.csv
+func runUserImportCommand(ctx context.Context, args []string, userService *UserService, organizationStore OrganizationStore, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import-users", flag.ContinueOnError)
+	organizationID := flags.String("organization", "", "id of the organization the users join")
+	dryRun := flags.Bool("dry-run", false, "validate the file without creating any users")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *organizationID == "" || flags.NArg() != 1 {
+		return errors.New("usage: divinity import-users -organization <id> [-dry-run] <file.csv>")
+	}
+
+	organization, err := organizationStore.GetByID(ctx, *organizationID)
+
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	if organization == nil {
+		return fmt.Errorf("organization %s not found", *organizationID)
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithValue(ctx, requestMetadataContextKey{}, RequestMetadata{UserAgent: "divinity import-users"})
+	report, err := userService.importUsers(ctx, organization.ID, data, *dryRun)
+
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	if report.Rejected > 0 {
+		return fmt.Errorf("%d of %d rows were rejected", report.Rejected, report.Total)
+	}
+
+	return nil
+}
+
+type UserImportService struct {
+	importStore UserImportStore
+	userService *UserService
+	memberStore OrganizationMemberStore
+}
+
+func NewUserImportService(importStore UserImportStore, userService *UserService, memberStore OrganizationMemberStore) *UserImportService {
+	return &UserImportService{importStore: importStore, userService: userService, memberStore: memberStore}
+}
+
+// Reports what importing the CSV would do without creating any users. Only organization admins
+// may import users.
+func (s *UserImportService) Validate(ctx context.Context, organizationID string, data []byte) (*UserImportReport, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return s.userService.importUsers(ctx, organizationID, data, true)
+}
+
+// Queues the CSV to be imported into the organization by the worker, since every row's password
+// is hashed. A file that cannot be read is rejected straight away.
+func (s *UserImportService) Queue(ctx context.Context, organizationID string, data []byte) (*UserImport, error) {
+	member, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := readUserImportCSV(data); err != nil {
+		return nil, err
+	}
+
+	userImport := &UserImport{
+		OrganizationID:    organizationID,
+		RequestedByUserID: member.UserID,
+		Status:            UserImportPending,
+		CreatedAt:         time.Now(),
+	}
+
+	if err := s.importStore.Create(ctx, userImport, data); err != nil {
+		slog.Error("failed to create user import", "error", err)
+		return nil, ErrInternal
+	}
+
+	return userImport, nil
+}
+
+// Returns an import of the organization to its admins
+func (s *UserImportService) Get(ctx context.Context, organizationID string, id string) (*UserImport, error) {
+	if _, err := requireMembership(ctx, s.memberStore, organizationID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	userImport, err := s.importStore.GetByID(ctx, id)
+
+	if err != nil {
+		slog.Error("failed to get user import", "error", err)
+		return nil, ErrInternal
+	}
+
+	if userImport == nil || userImport.OrganizationID != organizationID {
+		return nil, notFound("user import")
+	}
+
+	return userImport, nil
+}
+
+// Runs the next pending import. Its audit entries name the admin who queued it as the actor.
+// Returns false when there was nothing to do.
+func (s *UserImportService) ProcessNext(ctx context.Context) (bool, error) {
+	userImport, data, err := s.importStore.ClaimPending(ctx)
+
+	if err != nil {
+		slog.Error("failed to claim user import", "error", err)
+		return false, ErrInternal
+	}
+
+	if userImport == nil {
+		return false, nil
+	}
+
+	importCtx := contextWithSession(ctx, &Session{UserID: userImport.RequestedByUserID, OrganizationID: userImport.OrganizationID})
+	report, err := s.userService.importUsers(importCtx, userImport.OrganizationID, data, false)
+
+	if err != nil {
+		slog.Error("failed to import users", "error", err, "importId", userImport.ID)
+		message := err.Error()
+
+		if errors.Is(err, ErrInternal) {
+			message = "failed to import users"
+		}
+
+		if err := s.importStore.Fail(ctx, userImport.ID, message); err != nil {
+			slog.Error("failed to mark user import as failed", "error", err, "importId", userImport.ID)
+		}
+
+		return true, nil
+	}
+
+	if err := s.importStore.Complete(ctx, userImport.ID, report, time.Now()); err != nil {
+		slog.Error("failed to complete user import", "error", err, "importId", userImport.ID)
+		return true, ErrInternal
+	}
+
+	return true, nil
+}
+
+// Processes imports until ctx is cancelled
+func (s *UserImportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(userImportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.ProcessNext(ctx)
+
+			if err != nil || !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type UserImportHandler struct {
+	userImportService *UserImportService
+}
+
+// Takes the CSV file as the request body and queues it, responding with the import. dryRun=true
+// instead validates the file straight away and responds with the report, or with 422 when any
+// row is rejected.
+func (h *UserImportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		var err error
+
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			writeError(w, errors.New("dryRun must be true or false"))
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUserImportSize)
+	data, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			writeError(w, fmt.Errorf("files must not exceed %d MB", maxUserImportSize>>20))
+			return
+		}
+
+		writeError(w, errors.New("invalid request body"))
+		return
+	}
+
+	if !dryRun {
+		userImport, err := h.userImportService.Queue(r.Context(), r.PathValue("id"), data)
+
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, userImport)
+		return
+	}
+
+	report, err := h.userImportService.Validate(r.Context(), r.PathValue("id"), data)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+
+	if report.Rejected > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, report)
+}
+
+func (h *UserImportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userImport, err := h.userImportService.Get(r.Context(), r.PathValue("id"), r.PathValue("importId"))
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userImport)
+}
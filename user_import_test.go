@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockUserImportStore struct {
+	CreateFunc       func(ctx context.Context, userImport *UserImport, data []byte) error
+	GetByIDFunc      func(ctx context.Context, id string) (*UserImport, error)
+	ClaimPendingFunc func(ctx context.Context) (*UserImport, []byte, error)
+	CompleteFunc     func(ctx context.Context, id string, report *UserImportReport, completedAt time.Time) error
+	FailFunc         func(ctx context.Context, id string, message string) error
+}
+
+func (m *MockUserImportStore) Create(ctx context.Context, userImport *UserImport, data []byte) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, userImport, data)
+	}
+
+	return nil
+}
+
+func (m *MockUserImportStore) GetByID(ctx context.Context, id string) (*UserImport, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockUserImportStore) ClaimPending(ctx context.Context) (*UserImport, []byte, error) {
+	if m.ClaimPendingFunc != nil {
+		return m.ClaimPendingFunc(ctx)
+	}
+
+	return nil, nil, nil
+}
+
+func (m *MockUserImportStore) Complete(ctx context.Context, id string, report *UserImportReport, completedAt time.Time) error {
+	if m.CompleteFunc != nil {
+		return m.CompleteFunc(ctx, id, report, completedAt)
+	}
+
+	return nil
+}
+
+func (m *MockUserImportStore) Fail(ctx context.Context, id string, message string) error {
+	if m.FailFunc != nil {
+		return m.FailFunc(ctx, id, message)
+	}
+
+	return nil
+}
+
+func userImportCSV(rows ...string) []byte {
+	return []byte(strings.Join(append([]string{"firstName,lastName,email,password,role"}, rows...), "\n"))
+}
+
+func TestUserImportService_Queue_ReturnsErrorForTeacher(t *testing.T) {
+	userImportService := NewUserImportService(&MockUserImportStore{}, NewUserService(&MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), orgMembers())
+
+	userImport, err := userImportService.Queue(sessionContext("teacher"), "org", userImportCSV("Ada,Lovelace,ada@example.com,secret,student"))
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, userImport)
+}
+
+func TestUserImportService_Queue_ReturnsErrorForMissingColumn(t *testing.T) {
+	userImportService := NewUserImportService(&MockUserImportStore{
+		CreateFunc: func(ctx context.Context, userImport *UserImport, data []byte) error {
+			t.Fatal("an unreadable file must not be queued")
+			return nil
+		},
+	}, NewUserService(&MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), orgMembers())
+
+	userImport, err := userImportService.Queue(sessionContext("admin"), "org", []byte("firstName,lastName,email,password\nAda,Lovelace,ada@example.com,secret"))
+
+	assert.EqualError(t, err, "missing column role, expected firstName, lastName, email, password, role")
+	assert.Nil(t, userImport)
+}
+
+func TestUserImportService_Queue_SavesPendingImportWithoutCreatingUsers(t *testing.T) {
+	var saved []byte
+	userImportService := NewUserImportService(&MockUserImportStore{
+		CreateFunc: func(ctx context.Context, userImport *UserImport, data []byte) error {
+			userImport.ID = "import"
+			saved = data
+			return nil
+		},
+	}, NewUserService(&MockUserStore{
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			t.Fatal("queueing must not create users")
+			return nil
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), orgMembers())
+	data := userImportCSV("Ada,Lovelace,ada@example.com,secret,student")
+
+	userImport, err := userImportService.Queue(sessionContext("admin"), "org", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &UserImport{ID: "import", OrganizationID: "org", RequestedByUserID: "admin", Status: UserImportPending, CreatedAt: userImport.CreatedAt}, userImport)
+	assert.Equal(t, data, saved)
+}
+
+func TestUserImportService_ProcessNext_ImportsAsRequesterAndCompletes(t *testing.T) {
+	var completed *UserImportReport
+	var entries []*AuditEntry
+	auditService := NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, entry *AuditEntry) error {
+			entries = append(entries, entry)
+			return nil
+		},
+	}, orgMembers())
+	userService := NewUserService(&MockUserStore{
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			users[0].ID = "ada"
+			return nil
+		},
+	}, orgMembers(), auditService)
+	userImportService := NewUserImportService(&MockUserImportStore{
+		ClaimPendingFunc: func(ctx context.Context) (*UserImport, []byte, error) {
+			return &UserImport{ID: "import", OrganizationID: "org", RequestedByUserID: "admin", Status: UserImportProcessing}, userImportCSV("Ada,Lovelace,ada@example.com,secret,student"), nil
+		},
+		CompleteFunc: func(ctx context.Context, id string, report *UserImportReport, completedAt time.Time) error {
+			assert.Equal(t, "import", id)
+			completed = report
+			return nil
+		},
+	}, userService, orgMembers())
+
+	processed, err := userImportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, 1, completed.Created)
+	assert.Equal(t, "ada", completed.Rows[0].UserID)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "admin", entries[0].ActorUserID)
+	assert.Equal(t, "organization.import", entries[1].Action)
+}
+
+func TestUserImportService_ProcessNext_FailsImportThatCannotRun(t *testing.T) {
+	var failedID, message string
+	userImportService := NewUserImportService(&MockUserImportStore{
+		ClaimPendingFunc: func(ctx context.Context) (*UserImport, []byte, error) {
+			return &UserImport{ID: "import", OrganizationID: "org", RequestedByUserID: "admin"}, userImportCSV("Ada,Lovelace,ada@example.com,secret,student"), nil
+		},
+		FailFunc: func(ctx context.Context, id string, msg string) error {
+			failedID, message = id, msg
+			return nil
+		},
+	}, NewUserService(&MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			return nil, errors.New("connection refused")
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), orgMembers())
+
+	processed, err := userImportService.ProcessNext(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, "import", failedID)
+	assert.Equal(t, "failed to import users", message)
+}
+
+func TestUserImportService_Get_ReturnsNotFoundForImportOfAnotherOrganization(t *testing.T) {
+	userImportService := NewUserImportService(&MockUserImportStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*UserImport, error) {
+			return &UserImport{ID: id, OrganizationID: "other-org"}, nil
+		},
+	}, NewUserService(&MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), orgMembers())
+
+	userImport, err := userImportService.Get(sessionContext("admin"), "org", "import")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, userImport)
+}
+
+func TestUserImportService_Validate_ReportsInvalidAndDuplicateRows(t *testing.T) {
+	userService := NewUserService(&MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			if email == "taken@example.com" {
+				return &User{ID: "existing", Email: email}, nil
+			}
+
+			return nil, nil
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	report, err := NewUserImportService(&MockUserImportStore{}, userService, orgMembers()).Validate(sessionContext("admin"), "org", userImportCSV(
+		"Ada,Lovelace,ada@example.com,secret,student",
+		",Hopper,grace@example.com,secret,teacher",
+		"Alan,Turing,not-an-email,secret,janitor",
+		"Ada,King,ADA@example.com,secret,student",
+		"Taken,User,taken@example.com,secret,student",
+	))
+
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 4, report.Rejected)
+	assert.Equal(t, []*UserImportRow{
+		{Line: 2, Email: "ada@example.com", Status: UserImportStatusValid},
+		{Line: 3, Email: "grace@example.com", Status: UserImportStatusInvalid, Errors: []string{"first name is required"}},
+		{Line: 4, Email: "not-an-email", Status: UserImportStatusInvalid, Errors: []string{"invalid email format", "role must be one of admin, teacher, student"}},
+		{Line: 5, Email: "ADA@example.com", Status: UserImportStatusDuplicate, Errors: []string{"email is also on line 2"}},
+		{Line: 6, Email: "taken@example.com", Status: UserImportStatusDuplicate, Errors: []string{"user with this email already exists"}},
+	}, report.Rows)
+}
+
+func TestUserImportService_Validate_CreatesNothing(t *testing.T) {
+	userService := NewUserService(&MockUserStore{
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			t.Fatal("dry run must not create users")
+			return nil
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	report, err := NewUserImportService(&MockUserImportStore{}, userService, orgMembers()).Validate(sessionContext("admin"), "org", userImportCSV("Ada,Lovelace,ada@example.com,secret,student"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, UserImportStatusValid, report.Rows[0].Status)
+}
+
+func TestUserService_ImportUsers_CreatesUsersInBatches(t *testing.T) {
+	var batches [][]string
+	userStore := &MockUserStore{
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			assert.Equal(t, "org", organizationID)
+
+			for i, user := range users {
+				assert.NotEqual(t, "secret", user.Password)
+				assert.Equal(t, RoleTeacher, roles[i])
+				user.ID = "user-" + user.Email
+			}
+
+			batches = append(batches, roles)
+			return nil
+		},
+	}
+	userService := NewUserService(userStore, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	rows := make([]string, userImportBatchSize+1)
+
+	for i := range rows {
+		rows[i] = fmt.Sprintf("Teacher,%d,teacher%d@example.com,secret,Teacher", i, i)
+	}
+
+	report, err := userService.importUsers(sessionContext("admin"), "org", userImportCSV(rows...), false)
+
+	assert.NoError(t, err)
+	assert.Len(t, batches, 2)
+	assert.Len(t, batches[0], userImportBatchSize)
+	assert.Len(t, batches[1], 1)
+	assert.Equal(t, userImportBatchSize+1, report.Created)
+	assert.Equal(t, 0, report.Rejected)
+	assert.Equal(t, &UserImportRow{Line: 2, Email: "teacher0@example.com", Status: UserImportStatusCreated, UserID: "user-teacher0@example.com"}, report.Rows[0])
+}
+
+func TestUserService_ImportUsers_MarksRowsOfFailedBatch(t *testing.T) {
+	userService := NewUserService(&MockUserStore{
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			return errors.New("duplicate key")
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	report, err := userService.importUsers(sessionContext("admin"), "org", userImportCSV("Ada,Lovelace,ada@example.com,secret,student"), false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, UserImportStatusFailed, report.Rows[0].Status)
+}
+
+func TestUserService_ImportUsers_ReturnsErrorForFailingToCheckForExistingUser(t *testing.T) {
+	userService := NewUserService(&MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			return nil, errors.New("connection refused")
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	report, err := userService.importUsers(sessionContext("admin"), "org", userImportCSV("Ada,Lovelace,ada@example.com,secret,student"), false)
+
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Nil(t, report)
+}
+
+func TestRunUserImportCommand_PrintsReportAndFailsOnRejectedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	assert.NoError(t, os.WriteFile(path, userImportCSV("Ada,Lovelace,ada@example.com,secret,student", "Alan,Turing,,secret,student"), 0o600))
+
+	organizationStore := &MockOrganizationStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Organization, error) {
+			return &Organization{ID: id}, nil
+		},
+	}
+	var stdout bytes.Buffer
+
+	err := runUserImportCommand(context.Background(), []string{"-organization", "org", "-dry-run", path}, NewUserService(&MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), organizationStore, &stdout)
+
+	assert.EqualError(t, err, "1 of 2 rows were rejected")
+	assert.Contains(t, stdout.String(), `"dryRun": true`)
+	assert.Contains(t, stdout.String(), `"email is required"`)
+}
+
+func TestRunUserImportCommand_ReturnsErrorForUnknownOrganization(t *testing.T) {
+	err := runUserImportCommand(context.Background(), []string{"-organization", "missing", "users.csv"}, NewUserService(&MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers())), &MockOrganizationStore{}, &bytes.Buffer{})
+
+	assert.EqualError(t, err, "organization missing not found")
+}
+
+func TestUserService_ImportUsers_NormalizesEmails(t *testing.T) {
+	var created *User
+	userService := NewUserService(&MockUserStore{
+		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+			assert.Equal(t, "ada@example.com", email)
+			return nil, nil
+		},
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			created = users[0]
+			return nil
+		},
+	}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	report, err := userService.importUsers(sessionContext("admin"), "org", userImportCSV(" Ada,Lovelace, Ada@Example.com ,secret,student"), false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, "ada@example.com", created.Email)
+}
+
+func TestRunUserImportCommand_RecordsAuditEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	assert.NoError(t, os.WriteFile(path, userImportCSV("Ada,Lovelace,ada@example.com,secret,student"), 0o600))
+
+	var entries []*AuditEntry
+	auditService := NewAuditService(&MockAuditStore{
+		CreateFunc: func(ctx context.Context, entry *AuditEntry) error {
+			entries = append(entries, entry)
+			return nil
+		},
+	}, orgMembers())
+	userService := NewUserService(&MockUserStore{
+		CreateBatchFunc: func(ctx context.Context, organizationID string, users []*User, roles []string) error {
+			users[0].ID = "ada"
+			return nil
+		},
+	}, orgMembers(), auditService)
+	organizationStore := &MockOrganizationStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*Organization, error) {
+			return &Organization{ID: id}, nil
+		},
+	}
+
+	err := runUserImportCommand(context.Background(), []string{"-organization", "org", path}, userService, organizationStore, &bytes.Buffer{})
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "user.create", entries[0].Action)
+	assert.Equal(t, "ada", entries[0].EntityID)
+	assert.Equal(t, "Ada", entries[0].Changes["firstName"].After)
+	assert.Equal(t, "organization.import", entries[1].Action)
+	assert.Empty(t, entries[0].ActorUserID)
+	assert.Equal(t, "divinity import-users", entries[1].UserAgent)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -92,11 +93,18 @@ func TestValidateUser_ReturnsErrorForInvalidEmailFormat(t *testing.T) {
 }
 
 type MockUserStore struct {
-	CreateFunc     func(ctx context.Context, user *User) error
-	GetByIDFunc    func(ctx context.Context, id string) (*User, error)
-	GetByEmailFunc func(ctx context.Context, email string) (*User, error)
-	UpdateFunc     func(ctx context.Context, user *User) error
-	DeleteFunc     func(ctx context.Context, id string) error
+	CreateFunc                          func(ctx context.Context, user *User) error
+	GetByIDFunc                         func(ctx context.Context, id string) (*User, error)
+	ListByIDsFunc                       func(ctx context.Context, ids []string) ([]*User, error)
+	GetByEmailFunc                      func(ctx context.Context, email string) (*User, error)
+	UpdateFunc                          func(ctx context.Context, user *User) error
+	DeleteFunc                          func(ctx context.Context, id string) error
+	GetDeletedByIDFunc                  func(ctx context.Context, id string) (*User, error)
+	RestoreFunc                         func(ctx context.Context, id string) error
+	PurgeDeletedFunc                    func(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ListExpiredWithEducationRecordsFunc func(ctx context.Context, deletedBefore time.Time) ([]*User, error)
+	PseudonymizeFunc                    func(ctx context.Context, user *User) error
+	CreateBatchFunc                     func(ctx context.Context, organizationID string, users []*User, roles []string) error
 }
 
 func (m *MockUserStore) Create(ctx context.Context, user *User) error {
@@ -115,6 +123,14 @@ func (m *MockUserStore) GetByID(ctx context.Context, id string) (*User, error) {
 	return nil, nil
 }
 
+func (m *MockUserStore) ListByIDs(ctx context.Context, ids []string) ([]*User, error) {
+	if m.ListByIDsFunc != nil {
+		return m.ListByIDsFunc(ctx, ids)
+	}
+
+	return nil, nil
+}
+
 func (m *MockUserStore) GetByEmail(ctx context.Context, email string) (*User, error) {
 	if m.GetByEmailFunc != nil {
 		return m.GetByEmailFunc(ctx, email)
@@ -139,12 +155,60 @@ func (m *MockUserStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *MockUserStore) GetDeletedByID(ctx context.Context, id string) (*User, error) {
+	if m.GetDeletedByIDFunc != nil {
+		return m.GetDeletedByIDFunc(ctx, id)
+	}
+
+	return nil, nil
+}
+
+func (m *MockUserStore) Restore(ctx context.Context, id string) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, id)
+	}
+
+	return nil
+}
+
+func (m *MockUserStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if m.PurgeDeletedFunc != nil {
+		return m.PurgeDeletedFunc(ctx, deletedBefore)
+	}
+
+	return 0, nil
+}
+
+func (m *MockUserStore) ListExpiredWithEducationRecords(ctx context.Context, deletedBefore time.Time) ([]*User, error) {
+	if m.ListExpiredWithEducationRecordsFunc != nil {
+		return m.ListExpiredWithEducationRecordsFunc(ctx, deletedBefore)
+	}
+
+	return nil, nil
+}
+
+func (m *MockUserStore) Pseudonymize(ctx context.Context, user *User) error {
+	if m.PseudonymizeFunc != nil {
+		return m.PseudonymizeFunc(ctx, user)
+	}
+
+	return nil
+}
+
+func (m *MockUserStore) CreateBatch(ctx context.Context, organizationID string, users []*User, roles []string) error {
+	if m.CreateBatchFunc != nil {
+		return m.CreateBatchFunc(ctx, organizationID, users, roles)
+	}
+
+	return nil
+}
+
 func TestUserService_Create_ReturnsErrorForFailingToCheckForExistingUser(t *testing.T) {
 	userService := NewUserService(&MockUserStore{
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user := &User{
 		FirstName: "John",
@@ -164,7 +228,7 @@ func TestUserService_Create_ReturnsErrorForExistingUserEmail(t *testing.T) {
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return &User{ID: "1", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user := &User{
 		FirstName: "John",
@@ -179,12 +243,34 @@ func TestUserService_Create_ReturnsErrorForExistingUserEmail(t *testing.T) {
 	assert.Equal(t, "user with this email already exists", err.Error())
 }
 
+func TestUserService_Create_NormalizesEmail(t *testing.T) {
+	var created *User
+	userService := NewUserService(&MockUserStore{
+		CreateFunc: func(ctx context.Context, user *User) error {
+			created = user
+			return nil
+		},
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
+
+	user := &User{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     " John.Doe@Example.com ",
+		Password:  "password",
+	}
+
+	err := userService.Create(context.Background(), user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "john.doe@example.com", created.Email)
+}
+
 func TestUserService_Create_ReturnsErrorForFailingToCreateUser(t *testing.T) {
 	userService := NewUserService(&MockUserStore{
 		CreateFunc: func(ctx context.Context, user *User) error {
 			return errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user := &User{
 		FirstName: "John",
@@ -204,7 +290,7 @@ func TestUserService_Create_ReturnsErrorForFailingToHashPassword(t *testing.T) {
 		CreateFunc: func(ctx context.Context, user *User) error {
 			return errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user := &User{
 		FirstName: "John",
@@ -224,7 +310,7 @@ func TestUserService_Create_ReturnsNoErrorForValidUser(t *testing.T) {
 		CreateFunc: func(ctx context.Context, user *User) error {
 			return nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user := &User{
 		FirstName: "John",
@@ -243,7 +329,7 @@ func TestUserService_GetByID_ReturnsErrorForFailingToGetUser(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user, err := userService.GetByID(context.Background(), "1")
 
@@ -257,7 +343,7 @@ func TestUserService_GetByID_ReturnsErrorForUserNotFound(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user, err := userService.GetByID(context.Background(), "1")
 
@@ -271,7 +357,7 @@ func TestUserService_GetByID_ReturnsUserForValidID(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user, err := userService.GetByID(context.Background(), "1")
 
@@ -287,7 +373,7 @@ func TestUserService_GetByEmail_ReturnsErrorForFailingToGetUser(t *testing.T) {
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user, err := userService.GetByEmail(context.Background(), "john.doe@example.com")
 
@@ -301,7 +387,7 @@ func TestUserService_GetByEmail_ReturnsErrorForUserNotFound(t *testing.T) {
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return nil, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user, err := userService.GetByEmail(context.Background(), "john.doe@example.com")
 
@@ -315,7 +401,7 @@ func TestUserService_GetByEmail_ReturnsUserForValidEmail(t *testing.T) {
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	user, err := userService.GetByEmail(context.Background(), "john.doe@example.com")
 
@@ -331,7 +417,7 @@ func TestUserService_Update_ReturnsErrorForFailingToCheckForExistingUser(t *test
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Update(context.Background(), "1", &UpdateUserRequest{
 		FirstName: "Jane",
@@ -350,7 +436,7 @@ func TestUserService_Update_ReturnsErrorForFailingToUpdateUser(t *testing.T) {
 		UpdateFunc: func(ctx context.Context, user *User) error {
 			return errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Update(context.Background(), "1", &UpdateUserRequest{
 		FirstName: "Jane",
@@ -369,7 +455,7 @@ func TestUserService_Update_ReturnsValidUserForValidRequest(t *testing.T) {
 		UpdateFunc: func(ctx context.Context, user *User) error {
 			return nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Update(context.Background(), "1", &UpdateUserRequest{
 		FirstName: "Jane",
@@ -384,7 +470,7 @@ func TestUserService_UpdatePassword_ReturnsErrorForFailingToGetUser(t *testing.T
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdatePassword(context.Background(), "1", &UpdatePasswordRequest{
 		Password: "password",
@@ -399,7 +485,7 @@ func TestUserService_UpdatePassword_ReturnsErrorForUserNotFound(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdatePassword(context.Background(), "1", &UpdatePasswordRequest{
 		Password: "password",
@@ -414,7 +500,7 @@ func TestUserService_UpdatePassword_ReturnsErrorForEmptyPassword(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdatePassword(context.Background(), "1", &UpdatePasswordRequest{
 		Password: "",
@@ -432,7 +518,7 @@ func TestUserService_UpdatePassword_ReturnsNoErrorForValidRequest(t *testing.T)
 		UpdateFunc: func(ctx context.Context, user *User) error {
 			return nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdatePassword(context.Background(), "1", &UpdatePasswordRequest{
 		Password: "password",
@@ -449,7 +535,7 @@ func TestUserService_UpdatePassword_ReturnsErrorForFailingToUpdateUser(t *testin
 		UpdateFunc: func(ctx context.Context, user *User) error {
 			return errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdatePassword(context.Background(), "1", &UpdatePasswordRequest{
 		Password: "password",
@@ -464,7 +550,7 @@ func TestUserService_UpdateEmail_ReturnsErrorForFailingToGetUser(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "john.doe@example.com",
@@ -479,7 +565,7 @@ func TestUserService_UpdateEmail_ReturnsErrorForUserNotFound(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "john.doe@example.com",
@@ -494,7 +580,7 @@ func TestUserService_UpdateEmail_ReturnsErrorForEmptyEmail(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "",
@@ -512,7 +598,7 @@ func TestUserService_UpdateEmail_ReturnsErrorForFailingToCheckForExistingUser(t
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "john.doe@example.com",
@@ -530,7 +616,7 @@ func TestUserService_UpdateEmail_ReturnsErrorForExistingEmail(t *testing.T) {
 		GetByEmailFunc: func(ctx context.Context, email string) (*User, error) {
 			return &User{ID: "2", FirstName: "Jane", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "john.doe@example.com",
@@ -548,7 +634,7 @@ func TestUserService_UpdateEmail_ReturnsErrorForFailingToUpdateUser(t *testing.T
 		UpdateFunc: func(ctx context.Context, user *User) error {
 			return errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "john.doe@example.com",
@@ -563,7 +649,7 @@ func TestUserService_UpdateEmail_ReturnsNoErrorForValidRequest(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.UpdateEmail(context.Background(), "1", &UpdateEmailRequest{
 		Email: "john.doe@example.com",
@@ -577,7 +663,7 @@ func TestUserService_Delete_ReturnsErrorForFailingToGetUser(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Delete(context.Background(), "1")
 
@@ -590,7 +676,7 @@ func TestUserService_Delete_ReturnsErrorForUserNotFound(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return nil, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Delete(context.Background(), "1")
 
@@ -606,7 +692,7 @@ func TestUserService_Delete_ReturnsErrorForFailingToDeleteUser(t *testing.T) {
 		DeleteFunc: func(ctx context.Context, id string) error {
 			return errors.New("random error")
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Delete(context.Background(), "1")
 
@@ -619,9 +705,116 @@ func TestUserService_Delete_ReturnsNoErrorForValidRequest(t *testing.T) {
 		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
 			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
 		},
-	})
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
 
 	err := userService.Delete(context.Background(), "1")
 
 	assert.NoError(t, err)
 }
+
+func TestUserService_UpdatePassword_ReturnsErrorWhileImpersonating(t *testing.T) {
+	userService := NewUserService(&MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
+		},
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
+
+	ctx := contextWithSession(context.Background(), &Session{UserID: "1", ImpersonatorUserID: "2"})
+
+	err := userService.UpdatePassword(ctx, "1", &UpdatePasswordRequest{
+		Password: "password",
+	})
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Equal(t, "action not allowed while impersonating a user", err.Error())
+}
+
+func TestUserService_Delete_ReturnsErrorWhileImpersonating(t *testing.T) {
+	userService := NewUserService(&MockUserStore{
+		GetByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			return &User{ID: "1", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}, nil
+		},
+	}, &MockOrganizationMemberStore{}, NewAuditService(&MockAuditStore{}, &MockOrganizationMemberStore{}))
+
+	ctx := contextWithSession(context.Background(), &Session{UserID: "1", ImpersonatorUserID: "2"})
+
+	err := userService.Delete(ctx, "1")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func deletedUser() *MockUserStore {
+	return &MockUserStore{
+		GetDeletedByIDFunc: func(ctx context.Context, id string) (*User, error) {
+			deletedAt := time.Now()
+			return &User{ID: id, FirstName: "John", LastName: "Doe", Email: "john.doe@example.com", DeletedAt: &deletedAt}, nil
+		},
+	}
+}
+
+func TestUserService_Restore_ReturnsErrorForUserNotFound(t *testing.T) {
+	userService := NewUserService(&MockUserStore{}, orgMembers(), NewAuditService(&MockAuditStore{}, orgMembers()))
+
+	user, err := userService.Restore(sessionContext("admin"), "teacher")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "deleted user not found", err.Error())
+	assert.Nil(t, user)
+}
+
+func TestUserService_Restore_ReturnsErrorForNonAdmin(t *testing.T) {
+	members := orgMembers()
+	members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleTeacher}}, nil
+	}
+
+	userService := NewUserService(deletedUser(), members, NewAuditService(&MockAuditStore{}, members))
+
+	user, err := userService.Restore(sessionContext("teacher"), "student")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, user)
+}
+
+func TestUserService_Restore_ReturnsErrorForTakenEmail(t *testing.T) {
+	members := orgMembers()
+	members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleTeacher}}, nil
+	}
+
+	store := deletedUser()
+	store.GetByEmailFunc = func(ctx context.Context, email string) (*User, error) {
+		return &User{ID: "2", Email: email}, nil
+	}
+
+	userService := NewUserService(store, members, NewAuditService(&MockAuditStore{}, members))
+
+	user, err := userService.Restore(sessionContext("admin"), "teacher")
+
+	assert.Error(t, err)
+	assert.Equal(t, "user with this email already exists", err.Error())
+	assert.Nil(t, user)
+}
+
+func TestUserService_Restore_ReturnsUserForAdmin(t *testing.T) {
+	restored := false
+
+	members := orgMembers()
+	members.ListByUserFunc = func(ctx context.Context, userID string) ([]*OrganizationMember, error) {
+		return []*OrganizationMember{{OrganizationID: "org", UserID: userID, Role: RoleTeacher}}, nil
+	}
+
+	store := deletedUser()
+	store.RestoreFunc = func(ctx context.Context, id string) error {
+		restored = true
+		return nil
+	}
+
+	userService := NewUserService(store, members, NewAuditService(&MockAuditStore{}, members))
+
+	user, err := userService.Restore(sessionContext("admin"), "teacher")
+
+	assert.NoError(t, err)
+	assert.True(t, restored)
+	assert.Nil(t, user.DeletedAt)
+}